@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var (
+	auditTarget string
+	auditAction string
+	auditSince  time.Duration
+	auditLimit  uint32
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "List the changes done to the authd users and groups",
+	Long: `List the changes done to the authd users and groups, from the oldest to the newest: who requested the change,
+what was changed and when.
+
+The audit log is append-only. Changes applied on login are recorded with the "login" actor.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		req := &authd.AuditEventsRequest{
+			Target: auditTarget,
+			Action: auditAction,
+			Limit:  auditLimit,
+		}
+		if auditSince > 0 {
+			req.Since = time.Now().Add(-auditSince).Unix()
+		}
+
+		resp, err := c.ListAuditEvents(context.Background(), req)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tACTOR\tACTION\tTARGET\tDETAILS")
+		for _, e := range resp.GetEvents() {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", time.Unix(e.GetTime(), 0).Format(time.RFC3339), e.GetActor(), e.GetAction(), e.GetTarget(), e.GetDetails())
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditTarget, "target", "", "only list the changes of this user or group")
+	auditCmd.Flags().StringVar(&auditAction, "action", "", "only list the changes of this action, for example \"user-added\"")
+	auditCmd.Flags().DurationVar(&auditSince, "since", 0, "only list the changes done during this duration, for example \"24h\"")
+	auditCmd.Flags().Uint32Var(&auditLimit, "limit", 0, "only list this number of most recent changes")
+}
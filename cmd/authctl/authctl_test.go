@@ -0,0 +1,191 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+	"golang.org/x/sys/unix"
+)
+
+var daemonPath, authctlPath string
+
+// variableOutput matches the parts of the output of authctl which depend on the run, like the size of the database.
+var variableOutput = regexp.MustCompile(`(?m)\d+( bytes)|(time: ).+$`)
+
+func TestAuthctl(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args []string
+		db   string
+
+		currentUserNotRoot bool
+		noDaemon           bool
+		needsKeyring       bool
+
+		wantExitCode int
+	}{
+		"Print_help": {args: []string{"--help"}},
+
+		"List_audit_log":                        {args: []string{"audit"}},
+		"List_audit_log_of_target":              {args: []string{"audit", "--target", "user1"}},
+		"List_audit_log_of_action":              {args: []string{"audit", "--action", "user-added"}},
+		"List_most_recent_entries_of_audit_log": {args: []string{"audit", "--limit", "2"}},
+		"List_nothing_if_audit_log_is_older":    {args: []string{"audit", "--since", "24h"}},
+
+		"List_ID_collisions":          {args: []string{"collisions"}, db: "colliding_ids"},
+		"List_no_ID_collisions":       {args: []string{"collisions"}},
+		"List_ID_translations":        {args: []string{"translations"}},
+		"List_no_ID_translations":     {args: []string{"translations"}, db: "colliding_ids"},
+		"Show_local_group_changes":    {args: []string{"local-groups"}},
+		"Set_local_group_dry_run":     {args: []string{"local-groups", "--dry-run"}},
+		"Enforce_local_group_changes": {args: []string{"local-groups", "--dry-run=false"}},
+
+		"Show_log_level":                 {args: []string{"log-level"}},
+		"Set_log_level":                  {args: []string{"log-level", "debug"}},
+		"Print_debug_logs_of_users":      {args: []string{"log-level", "--debug-user", "user1", "--debug-user", "user2"}},
+		"Print_debug_logs_of_brokers":    {args: []string{"log-level", "--debug-broker", "local"}},
+		"Clear_debug_logs_and_set_level": {args: []string{"log-level", "warn", "--clear-debug"}},
+		"Check_health_of_all_services":   {args: []string{"health"}},
+		"Check_health_of_some_services":  {args: []string{"health", "nss", "user"}},
+		"Print_metrics":                  {args: []string{"metrics"}},
+		"Run_maintenance":                {args: []string{"maintenance"}},
+		"Print_no_device_token_if_none":  {args: []string{"device-token", "broker-id"}, noDaemon: true, needsKeyring: true, wantExitCode: 1},
+		"List_groups_of_member":          {args: []string{"group", "list", "--member", "user1"}},
+
+		// Usage errors
+		"Error_on_unknown_command":                   {args: []string{"unknown"}, wantExitCode: 2},
+		"Error_on_unknown_subcommand":                {args: []string{"user", "unknown"}, wantExitCode: 2},
+		"Error_on_unknown_flag":                      {args: []string{"audit", "--unknown"}, wantExitCode: 2},
+		"Error_when_since_is_not_a_duration":         {args: []string{"audit", "--since", "yesterday"}, wantExitCode: 2},
+		"Error_when_limit_is_negative":               {args: []string{"audit", "--limit", "-1"}, wantExitCode: 2},
+		"Error_when_health_service_is_unknown":       {args: []string{"health", "unknown"}, wantExitCode: 2},
+		"Error_when_log_level_is_given_twice":        {args: []string{"log-level", "debug", "info"}, wantExitCode: 2},
+		"Error_when_device_token_has_no_broker":      {args: []string{"device-token"}, wantExitCode: 2},
+		"Error_when_local_groups_dry_run_is_invalid": {args: []string{"local-groups", "--dry-run=maybe"}, wantExitCode: 2},
+		"Error_when_unlock_host_has_no_host":         {args: []string{"unlock-host"}, wantExitCode: 2},
+
+		// Errors of the daemon
+		"Error_when_log_level_is_invalid":             {args: []string{"log-level", "verbose"}, wantExitCode: 1},
+		"Error_when_debug_broker_does_not_exist":      {args: []string{"log-level", "--debug-broker", "unknown"}, wantExitCode: 1},
+		"Error_when_setting_log_level_as_non_root":    {args: []string{"log-level", "debug"}, currentUserNotRoot: true, wantExitCode: 1},
+		"Error_when_running_maintenance_as_non_root":  {args: []string{"maintenance"}, currentUserNotRoot: true, wantExitCode: 1},
+		"Error_when_daemon_is_not_running":            {args: []string{"metrics"}, noDaemon: true, wantExitCode: 1},
+		"Error_when_health_is_checked_without_daemon": {args: []string{"health"}, noDaemon: true, wantExitCode: 1},
+		"Error_when_remote_host_was_not_locked_out":   {args: []string{"unlock-host", "192.0.2.1"}, wantExitCode: 1},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.needsKeyring {
+				if _, err := unix.KeyctlGetKeyringID(unix.KEY_SPEC_PROCESS_KEYRING, true); err != nil {
+					t.Skipf("Kernel keyrings can't be used: %v", err)
+				}
+			}
+
+			if tc.db == "" {
+				tc.db = "multiple_users_and_groups"
+			}
+
+			socketPath := "/nonexistent/authd.socket"
+			if !tc.noDaemon {
+				env := localgroupstestutils.AuthdIntegrationTestsEnvWithGroupMock(t, filepath.Join(t.TempDir(), "group"),
+					filepath.Join("testdata", "empty.group"))
+				if !tc.currentUserNotRoot {
+					env = append(env, "AUTHD_INTEGRATIONTESTS_CURRENT_USER_AS_ROOT=1")
+				}
+
+				var stopped chan struct{}
+				ctx, cancel := context.WithCancel(context.Background())
+				socketPath, stopped = testutils.RunDaemon(ctx, t, daemonPath,
+					testutils.WithPreviousDBState(tc.db),
+					testutils.WithEnvironment(env...),
+				)
+				t.Cleanup(func() {
+					cancel()
+					<-stopped
+				})
+			}
+
+			got, exitCode := runAuthctl(t, socketPath, tc.args...)
+			require.Equal(t, tc.wantExitCode, exitCode, "Unexpected exit code, output:\n%s", got)
+
+			golden.CheckOrUpdate(t, variableOutput.ReplaceAllString(got, "${2}<VARIABLE>${1}"))
+		})
+	}
+}
+
+// runAuthctl runs authctl with the given arguments against the daemon listening on socketPath, and returns its
+// combined output and its exit code.
+func runAuthctl(t *testing.T, socketPath string, args ...string) (out string, exitCode int) {
+	t.Helper()
+
+	// #nosec:G204 - we control the command arguments in tests
+	cmd := exec.Command(authctlPath, args...)
+	cmd.Env = testutils.AppendCovEnv(append(os.Environ(),
+		"AUTHD_SOCKET="+socketPath,
+		// The times are printed in the local time zone.
+		"TZ=UTC",
+	))
+
+	var b bytes.Buffer
+	cmd.Stdout = &b
+	cmd.Stderr = &b
+
+	// We are only interested in the output and the exit code of the command, so we can ignore the error.
+	_ = cmd.Run()
+
+	return b.String(), cmd.ProcessState.ExitCode()
+}
+
+// buildAuthctl builds the authctl executable in dir and returns its path.
+func buildAuthctl(dir string) (string, error) {
+	execPath := filepath.Join(dir, "authctl")
+
+	cmd := exec.Command("go", "build")
+	cmd.Dir = testutils.ProjectRoot()
+	if testutils.CoverDirForTests() != "" {
+		// -cover is a "positional flag", so it needs to come right after the "build" command.
+		cmd.Args = append(cmd.Args, "-cover")
+	}
+	if testutils.IsRace() {
+		cmd.Args = append(cmd.Args, "-race")
+	}
+	cmd.Args = append(cmd.Args, "-o", execPath, "./cmd/authctl")
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to build authctl(%v): %s", err, out)
+	}
+	return execPath, nil
+}
+
+func TestMain(m *testing.M) {
+	execPath, cleanup, err := testutils.BuildDaemon("-tags=withexamplebroker,integrationtests")
+	if err != nil {
+		log.Printf("Setup: failed to build daemon: %v", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+	daemonPath = execPath
+
+	authctlPath, err = buildAuthctl(filepath.Dir(daemonPath))
+	if err != nil {
+		log.Printf("Setup: %v", err)
+		cleanup()
+		os.Exit(1)
+	}
+
+	m.Run()
+}
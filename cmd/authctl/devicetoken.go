@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/internal/users/devicetokens"
+)
+
+var deviceTokenCmd = &cobra.Command{
+	Use:   "device-token BROKER_ID",
+	Short: "Print the device token the broker issued to the current user",
+	Long: `Print the device token the broker issued to the current user at their last login on this machine, for the
+tools of their sessions to authenticate the device to the provider.
+
+The token is read from the persistent kernel keyring of the user, where authd stores it when the keyring can be used.
+The command fails if there is no token, or if it expired.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token, err := devicetokens.Lookup(args[0])
+		if err != nil {
+			return err
+		}
+		defer token.Wipe()
+		if token.Len() == 0 {
+			return errors.New("no device token was issued by this broker, or it expired")
+		}
+
+		// The token is written as is, as fmt would copy it to its buffers.
+		if _, err := os.Stdout.Write(token.Bytes()); err != nil {
+			return err
+		}
+		fmt.Println()
+		return nil
+	},
+}
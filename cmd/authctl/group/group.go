@@ -0,0 +1,20 @@
+// Package group contains the authctl commands to manage authd groups.
+package group
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// GroupCmd is a command to perform group-related operations.
+var GroupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Commands related to groups",
+	Args:  cobra.NoArgs,
+	RunE:  func(cmd *cobra.Command, args []string) error { return cmd.Usage() },
+}
+
+func init() {
+	GroupCmd.AddCommand(remapCmd)
+	GroupCmd.AddCommand(listCmd)
+	GroupCmd.AddCommand(reconcileCmd)
+}
@@ -0,0 +1,70 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+// listPageSize is the number of groups requested at once to the daemon.
+const listPageSize = 500
+
+var (
+	listMember string
+	listMinGID uint32
+	listMaxGID uint32
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the authd groups",
+	Long: `List the authd groups, sorted by GID.
+
+The flags select the groups to list. Without any flag, all the groups are listed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		req := &authd.ListGroupsRequest{
+			Member:   listMember,
+			MinGid:   listMinGID,
+			MaxGid:   listMaxGID,
+			PageSize: listPageSize,
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tGID\tMEMBERS")
+		for {
+			resp, err := c.ListGroups(context.Background(), req)
+			if err != nil {
+				return err
+			}
+
+			for _, g := range resp.GetGroups() {
+				fmt.Fprintf(w, "%s\t%d\t%s\n", g.GetName(), g.GetGid(), strings.Join(g.GetMembers(), ","))
+			}
+
+			if resp.GetNextPageToken() == "" {
+				break
+			}
+			req.PageToken = resp.GetNextPageToken()
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	listCmd.Flags().StringVar(&listMember, "member", "", "only list the groups having this user as member")
+	listCmd.Flags().Uint32Var(&listMinGID, "min-gid", 0, "only list the groups whose GID is greater than or equal to this one")
+	listCmd.Flags().Uint32Var(&listMaxGID, "max-gid", 0, "only list the groups whose GID is less than or equal to this one")
+}
@@ -0,0 +1,69 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var reconcileRepair bool
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Compare the memberships in local groups granted by authd with the local group file",
+	Long: `Report the differences between the memberships in local groups which authd granted to its users, according to its
+database, and the local group file, for example because it was edited manually or because one of its edits failed:
+  not-member      the user is not a member of a local group authd added it to
+  missing-group   the local group authd added the user to doesn't exist
+  not-removed     the user is still a member of a local group whose membership expired
+  extra-member    the user is a member of a local group authd manages, but authd didn't add it to the group
+
+With --repair, the users are added back to the local groups they are missing from, the missing groups are created if
+the local_groups_missing option allows it, and the users are removed from the groups whose membership expired. The
+extra memberships are only reported, as an administrator may have granted them. In dry run, the changes are only
+logged.`,
+	Example: `  authctl group reconcile
+  authctl group reconcile --repair`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		resp, err := c.ReconcileLocalGroups(context.Background(), &authd.ReconcileLocalGroupsRequest{Repair: reconcileRepair})
+		if err != nil {
+			return err
+		}
+
+		if len(resp.GetDrift()) == 0 {
+			fmt.Println("The local groups match the memberships granted by authd")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		if reconcileRepair {
+			fmt.Fprintln(w, "USER\tGROUP\tDRIFT\tREPAIRED")
+		} else {
+			fmt.Fprintln(w, "USER\tGROUP\tDRIFT")
+		}
+		for _, d := range resp.GetDrift() {
+			if reconcileRepair {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", d.GetUser(), d.GetGroup(), d.GetKind(), d.GetRepaired())
+			} else {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", d.GetUser(), d.GetGroup(), d.GetKind())
+			}
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	reconcileCmd.Flags().BoolVar(&reconcileRepair, "repair", false, "add the users back to the local groups they are missing from and revoke the expired memberships")
+}
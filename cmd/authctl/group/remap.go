@@ -0,0 +1,49 @@
+package group
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var (
+	remapGID       uint32
+	remapChownHome bool
+)
+
+var remapCmd = &cobra.Command{
+	Use:   "remap <name>",
+	Short: "Change the GID of an authd group",
+	Long: `Change the GID of an authd group, for example to fix a collision with a local group.
+
+If no GID is given, a free one is generated in the configured range. Users having the group as primary group are
+updated accordingly. The remapping is recorded and can be listed with "authctl translations".`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		t, err := c.RemapGroupID(context.Background(), &authd.RemapIDRequest{
+			Name:      args[0],
+			NewId:     remapGID,
+			ChownHome: remapChownHome,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("GID of group %q changed from %d to %d\n", t.GetName(), t.GetOldId(), t.GetNewId())
+		return nil
+	},
+}
+
+func init() {
+	remapCmd.Flags().Uint32Var(&remapGID, "gid", 0, "new GID of the group (generated if not set)")
+	remapCmd.Flags().BoolVar(&remapChownHome, "chown-home", false, "change the group of the files in the home directories of its members to the new GID")
+}
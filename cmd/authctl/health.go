@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/consts"
+	"github.com/ubuntu/authd/internal/proto/authd"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthServices are the gRPC names of the services whose health can be checked, by their name on the command line.
+var healthServices = map[string]string{
+	"authd": consts.ServiceName,
+	"nss":   authd.NSS_ServiceDesc.ServiceName,
+	"pam":   authd.PAM_ServiceDesc.ServiceName,
+	"user":  authd.UserService_ServiceDesc.ServiceName,
+}
+
+var healthCmd = &cobra.Command{
+	Use:   "health [SERVICE...]",
+	Short: "Check the health of the authd daemon and its services",
+	Long: `Check the health of the authd daemon and of its services: "nss", "pam" and "user". The global health of the
+daemon is reported as "authd". All of them are checked if none is given.
+
+The command fails if any of the checked services is not serving, so that it can be used as a readiness probe.`,
+	ValidArgs: []string{"authd", "nss", "pam", "user"},
+	Args:      cobra.OnlyValidArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			args = []string{"authd", "nss", "pam", "user"}
+		}
+
+		c, closeConn, err := client.NewHealthClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		var unhealthy []string
+		for _, name := range args {
+			state := healthpb.HealthCheckResponse_SERVICE_UNKNOWN.String()
+			r, err := c.Check(context.Background(), &healthpb.HealthCheckRequest{Service: healthServices[name]})
+			if err != nil && status.Code(err) != codes.NotFound {
+				return err
+			}
+			if err == nil {
+				state = r.GetStatus().String()
+			}
+
+			fmt.Printf("%s: %s\n", name, state)
+			if state != healthpb.HealthCheckResponse_SERVING.String() && !slices.Contains(unhealthy, name) {
+				unhealthy = append(unhealthy, name)
+			}
+		}
+
+		if len(unhealthy) > 0 {
+			return fmt.Errorf("not serving: %s", strings.Join(unhealthy, ", "))
+		}
+		return nil
+	},
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var collisionsCmd = &cobra.Command{
+	Use:   "collisions",
+	Short: "List UIDs and GIDs used by more than one user or group",
+	Long: `List the UIDs and GIDs of authd users and groups which are also used by other authd entries or by entries of
+the local passwd and group files.
+
+Collisions can be fixed with "authctl user remap" and "authctl group remap".`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		resp, err := c.ListIDCollisions(context.Background(), &authd.Empty{})
+		if err != nil {
+			return err
+		}
+
+		if len(resp.GetCollisions()) == 0 {
+			fmt.Println("No collisions found.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tID\tAUTHD ENTRIES\tLOCAL ENTRIES")
+		for _, c := range resp.GetCollisions() {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", c.GetKind(), c.GetId(), strings.Join(c.GetAuthdNames(), ","), strings.Join(c.GetLocalNames(), ","))
+		}
+		return w.Flush()
+	},
+}
+
+var translationsCmd = &cobra.Command{
+	Use:   "translations",
+	Short: "List the UID and GID remappings done so far",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		resp, err := c.ListIDTranslations(context.Background(), &authd.Empty{})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tKIND\tNAME\tOLD ID\tNEW ID")
+		for _, t := range resp.GetTranslations() {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", time.Unix(t.GetTime(), 0).Format(time.RFC3339), t.GetKind(), t.GetName(), t.GetOldId(), t.GetNewId())
+		}
+		return w.Flush()
+	},
+}
@@ -0,0 +1,57 @@
+// Package client provides the connection to the authd daemon used by the authctl commands.
+package client
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ubuntu/authd/internal/consts"
+	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/services/apiversion"
+	"github.com/ubuntu/authd/internal/services/errmessages"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// socketPathEnv is the environment variable which can be used to override the path to the authd socket.
+const socketPathEnv = "AUTHD_SOCKET"
+
+// NewUserServiceClient returns a new client for the user service of the authd daemon.
+// The returned function must be called to close the connection.
+func NewUserServiceClient() (client authd.UserServiceClient, closeConn func(), err error) {
+	conn, err := newConnection()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return authd.NewUserServiceClient(conn), func() { _ = conn.Close() }, nil
+}
+
+// NewHealthClient returns a new client for the health checking service of the authd daemon.
+// The returned function must be called to close the connection.
+func NewHealthClient() (client healthpb.HealthClient, closeConn func(), err error) {
+	conn, err := newConnection()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return healthpb.NewHealthClient(conn), func() { _ = conn.Close() }, nil
+}
+
+// newConnection creates a new connection to the authd socket.
+func newConnection() (*grpc.ClientConn, error) {
+	socketPath := consts.DefaultSocketPath
+	if p := os.Getenv(socketPathEnv); p != "" {
+		socketPath = p
+	}
+
+	conn, err := grpc.NewClient("unix://"+socketPath,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(errmessages.FormatErrorMessage, apiversion.ClientInterceptor))
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to authd: %v", err)
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var localGroupsDryRun bool
+
+var localGroupsCmd = &cobra.Command{
+	Use:   "local-groups",
+	Short: "Show or change whether the daemon changes the local groups",
+	Long: `Show or change whether the running daemon changes the local groups of the users, or only logs the changes it
+would make, without restarting it.
+
+The dry run allows to validate new local group rules before enforcing them. It's reset to the local_groups_dry_run
+option when the configuration of the daemon is reloaded.`,
+	Example: `  authctl local-groups --dry-run
+  authctl local-groups --dry-run=false`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		var current *authd.LocalGroupsDryRun
+		if cmd.Flags().Changed("dry-run") {
+			current, err = c.SetLocalGroupsDryRun(context.Background(), &authd.LocalGroupsDryRun{Enabled: localGroupsDryRun})
+		} else {
+			current, err = c.GetLocalGroupsDryRun(context.Background(), &authd.Empty{})
+		}
+		if err != nil {
+			return err
+		}
+
+		if current.GetEnabled() {
+			fmt.Println("Local group changes: dry run, only logged")
+		} else {
+			fmt.Println("Local group changes: enforced")
+		}
+		return nil
+	},
+}
+
+func init() {
+	localGroupsCmd.Flags().BoolVar(&localGroupsDryRun, "dry-run", false, "only log the changes of the local groups, or make them again with --dry-run=false")
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var (
+	logLevelDebugUsers   []string
+	logLevelDebugBrokers []string
+	logLevelClearDebug   bool
+)
+
+var logLevelCmd = &cobra.Command{
+	Use:   "log-level [debug|info|warn|error]",
+	Short: "Show or change the level of the logs of the daemon",
+	Long: `Show or change the level of the logs of the running daemon, without restarting it.
+
+The debug logs about some users or the sessions of some brokers can be printed whatever the level, to investigate an
+issue without flooding the logs. The level is reset to the configured verbosity when the configuration of the daemon
+is reloaded.`,
+	Example: `  authctl log-level debug
+  authctl log-level --debug-user alice --debug-broker "Microsoft Entra ID"
+  authctl log-level info --clear-debug`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"debug", "info", "warn", "error"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		current, err := c.GetLogLevel(context.Background(), &authd.Empty{})
+		if err != nil {
+			return err
+		}
+
+		changed := len(args) > 0 || logLevelClearDebug || len(logLevelDebugUsers) > 0 || len(logLevelDebugBrokers) > 0
+		if changed {
+			// The parts which are not specified are kept.
+			req := &authd.LogLevel{
+				DebugUsers:   current.GetDebugUsers(),
+				DebugBrokers: current.GetDebugBrokers(),
+			}
+			if len(args) > 0 {
+				req.Level = args[0]
+			}
+			if logLevelClearDebug {
+				req.DebugUsers, req.DebugBrokers = nil, nil
+			}
+			req.DebugUsers = append(req.DebugUsers, logLevelDebugUsers...)
+			req.DebugBrokers = append(req.DebugBrokers, logLevelDebugBrokers...)
+
+			current, err = c.SetLogLevel(context.Background(), req)
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("Level: %s\n", current.GetLevel())
+		fmt.Printf("Debug logs of users: %s\n", strings.Join(current.GetDebugUsers(), ", "))
+		fmt.Printf("Debug logs of brokers: %s\n", strings.Join(current.GetDebugBrokers(), ", "))
+		return nil
+	},
+}
+
+func init() {
+	logLevelCmd.Flags().StringSliceVar(&logLevelDebugUsers, "debug-user", nil, "also print the debug logs about this user, whatever the level")
+	logLevelCmd.Flags().StringSliceVar(&logLevelDebugBrokers, "debug-broker", nil, "also print the debug logs about the sessions of this broker, by ID or name, whatever the level")
+	logLevelCmd.Flags().BoolVar(&logLevelClearDebug, "clear-debug", false, "stop printing the debug logs of the users and brokers previously added")
+}
@@ -0,0 +1,50 @@
+// Package main implements authctl, the command line tool to manage authd.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/group"
+	"github.com/ubuntu/authd/cmd/authctl/session"
+	"github.com/ubuntu/authd/cmd/authctl/user"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "authctl",
+	Short: "CLI tool to manage authd",
+	Long:  "authctl is a command line tool to inspect and manage the users and groups handled by authd.",
+	// We display usage error ourselves
+	SilenceErrors: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Command parsing has been successful. Returns to not print usage anymore.
+		cmd.Root().SilenceUsage = true
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(user.UserCmd)
+	rootCmd.AddCommand(group.GroupCmd)
+	rootCmd.AddCommand(collisionsCmd)
+	rootCmd.AddCommand(translationsCmd)
+	rootCmd.AddCommand(maintenanceCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(metricsCmd)
+	rootCmd.AddCommand(healthCmd)
+	rootCmd.AddCommand(logLevelCmd)
+	rootCmd.AddCommand(localGroupsCmd)
+	rootCmd.AddCommand(deviceTokenCmd)
+	rootCmd.AddCommand(unlockHostCmd)
+	rootCmd.AddCommand(session.SessionCmd)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		if !rootCmd.SilenceUsage {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
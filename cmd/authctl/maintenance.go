@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Run the maintenance of the authd database now",
+	Long: `Prune orphaned references from the authd database, rebuild its indexes and compact it.
+
+The maintenance also runs periodically, as configured by maintenance_interval and maintenance_start in the authd
+configuration file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		r, err := c.RunMaintenance(context.Background(), &authd.Empty{})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Pruned references: %d\n", r.GetPrunedReferences())
+		fmt.Printf("Rebuilt index entries: %d\n", r.GetRebuiltIndexEntries())
+		fmt.Printf("Database size: %d bytes (was %d bytes)\n", r.GetSizeAfter(), r.GetSizeBefore())
+		return nil
+	},
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Show statistics about the authd users, database and sessions",
+	Long: `Show the number of authd users and groups, the number of users created during the last hour, the size of the
+authd database, the latency of its write transactions and the number of idle authentication sessions ended since the
+start of the daemon.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		m, err := c.GetMetrics(context.Background(), &authd.Empty{})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Users: %d\n", m.GetUsers())
+		fmt.Printf("Groups: %d\n", m.GetGroups())
+		fmt.Printf("Users created during the last hour: %d\n", m.GetUsersCreatedLastHour())
+		fmt.Printf("Database size: %d bytes\n", m.GetDatabaseSize())
+		fmt.Printf("Write transactions: %d\n", m.GetWriteTransactions())
+		fmt.Printf("Average write transaction time: %s\n", time.Duration(m.GetAverageWriteTransactionTimeUs())*time.Microsecond)
+		fmt.Printf("Maximum write transaction time: %s\n", time.Duration(m.GetMaxWriteTransactionTimeUs())*time.Microsecond)
+		fmt.Printf("Idle sessions ended: %d\n", m.GetReapedSessions())
+		fmt.Printf("Pending sessions timed out: %d\n", m.GetTimedOutSessions())
+		return nil
+	},
+}
@@ -0,0 +1,44 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the authentication sessions in progress",
+	Long: `List the authentication sessions in progress, the oldest first.
+
+A session lasts from the selection of the broker until the client, like the login screen, ends it. The sessions which
+stay in progress for a long time are likely stuck, and can be ended with "authctl session terminate".`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		resp, err := c.ListSessions(context.Background(), &authd.Empty{})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSER\tBROKER\tMODE\tAUTH MODE\tSTATE\tAGE")
+		for _, s := range resp.GetSessions() {
+			age := time.Since(time.Unix(s.GetStarted(), 0)).Truncate(time.Second)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.GetId(), s.GetUsername(), s.GetBrokerName(), s.GetMode(),
+				s.GetAuthMode(), s.GetState(), age)
+		}
+		return w.Flush()
+	},
+}
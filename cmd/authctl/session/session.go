@@ -0,0 +1,19 @@
+// Package session contains the authctl commands to manage the authentication sessions in progress.
+package session
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// SessionCmd is a command to perform session-related operations.
+var SessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Commands related to the authentication sessions in progress",
+	Args:  cobra.NoArgs,
+	RunE:  func(cmd *cobra.Command, args []string) error { return cmd.Usage() },
+}
+
+func init() {
+	SessionCmd.AddCommand(listCmd)
+	SessionCmd.AddCommand(terminateCmd)
+}
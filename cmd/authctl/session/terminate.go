@@ -0,0 +1,35 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var terminateCmd = &cobra.Command{
+	Use:   "terminate <id>",
+	Short: "Terminate an authentication session in progress",
+	Long: `Terminate an authentication session in progress, as listed by "authctl session list", without restarting the
+daemon.
+
+The pending authentication of the session, if any, is cancelled and its broker is asked to end it. The client of the
+session, like the login screen, gets an error on its next request.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		if _, err := c.TerminateSession(context.Background(), &authd.TerminateSessionRequest{Id: args[0]}); err != nil {
+			return err
+		}
+
+		fmt.Printf("Session %q terminated\n", args[0])
+		return nil
+	},
+}
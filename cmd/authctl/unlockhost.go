@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var unlockHostCmd = &cobra.Command{
+	Use:   "unlock-host HOST",
+	Short: "Unlock a remote host locked out after too many failed authentication attempts",
+	Long: `Forget the recent failed authentication attempts from a remote host, as reported by PAM, so that the users can
+log in from it again before its lockout ends.
+
+The lockout of the remote hosts is configured by lockout_source_deny and lockout_fail_interval in the authd
+configuration file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		if _, err := c.UnlockRemoteHost(context.Background(), &authd.UnlockRemoteHostRequest{Host: args[0]}); err != nil {
+			return err
+		}
+
+		fmt.Printf("Remote host %q unlocked\n", args[0])
+		return nil
+	},
+}
@@ -0,0 +1,49 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var adoptBroker string
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt <name>",
+	Short: "Move a local user under the management of authd",
+	Long: `Move a user of /etc/passwd under the management of authd, provided by the given broker.
+
+The UID, the user private group, the home directory, the GECOS and the shell of the user are kept, so that the files
+of the user don't need to be modified. The other groups of the user are kept as local groups.
+
+The local files are not modified: once the user is adopted, its entries must be removed from /etc/passwd and
+/etc/shadow with vipw, and the ones of its user private group from /etc/group and /etc/gshadow with vigr, for the user
+to be provided by authd. Removing the user with userdel would also remove it from its local groups.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		u, err := c.AdoptUser(context.Background(), &authd.AdoptUserRequest{Name: name, Broker: adoptBroker})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("User %q adopted with UID %d\n", name, u.GetUid())
+		fmt.Println(`Remove its local entries with "vipw", "vipw -s", "vigr" and "vigr -s" for it to be provided by authd`)
+		return nil
+	},
+}
+
+func init() {
+	adoptCmd.Flags().StringVar(&adoptBroker, "broker", "", "ID or name of the broker which will provide the user (required)")
+	_ = adoptCmd.MarkFlagRequired("broker")
+}
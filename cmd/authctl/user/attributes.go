@@ -0,0 +1,40 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var attributesCmd = &cobra.Command{
+	Use:   "attributes <name>",
+	Short: "Show the extended attributes attached to an authd user by its broker",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		resp, err := c.GetUserExtendedAttributes(context.Background(), &authd.ExtendedAttributesRequest{Name: args[0]})
+		if err != nil {
+			return err
+		}
+
+		attributes := resp.GetAttributes()
+		keys := make([]string, 0, len(attributes))
+		for k := range attributes {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			fmt.Printf("%s=%s\n", k, attributes[k])
+		}
+		return nil
+	},
+}
@@ -0,0 +1,43 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var lastLoginCmd = &cobra.Command{
+	Use:   "last-login <name>",
+	Short: "Show the last successful login of an authd user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		resp, err := c.GetLastLogin(context.Background(), &authd.LastLoginRequest{Name: args[0]})
+		if err != nil {
+			return err
+		}
+
+		if resp.GetTime() == 0 {
+			fmt.Printf("User %q never logged in\n", args[0])
+			return nil
+		}
+
+		source := resp.GetSource()
+		if source == "" {
+			source = "unknown"
+		}
+		fmt.Printf("Time:   %s\n", time.Unix(resp.GetTime(), 0).Format(time.RFC3339))
+		fmt.Printf("Source: %s\n", source)
+		fmt.Printf("Broker: %s\n", resp.GetBrokerId())
+		return nil
+	},
+}
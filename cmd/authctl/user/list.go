@@ -0,0 +1,97 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+// listPageSize is the number of users requested at once to the daemon.
+const listPageSize = 500
+
+var (
+	listBroker      string
+	listInactiveFor time.Duration
+	listLocked      bool
+	listUnlocked    bool
+	listStale       bool
+	listMinUID      uint32
+	listMaxUID      uint32
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the authd users",
+	Long: `List the authd users, sorted by UID.
+
+The flags select the users to list. Without any flag, all the users are listed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if listLocked && listUnlocked {
+			return errors.New("--locked and --unlocked are mutually exclusive")
+		}
+
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		req := &authd.ListUsersRequest{
+			BrokerId: listBroker,
+			MinUid:   listMinUID,
+			MaxUid:   listMaxUID,
+			PageSize: listPageSize,
+		}
+		if listInactiveFor > 0 {
+			req.LastLoginBefore = time.Now().Add(-listInactiveFor).Unix()
+		}
+		if listLocked || listUnlocked {
+			req.Locked = &listLocked
+		}
+		if listStale {
+			req.Stale = &listStale
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tUID\tGID\tHOME\tSHELL\tBROKER\tLAST LOGIN\tLOCKED\tSTALE")
+		for {
+			resp, err := c.ListUsers(context.Background(), req)
+			if err != nil {
+				return err
+			}
+
+			for _, u := range resp.GetUsers() {
+				lastLogin := "never"
+				if u.GetLastLogin() != 0 {
+					lastLogin = time.Unix(u.GetLastLogin(), 0).Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%t\t%t\n", u.GetName(), u.GetUid(), u.GetGid(), u.GetHomedir(),
+					u.GetShell(), u.GetBrokerId(), lastLogin, u.GetLocked(), u.GetStale())
+			}
+
+			if resp.GetNextPageToken() == "" {
+				break
+			}
+			req.PageToken = resp.GetNextPageToken()
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	listCmd.Flags().StringVar(&listBroker, "broker", "", "only list the users of the broker with this ID")
+	listCmd.Flags().DurationVar(&listInactiveFor, "inactive-for", 0, "only list the users who didn't log in during this duration, for example \"720h\"")
+	listCmd.Flags().BoolVar(&listLocked, "locked", false, "only list the locked users")
+	listCmd.Flags().BoolVar(&listUnlocked, "unlocked", false, "only list the unlocked users")
+	listCmd.Flags().BoolVar(&listStale, "stale", false, "only list the users whose information expired and must be re-validated with their broker")
+	listCmd.Flags().Uint32Var(&listMinUID, "min-uid", 0, "only list the users whose UID is greater than or equal to this one")
+	listCmd.Flags().Uint32Var(&listMaxUID, "max-uid", 0, "only list the users whose UID is less than or equal to this one")
+}
@@ -0,0 +1,56 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock <name>",
+	Short: "Lock an authd user",
+	Long: `Lock an authd user, so that it can't authenticate anymore.
+
+The user is still known to the system, but any login attempt is denied until the user is unlocked.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		if _, err := c.LockUser(context.Background(), &authd.LockUserRequest{Name: args[0]}); err != nil {
+			return err
+		}
+
+		fmt.Printf("User %q locked\n", args[0])
+		return nil
+	},
+}
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock <name>",
+	Short: "Unlock an authd user",
+	Long: `Unlock an authd user previously locked with "authctl user lock", so that it can authenticate again.
+
+It also ends the lockout of a user after too many failed authentication attempts.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		if _, err := c.UnlockUser(context.Background(), &authd.LockUserRequest{Name: args[0]}); err != nil {
+			return err
+		}
+
+		fmt.Printf("User %q unlocked\n", args[0])
+		return nil
+	},
+}
@@ -0,0 +1,53 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var lockoutCmd = &cobra.Command{
+	Use:   "lockout <name>",
+	Short: "Show whether a user is locked out after too many failed authentication attempts",
+	Long: `Show whether a user is locked out after too many failed authentication attempts, and its recent failed attempts.
+
+The lockout is configured by lockout_deny, lockout_fail_interval and lockout_unlock_time in the authd configuration
+file. A locked out user can be unlocked before its lockout ends with "authctl user unlock", and a remote host locked
+out after too many failed attempts of any user with "authctl unlock-host".`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		resp, err := c.GetLockout(context.Background(), &authd.LockoutRequest{Name: args[0]})
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case !resp.GetLockedOut():
+			fmt.Println("Locked out: no")
+		case resp.GetLockedUntil() == 0:
+			fmt.Println("Locked out: until unlocked by an administrator")
+		default:
+			fmt.Printf("Locked out: until %s\n", time.Unix(resp.GetLockedUntil(), 0).Format(time.RFC3339))
+		}
+
+		fmt.Printf("Failed attempts: %d\n", len(resp.GetFailedLogins()))
+		for _, f := range resp.GetFailedLogins() {
+			source := f.GetSource()
+			if source == "" {
+				source = "unknown"
+			}
+			fmt.Printf("  %s from %s\n", time.Unix(f.GetTime(), 0).Format(time.RFC3339), source)
+		}
+		return nil
+	},
+}
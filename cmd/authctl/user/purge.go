@@ -0,0 +1,35 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge <name>",
+	Short: "Remove an authd user from the database",
+	Long: `Remove an authd user from the database, including its group memberships, its broker assignment and its user
+private group. The user is also removed from the local groups authd added it to.
+
+The home directory and the mail spool of the user are kept, archived or deleted depending on the home_dir_cleanup
+option of the daemon. If the user logs in again, it gets a new UID.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		if _, err := c.PurgeUser(context.Background(), &authd.PurgeUserRequest{Name: args[0]}); err != nil {
+			return err
+		}
+
+		fmt.Printf("User %q purged\n", args[0])
+		return nil
+	},
+}
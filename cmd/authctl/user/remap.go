@@ -0,0 +1,49 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var (
+	remapUID       uint32
+	remapChownHome bool
+)
+
+var remapCmd = &cobra.Command{
+	Use:   "remap <name>",
+	Short: "Change the UID of an authd user",
+	Long: `Change the UID of an authd user, for example to fix a collision with a local user.
+
+If no UID is given, a free one is generated in the configured range. The remapping is recorded and can be listed
+with "authctl translations".`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		t, err := c.RemapUserID(context.Background(), &authd.RemapIDRequest{
+			Name:      args[0],
+			NewId:     remapUID,
+			ChownHome: remapChownHome,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("UID of user %q changed from %d to %d\n", t.GetName(), t.GetOldId(), t.GetNewId())
+		return nil
+	},
+}
+
+func init() {
+	remapCmd.Flags().Uint32Var(&remapUID, "uid", 0, "new UID of the user (generated if not set)")
+	remapCmd.Flags().BoolVar(&remapChownHome, "chown-home", false, "change the owner of the files in the home directory to the new UID")
+}
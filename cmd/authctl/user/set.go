@@ -0,0 +1,61 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/cmd/authctl/internal/client"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+var (
+	setGecos string
+	setHome  string
+	setShell string
+)
+
+var setCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Modify the attributes of an authd user locally",
+	Long: `Modify the GECOS, home directory or shell of an authd user locally.
+
+The attributes provided by the broker are refreshed on every login, but the ones modified with this command are
+kept. The home directory itself is not moved.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req := &authd.SetUserAttributesRequest{Name: args[0]}
+		if cmd.Flags().Changed("gecos") {
+			req.Gecos = &setGecos
+		}
+		if cmd.Flags().Changed("home") {
+			req.Home = &setHome
+		}
+		if cmd.Flags().Changed("shell") {
+			req.Shell = &setShell
+		}
+		if req.Gecos == nil && req.Home == nil && req.Shell == nil {
+			return errors.New("no attribute to set, use --gecos, --home or --shell")
+		}
+
+		c, closeConn, err := client.NewUserServiceClient()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		if _, err := c.SetUserAttributes(context.Background(), req); err != nil {
+			return err
+		}
+
+		fmt.Printf("Attributes of user %q updated\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	setCmd.Flags().StringVar(&setGecos, "gecos", "", "new GECOS of the user")
+	setCmd.Flags().StringVar(&setHome, "home", "", "new home directory of the user")
+	setCmd.Flags().StringVar(&setShell, "shell", "", "new login shell of the user")
+}
@@ -0,0 +1,27 @@
+// Package user contains the authctl commands to manage authd users.
+package user
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// UserCmd is a command to perform user-related operations.
+var UserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Commands related to users",
+	Args:  cobra.NoArgs,
+	RunE:  func(cmd *cobra.Command, args []string) error { return cmd.Usage() },
+}
+
+func init() {
+	UserCmd.AddCommand(remapCmd)
+	UserCmd.AddCommand(purgeCmd)
+	UserCmd.AddCommand(setCmd)
+	UserCmd.AddCommand(lockCmd)
+	UserCmd.AddCommand(unlockCmd)
+	UserCmd.AddCommand(lockoutCmd)
+	UserCmd.AddCommand(lastLoginCmd)
+	UserCmd.AddCommand(attributesCmd)
+	UserCmd.AddCommand(listCmd)
+	UserCmd.AddCommand(adoptCmd)
+}
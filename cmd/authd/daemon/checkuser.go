@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/internal/checkuser"
+)
+
+func (a *App) installCheckUser() {
+	cmd := &cobra.Command{
+		Use:                                                                                                     "check-user USERNAME",
+		Short:/*i18n.G(*/ "Resolves a user like the system and the daemon do, prints each step of it and exits", /*)*/
+		Args:                                                                                                    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := checkuser.Run(cmd.Context(), checkUserConfig(a.currentConfig()), args[0])
+			checkuser.Print(cmd.OutOrStdout(), r)
+			if r.Entry == nil {
+				return fmt.Errorf("user %q is not resolved", args[0])
+			}
+			return nil
+		},
+	}
+	a.rootCmd.AddCommand(cmd)
+}
+
+// checkUserConfig returns the environment the user is resolved in, from the configuration of the daemon.
+func checkUserConfig(config daemonConfig) checkuser.Config {
+	return checkuser.Config{
+		PasswdFile:      "/etc/passwd",
+		CacheDir:        config.Paths.Cache,
+		BrokersConfPath: config.Paths.BrokersConf,
+		Brokers:         config.Brokers,
+	}
+}
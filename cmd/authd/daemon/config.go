@@ -1,21 +1,43 @@
 package daemon
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
+	"os/user"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/ubuntu/authd/internal/consts"
+	"github.com/ubuntu/authd/internal/services/permissions"
 	"github.com/ubuntu/authd/log"
 	"github.com/ubuntu/decorate"
+	"gopkg.in/yaml.v3"
 )
 
+const (
+	// defaultConfigDir is the system directory of the configuration file.
+	defaultConfigDir = "/etc/authd/"
+	// logHashKeyFileName is the file of the key the names of the users are hashed with in the logs, in the cache
+	// directory.
+	logHashKeyFileName = "log-hash.key"
+	// dropInDirSuffix is the suffix of the directory of the configuration drop-ins, after the name of the daemon.
+	dropInDirSuffix = ".conf.d"
+)
+
+// envVarRegexp matches the references to environment variables in the configuration files, like ${HOME}.
+var envVarRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
 // initViperConfig sets verbosity level and add config env variables and file support based on name prefix.
 func initViperConfig(name string, cmd *cobra.Command, vip *viper.Viper) (err error) {
 	defer decorate.OnError(&err, "can't load configuration")
@@ -37,7 +59,7 @@ func initViperConfig(name string, cmd *cobra.Command, vip *viper.Viper) (err err
 		vip.SetConfigName(name)
 		vip.AddConfigPath("./")
 		vip.AddConfigPath("$HOME/")
-		vip.AddConfigPath("/etc/authd/")
+		vip.AddConfigPath(defaultConfigDir)
 		// Add the executable path to the config search path.
 		if binPath, err := os.Executable(); err != nil {
 			log.Warningf(context.Background(), "Failed to get current executable path, not adding it as a config dir: %v", err)
@@ -46,15 +68,35 @@ func initViperConfig(name string, cmd *cobra.Command, vip *viper.Viper) (err err
 		}
 	}
 
+	// The drop-in directory is next to the configuration file, or in the system configuration directory without any.
+	dropInDir := filepath.Join(defaultConfigDir, name+dropInDirSuffix)
 	if err := vip.ReadInConfig(); err != nil {
 		var e viper.ConfigFileNotFoundError
 		if errors.As(err, &e) {
 			log.Infof(context.Background(), "No configuration file: %v.\nWe will only use the defaults, env variables or flags.", e)
+			// Forget the options of the previously read drop-ins, which may have been removed since.
+			vip.SetConfigType("yaml")
+			if err := vip.ReadConfig(bytes.NewReader(nil)); err != nil {
+				return err
+			}
 		} else {
 			return fmt.Errorf("invalid configuration file: %w", err)
 		}
 	} else {
 		log.Infof(context.Background(), "Using configuration file: %v", vip.ConfigFileUsed())
+		dropInDir = filepath.Join(filepath.Dir(vip.ConfigFileUsed()), name+dropInDirSuffix)
+
+		// Read the file again to expand the environment variables it references.
+		data, err := readConfigFile(vip.ConfigFileUsed())
+		if err != nil {
+			return fmt.Errorf("invalid configuration file: %w", err)
+		}
+		if err := vip.ReadConfig(bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("invalid configuration file: %w", err)
+		}
+	}
+	if err := mergeDropIns(vip, dropInDir); err != nil {
+		return err
 	}
 
 	// Handle environment.
@@ -80,6 +122,217 @@ func initViperConfig(name string, cmd *cobra.Command, vip *viper.Viper) (err err
 	return nil
 }
 
+// readConfigFile returns the content of the configuration file at path, with the references to environment variables,
+// like ${HOME}, replaced by their value in the values of the options. The comments and the keys are left as they are.
+// Referencing an unset variable is an error, to not silently use an empty value.
+func readConfigFile(path string) (data []byte, err error) {
+	defer decorate.OnError(&err, "%s", path)
+
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	// The file has no options.
+	if doc.Kind == 0 {
+		return data, nil
+	}
+
+	var unset []string
+	expandEnvVars(&doc, &unset)
+	if len(unset) > 0 {
+		return nil, fmt.Errorf("unset environment variables: %s", strings.Join(unset, ", "))
+	}
+
+	return yaml.Marshal(&doc)
+}
+
+// expandEnvVars replaces the references to environment variables in the scalar values of the YAML node and of its
+// children, appending the names of the unset variables to unset. The keys of the mappings are not expanded.
+func expandEnvVars(node *yaml.Node, unset *[]string) {
+	if node.Kind == yaml.ScalarNode {
+		if !envVarRegexp.MatchString(node.Value) {
+			return
+		}
+		node.Value = envVarRegexp.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			name := envVarRegexp.FindStringSubmatch(ref)[1]
+			v, ok := os.LookupEnv(name)
+			if !ok {
+				*unset = append(*unset, name)
+			}
+			return v
+		})
+		// The type of the unquoted values is resolved from the expanded value, as if it was written in the file.
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+		return
+	}
+
+	for i, child := range node.Content {
+		if node.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		expandEnvVars(child, unset)
+	}
+}
+
+// mergeDropIns merges the YAML files of the drop-in directory into the configuration, in lexical order, so that the
+// settings can be layered by different tools without editing the main configuration file. The later files override
+// the options of the previous ones and of the main configuration file.
+func mergeDropIns(vip *viper.Viper, dir string) (err error) {
+	defer decorate.OnError(&err, "invalid configuration drop-in")
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !slices.Contains([]string{".yaml", ".yml"}, filepath.Ext(e.Name())) {
+			continue
+		}
+
+		path := filepath.Join(dir, e.Name())
+		data, err := readConfigFile(path)
+		if err != nil {
+			return err
+		}
+
+		// Parse the drop-in on its own, as the format of the main configuration file may differ.
+		dropIn := viper.New()
+		dropIn.SetConfigType("yaml")
+		if err := dropIn.ReadConfig(bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := vip.MergeConfigMap(dropIn.AllSettings()); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		log.Infof(context.Background(), "Using configuration drop-in: %v", path)
+	}
+
+	return nil
+}
+
+// unmarshalConfig decodes the configuration into config and validates it. Unknown options of the configuration file,
+// which are likely typos, are rejected instead of being silently ignored. The environment variables are not checked, as
+// other components use the same prefix.
+func unmarshalConfig(vip *viper.Viper, config *daemonConfig) (err error) {
+	defer decorate.OnError(&err, "invalid configuration")
+	if f := vip.ConfigFileUsed(); f != "" {
+		defer decorate.OnError(&err, "%s", f)
+	}
+
+	var md mapstructure.Metadata
+	if err := vip.Unmarshal(config, func(dc *mapstructure.DecoderConfig) { dc.Metadata = &md }); err != nil {
+		return err
+	}
+	var unknown []string
+	for _, k := range md.Unused {
+		// The keys of the configuration file are case-insensitive.
+		if k = strings.ToLower(k); vip.InConfig(k) {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return fmt.Errorf("unknown options: %s", strings.Join(unknown, ", "))
+	}
+
+	if config.LogFile.MaxSize < 0 || config.LogFile.MaxAge < 0 || config.LogFile.MaxBackups < 0 {
+		return errors.New("log_file: max_size, max_age and max_backups can't be negative")
+	}
+	if config.ShutdownGracePeriod < 0 {
+		return errors.New("shutdown_grace_period: can't be negative")
+	}
+	if config.SessionIdleTimeout < 0 {
+		return errors.New("session_idle_timeout: can't be negative")
+	}
+	if config.SessionPendingTimeout < 0 {
+		return errors.New("session_pending_timeout: can't be negative")
+	}
+	if err := config.Load.Validate(); err != nil {
+		return fmt.Errorf("load: %w", err)
+	}
+	if err := config.BrokerCalls.Validate(); err != nil {
+		return fmt.Errorf("broker_calls: %w", err)
+	}
+	if err := config.SessionLimits.Validate(); err != nil {
+		return fmt.Errorf("session_limits: %w", err)
+	}
+	if err := config.SecurityEvents.Validate(); err != nil {
+		return fmt.Errorf("security_events: %w", err)
+	}
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		return errors.New("tracing: sample_ratio must be between 0 and 1")
+	}
+	if err := config.Features.Validate(); err != nil {
+		return fmt.Errorf("features: %w", err)
+	}
+	if config.PrivilegeSeparation.Enabled && config.PrivilegeSeparation.User == "" {
+		return errors.New("privilege_separation: no user given")
+	}
+	if err := config.Authorization.Validate(); err != nil {
+		return fmt.Errorf("authorization: %w", err)
+	}
+	if !config.ConfineSnaps && slices.ContainsFunc(config.Authorization, func(r permissions.Rule) bool { return len(r.Snaps) > 0 }) {
+		return errors.New("authorization: the rules listing snaps require confine_snaps")
+	}
+	if err := config.AccessPolicy.Validate(); err != nil {
+		return fmt.Errorf("access_policy: %w", err)
+	}
+	if err := config.RateLimits.Validate(); err != nil {
+		return fmt.Errorf("rate_limits: %w", err)
+	}
+	if !slices.Contains(log.Formats, log.Format(config.LogFormat)) {
+		return fmt.Errorf("log_format: unknown format %q, must be one of %v", config.LogFormat, log.Formats)
+	}
+
+	if tc := config.TCP; tc.Address != "" && (len(tc.Services) == 0 || tc.Cert == "" || tc.Key == "" || tc.ClientCA == "") {
+		return errors.New("tcp: services, cert, key and client_ca are required to listen on a TCP address")
+	}
+	if slices.Contains(config.TCP.Services, "nss") {
+		return errors.New("tcp: the nss service can't be served over TCP, the NSS module only connects to the local sockets")
+	}
+
+	if p := config.Paths.Socket; p != "" && !filepath.IsAbs(p) {
+		return fmt.Errorf("paths.socket: %q is not an absolute path", p)
+	}
+	if _, err := parseSocketMode(config.SocketMode); err != nil {
+		return fmt.Errorf("socket_mode: %w", err)
+	}
+	if g := config.SocketGroup; g != "" {
+		if _, err := user.LookupGroup(g); err != nil {
+			return fmt.Errorf("socket_group: %w", err)
+		}
+	}
+
+	if u := config.UserDB; u.Enabled && !filepath.IsAbs(u.Socket) {
+		return fmt.Errorf("userdb.socket: %q is not an absolute path", u.Socket)
+	}
+
+	for i, s := range config.Sockets {
+		if s.Path == "" {
+			return fmt.Errorf("sockets[%d]: no path given", i)
+		}
+		if len(s.Services) == 0 {
+			return fmt.Errorf("sockets[%d]: no services given for socket %q", i, s.Path)
+		}
+		if _, err := parseSocketMode(s.Mode); err != nil {
+			return fmt.Errorf("sockets[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
 // installConfigFlag installs a --config option.
 func installConfigFlag(cmd *cobra.Command) *string {
 	return cmd.PersistentFlags().StringP("config", "c", "" /*i18n.G(*/, "use a specific configuration file") /*)*/
@@ -96,4 +349,83 @@ func setVerboseMode(level int) {
 		log.SetLevel(log.DebugLevel)
 		slog.SetLogLoggerLevel(slog.LevelDebug)
 	}
+	log.SetPayloadDumps(level > 2)
+}
+
+// logHashKey returns the key the names of the users are hashed with in the logs, creating it in the cache directory if
+// it doesn't exist yet, unless it's read-only. It is kept across restarts, so that the logs of a user can be followed.
+func logHashKey(cacheDir string, readOnly bool) (key []byte, err error) {
+	defer decorate.OnError(&err, "can't get the key of the user names in the logs")
+
+	path := filepath.Join(cacheDir, logHashKeyFileName)
+	key, err = os.ReadFile(path)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) || readOnly {
+		return nil, err
+	}
+
+	if err := ensureDirWithPerms(cacheDir, 0700); err != nil {
+		return nil, err
+	}
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(key); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return nil, err
+	}
+	return key, f.Close()
+}
+
+// setLogOutput sets the format and the output of the logs. They are written to the log file if one is configured.
+// Otherwise, they are sent to the journal with their fields when stderr is connected to it, unless a structured format
+// is requested: the log lines are then printed on stderr in that format.
+func (a *App) setLogOutput(config daemonConfig) (err error) {
+	defer decorate.OnError(&err, "can't set log output")
+
+	f := a.logFile
+	if config.LogFile.Path == "" {
+		f = nil
+	} else if f == nil || config.LogFile != a.logFileConfig {
+		lf := config.LogFile
+		f, err = log.OpenRotatingFile(lf.Path, lf.MaxSize*1024*1024, lf.MaxAge, lf.MaxBackups)
+		if err != nil {
+			return err
+		}
+	}
+
+	if f != nil {
+		log.SetHandler(nil)
+		log.SetOutput(f)
+	} else if a.logFile != nil {
+		log.SetOutput(nil)
+	}
+	if f == nil && log.Format(config.LogFormat) == log.TextFormat {
+		log.InitJournalHandler(false)
+	} else if f == nil {
+		log.SetHandler(nil)
+	}
+	// The format is validated with the configuration.
+	decorate.LogOnError(log.SetFormat(log.Format(config.LogFormat)))
+	var hashKey []byte
+	if config.LogHashUsernames {
+		if hashKey, err = logHashKey(config.Paths.Cache, config.UsersConfig.ReadOnly); err != nil {
+			return err
+		}
+	}
+	log.SetHashUsernames(hashKey)
+
+	if a.logFile != nil && a.logFile != f {
+		decorate.LogOnError(a.logFile.Close())
+	}
+	a.logFile, a.logFileConfig = f, config.LogFile
+	return nil
 }
@@ -4,14 +4,30 @@ package daemon
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"runtime"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/ubuntu/authd/internal/brokers"
 	"github.com/ubuntu/authd/internal/consts"
 	"github.com/ubuntu/authd/internal/daemon"
+	"github.com/ubuntu/authd/internal/features"
+	"github.com/ubuntu/authd/internal/sandbox"
 	"github.com/ubuntu/authd/internal/services"
+	"github.com/ubuntu/authd/internal/services/accesspolicy"
+	"github.com/ubuntu/authd/internal/services/loadshed"
+	"github.com/ubuntu/authd/internal/services/permissions"
+	"github.com/ubuntu/authd/internal/services/ratelimit"
+	"github.com/ubuntu/authd/internal/services/securityevents"
+	"github.com/ubuntu/authd/internal/tracing"
 	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/localentries"
 	"github.com/ubuntu/authd/log"
 	"github.com/ubuntu/decorate"
 )
@@ -19,6 +35,17 @@ import (
 // cmdName is the binary name for the agent.
 const cmdName = "authd"
 
+// defaultShutdownGracePeriod leaves enough time to the users to finish an authentication in progress, while being
+// shorter than the time systemd waits before killing authd.
+const defaultShutdownGracePeriod = 30 * time.Second
+
+// defaultSessionIdleTimeout ends the sessions abandoned by their client, while leaving a login screen waiting for its
+// user long enough.
+const defaultSessionIdleTimeout = time.Hour
+
+// pidFileName is the name of the PID file in the cache directory, locked by the running instance of the daemon.
+const pidFileName = "authd.pid"
+
 // App encapsulate commands and options of the daemon, which can be controlled by env variables and config files.
 type App struct {
 	rootCmd cobra.Command
@@ -27,6 +54,15 @@ type App struct {
 
 	daemon *daemon.Daemon
 
+	// manager is the services manager of the running daemon, to which the configuration is reloaded.
+	manager *services.Manager
+	// managerMu protects the manager and the configuration reloaded on SIGHUP.
+	managerMu sync.Mutex
+
+	// logFile is the rotated log file, if the logs are written to a file, opened with logFileConfig.
+	logFile       *log.RotatingFile
+	logFileConfig logFileConfig
+
 	ready chan struct{}
 }
 
@@ -37,12 +73,133 @@ type systemPaths struct {
 	Socket      string
 }
 
+// socketConfig is an additional socket serving some of the services, with its own permissions.
+type socketConfig struct {
+	Path string
+	// Services are the names of the services served on the socket: "nss", "pam" and "user".
+	Services []string
+	// Mode is the octal permission of the socket, 0666 by default.
+	Mode string
+	// Group is the group owning the socket, if set.
+	Group string
+}
+
+// tcpConfig is the TCP address serving some of the services to remote clients, like thin clients, authenticated with
+// mutual TLS.
+type tcpConfig struct {
+	// Address is the address to listen on, like ":9443". The TCP listener is disabled if empty.
+	Address string
+	// Services are the names of the services served to the remote clients: "pam" and "user". The NSS module only
+	// connects to the local sockets, so "nss" is not served over TCP.
+	Services []string
+	// Cert and Key are the files of the certificate of the daemon and of its private key.
+	Cert string
+	Key  string
+	// ClientCA is the file of the certificates of the authorities signing the certificates of the clients.
+	ClientCA string `mapstructure:"client_ca"`
+	// RootClients are the names of the clients, as in their certificate, allowed what root is, like authenticating
+	// users.
+	RootClients []string `mapstructure:"root_clients"`
+}
+
+// sandboxConfig restricts what the daemon can do once started.
+type sandboxConfig struct {
+	// Enabled applies a seccomp filter and landlock rules to the daemon. It can be disabled to debug the daemon.
+	Enabled bool
+	// WritablePaths are the paths the daemon can write to, like the parents of the home directories, in addition to
+	// the ones of its configuration. If empty, /home is writable when the daemon creates or cleans up home directories.
+	WritablePaths []string `mapstructure:"writable_paths"`
+}
+
+// privilegeSeparationConfig runs the code decoding the messages of the brokers in a worker process without the
+// privileges of the daemon.
+type privilegeSeparationConfig struct {
+	// Enabled starts the worker. The daemon needs the CAP_SETUID and CAP_SETGID capabilities to start it.
+	Enabled bool
+	// User is the user the worker runs as, which the D-Bus policy of the brokers must allow.
+	User string
+}
+
+// userDBConfig serves the users and groups to the systemd user database, with the io.systemd.UserDatabase varlink
+// interface.
+type userDBConfig struct {
+	Enabled bool
+	// Socket is the socket of the service, in the directory where systemd looks for the services of its user
+	// database.
+	Socket string
+}
+
+// debugConfig enables the helpers for debugging the running daemon during support sessions.
+type debugConfig struct {
+	// GRPCReflection serves the gRPC server reflection to root on the main socket, for grpcurl to be used against
+	// the daemon.
+	GRPCReflection bool `mapstructure:"grpc_reflection"`
+}
+
+// logFileConfig is the file the logs are written to, instead of stderr or the journal.
+type logFileConfig struct {
+	Path string
+	// MaxSize is the size in MiB above which the file is rotated, 0 to disable the rotation on size.
+	MaxSize int64 `mapstructure:"max_size"`
+	// MaxAge is the age above which the file is rotated, 0 to disable the rotation on age.
+	MaxAge time.Duration `mapstructure:"max_age"`
+	// MaxBackups is the number of rotated files to keep.
+	MaxBackups int `mapstructure:"max_backups"`
+}
+
 // daemonConfig defines configuration parameters of the daemon.
 type daemonConfig struct {
-	Brokers     []string
-	Verbosity   int
-	Paths       systemPaths
-	UsersConfig users.Config `mapstructure:",squash"`
+	Brokers    []string
+	Verbosity  int
+	LogFormat  string        `mapstructure:"log_format"`
+	LogFile    logFileConfig `mapstructure:"log_file"`
+	Paths      systemPaths
+	Sockets    []socketConfig
+	TCP        tcpConfig        `mapstructure:"tcp"`
+	RateLimits ratelimit.Limits `mapstructure:"rate_limits"`
+	// Load bounds the requests handled at once, so that authd doesn't run out of memory when it's flooded.
+	Load loadshed.Limits
+	// LogHashUsernames replaces the names of the users by their hash in the logs.
+	LogHashUsernames bool `mapstructure:"log_hash_usernames"`
+	// SocketMode is the octal permission of the main socket, and SocketGroup the group owning it, if set. They override
+	// the ones of the systemd socket unit with socket activation.
+	SocketMode  string `mapstructure:"socket_mode"`
+	SocketGroup string `mapstructure:"socket_group"`
+	// Authorization grants the access to some of the methods restricted to root to other users.
+	Authorization permissions.Policy
+	// ConfineSnaps restricts the clients confined in a snap to what the authorization policy grants their snap, even
+	// if they run as root.
+	ConfineSnaps bool `mapstructure:"confine_snaps"`
+	// AccessPolicy is the conditional access policy evaluated before the authentications are granted.
+	AccessPolicy accesspolicy.Policy `mapstructure:"access_policy"`
+	// ShutdownGracePeriod is how long the requests in progress, like authentications, can take to finish when authd
+	// is stopped, before being cancelled.
+	ShutdownGracePeriod time.Duration `mapstructure:"shutdown_grace_period"`
+	// SessionIdleTimeout is how long the authentication sessions can stay without any request of their client, like
+	// when it crashed, before being ended. 0 disables it.
+	SessionIdleTimeout time.Duration `mapstructure:"session_idle_timeout"`
+	// SessionPendingTimeout is how long the authentication sessions can wait for the user or the broker after they
+	// started, even if their client is active, before being ended. The brokers can override it. 0 disables it.
+	SessionPendingTimeout time.Duration `mapstructure:"session_pending_timeout"`
+	// SessionLimits bounds the authentication sessions in progress of each user and of each source they log in from.
+	SessionLimits brokers.SessionLimits `mapstructure:"session_limits"`
+	// SecurityEvents is where the security events, like the lockouts of the users, are notified.
+	SecurityEvents securityevents.Config `mapstructure:"security_events"`
+	// BrokerCalls bounds the calls to each broker, so that a burst of logins can't overload it.
+	BrokerCalls brokers.CallLimits `mapstructure:"broker_calls"`
+	// Tracing is the OpenTelemetry collector the spans of the requests are exported to.
+	Tracing tracing.Config
+	// Features enables the experimental behaviors of the daemon.
+	Features features.Config
+	// Sandbox restricts the system calls of the daemon and the paths it can write to.
+	Sandbox sandboxConfig
+	// PrivilegeSeparation calls the brokers from an unprivileged worker process.
+	PrivilegeSeparation privilegeSeparationConfig `mapstructure:"privilege_separation"`
+	UsersConfig         users.Config              `mapstructure:",squash"`
+	// UserDB serves the users and groups to the systemd components, in addition to the NSS module.
+	UserDB userDBConfig
+	// Debug enables the helpers for debugging the running daemon.
+	Debug debugConfig
 }
 
 // New registers commands and return a new App.
@@ -61,35 +218,35 @@ func New() *App {
 			a.rootCmd.SilenceUsage = true
 			// TODO: before or after?  cmd.LocalFlags()
 
-			// Set config defaults
-			a.config = daemonConfig{
-				Paths: systemPaths{
-					BrokersConf: consts.DefaultBrokersConfPath,
-					Cache:       consts.DefaultCacheDir,
-					Socket:      "",
-				},
-				UsersConfig: users.DefaultConfig,
-			}
-
-			// Install and unmarshall configuration
-			if err := initViperConfig(cmdName, &a.rootCmd, a.viper); err != nil {
+			config, err := a.loadConfig()
+			if err != nil {
+				close(a.ready)
 				return err
 			}
-			if err := a.viper.Unmarshal(&a.config); err != nil {
-				return fmt.Errorf("unable to decode configuration into struct: %w", err)
+			a.managerMu.Lock()
+			a.config = config
+			a.managerMu.Unlock()
+
+			setVerboseMode(config.Verbosity)
+
+			// Don't modify the cache directory in read-only mode. It's migrated before the logs are set, as they keep
+			// the key of the user names there.
+			if !config.UsersConfig.ReadOnly {
+				if err := migrateOldCacheDir(consts.OldCacheDir, config.Paths.Cache); err != nil {
+					return err
+				}
 			}
 
-			setVerboseMode(a.config.Verbosity)
-			log.Debugf(context.Background(), "Verbosity: %d", a.config.Verbosity)
-
-			if err := migrateOldCacheDir(consts.OldCacheDir, a.config.Paths.Cache); err != nil {
+			if err := a.setLogOutput(config); err != nil {
+				close(a.ready)
 				return err
 			}
+			log.Debugf(context.Background(), "Verbosity: %d", config.Verbosity)
 
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return a.serve(a.config)
+			return a.serve(a.currentConfig())
 		},
 		// We display usage error ourselves
 		SilenceErrors: true,
@@ -99,14 +256,64 @@ func New() *App {
 	a.viper = viper
 
 	installVerbosityFlag(&a.rootCmd, a.viper)
+	installReadOnlyFlag(&a.rootCmd, a.viper)
+	installSocketFlags(&a.rootCmd, a.viper)
+	installGRPCReflectionFlag(&a.rootCmd, a.viper)
 	installConfigFlag(&a.rootCmd)
 
 	// subcommands
 	a.installVersion()
+	a.installDoctor()
+	a.installCheckUser()
+	a.installBrokerWorker()
 
 	return &a
 }
 
+// loadConfig returns the configuration of the daemon from its defaults, configuration file, environment and flags.
+func (a *App) loadConfig() (config daemonConfig, err error) {
+	// Set config defaults
+	config = daemonConfig{
+		LogFormat: string(log.TextFormat),
+		LogFile: logFileConfig{
+			MaxSize:    10,
+			MaxAge:     7 * 24 * time.Hour,
+			MaxBackups: 3,
+		},
+		Paths: systemPaths{
+			BrokersConf: consts.DefaultBrokersConfPath,
+			Cache:       consts.DefaultCacheDir,
+			Socket:      "",
+		},
+		RateLimits:          ratelimit.DefaultLimits,
+		Load:                loadshed.DefaultLimits,
+		ShutdownGracePeriod: defaultShutdownGracePeriod,
+		SessionIdleTimeout:  defaultSessionIdleTimeout,
+		BrokerCalls:         brokers.DefaultCallLimits,
+		SessionLimits:       brokers.DefaultSessionLimits,
+		Tracing:             tracing.Config{SampleRatio: 1},
+		Sandbox:             sandboxConfig{Enabled: true},
+		PrivilegeSeparation: privilegeSeparationConfig{User: "nobody"},
+		UserDB:              userDBConfig{Socket: consts.DefaultUserDBSocketPath},
+		UsersConfig:         users.DefaultConfig,
+	}
+
+	// Install and unmarshall configuration
+	if err := initViperConfig(cmdName, &a.rootCmd, a.viper); err != nil {
+		return config, err
+	}
+	if err := unmarshalConfig(a.viper, &config); err != nil {
+		return config, err
+	}
+	if config.Paths.Socket == "" {
+		if p, ok := snapSocketPath(); ok {
+			config.Paths.Socket = p
+		}
+	}
+
+	return config, nil
+}
+
 // serve creates new GRPC services and listen on a TCP socket. This call is blocking until we quit it.
 func (a *App) serve(config daemonConfig) error {
 	ctx := context.Background()
@@ -116,8 +323,96 @@ func (a *App) serve(config daemonConfig) error {
 		close(a.ready)
 		return fmt.Errorf("error initializing cache directory at %q: %v", cacheDir, err)
 	}
+	if p, ok := snapSocketPath(); ok && config.Paths.Socket == p {
+		// The clients confined in the other snaps must be able to reach the socket.
+		if err := ensureDirWithPerms(filepath.Dir(p), 0755); err != nil {
+			close(a.ready)
+			return fmt.Errorf("error initializing socket directory of the snap: %v", err)
+		}
+	}
+
+	if config.UserDB.Enabled {
+		// systemd creates the directory of the services of its user database, but it can start after authd.
+		if err := ensureDirWithPerms(filepath.Dir(config.UserDB.Socket), 0755); err != nil {
+			close(a.ready)
+			return fmt.Errorf("error initializing user database socket directory: %v", err)
+		}
+	}
 
-	m, err := services.NewManager(ctx, cacheDir, config.Paths.BrokersConf, config.Brokers, config.UsersConfig)
+	if config.Sandbox.Enabled {
+		paths, replaceableDirs := writablePaths(config), []string(nil)
+		if !config.UsersConfig.ReadOnly {
+			groupPaths, groupDir, err := localentries.PrepareSandbox()
+			if err != nil {
+				close(a.ready)
+				return err
+			}
+			paths, replaceableDirs = append(paths, groupPaths...), []string{groupDir}
+		}
+		// This re-executes the daemon, so it's done before anything else is started.
+		if err := sandbox.Apply(ctx, paths, replaceableDirs); err != nil {
+			close(a.ready)
+			return err
+		}
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, config.Tracing, consts.Version)
+	if err != nil {
+		close(a.ready)
+		return err
+	}
+	// The spans not exported yet are exported on exit.
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Warningf(ctx, "Could not export the last spans: %v", err)
+		}
+	}()
+
+	features.Set(config.Features)
+	if enabled := features.List(); len(enabled) > 0 {
+		log.Warningf(ctx, "Experimental features enabled: %v", enabled)
+	}
+
+	// The lock is taken before opening the database, so that a second instance fails right away instead of waiting
+	// for the database.
+	unlock, err := daemon.LockInstance(filepath.Join(cacheDir, pidFileName))
+	if err != nil {
+		close(a.ready)
+		return err
+	}
+	defer func() { decorate.LogOnError(unlock()) }()
+
+	managerOpts := []services.Option{
+		services.WithRateLimits(config.RateLimits),
+		services.WithLoadLimits(config.Load),
+		services.WithAuthorizationPolicy(config.Authorization),
+		services.WithAccessPolicy(config.AccessPolicy),
+		services.WithSessionIdleTimeout(config.SessionIdleTimeout),
+		services.WithSessionPendingTimeout(config.SessionPendingTimeout),
+		services.WithBrokerCallLimits(config.BrokerCalls),
+		services.WithSessionLimits(config.SessionLimits),
+		services.WithSecurityEvents(config.SecurityEvents),
+	}
+	if config.PrivilegeSeparation.Enabled {
+		w, err := startBrokerWorker(ctx, config.PrivilegeSeparation)
+		if err != nil {
+			close(a.ready)
+			return err
+		}
+		// The worker is stopped once the sessions are ended.
+		defer func() { decorate.LogOnError(w.Close()) }()
+		managerOpts = append(managerOpts, services.WithBrokerWorker(w))
+	}
+	if config.Debug.GRPCReflection {
+		managerOpts = append(managerOpts, services.WithReflection())
+	}
+	if config.ConfineSnaps {
+		managerOpts = append(managerOpts, services.WithSnapConfinement())
+	}
+
+	m, err := services.NewManager(ctx, cacheDir, config.Paths.BrokersConf, config.Brokers, config.UsersConfig, managerOpts...)
 	if err != nil {
 		close(a.ready)
 		return err
@@ -125,11 +420,51 @@ func (a *App) serve(config daemonConfig) error {
 	// We are closing the cache on exit.
 	defer func() { _ = m.Stop() }()
 
+	a.managerMu.Lock()
+	a.manager = &m
+	a.managerMu.Unlock()
+	defer func() {
+		a.managerMu.Lock()
+		defer a.managerMu.Unlock()
+		a.manager = nil
+	}()
+
 	socketPath := config.Paths.Socket
-	var daemonopts []daemon.Option
+	daemonopts := []daemon.Option{daemon.WithHealthCheck(m.SelfCheck)}
 	if socketPath != "" {
 		daemonopts = append(daemonopts, daemon.WithSocketPath(socketPath))
 	}
+	if config.SocketMode != "" || config.SocketGroup != "" {
+		mode, err := parseSocketMode(config.SocketMode)
+		if err != nil {
+			close(a.ready)
+			return err
+		}
+		daemonopts = append(daemonopts, daemon.WithSocketPermissions(mode, config.SocketGroup))
+	}
+	for _, sc := range config.Sockets {
+		socket, err := extraSocket(sc, m)
+		if err != nil {
+			close(a.ready)
+			return err
+		}
+		daemonopts = append(daemonopts, daemon.WithExtraSocket(socket))
+	}
+	if config.UserDB.Enabled {
+		daemonopts = append(daemonopts, daemon.WithServerSocket(daemon.ServerSocket{
+			Path:   config.UserDB.Socket,
+			Mode:   0666,
+			Server: m.UserDBServer(ctx),
+		}))
+	}
+	if config.TCP.Address != "" {
+		listener, err := tcpListener(config.TCP, m)
+		if err != nil {
+			close(a.ready)
+			return err
+		}
+		daemonopts = append(daemonopts, daemon.WithTCPListener(listener))
+	}
 
 	daemon, err := daemon.New(ctx, m.RegisterGRPCServices, daemonopts...)
 	if err != nil {
@@ -143,38 +478,180 @@ func (a *App) serve(config daemonConfig) error {
 	return daemon.Serve(ctx)
 }
 
+// extraSocket returns the additional socket of the daemon matching its configuration.
+func extraSocket(sc socketConfig, m services.Manager) (s daemon.Socket, err error) {
+	defer decorate.OnError(&err, "invalid configuration of socket %q", sc.Path)
+
+	register, err := m.GRPCServicesRegisterer(sc.Services...)
+	if err != nil {
+		return daemon.Socket{}, err
+	}
+
+	mode, err := parseSocketMode(sc.Mode)
+	if err != nil {
+		return daemon.Socket{}, err
+	}
+
+	return daemon.Socket{Path: sc.Path, Mode: mode, Group: sc.Group, Register: register}, nil
+}
+
+// tcpListener returns the TCP listener of the daemon matching its configuration.
+func tcpListener(tc tcpConfig, m services.Manager) (l daemon.TCPListener, err error) {
+	defer decorate.OnError(&err, "invalid configuration of TCP listener %q", tc.Address)
+
+	tlsConfig, err := permissions.NewServerTLSConfig(tc.Cert, tc.Key, tc.ClientCA)
+	if err != nil {
+		return daemon.TCPListener{}, err
+	}
+
+	register, err := m.TLSGRPCServicesRegisterer(tlsConfig, tc.RootClients, tc.Services...)
+	if err != nil {
+		return daemon.TCPListener{}, err
+	}
+
+	return daemon.TCPListener{Address: tc.Address, Register: register}, nil
+}
+
+// parseSocketMode returns the permission of a socket, which is 0666 if not configured.
+func parseSocketMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0666, nil
+	}
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid mode %q, it must be a quoted octal permission like \"0660\"", s)
+	}
+	return os.FileMode(mode), nil
+}
+
 // installVerbosityFlag adds the -v and -vv options and returns the reference to it.
 func installVerbosityFlag(cmd *cobra.Command, viper *viper.Viper) *int {
-	r := cmd.PersistentFlags().CountP("verbosity", "v" /*i18n.G(*/, "issue INFO (-v), DEBUG (-vv) or DEBUG with caller (-vvv) output") //)
+	r := cmd.PersistentFlags().CountP("verbosity", "v" /*i18n.G(*/, "issue INFO (-v), DEBUG (-vv) or DEBUG with the payloads of the calls (-vvv) output") //)
 	decorate.LogOnError(viper.BindPFlag("verbosity", cmd.PersistentFlags().Lookup("verbosity")))
 	return r
 }
 
+// installReadOnlyFlag adds the --read-only option and returns the reference to it.
+func installReadOnlyFlag(cmd *cobra.Command, viper *viper.Viper) *bool {
+	r := cmd.PersistentFlags().Bool("read-only", false /*i18n.G(*/, "open the users database read-only: users and groups can be looked up, but none are created or modified") //)
+	decorate.LogOnError(viper.BindPFlag("read_only", cmd.PersistentFlags().Lookup("read-only")))
+	return r
+}
+
+// installGRPCReflectionFlag adds the --grpc-reflection option.
+func installGRPCReflectionFlag(cmd *cobra.Command, viper *viper.Viper) {
+	cmd.PersistentFlags().Bool("grpc-reflection", false /*i18n.G(*/, "serve the gRPC server reflection to root on the socket, for debugging with grpcurl") //)
+	decorate.LogOnError(viper.BindPFlag("debug.grpc_reflection", cmd.PersistentFlags().Lookup("grpc-reflection")))
+}
+
+// installSocketFlags adds the --socket, --socket-mode and --socket-group options.
+func installSocketFlags(cmd *cobra.Command, viper *viper.Viper) {
+	cmd.PersistentFlags().String("socket", "" /*i18n.G(*/, "path of the socket to listen on, instead of the one passed by systemd") //)
+	decorate.LogOnError(viper.BindPFlag("paths.socket", cmd.PersistentFlags().Lookup("socket")))
+	cmd.PersistentFlags().String("socket-mode", "" /*i18n.G(*/, "octal permission of the socket, like 0660 (default 0666)") //)
+	decorate.LogOnError(viper.BindPFlag("socket_mode", cmd.PersistentFlags().Lookup("socket-mode")))
+	cmd.PersistentFlags().String("socket-group", "" /*i18n.G(*/, "group owning the socket") //)
+	decorate.LogOnError(viper.BindPFlag("socket_group", cmd.PersistentFlags().Lookup("socket-group")))
+}
+
 // Run executes the command and associated process. It returns an error on syntax/usage error.
 func (a *App) Run() error {
 	return a.rootCmd.Execute()
 }
 
 // UsageError returns if the error is a command parsing or runtime one.
-func (a App) UsageError() bool {
+func (a *App) UsageError() bool {
 	return !a.rootCmd.SilenceUsage
 }
 
-// Hup prints all goroutine stack traces and return false to signal you shouldn't quit.
-func (a App) Hup() (shouldQuit bool) {
+// Hup reloads the configuration of the running daemon, prints all goroutine stack traces and return false to signal
+// you shouldn't quit.
+func (a *App) Hup() (shouldQuit bool) {
+	a.reload()
+
 	buf := make([]byte, 1<<16)
 	runtime.Stack(buf, true)
 	fmt.Printf("%s", buf)
 	return false
 }
 
-// Quit gracefully shutdown the service.
+// reload applies the current configuration to the running daemon, if any. The daemon keeps its previous configuration
+// if the new one is invalid.
+func (a *App) reload() {
+	a.managerMu.Lock()
+	defer a.managerMu.Unlock()
+	if a.manager == nil {
+		return
+	}
+
+	ctx := context.Background()
+	log.Info(ctx, "Reloading configuration")
+
+	config, err := a.loadConfig()
+	if err != nil {
+		log.Errorf(ctx, "Not reloading the configuration: %v", err)
+		setVerboseMode(a.config.Verbosity)
+		return
+	}
+
+	setVerboseMode(config.Verbosity)
+	if err := a.setLogOutput(config); err != nil {
+		log.Errorf(ctx, "Not changing the log output: %v", err)
+		config.LogFormat, config.LogFile = a.config.LogFormat, a.config.LogFile
+	}
+	if !reflect.DeepEqual(config.Paths, a.config.Paths) || !reflect.DeepEqual(config.Sockets, a.config.Sockets) ||
+		config.SocketMode != a.config.SocketMode || config.SocketGroup != a.config.SocketGroup ||
+		!reflect.DeepEqual(config.TCP, a.config.TCP) || config.Tracing != a.config.Tracing ||
+		!reflect.DeepEqual(config.Features, a.config.Features) || !reflect.DeepEqual(config.Sandbox, a.config.Sandbox) ||
+		config.PrivilegeSeparation != a.config.PrivilegeSeparation || config.Debug != a.config.Debug ||
+		config.BrokerCalls != a.config.BrokerCalls || config.UserDB != a.config.UserDB || config.ConfineSnaps != a.config.ConfineSnaps {
+		log.Warning(ctx, "The changes of the paths, sockets, TCP listener, tracing, features, sandbox, privilege separation, broker calls, user database, snap confinement and debug options are only applied when authd restarts")
+		config.Paths, config.Sockets, config.TCP, config.Tracing = a.config.Paths, a.config.Sockets, a.config.TCP, a.config.Tracing
+		config.SocketMode, config.SocketGroup = a.config.SocketMode, a.config.SocketGroup
+		config.Features, config.Sandbox, config.PrivilegeSeparation = a.config.Features, a.config.Sandbox, a.config.PrivilegeSeparation
+		config.Debug, config.BrokerCalls, config.UserDB = a.config.Debug, a.config.BrokerCalls, a.config.UserDB
+		config.ConfineSnaps = a.config.ConfineSnaps
+	}
+
+	a.manager.SetRateLimits(config.RateLimits)
+	a.manager.SetLoadLimits(config.Load)
+	a.manager.SetAuthorizationPolicy(config.Authorization)
+	a.manager.SetAccessPolicy(config.AccessPolicy)
+	a.manager.SetSessionIdleTimeout(config.SessionIdleTimeout)
+	a.manager.SetSessionPendingTimeout(config.SessionPendingTimeout)
+	a.manager.SetSessionLimits(config.SessionLimits)
+	a.manager.SetSecurityEvents(config.SecurityEvents)
+	if err := a.manager.Reload(ctx, config.Brokers, config.UsersConfig); err != nil {
+		log.Errorf(ctx, "Could not reload the whole configuration: %v", err)
+	}
+	a.config = config
+}
+
+// currentConfig returns the configuration of the daemon, which SIGHUP can reload at any time.
+func (a *App) currentConfig() daemonConfig {
+	a.managerMu.Lock()
+	defer a.managerMu.Unlock()
+	return a.config
+}
+
+// Quit gracefully shutdown the service. No new authentication can start, and the ones in progress are cancelled if
+// they don't finish within the shutdown grace period. The sessions still open are then ended with their broker.
 func (a *App) Quit() {
 	a.WaitReady()
 	if a.daemon == nil {
 		return
 	}
-	a.daemon.Quit(context.Background(), false)
+
+	a.managerMu.Lock()
+	if a.manager != nil {
+		a.manager.StopSessions()
+	}
+	gracePeriod := a.config.ShutdownGracePeriod
+	a.managerMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+	a.daemon.Quit(ctx, false)
 }
 
 // WaitReady signals when the daemon is ready
@@ -184,6 +661,6 @@ func (a *App) WaitReady() {
 }
 
 // RootCmd returns a copy of the root command for the app. Shouldn't be in general necessary apart when running generators.
-func (a App) RootCmd() cobra.Command {
+func (a *App) RootCmd() cobra.Command {
 	return a.rootCmd
 }
@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -16,6 +20,7 @@ import (
 	"github.com/ubuntu/authd/internal/consts"
 	"github.com/ubuntu/authd/internal/testutils"
 	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/log"
 )
 
 func TestHelp(t *testing.T) {
@@ -127,6 +132,7 @@ func TestAppRunFailsOnComponentsCreationAndQuit(t *testing.T) {
 		cacheDBBehavior    int
 		cachePathBehavior  int
 		socketPathBehavior int
+		extraSocket        *daemon.SocketConfig
 	}{
 		"Error_on_existing_cache_path_not_being_a_directory":    {cachePathBehavior: dirIsFile},
 		"Error_on_existing_cache_path_with_invalid_permissions": {cachePathBehavior: hasWrongPermission},
@@ -134,6 +140,11 @@ func TestAppRunFailsOnComponentsCreationAndQuit(t *testing.T) {
 
 		"Error_on_grpc_daemon_creation_failure": {socketPathBehavior: dirIsFile},
 
+		"Error_on_extra_socket_without_path":         {extraSocket: &daemon.SocketConfig{Services: []string{"nss"}}},
+		"Error_on_extra_socket_without_services":     {extraSocket: &daemon.SocketConfig{Path: "extra.sock"}},
+		"Error_on_extra_socket_with_unknown_service": {extraSocket: &daemon.SocketConfig{Path: "extra.sock", Services: []string{"doesnotexist"}}},
+		"Error_on_extra_socket_with_invalid_mode":    {extraSocket: &daemon.SocketConfig{Path: "extra.sock", Services: []string{"nss"}, Mode: "rw-rw-rw-"}},
+
 		"Error_on_manager_creationg_failure": {cacheDBBehavior: hasWrongPermission},
 	}
 
@@ -179,6 +190,12 @@ func TestAppRunFailsOnComponentsCreationAndQuit(t *testing.T) {
 				err = os.WriteFile(filepath.Join(config.Paths.Cache, cache.Z_ForTests_DBName()), nil, 0644)
 				require.NoError(t, err, "Setup: could not create database with invalid permissions")
 			}
+			if tc.extraSocket != nil {
+				if tc.extraSocket.Path != "" {
+					tc.extraSocket.Path = filepath.Join(shortTmp, tc.extraSocket.Path)
+				}
+				config.Sockets = []daemon.SocketConfig{*tc.extraSocket}
+			}
 
 			a := daemon.NewForTests(t, &config)
 
@@ -189,6 +206,26 @@ func TestAppRunFailsOnComponentsCreationAndQuit(t *testing.T) {
 	}
 }
 
+func TestAppRunFailsWhenAnotherInstanceIsRunning(t *testing.T) {
+	t.Parallel()
+
+	var config daemon.DaemonConfig
+	config.Paths.Cache = t.TempDir()
+	err := os.Chmod(config.Paths.Cache, 0700)
+	require.NoError(t, err, "Setup: could not change permission on cache directory for tests")
+
+	first, wait := startDaemon(t, &config)
+	defer wait()
+	defer first.Quit()
+
+	// The second instance uses the same cache directory, but another socket.
+	second := daemon.NewForTests(t, &config)
+
+	err = second.Run()
+	require.ErrorContains(t, err, "authd is already running", "Run should fail as another instance is running")
+	second.Quit()
+}
+
 func TestAppCanSigHupWhenExecute(t *testing.T) {
 	r, w, err := os.Pipe()
 	require.NoError(t, err, "Setup: pipe shouldn't fail")
@@ -254,6 +291,125 @@ func TestAppCanSigHupWithoutExecute(t *testing.T) {
 	require.NotEmpty(t, out.String(), "Stacktrace is printed")
 }
 
+func TestAppReloadsConfigOnSigHup(t *testing.T) {
+	tests := map[string]struct {
+		changeConfig  func(*daemon.DaemonConfig)
+		invalidConfig bool
+
+		wantVerbosity   int
+		wantUserInfoTTL time.Duration
+	}{
+		"Applies_new_configuration": {
+			changeConfig:    func(c *daemon.DaemonConfig) { c.Verbosity = 1; c.UsersConfig.UserInfoTTL = time.Hour },
+			wantVerbosity:   1,
+			wantUserInfoTTL: time.Hour,
+		},
+		"Keeps_paths_until_restart": {
+			changeConfig:  func(c *daemon.DaemonConfig) { c.Verbosity = 1; c.Paths.Socket += ".new" },
+			wantVerbosity: 1,
+		},
+		"Keeps_previous_configuration_if_new_one_is_invalid": {
+			invalidConfig: true,
+			wantVerbosity: 2,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			configPath := daemon.GenerateTestConfig(t, nil)
+			a := daemon.New()
+			a.SetArgs("--config", configPath)
+
+			wg := sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := a.Run()
+				require.NoError(t, err, "Run should exits without any error")
+			}()
+			a.WaitReady()
+			defer wg.Wait()
+			defer a.Quit()
+
+			initial := a.Config()
+
+			if tc.invalidConfig {
+				err := os.WriteFile(configPath, []byte("unknown_option: true\n"), 0600)
+				require.NoError(t, err, "Setup: could not write invalid configuration")
+			} else {
+				newConfig := initial
+				tc.changeConfig(&newConfig)
+				d, err := os.ReadFile(daemon.GenerateTestConfig(t, &newConfig))
+				require.NoError(t, err, "Setup: could not read new configuration")
+				err = os.WriteFile(configPath, d, 0600)
+				require.NoError(t, err, "Setup: could not write new configuration")
+			}
+
+			getStdout := captureStdout(t)
+			a.Hup()
+			require.NotEmpty(t, getStdout(), "Stacktrace is printed")
+
+			got := a.Config()
+			require.Equal(t, tc.wantVerbosity, got.Verbosity, "Verbosity after reload")
+			require.Equal(t, tc.wantUserInfoTTL, got.UsersConfig.UserInfoTTL, "User info TTL after reload")
+			require.Equal(t, initial.Paths, got.Paths, "Paths should not change until restart")
+		})
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	// This can't be parallel: the logs are global.
+	t.Cleanup(func() {
+		log.SetOutput(nil)
+		require.NoError(t, log.SetFormat(log.TextFormat), "Teardown: could not restore log format")
+	})
+
+	logPath := filepath.Join(t.TempDir(), "authd.log")
+	conf := daemon.DaemonConfig{
+		LogFormat: string(log.JSONFormat),
+		LogFile:   daemon.LogFileConfig{Path: logPath, MaxSize: 1, MaxBackups: 1},
+	}
+
+	a, wait := startDaemon(t, &conf)
+	a.Quit()
+	wait()
+
+	d, err := os.ReadFile(logPath)
+	require.NoError(t, err, "The log file should have been created")
+	require.Contains(t, string(d), `"msg":"Serving requests`, "The logs should be written to the log file in the configured format")
+}
+
+func TestLogHashUsernames(t *testing.T) {
+	// This can't be parallel: the logs are global.
+	t.Cleanup(func() { log.SetHashUsernames(nil) })
+
+	cacheDir := t.TempDir()
+	//nolint: gosec // This is a directory owned only by the current user for tests.
+	require.NoError(t, os.Chmod(cacheDir, 0700), "Setup: could not change permission on cache directory")
+	conf := daemon.DaemonConfig{LogHashUsernames: true, Paths: daemon.SystemPaths{Cache: cacheDir}}
+
+	a, wait := startDaemon(t, &conf)
+	a.Quit()
+	wait()
+
+	keyPath := filepath.Join(cacheDir, "log-hash.key")
+	key, err := os.ReadFile(keyPath)
+	require.NoError(t, err, "The key of the user names should have been created")
+	require.Len(t, key, 32, "The key of the user names should be of 32 bytes")
+	fi, err := os.Stat(keyPath)
+	require.NoError(t, err, "Setup: could not stat the key of the user names")
+	require.Equal(t, os.FileMode(0600), fi.Mode().Perm(), "The key of the user names should only be readable by its owner")
+	hash := log.HashUsername("user1")
+
+	a, wait = startDaemon(t, &conf)
+	a.Quit()
+	wait()
+
+	got, err := os.ReadFile(keyPath)
+	require.NoError(t, err, "The key of the user names should be kept")
+	require.Equal(t, key, got, "The key of the user names should be kept across restarts")
+	require.Equal(t, hash, log.HashUsername("user1"), "The user names should have the same hash across restarts")
+}
+
 func TestAppGetRootCmd(t *testing.T) {
 	t.Parallel()
 
@@ -276,6 +432,61 @@ func TestConfigLoad(t *testing.T) {
 	require.Equal(t, 1, a.Config().Verbosity, "Verbosity is set from config")
 }
 
+func TestConfigLoadExtraSockets(t *testing.T) {
+	// We are using our own temporary directory for the unix socket path being too long.
+	socketDir, err := os.MkdirTemp("", "authd-tests")
+	require.NoError(t, err, "Setup: could not create temporary directory")
+	t.Cleanup(func() { _ = os.RemoveAll(socketDir) })
+
+	var config daemon.DaemonConfig
+	config.Sockets = []daemon.SocketConfig{
+		{Path: filepath.Join(socketDir, "nss.sock"), Services: []string{"nss"}},
+		{Path: filepath.Join(socketDir, "pam.sock"), Services: []string{"pam", "user"}, Mode: "0600"},
+	}
+
+	a, wait := startDaemon(t, &config)
+	defer wait()
+	defer a.Quit()
+
+	for path, wantMode := range map[string]os.FileMode{"nss.sock": 0666, "pam.sock": 0600} {
+		info, err := os.Stat(filepath.Join(socketDir, path))
+		require.NoError(t, err, "Socket %q should exist", path)
+		require.Equal(t, wantMode, info.Mode().Perm(), "Socket %q should have the configured permissions", path)
+	}
+}
+
+func TestSocketFlags(t *testing.T) {
+	currentGroup, err := user.LookupGroupId(strconv.Itoa(os.Getegid()))
+	require.NoError(t, err, "Setup: could not get the current group")
+
+	var config daemon.DaemonConfig
+	config.Paths.Socket = filepath.Join(t.TempDir(), "config.sock")
+	config.SocketMode = "0666"
+	flagSocketPath := filepath.Join(t.TempDir(), "flag.sock")
+
+	a, wait := startDaemon(t, &config, "--socket", flagSocketPath, "--socket-mode", "0660", "--socket-group", currentGroup.Name)
+	defer wait()
+	defer a.Quit()
+
+	info, err := os.Stat(flagSocketPath)
+	require.NoError(t, err, "The socket of the flag should exist")
+	require.Equal(t, os.FileMode(0660), info.Mode().Perm(), "The socket should have the mode of the flag")
+	require.Equal(t, uint32(os.Getegid()), info.Sys().(*syscall.Stat_t).Gid, "The socket should be owned by the group of the flag")
+	_, err = os.Stat(config.Paths.Socket)
+	require.ErrorIs(t, err, fs.ErrNotExist, "The socket of the configuration file should not be created")
+}
+
+func TestGRPCReflectionFlag(t *testing.T) {
+	var config daemon.DaemonConfig
+	config.Paths.Socket = filepath.Join(t.TempDir(), "authd.sock")
+
+	a, wait := startDaemon(t, &config, "--grpc-reflection")
+	defer wait()
+	defer a.Quit()
+
+	require.True(t, a.Config().Debug.GRPCReflection, "The gRPC reflection should be enabled by the flag")
+}
+
 func TestAutoDetectConfig(t *testing.T) {
 	customizedSocketPath := filepath.Join(t.TempDir(), "mysocket")
 	var config daemon.DaemonConfig
@@ -323,6 +534,41 @@ func TestNoConfigSetDefaults(t *testing.T) {
 	require.Equal(t, "", a.Config().Paths.Socket, "No socket address as default")
 }
 
+func TestSnapSocketPath(t *testing.T) {
+	tests := map[string]struct {
+		snapInstance     string
+		socketActivation bool
+		socket           string
+
+		want string
+	}{
+		"Socket_in_runtime_directory_of_snap":          {snapInstance: "authd", want: "/run/snap.authd/authd.sock"},
+		"Socket_in_runtime_directory_of_snap_instance": {snapInstance: "authd_test", want: "/run/snap.authd_test/authd.sock"},
+		"Configured_socket_is_kept_in_snap":            {snapInstance: "authd", socket: "/run/authd.sock", want: "/run/authd.sock"},
+		"Socket_activation_is_used_in_snap":            {snapInstance: "authd", socketActivation: true},
+		"Socket_activation_is_used_when_not_in_a_snap": {},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// We don't run the subtests in parallel, as they set environment variables.
+			t.Setenv("SNAP_INSTANCE_NAME", tc.snapInstance)
+			if tc.socketActivation {
+				t.Setenv("LISTEN_FDS", "1")
+			}
+
+			configPath := filepath.Join(t.TempDir(), "authd.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(fmt.Sprintf("paths:\n  socket: %q\n", tc.socket)), 0600), "Setup: could not write configuration")
+
+			a := daemon.New()
+			// Use version to still run preExec to load the config but without running server
+			a.SetArgs("version", "--config", configPath)
+
+			require.NoError(t, a.Run(), "Run should not return an error")
+			require.Equal(t, tc.want, a.Config().Paths.Socket, "Socket path should be the expected one")
+		})
+	}
+}
+
 func TestBadConfigReturnsError(t *testing.T) {
 	a := daemon.New()
 	// Use version to still run preExec to load no config but without running server
@@ -332,6 +578,179 @@ func TestBadConfigReturnsError(t *testing.T) {
 	require.Error(t, err, "Run should return an error on config file")
 }
 
+func TestWritablePaths(t *testing.T) {
+	t.Parallel()
+
+	conf := daemon.DaemonConfig{
+		Paths:   daemon.SystemPaths{Cache: "/var/lib/authd", Socket: "/run/authd/authd.sock"},
+		Sockets: []daemon.SocketConfig{{Path: "/run/authd-nss/nss.sock"}},
+		LogFile: daemon.LogFileConfig{Path: "/var/log/authd/authd.log"},
+		Sandbox: daemon.SandboxConfig{WritablePaths: []string{"/srv/home"}},
+	}
+	conf.UsersConfig.AccountsServiceDir = "/var/lib/AccountsService"
+
+	got := daemon.WritablePaths(conf)
+	for _, want := range []string{"/var/lib/authd", "/run/authd", "/run/authd-nss", "/var/log/authd", "/var/lib/AccountsService", "/srv/home"} {
+		require.Contains(t, got, want, "The daemon should be able to write to %s", want)
+	}
+	require.NotContains(t, got, "", "Unset paths should not be writable")
+	require.NotContains(t, got, "/etc", "The local groups should only be replaced, not written to")
+
+	conf.Sandbox.WritablePaths = nil
+	require.NotContains(t, daemon.WritablePaths(conf), "/home", "The home directories should not be writable if they are not created")
+	conf.UsersConfig.CreateHomeDirs = true
+	require.Contains(t, daemon.WritablePaths(conf), "/home", "The home directories should be writable if they are created")
+}
+
+func TestConfigValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config string
+
+		wantErrContains string
+	}{
+		"Valid_configuration":                           {config: "verbosity: 1\npaths:\n  cache: /tmp\nUID_MIN: 1000000\nstale_users_retention_days: 30\n"},
+		"Valid_configuration_with_sockets":              {config: "sockets:\n  - path: /run/nss.sock\n    services: [nss]\n    mode: \"0644\"\n"},
+		"Valid_configuration_with_durations":            {config: "presync_interval: 1h\nuser_info_ttl: 720h\n"},
+		"Valid_configuration_with_sandbox":              {config: "sandbox:\n  enabled: false\n  writable_paths: [/srv/home]\n"},
+		"Valid_configuration_with_privilege_separation": {config: "privilege_separation:\n  enabled: true\n  user: authd-broker\n"},
+		"Valid_configuration_with_features":             {config: "features: {}\n"},
+		"Valid_configuration_with_snap_rules":           {config: "confine_snaps: true\nauthorization:\n  - methods: [authd.PAM/*]\n    snaps: [greeter]\n"},
+		"Error_on_unknown_option":                       {config: "stale_user_retention_days: 30\n", wantErrContains: "unknown options: stale_user_retention_days"},
+		"Error_on_unknown_nested_option":                {config: "paths:\n  sockets: /run/authd.sock\n", wantErrContains: "unknown options: paths.sockets"},
+		"Error_on_invalid_value":                        {config: "verbosity: high\n", wantErrContains: "cannot parse 'Verbosity'"},
+		"Error_on_negative_log_file_rotation":           {config: "log_file:\n  path: /tmp/authd.log\n  max_size: -1\n", wantErrContains: "log_file: max_size, max_age and max_backups can't be negative"},
+		"Error_on_unknown_log_format":                   {config: "log_format: xml\n", wantErrContains: "log_format: unknown format \"xml\""},
+		"Error_on_negative_shutdown_grace_period":       {config: "shutdown_grace_period: -1s\n", wantErrContains: "shutdown_grace_period: can't be negative"},
+		"Error_on_negative_session_idle_timeout":        {config: "session_idle_timeout: -1s\n", wantErrContains: "session_idle_timeout: can't be negative"},
+		"Error_on_negative_broker_calls_limit":          {config: "broker_calls:\n  max_queued: -1\n", wantErrContains: "broker_calls: max_concurrent, max_queued and queue_timeout can't be negative"},
+		"Error_on_negative_session_limit":               {config: "session_limits:\n  per_user: -1\n", wantErrContains: "session_limits: per_user and per_source can't be negative"},
+		"Error_on_relative_security_events_exec":        {config: "security_events:\n  exec: forward-event\n", wantErrContains: "security_events: exec: \"forward-event\" is not an absolute path"},
+		"Error_on_authorization_rule_without_users":     {config: "authorization:\n  - methods: [authd.UserService/GetMetrics]\n", wantErrContains: "authorization: rule 0: no uids, groups, clients nor snaps given"},
+		"Error_on_snap_rule_without_confinement":        {config: "authorization:\n  - methods: [authd.PAM/*]\n    snaps: [greeter]\n", wantErrContains: "authorization: the rules listing snaps require confine_snaps"},
+		"Error_on_tcp_listener_without_certificate":     {config: "tcp:\n  address: :9443\n  services: [pam]\n", wantErrContains: "tcp: services, cert, key and client_ca are required"},
+		"Error_on_tcp_listener_serving_nss":             {config: "tcp:\n  address: :9443\n  services: [nss]\n  cert: /c\n  key: /k\n  client_ca: /ca\n", wantErrContains: "tcp: the nss service can't be served over TCP"},
+		"Error_on_tracing_sample_ratio_above_1":         {config: "tracing:\n  sample_ratio: 2\n", wantErrContains: "tracing: sample_ratio must be between 0 and 1"},
+		"Error_on_privilege_separation_without_user":    {config: "privilege_separation:\n  enabled: true\n  user: \"\"\n", wantErrContains: "privilege_separation: no user given"},
+		"Error_on_unknown_feature":                      {config: "features:\n  doesnotexist: true\n", wantErrContains: "features: unknown features doesnotexist"},
+		"Error_on_negative_load_limit":                  {config: "load:\n  max_requests: -1\n", wantErrContains: "load: max_requests, max_enumerations, max_queued and queue_timeout can't be negative"},
+		"Error_on_negative_rate_limit":                  {config: "rate_limits:\n  others:\n    rate: -1\n", wantErrContains: "rate_limits: others"},
+		"Error_on_socket_without_path":                  {config: "sockets:\n  - services: [nss]\n", wantErrContains: "sockets[0]: no path given"},
+		"Error_on_socket_without_services":              {config: "sockets:\n  - path: /run/nss.sock\n", wantErrContains: "no services given"},
+		"Error_on_socket_with_non_octal_mode":           {config: "sockets:\n  - path: /run/nss.sock\n    services: [nss]\n    mode: \"0999\"\n", wantErrContains: "invalid mode"},
+		"Valid_configuration_with_socket_permissions":   {config: "socket_mode: \"0660\"\nsocket_group: root\n"},
+		"Error_on_relative_socket_path":                 {config: "paths:\n  socket: authd.sock\n", wantErrContains: "paths.socket: \"authd.sock\" is not an absolute path"},
+		"Error_on_invalid_socket_mode":                  {config: "socket_mode: \"0999\"\n", wantErrContains: "socket_mode: invalid mode"},
+		"Error_on_unknown_socket_group":                 {config: "socket_group: doesnotexist\n", wantErrContains: "socket_group:"},
+		"Error_on_socket_with_too_large_mode":           {config: "sockets:\n  - path: /run/nss.sock\n    services: [nss]\n    mode: \"7777\"\n", wantErrContains: "invalid mode"},
+
+		"Valid_configuration_with_userdb":      {config: "userdb:\n  enabled: true\n"},
+		"Error_on_relative_userdb_socket_path": {config: "userdb:\n  enabled: true\n  socket: com.ubuntu.authd\n", wantErrContains: "userdb.socket: \"com.ubuntu.authd\" is not an absolute path"},
+
+		"Valid_configuration_with_session_pending_timeout": {config: "session_pending_timeout: 10m\n"},
+		"Error_on_negative_session_pending_timeout":        {config: "session_pending_timeout: -1s\n", wantErrContains: "session_pending_timeout: can't be negative"},
+
+		"Valid_configuration_with_access_policy": {
+			config: "access_policy:\n  hours: [\"08:00-18:00\"]\n  outside_hours: step-up\n  hook: /usr/libexec/access-hook\n  hook_timeout: 2s\n",
+		},
+		"Error_on_invalid_access_policy_hours":    {config: "access_policy:\n  hours: [\"8h-18h\"]\n", wantErrContains: "access_policy: hours: invalid window"},
+		"Error_on_unknown_access_policy_decision": {config: "access_policy:\n  non_compliant: maybe\n", wantErrContains: "access_policy: non_compliant: unknown decision"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			configPath := filepath.Join(t.TempDir(), "authd.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(tc.config), 0600), "Setup: could not write configuration")
+
+			a := daemon.New()
+			// Use version to still run preExec to load the config but without running server
+			a.SetArgs("version", "--config", configPath)
+
+			err := a.Run()
+			if tc.wantErrContains == "" {
+				require.NoError(t, err, "Run should not return an error")
+				return
+			}
+			require.Error(t, err, "Run should return an error on invalid configuration")
+			require.ErrorContains(t, err, tc.wantErrContains, "Run should return a helpful error")
+			require.ErrorContains(t, err, configPath, "The error should mention the configuration file")
+		})
+	}
+}
+
+func TestConfigEnvAndDropIns(t *testing.T) {
+	tests := map[string]struct {
+		config  string
+		dropIns map[string]string
+		env     map[string]string
+
+		wantVerbosity   int
+		wantCacheDir    string
+		wantErrContains string
+	}{
+		"Expand_environment_variables":                   {config: "paths:\n  cache: ${AUTHD_TEST_CACHE}/cache\n", env: map[string]string{"AUTHD_TEST_CACHE": "/srv"}, wantCacheDir: "/srv/cache"},
+		"Expand_environment_variables_set_to_empty":      {config: "paths:\n  cache: /srv${AUTHD_TEST_CACHE}\n", env: map[string]string{"AUTHD_TEST_CACHE": ""}, wantCacheDir: "/srv"},
+		"Expand_environment_variables_to_numbers":        {config: "verbosity: ${AUTHD_TEST_VERBOSITY}\n", env: map[string]string{"AUTHD_TEST_VERBOSITY": "2"}, wantVerbosity: 2},
+		"Environment_variables_in_comments_are_ignored":  {config: "# Set it to ${AUTHD_TEST_UNSET}.\nverbosity: 1 # or ${AUTHD_TEST_UNSET}\n", wantVerbosity: 1},
+		"Drop-ins_override_the_configuration_file":       {config: "verbosity: 1\npaths:\n  cache: /srv\n", dropIns: map[string]string{"50-fleet.yaml": "verbosity: 2\n"}, wantVerbosity: 2, wantCacheDir: "/srv"},
+		"Drop-ins_are_applied_in_lexical_order":          {config: "verbosity: 1\n", dropIns: map[string]string{"10-first.yaml": "verbosity: 3\n", "20-second.yml": "verbosity: 2\n"}, wantVerbosity: 2},
+		"Drop-ins_merge_nested_options":                  {config: "paths:\n  cache: /srv\n", dropIns: map[string]string{"50-fleet.yaml": "paths:\n  brokersconf: /srv/brokers\n"}, wantCacheDir: "/srv"},
+		"Drop-ins_expand_environment_variables":          {dropIns: map[string]string{"50-fleet.yaml": "paths:\n  cache: ${AUTHD_TEST_CACHE}\n"}, env: map[string]string{"AUTHD_TEST_CACHE": "/srv"}, wantCacheDir: "/srv"},
+		"Files_without_yaml_extension_are_ignored":       {config: "verbosity: 1\n", dropIns: map[string]string{"50-fleet.yaml.dpkg-old": "verbosity: 2\n"}, wantVerbosity: 1},
+		"Error_on_unset_environment_variable":            {config: "paths:\n  cache: ${AUTHD_TEST_UNSET}/${AUTHD_TEST_UNSET2}\n", wantErrContains: "unset environment variables: AUTHD_TEST_UNSET, AUTHD_TEST_UNSET2"},
+		"Error_on_invalid_drop-in":                       {config: "verbosity: 1\n", dropIns: map[string]string{"50-fleet.yaml": "verbosity: [\n"}, wantErrContains: "50-fleet.yaml"},
+		"Error_on_unknown_option_in_drop-in":             {dropIns: map[string]string{"50-fleet.yaml": "stale_user_retention_days: 30\n"}, wantErrContains: "unknown options: stale_user_retention_days"},
+		"Error_on_unset_environment_variable_in_drop-in": {dropIns: map[string]string{"50-fleet.yaml": "paths:\n  cache: ${AUTHD_TEST_UNSET}\n"}, wantErrContains: "unset environment variables: AUTHD_TEST_UNSET"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// We don't run the subtests in parallel, as they set environment variables.
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			dir := t.TempDir()
+			configPath := filepath.Join(dir, "authd.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(tc.config), 0600), "Setup: could not write configuration")
+			dropInDir := filepath.Join(dir, "authd.conf.d")
+			require.NoError(t, os.Mkdir(dropInDir, 0700), "Setup: could not create drop-in directory")
+			for file, content := range tc.dropIns {
+				require.NoError(t, os.WriteFile(filepath.Join(dropInDir, file), []byte(content), 0600), "Setup: could not write drop-in")
+			}
+
+			a := daemon.New()
+			// Use version to still run preExec to load the config but without running server
+			a.SetArgs("version", "--config", configPath)
+
+			err := a.Run()
+			if tc.wantErrContains != "" {
+				require.Error(t, err, "Run should return an error on invalid configuration")
+				require.ErrorContains(t, err, tc.wantErrContains, "Run should return a helpful error")
+				return
+			}
+			require.NoError(t, err, "Run should not return an error")
+
+			if tc.wantCacheDir == "" {
+				tc.wantCacheDir = consts.DefaultCacheDir
+			}
+			require.Equal(t, tc.wantVerbosity, a.Config().Verbosity, "Verbosity should be the layered one")
+			require.Equal(t, tc.wantCacheDir, a.Config().Paths.Cache, "Cache directory should be the layered one")
+		})
+	}
+}
+
+func TestShippedConfig(t *testing.T) {
+	a := daemon.New()
+	// Use version to still run preExec to load the config but without running server
+	a.SetArgs("version", "--config", filepath.Join("..", "..", "..", "debian", "authd-config", "authd.yaml"))
+
+	require.NoError(t, a.Run(), "Run should not return an error with the shipped configuration")
+	require.Equal(t, 0, a.Config().Verbosity, "Default Verbosity")
+	require.Equal(t, consts.DefaultCacheDir, a.Config().Paths.Cache, "Default cache directory")
+}
+
 // requireGoroutineStarted starts a goroutine and blocks until it has been launched.
 func requireGoroutineStarted(t *testing.T, f func()) {
 	t.Helper()
@@ -348,10 +767,10 @@ func requireGoroutineStarted(t *testing.T, f func()) {
 
 // startDaemon prepares and starts the daemon in the background. The done function should be called
 // to wait for the daemon to stop.
-func startDaemon(t *testing.T, conf *daemon.DaemonConfig) (app *daemon.App, done func()) {
+func startDaemon(t *testing.T, conf *daemon.DaemonConfig, args ...string) (app *daemon.App, done func()) {
 	t.Helper()
 
-	a := daemon.NewForTests(t, conf)
+	a := daemon.NewForTests(t, conf, args...)
 
 	wg := sync.WaitGroup{}
 	wg.Add(1)
@@ -0,0 +1,58 @@
+package daemon
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/internal/consts"
+	"github.com/ubuntu/authd/internal/doctor"
+)
+
+func (a *App) installDoctor() {
+	cmd := &cobra.Command{
+		Use:                                                                                         "doctor",
+		Short:/*i18n.G(*/ "Checks the environment of the daemon and prints how to fix the problems", /*)*/
+		Args:                                                                                        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := doctorConfig(a.currentConfig())
+			if err != nil {
+				return err
+			}
+			if doctor.Print(cmd.OutOrStdout(), doctor.Run(cmd.Context(), config)) {
+				return errors.New("some checks failed")
+			}
+			return nil
+		},
+	}
+	a.rootCmd.AddCommand(cmd)
+}
+
+// doctorConfig returns the environment of the daemon of the configuration checked by the doctor.
+func doctorConfig(config daemonConfig) (doctor.Config, error) {
+	socketPath := config.Paths.Socket
+	if socketPath == "" {
+		socketPath = consts.DefaultSocketPath
+	}
+	mode, err := parseSocketMode(config.SocketMode)
+	if err != nil {
+		return doctor.Config{}, err
+	}
+	sockets := []doctor.Socket{{Path: socketPath, Mode: mode, Group: config.SocketGroup}}
+	for _, sc := range config.Sockets {
+		mode, err := parseSocketMode(sc.Mode)
+		if err != nil {
+			return doctor.Config{}, err
+		}
+		sockets = append(sockets, doctor.Socket{Path: sc.Path, Mode: mode, Group: sc.Group})
+	}
+
+	return doctor.Config{
+		Sockets:         sockets,
+		NSSwitchFile:    "/etc/nsswitch.conf",
+		PAMDir:          "/etc/pam.d",
+		BrokersConfPath: config.Paths.BrokersConf,
+		Brokers:         config.Brokers,
+		CacheDir:        config.Paths.Cache,
+		GroupFile:       "/etc/group",
+	}, nil
+}
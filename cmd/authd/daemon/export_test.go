@@ -3,15 +3,22 @@ package daemon
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/log"
 	"gopkg.in/yaml.v3"
 )
 
 type (
-	DaemonConfig = daemonConfig
-	SystemPaths  = systemPaths
+	DaemonConfig  = daemonConfig
+	SystemPaths   = systemPaths
+	SocketConfig  = socketConfig
+	LogFileConfig = logFileConfig
+	SandboxConfig = sandboxConfig
 )
 
 func NewForTests(t *testing.T, conf *DaemonConfig, args ...string) *App {
@@ -38,6 +45,12 @@ func GenerateTestConfig(t *testing.T, origConf *daemonConfig) string {
 	if conf.Verbosity == 0 {
 		conf.Verbosity = 2
 	}
+	if conf.LogFormat == "" {
+		conf.LogFormat = string(log.TextFormat)
+	}
+	if conf.ShutdownGracePeriod == 0 {
+		conf.ShutdownGracePeriod = defaultShutdownGracePeriod
+	}
 	if conf.Paths.Cache == "" {
 		conf.Paths.Cache = t.TempDir()
 		//nolint: gosec // This is a directory owned only by the current user for tests.
@@ -47,7 +60,15 @@ func GenerateTestConfig(t *testing.T, origConf *daemonConfig) string {
 	if conf.Paths.Socket == "" {
 		conf.Paths.Socket = filepath.Join(t.TempDir(), "authd.socket")
 	}
-	d, err := yaml.Marshal(conf)
+	if reflect.ValueOf(conf.UsersConfig).IsZero() {
+		conf.UsersConfig = users.DefaultConfig
+	}
+
+	// Use the same keys as when the configuration is loaded.
+	var confMap map[string]any
+	err := mapstructure.Decode(conf, &confMap)
+	require.NoError(t, err, "Setup: could not convert configuration for tests")
+	d, err := yaml.Marshal(confMap)
 	require.NoError(t, err, "Setup: could not marshal configuration for tests")
 
 	confPath := filepath.Join(t.TempDir(), "testconfig.yaml")
@@ -60,11 +81,16 @@ func GenerateTestConfig(t *testing.T, origConf *daemonConfig) string {
 // Config returns a DaemonConfig for tests.
 //
 //nolint:revive // DaemonConfig is a type alias for tests
-func (a App) Config() DaemonConfig {
-	return a.config
+func (a *App) Config() DaemonConfig {
+	return a.currentConfig()
 }
 
 // SetArgs set some arguments on root command for tests.
 func (a *App) SetArgs(args ...string) {
 	a.rootCmd.SetArgs(args)
 }
+
+// WritablePaths returns the paths the daemon can write to in the sandbox with the configuration.
+func WritablePaths(conf DaemonConfig) []string {
+	return writablePaths(conf)
+}
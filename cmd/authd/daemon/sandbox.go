@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"path/filepath"
+
+	"github.com/ubuntu/authd/internal/consts"
+	"github.com/ubuntu/authd/internal/users"
+)
+
+// defaultHomeDirsParent is the parent of the home directories the daemon creates or cleans up, if no writable path is
+// configured.
+const defaultHomeDirsParent = "/home"
+
+// writablePaths returns the paths the daemon, and the commands it runs, write to with the configuration. The local
+// groups are not among them, as their files are only replaced by the ones of their staging directory.
+func writablePaths(config daemonConfig) []string {
+	paths := []string{
+		config.Paths.Cache,
+		consts.DefaultCrashReportsDir,
+		// The commands run by the daemon write their output there if it's not read.
+		"/dev/null",
+	}
+	if config.Paths.Socket != "" {
+		paths = append(paths, filepath.Dir(config.Paths.Socket))
+	}
+	for _, s := range config.Sockets {
+		paths = append(paths, filepath.Dir(s.Path))
+	}
+	if config.UserDB.Enabled {
+		paths = append(paths, filepath.Dir(config.UserDB.Socket))
+	}
+	if config.LogFile.Path != "" {
+		// The rotated files are created next to the log file.
+		paths = append(paths, filepath.Dir(config.LogFile.Path))
+	}
+
+	u := config.UsersConfig
+	for _, p := range []string{u.AccountsServiceDir, u.LastlogFile, u.StaleUsersArchiveDir, u.HomeDirArchiveDir, u.MailSpoolDir, u.SudoersDir} {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if u.Lastlog2Database != "" {
+		// SQLite creates its journal next to the database.
+		paths = append(paths, filepath.Dir(u.Lastlog2Database))
+	}
+
+	cleanup := u.HomeDirCleanup != "" && u.HomeDirCleanup != users.HomeDirCleanupKeep
+	if len(config.Sandbox.WritablePaths) == 0 && (u.CreateHomeDirs || cleanup) {
+		paths = append(paths, defaultHomeDirsParent)
+	}
+
+	return append(paths, config.Sandbox.WritablePaths...)
+}
@@ -0,0 +1,20 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// snapInstanceEnv is the environment variable set by snapd to the name of the instance of the snap a process runs in.
+const snapInstanceEnv = "SNAP_INSTANCE_NAME"
+
+// snapSocketPath returns the path of the main socket when authd runs in a snap without socket activation. The strictly
+// confined snaps can only create their sockets in their own runtime directory, where the clients confined in the other
+// snaps reach it through the interfaces connected to authd.
+func snapSocketPath() (path string, ok bool) {
+	instance := os.Getenv(snapInstanceEnv)
+	if instance == "" || os.Getenv("LISTEN_FDS") != "" {
+		return "", false
+	}
+	return filepath.Join("/run", "snap."+instance, "authd.sock"), true
+}
@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/internal/brokers"
+	"github.com/ubuntu/authd/internal/brokers/worker"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// installBrokerWorker installs the hidden command run by the unprivileged worker started by the daemon.
+func (a *App) installBrokerWorker() {
+	cmd := &cobra.Command{
+		Use:                                                  worker.Command,
+		Short:/*i18n.G(*/ "Calls the brokers for the daemon", /*)*/
+		Args:                                                 cobra.NoArgs,
+		Hidden:                                               true,
+		// The worker doesn't read the configuration of the daemon, which it can't access anyway.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			log.InitJournalHandler(false)
+			a.rootCmd.SilenceUsage = true
+			// There is no daemon to stop: the worker exits once the daemon closes the connection.
+			close(a.ready)
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The daemon passes its end of the connection as the first extra file descriptor.
+			return brokers.ServeWorker(context.Background(), os.NewFile(3, "daemon"))
+		},
+	}
+	a.rootCmd.AddCommand(cmd)
+}
+
+// startBrokerWorker starts the worker calling the brokers as the user of the configuration.
+func startBrokerWorker(ctx context.Context, config privilegeSeparationConfig) (w *worker.Client, err error) {
+	defer decorate.OnError(&err, "privilege_separation")
+
+	u, err := user.Lookup(config.User)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UID of user %q: %v", u.Username, err)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GID of user %q: %v", u.Username, err)
+	}
+	if uid == 0 {
+		log.Warningf(ctx, "The broker worker runs as %q, which is root", u.Username)
+	}
+
+	return worker.Start(ctx, uint32(uid), uint32(gid))
+}
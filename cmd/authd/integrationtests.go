@@ -4,7 +4,6 @@ package main
 
 import (
 	"os"
-	"strings"
 
 	"github.com/ubuntu/authd/internal/services/permissions"
 	"github.com/ubuntu/authd/internal/users/localentries"
@@ -16,11 +15,9 @@ func init() {
 		permissions.Z_ForTests_DefaultCurrentUserAsRoot()
 	}
 
-	gpasswdArgs := os.Getenv("AUTHD_INTEGRATIONTESTS_GPASSWD_ARGS")
-	grpFilePath := os.Getenv("AUTHD_INTEGRATIONTESTS_GPASSWD_GRP_FILE_PATH")
-	if gpasswdArgs == "" || grpFilePath == "" {
-		panic("AUTHD_INTEGRATIONTESTS_GPASSWD_ARGS and AUTHD_INTEGRATIONTESTS_GPASSWD_GRP_FILE_PATH must be set")
+	grpFilePath := os.Getenv("AUTHD_INTEGRATIONTESTS_GROUP_FILE_PATH")
+	if grpFilePath == "" {
+		panic("AUTHD_INTEGRATIONTESTS_GROUP_FILE_PATH must be set")
 	}
-	localentries.Z_ForTests_SetGpasswdCmd(strings.Split(gpasswdArgs, " "))
 	localentries.Z_ForTests_SetGroupPath(grpFilePath)
 }
@@ -0,0 +1,67 @@
+// Package main implements mockbroker, a broker whose behavior is driven by a scenario, for authd to be tested against
+// it without the example broker built in.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/authd/examplebroker"
+	"github.com/ubuntu/authd/log"
+)
+
+var brokersDir string
+
+var rootCmd = &cobra.Command{
+	Use:   "mockbroker SCENARIO",
+	Short: "Broker driven by a scenario, for testing authd",
+	Long: `mockbroker is a broker whose users, authentication modes, delays and failures are described by a YAML scenario
+file, for authd to be tested against it.
+
+It owns the D-Bus name of the scenario on the system bus, which requires the D-Bus policy of
+com.ubuntu.authd.MockBroker.conf, and runs until it's interrupted.`,
+	Args: cobra.ExactArgs(1),
+	// We display usage error ourselves
+	SilenceErrors: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Command parsing has been successful. Returns to not print usage anymore.
+		cmd.Root().SilenceUsage = true
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := examplebroker.LoadScenario(args[0])
+		if err != nil {
+			return err
+		}
+
+		conn, err := examplebroker.StartScenarioBus(s, brokersDir)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		log.Infof(context.Background(), "Broker %q serving %d users on %s", s.Name, len(s.Users), s.DBusName)
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		<-ctx.Done()
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.Flags().StringVar(&brokersDir, "brokers-dir", "",
+		"directory to write the configuration file of the broker to, like /etc/authd/brokers.d")
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		if !rootCmd.SilenceUsage {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
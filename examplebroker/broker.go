@@ -4,10 +4,14 @@ package examplebroker
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/aes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha512"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
@@ -26,8 +30,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/authd/internal/brokers/challenge"
 	"github.com/ubuntu/authd/internal/brokers/layouts"
 	"github.com/ubuntu/authd/internal/brokers/layouts/entries"
+	"github.com/ubuntu/authd/internal/consttime"
 	"github.com/ubuntu/authd/log"
 	"golang.org/x/exp/slices"
 )
@@ -74,6 +80,12 @@ type sessionInfo struct {
 
 	qrcodeSelections int
 	totpSelections   int
+
+	// privateKeys decrypt the next challenge of the session. They are rotated after each challenge.
+	privateKeys []crypto.PrivateKey
+
+	// platformChallenge is the challenge signed by the platform authenticator of the device in the selected mode.
+	platformChallenge []byte
 }
 
 type isAuthenticatedCtx struct {
@@ -89,10 +101,25 @@ type Broker struct {
 	userLastSelectedModeMu sync.Mutex
 	isAuthenticatedCalls   map[string]isAuthenticatedCtx
 	isAuthenticatedCallsMu sync.Mutex
-
-	privateKey *rsa.PrivateKey
+	// deviceTokens are the device tokens issued to the users after a multi-factor authentication, by user name.
+	deviceTokens   map[string]string
+	deviceTokensMu sync.Mutex
+	// platformAuthenticators are the public keys of the platform authenticators registered by the users, by user name.
+	platformAuthenticators   map[string]*ecdsa.PublicKey
+	platformAuthenticatorsMu sync.Mutex
+	// platformKey simulates the key held by the platform authenticator of the device, like a TPM or a FIDO2 device.
+	// It's only a demo: the key is generated in memory by the broker and the registrations are lost on restart, so it
+	// doesn't prove anything about the device.
+	platformKey *ecdsa.PrivateKey
+
+	privateKey       *rsa.PrivateKey
+	x25519PrivateKey *ecdh.PrivateKey
 
 	sleepMultiplier float64
+
+	// scenario drives the users, the authentication modes and the failures of the broker instead of the example
+	// users, if set.
+	scenario *scenarioState
 }
 
 type userInfoBroker struct {
@@ -235,6 +262,28 @@ var (
 	codeMode = qrCodeModeBase("codewithtypo", "Use a Login code",
 		"Enter the code in the login page")
 
+	// The platform authenticator modes demonstrate a passwordless login with a key bound to the device. The example
+	// broker doesn't talk to any TPM or FIDO2 device: the key and the user verification are simulated.
+	platformAuthenticatorMode = authMode{
+		id:             "platformauthenticator",
+		selectionLabel: "Use the platform authenticator of this device",
+		ui: map[string]string{
+			layouts.Type:  layouts.Form,
+			layouts.Label: "Confirm your identity with the platform authenticator of this device",
+			layouts.Wait:  layouts.True,
+		},
+	}
+
+	platformEnrolmentMode = authMode{
+		id:             "platformenrolment",
+		selectionLabel: "Register the platform authenticator of this device",
+		ui: map[string]string{
+			layouts.Type:  layouts.Form,
+			layouts.Label: "Confirm your identity with the platform authenticator of this device to log in without password",
+			layouts.Wait:  layouts.True,
+		},
+	}
+
 	// Not implemented yet.
 	webViewMode = authMode{
 		id: "webview",
@@ -248,6 +297,14 @@ func New(name string) (b *Broker, fullName, brandIcon string) {
 	if err != nil {
 		panic(fmt.Sprintf("could not create an valid rsa key: %v", err))
 	}
+	x25519PrivateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		panic(fmt.Sprintf("could not create an valid x25519 key: %v", err))
+	}
+	platformKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(fmt.Sprintf("could not create an valid platform authenticator key: %v", err))
+	}
 
 	sleepMultiplier := 1.0
 	if v := os.Getenv("AUTHD_EXAMPLE_BROKER_SLEEP_MULTIPLIER"); v != "" {
@@ -270,7 +327,11 @@ func New(name string) (b *Broker, fullName, brandIcon string) {
 		userLastSelectedModeMu: sync.Mutex{},
 		isAuthenticatedCalls:   make(map[string]isAuthenticatedCtx),
 		isAuthenticatedCallsMu: sync.Mutex{},
+		deviceTokens:           make(map[string]string),
+		platformAuthenticators: make(map[string]*ecdsa.PublicKey),
+		platformKey:            platformKey,
 		privateKey:             privateKey,
+		x25519PrivateKey:       x25519PrivateKey,
 		sleepMultiplier:        sleepMultiplier,
 	}, strings.ReplaceAll(name, "_", " "), fmt.Sprintf("/usr/share/brokers/%s.png", name)
 }
@@ -288,7 +349,38 @@ func (b *Broker) NewSession(ctx context.Context, username, lang, mode string) (s
 		attemptsPerMode: make(map[string]int),
 	}
 
-	switch username {
+	if b.scenario != nil {
+		if err := b.scenario.inject(ctx, "NewSession", username); err != nil {
+			return "", "", err
+		}
+		if err := b.scenario.configureSession(&info); err != nil {
+			return "", "", err
+		}
+	} else if err := configureExampleSession(&info); err != nil {
+		return "", "", err
+	}
+
+	// Offer a key for each version of the encryption of the challenges, the daemon gives the client the one it supports.
+	var keys []string
+	for _, k := range []crypto.PrivateKey{b.x25519PrivateKey, b.privateKey} {
+		key, err := encodePublicKey(k)
+		if err != nil {
+			return "", "", err
+		}
+		keys = append(keys, key)
+		info.privateKeys = append(info.privateKeys, k)
+	}
+
+	b.currentSessionsMu.Lock()
+	b.currentSessions[sessionID] = info
+	b.currentSessionsMu.Unlock()
+	return sessionID, strings.Join(keys, challenge.KeysSeparator), nil
+}
+
+// configureExampleSession sets the authentication steps of the session of the example user, which can be created on
+// the fly for the integration tests. It returns an error if the user can't exist.
+func configureExampleSession(info *sessionInfo) error {
+	switch info.username {
 	case "user-mfa":
 		info.neededAuthSteps = 3
 	case "user-needs-reset":
@@ -305,7 +397,7 @@ func (b *Broker) NewSession(ctx context.Context, username, lang, mode string) (s
 		info.neededAuthSteps = 3
 		info.pwdChange = canReset
 	case "user-unexistent":
-		return "", "", fmt.Errorf("user %q does not exist", username)
+		return fmt.Errorf("user %q does not exist", info.username)
 	}
 
 	if info.sessionMode == auth.SessionModePasswd {
@@ -315,48 +407,40 @@ func (b *Broker) NewSession(ctx context.Context, username, lang, mode string) (s
 
 	exampleUsersMu.Lock()
 	defer exampleUsersMu.Unlock()
-	if _, ok := exampleUsers[username]; !ok && strings.HasPrefix(username, "user-integration") {
-		exampleUsers[username] = userInfoBroker{Password: "goodpass"}
+	if _, ok := exampleUsers[info.username]; !ok && strings.HasPrefix(info.username, "user-integration") {
+		exampleUsers[info.username] = userInfoBroker{Password: "goodpass"}
 	}
 
-	if _, ok := exampleUsers[username]; !ok && strings.HasPrefix(username, "user-mfa-integration") {
-		exampleUsers[username] = userInfoBroker{Password: "goodpass"}
+	if _, ok := exampleUsers[info.username]; !ok && strings.HasPrefix(info.username, "user-mfa-integration") {
+		exampleUsers[info.username] = userInfoBroker{Password: "goodpass"}
 		info.neededAuthSteps = 3
 	}
 
-	if _, ok := exampleUsers[username]; !ok && strings.HasPrefix(username, "user-mfa-needs-reset-integration") {
-		exampleUsers[username] = userInfoBroker{Password: "goodpass"}
+	if _, ok := exampleUsers[info.username]; !ok && strings.HasPrefix(info.username, "user-mfa-needs-reset-integration") {
+		exampleUsers[info.username] = userInfoBroker{Password: "goodpass"}
 		info.neededAuthSteps = 3
 		info.pwdChange = mustReset
 	}
 
-	if _, ok := exampleUsers[username]; !ok && strings.HasPrefix(username, "user-mfa-with-reset-integration") {
-		exampleUsers[username] = userInfoBroker{Password: "goodpass"}
+	if _, ok := exampleUsers[info.username]; !ok && strings.HasPrefix(info.username, "user-mfa-with-reset-integration") {
+		exampleUsers[info.username] = userInfoBroker{Password: "goodpass"}
 		info.neededAuthSteps = 3
 		info.pwdChange = canReset
 	}
 
-	if _, ok := exampleUsers[username]; !ok && strings.HasPrefix(username, "user-needs-reset-integration") {
-		exampleUsers[username] = userInfoBroker{Password: "goodpass"}
+	if _, ok := exampleUsers[info.username]; !ok && strings.HasPrefix(info.username, "user-needs-reset-integration") {
+		exampleUsers[info.username] = userInfoBroker{Password: "goodpass"}
 		info.neededAuthSteps = 2
 		info.pwdChange = mustReset
 	}
 
-	if _, ok := exampleUsers[username]; !ok && strings.HasPrefix(username, "user-can-reset-integration") {
-		exampleUsers[username] = userInfoBroker{Password: "goodpass"}
+	if _, ok := exampleUsers[info.username]; !ok && strings.HasPrefix(info.username, "user-can-reset-integration") {
+		exampleUsers[info.username] = userInfoBroker{Password: "goodpass"}
 		info.neededAuthSteps = 2
 		info.pwdChange = canReset
 	}
 
-	pubASN1, err := x509.MarshalPKIXPublicKey(&b.privateKey.PublicKey)
-	if err != nil {
-		return "", "", err
-	}
-
-	b.currentSessionsMu.Lock()
-	b.currentSessions[sessionID] = info
-	b.currentSessionsMu.Unlock()
-	return sessionID, base64.StdEncoding.EncodeToString(pubASN1), nil
+	return nil
 }
 
 // GetAuthenticationModes returns the list of supported authentication modes for the selected broker depending on session info.
@@ -365,9 +449,15 @@ func (b *Broker) GetAuthenticationModes(ctx context.Context, sessionID string, s
 	if err != nil {
 		return nil, err
 	}
+	if err := b.inject(ctx, "GetAuthenticationModes", sessionInfo.username); err != nil {
+		return nil, err
+	}
 
 	log.Debugf(ctx, "Supported UI layouts by %s, %#v", sessionID, supportedUILayouts)
-	allModes := getSupportedModes(sessionInfo, supportedUILayouts)
+	allModes := getSupportedModes(sessionInfo, supportedUILayouts, b.hasPlatformAuthenticator(sessionInfo.username))
+	if b.scenario != nil {
+		allModes = b.scenario.filterModes(sessionInfo.username, allModes)
+	}
 
 	// If the user needs mfa, we remove the last used mode from the list of available modes.
 	if sessionInfo.currentAuthStep > 1 && sessionInfo.currentAuthStep <= sessionInfo.neededAuthSteps {
@@ -395,7 +485,7 @@ func (b *Broker) GetAuthenticationModes(ctx context.Context, sessionID string, s
 
 	var allModeIDs []string
 	for n := range allModes {
-		if n == passwordMode.id || n == lastSelection {
+		if n == passwordMode.id || n == platformAuthenticatorMode.id || n == lastSelection {
 			continue
 		}
 		allModeIDs = append(allModeIDs, n)
@@ -405,6 +495,10 @@ func (b *Broker) GetAuthenticationModes(ctx context.Context, sessionID string, s
 	if _, exists := allModes[passwordMode.id]; exists {
 		allModeIDs = append([]string{passwordMode.id}, allModeIDs...)
 	}
+	// The users who registered a platform authenticator log in without password by default.
+	if _, exists := allModes[platformAuthenticatorMode.id]; exists && lastSelection != platformAuthenticatorMode.id {
+		allModeIDs = append([]string{platformAuthenticatorMode.id}, allModeIDs...)
+	}
 	if lastSelection != "" && lastSelection != passwordMode.id {
 		allModeIDs = append([]string{lastSelection}, allModeIDs...)
 	}
@@ -426,7 +520,7 @@ func (b *Broker) GetAuthenticationModes(ctx context.Context, sessionID string, s
 	return authenticationModes, nil
 }
 
-func getSupportedModes(sessionInfo sessionInfo, supportedUILayouts []map[string]string) map[string]authMode {
+func getSupportedModes(sessionInfo sessionInfo, supportedUILayouts []map[string]string, platformAuthenticator bool) map[string]authMode {
 	allModes := make(map[string]authMode)
 	for _, layout := range supportedUILayouts {
 		switch layout[layouts.Type] {
@@ -456,6 +550,9 @@ func getSupportedModes(sessionInfo sessionInfo, supportedUILayouts []map[string]
 				allModes[phoneAck1Mode.id] = phoneAck1Mode
 				allModes[phoneAck2Mode.id] = phoneAck2Mode
 				allModes[fidoDeviceMode.id] = fidoDeviceMode
+				if platformAuthenticator {
+					allModes[platformAuthenticatorMode.id] = platformAuthenticatorMode
+				}
 			}
 
 		case layouts.QrCode:
@@ -493,6 +590,12 @@ func getMfaModes(info sessionInfo, supportedModes map[string]authMode) map[strin
 func getPasswdResetModes(info sessionInfo, supportedUILayouts []map[string]string) map[string]authMode {
 	passwdResetModes := make(map[string]authMode)
 	for _, layout := range supportedUILayouts {
+		// The users changing their credentials can register the platform authenticator of the device instead of a
+		// new password.
+		if info.sessionMode == auth.SessionModePasswd && layout[layouts.Type] == layouts.Form && layout[layouts.Wait] != "" {
+			passwdResetModes[platformEnrolmentMode.id] = platformEnrolmentMode
+			continue
+		}
 		if layout[layouts.Type] != layouts.NewPassword {
 			continue
 		}
@@ -546,6 +649,9 @@ func (b *Broker) SelectAuthenticationMode(ctx context.Context, sessionID, authen
 	if err != nil {
 		return nil, err
 	}
+	if err := b.inject(ctx, "SelectAuthenticationMode", sessionInfo.username); err != nil {
+		return nil, err
+	}
 
 	authenticationMode, exists := sessionInfo.allModes[authenticationModeName]
 	if !exists {
@@ -569,6 +675,12 @@ func (b *Broker) SelectAuthenticationMode(ctx context.Context, sessionID, authen
 		// send request to sessionInfo.allModes[authenticationModeName].phone
 	case fidoDeviceMode.id:
 		// start transaction with fido device
+	case platformAuthenticatorMode.id, platformEnrolmentMode.id:
+		// generate the challenge the platform authenticator has to sign
+		sessionInfo.platformChallenge = make([]byte, 32)
+		if _, err := rand.Read(sessionInfo.platformChallenge); err != nil {
+			return nil, err
+		}
 	case qrCodeAndCodeMode.id, codeMode.id:
 		uiLayoutInfo[layouts.Content], uiLayoutInfo[layouts.Code] = qrcodeData(&sessionInfo)
 	case qrCodeMode.id:
@@ -624,8 +736,21 @@ func (b *Broker) IsAuthenticated(ctx context.Context, sessionID, authenticationD
 		b.isAuthenticatedCallsMu.Unlock()
 	}()
 
+	if err := b.inject(ctx, "IsAuthenticated", sessionInfo.username); errors.Is(err, context.Canceled) {
+		return auth.Cancelled, "", nil
+	} else if err != nil {
+		return "", "", err
+	}
+
 	access, data = b.handleIsAuthenticated(ctx, sessionInfo, authData)
-	if access == auth.Granted && sessionInfo.currentAuthStep < sessionInfo.neededAuthSteps {
+	// The second factor is skipped on a device trusted at a previous multi-factor authentication of the user, unless
+	// the access policy of the machine requires all the factors.
+	multiFactorOnly := sessionInfo.sessionMode == auth.SessionModeAuth && sessionInfo.pwdChange == noReset &&
+		sessionInfo.neededAuthSteps > 1 && authData["access_decision"] != "step-up"
+	trustedDevice := multiFactorOnly && b.isDeviceTrusted(sessionInfo.username, authData["device_token"])
+	// The platform authenticator is the only factor needed, as it checks both the device and the user.
+	passwordless := multiFactorOnly && sessionInfo.currentAuthMode == platformAuthenticatorMode.id
+	if access == auth.Granted && sessionInfo.currentAuthStep < sessionInfo.neededAuthSteps && !trustedDevice && !passwordless {
 		sessionInfo.currentAuthStep++
 		access = auth.Next
 		data = ""
@@ -636,6 +761,21 @@ func (b *Broker) IsAuthenticated(ctx context.Context, sessionID, authenticationD
 		}
 	}
 
+	// Each challenge of the session is encrypted with its own key, so that the compromise of one of them doesn't expose
+	// the other ones.
+	if access == auth.Retry || access == auth.Next {
+		if data, err = rotateEncryptionKey(&sessionInfo, data, authData["challenge"]); err != nil {
+			return auth.Denied, "", err
+		}
+	}
+
+	// Trust the device after a complete multi-factor authentication, for the next logins to skip the second factor.
+	if access == auth.Granted && multiFactorOnly && !trustedDevice && !passwordless {
+		if data, err = b.trustDevice(sessionInfo.username, data); err != nil {
+			return auth.Denied, "", err
+		}
+	}
+
 	// Store last successful authentication mode for this user in the broker.
 	if access == auth.Granted {
 		b.userLastSelectedModeMu.Lock()
@@ -656,14 +796,12 @@ func (b *Broker) sleepDuration(in time.Duration) time.Duration {
 
 func (b *Broker) handleIsAuthenticated(ctx context.Context, sessionInfo sessionInfo, authData map[string]string) (access, data string) {
 	// Decrypt secret if present.
-	secret, err := decodeRawSecret(b.privateKey, authData["challenge"])
+	secret, err := decodeRawSecret(sessionInfo.privateKeys, authData["challenge"])
 	if err != nil {
 		return auth.Retry, fmt.Sprintf(`{"message": "could not decode secret: %v"}`, err)
 	}
 
-	exampleUsersMu.Lock()
-	user, userExists := exampleUsers[sessionInfo.username]
-	exampleUsersMu.Unlock()
+	password, userExists := b.userPassword(sessionInfo.username)
 	if !userExists {
 		return auth.Denied, `{"message": "user not found"}`
 	}
@@ -674,20 +812,20 @@ func (b *Broker) handleIsAuthenticated(ctx context.Context, sessionInfo sessionI
 	// Take into account the cancellation.
 	switch sessionInfo.currentAuthMode {
 	case passwordMode.id:
-		expectedSecret := user.Password
+		expectedSecret := password
 
-		if secret != expectedSecret {
+		if !consttime.Equal(secret, expectedSecret) {
 			return auth.Retry, fmt.Sprintf(`{"message": "invalid password '%s', should be '%s'"}`, secret, expectedSecret)
 		}
 
 	case pinCodeMode.id:
-		if secret != "4242" {
+		if !consttime.Equal(secret, "4242") {
 			return auth.Retry, `{"message": "invalid pincode, should be 4242"}`
 		}
 
 	case totpWithButtonMode.id, totpMode.id:
 		wantedCode := sessionInfo.allModes[sessionInfo.currentAuthMode].wantedCode
-		if secret != wantedCode {
+		if !consttime.Equal(secret, wantedCode) {
 			return auth.Retry, `{"message": "invalid totp code"}`
 		}
 
@@ -728,6 +866,31 @@ func (b *Broker) handleIsAuthenticated(ctx context.Context, sessionInfo sessionI
 			return auth.Cancelled, ""
 		}
 
+	case platformAuthenticatorMode.id, platformEnrolmentMode.id:
+		if authData[layouts.Wait] != layouts.True {
+			return auth.Denied, fmt.Sprintf(`{"message": "%s should have wait set to true"}`, sessionInfo.currentAuthMode)
+		}
+		// simulate the user verification by the platform authenticator, with a fingerprint or a PIN
+		select {
+		case <-time.After(sleepDuration):
+		case <-ctx.Done():
+			return auth.Cancelled, ""
+		}
+
+		signature, err := b.platformSign(sessionInfo.platformChallenge)
+		if err != nil {
+			return auth.Denied, fmt.Sprintf(`{"message": "platform authenticator failed: %v"}`, err)
+		}
+		if sessionInfo.currentAuthMode == platformEnrolmentMode.id {
+			if err := b.registerPlatformAuthenticator(sessionInfo.username, &b.platformKey.PublicKey, sessionInfo.platformChallenge, signature); err != nil {
+				return auth.Denied, fmt.Sprintf(`{"message": "could not register platform authenticator: %v"}`, err)
+			}
+			break
+		}
+		if !b.verifyPlatformAssertion(sessionInfo.username, sessionInfo.platformChallenge, signature) {
+			return auth.Denied, `{"message": "invalid platform authenticator assertion"}`
+		}
+
 	case qrCodeMode.id, qrCodeAndCodeMode.id, codeMode.id:
 		if authData[layouts.Wait] != layouts.True {
 			return auth.Denied, fmt.Sprintf(`{"message": "%s should have wait set to true"}`, sessionInfo.currentAuthMode)
@@ -748,23 +911,21 @@ func (b *Broker) handleIsAuthenticated(ctx context.Context, sessionInfo sessionI
 		expectedSecret := "authd2404"
 		// Reset the password to default if it had already been changed.
 		// As at PAM level we'd refuse a previous password to be re-used.
-		if user.Password == expectedSecret {
+		if consttime.Equal(password, expectedSecret) {
 			expectedSecret = "goodpass"
 		}
 
-		if secret != expectedSecret {
+		if !consttime.Equal(secret, expectedSecret) {
 			return auth.Retry, fmt.Sprintf(`{"message": "new password does not match criteria: must be '%s'"}`, expectedSecret)
 		}
-		exampleUsersMu.Lock()
-		exampleUsers[sessionInfo.username] = userInfoBroker{Password: secret}
-		exampleUsersMu.Unlock()
+		b.setUserPassword(sessionInfo.username, secret)
 
 	// this case name was dynamically generated
 	case emailMode(sessionInfo.username).id:
 		// do we have a secret sent or should we just wait?
 		if secret != "" {
 			// validate secret given manually by the user
-			if secret != "aaaaa" {
+			if !consttime.Equal(secret, "aaaaa") {
 				return auth.Denied, `{"message": "invalid secret, should be aaaaa"}`
 			}
 		} else if authData[layouts.Wait] == layouts.True {
@@ -780,21 +941,140 @@ func (b *Broker) handleIsAuthenticated(ctx context.Context, sessionInfo sessionI
 		}
 	}
 
-	return auth.Granted, fmt.Sprintf(`{"userinfo": %s}`, userInfoFromName(sessionInfo.username))
+	return auth.Granted, fmt.Sprintf(`{"userinfo": %s}`, b.userInfo(sessionInfo.username))
 }
 
-// decodeRawSecret extract the base64 secret and try to decrypt it with the private key.
-func decodeRawSecret(priv *rsa.PrivateKey, rawSecret string) (string, error) {
+// rotateEncryptionKey generates a new key for the next challenge of the session, of the same kind as the one the
+// client encrypted the last challenge with, and adds its public part to the data of the reply. The key is not rotated
+// if no challenge was sent or if the data is not a JSON object.
+func rotateEncryptionKey(sessionInfo *sessionInfo, data, rawSecret string) (string, error) {
 	if rawSecret == "" {
-		return "", nil
+		return data, nil
+	}
+	reply := make(map[string]string)
+	if data != "" {
+		if err := json.Unmarshal([]byte(data), &reply); err != nil {
+			return data, nil
+		}
+	}
+
+	var privateKey crypto.PrivateKey
+	var err error
+	switch challenge.VersionOf(rawSecret) {
+	case challenge.VersionX25519:
+		privateKey, err = ecdh.X25519().GenerateKey(rand.Reader)
+	default:
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not create a new encryption key: %v", err)
+	}
+	if reply["encryption_key"], err = encodePublicKey(privateKey); err != nil {
+		return "", err
+	}
+
+	d, err := json.Marshal(reply)
+	if err != nil {
+		return "", err
+	}
+	sessionInfo.privateKeys = []crypto.PrivateKey{privateKey}
+	return string(d), nil
+}
+
+// isDeviceTrusted returns true if the device token was issued to the user.
+func (b *Broker) isDeviceTrusted(username, token string) bool {
+	b.deviceTokensMu.Lock()
+	defer b.deviceTokensMu.Unlock()
+	issued, ok := b.deviceTokens[username]
+	return ok && token != "" && consttime.Equal(issued, token)
+}
+
+// trustDevice issues a new device token to the user, and adds it to the data of the granted reply.
+func (b *Broker) trustDevice(username, data string) (string, error) {
+	reply := make(map[string]json.RawMessage)
+	if err := json.Unmarshal([]byte(data), &reply); err != nil {
+		return "", err
 	}
 
-	ciphertext, err := base64.StdEncoding.DecodeString(rawSecret)
+	t := make([]byte, 32)
+	if _, err := rand.Read(t); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(t)
+	reply["device_token"] = json.RawMessage(strconv.Quote(token))
+
+	d, err := json.Marshal(reply)
+	if err != nil {
+		return "", err
+	}
+	b.deviceTokensMu.Lock()
+	b.deviceTokens[username] = token
+	b.deviceTokensMu.Unlock()
+	return string(d), nil
+}
+
+// platformSign signs the challenge with the platform authenticator of the device, simulated by the key of the broker.
+func (b *Broker) platformSign(challenge []byte) ([]byte, error) {
+	if len(challenge) == 0 {
+		return nil, errors.New("no challenge to sign")
+	}
+	digest := sha256.Sum256(challenge)
+	return ecdsa.SignASN1(rand.Reader, b.platformKey, digest[:])
+}
+
+// registerPlatformAuthenticator registers the key of a platform authenticator for the user, replacing the previous one,
+// once it proved it owns it by signing the challenge.
+func (b *Broker) registerPlatformAuthenticator(username string, key *ecdsa.PublicKey, challenge, signature []byte) error {
+	digest := sha256.Sum256(challenge)
+	if !ecdsa.VerifyASN1(key, digest[:], signature) {
+		return errors.New("invalid signature of the challenge")
+	}
+	b.platformAuthenticatorsMu.Lock()
+	defer b.platformAuthenticatorsMu.Unlock()
+	b.platformAuthenticators[username] = key
+	return nil
+}
+
+// hasPlatformAuthenticator returns true if the user registered a platform authenticator.
+func (b *Broker) hasPlatformAuthenticator(username string) bool {
+	b.platformAuthenticatorsMu.Lock()
+	defer b.platformAuthenticatorsMu.Unlock()
+	_, ok := b.platformAuthenticators[username]
+	return ok
+}
+
+// verifyPlatformAssertion returns true if the challenge was signed by the platform authenticator registered by the user.
+func (b *Broker) verifyPlatformAssertion(username string, challenge, signature []byte) bool {
+	b.platformAuthenticatorsMu.Lock()
+	key, ok := b.platformAuthenticators[username]
+	b.platformAuthenticatorsMu.Unlock()
+	if !ok {
+		return false
+	}
+	digest := sha256.Sum256(challenge)
+	return ecdsa.VerifyASN1(key, digest[:], signature)
+}
+
+// encodePublicKey returns the public part of the private key, encoded in base64 in PKIX format.
+func encodePublicKey(priv crypto.PrivateKey) (string, error) {
+	k, ok := priv.(interface{ Public() crypto.PublicKey })
+	if !ok {
+		return "", fmt.Errorf("unsupported private key %T", priv)
+	}
+	pubASN1, err := x509.MarshalPKIXPublicKey(k.Public())
 	if err != nil {
 		return "", err
 	}
+	return base64.StdEncoding.EncodeToString(pubASN1), nil
+}
+
+// decodeRawSecret extract the secret and try to decrypt it with the private keys.
+func decodeRawSecret(keys []crypto.PrivateKey, rawSecret string) (string, error) {
+	if rawSecret == "" {
+		return "", nil
+	}
 
-	plaintext, err := rsa.DecryptOAEP(sha512.New(), nil, priv, ciphertext, nil)
+	plaintext, err := challenge.Decrypt(rawSecret, keys...)
 	if err != nil {
 		return "", err
 	}
@@ -804,7 +1084,11 @@ func decodeRawSecret(priv *rsa.PrivateKey, rawSecret string) (string, error) {
 
 // EndSession ends the requested session and triggers the necessary clean up steps, if any.
 func (b *Broker) EndSession(ctx context.Context, sessionID string) error {
-	if _, err := b.sessionInfo(sessionID); err != nil {
+	sessionInfo, err := b.sessionInfo(sessionID)
+	if err != nil {
+		return err
+	}
+	if err := b.inject(ctx, "EndSession", sessionInfo.username); err != nil {
 		return err
 	}
 
@@ -839,13 +1123,41 @@ func (b *Broker) cancelIsAuthenticatedUnlocked(_ context.Context, sessionID stri
 
 // UserPreCheck checks if the user is known to the broker.
 func (b *Broker) UserPreCheck(ctx context.Context, username string) (string, error) {
-	if strings.HasPrefix(username, "user-integration-pre-check") {
+	if err := b.inject(ctx, "UserPreCheck", username); err != nil {
+		return "", err
+	}
+	if b.scenario == nil && strings.HasPrefix(username, "user-integration-pre-check") {
 		return userInfoFromName(username), nil
 	}
-	if _, exists := exampleUsers[username]; !exists {
+	if _, exists := b.userPassword(username); !exists {
 		return "", fmt.Errorf("user %q does not exist", username)
 	}
-	return userInfoFromName(username), nil
+	return b.userInfo(username), nil
+}
+
+// ListUsers returns the user information of all the example users, or of the users of the scenario.
+func (b *Broker) ListUsers(ctx context.Context) (string, error) {
+	if err := b.inject(ctx, "ListUsers", ""); err != nil {
+		return "", err
+	}
+
+	var names []string
+	if b.scenario != nil {
+		names = b.scenario.usernames()
+	} else {
+		exampleUsersMu.RLock()
+		for name := range exampleUsers {
+			names = append(names, name)
+		}
+		exampleUsersMu.RUnlock()
+		sort.Strings(names)
+	}
+
+	var users []string
+	for _, name := range names {
+		users = append(users, b.userInfo(name))
+	}
+	return "[" + strings.Join(users, ", ") + "]", nil
 }
 
 // decryptAES is just here to illustrate the encryption and decryption
@@ -892,6 +1204,44 @@ func decryptAES(key []byte, ct string) string {
 	return string(pt[:])
 }
 
+// inject delays the call of the method for the user, or makes it fail, as the scenario of the broker requires.
+func (b *Broker) inject(ctx context.Context, method, username string) error {
+	if b.scenario == nil {
+		return nil
+	}
+	return b.scenario.inject(ctx, method, username)
+}
+
+// userPassword returns the current password of the user, and whether the user is known to the broker.
+func (b *Broker) userPassword(username string) (string, bool) {
+	if b.scenario != nil {
+		return b.scenario.password(username)
+	}
+	exampleUsersMu.RLock()
+	defer exampleUsersMu.RUnlock()
+	user, ok := exampleUsers[username]
+	return user.Password, ok
+}
+
+// setUserPassword changes the password of the user.
+func (b *Broker) setUserPassword(username, password string) {
+	if b.scenario != nil {
+		b.scenario.setPassword(username, password)
+		return
+	}
+	exampleUsersMu.Lock()
+	defer exampleUsersMu.Unlock()
+	exampleUsers[username] = userInfoBroker{Password: password}
+}
+
+// userInfo returns the stringified user information of the user, with the groups the scenario gives them.
+func (b *Broker) userInfo(username string) string {
+	if b.scenario != nil {
+		return userInfoFromName(username, b.scenario.groups(username)...)
+	}
+	return userInfoFromName(username)
+}
+
 // sessionInfo returns the session information for the specified session ID or an error if the session is not active.
 func (b *Broker) sessionInfo(sessionID string) (sessionInfo, error) {
 	b.currentSessionsMu.RLock()
@@ -915,13 +1265,14 @@ func (b *Broker) updateSession(sessionID string, info sessionInfo) error {
 	return nil
 }
 
-// userInfoFromName transform a given name to the strinfigy userinfo string.
-func userInfoFromName(name string) string {
-	type groupJSONInfo struct {
-		Name string
-		UGID string
-	}
+type groupJSONInfo struct {
+	Name string
+	UGID string
+}
 
+// userInfoFromName transform a given name to the strinfigy userinfo string, with the extra groups added to the ones of
+// the user.
+func userInfoFromName(name string, extraGroups ...groupJSONInfo) string {
 	user := struct {
 		Name   string
 		UUID   string
@@ -937,6 +1288,7 @@ func userInfoFromName(name string) string {
 		Groups: []groupJSONInfo{{Name: "group-" + name, UGID: "ugid-" + name}},
 		Gecos:  "gecos for " + name,
 	}
+	user.Groups = append(user.Groups, extraGroups...)
 
 	switch name {
 	case "user-local-groups":
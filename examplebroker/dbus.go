@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/godbus/dbus/v5"
 	"github.com/godbus/dbus/v5/introspect"
@@ -28,20 +29,48 @@ type Bus struct {
 func StartBus(cfgPath string) (conn *dbus.Conn, err error) {
 	defer decorate.OnError(&err, "could not start example broker bus")
 
+	b, _, _ := New("ExampleBroker")
+	return exportBroker(b, busName, dbusObjectPath, filepath.Join(cfgPath, "examplebroker.conf"), fmt.Sprintf(`[authd]
+name = ExampleBroker
+brand_icon = /usr/share/backgrounds/warty-final-ubuntu.png
+dbus_name = %s
+dbus_object = %s
+`, busName, dbusObjectPath))
+}
+
+// StartScenarioBus starts the D-Bus service of a broker driven by the scenario and exports it on the system bus. The
+// configuration file of the broker for authd is written to cfgPath, unless it's empty.
+func StartScenarioBus(s Scenario, cfgPath string) (conn *dbus.Conn, err error) {
+	defer decorate.OnError(&err, "could not start the bus of broker %q", s.Name)
+
+	var cfgFile string
+	if cfgPath != "" {
+		cfgFile = filepath.Join(cfgPath, strings.ToLower(strings.ReplaceAll(s.Name, " ", "_"))+".conf")
+	}
+	return exportBroker(NewWithScenario(s), s.DBusName, dbus.ObjectPath(s.DBusObject), cfgFile, fmt.Sprintf(`[authd]
+name = %s
+brand_icon = %s
+dbus_name = %s
+dbus_object = %s
+`, s.Name, s.BrandIcon, s.DBusName, s.DBusObject))
+}
+
+// exportBroker exports the broker on the system bus with the name and the object path, and writes its configuration
+// to cfgFile, unless it's empty.
+func exportBroker(b *Broker, name string, objectPath dbus.ObjectPath, cfgFile, cfg string) (conn *dbus.Conn, err error) {
 	conn, err = dbus.ConnectSystemBus()
 	if err != nil {
 		return nil, err
 	}
 
-	b, _, _ := New("ExampleBroker")
 	obj := Bus{broker: b}
-	err = conn.Export(&obj, dbusObjectPath, dbusInterface)
+	err = conn.Export(&obj, objectPath, dbusInterface)
 	if err != nil {
 		return nil, err
 	}
 
 	if err = conn.Export(introspect.NewIntrospectable(&introspect.Node{
-		Name: dbusObjectPath,
+		Name: string(objectPath),
 		Interfaces: []introspect.Interface{
 			introspect.IntrospectData,
 			{
@@ -49,11 +78,11 @@ func StartBus(cfgPath string) (conn *dbus.Conn, err error) {
 				Methods: introspect.Methods(&obj),
 			},
 		},
-	}), dbusObjectPath, introspect.IntrospectData.Name); err != nil {
+	}), objectPath, introspect.IntrospectData.Name); err != nil {
 		return nil, err
 	}
 
-	reply, err := conn.RequestName(busName, dbus.NameFlagDoNotQueue)
+	reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue)
 	if err != nil {
 		return nil, err
 	}
@@ -61,14 +90,10 @@ func StartBus(cfgPath string) (conn *dbus.Conn, err error) {
 		return nil, errors.New("D-Bus name already taken")
 	}
 
-	if err = os.WriteFile(filepath.Join(cfgPath, "examplebroker.conf"),
-		[]byte(fmt.Sprintf(`[authd]
-name = ExampleBroker
-brand_icon = /usr/share/backgrounds/warty-final-ubuntu.png
-dbus_name = %s
-dbus_object = %s
-`, busName, dbusObjectPath)),
-		0600); err != nil {
+	if cfgFile == "" {
+		return conn, nil
+	}
+	if err = os.WriteFile(cfgFile, []byte(cfg), 0600); err != nil {
 		return nil, err
 	}
 
@@ -134,3 +159,12 @@ func (b *Bus) UserPreCheck(username string) (userinfo string, dbusErr *dbus.Erro
 	}
 	return userinfo, nil
 }
+
+// ListUsers is the method through which the broker and the daemon will communicate once dbusInterface.ListUsers is called.
+func (b *Bus) ListUsers() (usersinfo string, dbusErr *dbus.Error) {
+	usersinfo, err := b.broker.ListUsers(context.Background())
+	if err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+	return usersinfo, nil
+}
@@ -0,0 +1,309 @@
+package examplebroker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/decorate"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultScenarioName is the name of the broker driven by a scenario which doesn't set it.
+	DefaultScenarioName = "MockBroker"
+	// DefaultScenarioDBusName is the D-Bus name of the broker driven by a scenario which doesn't set it.
+	DefaultScenarioDBusName = "com.ubuntu.authd.MockBroker"
+	// DefaultScenarioDBusObject is the D-Bus object of the broker driven by a scenario which doesn't set it.
+	DefaultScenarioDBusObject = "/com/ubuntu/authd/MockBroker"
+)
+
+// emailModeID is the ID of the email authentication mode in the scenarios, as its actual ID depends on the user.
+const emailModeID = "email"
+
+// scenarioMethods are the methods of the broker whose calls can be delayed or made to fail by a scenario.
+var scenarioMethods = []string{
+	"NewSession",
+	"GetAuthenticationModes",
+	"SelectAuthenticationMode",
+	"IsAuthenticated",
+	"EndSession",
+	"UserPreCheck",
+	"ListUsers",
+}
+
+// Scenario drives the broker, for authd to be tested against the users, the authentication modes and the failures
+// chosen by the tests rather than the ones of the example broker.
+type Scenario struct {
+	// Name is the name of the broker shown to the users, DefaultScenarioName if empty.
+	Name string
+	// BrandIcon is the path of the icon of the broker.
+	BrandIcon string `yaml:"brand_icon"`
+	// DBusName is the name the broker owns on the system bus, DefaultScenarioDBusName if empty.
+	DBusName string `yaml:"dbus_name"`
+	// DBusObject is the path of the object of the broker on the system bus, DefaultScenarioDBusObject if empty.
+	DBusObject string `yaml:"dbus_object"`
+
+	// Users are the only users known to the broker, by name.
+	Users map[string]ScenarioUser
+	// Delays are how long the calls of each method, by name, take before being handled.
+	Delays map[string]time.Duration
+	// Failures make some calls of the broker fail, the first one matching a call applying to it.
+	Failures []ScenarioFailure
+}
+
+// ScenarioUser is a user known to a broker driven by a scenario.
+type ScenarioUser struct {
+	// Password is the password of the user, "goodpass" if empty.
+	Password string
+	// Modes are the IDs of the authentication modes offered to the user, among the ones of the example broker, all of
+	// them if empty. "email" is the mode sending a link to the email address of the user.
+	Modes []string
+	// Steps is the number of authentication modes the user must complete, 1 if 0. The modes after the first one are
+	// only the multi-factor ones: totp_with_button, phoneack1 and fidodevice1.
+	Steps int
+	// PasswordReset is "can" or "must" if the user can or must change their password after authenticating.
+	PasswordReset string `yaml:"password_reset"`
+	// Groups are added to the group of the user. The local groups have no UGID.
+	Groups []ScenarioGroup
+}
+
+// ScenarioGroup is a group of a user known to a broker driven by a scenario.
+type ScenarioGroup struct {
+	Name string
+	UGID string
+}
+
+// ScenarioFailure makes some calls of a method of the broker fail.
+type ScenarioFailure struct {
+	// Method is the name of the method whose calls fail, like "IsAuthenticated".
+	Method string
+	// User restricts the failure to the calls for this user, all the calls failing if empty.
+	User string
+	// Error is the message of the error returned by the calls.
+	Error string
+	// Times is the number of calls failing, after which the calls succeed again, all of them if 0.
+	Times int
+}
+
+// LoadScenario returns the scenario of the YAML file at path, with its defaults set.
+func LoadScenario(path string) (s Scenario, err error) {
+	defer decorate.OnError(&err, "could not load scenario %q", path)
+
+	d, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(d))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return s, err
+	}
+
+	if s.Name == "" {
+		s.Name = DefaultScenarioName
+	}
+	if s.DBusName == "" {
+		s.DBusName = DefaultScenarioDBusName
+	}
+	if s.DBusObject == "" {
+		s.DBusObject = DefaultScenarioDBusObject
+	}
+	for name, u := range s.Users {
+		if u.Password == "" {
+			u.Password = "goodpass"
+		}
+		if u.Steps == 0 {
+			u.Steps = 1
+		}
+		s.Users[name] = u
+	}
+
+	return s, s.validate()
+}
+
+// validate returns an error if the scenario refers to an unknown method or authentication mode, or if a value is out
+// of range.
+func (s Scenario) validate() error {
+	if !dbus.ObjectPath(s.DBusObject).IsValid() {
+		return fmt.Errorf("dbus_object: %q is not a valid D-Bus object path", s.DBusObject)
+	}
+	if len(s.Users) == 0 {
+		return errors.New("no users")
+	}
+
+	modes := []string{emailModeID}
+	for _, m := range []authMode{passwordMode, pinCodeMode, totpMode, totpWithButtonMode, phoneAck1Mode, phoneAck2Mode,
+		fidoDeviceMode, qrCodeMode, qrCodeAndCodeMode, codeMode, platformAuthenticatorMode} {
+		modes = append(modes, m.id)
+	}
+	for name, u := range s.Users {
+		for _, m := range u.Modes {
+			if !slices.Contains(modes, m) {
+				return fmt.Errorf("user %q: unknown authentication mode %q, must be one of %v", name, m, modes)
+			}
+		}
+		if u.Steps < 0 {
+			return fmt.Errorf("user %q: steps can't be negative", name)
+		}
+		if u.PasswordReset != "" && u.PasswordReset != "can" && u.PasswordReset != "must" {
+			return fmt.Errorf("user %q: password_reset must be \"can\" or \"must\"", name)
+		}
+	}
+
+	for method, d := range s.Delays {
+		if !slices.Contains(scenarioMethods, method) {
+			return fmt.Errorf("delays: unknown method %q, must be one of %v", method, scenarioMethods)
+		}
+		if d < 0 {
+			return fmt.Errorf("delays: delay of %s can't be negative", method)
+		}
+	}
+
+	for i, f := range s.Failures {
+		if !slices.Contains(scenarioMethods, f.Method) {
+			return fmt.Errorf("failure %d: unknown method %q, must be one of %v", i, f.Method, scenarioMethods)
+		}
+		if f.Error == "" {
+			return fmt.Errorf("failure %d: no error given", i)
+		}
+		if f.Times < 0 {
+			return fmt.Errorf("failure %d: times can't be negative", i)
+		}
+	}
+
+	return nil
+}
+
+// scenarioState is the state of a broker driven by a scenario.
+type scenarioState struct {
+	Scenario
+
+	mu sync.Mutex
+	// passwords are the current passwords of the users, which change when they reset them.
+	passwords map[string]string
+	// failedCalls is the number of calls each failure of the scenario applied to, by index.
+	failedCalls map[int]int
+}
+
+// NewWithScenario creates a new broker driven by the scenario.
+func NewWithScenario(s Scenario) *Broker {
+	b, _, _ := New(s.Name)
+
+	passwords := make(map[string]string)
+	for name, u := range s.Users {
+		passwords[name] = u.Password
+	}
+	b.scenario = &scenarioState{Scenario: s, passwords: passwords, failedCalls: make(map[int]int)}
+	return b
+}
+
+// inject delays the call of the method for the user as the scenario requires, and returns the error of the first
+// failure of the scenario applying to it, if any. It returns the error of the context if it's done while delaying.
+func (s *scenarioState) inject(ctx context.Context, method, username string) error {
+	if d := s.Delays[method]; d > 0 {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.Failures {
+		if f.Method != method || (f.User != "" && f.User != username) {
+			continue
+		}
+		if f.Times > 0 && s.failedCalls[i] >= f.Times {
+			continue
+		}
+		s.failedCalls[i]++
+		return errors.New(f.Error)
+	}
+	return nil
+}
+
+// configureSession sets the authentication steps of the session of the user of the scenario. It returns an error if
+// the user is unknown.
+func (s *scenarioState) configureSession(info *sessionInfo) error {
+	u, ok := s.Users[info.username]
+	if !ok {
+		return fmt.Errorf("user %q does not exist", info.username)
+	}
+
+	info.neededAuthSteps = u.Steps
+	switch u.PasswordReset {
+	case "can":
+		info.neededAuthSteps++
+		info.pwdChange = canReset
+	case "must":
+		info.neededAuthSteps++
+		info.pwdChange = mustReset
+	}
+
+	if info.sessionMode == auth.SessionModePasswd {
+		info.neededAuthSteps++
+		info.pwdChange = mustReset
+	}
+	return nil
+}
+
+// filterModes returns the modes among the supported ones which the scenario offers to the user.
+func (s *scenarioState) filterModes(username string, supported map[string]authMode) map[string]authMode {
+	u := s.Users[username]
+	if len(u.Modes) == 0 {
+		return supported
+	}
+
+	modes := make(map[string]authMode)
+	for id, m := range supported {
+		if slices.Contains(u.Modes, id) || (id == emailMode(username).id && slices.Contains(u.Modes, emailModeID)) {
+			modes[id] = m
+		}
+	}
+	return modes
+}
+
+// password returns the current password of the user of the scenario, and whether the user exists.
+func (s *scenarioState) password(username string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.passwords[username]
+	return p, ok
+}
+
+// setPassword changes the password of the user of the scenario.
+func (s *scenarioState) setPassword(username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.passwords[username] = password
+}
+
+// groups returns the groups of the scenario added to the ones of the user.
+func (s *scenarioState) groups(username string) []groupJSONInfo {
+	var groups []groupJSONInfo
+	for _, g := range s.Users[username].Groups {
+		groups = append(groups, groupJSONInfo{Name: g.Name, UGID: g.UGID})
+	}
+	return groups
+}
+
+// usernames returns the names of the users of the scenario, sorted.
+func (s *scenarioState) usernames() []string {
+	var names []string
+	for name := range s.Users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
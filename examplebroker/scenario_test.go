@@ -0,0 +1,189 @@
+package examplebroker_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/examplebroker"
+	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/authd/internal/brokers/challenge"
+	"github.com/ubuntu/authd/internal/brokers/layouts"
+	"github.com/ubuntu/authd/internal/brokers/layouts/entries"
+	"github.com/ubuntu/authd/internal/testutils"
+)
+
+const testScenario = `
+users:
+  user1:
+    password: user1pass
+    modes: [password]
+    groups:
+      - name: group1
+        ugid: "12345"
+  user2:
+    modes: [pincode]
+delays:
+  SelectAuthenticationMode: 10ms
+failures:
+  - method: NewSession
+    user: user2
+    error: the provider is unreachable
+    times: 1
+`
+
+func TestLoadScenario(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		scenario string
+
+		wantErr bool
+	}{
+		"Load_scenario_with_defaults": {scenario: "users:\n  user1: {}\n"},
+		"Load_scenario":               {scenario: testScenario},
+
+		"Error_if_the_file_does_not_exist":       {wantErr: true},
+		"Error_if_the_scenario_has_no_users":     {scenario: "name: SomeBroker\n", wantErr: true},
+		"Error_if_a_field_is_unknown":            {scenario: "users:\n  user1:\n    pasword: typo\n", wantErr: true},
+		"Error_if_a_mode_is_unknown":             {scenario: "users:\n  user1:\n    modes: [webauthn]\n", wantErr: true},
+		"Error_if_the_steps_are_negative":        {scenario: "users:\n  user1:\n    steps: -1\n", wantErr: true},
+		"Error_if_the_password_reset_is_unknown": {scenario: "users:\n  user1:\n    password_reset: always\n", wantErr: true},
+		"Error_if_the_object_path_is_invalid":    {scenario: "dbus_object: MockBroker\nusers:\n  user1: {}\n", wantErr: true},
+		"Error_if_a_delay_is_for_an_unknown_method": {
+			scenario: "users:\n  user1: {}\ndelays:\n  Authenticate: 1s\n", wantErr: true,
+		},
+		"Error_if_a_failure_is_for_an_unknown_method": {
+			scenario: "users:\n  user1: {}\nfailures:\n  - method: Authenticate\n    error: failed\n", wantErr: true,
+		},
+		"Error_if_a_failure_has_no_error": {
+			scenario: "users:\n  user1: {}\nfailures:\n  - method: NewSession\n", wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "scenario.yaml")
+			if tc.scenario != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tc.scenario), 0600), "Setup: could not write the scenario")
+			}
+
+			s, err := examplebroker.LoadScenario(path)
+			if tc.wantErr {
+				require.Error(t, err, "LoadScenario should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "LoadScenario should not return an error, but did")
+			require.Equal(t, examplebroker.DefaultScenarioName, s.Name, "The default name should be set")
+			require.Equal(t, examplebroker.DefaultScenarioDBusName, s.DBusName, "The default D-Bus name should be set")
+			require.Equal(t, examplebroker.DefaultScenarioDBusObject, s.DBusObject, "The default D-Bus object should be set")
+			require.Equal(t, 1, s.Users["user1"].Steps, "The default steps should be set")
+		})
+	}
+}
+
+func TestScenario(t *testing.T) {
+	t.Parallel()
+
+	b := examplebroker.NewWithScenario(loadTestScenario(t))
+	ctx := context.Background()
+	uiLayouts := []map[string]string{
+		{layouts.Type: layouts.Form, layouts.Entry: layouts.OptionalItems(entries.Chars, entries.CharsPassword, entries.Digits)},
+	}
+
+	_, _, err := b.NewSession(ctx, "user3", "C", auth.SessionModeAuth)
+	require.Error(t, err, "NewSession should fail for the users not in the scenario")
+
+	_, _, err = b.NewSession(ctx, "user2", "C", auth.SessionModeAuth)
+	require.ErrorContains(t, err, "the provider is unreachable", "NewSession should fail as the scenario requires")
+	sessionID, keys, err := b.NewSession(ctx, "user2", "C", auth.SessionModeAuth)
+	require.NoError(t, err, "NewSession should succeed once the failure of the scenario is over")
+	modes, err := b.GetAuthenticationModes(ctx, sessionID, uiLayouts)
+	require.NoError(t, err, "GetAuthenticationModes should not return an error, but did")
+	require.Equal(t, []map[string]string{{layouts.ID: "pincode", layouts.Label: "Pin code"}}, modes,
+		"Only the modes of the scenario should be offered")
+	require.NoError(t, b.EndSession(ctx, sessionID), "EndSession should not return an error, but did")
+
+	sessionID, keys, err = b.NewSession(ctx, "user1", "C", auth.SessionModeAuth)
+	require.NoError(t, err, "NewSession should not return an error, but did")
+	_, err = b.GetAuthenticationModes(ctx, sessionID, uiLayouts)
+	require.NoError(t, err, "GetAuthenticationModes should not return an error, but did")
+	start := time.Now()
+	_, err = b.SelectAuthenticationMode(ctx, sessionID, "password")
+	require.NoError(t, err, "SelectAuthenticationMode should not return an error, but did")
+	require.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond, "SelectAuthenticationMode should be delayed")
+
+	access, data, err := b.IsAuthenticated(ctx, sessionID, authData(t, keys, "goodpass"))
+	require.NoError(t, err, "IsAuthenticated should not return an error, but did")
+	require.Equal(t, auth.Retry, access, "The password of the example users should not be accepted")
+	// The next challenge is encrypted with the new key of the session.
+	var reply map[string]string
+	require.NoError(t, json.Unmarshal([]byte(data), &reply), "IsAuthenticated should return valid JSON")
+	access, data, err = b.IsAuthenticated(ctx, sessionID, authData(t, reply["encryption_key"], "user1pass"))
+	require.NoError(t, err, "IsAuthenticated should not return an error, but did")
+	require.Equal(t, auth.Granted, access, "The password of the scenario should be accepted")
+	require.Contains(t, data, `{"name": "group1", "ugid": "12345"}`, "The groups of the scenario should be returned")
+
+	usersInfo, err := b.ListUsers(ctx)
+	require.NoError(t, err, "ListUsers should not return an error, but did")
+	var users []struct{ Name string }
+	require.NoError(t, json.Unmarshal([]byte(usersInfo), &users), "ListUsers should return valid JSON")
+	require.Equal(t, []struct{ Name string }{{"user1"}, {"user2"}}, users, "ListUsers should return the users of the scenario")
+}
+
+func TestStartScenarioBus(t *testing.T) {
+	// The system bus mock is set in the environment of the whole test binary.
+	busCleanup, err := testutils.StartSystemBusMock()
+	require.NoError(t, err, "Setup: could not start the system bus mock")
+	t.Cleanup(busCleanup)
+
+	s := loadTestScenario(t)
+	cfgPath := t.TempDir()
+	conn, err := examplebroker.StartScenarioBus(s, cfgPath)
+	require.NoError(t, err, "StartScenarioBus should not return an error, but did")
+	t.Cleanup(func() { conn.Close() })
+
+	cfg, err := os.ReadFile(filepath.Join(cfgPath, "mockbroker.conf"))
+	require.NoError(t, err, "The configuration of the broker should be written")
+	require.Contains(t, string(cfg), "dbus_name = "+examplebroker.DefaultScenarioDBusName,
+		"The configuration should point to the broker on the bus")
+
+	client, err := testutils.GetSystemBusConnection(t)
+	require.NoError(t, err, "Setup: could not connect to the system bus mock")
+	t.Cleanup(func() { client.Close() })
+	var userInfo string
+	err = client.Object(s.DBusName, "/com/ubuntu/authd/MockBroker").Call("com.ubuntu.authd.Broker.UserPreCheck", 0, "user1").Store(&userInfo)
+	require.NoError(t, err, "The broker should answer on the bus")
+	require.Contains(t, userInfo, `"name": "user1"`, "The broker should return the user of the scenario")
+}
+
+// loadTestScenario returns the scenario of testScenario.
+func loadTestScenario(t *testing.T) examplebroker.Scenario {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testScenario), 0600), "Setup: could not write the scenario")
+	s, err := examplebroker.LoadScenario(path)
+	require.NoError(t, err, "Setup: could not load the scenario")
+	return s
+}
+
+// authData returns the authentication data sending the secret encrypted with the key of the broker.
+func authData(t *testing.T, keys, secret string) string {
+	t.Helper()
+
+	encoded, err := challenge.SelectKey(keys, challenge.VersionX25519)
+	require.NoError(t, err, "Setup: could not select the encryption key")
+	key, _, err := challenge.ParsePublicKey(encoded)
+	require.NoError(t, err, "Setup: could not parse the encryption key")
+	c, err := challenge.Encrypt(key, []byte(secret))
+	require.NoError(t, err, "Setup: could not encrypt the secret")
+	d, err := json.Marshal(map[string]string{"challenge": c})
+	require.NoError(t, err, "Setup: could not encode the authentication data")
+	return string(d)
+}
@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"hash/fnv"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/godbus/dbus/v5"
-	"github.com/ubuntu/authd/internal/brokers/auth"
 	"github.com/ubuntu/authd/internal/brokers/layouts"
+	"github.com/ubuntu/authd/internal/brokers/worker"
+	"github.com/ubuntu/authd/internal/crash"
 	"github.com/ubuntu/authd/internal/users/types"
 	"github.com/ubuntu/authd/log"
 	"github.com/ubuntu/decorate"
@@ -22,6 +25,20 @@ import (
 // LocalBrokerName is the name of the local broker.
 const LocalBrokerName = "local"
 
+const (
+	// maxExtendedAttributes is the maximum number of extended attributes a broker can attach to a user.
+	maxExtendedAttributes = 64
+	// maxExtendedAttributeValueSize is the maximum size in bytes of the value of an extended attribute.
+	maxExtendedAttributeValueSize = 4096
+)
+
+// extendedAttributeKeyRegexp matches the valid keys of extended attributes.
+var extendedAttributeKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,64}$`)
+
+// localGroupNameRegexp matches the valid names of the local groups joined by the users of a broker, as accepted by
+// groupadd by default.
+var localGroupNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_-]*\$?$`)
+
 type brokerer interface {
 	NewSession(ctx context.Context, username, lang, mode string) (sessionID, encryptionKey string, err error)
 	GetAuthenticationModes(ctx context.Context, sessionID string, supportedUILayouts []map[string]string) (authenticationModes []map[string]string, err error)
@@ -31,21 +48,68 @@ type brokerer interface {
 	CancelIsAuthenticated(ctx context.Context, sessionID string)
 
 	UserPreCheck(ctx context.Context, username string) (userinfo string, err error)
+	ListUsers(ctx context.Context) (usersinfo string, err error)
 }
 
+// ErrListUsersNotSupported is returned when the broker can't list the users it expects on the machine.
+var ErrListUsersNotSupported = errors.New("the broker does not support listing its users")
+
 // Broker represents a broker object that can be used for authentication.
 type Broker struct {
-	ID                    string
-	Name                  string
-	BrandIconPath         string
+	ID            string
+	Name          string
+	BrandIconPath string
+	// Users overrides the users configuration of the daemon for the users of this broker.
+	Users UsersConfig
+	// Sessions overrides the sessions configuration of the daemon for the sessions of this broker.
+	Sessions SessionsConfig
+
 	layoutValidators      map[string]map[string]layoutValidator
 	layoutValidatorsMu    *sync.Mutex
-	ongoingUserRequests   map[string]string
-	ongoingUserRequestsMu *sync.Mutex
+	ongoingSessionsInfo   map[string]*SessionInfo
+	ongoingSessionsInfoMu *sync.Mutex
 
 	brokerer brokerer
 }
 
+// UsersConfig is the configuration of the users of a broker, read from the optional users section of its configuration
+// file. Unset fields don't override the configuration of the daemon.
+type UsersConfig struct {
+	// CreateHomeDir, if set, overrides whether the home directories of the users are created at their first login.
+	CreateHomeDir *bool
+	// QuotaProfile is the name of the quota profile applied to the users.
+	QuotaProfile string
+	// DefaultShell is the login shell of the users if the broker doesn't provide one.
+	DefaultShell string
+	// ShadowAging overrides the password aging fields of the shadow entries of the users.
+	ShadowAging types.ShadowAging
+	// PrimaryGroup is the name of the group shared by the users as their primary group.
+	PrimaryGroup string
+	// LocalGroups are the local groups joined by all the users, in addition to the groups provided by the broker.
+	LocalGroups []string
+}
+
+// Apply overrides the user information provided by the broker with the users configuration of the broker. The default
+// shell is not applied, as it depends on the configuration of the daemon.
+func (c UsersConfig) Apply(u *types.UserInfo) {
+	u.ShadowAging = c.ShadowAging
+	u.PrimaryGroup = c.PrimaryGroup
+	for _, g := range c.LocalGroups {
+		if slices.ContainsFunc(u.Groups, func(group types.GroupInfo) bool { return group.Name == g }) {
+			continue
+		}
+		u.Groups = append(u.Groups, types.GroupInfo{Name: g})
+	}
+}
+
+// SessionsConfig is the configuration of the sessions of a broker, read from the optional sessions section of its
+// configuration file. Unset fields don't override the configuration of the daemon.
+type SessionsConfig struct {
+	// PendingTimeout, if set, overrides how long the sessions can wait for the user or the broker before being ended.
+	// 0 disables it for the sessions of this broker.
+	PendingTimeout *time.Duration
+}
+
 type layoutValidator map[string]fieldValidator
 
 type fieldValidator struct {
@@ -60,11 +124,13 @@ func newBroker(ctx context.Context, configFile string, bus *dbus.Conn) (b Broker
 	name := LocalBrokerName
 	id := LocalBrokerName
 	var brandIcon string
+	var usersConfig UsersConfig
+	var sessionsConfig SessionsConfig
 	var broker brokerer
 
 	if configFile != "" {
 		log.Debugf(ctx, "Loading broker from %q", configFile)
-		broker, name, brandIcon, err = newDbusBroker(ctx, bus, configFile)
+		broker, name, brandIcon, usersConfig, sessionsConfig, err = newDbusBroker(ctx, bus, configFile)
 		if err != nil {
 			return Broker{}, err
 		}
@@ -78,11 +144,13 @@ func newBroker(ctx context.Context, configFile string, bus *dbus.Conn) (b Broker
 		ID:                    id,
 		Name:                  name,
 		BrandIconPath:         brandIcon,
+		Users:                 usersConfig,
+		Sessions:              sessionsConfig,
 		brokerer:              broker,
 		layoutValidators:      make(map[string]map[string]layoutValidator),
 		layoutValidatorsMu:    &sync.Mutex{},
-		ongoingUserRequests:   make(map[string]string),
-		ongoingUserRequestsMu: &sync.Mutex{},
+		ongoingSessionsInfo:   make(map[string]*SessionInfo),
+		ongoingSessionsInfoMu: &sync.Mutex{},
 	}, nil
 }
 
@@ -97,9 +165,20 @@ func (b Broker) newSession(ctx context.Context, username, lang, mode string) (se
 		return "", "", errors.New("no session ID provided by broker")
 	}
 
-	b.ongoingUserRequestsMu.Lock()
-	b.ongoingUserRequests[sessionID] = username
-	b.ongoingUserRequestsMu.Unlock()
+	now := time.Now()
+	b.ongoingSessionsInfoMu.Lock()
+	b.ongoingSessionsInfo[sessionID] = &SessionInfo{
+		ID:         fmt.Sprintf("%s-%s", b.ID, sessionID),
+		Username:   username,
+		BrokerID:   b.ID,
+		BrokerName: b.Name,
+		Mode:       mode,
+		State:      SessionStarted,
+		Started:    now,
+		// The session starts active, even if the broker took long to create it.
+		LastActivity: now,
+	}
+	b.ongoingSessionsInfoMu.Unlock()
 
 	return fmt.Sprintf("%s-%s", b.ID, sessionID), encryptionKey, nil
 }
@@ -111,6 +190,7 @@ func (b *Broker) GetAuthenticationModes(ctx context.Context, sessionID string, s
 	b.layoutValidatorsMu.Lock()
 	b.layoutValidators[sessionID] = generateValidators(ctx, sessionID, supportedUILayouts)
 	b.layoutValidatorsMu.Unlock()
+	b.updateSession(sessionID, func(*SessionInfo) {})
 
 	authenticationModes, err = b.brokerer.GetAuthenticationModes(ctx, sessionID, supportedUILayouts)
 	if err != nil {
@@ -135,18 +215,33 @@ func (b Broker) SelectAuthenticationMode(ctx context.Context, sessionID, authent
 	if err != nil {
 		return nil, err
 	}
-	return b.validateUILayout(sessionID, uiLayoutInfo)
+	if uiLayoutInfo, err = b.validateUILayout(sessionID, uiLayoutInfo); err != nil {
+		return nil, err
+	}
+
+	b.updateSession(sessionID, func(s *SessionInfo) {
+		s.AuthMode = authenticationModeName
+		s.State = SessionModeSelected
+	})
+	return uiLayoutInfo, nil
 }
 
 // IsAuthenticated calls the broker corresponding method, stripping broker ID prefix from sessionID.
 func (b Broker) IsAuthenticated(ctx context.Context, sessionID, authenticationData string) (access string, data string, err error) {
 	sessionID = b.parseSessionID(sessionID)
 
+	b.updateSession(sessionID, func(s *SessionInfo) { s.State = SessionAuthenticating })
+	defer func() {
+		b.updateSession(sessionID, func(s *SessionInfo) { s.State = stateAfterAuthentication(access, err) })
+	}()
+
 	// monitor ctx in goroutine to call cancel
+	var reply worker.AuthenticationReply
 	done := make(chan struct{})
 	go func() {
-		access, data, err = b.brokerer.IsAuthenticated(ctx, sessionID, authenticationData)
-		close(done)
+		defer close(done)
+		defer crash.Recover(ctx, fmt.Sprintf("IsAuthenticated of broker %q", b.Name), &err)
+		reply, err = isAuthenticatedReply(ctx, b.brokerer, sessionID, authenticationData)
 	}()
 
 	select {
@@ -159,58 +254,21 @@ func (b Broker) IsAuthenticated(ctx context.Context, sessionID, authenticationDa
 		<-done
 	}
 
-	// Validate access authentication.
-	if !slices.Contains(auth.Replies, access) {
-		return "", "", fmt.Errorf("invalid access authentication key: %v", access)
-	}
-
-	if data == "" {
-		data = "{}"
-	}
-
-	switch access {
-	case auth.Granted:
-		rawUserInfo, err := unmarshalAndGetKey(data, "userinfo")
-		if err != nil {
-			return "", "", err
-		}
-
-		info, err := unmarshalUserInfo(rawUserInfo)
-		if err != nil {
-			return "", "", err
-		}
-
-		if err = validateUserInfo(info); err != nil {
-			return "", "", err
-		}
-
-		d, err := json.Marshal(info)
-		if err != nil {
-			return "", "", fmt.Errorf("can't marshal UserInfo: %v", err)
-		}
-		data = string(d)
-
-	case auth.Denied, auth.Retry:
-		if _, err := unmarshalAndGetKey(data, "message"); err != nil {
-			return "", "", err
-		}
-
-	case auth.Cancelled, auth.Next:
-		if data != "{}" {
-			return "", "", fmt.Errorf("access mode %q should not return any data, got: %v", access, data)
-		}
+	// The reply was decoded and validated, by the worker if the broker is called through it.
+	data, err = encodeAuthenticationReply(reply)
+	if err != nil {
+		return "", "", err
 	}
-
-	return access, data, nil
+	return reply.Access, data, nil
 }
 
 // endSession calls the broker corresponding method, stripping broker ID prefix from sessionID.
 func (b Broker) endSession(ctx context.Context, sessionID string) (err error) {
 	sessionID = b.parseSessionID(sessionID)
 
-	b.ongoingUserRequestsMu.Lock()
-	defer b.ongoingUserRequestsMu.Unlock()
-	delete(b.ongoingUserRequests, sessionID)
+	b.ongoingSessionsInfoMu.Lock()
+	defer b.ongoingSessionsInfoMu.Unlock()
+	delete(b.ongoingSessionsInfo, sessionID)
 
 	return b.brokerer.EndSession(ctx, sessionID)
 }
@@ -228,6 +286,34 @@ func (b Broker) UserPreCheck(ctx context.Context, username string) (userinfo str
 	return b.brokerer.UserPreCheck(ctx, username)
 }
 
+// UserPreCheckInfo calls UserPreCheck and returns the decoded and validated user information, which is empty if the
+// broker doesn't know the user.
+func (b Broker) UserPreCheckInfo(ctx context.Context, username string) (u types.UserInfo, err error) {
+	defer decorate.OnError(&err, "can't pre-check user %q with broker %q", username, b.Name)
+
+	userinfo, err := b.brokerer.UserPreCheck(ctx, username)
+	if err != nil || userinfo == "" {
+		return types.UserInfo{}, err
+	}
+
+	if u, err = unmarshalUserInfo(json.RawMessage(userinfo)); err != nil {
+		return types.UserInfo{}, err
+	}
+	if err := validateUserInfo(u); err != nil {
+		return types.UserInfo{}, err
+	}
+	return u, nil
+}
+
+// ListUsers returns the users the broker expects on the machine, in the format of the user information returned on
+// authentication. Listing the users is optional for the brokers: ErrListUsersNotSupported is returned if the broker
+// doesn't implement it. Invalid users are skipped.
+func (b Broker) ListUsers(ctx context.Context) (users []types.UserInfo, err error) {
+	defer decorate.OnError(&err, "can't list users of broker %q", b.Name)
+
+	return listUsersInfo(ctx, b.brokerer, b.Name)
+}
+
 // generateValidators generates layout validators based on what is supported by the system.
 //
 // The layout validators are in the form:
@@ -341,7 +427,8 @@ func validateUserInfo(uInfo types.UserInfo) (err error) {
 	if !filepath.IsAbs(filepath.Clean(uInfo.Dir)) {
 		return fmt.Errorf("value provided for homedir is not an absolute path: %s", uInfo.Dir)
 	}
-	if !filepath.IsAbs(filepath.Clean(uInfo.Shell)) {
+	// An empty shell is replaced by the default shell.
+	if uInfo.Shell != "" && !filepath.IsAbs(filepath.Clean(uInfo.Shell)) {
 		return fmt.Errorf("value provided for shell is not an absolute path: %s", uInfo.Shell)
 	}
 
@@ -352,9 +439,114 @@ func validateUserInfo(uInfo types.UserInfo) (err error) {
 		}
 	}
 
+	return validateExtendedAttributes(uInfo.ExtendedAttributes)
+}
+
+// validateExtendedAttributes checks that the extended attributes of a user have valid keys and don't exceed the
+// size limits.
+func validateExtendedAttributes(attributes map[string]string) error {
+	if len(attributes) > maxExtendedAttributes {
+		return fmt.Errorf("too many extended attributes: %d, the maximum is %d", len(attributes), maxExtendedAttributes)
+	}
+	for k, v := range attributes {
+		if !extendedAttributeKeyRegexp.MatchString(k) {
+			return fmt.Errorf("invalid extended attribute key %q", k)
+		}
+		if len(v) > maxExtendedAttributeValueSize {
+			return fmt.Errorf("value of extended attribute %q is too long: %d bytes, the maximum is %d", k, len(v), maxExtendedAttributeValueSize)
+		}
+	}
 	return nil
 }
 
+// encryptionKeyDataKey is the key of the data of the retry and next replies holding the new encryption key of the
+// session, when the broker rotates it.
+const (
+	encryptionKeyDataKey = "encryption_key"
+	deviceTokenDataKey   = "device_token"
+)
+
+// accessDecisionDataKey and accessReasonsDataKey are the keys of the authentication data holding the decision of the
+// conditional access policy and its reasons.
+const (
+	accessDecisionDataKey = "access_decision"
+	accessReasonsDataKey  = "access_reasons"
+)
+
+// RotatedEncryptionKey returns the encryption key the broker rotated to in the data of a retry or next reply, for the
+// next challenges of the session not to be encrypted with the same key as the previous ones, and the data without it.
+// The key is empty if the broker didn't rotate it, and the data is then returned unchanged.
+func RotatedEncryptionKey(data string) (key, remaining string, err error) {
+	return cutStringKey(data, encryptionKeyDataKey, "encryption key")
+}
+
+// DeviceToken returns the device token the broker issued to the user in the data of a granted reply, to be presented
+// to the broker at the next logins of the user on this machine for it to skip the second factor, and the data without
+// it. The token is empty if the broker didn't issue one, and the data is then returned unchanged.
+func DeviceToken(data string) (token, remaining string, err error) {
+	return cutStringKey(data, deviceTokenDataKey, "device token")
+}
+
+// WithDeviceToken returns the authentication data, or the data of a granted reply, with the device token.
+func WithDeviceToken(data, token string) (string, error) {
+	return withValues(data, map[string]string{deviceTokenDataKey: token}, "device token")
+}
+
+// WithAccessContext returns the authentication data with the decision of the conditional access policy and its
+// reasons, for the broker to take them into account, like by asking for all the factors of the user on a step-up.
+func WithAccessContext(data, decision, reasons string) (string, error) {
+	return withValues(data, map[string]string{accessDecisionDataKey: decision, accessReasonsDataKey: reasons}, "access context")
+}
+
+// withValues returns the data with the values of the keys.
+func withValues(data string, values map[string]string, name string) (string, error) {
+	var d map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &d); err != nil {
+		return "", fmt.Errorf("data is not a valid json: %v", err)
+	}
+	if d == nil {
+		d = make(map[string]json.RawMessage)
+	}
+
+	for k, v := range values {
+		rawValue, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		d[k] = rawValue
+	}
+	withData, err := json.Marshal(d)
+	if err != nil {
+		return "", fmt.Errorf("can't marshal data with the %s: %v", name, err)
+	}
+	return string(withData), nil
+}
+
+// cutStringKey returns the value of the key of the data returned by the broker, which must be a non-empty string if
+// it's set, and the data without it. The value is empty if the key is not set, and the data is then returned
+// unchanged.
+func cutStringKey(data, key, name string) (value, remaining string, err error) {
+	var returnedData map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &returnedData); err != nil {
+		return "", "", fmt.Errorf("response returned by the broker is not a valid json: %v\nBroker returned: %v", err, data)
+	}
+
+	rawValue, ok := returnedData[key]
+	if !ok {
+		return "", data, nil
+	}
+	if err := json.Unmarshal(rawValue, &value); err != nil || value == "" {
+		return "", "", fmt.Errorf("invalid %s returned by the broker, got: %s", name, rawValue)
+	}
+
+	delete(returnedData, key)
+	d, err := json.Marshal(returnedData)
+	if err != nil {
+		return "", "", fmt.Errorf("can't marshal data without the %s: %v", name, err)
+	}
+	return value, string(d), nil
+}
+
 // unmarshalAndGetKey tries to unmarshal the content in data and returns the value of the requested key.
 func unmarshalAndGetKey(data, key string) (json.RawMessage, error) {
 	var returnedData map[string]json.RawMessage
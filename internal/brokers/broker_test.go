@@ -3,6 +3,7 @@ package brokers_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -15,6 +16,7 @@ import (
 	"github.com/ubuntu/authd/internal/brokers/layouts"
 	"github.com/ubuntu/authd/internal/testutils"
 	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users/types"
 )
 
 var supportedLayouts = map[string]map[string]string{
@@ -49,6 +51,7 @@ func TestNewBroker(t *testing.T) {
 	}{
 		"No_config_means_local_broker":                        {configFile: "-"},
 		"Successfully_create_broker_with_correct_config_file": {configFile: "valid.conf"},
+		"Successfully_create_broker_with_optional_sections":   {configFile: "valid_2.conf"},
 
 		// General config errors
 		"Error_when_config_file_is_invalid":     {configFile: "invalid.conf", wantErr: true},
@@ -59,6 +62,15 @@ func TestNewBroker(t *testing.T) {
 		"Error_when_config_does_not_have_brand_icon_field":  {configFile: "no_brand_icon.conf", wantErr: true},
 		"Error_when_config_does_not_have_dbus_name_field":   {configFile: "no_dbus_name.conf", wantErr: true},
 		"Error_when_config_does_not_have_dbus_object_field": {configFile: "no_dbus_object.conf", wantErr: true},
+
+		// Users section errors
+		"Error_when_create_home_dir_is_not_a_boolean":       {configFile: "invalid_create_home_dir.conf", wantErr: true},
+		"Error_when_default_shell_is_not_an_absolute_path":  {configFile: "invalid_default_shell.conf", wantErr: true},
+		"Error_when_password_aging_is_not_a_number_of_days": {configFile: "invalid_pwd_aging.conf", wantErr: true},
+		"Error_when_a_local_group_name_is_invalid":          {configFile: "invalid_local_groups.conf", wantErr: true},
+
+		// Sessions section errors
+		"Error_when_pending_timeout_is_negative": {configFile: "invalid_pending_timeout.conf", wantErr: true},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -85,6 +97,38 @@ func TestNewBroker(t *testing.T) {
 			require.NoError(t, err, "NewBroker should not return an error, but did")
 
 			gotString := fmt.Sprintf("ID: %s\nName: %s\nBrand Icon: %s\n", got.ID, got.Name, got.BrandIconPath)
+			if got.Users.CreateHomeDir != nil {
+				gotString += fmt.Sprintf("Create Home Dir: %t\n", *got.Users.CreateHomeDir)
+			}
+			if got.Users.QuotaProfile != "" {
+				gotString += fmt.Sprintf("Quota Profile: %s\n", got.Users.QuotaProfile)
+			}
+			if got.Users.DefaultShell != "" {
+				gotString += fmt.Sprintf("Default Shell: %s\n", got.Users.DefaultShell)
+			}
+			aging := got.Users.ShadowAging
+			for _, f := range []struct {
+				name  string
+				value *int
+			}{
+				{"Min Pwd Age", aging.MinPwdAge},
+				{"Max Pwd Age", aging.MaxPwdAge},
+				{"Pwd Warn Period", aging.PwdWarnPeriod},
+				{"Pwd Inactivity", aging.PwdInactivity},
+			} {
+				if f.value != nil {
+					gotString += fmt.Sprintf("%s: %d\n", f.name, *f.value)
+				}
+			}
+			if got.Users.PrimaryGroup != "" {
+				gotString += fmt.Sprintf("Primary Group: %s\n", got.Users.PrimaryGroup)
+			}
+			if len(got.Users.LocalGroups) > 0 {
+				gotString += fmt.Sprintf("Local Groups: %s\n", strings.Join(got.Users.LocalGroups, ", "))
+			}
+			if got.Sessions.PendingTimeout != nil {
+				gotString += fmt.Sprintf("Session Pending Timeout: %s\n", *got.Sessions.PendingTimeout)
+			}
 
 			golden.CheckOrUpdate(t, gotString)
 		})
@@ -220,6 +264,10 @@ func TestIsAuthenticated(t *testing.T) {
 		"No_error_when_broker_returns_userinfo_with_empty_gecos":           {sessionID: "IA_info_empty_gecos"},
 		"No_error_when_broker_returns_userinfo_with_group_with_empty_UGID": {sessionID: "IA_info_empty_ugid"},
 		"No_error_when_broker_returns_userinfo_with_mismatching_username":  {sessionID: "IA_info_mismatching_user_name"},
+		"No_error_when_broker_returns_userinfo_with_extended_attributes":   {sessionID: "success_with_extended_attributes"},
+		"No_error_when_auth.Retry_rotates_the_encryption_key":              {sessionID: "IA_retry_with_rotated_key"},
+		"No_error_when_auth.Next_rotates_the_encryption_key":               {sessionID: "IA_next_with_rotated_key"},
+		"No_error_when_auth.Granted_issues_a_device_token":                 {sessionID: "IA_granted_with_device_token"},
 
 		// broker errors
 		"Error_when_authenticating":                                           {sessionID: "IA_error"},
@@ -231,10 +279,13 @@ func TestIsAuthenticated(t *testing.T) {
 		"Error_when_broker_returns_userinfo_with_empty_group_name":            {sessionID: "IA_info_empty_group_name"},
 		"Error_when_broker_returns_userinfo_with_invalid_homedir":             {sessionID: "IA_info_invalid_home"},
 		"Error_when_broker_returns_userinfo_with_invalid_shell":               {sessionID: "IA_info_invalid_shell"},
+		"Error_when_broker_returns_userinfo_with_invalid_extended_attribute":  {sessionID: "IA_info_invalid_extended_attribute"},
 		"Error_when_broker_returns_data_on_auth.Next":                         {sessionID: "IA_next_with_data"},
 		"Error_when_broker_returns_data_on_auth.Cancelled":                    {sessionID: "IA_cancelled_with_data"},
 		"Error_when_broker_returns_no_data_on_auth.Denied":                    {sessionID: "IA_denied_without_data"},
 		"Error_when_broker_returns_no_data_on_auth.Retry":                     {sessionID: "IA_retry_without_data"},
+		"Error_when_broker_returns_invalid_encryption_key_on_auth.Next":       {sessionID: "IA_next_with_invalid_rotated_key"},
+		"Error_when_broker_returns_invalid_device_token_on_auth.Granted":      {sessionID: "IA_granted_with_invalid_device_token"},
 		"Error_when_calling_IsAuthenticated_a_second_time_without_cancelling": {sessionID: "IA_second_call", secondCall: true, cancelFirstCall: true},
 	}
 	for name, tc := range tests {
@@ -345,6 +396,66 @@ func TestUserPreCheck(t *testing.T) {
 	}
 }
 
+func TestUsersConfigApply(t *testing.T) {
+	t.Parallel()
+
+	maxPwdAge := 90
+	config := brokers.UsersConfig{
+		PrimaryGroup: "staff",
+		LocalGroups:  []string{"plugdev", "lpadmin"},
+		ShadowAging:  types.ShadowAging{MaxPwdAge: &maxPwdAge},
+	}
+	u := types.UserInfo{
+		Name:   "user1",
+		Groups: []types.GroupInfo{{Name: "group1", UGID: "1"}, {Name: "lpadmin"}},
+	}
+
+	config.Apply(&u)
+
+	require.Equal(t, "staff", u.PrimaryGroup, "Apply should set the primary group of the broker")
+	require.Equal(t, &maxPwdAge, u.ShadowAging.MaxPwdAge, "Apply should set the password aging of the broker")
+	require.Equal(t, []types.GroupInfo{{Name: "group1", UGID: "1"}, {Name: "lpadmin"}, {Name: "plugdev"}}, u.Groups,
+		"Apply should add the local groups of the broker which are not already provided")
+}
+
+func TestListUsers(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		brokerName string
+
+		wantErr             bool
+		wantErrNotSupported bool
+	}{
+		"Successfully_list_users_skipping_invalid_ones": {},
+
+		"Error_if_broker_does_not_support_listing_users": {brokerName: "ListUsers_not_supported", wantErr: true, wantErrNotSupported: true},
+		"Error_if_broker_fails_to_list_users":            {brokerName: "ListUsers_error", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := ""
+			if tc.brokerName != "" {
+				cfg = tc.brokerName + ".conf"
+			}
+			b := newBrokerForTests(t, "", cfg)
+
+			got, err := b.ListUsers(context.Background())
+			if tc.wantErr {
+				require.Error(t, err, "ListUsers should return an error, but did not")
+				require.Equal(t, tc.wantErrNotSupported, errors.Is(err, brokers.ErrListUsersNotSupported),
+					"ListUsers should only return ErrListUsersNotSupported if the broker does not implement it")
+				return
+			}
+			require.NoError(t, err, "ListUsers should not return an error, but did")
+
+			golden.CheckOrUpdateYAML(t, got)
+		})
+	}
+}
+
 func newBrokerForTests(t *testing.T, cfgDir, brokerCfg string) (b brokers.Broker) {
 	t.Helper()
 
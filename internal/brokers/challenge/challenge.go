@@ -0,0 +1,214 @@
+// Package challenge encrypts the challenges sent by the PAM clients to the brokers, like the passwords, so that only the
+// broker of the session can read them.
+//
+// The brokers return their public keys when a session starts, encoded in base64 in PKIX format. The kind of a key
+// tells the version of the encryption it's for. A broker supporting several versions returns a key for each of them,
+// separated by commas, and the daemon gives the clients the key of the most recent version they support.
+package challenge
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Version is a version of the encryption of the challenges.
+type Version uint32
+
+const (
+	// VersionRSA encrypts the challenges with RSA-OAEP and SHA-512, with an RSA public key of the broker. It's the
+	// version of the clients which don't negotiate it.
+	VersionRSA Version = 1
+	// VersionX25519 encrypts the challenges with AES-256-GCM, with a key derived with HKDF-SHA256 from an X25519 key
+	// agreement between an ephemeral key and an X25519 public key of the broker.
+	VersionX25519 Version = 2
+
+	// LatestVersion is the most recent version of the encryption of the challenges.
+	LatestVersion = VersionX25519
+)
+
+// KeysSeparator separates the public keys of the different versions a broker returns when a session starts.
+const KeysSeparator = ","
+
+// x25519Prefix prefixes the challenges encrypted with VersionX25519, which can't be mistaken for the ones encrypted
+// with VersionRSA, as they are only encoded in base64.
+const x25519Prefix = "x25519:"
+
+// hkdfInfo binds the keys derived for the challenges to their use.
+const hkdfInfo = "authd challenge encryption"
+
+// ErrUnsupportedVersion is returned when the broker doesn't offer any key of a version supported by the client.
+var ErrUnsupportedVersion = errors.New("no encryption key of a version supported by the client")
+
+// ParsePublicKey returns the public key of a broker, encoded in base64 in PKIX format, with the version of the
+// encryption it's for.
+func ParsePublicKey(encoded string) (key crypto.PublicKey, version Version, err error) {
+	pubASN1, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, 0, fmt.Errorf("encryption key sent by broker is not a valid base64 encoded string: %v", err)
+	}
+
+	pubKey, err := x509.ParsePKIXPublicKey(pubASN1)
+	if err != nil {
+		return nil, 0, fmt.Errorf("encryption key send by broker is not valid: %v", err)
+	}
+	switch k := pubKey.(type) {
+	case *rsa.PublicKey:
+		return k, VersionRSA, nil
+	case *ecdh.PublicKey:
+		if k.Curve() == ecdh.X25519() {
+			return k, VersionX25519, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("expected encryption key sent by broker to be an RSA or X25519 public key, got %T", pubKey)
+}
+
+// SelectKey returns the key of the most recent version supported by the client among the keys of a broker. A client
+// which doesn't negotiate the version, with a version of 0, only supports VersionRSA. A single key is returned as is,
+// for the client to check it.
+func SelectKey(keys string, clientVersion Version) (key string, err error) {
+	if !strings.Contains(keys, KeysSeparator) {
+		return keys, nil
+	}
+
+	if clientVersion == 0 {
+		clientVersion = VersionRSA
+	}
+	var selected Version
+	for _, k := range strings.Split(keys, KeysSeparator) {
+		_, version, err := ParsePublicKey(k)
+		if err != nil {
+			return "", err
+		}
+		if version <= clientVersion && version > selected {
+			key, selected = k, version
+		}
+	}
+	if key == "" {
+		return "", fmt.Errorf("%w, which is %d", ErrUnsupportedVersion, clientVersion)
+	}
+	return key, nil
+}
+
+// VersionOf returns the version of the encryption of a challenge encrypted by Encrypt, so that the brokers know which
+// kind of key the client uses.
+func VersionOf(challenge string) Version {
+	if strings.HasPrefix(challenge, x25519Prefix) {
+		return VersionX25519
+	}
+	return VersionRSA
+}
+
+// Encrypt encrypts the challenge with the public key of the broker, with the version of the key, and encodes it for the
+// broker.
+func Encrypt(key crypto.PublicKey, challenge []byte) (string, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		ciphertext, err := rsa.EncryptOAEP(sha512.New(), rand.Reader, k, challenge, nil)
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(ciphertext), nil
+
+	case *ecdh.PublicKey:
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return "", err
+		}
+		shared, err := ephemeral.ECDH(k)
+		if err != nil {
+			return "", err
+		}
+		aead, err := newAEAD(shared, ephemeral.PublicKey(), k)
+		if err != nil {
+			return "", err
+		}
+
+		// The ciphertext is made of the ephemeral public key, the nonce, and the encrypted challenge.
+		ciphertext := append([]byte{}, ephemeral.PublicKey().Bytes()...)
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		ciphertext = append(ciphertext, nonce...)
+		ciphertext = aead.Seal(ciphertext, nonce, challenge, nil)
+		return x25519Prefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+	}
+
+	return "", fmt.Errorf("unsupported encryption key %T", key)
+}
+
+// Decrypt decrypts a challenge encrypted by Encrypt with the public key of one of the private keys of the broker.
+func Decrypt(challenge string, keys ...crypto.PrivateKey) ([]byte, error) {
+	encoded, isX25519 := strings.CutPrefix(challenge, x25519Prefix)
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isX25519 {
+		for _, k := range keys {
+			if k, ok := k.(*rsa.PrivateKey); ok {
+				return rsa.DecryptOAEP(sha512.New(), nil, k, ciphertext, nil)
+			}
+		}
+		return nil, errors.New("no RSA key to decrypt the challenge")
+	}
+
+	for _, k := range keys {
+		k, ok := k.(*ecdh.PrivateKey)
+		if !ok || k.Curve() != ecdh.X25519() {
+			continue
+		}
+
+		keySize := len(k.PublicKey().Bytes())
+		if len(ciphertext) < keySize {
+			return nil, errors.New("challenge is too short")
+		}
+		ephemeral, err := ecdh.X25519().NewPublicKey(ciphertext[:keySize])
+		if err != nil {
+			return nil, err
+		}
+		shared, err := k.ECDH(ephemeral)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := newAEAD(shared, ephemeral, k.PublicKey())
+		if err != nil {
+			return nil, err
+		}
+		if len(ciphertext) < keySize+aead.NonceSize() {
+			return nil, errors.New("challenge is too short")
+		}
+		nonce, sealed := ciphertext[keySize:keySize+aead.NonceSize()], ciphertext[keySize+aead.NonceSize():]
+		return aead.Open(nil, nonce, sealed, nil)
+	}
+	return nil, errors.New("no X25519 key to decrypt the challenge")
+}
+
+// newAEAD returns the AES-256-GCM cipher of a challenge, whose key is derived from the shared secret of the X25519 key
+// agreement between the ephemeral key of the challenge and the key of the broker, and bound to both public keys.
+func newAEAD(shared []byte, ephemeral, broker *ecdh.PublicKey) (cipher.AEAD, error) {
+	// HKDF-SHA256 (RFC 5869), whose output of 32 bytes is the first block of its expansion.
+	extract := hmac.New(sha256.New, append(ephemeral.Bytes(), broker.Bytes()...))
+	extract.Write(shared)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write(append([]byte(hkdfInfo), 1))
+
+	block, err := aes.NewCipher(expand.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
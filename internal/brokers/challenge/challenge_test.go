@@ -0,0 +1,185 @@
+package challenge_test
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/brokers/challenge"
+)
+
+func TestParsePublicKey(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, x25519Key := newRSAKey(t), newX25519Key(t)
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "Setup: could not generate P-256 key")
+
+	tests := map[string]struct {
+		key string
+
+		wantVersion challenge.Version
+		wantErr     bool
+	}{
+		"RSA_key_is_for_the_RSA_version":       {key: encodePublicKey(t, rsaKey.Public()), wantVersion: challenge.VersionRSA},
+		"X25519_key_is_for_the_X25519_version": {key: encodePublicKey(t, x25519Key.Public()), wantVersion: challenge.VersionX25519},
+
+		"Error_if_the_key_is_not_base64":      {key: "not base64!", wantErr: true},
+		"Error_if_the_key_is_not_PKIX":        {key: base64.StdEncoding.EncodeToString([]byte("not a key")), wantErr: true},
+		"Error_if_the_key_is_of_another_kind": {key: encodePublicKey(t, p256Key.Public()), wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, version, err := challenge.ParsePublicKey(tc.key)
+			if tc.wantErr {
+				require.Error(t, err, "ParsePublicKey should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "ParsePublicKey should not return an error, but did")
+			require.Equal(t, tc.wantVersion, version, "ParsePublicKey should return the version of the key")
+		})
+	}
+}
+
+func TestSelectKey(t *testing.T) {
+	t.Parallel()
+
+	rsaKey := encodePublicKey(t, newRSAKey(t).Public())
+	x25519Key := encodePublicKey(t, newX25519Key(t).Public())
+	bothKeys := x25519Key + challenge.KeysSeparator + rsaKey
+
+	tests := map[string]struct {
+		keys          string
+		clientVersion challenge.Version
+
+		wantKey   string
+		wantErr   bool
+		wantErrIs error
+	}{
+		"Select_the_X25519_key_for_a_client_supporting_it":       {keys: bothKeys, clientVersion: challenge.VersionX25519, wantKey: x25519Key},
+		"Select_the_X25519_key_for_a_client_of_a_newer_version":  {keys: bothKeys, clientVersion: challenge.LatestVersion + 1, wantKey: x25519Key},
+		"Select_the_RSA_key_for_a_client_supporting_only_it":     {keys: bothKeys, clientVersion: challenge.VersionRSA, wantKey: rsaKey},
+		"Select_the_RSA_key_for_a_client_not_negotiating":        {keys: bothKeys, wantKey: rsaKey},
+		"Return_a_single_key_as_is":                              {keys: "some key", clientVersion: challenge.VersionX25519, wantKey: "some key"},
+		"Return_a_single_key_as_is_for_a_client_not_negotiating": {keys: x25519Key, wantKey: x25519Key},
+
+		"Error_if_no_key_is_of_a_version_supported_by_the_client": {
+			keys: x25519Key + challenge.KeysSeparator + x25519Key, clientVersion: challenge.VersionRSA, wantErr: true,
+			wantErrIs: challenge.ErrUnsupportedVersion,
+		},
+		"Error_if_one_of_the_keys_is_invalid": {keys: bothKeys + challenge.KeysSeparator + "not base64!", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			key, err := challenge.SelectKey(tc.keys, tc.clientVersion)
+			if tc.wantErr {
+				require.Error(t, err, "SelectKey should return an error, but did not")
+				if tc.wantErrIs != nil {
+					require.ErrorIs(t, err, tc.wantErrIs, "SelectKey should return the expected error")
+				}
+				return
+			}
+			require.NoError(t, err, "SelectKey should not return an error, but did")
+			require.Equal(t, tc.wantKey, key, "SelectKey should return the expected key")
+		})
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, x25519Key := newRSAKey(t), newX25519Key(t)
+
+	tests := map[string]struct {
+		publicKey   crypto.PublicKey
+		privateKeys []crypto.PrivateKey
+		tamper      bool
+
+		wantVersion challenge.Version
+		wantErr     bool
+	}{
+		"Decrypt_challenge_encrypted_with_RSA": {
+			publicKey: rsaKey.Public(), privateKeys: []crypto.PrivateKey{rsaKey}, wantVersion: challenge.VersionRSA,
+		},
+		"Decrypt_challenge_encrypted_with_X25519": {
+			publicKey: x25519Key.Public(), privateKeys: []crypto.PrivateKey{x25519Key}, wantVersion: challenge.VersionX25519,
+		},
+		"Decrypt_challenge_encrypted_with_RSA_by_a_broker_with_both_keys": {
+			publicKey: rsaKey.Public(), privateKeys: []crypto.PrivateKey{x25519Key, rsaKey}, wantVersion: challenge.VersionRSA,
+		},
+		"Decrypt_challenge_encrypted_with_X25519_by_a_broker_with_both_keys": {
+			publicKey: x25519Key.Public(), privateKeys: []crypto.PrivateKey{rsaKey, x25519Key}, wantVersion: challenge.VersionX25519,
+		},
+
+		"Error_if_the_broker_has_no_RSA_key":                   {publicKey: rsaKey.Public(), privateKeys: []crypto.PrivateKey{x25519Key}, wantErr: true},
+		"Error_if_the_broker_has_no_X25519_key":                {publicKey: x25519Key.Public(), privateKeys: []crypto.PrivateKey{rsaKey}, wantErr: true},
+		"Error_if_the_challenge_was_encrypted_for_another_key": {publicKey: newX25519Key(t).Public(), privateKeys: []crypto.PrivateKey{x25519Key}, wantErr: true},
+		"Error_if_the_X25519_challenge_was_tampered_with": {
+			publicKey: x25519Key.Public(), privateKeys: []crypto.PrivateKey{x25519Key}, tamper: true, wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encrypted, err := challenge.Encrypt(tc.publicKey, []byte("some secret"))
+			require.NoError(t, err, "Encrypt should not return an error, but did")
+			require.NotContains(t, encrypted, "some secret", "Encrypt should not return the challenge in clear")
+			if tc.wantVersion != 0 {
+				require.Equal(t, tc.wantVersion, challenge.VersionOf(encrypted), "VersionOf should return the version of the key")
+			}
+
+			if tc.tamper {
+				prefix, encoded, _ := strings.Cut(encrypted, ":")
+				raw, err := base64.StdEncoding.DecodeString(encoded)
+				require.NoError(t, err, "Setup: could not decode the challenge")
+				raw[len(raw)-1] ^= 1
+				encrypted = prefix + ":" + base64.StdEncoding.EncodeToString(raw)
+			}
+
+			got, err := challenge.Decrypt(encrypted, tc.privateKeys...)
+			if tc.wantErr {
+				require.Error(t, err, "Decrypt should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "Decrypt should not return an error, but did")
+			require.Equal(t, "some secret", string(got), "Decrypt should return the challenge")
+		})
+	}
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, "Setup: could not generate RSA key")
+	return k
+}
+
+func newX25519Key(t *testing.T) *ecdh.PrivateKey {
+	t.Helper()
+
+	k, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err, "Setup: could not generate X25519 key")
+	return k
+}
+
+func encodePublicKey(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+
+	pubASN1, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err, "Setup: could not marshal public key")
+	return base64.StdEncoding.EncodeToString(pubASN1)
+}
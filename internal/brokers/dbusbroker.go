@@ -4,58 +4,141 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
 
 	"github.com/godbus/dbus/v5"
 	"github.com/ubuntu/authd/internal/services/errmessages"
+	"github.com/ubuntu/authd/internal/tracing"
+	"github.com/ubuntu/authd/internal/users/types"
 	"github.com/ubuntu/authd/log"
 	"github.com/ubuntu/decorate"
+	"go.opentelemetry.io/otel/attribute"
 	"gopkg.in/ini.v1"
 )
 
 // DbusInterface is the expected interface that should be implemented by the brokers.
 const DbusInterface string = "com.ubuntu.authd.Broker"
 
+// brokerUnavailableErrors are the D-Bus errors returned when a broker is not running or doesn't answer.
+var brokerUnavailableErrors = []string{
+	"org.freedesktop.DBus.Error.ServiceUnknown",
+	"org.freedesktop.DBus.Error.NameHasNoOwner",
+	"org.freedesktop.DBus.Error.NoReply",
+}
+
 type dbusBroker struct {
 	name string
 
 	dbusObject dbus.BusObject
 }
 
+// CheckReachable returns the name of the D-Bus broker of the configuration file, and an error if it doesn't answer on
+// the bus.
+func CheckReachable(ctx context.Context, bus *dbus.Conn, configFile string) (name string, err error) {
+	b, name, _, _, _, err := newDbusBroker(ctx, bus, configFile)
+	if err != nil {
+		return "", err
+	}
+	if err := b.dbusObject.CallWithContext(ctx, "org.freedesktop.DBus.Peer.Ping", 0).Err; err != nil {
+		return name, fmt.Errorf("broker %q is not reachable on %s: %v", name, b.dbusObject.Destination(), err)
+	}
+	return name, nil
+}
+
 // newDbusBroker returns a dbus broker and broker attributes from its configuration file.
-func newDbusBroker(ctx context.Context, bus *dbus.Conn, configFile string) (b dbusBroker, name, brandIcon string, err error) {
+func newDbusBroker(ctx context.Context, bus *dbus.Conn, configFile string) (b dbusBroker, name, brandIcon string, users UsersConfig, sessions SessionsConfig, err error) {
 	defer decorate.OnError(&err, "D-Bus broker from configuration file: %q", configFile)
 
 	log.Debugf(ctx, "D-Bus broker configuration at %q", configFile)
 
 	cfg, err := ini.Load(configFile)
 	if err != nil {
-		return b, "", "", fmt.Errorf("could not read ini configuration for broker %v", err)
+		return b, "", "", UsersConfig{}, SessionsConfig{}, fmt.Errorf("could not read ini configuration for broker %v", err)
 	}
 
 	nameVal, err := cfg.Section("authd").GetKey("name")
 	if err != nil {
-		return b, "", "", fmt.Errorf("missing field for broker: %v", err)
+		return b, "", "", UsersConfig{}, SessionsConfig{}, fmt.Errorf("missing field for broker: %v", err)
 	}
 
 	brandIconVal, err := cfg.Section("authd").GetKey("brand_icon")
 	if err != nil {
-		return b, "", "", fmt.Errorf("missing field for broker: %v", err)
+		return b, "", "", UsersConfig{}, SessionsConfig{}, fmt.Errorf("missing field for broker: %v", err)
 	}
 
 	dbusName, err := cfg.Section("authd").GetKey("dbus_name")
 	if err != nil {
-		return b, "", "", fmt.Errorf("missing field for broker: %v", err)
+		return b, "", "", UsersConfig{}, SessionsConfig{}, fmt.Errorf("missing field for broker: %v", err)
 	}
 
 	objectName, err := cfg.Section("authd").GetKey("dbus_object")
 	if err != nil {
-		return b, "", "", fmt.Errorf("missing field for broker: %v", err)
+		return b, "", "", UsersConfig{}, SessionsConfig{}, fmt.Errorf("missing field for broker: %v", err)
+	}
+
+	// The optional users section overrides the users configuration of the daemon for the users of this broker.
+	usersSection := cfg.Section("users")
+	if key, err := usersSection.GetKey("create_home_dir"); err == nil {
+		v, err := key.Bool()
+		if err != nil {
+			return b, "", "", UsersConfig{}, SessionsConfig{}, fmt.Errorf("invalid value for create_home_dir: %v", err)
+		}
+		users.CreateHomeDir = &v
+	}
+	users.QuotaProfile = usersSection.Key("quota_profile").String()
+	users.DefaultShell = usersSection.Key("default_shell").String()
+	if users.DefaultShell != "" && !filepath.IsAbs(users.DefaultShell) {
+		return b, "", "", UsersConfig{}, SessionsConfig{}, fmt.Errorf("default_shell %q is not an absolute path", users.DefaultShell)
+	}
+	if users.ShadowAging, err = parseShadowAging(usersSection); err != nil {
+		return b, "", "", UsersConfig{}, SessionsConfig{}, err
+	}
+	users.PrimaryGroup = usersSection.Key("primary_group").String()
+	for _, g := range usersSection.Key("local_groups").Strings(",") {
+		if !localGroupNameRegexp.MatchString(g) {
+			return b, "", "", UsersConfig{}, SessionsConfig{}, fmt.Errorf("invalid local group name %q in local_groups", g)
+		}
+		users.LocalGroups = append(users.LocalGroups, g)
+	}
+
+	// The optional sessions section overrides the sessions configuration of the daemon for this broker.
+	if key, err := cfg.Section("sessions").GetKey("pending_timeout"); err == nil {
+		v, err := key.Duration()
+		if err != nil || v < 0 {
+			return b, "", "", UsersConfig{}, SessionsConfig{}, fmt.Errorf("invalid value for pending_timeout %q, must be a positive duration or 0", key.String())
+		}
+		sessions.PendingTimeout = &v
 	}
 
 	return dbusBroker{
 		name:       nameVal.String(),
 		dbusObject: bus.Object(dbusName.String(), dbus.ObjectPath(objectName.String())),
-	}, nameVal.String(), brandIconVal.String(), nil
+	}, nameVal.String(), brandIconVal.String(), users, sessions, nil
+}
+
+// parseShadowAging returns the password aging fields set in the users section of the configuration file.
+func parseShadowAging(section *ini.Section) (aging types.ShadowAging, err error) {
+	for _, f := range []struct {
+		key   string
+		value **int
+	}{
+		{"min_pwd_age", &aging.MinPwdAge},
+		{"max_pwd_age", &aging.MaxPwdAge},
+		{"pwd_warn_period", &aging.PwdWarnPeriod},
+		{"pwd_inactivity", &aging.PwdInactivity},
+	} {
+		key, err := section.GetKey(f.key)
+		if err != nil {
+			continue
+		}
+		v, err := key.Int()
+		if err != nil || v < -1 {
+			return types.ShadowAging{}, fmt.Errorf("invalid value for %s %q, must be a number of days or -1", f.key, key.String())
+		}
+		*f.value = &v
+	}
+	return aging, nil
 }
 
 // NewSession calls the corresponding method on the broker bus and returns the session ID and encryption key.
@@ -98,9 +181,9 @@ func (b dbusBroker) SelectAuthenticationMode(ctx context.Context, sessionID, aut
 }
 
 // IsAuthenticated calls the corresponding method on the broker bus and returns the user information and access.
-func (b dbusBroker) IsAuthenticated(_ context.Context, sessionID, authenticationData string) (access, data string, err error) {
+func (b dbusBroker) IsAuthenticated(ctx context.Context, sessionID, authenticationData string) (access, data string, err error) {
 	// We don’t want to cancel the context when the parent call is cancelled.
-	call, err := b.call(context.Background(), "IsAuthenticated", sessionID, authenticationData)
+	call, err := b.call(context.WithoutCancel(ctx), "IsAuthenticated", sessionID, authenticationData)
 	if err != nil {
 		return "", "", err
 	}
@@ -122,7 +205,7 @@ func (b dbusBroker) EndSession(ctx context.Context, sessionID string) (err error
 // CancelIsAuthenticated calls the corresponding method on the broker bus.
 func (b dbusBroker) CancelIsAuthenticated(ctx context.Context, sessionID string) {
 	// We don’t want to cancel the context when the parent call is cancelled.
-	if _, err := b.call(context.Background(), "CancelIsAuthenticated", sessionID); err != nil {
+	if _, err := b.call(context.WithoutCancel(ctx), "CancelIsAuthenticated", sessionID); err != nil {
 		log.Errorf(ctx, "could not cancel IsAuthenticated call for session %q: %v", sessionID, err)
 	}
 }
@@ -140,20 +223,48 @@ func (b dbusBroker) UserPreCheck(ctx context.Context, username string) (userinfo
 	return userinfo, nil
 }
 
+// ListUsers calls the corresponding method on the broker bus. The method is optional, so ErrListUsersNotSupported is
+// returned if the broker doesn't provide it.
+func (b dbusBroker) ListUsers(ctx context.Context) (usersinfo string, err error) {
+	ctx, span := tracing.Start(ctx, "broker.ListUsers", attribute.String("broker.name", b.name))
+	defer func() { tracing.End(span, err) }()
+
+	call := b.dbusObject.CallWithContext(ctx, DbusInterface+".ListUsers", 0)
+	var dbusError dbus.Error
+	if errors.As(call.Err, &dbusError) && dbusError.Name == "org.freedesktop.DBus.Error.UnknownMethod" {
+		return "", ErrListUsersNotSupported
+	}
+	if call.Err != nil {
+		return "", call.Err
+	}
+	if err = call.Store(&usersinfo); err != nil {
+		return "", err
+	}
+
+	return usersinfo, nil
+}
+
 // call is an abstraction over dbus calls to ensure we wrap the returned error to an ErrorToDisplay.
 // All wrapped errors will be logged, but not returned to the UI.
-func (b dbusBroker) call(ctx context.Context, method string, args ...interface{}) (*dbus.Call, error) {
+func (b dbusBroker) call(ctx context.Context, method string, args ...interface{}) (_ *dbus.Call, err error) {
+	ctx, span := tracing.Start(ctx, "broker."+method, attribute.String("broker.name", b.name))
+	defer func() { tracing.End(span, err) }()
+
 	dbusMethod := DbusInterface + "." + method
+	log.Payloadf(ctx, "D-Bus call %s of broker %q: %v", dbusMethod, b.name, args)
 	call := b.dbusObject.CallWithContext(ctx, dbusMethod, 0, args...)
 	if err := call.Err; err != nil {
+		log.Payloadf(ctx, "D-Bus error %s of broker %q: %v", dbusMethod, b.name, err)
 		var dbusError dbus.Error
 		// If the broker is not available ib dbus, the original "method was not provided by any .service files" isn't
 		// user-friendly, so we replace it with a better message.
-		if errors.As(err, &dbusError) && dbusError.Name == "org.freedesktop.DBus.Error.ServiceUnknown" {
-			err = fmt.Errorf("couldn't connect to broker %q. Is it running?", b.name)
+		if errors.As(err, &dbusError) && slices.Contains(brokerUnavailableErrors, dbusError.Name) {
+			err = errmessages.ErrBrokerUnavailable.Wrap(fmt.Errorf("couldn't connect to broker %q. Is it running?", b.name)).
+				WithMetadata(errmessages.MetadataBroker, b.name)
 		}
 		return nil, errmessages.NewToDisplayError(err)
 	}
+	log.Payloadf(ctx, "D-Bus reply %s of broker %q: %v", dbusMethod, b.name, call.Body)
 
 	return call, nil
 }
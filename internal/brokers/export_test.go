@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/godbus/dbus/v5"
+	"github.com/ubuntu/authd/internal/brokers/worker"
 )
 
 // NewBroker exports the private newBroker function for testing purposes.
@@ -65,7 +67,39 @@ func (b *Broker) LayoutValidatorsString(sessionID string) string {
 
 // AddOngoingUserRequest adds an ongoing user request to the broker for tests.
 func (b *Broker) AddOngoingUserRequest(sessionID, username string) {
-	b.ongoingUserRequestsMu.Lock()
-	defer b.ongoingUserRequestsMu.Unlock()
-	b.ongoingUserRequests[sessionID] = username
+	b.ongoingSessionsInfoMu.Lock()
+	defer b.ongoingSessionsInfoMu.Unlock()
+	b.ongoingSessionsInfo[sessionID] = &SessionInfo{ID: sessionID, Username: username, BrokerID: b.ID, BrokerName: b.Name}
+}
+
+// ThroughWorker returns a copy of the D-Bus broker calling it through the worker.
+func (b Broker) ThroughWorker(w *worker.Client) Broker {
+	b.brokerer = newWorkerBroker(w, b.brokerer.(dbusBroker))
+	return b
+}
+
+// ReapIdleSessions ends the sessions which are idle at the given time.
+func (m *Manager) ReapIdleSessions(now time.Time) {
+	m.reapIdleSessions(context.Background(), now)
+}
+
+// ReapPendingSessions ends the sessions which are pending for too long at the given time.
+func (m *Manager) ReapPendingSessions(now time.Time) {
+	m.reapPendingSessions(context.Background(), now)
+}
+
+// SetBrokerSessionsConfig overrides the sessions configuration of the broker for tests.
+func (m *Manager) SetBrokerSessionsConfig(brokerID string, config SessionsConfig) {
+	m.brokersMu.Lock()
+	defer m.brokersMu.Unlock()
+	m.brokers[brokerID].Sessions = config
+}
+
+// CallsInProgress returns the number of calls in progress to the broker, as counted by its call limiter.
+func (b *Broker) CallsInProgress() int {
+	lb, ok := b.brokerer.(limitedBroker)
+	if !ok {
+		return 0
+	}
+	return len(lb.limiter.slots)
 }
@@ -0,0 +1,224 @@
+package brokers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/authd/internal/brokers/layouts"
+	"github.com/ubuntu/authd/internal/brokers/layouts/entries"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+// fuzzSessionID is the ID of the session of the broker responses fed by the fuzz targets.
+const fuzzSessionID = "fuzz-session"
+
+// fuzzSupportedUILayouts are the UI layouts supported by the client in the fuzz targets which don't fuzz them.
+var fuzzSupportedUILayouts = []map[string]string{
+	{
+		layouts.Type:   layouts.Form,
+		layouts.Label:  layouts.RequiredItems(),
+		layouts.Entry:  layouts.OptionalItems(entries.Chars, entries.CharsPassword),
+		layouts.Wait:   layouts.OptionalItems(layouts.True, layouts.False),
+		layouts.Button: layouts.OptionalItems(),
+	},
+	{
+		layouts.Type:    layouts.QrCode,
+		layouts.Content: layouts.RequiredItems(),
+		layouts.Code:    layouts.OptionalItems(),
+		layouts.Wait:    layouts.RequiredItems(layouts.True, layouts.False),
+		layouts.Label:   layouts.OptionalItems(),
+		layouts.Button:  layouts.OptionalItems(),
+	},
+}
+
+// responseBroker is a broker returning the responses the fuzz targets feed to the daemon, as a broker process could.
+type responseBroker struct {
+	localBroker
+
+	authModes []map[string]string
+	uiLayout  map[string]string
+	access    string
+	data      string
+	usersInfo string
+}
+
+func (b responseBroker) GetAuthenticationModes(context.Context, string, []map[string]string) ([]map[string]string, error) {
+	return b.authModes, nil
+}
+
+func (b responseBroker) SelectAuthenticationMode(context.Context, string, string) (map[string]string, error) {
+	return b.uiLayout, nil
+}
+
+func (b responseBroker) IsAuthenticated(context.Context, string, string) (string, string, error) {
+	return b.access, b.data, nil
+}
+
+func (b responseBroker) ListUsers(context.Context) (string, error) {
+	return b.usersInfo, nil
+}
+
+func FuzzGetAuthenticationModes(f *testing.F) {
+	f.Add(`[{"id": "password", "label": "Password authentication"}]`)
+	f.Add(`[{"id": "password", "label": "Password authentication"}, {"id": "qrcode"}]`)
+	f.Add(`[{"label": "Password authentication"}]`)
+	f.Add(`[{}]`)
+	f.Add(`[]`)
+	f.Add(`null`)
+
+	f.Fuzz(func(t *testing.T, authModes string) {
+		var modes []map[string]string
+		if err := json.Unmarshal([]byte(authModes), &modes); err != nil {
+			t.Skip("not a list of authentication modes")
+		}
+
+		b := newFuzzBroker(t, responseBroker{authModes: modes})
+		got, err := b.GetAuthenticationModes(context.Background(), fuzzSessionID, fuzzSupportedUILayouts)
+		if err != nil {
+			return
+		}
+		for _, m := range got {
+			require.Contains(t, m, layouts.ID, "Accepted authentication mode should have an ID")
+			require.Contains(t, m, layouts.Label, "Accepted authentication mode should have a label")
+		}
+	})
+}
+
+func FuzzSelectAuthenticationMode(f *testing.F) {
+	f.Add(`[{"type": "form", "label": "required", "entry": "optional:chars,chars_password"}]`,
+		`{"type": "form", "label": "Enter your password", "entry": "chars_password"}`)
+	f.Add(`[{"type": "form", "label": "required", "entry": "optional:chars,chars_password"}]`,
+		`{"type": "form", "entry": "chars_password"}`)
+	f.Add(`[{"type": "form", "label": "required", "entry": "optional:chars,chars_password"}]`,
+		`{"type": "form", "label": "Enter your password", "entry": "digits"}`)
+	f.Add(`[{"type": "form", "label": "required"}]`, `{"type": "form", "label": "Label", "button": "Button"}`)
+	f.Add(`[{"type": "form", "wait": "required:true,false"}, {"type": "form", "wait": "optional:true,false"}]`,
+		`{"type": "form"}`)
+	f.Add(`[{"label": "required"}]`, `{"label": "Label"}`)
+	f.Add(`[{"type": "qrcode", "content": "required:"}]`, `{"type": "qrcode", "content": "https://ubuntu.com"}`)
+	f.Add(`[]`, `{"type": "newpassword"}`)
+	f.Add(`[{"type": ""}]`, `{}`)
+
+	f.Fuzz(func(t *testing.T, supportedUILayouts, uiLayout string) {
+		var supported []map[string]string
+		if err := json.Unmarshal([]byte(supportedUILayouts), &supported); err != nil {
+			t.Skip("not a list of UI layouts")
+		}
+		var layout map[string]string
+		if err := json.Unmarshal([]byte(uiLayout), &layout); err != nil {
+			t.Skip("not a UI layout")
+		}
+
+		b := newFuzzBroker(t, responseBroker{uiLayout: layout})
+		b.layoutValidators[fuzzSessionID] = generateValidators(context.Background(), fuzzSessionID, supported)
+		got, err := b.SelectAuthenticationMode(context.Background(), fuzzSessionID, "some-mode")
+		if err != nil {
+			return
+		}
+
+		// The last supported layout of a type is the one the layout is validated against.
+		var want map[string]string
+		for _, l := range supported {
+			if typ, ok := l[layouts.Type]; ok && typ == got[layouts.Type] {
+				want = l
+			}
+		}
+		require.NotNil(t, want, "Accepted UI layout should be of a supported type")
+
+		for key, value := range got {
+			if key == layouts.Type {
+				continue
+			}
+			require.Contains(t, want, key, "Accepted UI layout should only have supported fields")
+			if _, values := layouts.ParseItems(want[key]); value != "" && values != nil {
+				require.Contains(t, values, value, "Accepted UI layout should only have supported values")
+			}
+		}
+		for key, items := range want {
+			if kind, _ := layouts.ParseItems(items); key != layouts.Type && kind == layouts.Required {
+				require.NotEmpty(t, got[key], "Accepted UI layout should have all the required fields")
+			}
+		}
+	})
+}
+
+func FuzzIsAuthenticated(f *testing.F) {
+	f.Add(auth.Granted, `{"userinfo": {"Name": "user1", "UID": 1111, "Dir": "/home/user1", "Shell": "/bin/bash", "Groups": [{"Name": "group1", "GID": 1111}]}}`)
+	f.Add(auth.Granted, `{"userinfo": {"Name": "user1", "Dir": "/home/user1"}, "device_token": "some-token"}`)
+	f.Add(auth.Granted, `{"userinfo": {"Name": "user1", "Dir": "/home/user1", "extended_attributes": {"employee_id": "42"}}}`)
+	f.Add(auth.Granted, `{"userinfo": {"Name": "", "Dir": "/home/user1"}}`)
+	f.Add(auth.Granted, `{"userinfo": {"Name": "user1", "Dir": "home/user1"}}`)
+	f.Add(auth.Granted, `{"userinfo": {"Name": "user1", "Dir": "/home/user1", "Groups": [{"Name": ""}]}}`)
+	f.Add(auth.Granted, `{"userinfo": "user1"}`)
+	f.Add(auth.Granted, `{"device_token": ""}`)
+	f.Add(auth.Granted, `{}`)
+	f.Add(auth.Denied, `{"message": "Access denied"}`)
+	f.Add(auth.Denied, `{}`)
+	f.Add(auth.Retry, `{"message": "Wrong password", "encryption_key": "some-key"}`)
+	f.Add(auth.Retry, `{"message": "Wrong password", "encryption_key": 42}`)
+	f.Add(auth.Next, ``)
+	f.Add(auth.Next, `{"encryption_key": "some-key"}`)
+	f.Add(auth.Next, `{"message": "Next step"}`)
+	f.Add(auth.Cancelled, `{}`)
+	f.Add(auth.Cancelled, `{"message": "Cancelled"}`)
+	f.Add("some-access", `{}`)
+	f.Add(auth.Granted, `not json`)
+
+	f.Fuzz(func(t *testing.T, access, data string) {
+		b := newFuzzBroker(t, responseBroker{access: access, data: data})
+		gotAccess, gotData, err := b.IsAuthenticated(context.Background(), fuzzSessionID, "some-data")
+		if err != nil {
+			return
+		}
+		require.Contains(t, auth.Replies, gotAccess, "Accepted access should be a valid authentication reply")
+
+		var reply map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal([]byte(gotData), &reply), "Accepted data should be a JSON object")
+
+		switch gotAccess {
+		case auth.Granted:
+			var u types.UserInfo
+			require.NoError(t, json.Unmarshal([]byte(gotData), &u), "Accepted data should be the user information")
+			require.NoError(t, validateUserInfo(u), "Accepted user information should be valid")
+		case auth.Denied, auth.Retry:
+			require.Contains(t, reply, "message", "Accepted data should have a message")
+		case auth.Cancelled:
+			require.Empty(t, reply, "Accepted data should be empty")
+		}
+	})
+}
+
+func FuzzListUsers(f *testing.F) {
+	f.Add(`[{"Name": "user1", "UID": 1111, "Dir": "/home/user1", "Groups": [{"Name": "group1"}]}, {"Name": "user2", "Dir": "/home/user2"}]`)
+	f.Add(`[{"Name": "user1", "Dir": "/home/user1"}, {"Name": "", "Dir": "/home/user2"}, {"Name": "user3", "Dir": "home"}]`)
+	f.Add(`[{"Name": "user1", "Dir": "/home/user1", "extended_attributes": {"in valid": "value"}}]`)
+	f.Add(`[42, "user1", null]`)
+	f.Add(`[]`)
+	f.Add(`{}`)
+	f.Add(``)
+
+	f.Fuzz(func(t *testing.T, usersInfo string) {
+		b := newFuzzBroker(t, responseBroker{usersInfo: usersInfo})
+		users, err := b.ListUsers(context.Background())
+		if err != nil {
+			return
+		}
+		for _, u := range users {
+			require.NoError(t, validateUserInfo(u), "Listed user should be valid")
+		}
+	})
+}
+
+// newFuzzBroker returns a broker whose session fuzzSessionID returns the responses of rb.
+func newFuzzBroker(t *testing.T, rb responseBroker) Broker {
+	t.Helper()
+
+	b, err := newBroker(context.Background(), "", nil)
+	require.NoError(t, err, "Setup: could not create the broker")
+	b.brokerer = rb
+	b.layoutValidators[fuzzSessionID] = generateValidators(context.Background(), fuzzSessionID, fuzzSupportedUILayouts)
+	return b
+}
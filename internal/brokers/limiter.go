@@ -0,0 +1,224 @@
+package brokers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ubuntu/authd/internal/brokers/layouts"
+	"github.com/ubuntu/authd/internal/brokers/worker"
+	"github.com/ubuntu/authd/internal/services/errmessages"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+// errBrokerBusy is returned when a call to a broker can't wait for the calls in progress to finish.
+var errBrokerBusy = errors.New("broker is busy")
+
+// CallLimits bound the calls the daemon does at once to each broker, so that a burst of logins can't overload it.
+type CallLimits struct {
+	// MaxConcurrent is the number of calls in progress to a broker, 0 for no limit.
+	MaxConcurrent int `mapstructure:"max_concurrent"`
+	// MaxQueued is the number of calls waiting for a call in progress to finish, above which the calls fail at once.
+	MaxQueued int `mapstructure:"max_queued"`
+	// QueueTimeout is how long a call can wait for a call in progress to finish, 0 for no limit.
+	QueueTimeout time.Duration `mapstructure:"queue_timeout"`
+}
+
+// DefaultCallLimits allow 64 calls in progress to each broker, and 256 more calls waiting up to 10 seconds, which is
+// far above what the logins of a busy machine need.
+var DefaultCallLimits = CallLimits{
+	MaxConcurrent: 64,
+	MaxQueued:     256,
+	QueueTimeout:  10 * time.Second,
+}
+
+// Validate returns an error if a limit is negative.
+func (l CallLimits) Validate() error {
+	if l.MaxConcurrent < 0 || l.MaxQueued < 0 || l.QueueTimeout < 0 {
+		return errors.New("max_concurrent, max_queued and queue_timeout can't be negative")
+	}
+	return nil
+}
+
+// callLimiter bounds the calls in progress to a broker, and queues the other ones.
+type callLimiter struct {
+	limits CallLimits
+	// slots holds a value for each call in progress.
+	slots  chan struct{}
+	queued atomic.Int64
+}
+
+// newCallLimiter returns a limiter of the calls to a broker, or nil if the calls are not limited.
+func newCallLimiter(limits CallLimits) *callLimiter {
+	if limits.MaxConcurrent == 0 {
+		return nil
+	}
+	return &callLimiter{
+		limits: limits,
+		slots:  make(chan struct{}, limits.MaxConcurrent),
+	}
+}
+
+// acquire waits for a call to the broker to be allowed, and returns the function to call once it's done. It returns
+// errBrokerBusy if too many calls are already waiting, or if the call waited for longer than the queue timeout.
+func (l *callLimiter) acquire(ctx context.Context) (release func(), err error) {
+	release = func() { <-l.slots }
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if l.queued.Add(1) > int64(l.limits.MaxQueued) {
+		l.queued.Add(-1)
+		return nil, errBrokerBusy
+	}
+	defer l.queued.Add(-1)
+
+	var timeout <-chan time.Time
+	if l.limits.QueueTimeout > 0 {
+		timer := time.NewTimer(l.limits.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		return nil, errBrokerBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// limitedBroker is a broker whose calls are bounded by a call limiter. Ending sessions and cancelling authentications
+// are never limited, as they free the resources of the broker. Neither are the authentications waiting for the user,
+// which can last for minutes and are already bounded by the session limits.
+type limitedBroker struct {
+	brokerer
+	name    string
+	limiter *callLimiter
+}
+
+// newLimitedBroker returns the broker b with its calls bounded by limits, or b itself if the calls are not limited.
+func newLimitedBroker(b brokerer, name string, limits CallLimits) brokerer {
+	limiter := newCallLimiter(limits)
+	if limiter == nil {
+		return b
+	}
+	return limitedBroker{brokerer: b, name: name, limiter: limiter}
+}
+
+// acquire waits for a call to the broker to be allowed, returning an error which can be displayed to the user if it
+// is busy.
+func (b limitedBroker) acquire(ctx context.Context) (release func(), err error) {
+	release, err = b.limiter.acquire(ctx)
+	if errors.Is(err, errBrokerBusy) {
+		busyErr := errmessages.ErrRetryable.Wrap(fmt.Errorf("broker %q is busy with too many requests, try again later", b.name))
+		return nil, errmessages.NewToDisplayError(busyErr.WithMetadata(errmessages.MetadataBroker, b.name))
+	}
+	return release, err
+}
+
+// NewSession calls the corresponding method of the broker once allowed by the limiter.
+func (b limitedBroker) NewSession(ctx context.Context, username, lang, mode string) (sessionID, encryptionKey string, err error) {
+	release, err := b.acquire(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	defer release()
+	return b.brokerer.NewSession(ctx, username, lang, mode)
+}
+
+// GetAuthenticationModes calls the corresponding method of the broker once allowed by the limiter.
+func (b limitedBroker) GetAuthenticationModes(ctx context.Context, sessionID string, supportedUILayouts []map[string]string) (authenticationModes []map[string]string, err error) {
+	release, err := b.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return b.brokerer.GetAuthenticationModes(ctx, sessionID, supportedUILayouts)
+}
+
+// SelectAuthenticationMode calls the corresponding method of the broker once allowed by the limiter.
+func (b limitedBroker) SelectAuthenticationMode(ctx context.Context, sessionID, authenticationModeName string) (uiLayoutInfo map[string]string, err error) {
+	release, err := b.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return b.brokerer.SelectAuthenticationMode(ctx, sessionID, authenticationModeName)
+}
+
+// IsAuthenticated calls the corresponding method of the broker once allowed by the limiter, unless the authentication
+// waits for the user.
+func (b limitedBroker) IsAuthenticated(ctx context.Context, sessionID, authenticationData string) (access, data string, err error) {
+	if waitsForUser(authenticationData) {
+		return b.brokerer.IsAuthenticated(ctx, sessionID, authenticationData)
+	}
+	release, err := b.acquire(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	defer release()
+	return b.brokerer.IsAuthenticated(ctx, sessionID, authenticationData)
+}
+
+// isAuthenticatedReply calls IsAuthenticated on the broker once allowed by the limiter, unless the authentication waits
+// for the user, and returns its decoded reply.
+func (b limitedBroker) isAuthenticatedReply(ctx context.Context, sessionID, authenticationData string) (worker.AuthenticationReply, error) {
+	if waitsForUser(authenticationData) {
+		return isAuthenticatedReply(ctx, b.brokerer, sessionID, authenticationData)
+	}
+	release, err := b.acquire(ctx)
+	if err != nil {
+		return worker.AuthenticationReply{}, err
+	}
+	defer release()
+	return isAuthenticatedReply(ctx, b.brokerer, sessionID, authenticationData)
+}
+
+// UserPreCheck calls the corresponding method of the broker once allowed by the limiter.
+func (b limitedBroker) UserPreCheck(ctx context.Context, username string) (userinfo string, err error) {
+	release, err := b.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return b.brokerer.UserPreCheck(ctx, username)
+}
+
+// ListUsers calls the corresponding method of the broker once allowed by the limiter.
+func (b limitedBroker) ListUsers(ctx context.Context) (usersinfo string, err error) {
+	release, err := b.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return b.brokerer.ListUsers(ctx)
+}
+
+// listUsersInfo calls ListUsers on the broker once allowed by the limiter, and returns the decoded users.
+func (b limitedBroker) listUsersInfo(ctx context.Context) ([]types.UserInfo, error) {
+	release, err := b.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return listUsersInfo(ctx, b.brokerer, b.name)
+}
+
+// waitsForUser returns true if the authentication data is of a layout waiting for the user to act elsewhere, like on
+// another device, rather than of a secret the broker checks at once.
+func waitsForUser(authenticationData string) bool {
+	var data map[string]string
+	if err := json.Unmarshal([]byte(authenticationData), &data); err != nil {
+		return false
+	}
+	return data[layouts.Wait] == layouts.True
+}
@@ -0,0 +1,139 @@
+package brokers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/brokers"
+	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/authd/internal/services/errmessages"
+	"github.com/ubuntu/authd/internal/testutils"
+)
+
+func TestCallLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		limits         brokers.CallLimits
+		waitForUser    bool
+		endPendingCall bool
+		cancelQueued   bool
+
+		wantErr          bool
+		wantDisplayError bool
+	}{
+		"Call_is_not_limited_without_limits":               {},
+		"Call_waits_for_the_call_in_progress_to_finish":    {limits: brokers.CallLimits{MaxConcurrent: 1, MaxQueued: 1}, endPendingCall: true},
+		"Call_is_not_limited_below_the_concurrency_limits": {limits: brokers.CallLimits{MaxConcurrent: 2}},
+		"Call_is_not_limited_by_a_wait_for_the_user":       {limits: brokers.CallLimits{MaxConcurrent: 1}, waitForUser: true},
+
+		"Error_when_too_many_calls_are_waiting":             {limits: brokers.CallLimits{MaxConcurrent: 1}, wantErr: true, wantDisplayError: true},
+		"Error_when_the_call_waits_for_longer_than_timeout": {limits: brokers.CallLimits{MaxConcurrent: 1, MaxQueued: 1, QueueTimeout: 100 * time.Millisecond}, wantErr: true, wantDisplayError: true},
+		"Error_when_the_waiting_call_is_cancelled":          {limits: brokers.CallLimits{MaxConcurrent: 1, MaxQueued: 1}, cancelQueued: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m, b := newManagerForSessionTests(t, brokers.WithCallLimits(tc.limits))
+
+			// Keep a call in progress with an authentication waiting until it's cancelled.
+			pendingSessionID, _, err := m.NewSession(context.Background(), b.ID, t.Name()+testutils.IDSeparator+"IA_wait", "some_lang", auth.SessionModeAuth, "")
+			require.NoError(t, err, "Setup: could not start session")
+			sb, err := m.BrokerFromSessionID(pendingSessionID)
+			require.NoError(t, err, "Setup: could not get the broker of the session")
+			authData := "some data"
+			if tc.waitForUser {
+				authData = `{"wait":"true"}`
+			}
+			pendingDone := make(chan struct{})
+			go func() {
+				defer close(pendingDone)
+				_, _, _ = sb.IsAuthenticated(context.Background(), pendingSessionID, authData)
+			}()
+			t.Cleanup(func() {
+				_ = m.TerminateSession(context.Background(), pendingSessionID)
+				<-pendingDone
+			})
+			if tc.waitForUser {
+				require.Eventually(t, func() bool {
+					sessions := m.Sessions()
+					return len(sessions) == 1 && sessions[0].State == brokers.SessionAuthenticating
+				}, 5*time.Second, 10*time.Millisecond, "Setup: the authentication should be in progress")
+				require.Never(t, func() bool { return sb.CallsInProgress() != 0 }, 200*time.Millisecond, 10*time.Millisecond,
+					"Authentication waiting for the user should not be counted as a call in progress")
+			} else if tc.limits.MaxConcurrent > 0 {
+				require.Eventually(t, func() bool { return sb.CallsInProgress() == 1 }, 5*time.Second, 10*time.Millisecond,
+					"Setup: the authentication should be in progress")
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan error)
+			go func() {
+				_, err := sb.UserPreCheck(ctx, "user-pre-check")
+				done <- err
+			}()
+
+			if tc.endPendingCall || tc.cancelQueued {
+				select {
+				case <-done:
+					t.Fatal("UserPreCheck should wait for the call in progress to finish")
+				case <-time.After(100 * time.Millisecond):
+				}
+			}
+			if tc.endPendingCall {
+				require.NoError(t, m.TerminateSession(context.Background(), pendingSessionID), "Setup: could not end the pending session")
+			}
+			if tc.cancelQueued {
+				cancel()
+			}
+
+			select {
+			case err = <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("UserPreCheck should return")
+			}
+			if !tc.wantErr {
+				require.NoError(t, err, "UserPreCheck should not return an error, but did")
+				return
+			}
+			require.Error(t, err, "UserPreCheck should return an error, but did not")
+			if tc.wantDisplayError {
+				require.ErrorAs(t, err, &errmessages.ToDisplayError{}, "UserPreCheck should return an error to display to the user")
+				require.ErrorContains(t, err, "is busy", "UserPreCheck should tell the broker is busy")
+			}
+		})
+	}
+}
+
+func TestCallLimitsValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		limits brokers.CallLimits
+
+		wantErr bool
+	}{
+		"Default_limits_are_valid": {limits: brokers.DefaultCallLimits},
+		"No_limits_are_valid":      {},
+
+		"Error_when_the_concurrency_limit_is_negative": {limits: brokers.CallLimits{MaxConcurrent: -1}, wantErr: true},
+		"Error_when_the_queue_limit_is_negative":       {limits: brokers.CallLimits{MaxQueued: -1}, wantErr: true},
+		"Error_when_the_queue_timeout_is_negative":     {limits: brokers.CallLimits{QueueTimeout: -time.Second}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.limits.Validate()
+			if tc.wantErr {
+				require.Error(t, err, "Validate should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "Validate should not return an error, but did")
+		})
+	}
+}
@@ -11,8 +11,11 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/godbus/dbus/v5"
+	"github.com/ubuntu/authd/internal/brokers/worker"
 	"github.com/ubuntu/authd/log"
 	"github.com/ubuntu/decorate"
 )
@@ -21,20 +24,111 @@ import (
 type Manager struct {
 	brokers      map[string]*Broker
 	brokersOrder []string
+	brokersMu    sync.RWMutex
+
+	bus             *dbus.Conn
+	brokersConfPath string
+	// worker, if set, calls the D-Bus brokers instead of the daemon.
+	worker *worker.Client
+	// callLimits bound the calls to each D-Bus broker.
+	callLimits CallLimits
 
 	usersToBroker   map[string]*Broker
 	usersToBrokerMu sync.RWMutex
 
 	transactionsToBroker   map[string]*Broker
 	transactionsToBrokerMu sync.RWMutex
+	// shuttingDown is set once no new session can be started, and is protected by transactionsToBrokerMu.
+	shuttingDown bool
+
+	// sessionIdleTimeout is the duration, in nanoseconds, after which the sessions without any activity are ended. 0
+	// disables it.
+	sessionIdleTimeout atomic.Int64
+	reapedSessions     atomic.Uint64
+	stopReaper         context.CancelFunc
+	reaper             sync.WaitGroup
+
+	// sessionPendingTimeout is the duration, in nanoseconds, after which the sessions still waiting for the user or
+	// the broker are ended, unless their broker overrides it. 0 disables it.
+	sessionPendingTimeout atomic.Int64
+	// timedOutSessions are the sessions recently ended because they were pending for too long, with the time they
+	// were ended at, so that their client is told why. They are protected by transactionsToBrokerMu.
+	timedOutSessions      map[string]time.Time
+	timedOutSessionsCount atomic.Uint64
+
+	// sessionLimits bound the sessions in progress of each user and source. The limits, the owners of the sessions in
+	// progress and the sessions being started are protected by transactionsToBrokerMu.
+	sessionLimits    SessionLimits
+	sessionOwners    map[string]sessionOwner
+	reservedSessions map[*sessionOwner]struct{}
 
 	cleanup func()
 }
 
+// ErrShuttingDown is returned when starting a session while authd is shutting down.
+var ErrShuttingDown = errors.New("authd is shutting down")
+
+type options struct {
+	worker                *worker.Client
+	sessionIdleTimeout    time.Duration
+	sessionPendingTimeout time.Duration
+	callLimits            CallLimits
+	sessionLimits         SessionLimits
+}
+
+// Option represents an optional function to override Manager default values.
+type Option func(*options)
+
+// WithWorker calls the D-Bus brokers through the worker, so that their responses are decoded without the privileges
+// of the daemon. By default, the daemon calls them itself.
+func WithWorker(w *worker.Client) Option {
+	return func(o *options) {
+		o.worker = w
+	}
+}
+
+// WithSessionIdleTimeout ends the sessions without any activity for longer than timeout, like the ones abandoned by
+// a client which crashed. By default, the sessions are only ended by their client or when authd stops.
+func WithSessionIdleTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.sessionIdleTimeout = timeout
+	}
+}
+
+// WithSessionPendingTimeout ends the sessions still waiting for the user or the broker timeout after they started, even
+// if their client is still active, to bound the resources used by the abandoned logins. The brokers can override it.
+// By default, the sessions can stay pending forever.
+func WithSessionPendingTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.sessionPendingTimeout = timeout
+	}
+}
+
+// WithCallLimits bounds the calls to each D-Bus broker, so that a burst of logins can't overload it. The calls above
+// the limits fail with an error telling the user to try again later. By default, the calls are not limited.
+func WithCallLimits(limits CallLimits) Option {
+	return func(o *options) {
+		o.callLimits = limits
+	}
+}
+
+// WithSessionLimits bounds the sessions in progress of each user and of each source. The sessions above the limits
+// fail to start with ErrTooManySessions. By default, the sessions are not limited.
+func WithSessionLimits(limits SessionLimits) Option {
+	return func(o *options) {
+		o.sessionLimits = limits
+	}
+}
+
 // NewManager creates a new broker manager object.
-func NewManager(ctx context.Context, brokersConfPath string, configuredBrokers []string) (m *Manager, err error) {
+func NewManager(ctx context.Context, brokersConfPath string, configuredBrokers []string, args ...Option) (m *Manager, err error) {
 	defer decorate.OnError(&err /*i18n.G(*/, "can't create brokers detection object") //)
 
+	var opts options
+	for _, arg := range args {
+		arg(&opts)
+	}
+
 	log.Debug(ctx, "Building broker detection")
 
 	brokersConfPathWithExample, cleanup, err := useExampleBrokers()
@@ -51,15 +145,61 @@ func NewManager(ctx context.Context, brokersConfPath string, configuredBrokers [
 		return m, err
 	}
 
+	m = &Manager{
+		bus:             bus,
+		brokersConfPath: brokersConfPath,
+		worker:          opts.worker,
+		callLimits:      opts.callLimits,
+
+		usersToBroker:        make(map[string]*Broker),
+		transactionsToBroker: make(map[string]*Broker),
+		timedOutSessions:     make(map[string]time.Time),
+		sessionLimits:        opts.sessionLimits,
+		sessionOwners:        make(map[string]sessionOwner),
+		reservedSessions:     make(map[*sessionOwner]struct{}),
+
+		cleanup: cleanup,
+	}
+	if m.brokers, m.brokersOrder, err = m.loadBrokers(ctx, configuredBrokers); err != nil {
+		return nil, err
+	}
+
+	m.SetSessionIdleTimeout(opts.sessionIdleTimeout)
+	m.SetSessionPendingTimeout(opts.sessionPendingTimeout)
+	m.startReaper(ctx)
+
+	return m, nil
+}
+
+// Reload loads the brokers again, detecting the ones added to or removed from the brokers configuration directory if
+// configuredBrokers is empty. The sessions in progress keep using the brokers they were started with.
+func (m *Manager) Reload(ctx context.Context, configuredBrokers []string) (err error) {
+	defer decorate.OnError(&err, "can't reload brokers")
+
+	brokers, brokersOrder, err := m.loadBrokers(ctx, configuredBrokers)
+	if err != nil {
+		return err
+	}
+
+	m.brokersMu.Lock()
+	defer m.brokersMu.Unlock()
+	m.brokers, m.brokersOrder = brokers, brokersOrder
+	log.Infof(ctx, "Brokers reloaded, %d available", len(brokersOrder))
+	return nil
+}
+
+// loadBrokers returns the local broker and the configured brokers, by ID and in preference order. All brokers in the
+// brokers configuration directory are selected in ascii order if none is configured.
+func (m *Manager) loadBrokers(ctx context.Context, configuredBrokers []string) (brokers map[string]*Broker, brokersOrder []string, err error) {
 	// Select all brokers in ascii order if none is configured
 	if len(configuredBrokers) == 0 {
 		log.Debug(ctx, "Auto-detecting brokers")
 
-		entries, err := os.ReadDir(brokersConfPath)
+		entries, err := os.ReadDir(m.brokersConfPath)
 		if errors.Is(err, fs.ErrNotExist) {
-			log.Warningf(ctx, "Broker configuration directory %q does not exist, only local broker will be available", brokersConfPath)
+			log.Warningf(ctx, "Broker configuration directory %q does not exist, only local broker will be available", m.brokersConfPath)
 		} else if err != nil {
-			return m, fmt.Errorf("could not read brokers directory to detect brokers: %v", err)
+			return nil, nil, fmt.Errorf("could not read brokers directory to detect brokers: %v", err)
 		}
 
 		for _, e := range entries {
@@ -74,8 +214,7 @@ func NewManager(ctx context.Context, brokersConfPath string, configuredBrokers [
 		}
 	}
 
-	brokers := make(map[string]*Broker)
-	var brokersOrder []string
+	brokers = make(map[string]*Broker)
 
 	// First broker is always the local one.
 	b, err := newBroker(ctx, "", nil)
@@ -84,29 +223,35 @@ func NewManager(ctx context.Context, brokersConfPath string, configuredBrokers [
 
 	// Load brokers configuration
 	for _, cfgFileName := range configuredBrokers {
-		configFile := filepath.Join(brokersConfPath, cfgFileName)
-		b, err := newBroker(ctx, configFile, bus)
+		configFile := filepath.Join(m.brokersConfPath, cfgFileName)
+		b, err := newBroker(ctx, configFile, m.bus)
 		if err != nil {
 			log.Warningf(ctx, "Skipping broker %q is not correctly configured: %v", cfgFileName, err)
 			continue
 		}
+		if db, ok := b.brokerer.(dbusBroker); ok && m.worker != nil {
+			b.brokerer = newWorkerBroker(m.worker, db)
+		}
+		b.brokerer = newLimitedBroker(b.brokerer, b.Name, m.callLimits)
 		brokersOrder = append(brokersOrder, b.ID)
 		brokers[b.ID] = &b
 	}
 
-	return &Manager{
-		brokers:      brokers,
-		brokersOrder: brokersOrder,
-
-		usersToBroker:        make(map[string]*Broker),
-		transactionsToBroker: make(map[string]*Broker),
+	return brokers, brokersOrder, nil
+}
 
-		cleanup: cleanup,
-	}, nil
+// CheckBus returns an error if the system bus the brokers are called on can't be reached.
+func (m *Manager) CheckBus(ctx context.Context) error {
+	if err := m.bus.BusObject().CallWithContext(ctx, "org.freedesktop.DBus.Peer.Ping", 0).Err; err != nil {
+		return fmt.Errorf("system bus unreachable: %v", err)
+	}
+	return nil
 }
 
 // AvailableBrokers returns currently loaded and available brokers in preference order.
 func (m *Manager) AvailableBrokers() (r []*Broker) {
+	m.brokersMu.RLock()
+	defer m.brokersMu.RUnlock()
 	for _, id := range m.brokersOrder {
 		r = append(r, m.brokers[id])
 	}
@@ -144,6 +289,9 @@ func (m *Manager) BrokerFromSessionID(id string) (broker *Broker, err error) {
 	}
 
 	broker, exists := m.transactionsToBroker[id]
+	if _, timedOut := m.timedOutSessions[id]; !exists && timedOut {
+		return nil, fmt.Errorf("session %q: %w", id, ErrSessionTimedOut)
+	}
 	if !exists {
 		return nil, fmt.Errorf("no broker found for session %q", id)
 	}
@@ -151,29 +299,58 @@ func (m *Manager) BrokerFromSessionID(id string) (broker *Broker, err error) {
 	return broker, nil
 }
 
-// NewSession create a new session for the broker and store the sesssionID on the manager.
-func (m *Manager) NewSession(brokerID, username, lang, mode string) (sessionID string, encryptionKey string, err error) {
+// NewSession create a new session for the broker and store the sesssionID on the manager. The source is where the user
+// logs in from, like a remote host, if known, for the sessions to be limited per source.
+func (m *Manager) NewSession(ctx context.Context, brokerID, username, lang, mode, source string) (sessionID string, encryptionKey string, err error) {
+	m.transactionsToBrokerMu.RLock()
+	shuttingDown := m.shuttingDown
+	m.transactionsToBrokerMu.RUnlock()
+	if shuttingDown {
+		return "", "", ErrShuttingDown
+	}
+
 	broker, err := m.brokerFromID(brokerID)
 	if err != nil {
 		return "", "", fmt.Errorf("invalid broker: %v", err)
 	}
 
-	sessionID, encryptionKey, err = broker.newSession(context.Background(), username, lang, mode)
+	owner, err := m.reserveSession(username, source)
 	if err != nil {
 		return "", "", err
 	}
 
+	// The session is created even if the request is cancelled, so that it's recorded and ended with the others.
+	ctx = context.WithoutCancel(ctx)
+	sessionID, encryptionKey, err = broker.newSession(ctx, username, lang, mode)
+	if err != nil {
+		m.releaseSession(owner)
+		return "", "", err
+	}
+
 	m.transactionsToBrokerMu.Lock()
 	defer m.transactionsToBrokerMu.Unlock()
-	log.Debug(context.Background(), fmt.Sprintf("%s: New session for %q", sessionID, username))
+	if m.shuttingDown {
+		// The shutdown started while the broker was creating the session, which would never be ended otherwise.
+		delete(m.reservedSessions, owner)
+		_ = broker.endSession(ctx, sessionID)
+		return "", "", ErrShuttingDown
+	}
+	log.Debug(ctx, fmt.Sprintf("%s: New session for %q", sessionID, username))
 	m.transactionsToBroker[sessionID] = broker
+	m.startedSession(owner, sessionID)
 	return sessionID, encryptionKey, nil
 }
 
 // EndSession signals the end of the session to the broker associated with the sessionID and then removes the
-// session -> broker mapping.
+// session -> broker mapping. The sessions which timed out are already ended.
 func (m *Manager) EndSession(sessionID string) error {
 	b, err := m.BrokerFromSessionID(sessionID)
+	if errors.Is(err, ErrSessionTimedOut) {
+		m.transactionsToBrokerMu.Lock()
+		delete(m.timedOutSessions, sessionID)
+		m.transactionsToBrokerMu.Unlock()
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -186,20 +363,54 @@ func (m *Manager) EndSession(sessionID string) error {
 	log.Debug(context.Background(), fmt.Sprintf("%s: End session %q",
 		sessionID, m.transactionsToBroker[sessionID].Name))
 	delete(m.transactionsToBroker, sessionID)
+	delete(m.sessionOwners, sessionID)
 	m.transactionsToBrokerMu.Unlock()
 	return nil
 }
 
+// StopSessions refuses any new session, for authd to shut down once the sessions in progress are done.
+func (m *Manager) StopSessions() {
+	m.transactionsToBrokerMu.Lock()
+	defer m.transactionsToBrokerMu.Unlock()
+	m.shuttingDown = true
+}
+
+// EndAllSessions signals the end of all the sessions in progress to their broker, so that none is left open when authd
+// shuts down. The idle sessions are not ended periodically anymore.
+func (m *Manager) EndAllSessions() (err error) {
+	m.stopReaper()
+	m.reaper.Wait()
+
+	m.transactionsToBrokerMu.Lock()
+	sessions := m.transactionsToBroker
+	m.transactionsToBroker = make(map[string]*Broker)
+	clear(m.timedOutSessions)
+	clear(m.sessionOwners)
+	m.transactionsToBrokerMu.Unlock()
+
+	for sessionID, b := range sessions {
+		log.Debugf(context.Background(), "%s: Ending session with broker %q on shutdown", sessionID, b.Name)
+		if endErr := b.endSession(context.Background(), sessionID); endErr != nil {
+			err = errors.Join(err, fmt.Errorf("could not end session %q: %v", sessionID, endErr))
+		}
+	}
+	return err
+}
+
 // BrokerExists returns true if the brokerID is known by the manager. It can
 // happen that a broker which was stored in the database is not available anymore
 // because the user removed the configuration file.
 func (m *Manager) BrokerExists(brokerID string) bool {
+	m.brokersMu.RLock()
+	defer m.brokersMu.RUnlock()
 	_, exists := m.brokers[brokerID]
 	return exists
 }
 
 // brokerFromID returns the broker matching this brokerID.
 func (m *Manager) brokerFromID(id string) (broker *Broker, err error) {
+	m.brokersMu.RLock()
+	defer m.brokersMu.RUnlock()
 	broker, exists := m.brokers[id]
 	if !exists {
 		return nil, fmt.Errorf("no broker found matching %q", id)
@@ -65,6 +65,88 @@ func TestNewManager(t *testing.T) {
 	}
 }
 
+func TestReload(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		addBroker         bool
+		removeBroker      bool
+		configuredBrokers []string
+		confDirIsFile     bool
+
+		wantBrokers []string
+		wantErr     bool
+	}{
+		"Keeps_brokers_when_nothing_changed":                   {wantBrokers: []string{"Initial"}},
+		"Adds_broker_added_to_config_dir":                      {addBroker: true, wantBrokers: []string{"Added", "Initial"}},
+		"Removes_broker_removed_from_config_dir":               {removeBroker: true},
+		"Loads_only_configured_brokers_when_configuredBrokers": {addBroker: true, configuredBrokers: []string{"Added.conf"}, wantBrokers: []string{"Added"}},
+
+		"Error_when_broker_config_dir_becomes_a_file": {confDirIsFile: true, wantErr: true, wantBrokers: []string{"Initial"}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			prefix := strings.ReplaceAll(t.Name(), "/", "_") + "_"
+			brokersConfPath := filepath.Join(t.TempDir(), "broker.d")
+			err := os.Mkdir(brokersConfPath, 0700)
+			require.NoError(t, err, "Setup: could not create brokers configuration directory")
+			initial := newBrokerForTests(t, brokersConfPath, prefix+"Initial.conf")
+
+			m, err := brokers.NewManager(context.Background(), brokersConfPath, nil)
+			require.NoError(t, err, "Setup: could not create manager")
+
+			var initialID string
+			for _, b := range m.AvailableBrokers() {
+				if b.Name == initial.Name {
+					initialID = b.ID
+				}
+			}
+			sessionID, _, err := m.NewSession(context.Background(), initialID, "success", "some_lang", auth.SessionModeAuth, "")
+			require.NoError(t, err, "Setup: could not start session")
+
+			if tc.addBroker {
+				newBrokerForTests(t, brokersConfPath, prefix+"Added.conf")
+			}
+			if tc.removeBroker {
+				err := os.Remove(filepath.Join(brokersConfPath, prefix+"Initial.conf"))
+				require.NoError(t, err, "Setup: could not remove broker configuration")
+			}
+			if tc.confDirIsFile {
+				err := os.RemoveAll(brokersConfPath)
+				require.NoError(t, err, "Setup: could not remove brokers configuration directory")
+				err = os.WriteFile(brokersConfPath, nil, 0600)
+				require.NoError(t, err, "Setup: could not replace brokers configuration directory with a file")
+			}
+			for i := range tc.configuredBrokers {
+				tc.configuredBrokers[i] = prefix + tc.configuredBrokers[i]
+			}
+
+			err = m.Reload(context.Background(), tc.configuredBrokers)
+			if tc.wantErr {
+				require.Error(t, err, "Reload should return an error, but did not")
+			} else {
+				require.NoError(t, err, "Reload should not return an error, but did")
+			}
+
+			want := []string{brokers.LocalBrokerName}
+			for _, b := range tc.wantBrokers {
+				want = append(want, prefix+b)
+			}
+			var got []string
+			for _, b := range m.AvailableBrokers() {
+				got = append(got, b.Name)
+			}
+			require.Equal(t, want, got, "Reload should load the expected brokers")
+
+			b, err := m.BrokerFromSessionID(sessionID)
+			require.NoError(t, err, "The session in progress should be kept after reload")
+			require.Equal(t, initialID, b.ID, "The session in progress should keep its broker after reload")
+		})
+	}
+}
+
 func TestSetDefaultBrokerForUser(t *testing.T) {
 	t.Parallel()
 
@@ -236,7 +318,7 @@ func TestNewSession(t *testing.T) {
 				tc.sessionMode = "auth"
 			}
 
-			gotID, gotEKey, err := m.NewSession(tc.brokerID, tc.username, "some_lang", tc.sessionMode)
+			gotID, gotEKey, err := m.NewSession(context.Background(), tc.brokerID, tc.username, "some_lang", tc.sessionMode, "")
 			if tc.wantErr {
 				require.Error(t, err, "NewSession should return an error, but did not")
 				return
@@ -332,13 +414,13 @@ func TestStartAndEndSession(t *testing.T) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		id, key, err := m.NewSession(b1.ID, "user1", "some_lang", "auth")
+		id, key, err := m.NewSession(context.Background(), b1.ID, "user1", "some_lang", "auth", "")
 		firstID, firstKey, firstErr = &id, &key, &err
 	}()
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		id, key, err := m.NewSession(b2.ID, "user2", "some_lang", "auth")
+		id, key, err := m.NewSession(context.Background(), b2.ID, "user2", "some_lang", "auth", "")
 		secondID, secondKey, secondErr = &id, &key, &err
 	}()
 	wg.Wait()
@@ -385,6 +467,58 @@ func TestStartAndEndSession(t *testing.T) {
 	require.Error(t, err, "Second EndSession should have removed the broker for the session, but did not")
 }
 
+func TestStopSessions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		failingSession bool
+
+		wantErr bool
+	}{
+		"Ends_all_sessions_in_progress": {},
+
+		"Error_when_ending_a_session": {failingSession: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			brokersConfPath := t.TempDir()
+			b := newBrokerForTests(t, brokersConfPath, strings.ReplaceAll(t.Name(), "/", "_")+".conf")
+			m, err := brokers.NewManager(context.Background(), brokersConfPath, []string{b.Name + ".conf"})
+			require.NoError(t, err, "Setup: could not create manager")
+			for _, broker := range m.AvailableBrokers() {
+				if broker.Name == b.Name {
+					b.ID = broker.ID
+				}
+			}
+
+			sessionID, _, err := m.NewSession(context.Background(), b.ID, "user1", "some_lang", "auth", "")
+			require.NoError(t, err, "Setup: could not start session")
+			if tc.failingSession {
+				m.SetBrokerForSession(&b, "ES_error")
+			}
+
+			m.StopSessions()
+
+			_, _, err = m.NewSession(context.Background(), b.ID, "user2", "some_lang", "auth", "")
+			require.ErrorIs(t, err, brokers.ErrShuttingDown, "NewSession should not start a session once the sessions are stopped")
+			_, err = m.BrokerFromSessionID(sessionID)
+			require.NoError(t, err, "The session in progress should be kept until all the sessions are ended")
+
+			err = m.EndAllSessions()
+			if tc.wantErr {
+				require.Error(t, err, "EndAllSessions should return an error, but did not")
+			} else {
+				require.NoError(t, err, "EndAllSessions should not return an error, but did")
+			}
+
+			_, err = m.BrokerFromSessionID(sessionID)
+			require.Error(t, err, "EndAllSessions should have removed the broker for the session, but did not")
+		})
+	}
+}
+
 func TestMain(m *testing.M) {
 	// Start system bus mock.
 	cleanup, err := testutils.StartSystemBusMock()
@@ -0,0 +1,195 @@
+package brokers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/authd/internal/brokers/worker"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"golang.org/x/exp/slices"
+)
+
+// decodingBrokerer is a brokerer which decodes and validates the replies of the broker itself, like the worker does in
+// its unprivileged process, so that the daemon only receives typed results.
+type decodingBrokerer interface {
+	isAuthenticatedReply(ctx context.Context, sessionID, authenticationData string) (worker.AuthenticationReply, error)
+	listUsersInfo(ctx context.Context) ([]types.UserInfo, error)
+}
+
+// isAuthenticatedReply calls IsAuthenticated on b and returns its reply, which is decoded here if b doesn't decode it.
+func isAuthenticatedReply(ctx context.Context, b brokerer, sessionID, authenticationData string) (worker.AuthenticationReply, error) {
+	if d, ok := b.(decodingBrokerer); ok {
+		return d.isAuthenticatedReply(ctx, sessionID, authenticationData)
+	}
+
+	access, data, err := b.IsAuthenticated(ctx, sessionID, authenticationData)
+	if err != nil {
+		return worker.AuthenticationReply{}, err
+	}
+	return decodeAuthenticationReply(access, data)
+}
+
+// listUsersInfo calls ListUsers on b and returns the valid users, which are decoded here if b doesn't decode them.
+func listUsersInfo(ctx context.Context, b brokerer, name string) ([]types.UserInfo, error) {
+	if d, ok := b.(decodingBrokerer); ok {
+		return d.listUsersInfo(ctx)
+	}
+
+	usersInfo, err := b.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return decodeUsersInfo(ctx, name, usersInfo)
+}
+
+// decodeAuthenticationReply decodes and validates the access and the data returned by the broker on IsAuthenticated.
+func decodeAuthenticationReply(access, data string) (reply worker.AuthenticationReply, err error) {
+	if !slices.Contains(auth.Replies, access) {
+		return worker.AuthenticationReply{}, fmt.Errorf("invalid access authentication key: %v", access)
+	}
+	reply.Access = access
+
+	if data == "" {
+		data = "{}"
+	}
+
+	switch access {
+	case auth.Granted:
+		var d string
+		reply.DeviceToken, d, err = DeviceToken(data)
+		if err != nil {
+			return worker.AuthenticationReply{}, err
+		}
+
+		rawUserInfo, err := unmarshalAndGetKey(d, "userinfo")
+		if err != nil {
+			return worker.AuthenticationReply{}, err
+		}
+
+		reply.UserInfo, err = unmarshalUserInfo(rawUserInfo)
+		if err != nil {
+			return worker.AuthenticationReply{}, err
+		}
+
+		if err = validateUserInfo(reply.UserInfo); err != nil {
+			return worker.AuthenticationReply{}, err
+		}
+
+	case auth.Denied:
+		if reply.Message, err = unmarshalMessage(data); err != nil {
+			return worker.AuthenticationReply{}, err
+		}
+
+	case auth.Retry:
+		if reply.Message, err = unmarshalMessage(data); err != nil {
+			return worker.AuthenticationReply{}, err
+		}
+		if reply.EncryptionKey, _, err = RotatedEncryptionKey(data); err != nil {
+			return worker.AuthenticationReply{}, err
+		}
+
+	case auth.Next:
+		// The only data allowed is the encryption key of the next challenges, if the broker rotated it.
+		var remaining string
+		reply.EncryptionKey, remaining, err = RotatedEncryptionKey(data)
+		if err != nil {
+			return worker.AuthenticationReply{}, err
+		}
+		if remaining != "{}" {
+			return worker.AuthenticationReply{}, fmt.Errorf("access mode %q should not return any data, got: %v", access, data)
+		}
+
+	case auth.Cancelled:
+		if data != "{}" {
+			return worker.AuthenticationReply{}, fmt.Errorf("access mode %q should not return any data, got: %v", access, data)
+		}
+	}
+
+	return reply, nil
+}
+
+// encodeAuthenticationReply returns the data of the decoded reply, as sent to the clients.
+func encodeAuthenticationReply(reply worker.AuthenticationReply) (data string, err error) {
+	values := make(map[string]string)
+
+	switch reply.Access {
+	case auth.Granted:
+		userInfo, err := json.Marshal(reply.UserInfo)
+		if err != nil {
+			return "", fmt.Errorf("can't marshal UserInfo: %v", err)
+		}
+		// The device token is kept next to the user information, for the daemon to store it.
+		if reply.DeviceToken == "" {
+			return string(userInfo), nil
+		}
+		return WithDeviceToken(string(userInfo), reply.DeviceToken)
+
+	case auth.Denied, auth.Retry:
+		values["message"] = reply.Message
+		fallthrough
+	case auth.Next:
+		if reply.EncryptionKey != "" {
+			values[encryptionKeyDataKey] = reply.EncryptionKey
+		}
+	case auth.Cancelled:
+	default:
+		return "", fmt.Errorf("invalid access authentication key: %v", reply.Access)
+	}
+
+	d, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("can't marshal data: %v", err)
+	}
+	return string(d), nil
+}
+
+// validateAuthenticationReply checks the reply decoded by another process, which could have been compromised by the
+// broker.
+func validateAuthenticationReply(reply worker.AuthenticationReply) error {
+	if !slices.Contains(auth.Replies, reply.Access) {
+		return fmt.Errorf("invalid access authentication key: %v", reply.Access)
+	}
+	if reply.Access != auth.Granted {
+		return nil
+	}
+	return validateUserInfo(reply.UserInfo)
+}
+
+// decodeUsersInfo decodes the users returned by the broker on ListUsers. Invalid users are skipped.
+func decodeUsersInfo(ctx context.Context, name, usersInfo string) (users []types.UserInfo, err error) {
+	var rawUsers []json.RawMessage
+	if err := json.Unmarshal([]byte(usersInfo), &rawUsers); err != nil {
+		return nil, fmt.Errorf("response returned by the broker is not a valid json list: %v", err)
+	}
+
+	for _, rawUser := range rawUsers {
+		u, err := unmarshalUserInfo(rawUser)
+		if err == nil {
+			err = validateUserInfo(u)
+		}
+		if err != nil {
+			log.Warningf(ctx, "Ignoring user listed by broker %q: %v", name, err)
+			continue
+		}
+		users = append(users, u)
+	}
+
+	return users, nil
+}
+
+// unmarshalMessage returns the message of the data returned by the broker, which must be a string.
+func unmarshalMessage(data string) (string, error) {
+	rawMsg, err := unmarshalAndGetKey(data, "message")
+	if err != nil {
+		return "", err
+	}
+
+	var msg string
+	if err := json.Unmarshal(rawMsg, &msg); err != nil {
+		return "", fmt.Errorf("invalid message returned by the broker, got: %s", rawMsg)
+	}
+	return msg, nil
+}
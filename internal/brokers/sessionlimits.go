@@ -0,0 +1,96 @@
+package brokers
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTooManySessions is returned when starting a session while the user, or the source they log in from, already has
+// as many sessions in progress as allowed.
+var ErrTooManySessions = errors.New("too many authentication sessions in progress")
+
+// SessionLimits bound the authentication sessions in progress at once, so that credential stuffing or a runaway
+// script can't start an unbounded number of them.
+type SessionLimits struct {
+	// PerUser is the number of sessions in progress for a same user, 0 for no limit.
+	PerUser int `mapstructure:"per_user"`
+	// PerSource is the number of sessions in progress from a same source, like a remote host, 0 for no limit. The
+	// sessions without a source are only limited per user.
+	PerSource int `mapstructure:"per_source"`
+}
+
+// DefaultSessionLimits allow 10 sessions in progress for each user and 20 from each source, which is far above what
+// the logins of a person need.
+var DefaultSessionLimits = SessionLimits{
+	PerUser:   10,
+	PerSource: 20,
+}
+
+// Validate returns an error if a limit is negative.
+func (l SessionLimits) Validate() error {
+	if l.PerUser < 0 || l.PerSource < 0 {
+		return errors.New("per_user and per_source can't be negative")
+	}
+	return nil
+}
+
+// sessionOwner is the user of a session and the source they log in from.
+type sessionOwner struct {
+	username string
+	source   string
+}
+
+// SetSessionLimits changes the limits of the sessions in progress. The sessions already above them are kept.
+func (m *Manager) SetSessionLimits(limits SessionLimits) {
+	m.transactionsToBrokerMu.Lock()
+	defer m.transactionsToBrokerMu.Unlock()
+	m.sessionLimits = limits
+}
+
+// reserveSession counts a session about to be started by the user from source in the sessions in progress, and
+// returns it, for it to be recorded with startedSession or removed with releaseSession. It returns ErrTooManySessions
+// if the user or the source already reached their limit.
+func (m *Manager) reserveSession(username, source string) (*sessionOwner, error) {
+	m.transactionsToBrokerMu.Lock()
+	defer m.transactionsToBrokerMu.Unlock()
+
+	var perUser, perSource int
+	count := func(o sessionOwner) {
+		if o.username == username {
+			perUser++
+		}
+		if source != "" && o.source == source {
+			perSource++
+		}
+	}
+	for _, o := range m.sessionOwners {
+		count(o)
+	}
+	for o := range m.reservedSessions {
+		count(*o)
+	}
+
+	if l := m.sessionLimits.PerUser; l > 0 && perUser >= l {
+		return nil, fmt.Errorf("%w for user %q, try again once some of them ended", ErrTooManySessions, username)
+	}
+	if l := m.sessionLimits.PerSource; l > 0 && perSource >= l {
+		return nil, fmt.Errorf("%w from %s, try again once some of them ended", ErrTooManySessions, source)
+	}
+
+	o := &sessionOwner{username: username, source: source}
+	m.reservedSessions[o] = struct{}{}
+	return o, nil
+}
+
+// releaseSession removes a reserved session which failed to start.
+func (m *Manager) releaseSession(o *sessionOwner) {
+	m.transactionsToBrokerMu.Lock()
+	defer m.transactionsToBrokerMu.Unlock()
+	delete(m.reservedSessions, o)
+}
+
+// startedSession records the reserved session as started with the ID. transactionsToBrokerMu must be held.
+func (m *Manager) startedSession(o *sessionOwner, sessionID string) {
+	delete(m.reservedSessions, o)
+	m.sessionOwners[sessionID] = *o
+}
@@ -0,0 +1,120 @@
+package brokers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/brokers"
+	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/authd/internal/testutils"
+)
+
+func TestSessionLimits(t *testing.T) {
+	t.Parallel()
+
+	type session struct {
+		username string
+		source   string
+	}
+
+	tests := map[string]struct {
+		limits   brokers.SessionLimits
+		existing []session
+		next     session
+
+		wantErr bool
+	}{
+		"Start_session_below_the_limits": {
+			limits:   brokers.SessionLimits{PerUser: 1, PerSource: 2},
+			existing: []session{{"user1", "host1"}},
+			next:     session{"user2", "host1"},
+		},
+		"Start_session_of_another_user": {
+			limits:   brokers.SessionLimits{PerUser: 1},
+			existing: []session{{"user1", ""}},
+			next:     session{"user2", ""},
+		},
+		"Start_session_from_another_source": {
+			limits:   brokers.SessionLimits{PerSource: 1},
+			existing: []session{{"user1", "host1"}},
+			next:     session{"user2", "host2"},
+		},
+		"Start_session_without_source_above_the_source_limit": {
+			limits:   brokers.SessionLimits{PerSource: 1},
+			existing: []session{{"user1", ""}},
+			next:     session{"user2", ""},
+		},
+		"Start_sessions_without_limits": {
+			existing: []session{{"user1", "host1"}, {"user2", "host1"}},
+			next:     session{"user3", "host1"},
+		},
+
+		"Error_when_the_user_reached_their_limit": {
+			limits:   brokers.SessionLimits{PerUser: 1},
+			existing: []session{{"user1", "host1"}},
+			next:     session{"user1", "host2"},
+			wantErr:  true,
+		},
+		"Error_when_the_source_reached_its_limit": {
+			limits:   brokers.SessionLimits{PerSource: 2},
+			existing: []session{{"user1", "host1"}, {"user2", "host1"}},
+			next:     session{"user3", "host1"},
+			wantErr:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m, b := newManagerForSessionTests(t, brokers.WithSessionLimits(tc.limits))
+
+			for _, s := range tc.existing {
+				username := t.Name() + testutils.IDSeparator + s.username
+				_, _, err := m.NewSession(context.Background(), b.ID, username, "some_lang", auth.SessionModeAuth, s.source)
+				require.NoError(t, err, "Setup: could not start session")
+			}
+
+			username := t.Name() + testutils.IDSeparator + tc.next.username
+			_, _, err := m.NewSession(context.Background(), b.ID, username, "some_lang", auth.SessionModeAuth, tc.next.source)
+			if tc.wantErr {
+				require.ErrorIs(t, err, brokers.ErrTooManySessions, "NewSession should return ErrTooManySessions")
+				require.Len(t, m.Sessions(), len(tc.existing), "The session above the limits should not be started")
+				return
+			}
+			require.NoError(t, err, "NewSession should not return an error, but did")
+			require.Len(t, m.Sessions(), len(tc.existing)+1, "The session should be started")
+		})
+	}
+}
+
+func TestSessionLimitsRelease(t *testing.T) {
+	t.Parallel()
+
+	m, b := newManagerForSessionTests(t, brokers.WithSessionLimits(brokers.SessionLimits{PerUser: 1, PerSource: 1}))
+	username := t.Name() + testutils.IDSeparator + "success"
+	newSession := func(username string) (string, error) {
+		id, _, err := m.NewSession(context.Background(), b.ID, username, "some_lang", auth.SessionModeAuth, "host1")
+		return id, err
+	}
+
+	_, err := newSession(t.Name() + testutils.IDSeparator + "NS_error")
+	require.Error(t, err, "Setup: the broker should fail to start the session")
+	sessionID, err := newSession(username)
+	require.NoError(t, err, "The sessions which failed to start should not count in the limits")
+
+	_, err = newSession(username)
+	require.ErrorIs(t, err, brokers.ErrTooManySessions, "The sessions above the limits should not start")
+
+	require.NoError(t, m.EndSession(sessionID), "Setup: could not end session")
+	sessionID, err = newSession(username)
+	require.NoError(t, err, "The ended sessions should not count in the limits")
+
+	require.NoError(t, m.TerminateSession(context.Background(), sessionID), "Setup: could not terminate session")
+	_, err = newSession(username)
+	require.NoError(t, err, "The terminated sessions should not count in the limits")
+
+	m.SetSessionLimits(brokers.SessionLimits{})
+	_, err = newSession(t.Name() + testutils.IDSeparator + "other-user")
+	require.NoError(t, err, "The sessions should not be limited once the limits are removed")
+}
@@ -0,0 +1,273 @@
+package brokers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// ErrSessionNotFound is returned when terminating a session which is not in progress.
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrSessionTimedOut is returned when using a session which was ended because it was waiting for the user or the broker
+// for too long.
+var ErrSessionTimedOut = errors.New("the authentication session timed out")
+
+// SessionState is the step an authentication session is at.
+type SessionState string
+
+const (
+	// SessionStarted is a session waiting for an authentication mode to be selected.
+	SessionStarted SessionState = "started"
+	// SessionModeSelected is a session waiting for the user to authenticate with the selected mode.
+	SessionModeSelected SessionState = "mode selected"
+	// SessionAuthenticating is a session whose authentication is being checked by the broker.
+	SessionAuthenticating SessionState = "authenticating"
+	// SessionGranted is a session whose authentication was granted, which the client didn't end yet.
+	SessionGranted SessionState = "granted"
+	// SessionDenied is a session whose authentication was denied, which the client didn't end yet.
+	SessionDenied SessionState = "denied"
+)
+
+// SessionInfo describes an authentication session in progress.
+type SessionInfo struct {
+	ID         string
+	Username   string
+	BrokerID   string
+	BrokerName string
+	// Mode is the mode the session was started with, like authenticating the user or changing their password.
+	Mode string
+	// AuthMode is the last authentication mode selected, if any.
+	AuthMode string
+	State    SessionState
+	Started  time.Time
+	// LastActivity is the time of the last request of the client of the session.
+	LastActivity time.Time
+}
+
+// idleSessionsCheckInterval is the interval between two checks of the idle and pending sessions, which are ended up to
+// this long after their timeout.
+const idleSessionsCheckInterval = 30 * time.Second
+
+// timedOutSessionsRetention is how long the sessions which timed out are remembered, for their client to be told why
+// they were ended.
+const timedOutSessionsRetention = time.Hour
+
+// updateSession records a request of the client of the session and changes its information, unless it has been
+// ended.
+func (b Broker) updateSession(sessionID string, update func(*SessionInfo)) {
+	b.ongoingSessionsInfoMu.Lock()
+	defer b.ongoingSessionsInfoMu.Unlock()
+
+	if s, ok := b.ongoingSessionsInfo[sessionID]; ok {
+		update(s)
+		s.LastActivity = time.Now()
+	}
+}
+
+// session returns a copy of the information of the session.
+func (b Broker) session(sessionID string) (s SessionInfo, ok bool) {
+	b.ongoingSessionsInfoMu.Lock()
+	defer b.ongoingSessionsInfoMu.Unlock()
+
+	info, ok := b.ongoingSessionsInfo[b.parseSessionID(sessionID)]
+	if !ok {
+		return SessionInfo{}, false
+	}
+	return *info, true
+}
+
+// pending returns true if the session is waiting for the user or the broker, before its authentication is granted or
+// denied.
+func (s SessionInfo) pending() bool {
+	return s.State != SessionGranted && s.State != SessionDenied
+}
+
+// stateAfterAuthentication returns the state of a session once the broker answered whether it is authenticated.
+func stateAfterAuthentication(access string, err error) SessionState {
+	if err != nil {
+		return SessionModeSelected
+	}
+
+	switch access {
+	case auth.Granted:
+		return SessionGranted
+	case auth.Denied:
+		return SessionDenied
+	case auth.Next:
+		return SessionStarted
+	default:
+		return SessionModeSelected
+	}
+}
+
+// Sessions returns the authentication sessions in progress, the oldest first.
+func (m *Manager) Sessions() []SessionInfo {
+	m.transactionsToBrokerMu.RLock()
+	defer m.transactionsToBrokerMu.RUnlock()
+
+	var sessions []SessionInfo
+	for id, b := range m.transactionsToBroker {
+		s, ok := b.session(id)
+		if !ok {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	slices.SortFunc(sessions, func(a, b SessionInfo) int {
+		if c := a.Started.Compare(b.Started); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return sessions
+}
+
+// TerminateSession ends a session in progress on behalf of an administrator, cancelling its pending authentication if
+// any. The session is forgotten by the daemon even if its broker fails to end it, so that a stuck session can always
+// be cleaned up.
+func (m *Manager) TerminateSession(ctx context.Context, sessionID string) (err error) {
+	defer decorate.OnError(&err, "could not terminate session %q", sessionID)
+
+	m.transactionsToBrokerMu.Lock()
+	b, ok := m.transactionsToBroker[sessionID]
+	delete(m.transactionsToBroker, sessionID)
+	delete(m.sessionOwners, sessionID)
+	m.transactionsToBrokerMu.Unlock()
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	log.Debugf(ctx, "%s: Terminating session with broker %q", sessionID, b.Name)
+	b.cancelIsAuthenticated(ctx, b.parseSessionID(sessionID))
+	if err := b.endSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("the session was removed, but the broker failed to end it: %v", err)
+	}
+	return nil
+}
+
+// SetSessionIdleTimeout changes the duration without any activity after which the sessions are ended. 0 disables it.
+func (m *Manager) SetSessionIdleTimeout(timeout time.Duration) {
+	m.sessionIdleTimeout.Store(int64(timeout))
+}
+
+// SetSessionPendingTimeout changes the duration after which the sessions still waiting for the user or the broker are
+// ended, for the brokers which don't override it. 0 disables it.
+func (m *Manager) SetSessionPendingTimeout(timeout time.Duration) {
+	m.sessionPendingTimeout.Store(int64(timeout))
+}
+
+// ReapedSessions returns the number of sessions ended because they were idle since the start of the daemon.
+func (m *Manager) ReapedSessions() uint64 {
+	return m.reapedSessions.Load()
+}
+
+// TimedOutSessions returns the number of sessions ended because they were pending for too long since the start of the
+// daemon.
+func (m *Manager) TimedOutSessions() uint64 {
+	return m.timedOutSessionsCount.Load()
+}
+
+// startReaper periodically ends the idle and the pending sessions which timed out, until EndAllSessions is called.
+func (m *Manager) startReaper(ctx context.Context) {
+	ctx, m.stopReaper = context.WithCancel(context.WithoutCancel(ctx))
+
+	m.reaper.Add(1)
+	go func() {
+		defer m.reaper.Done()
+
+		ticker := time.NewTicker(idleSessionsCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				m.reapPendingSessions(ctx, now)
+				m.reapIdleSessions(ctx, now)
+			}
+		}
+	}()
+}
+
+// reapIdleSessions ends the sessions without any activity for longer than the idle timeout at the given time. The
+// sessions whose authentication is being checked by their broker are not idle, as their client is waiting for it.
+func (m *Manager) reapIdleSessions(ctx context.Context, now time.Time) {
+	timeout := time.Duration(m.sessionIdleTimeout.Load())
+	if timeout <= 0 {
+		return
+	}
+
+	for _, s := range m.Sessions() {
+		if s.State == SessionAuthenticating || now.Sub(s.LastActivity) <= timeout {
+			continue
+		}
+
+		log.Infof(ctx, "%s: Ending session of %q with broker %q, idle since %s", s.ID, s.Username, s.BrokerName,
+			s.LastActivity.Format(time.RFC3339))
+		err := m.TerminateSession(ctx, s.ID)
+		if errors.Is(err, ErrSessionNotFound) {
+			// The client ended it in the meantime.
+			continue
+		}
+		m.reapedSessions.Add(1)
+		if err != nil {
+			log.Warningf(ctx, "Could not end idle session: %v", err)
+		}
+	}
+}
+
+// reapPendingSessions ends the sessions which are still waiting for the user or the broker longer than the pending
+// timeout after they started, at the given time, even if their client is active. Their pending authentication is
+// cancelled, and their client gets ErrSessionTimedOut.
+func (m *Manager) reapPendingSessions(ctx context.Context, now time.Time) {
+	m.transactionsToBrokerMu.Lock()
+	maps.DeleteFunc(m.timedOutSessions, func(_ string, t time.Time) bool { return now.Sub(t) > timedOutSessionsRetention })
+	m.transactionsToBrokerMu.Unlock()
+
+	for _, s := range m.Sessions() {
+		if !s.pending() {
+			continue
+		}
+
+		m.transactionsToBrokerMu.Lock()
+		b, ok := m.transactionsToBroker[s.ID]
+		timeout := time.Duration(m.sessionPendingTimeout.Load())
+		if ok && b.Sessions.PendingTimeout != nil {
+			timeout = *b.Sessions.PendingTimeout
+		}
+		timedOut := ok && timeout > 0 && now.Sub(s.Started) > timeout
+		if timedOut {
+			// The session is marked before being ended, for its pending authentication to fail with the right error.
+			m.timedOutSessions[s.ID] = now
+		}
+		m.transactionsToBrokerMu.Unlock()
+		if !timedOut {
+			continue
+		}
+
+		log.Infof(ctx, "%s: Ending session of %q with broker %q, pending since %s", s.ID, s.Username, s.BrokerName,
+			s.Started.Format(time.RFC3339))
+		err := m.TerminateSession(ctx, s.ID)
+		if errors.Is(err, ErrSessionNotFound) {
+			// The client ended it in the meantime.
+			m.transactionsToBrokerMu.Lock()
+			delete(m.timedOutSessions, s.ID)
+			m.transactionsToBrokerMu.Unlock()
+			continue
+		}
+		m.timedOutSessionsCount.Add(1)
+		if err != nil {
+			log.Warningf(ctx, "Could not end pending session: %v", err)
+		}
+	}
+}
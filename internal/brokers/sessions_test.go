@@ -0,0 +1,310 @@
+package brokers_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/brokers"
+	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/authd/internal/testutils"
+)
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username     string
+		authenticate bool
+
+		wantState brokers.SessionState
+	}{
+		"Started_session":                   {username: "success", wantState: brokers.SessionStarted},
+		"Granted_session":                   {username: "success", authenticate: true, wantState: brokers.SessionGranted},
+		"Session_waiting_for_the_next_step": {username: "IA_next", authenticate: true, wantState: brokers.SessionStarted},
+
+		"Session_keeps_its_mode_on_authentication_error": {username: "IA_error", authenticate: true, wantState: brokers.SessionModeSelected},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m, b := newManagerForSessionTests(t)
+
+			before := time.Now().Truncate(time.Second)
+			username := t.Name() + testutils.IDSeparator + tc.username
+			sessionID, _, err := m.NewSession(context.Background(), b.ID, username, "some_lang", auth.SessionModeAuth, "")
+			require.NoError(t, err, "Setup: could not start session")
+
+			if tc.authenticate {
+				sb, err := m.BrokerFromSessionID(sessionID)
+				require.NoError(t, err, "Setup: could not get the broker of the session")
+				_, _, _ = sb.IsAuthenticated(context.Background(), sessionID, "some data")
+			}
+
+			sessions := m.Sessions()
+			require.Len(t, sessions, 1, "Sessions should return the session in progress")
+			got := sessions[0]
+			require.False(t, got.Started.Before(before), "The session should have started after the test started")
+			require.False(t, got.LastActivity.Before(got.Started), "The last activity should not be before the start of the session")
+			got.Started, got.LastActivity = time.Time{}, time.Time{}
+			require.Equal(t, brokers.SessionInfo{
+				ID:         sessionID,
+				Username:   username,
+				BrokerID:   b.ID,
+				BrokerName: b.Name,
+				Mode:       auth.SessionModeAuth,
+				State:      tc.wantState,
+			}, got, "Sessions should return the information of the session")
+
+			require.NoError(t, m.EndSession(sessionID), "Setup: could not end session")
+			require.Empty(t, m.Sessions(), "Sessions should not return the ended sessions")
+		})
+	}
+}
+
+func TestTerminateSession(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username       string
+		authenticating bool
+		unknownSession bool
+
+		wantErr   bool
+		wantErrIs error
+	}{
+		"Terminate_an_idle_session":                         {username: "success"},
+		"Terminate_a_session_with_a_pending_authentication": {username: "IA_wait", authenticating: true},
+
+		"Error_when_the_session_does_not_exist": {unknownSession: true, wantErr: true, wantErrIs: brokers.ErrSessionNotFound},
+		"Error_when_the_broker_fails_to_end_it": {username: "ES_error", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m, b := newManagerForSessionTests(t)
+
+			username := t.Name() + testutils.IDSeparator + tc.username
+			sessionID, _, err := m.NewSession(context.Background(), b.ID, username, "some_lang", auth.SessionModeAuth, "")
+			require.NoError(t, err, "Setup: could not start session")
+
+			authDone := make(chan string)
+			if tc.authenticating {
+				sb, err := m.BrokerFromSessionID(sessionID)
+				require.NoError(t, err, "Setup: could not get the broker of the session")
+				go func() {
+					access, _, _ := sb.IsAuthenticated(context.Background(), sessionID, "some data")
+					authDone <- access
+				}()
+				require.Eventually(t, func() bool {
+					sessions := m.Sessions()
+					return len(sessions) == 1 && sessions[0].State == brokers.SessionAuthenticating
+				}, 5*time.Second, 10*time.Millisecond, "Setup: the session should be authenticating")
+			}
+
+			id := sessionID
+			if tc.unknownSession {
+				id = "does not exist"
+			}
+			err = m.TerminateSession(context.Background(), id)
+			if tc.wantErrIs != nil {
+				require.ErrorIs(t, err, tc.wantErrIs, "TerminateSession should return the expected error")
+			}
+			if tc.wantErr {
+				require.Error(t, err, "TerminateSession should return an error, but did not")
+			} else {
+				require.NoError(t, err, "TerminateSession should not return an error, but did")
+			}
+
+			if tc.authenticating {
+				select {
+				case access := <-authDone:
+					require.Equal(t, auth.Cancelled, access, "The pending authentication should be cancelled")
+				case <-time.After(5 * time.Second):
+					t.Fatal("The pending authentication should be cancelled")
+				}
+			}
+
+			if tc.unknownSession {
+				require.Len(t, m.Sessions(), 1, "TerminateSession should not remove the other sessions")
+				return
+			}
+			require.Empty(t, m.Sessions(), "TerminateSession should remove the session, even if the broker failed to end it")
+			_, err = m.BrokerFromSessionID(sessionID)
+			require.Error(t, err, "TerminateSession should have removed the broker for the session")
+		})
+	}
+}
+
+func TestReapIdleSessions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username       string
+		authenticating bool
+		timeout        time.Duration
+		idleFor        time.Duration
+
+		wantReaped bool
+	}{
+		"Idle_session_is_ended":                          {username: "success", timeout: time.Minute, idleFor: 2 * time.Minute, wantReaped: true},
+		"Idle_session_is_ended_even_if_its_broker_fails": {username: "ES_error", timeout: time.Minute, idleFor: 2 * time.Minute, wantReaped: true},
+
+		"Active_session_is_kept":                      {username: "success", timeout: time.Minute, idleFor: 30 * time.Second},
+		"Authenticating_session_is_kept":              {username: "IA_wait", authenticating: true, timeout: time.Minute, idleFor: 2 * time.Minute},
+		"Idle_session_is_kept_if_the_timeout_is_zero": {username: "success", idleFor: time.Hour},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m, b := newManagerForSessionTests(t, brokers.WithSessionIdleTimeout(tc.timeout))
+
+			username := t.Name() + testutils.IDSeparator + tc.username
+			sessionID, _, err := m.NewSession(context.Background(), b.ID, username, "some_lang", auth.SessionModeAuth, "")
+			require.NoError(t, err, "Setup: could not start session")
+
+			if tc.authenticating {
+				sb, err := m.BrokerFromSessionID(sessionID)
+				require.NoError(t, err, "Setup: could not get the broker of the session")
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					_, _, _ = sb.IsAuthenticated(context.Background(), sessionID, "some data")
+				}()
+				t.Cleanup(func() {
+					_ = m.TerminateSession(context.Background(), sessionID)
+					<-done
+				})
+				require.Eventually(t, func() bool {
+					sessions := m.Sessions()
+					return len(sessions) == 1 && sessions[0].State == brokers.SessionAuthenticating
+				}, 5*time.Second, 10*time.Millisecond, "Setup: the session should be authenticating")
+			}
+
+			m.ReapIdleSessions(time.Now().Add(tc.idleFor))
+
+			if !tc.wantReaped {
+				require.Len(t, m.Sessions(), 1, "ReapIdleSessions should keep the session")
+				require.Zero(t, m.ReapedSessions(), "ReapIdleSessions should not count any reaped session")
+				return
+			}
+			require.Empty(t, m.Sessions(), "ReapIdleSessions should end the idle session")
+			_, err = m.BrokerFromSessionID(sessionID)
+			require.Error(t, err, "ReapIdleSessions should have removed the broker for the session")
+			require.Equal(t, uint64(1), m.ReapedSessions(), "ReapIdleSessions should count the reaped session")
+		})
+	}
+}
+
+func TestReapPendingSessions(t *testing.T) {
+	t.Parallel()
+
+	oneMinute, zero := time.Minute, time.Duration(0)
+
+	tests := map[string]struct {
+		username       string
+		authenticating bool
+		granted        bool
+		timeout        time.Duration
+		brokerTimeout  *time.Duration
+		pendingFor     time.Duration
+
+		wantReaped bool
+	}{
+		"Pending_session_is_ended":                          {username: "success", timeout: time.Minute, pendingFor: 2 * time.Minute, wantReaped: true},
+		"Pending_session_is_ended_even_if_its_broker_fails": {username: "ES_error", timeout: time.Minute, pendingFor: 2 * time.Minute, wantReaped: true},
+		"Authenticating_session_is_ended":                   {username: "IA_wait", authenticating: true, timeout: time.Minute, pendingFor: 2 * time.Minute, wantReaped: true},
+		"Pending_session_is_ended_with_the_timeout_of_its_broker": {
+			username: "success", brokerTimeout: &oneMinute, pendingFor: 2 * time.Minute, wantReaped: true,
+		},
+
+		"Recent_session_is_kept":                         {username: "success", timeout: time.Minute, pendingFor: 30 * time.Second},
+		"Granted_session_is_kept":                        {username: "success", granted: true, timeout: time.Minute, pendingFor: 2 * time.Minute},
+		"Pending_session_is_kept_if_the_timeout_is_zero": {username: "success", pendingFor: time.Hour},
+		"Pending_session_is_kept_if_its_broker_disables_the_timeout": {
+			username: "success", timeout: time.Minute, brokerTimeout: &zero, pendingFor: 2 * time.Minute,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m, b := newManagerForSessionTests(t, brokers.WithSessionPendingTimeout(tc.timeout))
+			if tc.brokerTimeout != nil {
+				m.SetBrokerSessionsConfig(b.ID, brokers.SessionsConfig{PendingTimeout: tc.brokerTimeout})
+			}
+
+			username := t.Name() + testutils.IDSeparator + tc.username
+			sessionID, _, err := m.NewSession(context.Background(), b.ID, username, "some_lang", auth.SessionModeAuth, "")
+			require.NoError(t, err, "Setup: could not start session")
+			sb, err := m.BrokerFromSessionID(sessionID)
+			require.NoError(t, err, "Setup: could not get the broker of the session")
+
+			if tc.granted {
+				access, _, err := sb.IsAuthenticated(context.Background(), sessionID, "some data")
+				require.NoError(t, err, "Setup: could not authenticate")
+				require.Equal(t, auth.Granted, access, "Setup: the authentication should be granted")
+			}
+
+			var access string
+			done := make(chan struct{})
+			if tc.authenticating {
+				go func() {
+					defer close(done)
+					access, _, _ = sb.IsAuthenticated(context.Background(), sessionID, "some data")
+				}()
+				t.Cleanup(func() {
+					_ = m.TerminateSession(context.Background(), sessionID)
+					<-done
+				})
+				require.Eventually(t, func() bool {
+					sessions := m.Sessions()
+					return len(sessions) == 1 && sessions[0].State == brokers.SessionAuthenticating
+				}, 5*time.Second, 10*time.Millisecond, "Setup: the session should be authenticating")
+			}
+
+			m.ReapPendingSessions(time.Now().Add(tc.pendingFor))
+
+			if !tc.wantReaped {
+				require.Len(t, m.Sessions(), 1, "ReapPendingSessions should keep the session")
+				require.Zero(t, m.TimedOutSessions(), "ReapPendingSessions should not count any timed out session")
+				return
+			}
+			require.Empty(t, m.Sessions(), "ReapPendingSessions should end the pending session")
+			require.Equal(t, uint64(1), m.TimedOutSessions(), "ReapPendingSessions should count the timed out session")
+			if tc.authenticating {
+				<-done
+				require.Equal(t, auth.Cancelled, access, "ReapPendingSessions should cancel the authentication in progress")
+			}
+
+			_, err = m.BrokerFromSessionID(sessionID)
+			require.ErrorIs(t, err, brokers.ErrSessionTimedOut, "BrokerFromSessionID should tell the session timed out")
+			require.NoError(t, m.EndSession(sessionID), "EndSession should succeed for a session which timed out")
+			_, err = m.BrokerFromSessionID(sessionID)
+			require.NotErrorIs(t, err, brokers.ErrSessionTimedOut, "BrokerFromSessionID should forget the session once ended")
+		})
+	}
+}
+
+// newManagerForSessionTests returns a manager with a single broker, which is returned with its ID.
+func newManagerForSessionTests(t *testing.T, args ...brokers.Option) (*brokers.Manager, brokers.Broker) {
+	t.Helper()
+
+	brokersConfPath := t.TempDir()
+	b := newBrokerForTests(t, brokersConfPath, strings.ReplaceAll(t.Name(), "/", "_")+".conf")
+	m, err := brokers.NewManager(context.Background(), brokersConfPath, []string{b.Name + ".conf"}, args...)
+	require.NoError(t, err, "Setup: could not create manager")
+	t.Cleanup(func() { _ = m.EndAllSessions() })
+	for _, broker := range m.AvailableBrokers() {
+		if broker.Name == b.Name {
+			b.ID = broker.ID
+		}
+	}
+
+	return m, b
+}
@@ -0,0 +1,160 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+	"golang.org/x/sys/unix"
+)
+
+// Client calls the brokers through the worker.
+type Client struct {
+	mu sync.Mutex
+	// rpc is the client of the running worker.
+	rpc *rpc.Client
+	// exited is closed when the worker process exits.
+	exited chan struct{}
+	closed bool
+
+	// start starts a new worker process, nil if the worker is not a process started by the client.
+	start func() (*rpc.Client, chan struct{}, error)
+}
+
+// NewClient returns a client calling the worker served on conn.
+func NewClient(conn io.ReadWriteCloser) *Client {
+	return &Client{rpc: rpc.NewClient(conn)}
+}
+
+// Start starts the worker process with the given user and group IDs, and returns its client. The worker is started
+// again if it exits.
+func Start(ctx context.Context, uid, gid uint32) (c *Client, err error) {
+	defer decorate.OnError(&err, "could not start broker worker")
+
+	c = &Client{start: func() (*rpc.Client, chan struct{}, error) { return startProcess(ctx, uid, gid) }}
+	if c.rpc, c.exited, err = c.start(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// startProcess starts the worker process with the given user and group IDs, connected to the daemon with a socket
+// pair.
+func startProcess(ctx context.Context, uid, gid uint32) (*rpc.Client, chan struct{}, error) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	local, remote := os.NewFile(uintptr(fds[0]), "broker-worker"), os.NewFile(uintptr(fds[1]), "daemon")
+	defer local.Close()
+	defer remote.Close()
+
+	cmd := exec.Command("/proc/self/exe", Command)
+	// The socket is the file descriptor 3 of the worker.
+	cmd.ExtraFiles = []*os.File{remote}
+	// The logs of the worker are printed with the ones of the daemon.
+	cmd.Stderr = os.Stderr
+	// The worker exits when the daemon closes its end of the socket, including when the daemon dies.
+	cmd.SysProcAttr = &syscall.SysProcAttr{}
+	if uid != uint32(os.Getuid()) || gid != uint32(os.Getgid()) {
+		cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uid, Gid: gid, Groups: []uint32{}}
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := net.FileConn(local)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, nil, err
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		err := cmd.Wait()
+		log.Debugf(ctx, "Broker worker %d exited: %v", cmd.Process.Pid, err)
+	}()
+	log.Debugf(ctx, "Broker worker started with PID %d, UID %d and GID %d", cmd.Process.Pid, uid, gid)
+
+	return rpc.NewClient(conn), exited, nil
+}
+
+// Call calls the method of the broker of the request through the worker, which is started again if it exited.
+//
+// The call is not cancelled in the worker when ctx is done, as the calls to the brokers are not cancelled either but
+// with their cancellation method.
+func (c *Client) Call(ctx context.Context, req Request) (resp Response, err error) {
+	client, err := c.client(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	call := client.Go(serviceName+".Call", req, &resp, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	}
+	if call.Error != nil {
+		return Response{}, fmt.Errorf("broker worker failed: %w", call.Error)
+	}
+	return resp, nil
+}
+
+// client returns the client of the running worker, starting it again if it exited.
+func (c *Client) client(ctx context.Context) (*rpc.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, rpc.ErrShutdown
+	}
+	if c.exited == nil {
+		return c.rpc, nil
+	}
+	select {
+	case <-c.exited:
+	default:
+		return c.rpc, nil
+	}
+
+	log.Warning(ctx, "Broker worker exited, starting it again")
+	_ = c.rpc.Close()
+	client, exited, err := c.start()
+	if err != nil {
+		return nil, fmt.Errorf("could not start broker worker again: %w", err)
+	}
+	c.rpc, c.exited = client, exited
+	return client, nil
+}
+
+// Close stops the worker, which exits once the connection is closed.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	err := c.rpc.Close()
+	if errors.Is(err, rpc.ErrShutdown) {
+		err = nil
+	}
+	if c.exited != nil {
+		<-c.exited
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+// Package worker runs the calls to the D-Bus brokers in an unprivileged process, so that the messages sent by the
+// brokers are decoded without root privileges. The worker is the daemon executable itself, started with the Command
+// argument, which serves the calls of the daemon on the file descriptor it inherits.
+package worker
+
+import (
+	"context"
+	"io"
+	"net/rpc"
+
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+// Command is the hidden command of the daemon running the worker.
+const Command = "broker-worker"
+
+// Target is the D-Bus broker called by the worker.
+type Target struct {
+	Name       string
+	DbusName   string
+	DbusObject string
+}
+
+// Request is a call of a method of a broker.
+type Request struct {
+	Target Target
+	Method string
+	// Args are the string arguments of the method, in order.
+	Args []string
+	// Layouts are the UI layouts supported by the client, for GetAuthenticationModes.
+	Layouts []map[string]string
+}
+
+// Response is the result of a call of a method of a broker.
+type Response struct {
+	// Values are the strings returned by the method, in order.
+	Values []string
+	// Maps are the maps returned by the method, like the authentication modes.
+	Maps []map[string]string
+	// Reply is the decoded reply of IsAuthenticated, and Users the decoded users of ListUsers, so that the daemon
+	// doesn't parse the messages of the brokers.
+	Reply AuthenticationReply
+	Users []types.UserInfo
+
+	// Err is the error returned by the method, if any. ErrToDisplay is set if it can be displayed to the user, and
+	// ErrNotSupported if the broker doesn't provide the method.
+	Err             string
+	ErrToDisplay    bool
+	ErrNotSupported bool
+}
+
+// AuthenticationReply is the reply of a broker to IsAuthenticated, once decoded and validated.
+type AuthenticationReply struct {
+	Access string
+	// Message is the message of the denied and retry replies.
+	Message string
+	// EncryptionKey is the key the broker rotated to on the retry and next replies, if it did.
+	EncryptionKey string
+	// UserInfo is the user information of the granted replies, and DeviceToken the token the broker issued, if any.
+	UserInfo    types.UserInfo
+	DeviceToken string
+}
+
+// Handler calls the method of the broker of the request.
+type Handler func(ctx context.Context, req Request) Response
+
+// service is the RPC service served by the worker.
+type service struct {
+	handler Handler
+}
+
+// Call calls the method of the broker of the request. The errors of the broker are returned in the response, so that
+// they are not mixed up with the ones of the worker.
+func (s service) Call(req Request, resp *Response) error {
+	*resp = s.handler(context.Background(), req)
+	return nil
+}
+
+// Serve serves the calls of the daemon on conn with handler, until conn is closed.
+func Serve(conn io.ReadWriteCloser, handler Handler) error {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(serviceName, service{handler: handler}); err != nil {
+		return err
+	}
+	srv.ServeConn(conn)
+	return nil
+}
+
+// serviceName is the name of the RPC service of the worker.
+const serviceName = "Worker"
@@ -0,0 +1,95 @@
+package worker_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/brokers/worker"
+)
+
+// echo returns the arguments of the request, and exits the worker on the "Exit" method.
+func echo(_ context.Context, req worker.Request) worker.Response {
+	switch req.Method {
+	case "Exit":
+		os.Exit(0)
+	case "Error":
+		return worker.Response{Err: "requested error", ErrToDisplay: true}
+	}
+	return worker.Response{Values: append([]string{req.Target.Name, req.Method}, req.Args...), Maps: req.Layouts}
+}
+
+func TestCall(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		req worker.Request
+
+		want worker.Response
+	}{
+		"Returns_the_values_of_the_handler": {
+			req:  worker.Request{Target: worker.Target{Name: "broker"}, Method: "Method", Args: []string{"a", "b"}, Layouts: []map[string]string{{"type": "form"}}},
+			want: worker.Response{Values: []string{"broker", "Method", "a", "b"}, Maps: []map[string]string{{"type": "form"}}},
+		},
+		"Returns_the_error_of_the_handler": {
+			req:  worker.Request{Method: "Error"},
+			want: worker.Response{Err: "requested error", ErrToDisplay: true},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w, err := worker.Start(context.Background(), uint32(os.Getuid()), uint32(os.Getgid()))
+			require.NoError(t, err, "Setup: Start should not return an error")
+			t.Cleanup(func() { require.NoError(t, w.Close(), "Teardown: Close should not return an error") })
+
+			got, err := w.Call(context.Background(), tc.req)
+			require.NoError(t, err, "Call should not return an error")
+			require.Equal(t, tc.want, got, "Call should return the response of the handler")
+		})
+	}
+}
+
+func TestCallRestartsExitedWorker(t *testing.T) {
+	t.Parallel()
+
+	w, err := worker.Start(context.Background(), uint32(os.Getuid()), uint32(os.Getgid()))
+	require.NoError(t, err, "Setup: Start should not return an error")
+	t.Cleanup(func() { require.NoError(t, w.Close(), "Teardown: Close should not return an error") })
+
+	_, err = w.Call(context.Background(), worker.Request{Method: "Exit"})
+	require.Error(t, err, "Call should return an error when the worker exits")
+
+	// The worker is started again once the exit of the previous one is noticed.
+	require.Eventually(t, func() bool {
+		got, err := w.Call(context.Background(), worker.Request{Method: "Method"})
+		return err == nil && len(got.Values) == 2
+	}, 5*time.Second, 10*time.Millisecond, "Call should start the worker again")
+}
+
+func TestCallAfterClose(t *testing.T) {
+	t.Parallel()
+
+	w, err := worker.Start(context.Background(), uint32(os.Getuid()), uint32(os.Getgid()))
+	require.NoError(t, err, "Setup: Start should not return an error")
+	require.NoError(t, w.Close(), "Close should not return an error")
+	require.NoError(t, w.Close(), "Close should not return an error when called twice")
+
+	_, err = w.Call(context.Background(), worker.Request{Method: "Method"})
+	require.Error(t, err, "Call should return an error once the worker is closed")
+}
+
+func TestMain(m *testing.M) {
+	// The worker started by the tests is the test binary itself.
+	if len(os.Args) > 1 && os.Args[1] == worker.Command {
+		if err := worker.Serve(os.NewFile(3, "daemon"), echo); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,255 @@
+package brokers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/ubuntu/authd/internal/brokers/worker"
+	"github.com/ubuntu/authd/internal/services/errmessages"
+	"github.com/ubuntu/authd/internal/tracing"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// workerBroker is a D-Bus broker called through the unprivileged worker, which decodes its messages.
+type workerBroker struct {
+	worker *worker.Client
+	target worker.Target
+}
+
+// newWorkerBroker returns the broker calling the D-Bus broker b through the worker.
+func newWorkerBroker(w *worker.Client, b dbusBroker) workerBroker {
+	return workerBroker{
+		worker: w,
+		target: worker.Target{
+			Name:       b.name,
+			DbusName:   b.dbusObject.Destination(),
+			DbusObject: string(b.dbusObject.Path()),
+		},
+	}
+}
+
+// NewSession calls the corresponding method of the broker through the worker.
+func (b workerBroker) NewSession(ctx context.Context, username, lang, mode string) (sessionID, encryptionKey string, err error) {
+	resp, err := b.call(ctx, worker.Request{Method: "NewSession", Args: []string{username, lang, mode}})
+	if err != nil {
+		return "", "", err
+	}
+	if len(resp.Values) != 2 {
+		return "", "", fmt.Errorf("broker worker returned %d values for NewSession, expected 2", len(resp.Values))
+	}
+	return resp.Values[0], resp.Values[1], nil
+}
+
+// GetAuthenticationModes calls the corresponding method of the broker through the worker.
+func (b workerBroker) GetAuthenticationModes(ctx context.Context, sessionID string, supportedUILayouts []map[string]string) (authenticationModes []map[string]string, err error) {
+	resp, err := b.call(ctx, worker.Request{Method: "GetAuthenticationModes", Args: []string{sessionID}, Layouts: supportedUILayouts})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Maps, nil
+}
+
+// SelectAuthenticationMode calls the corresponding method of the broker through the worker.
+func (b workerBroker) SelectAuthenticationMode(ctx context.Context, sessionID, authenticationModeName string) (uiLayoutInfo map[string]string, err error) {
+	resp, err := b.call(ctx, worker.Request{Method: "SelectAuthenticationMode", Args: []string{sessionID, authenticationModeName}})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Maps) != 1 {
+		return nil, fmt.Errorf("broker worker returned %d layouts for SelectAuthenticationMode, expected 1", len(resp.Maps))
+	}
+	return resp.Maps[0], nil
+}
+
+// IsAuthenticated calls the corresponding method of the broker through the worker.
+func (b workerBroker) IsAuthenticated(ctx context.Context, sessionID, authenticationData string) (access, data string, err error) {
+	reply, err := b.isAuthenticatedReply(ctx, sessionID, authenticationData)
+	if err != nil {
+		return "", "", err
+	}
+	data, err = encodeAuthenticationReply(reply)
+	if err != nil {
+		return "", "", err
+	}
+	return reply.Access, data, nil
+}
+
+// isAuthenticatedReply calls IsAuthenticated on the broker through the worker, which decodes and validates its reply.
+func (b workerBroker) isAuthenticatedReply(ctx context.Context, sessionID, authenticationData string) (worker.AuthenticationReply, error) {
+	// We don’t want to cancel the context when the parent call is cancelled.
+	resp, err := b.call(context.WithoutCancel(ctx), worker.Request{Method: "IsAuthenticated", Args: []string{sessionID, authenticationData}})
+	if err != nil {
+		return worker.AuthenticationReply{}, err
+	}
+	if err := validateAuthenticationReply(resp.Reply); err != nil {
+		return worker.AuthenticationReply{}, fmt.Errorf("broker worker returned an invalid reply: %w", err)
+	}
+	return resp.Reply, nil
+}
+
+// EndSession calls the corresponding method of the broker through the worker.
+func (b workerBroker) EndSession(ctx context.Context, sessionID string) (err error) {
+	_, err = b.call(ctx, worker.Request{Method: "EndSession", Args: []string{sessionID}})
+	return err
+}
+
+// CancelIsAuthenticated calls the corresponding method of the broker through the worker.
+func (b workerBroker) CancelIsAuthenticated(ctx context.Context, sessionID string) {
+	// We don’t want to cancel the context when the parent call is cancelled.
+	if _, err := b.call(context.WithoutCancel(ctx), worker.Request{Method: "CancelIsAuthenticated", Args: []string{sessionID}}); err != nil {
+		log.Errorf(ctx, "could not cancel IsAuthenticated call for session %q: %v", sessionID, err)
+	}
+}
+
+// UserPreCheck calls the corresponding method of the broker through the worker.
+func (b workerBroker) UserPreCheck(ctx context.Context, username string) (userinfo string, err error) {
+	resp, err := b.call(ctx, worker.Request{Method: "UserPreCheck", Args: []string{username}})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Values) != 1 {
+		return "", fmt.Errorf("broker worker returned %d values for UserPreCheck, expected 1", len(resp.Values))
+	}
+	return resp.Values[0], nil
+}
+
+// ListUsers calls the corresponding method of the broker through the worker.
+func (b workerBroker) ListUsers(ctx context.Context) (usersinfo string, err error) {
+	users, err := b.listUsersInfo(ctx)
+	if err != nil {
+		return "", err
+	}
+	d, err := json.Marshal(users)
+	if err != nil {
+		return "", fmt.Errorf("can't marshal users: %v", err)
+	}
+	return string(d), nil
+}
+
+// listUsersInfo calls ListUsers on the broker through the worker, which decodes and validates the users.
+func (b workerBroker) listUsersInfo(ctx context.Context) ([]types.UserInfo, error) {
+	resp, err := b.call(ctx, worker.Request{Method: "ListUsers"})
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range resp.Users {
+		if err := validateUserInfo(u); err != nil {
+			return nil, fmt.Errorf("broker worker returned an invalid user: %w", err)
+		}
+	}
+	return resp.Users, nil
+}
+
+// call calls the method of the request on the broker through the worker, and returns the error of the broker as the
+// D-Bus broker does.
+func (b workerBroker) call(ctx context.Context, req worker.Request) (_ worker.Response, err error) {
+	ctx, span := tracing.Start(ctx, "broker."+req.Method, attribute.String("broker.name", b.target.Name))
+	defer func() { tracing.End(span, err) }()
+
+	req.Target = b.target
+	resp, err := b.worker.Call(ctx, req)
+	if err != nil {
+		return worker.Response{}, errmessages.NewToDisplayError(fmt.Errorf("couldn't call broker %q: %v", b.target.Name, err))
+	}
+
+	switch {
+	case resp.ErrNotSupported:
+		return worker.Response{}, ErrListUsersNotSupported
+	case resp.ErrToDisplay:
+		return worker.Response{}, errmessages.NewToDisplayError(errors.New(resp.Err))
+	case resp.Err != "":
+		return worker.Response{}, errors.New(resp.Err)
+	}
+	return resp, nil
+}
+
+// ServeWorker serves the calls of the daemon to the D-Bus brokers on conn, as the unprivileged worker, until conn is
+// closed.
+func ServeWorker(ctx context.Context, conn io.ReadWriteCloser) error {
+	// Don't call dbus.SystemBus which caches globally system dbus (issues in tests)
+	bus, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return err
+	}
+	defer bus.Close()
+
+	log.Debug(ctx, "Broker worker ready")
+	return worker.Serve(conn, func(ctx context.Context, req worker.Request) worker.Response {
+		b := dbusBroker{
+			name:       req.Target.Name,
+			dbusObject: bus.Object(req.Target.DbusName, dbus.ObjectPath(req.Target.DbusObject)),
+		}
+		return callDbusBroker(ctx, b, req)
+	})
+}
+
+// callDbusBroker calls the method of the request on the D-Bus broker.
+func callDbusBroker(ctx context.Context, b dbusBroker, req worker.Request) (resp worker.Response) {
+	wantArgs := map[string]int{
+		"NewSession":               3,
+		"GetAuthenticationModes":   1,
+		"SelectAuthenticationMode": 2,
+		"IsAuthenticated":          2,
+		"EndSession":               1,
+		"CancelIsAuthenticated":    1,
+		"UserPreCheck":             1,
+		"ListUsers":                0,
+	}
+	n, ok := wantArgs[req.Method]
+	if !ok {
+		return worker.Response{Err: fmt.Sprintf("unknown broker method %q", req.Method)}
+	}
+	if len(req.Args) != n {
+		return worker.Response{Err: fmt.Sprintf("%s expects %d arguments, got %d", req.Method, n, len(req.Args))}
+	}
+	args := req.Args
+
+	var err error
+	switch req.Method {
+	case "NewSession":
+		var sessionID, encryptionKey string
+		sessionID, encryptionKey, err = b.NewSession(ctx, args[0], args[1], args[2])
+		resp.Values = []string{sessionID, encryptionKey}
+	case "GetAuthenticationModes":
+		resp.Maps, err = b.GetAuthenticationModes(ctx, args[0], req.Layouts)
+	case "SelectAuthenticationMode":
+		var layout map[string]string
+		layout, err = b.SelectAuthenticationMode(ctx, args[0], args[1])
+		resp.Maps = []map[string]string{layout}
+	case "IsAuthenticated":
+		// The reply is decoded and validated here, so that the daemon doesn't parse the messages of the broker.
+		var access, data string
+		access, data, err = b.IsAuthenticated(ctx, args[0], args[1])
+		if err == nil {
+			resp.Reply, err = decodeAuthenticationReply(access, data)
+		}
+	case "EndSession":
+		err = b.EndSession(ctx, args[0])
+	case "CancelIsAuthenticated":
+		b.CancelIsAuthenticated(ctx, args[0])
+	case "UserPreCheck":
+		var userinfo string
+		userinfo, err = b.UserPreCheck(ctx, args[0])
+		resp.Values = []string{userinfo}
+	case "ListUsers":
+		var usersinfo string
+		usersinfo, err = b.ListUsers(ctx)
+		if err == nil {
+			resp.Users, err = decodeUsersInfo(ctx, b.name, usersinfo)
+		}
+	}
+	if err == nil {
+		return resp
+	}
+
+	resp = worker.Response{Err: err.Error()}
+	resp.ErrToDisplay = errors.As(err, &errmessages.ToDisplayError{})
+	resp.ErrNotSupported = errors.Is(err, ErrListUsersNotSupported)
+	return resp
+}
@@ -0,0 +1,142 @@
+package brokers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/brokers"
+	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/authd/internal/brokers/worker"
+	"github.com/ubuntu/authd/internal/services/errmessages"
+	"github.com/ubuntu/authd/internal/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestWorkerBroker(t *testing.T) {
+	t.Parallel()
+
+	b := newBrokerForTests(t, "", "")
+
+	daemonConn, workerConn := net.Pipe()
+	served := make(chan error, 1)
+	go func() { served <- brokers.ServeWorker(context.Background(), workerConn) }()
+	w := worker.NewClient(daemonConn)
+	t.Cleanup(func() {
+		require.NoError(t, w.Close(), "Teardown: Close should not return an error")
+		require.NoError(t, <-served, "Teardown: ServeWorker should not return an error")
+	})
+	wb := b.ThroughWorker(w)
+
+	tests := map[string]struct {
+		call func(t *testing.T, b brokers.Broker) (string, error)
+	}{
+		"Get_authentication_modes": {call: func(t *testing.T, b brokers.Broker) (string, error) {
+			modes, err := b.GetAuthenticationModes(context.Background(), prefixID(t, "GAM_multiple_modes"), []map[string]string{supportedLayouts["required-entry"]})
+			return fmt.Sprint(modes), err
+		}},
+		"Select_authentication_mode": {call: func(t *testing.T, b brokers.Broker) (string, error) {
+			brokers.GenerateLayoutValidators(&b, prefixID(t, "SAM_success_required_entry"), []map[string]string{supportedLayouts["required-entry"]})
+			layout, err := b.SelectAuthenticationMode(context.Background(), prefixID(t, "SAM_success_required_entry"), "mode1")
+			return fmt.Sprint(layout), err
+		}},
+		"Authenticate_user": {call: func(t *testing.T, b brokers.Broker) (string, error) {
+			b.AddOngoingUserRequest(prefixID(t, "success"), t.Name()+testutils.IDSeparator+"success")
+			access, data, err := b.IsAuthenticated(context.Background(), prefixID(t, "success"), "password")
+			return access + " " + data, err
+		}},
+		"Retry_authentication_with_rotated_key": {call: func(t *testing.T, b brokers.Broker) (string, error) {
+			b.AddOngoingUserRequest(prefixID(t, "IA_retry_with_rotated_key"), t.Name()+testutils.IDSeparator+"IA_retry_with_rotated_key")
+			access, data, err := b.IsAuthenticated(context.Background(), prefixID(t, "IA_retry_with_rotated_key"), "password")
+			return access + " " + data, err
+		}},
+		"Pre-check_user": {call: func(t *testing.T, b brokers.Broker) (string, error) {
+			return b.UserPreCheck(context.Background(), "user-pre-check")
+		}},
+
+		"Error_when_getting_authentication_modes": {call: func(t *testing.T, b brokers.Broker) (string, error) {
+			modes, err := b.GetAuthenticationModes(context.Background(), prefixID(t, "GAM_error"), nil)
+			return fmt.Sprint(modes), err
+		}},
+		"Error_when_authenticating": {call: func(t *testing.T, b brokers.Broker) (string, error) {
+			access, data, err := b.IsAuthenticated(context.Background(), prefixID(t, "IA_error"), "password")
+			return access + " " + data, err
+		}},
+		"Error_when_broker_returns_invalid_userinfo": {call: func(t *testing.T, b brokers.Broker) (string, error) {
+			access, data, err := b.IsAuthenticated(context.Background(), prefixID(t, "IA_invalid_userinfo"), "password")
+			return access + " " + data, err
+		}},
+		"Error_when_user_is_not_available": {call: func(t *testing.T, b brokers.Broker) (string, error) {
+			return b.UserPreCheck(context.Background(), "unexistent")
+		}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			want, wantErr := tc.call(t, b)
+			got, err := tc.call(t, wb)
+
+			require.Equal(t, want, got, "The broker should return the same result through the worker")
+			if wantErr == nil {
+				require.NoError(t, err, "The broker should not return an error through the worker")
+				return
+			}
+			require.EqualError(t, err, wantErr.Error(), "The broker should return the same error through the worker")
+			require.Equal(t, errors.As(wantErr, &errmessages.ToDisplayError{}), errors.As(err, &errmessages.ToDisplayError{}),
+				"The error should be displayable through the worker as it is without")
+		})
+	}
+}
+
+func TestWorkerBrokerRejectsInvalidReplies(t *testing.T) {
+	t.Parallel()
+
+	b := newBrokerForTests(t, "", "")
+
+	tests := map[string]struct {
+		resp worker.Response
+		call func(b brokers.Broker) error
+	}{
+		"Error_if_worker_returns_invalid_access": {
+			resp: worker.Response{Reply: worker.AuthenticationReply{Access: "some-access"}},
+			call: func(b brokers.Broker) error {
+				_, _, err := b.IsAuthenticated(context.Background(), "some-session", "password")
+				return err
+			},
+		},
+		"Error_if_worker_returns_invalid_userinfo": {
+			resp: worker.Response{Reply: worker.AuthenticationReply{Access: auth.Granted, UserInfo: types.UserInfo{Dir: "/home/user1"}}},
+			call: func(b brokers.Broker) error {
+				_, _, err := b.IsAuthenticated(context.Background(), "some-session", "password")
+				return err
+			},
+		},
+		"Error_if_worker_returns_invalid_user": {
+			resp: worker.Response{Users: []types.UserInfo{{Name: "user1", Dir: "home"}}},
+			call: func(b brokers.Broker) error {
+				_, err := b.ListUsers(context.Background())
+				return err
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// The worker could have been compromised by the broker, so its replies are validated again by the daemon.
+			daemonConn, workerConn := net.Pipe()
+			go func() {
+				_ = worker.Serve(workerConn, func(context.Context, worker.Request) worker.Response { return tc.resp })
+			}()
+			w := worker.NewClient(daemonConn)
+			t.Cleanup(func() { _ = w.Close() })
+
+			err := tc.call(b.ThroughWorker(w))
+			require.ErrorContains(t, err, "broker worker returned an invalid", "The invalid reply of the worker should be rejected")
+		})
+	}
+}
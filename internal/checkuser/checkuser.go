@@ -0,0 +1,194 @@
+// Package checkuser resolves a user the way the system and the daemon do, from the local files to the brokers, and
+// reports each step of the resolution, to debug why a user is or isn't resolved without running a second daemon.
+package checkuser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ubuntu/authd/internal/brokers"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/localentries"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+// Config is the environment the user is resolved in.
+type Config struct {
+	// PasswdFile is the path of the local passwd file, whose users are resolved before the ones of authd.
+	PasswdFile string
+	CacheDir   string
+	// BrokersConfPath is the directory of the configuration files of the brokers, and Brokers the names of the files
+	// of the configured ones, all of them if empty.
+	BrokersConfPath string
+	Brokers         []string
+}
+
+// Step is a step of the resolution of the user.
+type Step struct {
+	// Source is where the user was looked up, like the database or a broker.
+	Source  string
+	Message string
+}
+
+// Result is the resolution of a user.
+type Result struct {
+	Steps []Step
+	// Entry is the passwd entry the user resolves to, nil if it's not resolved.
+	Entry *types.UserEntry
+	// ResolvedBy is the source of the entry.
+	ResolvedBy string
+}
+
+// timeout is the time the pre-check of the user can take in each broker.
+const timeout = 5 * time.Second
+
+// Run resolves the user in the same order as the system and the daemon: the local users first, then the users of the
+// database of authd and last the users known by the brokers. The daemon gives its UID to a user known by a broker when
+// the user is looked up, so it's not set in the entry of such a user.
+func Run(ctx context.Context, config Config, username string) (r Result) {
+	if r.checkLocalFile(config.PasswdFile, username) {
+		return r
+	}
+	if r.checkDatabase(config.CacheDir, username) {
+		return r
+	}
+	r.checkBrokers(ctx, config.BrokersConfPath, config.Brokers, username)
+	return r
+}
+
+// Print prints the steps of the resolution of the user to w, then the entry it resolves to, if any.
+func Print(w io.Writer, r Result) {
+	for _, s := range r.Steps {
+		fmt.Fprintf(w, "[%s] %s\n", s.Source, s.Message)
+	}
+	if r.Entry == nil {
+		fmt.Fprintln(w, "The user is not resolved")
+		return
+	}
+	e := r.Entry
+	fmt.Fprintf(w, "Resolved by %s: %s:x:%d:%d:%s:%s:%s\n", r.ResolvedBy, e.Name, e.UID, e.GID, e.Gecos, e.Dir, e.Shell)
+}
+
+// addStep records a step of the resolution.
+func (r *Result) addStep(source, format string, a ...any) {
+	r.Steps = append(r.Steps, Step{Source: source, Message: fmt.Sprintf(format, a...)})
+}
+
+// resolve records the entry the user resolves to.
+func (r *Result) resolve(source string, entry types.UserEntry) {
+	r.Entry = &entry
+	r.ResolvedBy = source
+}
+
+// checkLocalFile looks up the user in the local passwd file, and returns true if it's found there, as the local users
+// are resolved by the files NSS source before authd.
+func (r *Result) checkLocalFile(path, username string) (resolved bool) {
+	const source = "local files"
+
+	users, err := localentries.LocalUsers(localentries.WithPasswdPath(path))
+	if err != nil {
+		r.addStep(source, "can't check the local users: %v", err)
+		return false
+	}
+	for _, u := range users {
+		if u.Name != username {
+			continue
+		}
+		r.addStep(source, "%q is a local user of %s, which is resolved before authd", username, path)
+		r.resolve(source, types.UserEntry{Name: u.Name, UID: u.UID, GID: u.GID, Gecos: u.Gecos, Dir: u.Dir, Shell: u.Shell})
+		return true
+	}
+
+	r.addStep(source, "%q is not a local user of %s", username, path)
+	return false
+}
+
+// checkDatabase looks up the user in the database of authd, and returns true if it's found there, as the daemon
+// doesn't ask the brokers about the users it knows.
+func (r *Result) checkDatabase(cacheDir, username string) (resolved bool) {
+	const source = "database"
+
+	c, err := cache.NewReadOnlyIfUnlocked(cacheDir)
+	if errors.Is(err, cache.LockedError{}) {
+		r.addStep(source, "the database is in use by authd, the user may be in it")
+		return false
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		r.addStep(source, "there is no database yet, no user has logged in")
+		return false
+	}
+	if err != nil {
+		r.addStep(source, "can't open the database: %v", err)
+		return false
+	}
+	defer c.Close()
+
+	u, err := c.UserByName(username)
+	if errors.Is(err, cache.NoDataFoundError{}) {
+		r.addStep(source, "%q is not in the database, it hasn't logged in yet or was removed", username)
+		return false
+	}
+	if err != nil {
+		r.addStep(source, "can't look up %q: %v", username, err)
+		return false
+	}
+
+	msg := fmt.Sprintf("%q is in the database", username)
+	brokerID, err := c.BrokerForUser(username)
+	if err != nil {
+		msg += fmt.Sprintf(", its broker can't be looked up: %v", err)
+	} else if brokerID != "" {
+		msg += fmt.Sprintf(", with the broker %q", brokerID)
+	}
+	if u.Locked {
+		msg += ", and is locked"
+	}
+	r.addStep(source, "%s", msg)
+	r.resolve(source, types.UserEntry{Name: u.Name, UID: u.UID, GID: u.GID, Gecos: u.Gecos, Dir: u.Dir, Shell: u.Shell})
+	return true
+}
+
+// checkBrokers asks the brokers, in order, whether they know the user, like the daemon does for the users it doesn't
+// know yet. The local broker is skipped, as its users are the local ones.
+func (r *Result) checkBrokers(ctx context.Context, confPath string, configured []string, username string) {
+	m, err := brokers.NewManager(ctx, confPath, configured)
+	if err != nil {
+		r.addStep("brokers", "%v", err)
+		return
+	}
+
+	for _, b := range m.AvailableBrokers() {
+		if b.ID == brokers.LocalBrokerName {
+			continue
+		}
+		source := fmt.Sprintf("broker %s", b.Name)
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		userinfo, err := b.UserPreCheck(ctx, username)
+		cancel()
+		if err != nil {
+			r.addStep(source, "the broker doesn't know %q: %v", username, err)
+			continue
+		}
+		if userinfo == "" {
+			r.addStep(source, "the broker doesn't know %q", username)
+			continue
+		}
+
+		var u types.UserEntry
+		if err := json.Unmarshal([]byte(userinfo), &u); err != nil {
+			r.addStep(source, "the broker returned invalid data for %q, authd doesn't resolve it: %v", username, err)
+			return
+		}
+		r.addStep(source, "the broker knows %q, authd gives it a UID when it's looked up", username)
+		r.resolve(source, u)
+		return
+	}
+
+	r.addStep("brokers", "no broker knows %q", username)
+}
@@ -0,0 +1,129 @@
+package checkuser_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/checkuser"
+	"github.com/ubuntu/authd/internal/testutils"
+	"github.com/ubuntu/authd/internal/users/cache"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username      string
+		localUser     bool
+		userInDB      bool
+		databaseInUse bool
+		noDatabase    bool
+		noBroker      bool
+
+		wantResolvedBy string
+		wantSteps      []string
+	}{
+		"Resolve_local_user":           {username: "user-pre-check", localUser: true, userInDB: true, wantResolvedBy: "local files", wantSteps: []string{"local files"}},
+		"Resolve_user_of_the_database": {username: "user-pre-check", userInDB: true, wantResolvedBy: "database", wantSteps: []string{"local files", "database"}},
+		"Resolve_user_known_by_broker": {username: "user-pre-check", wantResolvedBy: "broker", wantSteps: []string{"local files", "database", "broker"}},
+		"Resolve_user_known_by_broker_when_database_is_in_use": {
+			username: "user-pre-check", userInDB: true, databaseInUse: true, wantResolvedBy: "broker", wantSteps: []string{"local files", "database", "broker"},
+		},
+		"Resolve_user_known_by_broker_when_database_does_not_exist": {
+			username: "user-pre-check", noDatabase: true, wantResolvedBy: "broker", wantSteps: []string{"local files", "database", "broker"},
+		},
+
+		"Error_when_no_broker_knows_the_user": {username: "unknown-user", wantSteps: []string{"local files", "database", "broker", "brokers"}},
+		"Error_when_no_broker_is_configured":  {username: "user-pre-check", noBroker: true, wantSteps: []string{"local files", "database", "brokers"}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			config := checkuser.Config{
+				PasswdFile:      filepath.Join(dir, "passwd"),
+				CacheDir:        filepath.Join(dir, "cache"),
+				BrokersConfPath: filepath.Join(dir, "brokers.d"),
+			}
+
+			passwd := "root:x:0:0:root:/root:/bin/bash\n"
+			if tc.localUser {
+				passwd += fmt.Sprintf("%s:x:1000:1000:Local user:/home/%[1]s:/bin/bash\n", tc.username)
+			}
+			require.NoError(t, os.WriteFile(config.PasswdFile, []byte(passwd), 0600), "Setup: could not write passwd file")
+
+			require.NoError(t, os.MkdirAll(config.CacheDir, 0700), "Setup: could not create cache directory")
+			if !tc.noDatabase {
+				c, err := cache.New(config.CacheDir)
+				require.NoError(t, err, "Setup: could not create database")
+				if tc.userInDB {
+					u := cache.UserDB{Name: tc.username, UID: 1111, GID: 1111, Dir: "/home/" + tc.username, Shell: "/bin/sh"}
+					err := c.UpdateUserEntry(u, []cache.GroupDB{cache.NewGroupDB(tc.username, 1111, "ugid", nil)}, nil)
+					require.NoError(t, err, "Setup: could not add user to database")
+					require.NoError(t, c.UpdateBrokerForUser(tc.username, "broker-id"), "Setup: could not set broker of user")
+				}
+				if tc.databaseInUse {
+					t.Cleanup(func() { _ = c.Close() })
+				} else {
+					require.NoError(t, c.Close(), "Setup: could not close database")
+				}
+			}
+
+			require.NoError(t, os.MkdirAll(config.BrokersConfPath, 0700), "Setup: could not create brokers directory")
+			if !tc.noBroker {
+				_, stop, err := testutils.StartBusBrokerMock(config.BrokersConfPath, strings.ReplaceAll(t.Name(), "/", "_"))
+				require.NoError(t, err, "Setup: could not start bus broker mock")
+				t.Cleanup(stop)
+			}
+
+			r := checkuser.Run(context.Background(), config, tc.username)
+
+			var gotSteps []string
+			for _, s := range r.Steps {
+				// The steps of the brokers are named after them.
+				source, _, _ := strings.Cut(s.Source, " ")
+				if source == "local" {
+					source = s.Source
+				}
+				gotSteps = append(gotSteps, source)
+			}
+			require.Equal(t, tc.wantSteps, gotSteps, "Run should go through the expected steps")
+
+			source, _, _ := strings.Cut(r.ResolvedBy, " ")
+			if source == "local" {
+				source = r.ResolvedBy
+			}
+			require.Equal(t, tc.wantResolvedBy, source, "Run should resolve the user with the expected source")
+
+			var out bytes.Buffer
+			checkuser.Print(&out, r)
+			if tc.wantResolvedBy == "" {
+				require.Nil(t, r.Entry, "Run should not return an entry for a user which is not resolved")
+				require.Contains(t, out.String(), "The user is not resolved", "Print should print that the user is not resolved")
+				return
+			}
+			require.NotNil(t, r.Entry, "Run should return the entry of the resolved user")
+			require.Equal(t, tc.username, r.Entry.Name, "Run should return the entry of the user")
+			require.Contains(t, out.String(), "Resolved by "+r.ResolvedBy+": "+tc.username+":x:", "Print should print the resolved entry")
+		})
+	}
+}
+
+func TestMain(m *testing.M) {
+	// Start system bus mock.
+	cleanup, err := testutils.StartSystemBusMock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+
+	m.Run()
+}
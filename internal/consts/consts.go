@@ -27,6 +27,14 @@ const (
 	// DefaultCacheDir is the default directory for the database.
 	DefaultCacheDir = "/var/lib/authd/"
 
+	// DefaultCrashReportsDir is the default directory where the reports of the recovered panics are written, for
+	// apport.
+	DefaultCrashReportsDir = "/var/crash/"
+
+	// DefaultUserDBSocketPath is the default socket of the io.systemd.UserDatabase varlink service, in the directory
+	// where systemd looks for the services of its user database.
+	DefaultUserDBSocketPath = "/run/systemd/userdb/com.ubuntu.authd"
+
 	// ServiceName is the authd service name for health check purposes.
 	ServiceName = "com.ubuntu.authd"
 )
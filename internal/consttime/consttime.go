@@ -0,0 +1,15 @@
+// Package consttime compares secrets, like passwords, codes, tokens and nonces, in constant time, so that how long
+// the comparison takes doesn't tell how much of the secret was guessed.
+package consttime
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// Equal returns true if the secrets are equal, in a time which only depends on their lengths. Their digests are
+// compared, so that the time doesn't tell either whether the secrets have the same length.
+func Equal[T ~string | ~[]byte](a, b T) bool {
+	da, db := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(da[:], db[:]) == 1
+}
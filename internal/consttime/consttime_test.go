@@ -0,0 +1,211 @@
+package consttime_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/consttime"
+)
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		a, b string
+
+		want bool
+	}{
+		"Equal_secrets":       {a: "some secret", b: "some secret", want: true},
+		"Equal_empty_secrets": {a: "", b: "", want: true},
+
+		"Different_secrets":                  {a: "some secret", b: "other secret"},
+		"Secrets_differing_on_the_last_byte": {a: "some secret", b: "some secreT"},
+		"Secret_prefix_of_the_other":         {a: "some", b: "some secret"},
+		"Empty_secret":                       {a: "", b: "some secret"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.want, consttime.Equal(tc.a, tc.b), "Equal should compare the strings")
+			require.Equal(t, tc.want, consttime.Equal([]byte(tc.a), []byte(tc.b)), "Equal should compare the bytes")
+			require.Equal(t, tc.want, consttime.Equal(tc.b, tc.a), "Equal should be symmetric")
+		})
+	}
+}
+
+// secretName matches the names of the values holding secrets, like expectedSecret or DeviceToken, but not the ones
+// of their properties, like PasswordExpiresAt.
+var secretName = regexp.MustCompile(`(?i)(password|passwd|passphrase|secret|token|challenge|nonce|pincode|otp)s?$`)
+
+// ignoreDirective is the comment ignoring the comparisons of its line and of the next one, for the values named like
+// secrets which are not. It must be followed by the reason.
+const ignoreDirective = "//consttime:ignore "
+
+// lintedDirs are the directories of the sources comparing secrets, from the root of the module.
+var lintedDirs = []string{"cmd", "examplebroker", "internal", "log", "pam"}
+
+// TestSecretsAreComparedInConstantTime flags the comparisons of secrets with ==, != or bytes.Equal, whose time tells
+// how much of the secret matched. They must use consttime.Equal instead. Only whether a secret is set can be checked
+// directly, by comparing it to "" or nil.
+func TestSecretsAreComparedInConstantTime(t *testing.T) {
+	t.Parallel()
+
+	var found []string
+	for _, dir := range lintedDirs {
+		err := filepath.WalkDir(filepath.Join("..", "..", dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && (d.Name() == "testdata" || d.Name() == "testutils") {
+				return filepath.SkipDir
+			}
+			if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") ||
+				strings.HasSuffix(path, ".pb.go") {
+				return nil
+			}
+			comparisons, err := secretComparisons(path)
+			found = append(found, comparisons...)
+			return err
+		})
+		require.NoError(t, err, "Setup: could not parse the sources of %s", dir)
+	}
+
+	require.Empty(t, found, "Secrets should be compared with consttime.Equal")
+}
+
+// secretComparisons returns the positions of the comparisons of secrets which don't run in constant time in the file.
+func secretComparisons(path string) (found []string, err error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	ignored := make(map[int]bool)
+	for _, g := range f.Comments {
+		for _, c := range g.List {
+			if strings.HasPrefix(c.Text, ignoreDirective) {
+				line := fset.Position(c.Pos()).Line
+				ignored[line], ignored[line+1] = true, true
+			}
+		}
+	}
+
+	imports := make(map[string]string)
+	for _, i := range f.Imports {
+		p, _ := strconv.Unquote(i.Path.Value)
+		name := filepath.Base(p)
+		if i.Name != nil {
+			name = i.Name.Name
+		}
+		imports[name] = p
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		var operands []ast.Expr
+		switch n := n.(type) {
+		case *ast.BinaryExpr:
+			if n.Op != token.EQL && n.Op != token.NEQ {
+				return true
+			}
+			operands = []ast.Expr{n.X, n.Y}
+		case *ast.CallExpr:
+			fun, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok || len(n.Args) != 2 {
+				return true
+			}
+			pkg, ok := fun.X.(*ast.Ident)
+			if !ok || imports[pkg.Name] != "bytes" || fun.Sel.Name != "Equal" {
+				return true
+			}
+			operands = n.Args
+		default:
+			return true
+		}
+
+		if isUnset(operands[0]) || isUnset(operands[1]) || ignored[fset.Position(n.Pos()).Line] {
+			return true
+		}
+		if isSecret(operands[0], imports) || isSecret(operands[1], imports) {
+			found = append(found, fmt.Sprintf("%s: %s", fset.Position(n.Pos()), exprString(n)))
+		}
+		return true
+	})
+
+	return found, nil
+}
+
+// isUnset returns true if the expression is the value of an unset secret, "" or nil, or a number, which secrets are
+// not.
+func isUnset(e ast.Expr) bool {
+	if l, ok := e.(*ast.BasicLit); ok {
+		return l.Value == `""` || l.Value == "``" || l.Kind == token.INT || l.Kind == token.FLOAT
+	}
+	i, ok := e.(*ast.Ident)
+	return ok && i.Name == "nil"
+}
+
+// isSecret returns true if the expression is named like a secret. The identifiers of the imported packages, like
+// auth.SessionModePasswd, are constants, not secrets.
+func isSecret(e ast.Expr, imports map[string]string) bool {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return secretName.MatchString(e.Name)
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok && imports[x.Name] != "" {
+			return false
+		}
+		return secretName.MatchString(e.Sel.Name)
+	case *ast.CallExpr:
+		return isSecret(e.Fun, imports)
+	case *ast.IndexExpr:
+		if k, ok := e.Index.(*ast.BasicLit); ok {
+			if key, err := strconv.Unquote(k.Value); err == nil && secretName.MatchString(key) {
+				return true
+			}
+		}
+		return isSecret(e.X, imports)
+	case *ast.ParenExpr:
+		return isSecret(e.X, imports)
+	case *ast.StarExpr:
+		return isSecret(e.X, imports)
+	}
+	return false
+}
+
+// exprString returns the source of the comparison, in short.
+func exprString(n ast.Node) string {
+	switch n := n.(type) {
+	case *ast.BinaryExpr:
+		return fmt.Sprintf("%s %s %s", exprString(n.X), n.Op, exprString(n.Y))
+	case *ast.CallExpr:
+		args := make([]string, 0, len(n.Args))
+		for _, a := range n.Args {
+			args = append(args, exprString(a))
+		}
+		return fmt.Sprintf("%s(%s)", exprString(n.Fun), strings.Join(args, ", "))
+	case *ast.SelectorExpr:
+		return exprString(n.X) + "." + n.Sel.Name
+	case *ast.Ident:
+		return n.Name
+	case *ast.BasicLit:
+		return n.Value
+	case *ast.IndexExpr:
+		return fmt.Sprintf("%s[%s]", exprString(n.X), exprString(n.Index))
+	case *ast.ParenExpr:
+		return "(" + exprString(n.X) + ")"
+	case *ast.StarExpr:
+		return "*" + exprString(n.X)
+	}
+	return fmt.Sprintf("%T", n)
+}
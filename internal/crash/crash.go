@@ -0,0 +1,123 @@
+// Package crash recovers from the panics of the requests and of the background tasks of the daemon, so that a bug
+// triggered by one of them doesn't stop the logins of the whole machine, and reports them.
+package crash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ubuntu/authd/internal/consts"
+	"github.com/ubuntu/authd/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	reportsDirMu sync.RWMutex
+	reportsDir   = consts.DefaultCrashReportsDir
+)
+
+// SetReportsDir sets the directory the crash reports are written to. No report is written if dir is empty.
+func SetReportsDir(dir string) {
+	reportsDirMu.Lock()
+	defer reportsDirMu.Unlock()
+	reportsDir = dir
+}
+
+// Recover recovers from a panic of the goroutine and reports it. It must be deferred. If err is not nil, it's set to
+// an error describing what panicked, so that the caller fails instead of the daemon.
+func Recover(ctx context.Context, what string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	report(ctx, what, r, debug.Stack())
+	if err != nil {
+		*err = fmt.Errorf("internal error in %s", what)
+	}
+}
+
+// UnaryServerInterceptor recovers from the panics of the gRPC handlers and reports them. The request fails with an
+// internal error while the other ones are still served.
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			report(ctx, info.FullMethod, r, debug.Stack())
+			err = status.Errorf(codes.Internal, "internal error while handling %s", info.FullMethod)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// report logs the panic with its stack trace and writes it as a crash report.
+func report(ctx context.Context, what string, r any, stack []byte) {
+	log.Errorf(ctx, "Recovered from panic in %s: %v\n%s", what, r, stack)
+
+	reportsDirMu.RLock()
+	dir := reportsDir
+	reportsDirMu.RUnlock()
+	if dir == "" {
+		return
+	}
+
+	path, err := writeReport(dir, fmt.Sprintf("%s panicked in %s: %v", filepath.Base(os.Args[0]), what, r), stack)
+	if err != nil {
+		log.Warningf(ctx, "Could not write crash report: %v", err)
+		return
+	}
+	if path != "" {
+		log.Infof(ctx, "Crash report written to %s", path)
+	}
+}
+
+// writeReport writes the crash report in the format of apport, named after the executable as apport does. As apport,
+// an existing report of the executable is kept until it's processed, which also prevents a panic repeated on every
+// request from filling the disk. It returns the path of the report, or an empty one if there is already a report.
+func writeReport(dir, title string, stack []byte) (path string, err error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	path = filepath.Join(dir, fmt.Sprintf("%s.%d.crash", strings.ReplaceAll(exe, "/", "_"), os.Getuid()))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+	if errors.Is(err, os.ErrExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	fields := []struct{ key, value string }{
+		{"ProblemType", "Crash"},
+		{"Date", time.Now().Format(time.ANSIC)},
+		{"ExecutablePath", exe},
+		{"Package", "authd " + consts.Version},
+		{"ProcCmdline", strings.Join(os.Args, " ")},
+		{"Title", title},
+		{"Traceback", string(stack)},
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		// The lines of the multi-line values are indented, as in the reports of apport.
+		value := strings.TrimSuffix(f.value, "\n")
+		if strings.Contains(value, "\n") {
+			value = "\n " + strings.ReplaceAll(value, "\n", "\n ")
+		}
+		fmt.Fprintf(&b, "%s: %s\n", f.key, value)
+	}
+
+	if _, err := f.WriteString(b.String()); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
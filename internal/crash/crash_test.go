@@ -0,0 +1,136 @@
+package crash_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/crash"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRecover(t *testing.T) {
+	// The directory of the reports is global, so the tests can't run in parallel.
+
+	tests := map[string]struct {
+		noPanic        bool
+		noErr          bool
+		noReportsDir   bool
+		existingReport bool
+
+		wantReport bool
+	}{
+		"Recovers_and_reports_panic":          {wantReport: true},
+		"Recovers_panic_without_error_to_set": {noErr: true, wantReport: true},
+		"Recovers_panic_without_reports_dir":  {noReportsDir: true},
+		"Keeps_existing_report":               {existingReport: true},
+		"Does_nothing_without_panic":          {noPanic: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.noReportsDir {
+				crash.SetReportsDir("")
+			} else {
+				crash.SetReportsDir(dir)
+			}
+			t.Cleanup(func() { crash.SetReportsDir("") })
+
+			exe, err := os.Executable()
+			require.NoError(t, err, "Setup: could not get executable path")
+			reportPath := filepath.Join(dir, reportName(exe))
+			if tc.existingReport {
+				require.NoError(t, os.WriteFile(reportPath, []byte("existing report"), 0600), "Setup: could not write existing report")
+			}
+
+			var gotErr error
+			var errPtr *error
+			if !tc.noErr {
+				errPtr = &gotErr
+			}
+			func() {
+				defer crash.Recover(context.Background(), "some task", errPtr)
+				if !tc.noPanic {
+					panic("some panic")
+				}
+			}()
+
+			if tc.noPanic || tc.noErr {
+				require.NoError(t, gotErr, "Recover should not set an error")
+			} else {
+				require.EqualError(t, gotErr, "internal error in some task", "Recover should set the error describing the panic")
+			}
+
+			if tc.existingReport {
+				got, err := os.ReadFile(reportPath)
+				require.NoError(t, err, "The existing report should be kept")
+				require.Equal(t, "existing report", string(got), "The existing report should not be overwritten")
+				return
+			}
+			if !tc.wantReport {
+				require.NoFileExists(t, reportPath, "No report should be written")
+				return
+			}
+
+			got, err := os.ReadFile(reportPath)
+			require.NoError(t, err, "A report should be written")
+			require.Contains(t, string(got), "ProblemType: Crash\n", "The report should be a crash report")
+			require.Contains(t, string(got), "ExecutablePath: "+exe+"\n", "The report should have the executable path")
+			require.Contains(t, string(got), "panicked in some task: some panic\n", "The report should have the panic as title")
+			require.Regexp(t, "Traceback: \n goroutine [0-9]+ ", string(got), "The report should have the indented stack trace")
+		})
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		panics bool
+		err    error
+
+		wantCode codes.Code
+	}{
+		"Returns_response_of_handler":     {wantCode: codes.OK},
+		"Returns_error_of_handler":        {err: status.Error(codes.NotFound, "not found"), wantCode: codes.NotFound},
+		"Returns_internal_error_on_panic": {panics: true, wantCode: codes.Internal},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := func(ctx context.Context, req any) (any, error) {
+				if tc.panics {
+					panic(errors.New("some panic"))
+				}
+				return req, tc.err
+			}
+
+			resp, err := crash.UnaryServerInterceptor(context.Background(), "request", &grpc.UnaryServerInfo{FullMethod: "/authd.PAM/SelectBroker"}, handler)
+			require.Equal(t, tc.wantCode, status.Code(err), "UnaryServerInterceptor should return the expected code")
+			if tc.wantCode != codes.OK {
+				return
+			}
+			require.Equal(t, "request", resp, "UnaryServerInterceptor should return the response of the handler")
+		})
+	}
+}
+
+// reportName returns the name of the crash report of the executable, as apport names it.
+func reportName(exe string) string {
+	return fmt.Sprintf("%s.%d.crash", strings.ReplaceAll(exe, "/", "_"), os.Getuid())
+}
+
+func TestMain(m *testing.M) {
+	// The tests must not write reports to the directory of the system.
+	crash.SetReportsDir("")
+
+	os.Exit(m.Run())
+}
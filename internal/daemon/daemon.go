@@ -3,9 +3,14 @@ package daemon
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/coreos/go-systemd/v22/activation"
 	"github.com/coreos/go-systemd/v22/daemon"
@@ -16,18 +21,75 @@ import (
 
 // Daemon is a grpc daemon with systemd support.
 type Daemon struct {
-	grpcServer *grpc.Server
-	lis        net.Listener
+	// servers are the servers of the daemon, the first one serving all the gRPC services on the main socket.
+	servers []server
 
 	systemdSdNotifier systemdSdNotifier
+	// healthCheck, if set, must pass for the daemon to notify the systemd watchdog.
+	healthCheck             HealthCheck
+	systemdWatchdogInterval func() (time.Duration, error)
+}
+
+// server is a server with the socket it listens on.
+type server struct {
+	srv Server
+	lis net.Listener
+}
+
+// Server serves the requests of the clients on a socket, like a gRPC server.
+type Server interface {
+	Serve(lis net.Listener) error
+	// Stop closes the connections and cancels the requests in progress.
+	Stop()
+	// GracefulStop stops accepting new requests and waits for the ones in progress to finish.
+	GracefulStop()
+}
+
+// Socket is an additional socket on which the daemon serves some of the gRPC services, with its own permissions.
+type Socket struct {
+	Path string
+	// Mode is the permission of the socket file.
+	Mode os.FileMode
+	// Group is the group owning the socket file, if set, so that a restrictive mode can still allow its members.
+	Group string
+	// Register builds the gRPC server with the services available on this socket.
+	Register GRPCServiceRegisterer
+}
+
+// ServerSocket is an additional socket on which the daemon serves the requests of another protocol than gRPC, like the
+// varlink interfaces of systemd.
+type ServerSocket struct {
+	Path string
+	// Mode is the permission of the socket file.
+	Mode os.FileMode
+	// Group is the group owning the socket file, if set.
+	Group  string
+	Server Server
+}
+
+// TCPListener is an address on which the daemon serves some of the gRPC services to remote clients.
+type TCPListener struct {
+	Address string
+	// Register builds the gRPC server with the services available on this address, which must authenticate the
+	// clients.
+	Register GRPCServiceRegisterer
 }
 
 type options struct {
 	socketPath string
+	// socketPermissions, if set, are the mode and group of the main socket, which has mode 0666 by default when
+	// created by the daemon, or the permissions of the systemd socket unit with socket activation.
+	socketPermissions *socketPermissions
+
+	extraSockets  []Socket
+	serverSockets []ServerSocket
+	tcpListeners  []TCPListener
+	healthCheck   HealthCheck
 
 	// private member that we export for tests.
 	systemdActivationListener func() ([]net.Listener, error)
 	systemdSdNotifier         func(unsetEnvironment bool, state string) (bool, error)
+	systemdWatchdogInterval   func() (time.Duration, error)
 }
 
 type systemdSdNotifier func(unsetEnvironment bool, state string) (bool, error)
@@ -42,6 +104,55 @@ func WithSocketPath(p string) func(o *options) {
 	}
 }
 
+// WithSocketPermissions sets the permission of the main socket, and the group owning it if not empty. It applies to the
+// socket passed by systemd with socket activation too.
+func WithSocketPermissions(mode os.FileMode, group string) func(o *options) {
+	return func(o *options) {
+		o.socketPermissions = &socketPermissions{mode: mode, group: group}
+	}
+}
+
+// socketPermissions are the permission and the owning group of a socket file.
+type socketPermissions struct {
+	mode  os.FileMode
+	group string
+}
+
+// WithExtraSocket serves the services registered by the socket on an additional socket, which is always created by
+// the daemon, even with socket activation.
+func WithExtraSocket(s Socket) func(o *options) {
+	return func(o *options) {
+		o.extraSockets = append(o.extraSockets, s)
+	}
+}
+
+// WithServerSocket serves the requests of the server on an additional socket, which is always created by the daemon,
+// even with socket activation.
+func WithServerSocket(s ServerSocket) func(o *options) {
+	return func(o *options) {
+		o.serverSockets = append(o.serverSockets, s)
+	}
+}
+
+// WithTCPListener serves the services registered by the listener on a TCP address, which is always listened on by the
+// daemon, even with socket activation.
+func WithTCPListener(l TCPListener) func(o *options) {
+	return func(o *options) {
+		o.tcpListeners = append(o.tcpListeners, l)
+	}
+}
+
+// WithHealthCheck notifies the systemd watchdog, when it is enabled for the service, only while check passes, so that
+// systemd restarts the daemon when it hangs.
+func WithHealthCheck(check HealthCheck) func(o *options) {
+	return func(o *options) {
+		o.healthCheck = check
+	}
+}
+
+// HealthCheck returns an error if the daemon can't serve the requests, like when its database can't be read.
+type HealthCheck func(context.Context) error
+
 // GRPCServiceRegisterer is a function that the daemon will call everytime we want to build a new GRPC object.
 type GRPCServiceRegisterer func(context.Context) *grpc.Server
 
@@ -58,6 +169,7 @@ func New(ctx context.Context, registerGRPCService GRPCServiceRegisterer, args ..
 
 		systemdActivationListener: activation.Listeners,
 		systemdSdNotifier:         daemon.SdNotify,
+		systemdWatchdogInterval:   func() (time.Duration, error) { return daemon.SdWatchdogEnabled(false) },
 	}
 	// Apply given args.
 	for _, f := range args {
@@ -68,19 +180,15 @@ func New(ctx context.Context, registerGRPCService GRPCServiceRegisterer, args ..
 	var lis net.Listener
 
 	if opts.socketPath != "" {
-		log.Debugf(ctx, "Listening on %s", opts.socketPath)
-
-		// manual socket
-		// TODO: if socket exists, remove
-		lis, err = net.Listen("unix", opts.socketPath)
+		// By default, we want everyone to be able to write to our socket and we will filter permissions.
+		perms := socketPermissions{mode: 0666}
+		if opts.socketPermissions != nil {
+			perms = *opts.socketPermissions
+		}
+		lis, err = listen(ctx, opts.socketPath, perms.mode, perms.group)
 		if err != nil {
 			return nil, err
 		}
-
-		//nolint:gosec // We want everyone to be able to write to our socket and we will filter permissions
-		if err = os.Chmod(opts.socketPath, 0666); err != nil {
-			return nil, fmt.Errorf("could not change socket permission: %v", err)
-		}
 	} else {
 		log.Debug(ctx, "Use socket activation")
 
@@ -96,24 +204,111 @@ func New(ctx context.Context, registerGRPCService GRPCServiceRegisterer, args ..
 		lis = listeners[0]
 	}
 
+	// Close all the listeners, which removes the sockets we created, if the daemon can't be created.
+	servers := []server{{lis: lis}}
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, s := range servers {
+			_ = s.lis.Close()
+		}
+	}()
+
 	// Ensure selected socket exists.
 	if _, err := os.Stat(lis.Addr().String()); err != nil {
 		return nil, fmt.Errorf("%s can’t be acccessed: %v", lis.Addr().String(), err)
 	}
+	if p := opts.socketPermissions; p != nil && opts.socketPath == "" {
+		if err := setPermissions(lis.Addr().String(), p.mode, p.group); err != nil {
+			return nil, err
+		}
+	}
+
+	servers[0].srv = registerGRPCService(ctx)
+	for _, s := range opts.extraSockets {
+		lis, err := listen(ctx, s.Path, s.Mode, s.Group)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, server{srv: s.Register(ctx), lis: lis})
+	}
+	for _, s := range opts.serverSockets {
+		lis, err := listen(ctx, s.Path, s.Mode, s.Group)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, server{srv: s.Server, lis: lis})
+	}
+	for _, l := range opts.tcpListeners {
+		log.Debugf(ctx, "Listening on %s", l.Address)
+		lis, err := net.Listen("tcp", l.Address)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, server{srv: l.Register(ctx), lis: lis})
+	}
 
 	return &Daemon{
-		grpcServer: registerGRPCService(ctx),
-		lis:        lis,
+		servers: servers,
 
-		systemdSdNotifier: opts.systemdSdNotifier,
+		systemdSdNotifier:       opts.systemdSdNotifier,
+		healthCheck:             opts.healthCheck,
+		systemdWatchdogInterval: opts.systemdWatchdogInterval,
 	}, nil
 }
 
+// listen creates the unix socket at path with the given permissions and group, if not empty.
+func listen(ctx context.Context, path string, mode os.FileMode, group string) (_ net.Listener, err error) {
+	log.Debugf(ctx, "Listening on %s", path)
+
+	if err := removeStaleSocket(ctx, path); err != nil {
+		return nil, err
+	}
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = lis.Close()
+		}
+	}()
+
+	if err = setPermissions(path, mode, group); err != nil {
+		return nil, err
+	}
+
+	return lis, nil
+}
+
+// setPermissions changes the permission of the socket file at path, and its group if not empty.
+func setPermissions(path string, mode os.FileMode, group string) error {
+	if group != "" {
+		g, err := user.LookupGroup(group)
+		if err != nil {
+			return fmt.Errorf("could not find group of socket %s: %v", path, err)
+		}
+		gid, err := strconv.Atoi(g.Gid)
+		if err != nil {
+			return fmt.Errorf("invalid GID of group %q: %v", group, err)
+		}
+		if err := os.Chown(path, -1, gid); err != nil {
+			return fmt.Errorf("could not change socket group: %v", err)
+		}
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("could not change socket permission: %v", err)
+	}
+	return nil
+}
+
 // Serve listens on a tcp socket and starts serving GRPC requests on it.
 func (d *Daemon) Serve(ctx context.Context) (err error) {
 	defer decorate.OnError(&err /*i18n.G(*/, "error while serving") //)
 
-	log.Debugf(ctx, "Starting to serve requests on %s", d.lis.Addr())
+	log.Debugf(ctx, "Starting to serve requests on %s", d.servers[0].lis.Addr())
 
 	// Signal to systemd that we are ready.
 	if sent, err := d.systemdSdNotifier(false, "READY=1"); err != nil {
@@ -122,23 +317,104 @@ func (d *Daemon) Serve(ctx context.Context) (err error) {
 		log.Debug(context.Background(), "Ready state sent to systemd")
 	}
 
-	log.Infof(ctx, "Serving gRPC requests on %v", d.lis.Addr())
-	if err := d.grpcServer.Serve(d.lis); err != nil {
-		return fmt.Errorf("gRPC error: %v", err)
+	if d.healthCheck != nil {
+		interval, err := d.systemdWatchdogInterval()
+		if err != nil {
+			log.Warningf(ctx, "Systemd watchdog disabled: %v", err)
+		} else if interval > 0 {
+			stop := make(chan struct{})
+			defer close(stop)
+			go d.watchdog(ctx, interval, stop)
+		}
+	}
+
+	errs := make(chan error, len(d.servers))
+	for _, s := range d.servers {
+		log.Infof(ctx, "Serving requests on %v", s.lis.Addr())
+		go func() { errs <- s.srv.Serve(s.lis) }()
+	}
+
+	// The daemon stops serving on all its sockets as soon as it fails to serve on one of them.
+	var serveErr error
+	for range d.servers {
+		err := <-errs
+		if err != nil && serveErr == nil {
+			d.Quit(ctx, true)
+		}
+		serveErr = errors.Join(serveErr, err)
+	}
+	if serveErr != nil {
+		return fmt.Errorf("server error: %v", serveErr)
 	}
 	return nil
 }
 
+// watchdog notifies the systemd watchdog every half of its interval, as long as the health check passes. A health check
+// which fails or hangs stops the notifications, and systemd restarts the daemon once the interval is elapsed.
+func (d *Daemon) watchdog(ctx context.Context, interval time.Duration, stop chan struct{}) {
+	log.Debugf(ctx, "Notifying the systemd watchdog every %v", interval/2)
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, interval/2)
+		err := d.healthCheck(checkCtx)
+		cancel()
+		if err != nil {
+			log.Errorf(ctx, "Health check failed, not notifying the systemd watchdog: %v", err)
+			continue
+		}
+		if _, err := d.systemdSdNotifier(false, daemon.SdNotifyWatchdog); err != nil {
+			log.Warningf(ctx, "Couldn't notify the systemd watchdog: %v", err)
+		}
+	}
+}
+
 // Quit gracefully quits listening loop and stops the grpc server.
-// It can drops any existing connexion is force is true.
+// It can drops any existing connexion is force is true. Otherwise, the active requests are cancelled once ctx is done.
 func (d Daemon) Quit(ctx context.Context, force bool) {
 	log.Info(ctx, "Stopping daemon requested.")
+	// Systemd reports the daemon as stopping while the requests in progress, like authentications, finish.
+	if _, err := d.systemdSdNotifier(false, daemon.SdNotifyStopping); err != nil {
+		log.Warningf(ctx, "Couldn't send stopping notification to systemd: %v", err)
+	}
 	if force {
-		d.grpcServer.Stop()
+		for _, s := range d.servers {
+			s.srv.Stop()
+		}
 		return
 	}
 
 	log.Info(ctx, "Wait for active requests to close.")
-	d.grpcServer.GracefulStop()
+	var wg sync.WaitGroup
+	for _, s := range d.servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.srv.GracefulStop()
+		}()
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Warning(context.Background(), "Cancelling the requests which are still active.")
+		for _, s := range d.servers {
+			s.srv.Stop()
+		}
+		<-stopped
+	}
 	log.Debug(ctx, "All connections have now ended.")
 }
@@ -6,7 +6,9 @@ import (
 	"io/fs"
 	"net"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/ubuntu/authd/internal/daemon/testdata/grpctestservice"
 	"github.com/ubuntu/authd/internal/grpcutils"
 	"github.com/ubuntu/authd/internal/services/errmessages"
+	"github.com/ubuntu/authd/internal/varlink"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health"
@@ -35,6 +38,9 @@ func TestNew(t *testing.T) {
 		systemdActivationListenerFails
 		systemdActivationListenerSocketDoesNotExists
 		manualSocketParentDirectoryDoesNotExists
+		manualSocketStale
+		manualSocketInUse
+		manualSocketPathIsNotASocket
 	)
 
 	testCases := map[string]struct {
@@ -46,11 +52,14 @@ func TestNew(t *testing.T) {
 		"With_socket_activation":                               {wantSelectedSocket: "systemd.sock1"},
 		"Socket_provided_manually_is_created":                  {socketType: manualSocket, wantSelectedSocket: "manual.sock"},
 		"Socket_provided_manually_wins_over_socket_activation": {socketType: systemdActivationListenerAndManualSocket, wantSelectedSocket: "manual.sock"},
+		"Stale_socket_provided_manually_is_replaced":           {socketType: manualSocketStale, wantSelectedSocket: "manual.sock"},
 
 		"Error_when_systemd_provides_multiple_sockets":             {socketType: systemdActivationListenerMultipleSockets, wantErr: true},
 		"Error_when_systemd_activation_fails":                      {socketType: systemdActivationListenerFails, wantErr: true},
 		"Error_when_systemd_activated_socket_does_not_exists":      {socketType: systemdActivationListenerSocketDoesNotExists, wantErr: true},
 		"Error_when_manually_provided_socket_path_does_not_exists": {socketType: manualSocketParentDirectoryDoesNotExists, wantErr: true},
+		"Error_when_manually_provided_socket_is_in_use":            {socketType: manualSocketInUse, wantErr: true},
+		"Error_when_manually_provided_socket_path_is_not_a_socket": {socketType: manualSocketPathIsNotASocket, wantErr: true},
 	}
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
@@ -110,6 +119,22 @@ func TestNew(t *testing.T) {
 				err := os.Remove(filepath.Dir(manualSocketPath))
 				require.NoError(t, err, "Setup: removing manual socket dir fails")
 				args = append(args, daemon.WithSocketPath(manualSocketPath))
+			case manualSocketStale:
+				l, err := net.Listen("unix", manualSocketPath)
+				require.NoError(t, err, "Setup: couldn't create unix socket")
+				// Leave the socket file behind, as a crashed process does.
+				l.(*net.UnixListener).SetUnlinkOnClose(false)
+				l.Close()
+				args = append(args, daemon.WithSocketPath(manualSocketPath))
+			case manualSocketInUse:
+				l, err := net.Listen("unix", manualSocketPath)
+				require.NoError(t, err, "Setup: couldn't create unix socket")
+				defer l.Close()
+				args = append(args, daemon.WithSocketPath(manualSocketPath))
+			case manualSocketPathIsNotASocket:
+				err := os.WriteFile(manualSocketPath, []byte("not a socket"), 0600)
+				require.NoError(t, err, "Setup: couldn't create file")
+				args = append(args, daemon.WithSocketPath(manualSocketPath))
 			}
 
 			// Test itself
@@ -126,6 +151,170 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestSocketPermissions(t *testing.T) {
+	t.Parallel()
+
+	currentGroup, err := user.LookupGroupId(strconv.Itoa(os.Getegid()))
+	require.NoError(t, err, "Setup: could not get the current group")
+
+	testCases := map[string]struct {
+		socketActivation bool
+		noPermissions    bool
+		mode             os.FileMode
+		group            string
+
+		wantMode os.FileMode
+		wantErr  bool
+	}{
+		"Socket_has_mode_0666_by_default":                  {noPermissions: true, wantMode: 0666},
+		"Socket_has_the_configured_mode":                   {mode: 0660, wantMode: 0660},
+		"Socket_is_owned_by_the_configured_group":          {mode: 0660, group: currentGroup.Name, wantMode: 0660},
+		"Socket_from_activation_has_the_configured_mode":   {socketActivation: true, mode: 0600, wantMode: 0600},
+		"Socket_from_activation_keeps_its_mode_by_default": {socketActivation: true, noPermissions: true, wantMode: 0644},
+
+		"Error_when_socket_group_not_found":                 {mode: 0660, group: "doesnotexist", wantErr: true},
+		"Error_when_socket_group_from_activation_not_found": {socketActivation: true, mode: 0660, group: "doesnotexist", wantErr: true},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			registerGRPC := func(context.Context) *grpc.Server { return nil }
+			socketPath := filepath.Join(t.TempDir(), "authd.socket")
+
+			var args []daemon.Option
+			if tc.socketActivation {
+				l, err := net.Listen("unix", socketPath)
+				require.NoError(t, err, "Setup: couldn't create unix socket")
+				defer l.Close()
+				require.NoError(t, os.Chmod(socketPath, 0644), "Setup: couldn't change socket mode")
+				args = append(args, daemon.WithSystemdActivationListener(func() ([]net.Listener, error) { return []net.Listener{l}, nil }))
+			} else {
+				args = append(args, daemon.WithSocketPath(socketPath))
+			}
+			if !tc.noPermissions {
+				args = append(args, daemon.WithSocketPermissions(tc.mode, tc.group))
+			}
+
+			_, err := daemon.New(context.Background(), registerGRPC, args...)
+			if tc.wantErr {
+				require.Error(t, err, "New() should return an error")
+				return
+			}
+			require.NoError(t, err, "New() should not return an error")
+
+			info, err := os.Stat(socketPath)
+			require.NoError(t, err, "Socket should exist")
+			require.Equal(t, tc.wantMode, info.Mode().Perm(), "Socket should have the requested permissions")
+		})
+	}
+}
+
+func TestExtraSockets(t *testing.T) {
+	t.Parallel()
+
+	currentGroup, err := user.LookupGroupId(strconv.Itoa(os.Getegid()))
+	require.NoError(t, err, "Setup: could not get the current group")
+
+	testCases := map[string]struct {
+		mode  os.FileMode
+		group string
+
+		wantErr bool
+	}{
+		"Serve_on_extra_socket":                   {mode: 0600},
+		"Serve_on_extra_socket_owned_by_a_group":  {mode: 0660, group: currentGroup.Name},
+		"Error_when_extra_socket_group_not_found": {mode: 0660, group: "doesnotexist", wantErr: true},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			registerGRPC := func(context.Context) *grpc.Server {
+				grpcServer := grpc.NewServer(grpc.UnaryInterceptor(errmessages.RedactErrorInterceptor))
+				grpctestservice.RegisterTestServiceServer(grpcServer, testGRPCService{})
+				hc := health.NewServer()
+				hc.SetServingStatus(consts.ServiceName, healthpb.HealthCheckResponse_SERVING)
+				healthgrpc.RegisterHealthServer(grpcServer, hc)
+				return grpcServer
+			}
+			socketDir := t.TempDir()
+			extraSocketPath := filepath.Join(socketDir, "extra.socket")
+
+			d, err := daemon.New(context.Background(), registerGRPC,
+				daemon.WithSystemdSdNotifier(func(bool, string) (bool, error) { return true, nil }),
+				daemon.WithSocketPath(filepath.Join(socketDir, "manual.socket")),
+				daemon.WithExtraSocket(daemon.Socket{Path: extraSocketPath, Mode: tc.mode, Group: tc.group, Register: registerGRPC}))
+			if tc.wantErr {
+				require.Error(t, err, "New() should return an error")
+				require.NoFileExists(t, filepath.Join(socketDir, "manual.socket"), "Main socket should be closed and removed")
+				require.NoFileExists(t, extraSocketPath, "Extra socket should be closed and removed")
+				return
+			}
+			require.NoError(t, err, "New() should not return an error")
+
+			info, err := os.Stat(extraSocketPath)
+			require.NoError(t, err, "Extra socket should exist")
+			require.Equal(t, tc.mode, info.Mode().Perm(), "Extra socket should have the requested permissions")
+
+			serveErr := make(chan error)
+			go func() { serveErr <- d.Serve(context.Background()) }()
+			// make sure Serve() is called. Even std golang grpc has this timeout in tests
+			time.Sleep(100 * time.Millisecond)
+
+			connected, disconnect := createClientConnection(t, extraSocketPath)
+			require.True(t, connected, "Connection to the extra socket should be allowed")
+			disconnect()
+
+			d.Quit(context.Background(), false)
+			require.NoError(t, <-serveErr, "Serve() should not return an error")
+		})
+	}
+}
+
+func TestServerSockets(t *testing.T) {
+	t.Parallel()
+
+	registerGRPC := func(context.Context) *grpc.Server {
+		return grpc.NewServer(grpc.UnaryInterceptor(errmessages.RedactErrorInterceptor))
+	}
+	socketDir := t.TempDir()
+	serverSocketPath := filepath.Join(socketDir, "varlink.socket")
+	server := varlink.NewServer(map[string]varlink.MethodHandler{
+		"org.example.Ping": func(_ context.Context, _ varlink.Call, reply varlink.Reply) error {
+			return reply(nil, false)
+		},
+	})
+
+	d, err := daemon.New(context.Background(), registerGRPC,
+		daemon.WithSystemdSdNotifier(func(bool, string) (bool, error) { return true, nil }),
+		daemon.WithSocketPath(filepath.Join(socketDir, "manual.socket")),
+		daemon.WithServerSocket(daemon.ServerSocket{Path: serverSocketPath, Mode: 0666, Server: server}))
+	require.NoError(t, err, "New() should not return an error")
+
+	info, err := os.Stat(serverSocketPath)
+	require.NoError(t, err, "Server socket should exist")
+	require.Equal(t, os.FileMode(0666), info.Mode().Perm(), "Server socket should have the requested permissions")
+
+	serveErr := make(chan error)
+	go func() { serveErr <- d.Serve(context.Background()) }()
+	// make sure Serve() is called. Even std golang grpc has this timeout in tests
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial("unix", serverSocketPath)
+	require.NoError(t, err, "Connection to the server socket should be allowed")
+	_, err = conn.Write([]byte(`{"method":"org.example.Ping"}` + "\x00"))
+	require.NoError(t, err, "Call to the server should be sent")
+	reply := make([]byte, 64)
+	n, err := conn.Read(reply)
+	require.NoError(t, err, "Reply of the server should be received")
+	require.Equal(t, `{"parameters":{}}`+"\x00", string(reply[:n]), "Reply of the server should be the expected one")
+	require.NoError(t, conn.Close(), "Teardown: could not close connection")
+
+	d.Quit(context.Background(), false)
+	require.NoError(t, <-serveErr, "Serve() should not return an error")
+}
+
 func TestServe(t *testing.T) {
 	t.Parallel()
 
@@ -201,19 +390,86 @@ func TestServe(t *testing.T) {
 		})
 	}
 }
+func TestWatchdog(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		interval       time.Duration
+		noHealthCheck  bool
+		healthCheckErr error
+		intervalErr    error
+
+		wantWatchdogNotified bool
+	}{
+		"Notifies_watchdog_while_health_check_passes": {interval: 20 * time.Millisecond, wantWatchdogNotified: true},
+
+		"Does_not_notify_watchdog_when_health_check_fails":   {interval: 20 * time.Millisecond, healthCheckErr: errors.New("database unreachable")},
+		"Does_not_notify_watchdog_when_it_is_disabled":       {},
+		"Does_not_notify_watchdog_without_health_check":      {interval: 20 * time.Millisecond, noHealthCheck: true},
+		"Does_not_notify_watchdog_when_its_interval_errored": {interval: 20 * time.Millisecond, intervalErr: errors.New("invalid WATCHDOG_USEC")},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			registerGRPC := func(context.Context) *grpc.Server {
+				return grpc.NewServer(grpc.UnaryInterceptor(errmessages.RedactErrorInterceptor))
+			}
+			notifications := make(chan string, 100)
+			systemdNotifier := func(unsetEnvironment bool, state string) (bool, error) {
+				notifications <- state
+				return true, nil
+			}
+
+			opts := []daemon.Option{
+				daemon.WithSystemdSdNotifier(systemdNotifier),
+				daemon.WithSystemdWatchdogInterval(func() (time.Duration, error) { return tc.interval, tc.intervalErr }),
+				daemon.WithSocketPath(filepath.Join(t.TempDir(), "manual.socket")),
+			}
+			if !tc.noHealthCheck {
+				opts = append(opts, daemon.WithHealthCheck(func(context.Context) error { return tc.healthCheckErr }))
+			}
+			d, err := daemon.New(context.Background(), registerGRPC, opts...)
+			require.NoError(t, err, "Setup: New() should not return an error")
+
+			go func() {
+				// Leave time for a few watchdog intervals.
+				time.Sleep(100 * time.Millisecond)
+				d.Quit(context.Background(), false)
+			}()
+			err = d.Serve(context.Background())
+			require.NoError(t, err, "Serve() should not return an error")
+
+			var got []string
+			for len(notifications) > 0 {
+				got = append(got, <-notifications)
+			}
+			require.Equal(t, "READY=1", got[0], "The daemon should notify systemd that it is ready first")
+			require.Contains(t, got, "STOPPING=1", "The daemon should notify systemd that it is stopping")
+			if tc.wantWatchdogNotified {
+				require.Contains(t, got, "WATCHDOG=1", "The daemon should notify the watchdog")
+				return
+			}
+			require.NotContains(t, got, "WATCHDOG=1", "The daemon should not notify the watchdog")
+		})
+	}
+}
+
 func TestQuit(t *testing.T) {
 	t.Parallel()
 
 	testCases := map[string]struct {
-		force bool
+		force   bool
+		timeout time.Duration
 
 		activeConnection bool
 
 		wantErr bool
 	}{
 		"Graceful_stop": {},
-		"Graceful_stop_is_blocked_on_active_connection": {activeConnection: true},
-		"Force_stop_drops_active_connection":            {force: true, activeConnection: true},
+		"Graceful_stop_is_blocked_on_active_connection":    {activeConnection: true},
+		"Force_stop_drops_active_connection":               {force: true, activeConnection: true},
+		"Graceful_stop_drops_active_connection_on_timeout": {timeout: 50 * time.Millisecond, activeConnection: true},
 	}
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
@@ -258,7 +514,13 @@ func TestQuit(t *testing.T) {
 			quiteDone := make(chan struct{})
 			go func() {
 				defer close(quiteDone)
-				d.Quit(context.Background(), tc.force)
+				ctx := context.Background()
+				if tc.timeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+					defer cancel()
+				}
+				d.Quit(ctx, tc.force)
 			}()
 
 			time.Sleep(100 * time.Millisecond)
@@ -276,8 +538,8 @@ func TestQuit(t *testing.T) {
 				}
 			}
 
-			if !tc.activeConnection || tc.force {
-				require.Eventually(t, serverHasQuit, 100*time.Millisecond, 10*time.Millisecond, "Server should quit with no active connection or force")
+			if !tc.activeConnection || tc.force || tc.timeout > 0 {
+				require.Eventually(t, serverHasQuit, 100*time.Millisecond+tc.timeout, 10*time.Millisecond, "Server should quit with no active connection or force")
 				return
 			}
 
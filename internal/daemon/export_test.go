@@ -1,6 +1,9 @@
 package daemon
 
-import "net"
+import (
+	"net"
+	"time"
+)
 
 func WithSystemdActivationListener(f func() ([]net.Listener, error)) func(o *options) {
 	return func(o *options) {
@@ -15,5 +18,11 @@ func WithSystemdSdNotifier(f func(unsetEnvironment bool, state string) (bool, er
 }
 
 func (d Daemon) SelectedSocketAddr() string {
-	return d.lis.Addr().String()
+	return d.servers[0].lis.Addr().String()
+}
+
+func WithSystemdWatchdogInterval(f func() (time.Duration, error)) func(o *options) {
+	return func(o *options) {
+		o.systemdWatchdogInterval = f
+	}
 }
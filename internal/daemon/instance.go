@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+	"golang.org/x/sys/unix"
+)
+
+// ErrAlreadyRunning is returned when another instance of the daemon holds the lock of the PID file.
+var ErrAlreadyRunning = errors.New("authd is already running")
+
+// LockInstance ensures that a single instance of the daemon runs, by locking the PID file at path and writing the PID
+// of the daemon in it. It fails right away with ErrAlreadyRunning if another process holds the lock. The lock is
+// released when the returned function is called, or when the daemon exits, even if it crashes.
+func LockInstance(path string) (unlock func() error, err error) {
+	defer decorate.OnError(&err, "can't lock PID file %s", path)
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		defer f.Close()
+		if !errors.Is(err, unix.EWOULDBLOCK) {
+			return nil, err
+		}
+		if pid, err := readPID(f); err == nil {
+			return nil, fmt.Errorf("%w with PID %d", ErrAlreadyRunning, pid)
+		}
+		return nil, ErrAlreadyRunning
+	}
+
+	// The file is kept when the lock is released: removing it could let another instance lock a new file while a
+	// third one locks the removed one.
+	if err := f.Truncate(0); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return f.Close, nil
+}
+
+// readPID returns the PID written in the PID file.
+func readPID(f *os.File) (int, error) {
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 32))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// removeStaleSocket removes the socket at path if no process listens on it anymore, as when a previous instance of the
+// daemon crashed. It returns an error if another process still listens on it, or if the path is not a socket, which
+// are never removed.
+func removeStaleSocket(ctx context.Context, path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("%s already exists and is not a socket", path)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("socket %s is already used by another process, is authd already running?", path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("could not check whether socket %s is in use: %v", path, err)
+	}
+
+	log.Warningf(ctx, "Removing stale socket %s left by a previous instance", path)
+	return os.Remove(path)
+}
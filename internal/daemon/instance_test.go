@@ -0,0 +1,74 @@
+package daemon_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/daemon"
+)
+
+func TestLockInstance(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		existingContent string
+		alreadyLocked   bool
+		unlockFirst     bool
+		noParentDir     bool
+
+		wantErrRunning bool
+		wantErr        bool
+	}{
+		"Lock_new_pid_file":                       {},
+		"Lock_pid_file_left_by_previous_instance": {existingContent: "1234567\n"},
+		"Lock_pid_file_unlocked_by_instance":      {alreadyLocked: true, unlockFirst: true},
+
+		"Error_when_another_instance_holds_the_lock": {alreadyLocked: true, wantErrRunning: true, wantErr: true},
+		"Error_when_pid_file_can_not_be_created":     {noParentDir: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "authd.pid")
+			if tc.noParentDir {
+				path = filepath.Join(t.TempDir(), "doesnotexist", "authd.pid")
+			}
+			if tc.existingContent != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tc.existingContent), 0600), "Setup: could not write PID file")
+			}
+			if tc.alreadyLocked {
+				// The locks are owned by the open files, so the same process can't lock the file twice.
+				unlock, err := daemon.LockInstance(path)
+				require.NoError(t, err, "Setup: could not lock PID file")
+				if tc.unlockFirst {
+					require.NoError(t, unlock(), "Setup: could not unlock PID file")
+				} else {
+					t.Cleanup(func() { _ = unlock() })
+				}
+			}
+
+			unlock, err := daemon.LockInstance(path)
+			if tc.wantErr {
+				require.Error(t, err, "LockInstance should return an error, but did not")
+				if tc.wantErrRunning {
+					require.ErrorIs(t, err, daemon.ErrAlreadyRunning, "LockInstance should return ErrAlreadyRunning")
+					require.ErrorContains(t, err, fmt.Sprintf("with PID %d", os.Getpid()), "The error should have the PID of the other instance")
+				}
+				return
+			}
+			require.NoError(t, err, "LockInstance should not return an error, but did")
+
+			got, err := os.ReadFile(path)
+			require.NoError(t, err, "The PID file should be readable")
+			require.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(got), "The PID file should contain the PID of the daemon")
+
+			require.NoError(t, unlock(), "Unlocking should not return an error")
+			require.FileExists(t, path, "The PID file should be kept when unlocked")
+		})
+	}
+}
@@ -0,0 +1,358 @@
+// Package doctor checks that the environment of authd is set up end-to-end, from the sockets to the brokers, and
+// reports what is wrong and how to fix it, for support cases.
+package doctor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/ubuntu/authd/internal/brokers"
+	"github.com/ubuntu/authd/internal/users/cache"
+)
+
+// Status is the result of a check.
+type Status int
+
+const (
+	// OK is a check which passed.
+	OK Status = iota
+	// Warning is a check which passed, but found something which may not be intended.
+	Warning
+	// Failed is a check which found something that prevents authd from working.
+	Failed
+	// Skipped is a check which couldn't run.
+	Skipped
+)
+
+// String returns the label of the status printed by the doctor.
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case Warning:
+		return "WARN"
+	case Failed:
+		return "FAIL"
+	case Skipped:
+		return "SKIP"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// Finding is the result of a check.
+type Finding struct {
+	Check   string
+	Status  Status
+	Message string
+	// Fix is what the administrator can do to fix the problem, if any.
+	Fix string
+}
+
+// Socket is a socket of the daemon, with its expected permissions.
+type Socket struct {
+	Path  string
+	Mode  os.FileMode
+	Group string
+}
+
+// Config is the environment checked by the doctor.
+type Config struct {
+	Sockets []Socket
+	// NSSwitchFile is the path of nsswitch.conf.
+	NSSwitchFile string
+	// PAMDir is the directory of the PAM configuration of the services.
+	PAMDir string
+	// BrokersConfPath is the directory of the configuration files of the brokers, and Brokers the names of the files
+	// of the configured ones, all of them if empty.
+	BrokersConfPath string
+	Brokers         []string
+	CacheDir        string
+	// GroupFile is the group file in which the users are added to their local groups.
+	GroupFile string
+}
+
+// timeout is the time each check involving another process, like a broker, can take.
+const timeout = 5 * time.Second
+
+// Run runs all the checks and returns their findings, in order.
+func Run(ctx context.Context, config Config) (findings []Finding) {
+	for _, s := range config.Sockets {
+		findings = append(findings, checkSocket(s))
+	}
+	findings = append(findings, checkNSSwitch(config.NSSwitchFile))
+	findings = append(findings, checkPAM(config.PAMDir))
+	findings = append(findings, checkBrokers(ctx, config.BrokersConfPath, config.Brokers)...)
+	findings = append(findings, checkDatabase(config.CacheDir))
+	findings = append(findings, checkGroupFile(config.GroupFile))
+	return findings
+}
+
+// Print prints the findings to w, with the fixes of the problems, and returns true if any check failed.
+func Print(w io.Writer, findings []Finding) (failed bool) {
+	for _, f := range findings {
+		fmt.Fprintf(w, "[%s] %s: %s\n", f.Status, f.Check, f.Message)
+		if f.Fix != "" && f.Status != OK {
+			fmt.Fprintf(w, "       Fix: %s\n", f.Fix)
+		}
+		failed = failed || f.Status == Failed
+	}
+	return failed
+}
+
+// checkSocket checks that the socket exists with the expected permissions and that the daemon accepts connections on
+// it.
+func checkSocket(s Socket) Finding {
+	f := Finding{Check: "socket " + s.Path}
+
+	fi, err := os.Lstat(s.Path)
+	if err != nil {
+		f.Status, f.Message = Failed, fmt.Sprintf("can't be accessed: %v", err)
+		f.Fix = "Start authd and its socket with: systemctl enable --now authd.socket"
+		return f
+	}
+	if fi.Mode().Type() != os.ModeSocket {
+		f.Status, f.Message = Failed, fmt.Sprintf("is not a socket but a %v", fi.Mode().Type())
+		f.Fix = fmt.Sprintf("Remove %s and restart authd", s.Path)
+		return f
+	}
+	if perm := fi.Mode().Perm(); perm != s.Mode {
+		f.Status, f.Message = Failed, fmt.Sprintf("has mode %#o instead of %#o", perm, s.Mode)
+		f.Fix = "Restart authd, which sets the mode of its sockets, and check that nothing else changes it"
+		return f
+	}
+	if s.Group != "" {
+		if problem := checkGroup(fi, s.Group); problem != "" {
+			f.Status, f.Message = Failed, problem
+			f.Fix = "Restart authd, which sets the group of its sockets, and check that nothing else changes it"
+			return f
+		}
+	}
+
+	conn, err := net.DialTimeout("unix", s.Path, timeout)
+	if err != nil {
+		f.Status, f.Message = Failed, fmt.Sprintf("doesn't accept connections: %v", err)
+		f.Fix = "Check the logs of authd with: journalctl -u authd"
+		return f
+	}
+	_ = conn.Close()
+
+	f.Status, f.Message = OK, fmt.Sprintf("accepts connections with mode %#o", s.Mode)
+	return f
+}
+
+// checkGroup returns what is wrong if the file isn't owned by the group.
+func checkGroup(fi os.FileInfo, group string) (problem string) {
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return fmt.Sprintf("group %q can't be found: %v", group, err)
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	if strconv.FormatUint(uint64(st.Gid), 10) != g.Gid {
+		return fmt.Sprintf("is owned by GID %d instead of group %q", st.Gid, group)
+	}
+	return ""
+}
+
+// nssDatabases are the databases of nsswitch.conf which must use authd.
+var nssDatabases = []string{"passwd", "group", "shadow"}
+
+// checkNSSwitch checks that the passwd, group and shadow databases use the authd NSS module.
+func checkNSSwitch(path string) Finding {
+	f := Finding{Check: "nsswitch"}
+
+	file, err := os.Open(path)
+	if err != nil {
+		f.Status, f.Message = Failed, fmt.Sprintf("can't read %s: %v", path, err)
+		return f
+	}
+	defer file.Close()
+
+	sources := make(map[string][]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		db, s, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		sources[strings.TrimSpace(db)] = strings.Fields(s)
+	}
+	if err := scanner.Err(); err != nil {
+		f.Status, f.Message = Failed, fmt.Sprintf("can't read %s: %v", path, err)
+		return f
+	}
+
+	var missing []string
+	for _, db := range nssDatabases {
+		if !slices.Contains(sources[db], "authd") {
+			missing = append(missing, db)
+		}
+	}
+	if len(missing) > 0 {
+		f.Status, f.Message = Failed, fmt.Sprintf("%s in %s don't use authd", strings.Join(missing, ", "), path)
+		f.Fix = fmt.Sprintf("Add authd at the end of the %s lines of %s, like: passwd: files systemd authd",
+			strings.Join(missing, ", "), path)
+		return f
+	}
+
+	f.Status, f.Message = OK, fmt.Sprintf("%s use authd", strings.Join(nssDatabases, ", "))
+	return f
+}
+
+// pamModules are the PAM modules of authd.
+var pamModules = []string{"pam_authd_exec.so", "pam_authd.so"}
+
+// checkPAM checks that the PAM stack of at least one service uses an authd PAM module.
+func checkPAM(dir string) Finding {
+	f := Finding{Check: "pam"}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		f.Status, f.Message = Failed, fmt.Sprintf("can't read %s: %v", dir, err)
+		return f
+	}
+
+	var services []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		if slices.ContainsFunc(pamModules, func(m string) bool { return strings.Contains(string(content), m) }) {
+			services = append(services, e.Name())
+		}
+	}
+	if len(services) == 0 {
+		f.Status, f.Message = Failed, fmt.Sprintf("no PAM configuration in %s uses authd", dir)
+		f.Fix = "Enable the authd PAM module with: pam-auth-update --enable authd"
+		return f
+	}
+
+	f.Status, f.Message = OK, fmt.Sprintf("authd is used by %s", strings.Join(services, ", "))
+	return f
+}
+
+// checkBrokers checks that the configured brokers answer on the system bus.
+func checkBrokers(ctx context.Context, confPath string, configured []string) (findings []Finding) {
+	if len(configured) == 0 {
+		entries, err := os.ReadDir(confPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return []Finding{{Check: "brokers", Status: Failed, Message: fmt.Sprintf("can't read %s: %v", confPath, err)}}
+		}
+		for _, e := range entries {
+			if e.Type().IsRegular() && strings.HasSuffix(e.Name(), ".conf") {
+				configured = append(configured, e.Name())
+			}
+		}
+	}
+	if len(configured) == 0 {
+		return []Finding{{
+			Check:   "brokers",
+			Status:  Warning,
+			Message: "no broker is configured, only the local users can log in",
+			Fix:     fmt.Sprintf("Install a broker and copy its configuration file to %s", confPath),
+		}}
+	}
+
+	// Don't call dbus.SystemBus which caches globally system dbus (issues in tests)
+	bus, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return []Finding{{
+			Check:   "brokers",
+			Status:  Failed,
+			Message: fmt.Sprintf("can't connect to the system bus: %v", err),
+			Fix:     "Check that the D-Bus system bus is running with: systemctl status dbus",
+		}}
+	}
+	defer bus.Close()
+
+	for _, cfg := range configured {
+		f := Finding{Check: "broker " + cfg}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		name, err := brokers.CheckReachable(ctx, bus, filepath.Join(confPath, cfg))
+		cancel()
+		if err != nil && name == "" {
+			f.Status, f.Message = Failed, err.Error()
+			f.Fix = "Fix the configuration file of the broker or reinstall the broker"
+		} else if err != nil {
+			f.Status, f.Message = Failed, err.Error()
+			f.Fix = "Check that the broker is installed and running, and that its D-Bus policy allows root to call it"
+		} else {
+			f.Status, f.Message = OK, fmt.Sprintf("%q is reachable", name)
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// checkDatabase checks the integrity of the database, if the daemon isn't using it.
+func checkDatabase(cacheDir string) Finding {
+	f := Finding{Check: "database"}
+
+	err := cache.CheckIntegrity(cacheDir)
+	if errors.Is(err, cache.LockedError{}) {
+		f.Status, f.Message = Skipped, "the database is in use by authd"
+		f.Fix = "Stop authd with: systemctl stop authd.service authd.socket, to check its database"
+		return f
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		f.Status, f.Message = Warning, "there is no database yet, authd creates it when it starts"
+		return f
+	}
+	if err != nil {
+		f.Status, f.Message = Failed, err.Error()
+		f.Fix = fmt.Sprintf("Restore the database in %s from a backup, or move it away for authd to create a new one "+
+			"and the users to be added again on their next login", cacheDir)
+		return f
+	}
+
+	f.Status, f.Message = OK, "the database is consistent"
+	return f
+}
+
+// checkGroupFile checks that the group file, in which authd adds the users to their local groups, can be edited by it.
+func checkGroupFile(path string) Finding {
+	f := Finding{Check: "groups"}
+
+	d, err := os.ReadFile(path)
+	if err != nil {
+		f.Status, f.Message = Failed, fmt.Sprintf("can't be read: %v", err)
+		f.Fix = fmt.Sprintf("Restore %s from its backup, %s-", path, path)
+		return f
+	}
+
+	for i, l := range strings.Split(string(d), "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		if strings.Count(l, ":") != 3 {
+			f.Status, f.Message = Failed, fmt.Sprintf("line %d of %s is malformed: %q", i+1, path, l)
+			f.Fix = "Fix the entry with: vigr"
+			return f
+		}
+	}
+
+	f.Status, f.Message = OK, fmt.Sprintf("%s is well-formed", path)
+	return f
+}
@@ -0,0 +1,174 @@
+package doctor_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/doctor"
+	"github.com/ubuntu/authd/internal/testutils"
+	"github.com/ubuntu/authd/internal/users/cache"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		noSocket       bool
+		socketMode     os.FileMode
+		nsswitch       string
+		noPAMModule    bool
+		noBroker       bool
+		brokerStopped  bool
+		noDatabase     bool
+		corruptedDB    bool
+		databaseInUse  bool
+		noGroupFile    bool
+		malformedGroup bool
+
+		want map[string]doctor.Status
+	}{
+		"All_checks_pass": {},
+
+		"Warning_when_no_broker_is_configured": {noBroker: true, want: map[string]doctor.Status{"brokers": doctor.Warning}},
+		"Warning_when_database_does_not_exist": {noDatabase: true, want: map[string]doctor.Status{"database": doctor.Warning}},
+		"Skip_database_in_use":                 {databaseInUse: true, want: map[string]doctor.Status{"database": doctor.Skipped}},
+
+		"Error_when_socket_does_not_exist":       {noSocket: true, want: map[string]doctor.Status{"socket": doctor.Failed}},
+		"Error_when_socket_has_wrong_mode":       {socketMode: 0600, want: map[string]doctor.Status{"socket": doctor.Failed}},
+		"Error_when_nsswitch_does_not_use_authd": {nsswitch: "passwd: files authd\ngroup: files\n# shadow: files authd\n", want: map[string]doctor.Status{"nsswitch": doctor.Failed}},
+		"Error_when_pam_does_not_use_authd":      {noPAMModule: true, want: map[string]doctor.Status{"pam": doctor.Failed}},
+		"Error_when_broker_is_not_reachable":     {brokerStopped: true, want: map[string]doctor.Status{"broker": doctor.Failed}},
+		"Error_when_database_is_corrupted":       {corruptedDB: true, want: map[string]doctor.Status{"database": doctor.Failed}},
+		"Error_when_group_file_is_missing":       {noGroupFile: true, want: map[string]doctor.Status{"groups": doctor.Failed}},
+		"Error_when_group_file_is_malformed":     {malformedGroup: true, want: map[string]doctor.Status{"groups": doctor.Failed}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			config := doctor.Config{
+				NSSwitchFile:    filepath.Join(dir, "nsswitch.conf"),
+				PAMDir:          filepath.Join(dir, "pam.d"),
+				BrokersConfPath: filepath.Join(dir, "brokers.d"),
+				CacheDir:        filepath.Join(dir, "cache"),
+				GroupFile:       filepath.Join(dir, "group"),
+			}
+
+			// Socket path is limited in length.
+			socketDir, err := os.MkdirTemp("", "authd-doctor")
+			require.NoError(t, err, "Setup: could not create socket directory")
+			t.Cleanup(func() { _ = os.RemoveAll(socketDir) })
+			socket := doctor.Socket{Path: filepath.Join(socketDir, "authd.sock"), Mode: 0666}
+			config.Sockets = []doctor.Socket{socket}
+			if !tc.noSocket {
+				lis, err := net.Listen("unix", socket.Path)
+				require.NoError(t, err, "Setup: could not listen on socket")
+				t.Cleanup(func() { _ = lis.Close() })
+				if tc.socketMode == 0 {
+					tc.socketMode = 0666
+				}
+				require.NoError(t, os.Chmod(socket.Path, tc.socketMode), "Setup: could not change socket mode")
+			}
+
+			if tc.nsswitch == "" {
+				tc.nsswitch = "passwd: files systemd authd\ngroup: files systemd authd # local groups first\nshadow: files authd\n"
+			}
+			require.NoError(t, os.WriteFile(config.NSSwitchFile, []byte(tc.nsswitch), 0600), "Setup: could not write nsswitch.conf")
+
+			require.NoError(t, os.MkdirAll(config.PAMDir, 0700), "Setup: could not create PAM directory")
+			pamModule := "pam_authd_exec.so /usr/libexec/authd-pam"
+			if tc.noPAMModule {
+				pamModule = "pam_unix.so"
+			}
+			err = os.WriteFile(filepath.Join(config.PAMDir, "common-auth"), []byte("auth [success=end default=die] "+pamModule+"\n"), 0600)
+			require.NoError(t, err, "Setup: could not write PAM configuration")
+
+			require.NoError(t, os.MkdirAll(config.BrokersConfPath, 0700), "Setup: could not create brokers directory")
+			if !tc.noBroker {
+				_, stop, err := testutils.StartBusBrokerMock(config.BrokersConfPath, strings.ReplaceAll(t.Name(), "/", "_"))
+				require.NoError(t, err, "Setup: could not start bus broker mock")
+				if tc.brokerStopped {
+					stop()
+				} else {
+					t.Cleanup(stop)
+				}
+			}
+
+			require.NoError(t, os.MkdirAll(config.CacheDir, 0700), "Setup: could not create cache directory")
+			if !tc.noDatabase {
+				c, err := cache.New(config.CacheDir)
+				require.NoError(t, err, "Setup: could not create database")
+				if tc.databaseInUse {
+					t.Cleanup(func() { _ = c.Close() })
+				} else {
+					require.NoError(t, c.Close(), "Setup: could not close database")
+				}
+			}
+			if tc.corruptedDB {
+				err := os.WriteFile(filepath.Join(config.CacheDir, cache.Z_ForTests_DBName()), []byte("not a database"), 0600)
+				require.NoError(t, err, "Setup: could not corrupt database")
+			}
+
+			groups := "group1:x:1000:user1,user2\n\ngroup2:x:1001:\n"
+			if tc.malformedGroup {
+				groups = "group1:x:1000\n"
+			}
+			if !tc.noGroupFile {
+				require.NoError(t, os.WriteFile(config.GroupFile, []byte(groups), 0600), "Setup: could not write group file")
+			}
+
+			findings := doctor.Run(context.Background(), config)
+
+			got := make(map[string]doctor.Status)
+			for _, f := range findings {
+				// The checks of the sockets and of the brokers are named after them.
+				check, _, _ := strings.Cut(f.Check, " ")
+				got[check] = f.Status
+			}
+			want := map[string]doctor.Status{
+				"socket":   doctor.OK,
+				"nsswitch": doctor.OK,
+				"pam":      doctor.OK,
+				"broker":   doctor.OK,
+				"database": doctor.OK,
+				"groups":   doctor.OK,
+			}
+			if tc.noBroker {
+				delete(want, "broker")
+			}
+			for check, status := range tc.want {
+				want[check] = status
+			}
+			require.Equal(t, want, got, "Run should return the expected status for each check")
+
+			var out bytes.Buffer
+			failed := doctor.Print(&out, findings)
+			var wantFailed bool
+			for _, s := range want {
+				wantFailed = wantFailed || s == doctor.Failed
+			}
+			require.Equal(t, wantFailed, failed, "Print should return whether any check failed")
+			require.Equal(t, wantFailed, strings.Contains(out.String(), "[FAIL]"), "Print should print the failed checks")
+		})
+	}
+}
+
+func TestMain(m *testing.M) {
+	// Start system bus mock.
+	cleanup, err := testutils.StartSystemBusMock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+
+	m.Run()
+}
@@ -0,0 +1,67 @@
+// Package features gates the experimental behaviors of the daemon, so that they can ship disabled and be enabled per
+// deployment once they are stable enough for it.
+package features
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// Flag is the name of an experimental feature, as used in the configuration.
+type Flag string
+
+// Known are the experimental features which can be enabled. A feature is only added with the code it gates, which
+// checks it with Enabled, so there is none as long as no experimental behavior is shipped.
+var Known []Flag
+
+// Config enables or disables the experimental features by name. The features which are not listed are disabled.
+type Config map[Flag]bool
+
+// Validate returns an error if the configuration has unknown features.
+func (c Config) Validate() error {
+	var unknown []string
+	for f := range c {
+		if !slices.Contains(Known, f) {
+			unknown = append(unknown, string(f))
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	slices.Sort(unknown)
+	if len(Known) == 0 {
+		return fmt.Errorf("unknown features %s, no experimental feature is available", strings.Join(unknown, ", "))
+	}
+	return fmt.Errorf("unknown features %s, must be some of %v", strings.Join(unknown, ", "), Known)
+}
+
+// enabled are the experimental features enabled, sorted by name.
+var enabled atomic.Pointer[[]Flag]
+
+// Set enables the features of the configuration, and disables the other ones.
+func Set(c Config) {
+	var flags []Flag
+	for _, f := range slices.Sorted(maps.Keys(c)) {
+		if c[f] {
+			flags = append(flags, f)
+		}
+	}
+	enabled.Store(&flags)
+}
+
+// Enabled returns true if the experimental feature is enabled.
+func Enabled(f Flag) bool {
+	return slices.Contains(List(), f)
+}
+
+// List returns the experimental features enabled, sorted by name.
+func List() []Flag {
+	flags := enabled.Load()
+	if flags == nil {
+		return nil
+	}
+	return slices.Clone(*flags)
+}
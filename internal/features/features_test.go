@@ -0,0 +1,76 @@
+package features_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/features"
+)
+
+// There is no experimental feature for now, so the tests use their own.
+const (
+	featureA features.Flag = "feature_a"
+	featureB features.Flag = "feature_b"
+	featureC features.Flag = "feature_c"
+)
+
+func TestSet(t *testing.T) {
+	// The enabled features are global, so the tests can't run in parallel.
+	t.Cleanup(func() { features.Set(nil) })
+
+	tests := map[string]struct {
+		config features.Config
+
+		want []features.Flag
+	}{
+		"Disables_features_not_configured": {},
+		"Enables_features":                 {config: features.Config{featureC: true, featureA: true}, want: []features.Flag{featureA, featureC}},
+		"Disabled_features_are_not_listed": {
+			config: features.Config{featureA: true, featureB: false},
+			want:   []features.Flag{featureA},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			features.Set(features.Config{featureB: true})
+			features.Set(tc.config)
+
+			require.Equal(t, tc.want, features.List(), "List should return the enabled features")
+			for _, f := range features.Known {
+				require.Equal(t, tc.config[f], features.Enabled(f), "Enabled should match the configuration for %s", f)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config features.Config
+
+		wantErr bool
+	}{
+		"Valid_empty_config":       {},
+		"Valid_known_features":     {config: features.Config{featureA: true, featureB: false}},
+		"Error_on_unknown_feature": {config: features.Config{"doesnotexist": true}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.config.Validate()
+			if tc.wantErr {
+				require.Error(t, err, "Validate should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "Validate should not return an error, but did")
+		})
+	}
+}
+
+func TestMain(m *testing.M) {
+	features.Known = []features.Flag{featureA, featureB, featureC}
+	os.Exit(m.Run())
+}
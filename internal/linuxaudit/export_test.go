@@ -0,0 +1,14 @@
+package linuxaudit
+
+import "testing"
+
+// Z_ForTests_SetExe overrides the executable reported in the records, which is the test binary otherwise.
+//
+// nolint:revive,nolintlint // We want to use underscores in the function name here.
+func Z_ForTests_SetExe(t *testing.T, path string) {
+	t.Helper()
+
+	orig := exe
+	exe = path
+	t.Cleanup(func() { exe = orig })
+}
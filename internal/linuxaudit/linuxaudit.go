@@ -0,0 +1,148 @@
+// Package linuxaudit sends user messages to the Linux audit subsystem, like libaudit does, so that the authentications
+// done by authd are recorded by auditd and reported by ausearch and aureport like the other PAM authentications.
+package linuxaudit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Type is the type of an audit record, as defined in linux/audit.h.
+type Type uint16
+
+const (
+	// UserAuth is the type of the records of the authentications of the users.
+	UserAuth Type = 1100
+	// UserAcct is the type of the records of the checks of the accounts of the users.
+	UserAcct Type = 1101
+	// UserChauthtok is the type of the records of the changes of the passwords of the users.
+	UserChauthtok Type = 1108
+)
+
+// ErrUnavailable is returned when the records can't be sent to the audit subsystem because it's not available, like
+// when the kernel doesn't support it, in containers, or when the daemon lacks the CAP_AUDIT_WRITE capability.
+var ErrUnavailable = errors.New("audit subsystem is not available")
+
+// Record is a user message sent to the audit subsystem. The kernel adds the process information, like its PID and its
+// login UID.
+type Record struct {
+	Type Type
+	// Op is the operation, like "PAM:authentication".
+	Op      string
+	Account string
+	Broker  string
+	Session string
+	// Rhost is the remote host the user logs in from and Terminal its terminal, if they are known.
+	Rhost    string
+	Terminal string
+	Success  bool
+}
+
+// exe is the executable of the daemon, reported in the records like libaudit does.
+var exe, _ = os.Executable()
+
+// String returns the message of the record, formatted like the ones of libaudit, with the broker and the session.
+func (r Record) String() string {
+	addr := "?"
+	if net.ParseIP(r.Rhost) != nil {
+		addr = r.Rhost
+	}
+	res := "failed"
+	if r.Success {
+		res = "success"
+	}
+
+	return fmt.Sprintf("op=%s acct=%s broker=%s session_id=%s exe=%s hostname=%s addr=%s terminal=%s res=%s",
+		r.Op, encodeValue(r.Account), encodeValue(r.Broker), encodeValue(r.Session), encodeValue(exe),
+		encodeValue(r.Rhost), addr, encodeValue(r.Terminal), res)
+}
+
+// encodeValue returns the value of a field of a record, quoted, or hex encoded if it contains spaces, quotes or
+// control characters, like audit_encode_nv_string does. Unknown values are "?".
+func encodeValue(v string) string {
+	if v == "" {
+		return "?"
+	}
+	if strings.ContainsFunc(v, func(c rune) bool { return c == '"' || c < 0x21 || c > 0x7e }) {
+		return fmt.Sprintf("%X", v)
+	}
+	return `"` + v + `"`
+}
+
+// unavailable is set once the audit subsystem was found to not be available, so that it's not tried again.
+var unavailable atomic.Bool
+
+// Log sends the record to the audit subsystem and waits for it to be acknowledged. It returns ErrUnavailable if the
+// audit subsystem is not available.
+func Log(r Record) (err error) {
+	if unavailable.Load() {
+		return ErrUnavailable
+	}
+
+	err = send(r.Type, r.String())
+	if errors.Is(err, unix.EPROTONOSUPPORT) || errors.Is(err, unix.EAFNOSUPPORT) || errors.Is(err, unix.EPERM) ||
+		errors.Is(err, unix.ECONNREFUSED) {
+		unavailable.Store(true)
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if err != nil {
+		return fmt.Errorf("could not send audit record: %w", err)
+	}
+	return nil
+}
+
+// send sends the message with the given type on an audit netlink socket, and waits for its acknowledgement.
+func send(t Type, msg string) error {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_AUDIT)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	// Like libaudit, don't wait forever for the acknowledgement if the kernel is busy.
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &unix.Timeval{Sec: 1}); err != nil {
+		return err
+	}
+
+	// The message is NUL terminated, like the ones of libaudit, and padded to the netlink alignment.
+	payload := append([]byte(msg), 0)
+	size := unix.NLMSG_HDRLEN + len(payload)
+	buf := make([]byte, (size+unix.NLMSG_ALIGNTO-1) & ^(unix.NLMSG_ALIGNTO-1))
+	binary.NativeEndian.PutUint32(buf[0:4], uint32(size))
+	binary.NativeEndian.PutUint16(buf[4:6], uint16(t))
+	binary.NativeEndian.PutUint16(buf[6:8], unix.NLM_F_REQUEST|unix.NLM_F_ACK)
+	binary.NativeEndian.PutUint32(buf[8:12], 1)
+	copy(buf[unix.NLMSG_HDRLEN:], payload)
+
+	if err := unix.Sendto(fd, buf, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return err
+	}
+
+	ack := make([]byte, unix.Getpagesize())
+	n, _, err := unix.Recvfrom(fd, ack, 0)
+	if err != nil {
+		return err
+	}
+	msgs, err := syscall.ParseNetlinkMessage(ack[:n])
+	if err != nil {
+		return err
+	}
+	for _, m := range msgs {
+		if m.Header.Type != unix.NLMSG_ERROR || len(m.Data) < 4 {
+			continue
+		}
+		// The acknowledgement is an error message whose error is 0 if the record was accepted.
+		if errno := -int32(binary.NativeEndian.Uint32(m.Data[:4])); errno != 0 {
+			return syscall.Errno(errno)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package linuxaudit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/linuxaudit"
+)
+
+func TestRecordString(t *testing.T) {
+	linuxaudit.Z_ForTests_SetExe(t, "/usr/libexec/authd")
+
+	tests := map[string]struct {
+		record linuxaudit.Record
+
+		want string
+	}{
+		"Successful_authentication": {
+			record: linuxaudit.Record{Op: "PAM:authentication", Account: "user1", Broker: "Broker", Session: "id", Terminal: "tty1", Success: true},
+			want:   `op=PAM:authentication acct="user1" broker="Broker" session_id="id" exe="/usr/libexec/authd" hostname=? addr=? terminal="tty1" res=success`,
+		},
+		"Failed_authentication_from_remote_address": {
+			record: linuxaudit.Record{Op: "PAM:authentication", Account: "user1", Broker: "Broker", Session: "id", Rhost: "192.0.2.1"},
+			want:   `op=PAM:authentication acct="user1" broker="Broker" session_id="id" exe="/usr/libexec/authd" hostname="192.0.2.1" addr=192.0.2.1 terminal=? res=failed`,
+		},
+		"Remote_host_name_is_not_an_address": {
+			record: linuxaudit.Record{Op: "PAM:chauthtok", Account: "user1", Rhost: "host.example.com", Success: true},
+			want:   `op=PAM:chauthtok acct="user1" broker=? session_id=? exe="/usr/libexec/authd" hostname="host.example.com" addr=? terminal=? res=success`,
+		},
+		"Hex_encode_values_with_spaces_quotes_or_non_ASCII_characters": {
+			record: linuxaudit.Record{Op: "PAM:accounting", Account: `us"er`, Broker: "My Broker", Terminal: "tté"},
+			want:   `op=PAM:accounting acct=7573226572 broker=4D792042726F6B6572 session_id=? exe="/usr/libexec/authd" hostname=? addr=? terminal=7474C3A9 res=failed`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.record.String(), "String should format the record like libaudit")
+		})
+	}
+}
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+
+	// The audit subsystem is only available to privileged processes, when it's supported.
+	err := linuxaudit.Log(linuxaudit.Record{Type: linuxaudit.UserAuth, Op: "PAM:authentication", Account: "authd-test"})
+	if err != nil {
+		require.ErrorIs(t, err, linuxaudit.ErrUnavailable, "Log should only fail if the audit subsystem is not available")
+		require.ErrorIs(t, linuxaudit.Log(linuxaudit.Record{Type: linuxaudit.UserAuth}), linuxaudit.ErrUnavailable,
+			"Log should not try again if the audit subsystem is not available")
+	}
+}
@@ -18,6 +18,114 @@ import (
 // Requires gRPC-Go v1.64.0 or later.
 const _ = grpc.SupportPackageIsVersion9
 
+const (
+	Info_GetCapabilities_FullMethodName = "/authd.Info/GetCapabilities"
+)
+
+// InfoClient is the client API for Info service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Info is served on every socket, whatever the version of the API used by the client, so that clients can find out
+// what the daemon supports.
+type InfoClient interface {
+	GetCapabilities(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Capabilities, error)
+}
+
+type infoClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewInfoClient(cc grpc.ClientConnInterface) InfoClient {
+	return &infoClient{cc}
+}
+
+func (c *infoClient) GetCapabilities(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Capabilities, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Capabilities)
+	err := c.cc.Invoke(ctx, Info_GetCapabilities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InfoServer is the server API for Info service.
+// All implementations must embed UnimplementedInfoServer
+// for forward compatibility.
+//
+// Info is served on every socket, whatever the version of the API used by the client, so that clients can find out
+// what the daemon supports.
+type InfoServer interface {
+	GetCapabilities(context.Context, *Empty) (*Capabilities, error)
+	mustEmbedUnimplementedInfoServer()
+}
+
+// UnimplementedInfoServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedInfoServer struct{}
+
+func (UnimplementedInfoServer) GetCapabilities(context.Context, *Empty) (*Capabilities, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCapabilities not implemented")
+}
+func (UnimplementedInfoServer) mustEmbedUnimplementedInfoServer() {}
+func (UnimplementedInfoServer) testEmbeddedByValue()              {}
+
+// UnsafeInfoServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to InfoServer will
+// result in compilation errors.
+type UnsafeInfoServer interface {
+	mustEmbedUnimplementedInfoServer()
+}
+
+func RegisterInfoServer(s grpc.ServiceRegistrar, srv InfoServer) {
+	// If the following call pancis, it indicates UnimplementedInfoServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Info_ServiceDesc, srv)
+}
+
+func _Info_GetCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InfoServer).GetCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Info_GetCapabilities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InfoServer).GetCapabilities(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Info_ServiceDesc is the grpc.ServiceDesc for Info service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Info_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "authd.Info",
+	HandlerType: (*InfoServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCapabilities",
+			Handler:    _Info_GetCapabilities_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "authd.proto",
+}
+
 const (
 	PAM_AvailableBrokers_FullMethodName         = "/authd.PAM/AvailableBrokers"
 	PAM_GetPreviousBroker_FullMethodName        = "/authd.PAM/GetPreviousBroker"
@@ -27,6 +135,7 @@ const (
 	PAM_IsAuthenticated_FullMethodName          = "/authd.PAM/IsAuthenticated"
 	PAM_EndSession_FullMethodName               = "/authd.PAM/EndSession"
 	PAM_SetDefaultBrokerForUser_FullMethodName  = "/authd.PAM/SetDefaultBrokerForUser"
+	PAM_CheckAccount_FullMethodName             = "/authd.PAM/CheckAccount"
 )
 
 // PAMClient is the client API for PAM service.
@@ -41,6 +150,7 @@ type PAMClient interface {
 	IsAuthenticated(ctx context.Context, in *IARequest, opts ...grpc.CallOption) (*IAResponse, error)
 	EndSession(ctx context.Context, in *ESRequest, opts ...grpc.CallOption) (*Empty, error)
 	SetDefaultBrokerForUser(ctx context.Context, in *SDBFURequest, opts ...grpc.CallOption) (*Empty, error)
+	CheckAccount(ctx context.Context, in *CARequest, opts ...grpc.CallOption) (*CAResponse, error)
 }
 
 type pAMClient struct {
@@ -131,6 +241,16 @@ func (c *pAMClient) SetDefaultBrokerForUser(ctx context.Context, in *SDBFUReques
 	return out, nil
 }
 
+func (c *pAMClient) CheckAccount(ctx context.Context, in *CARequest, opts ...grpc.CallOption) (*CAResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CAResponse)
+	err := c.cc.Invoke(ctx, PAM_CheckAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PAMServer is the server API for PAM service.
 // All implementations must embed UnimplementedPAMServer
 // for forward compatibility.
@@ -143,6 +263,7 @@ type PAMServer interface {
 	IsAuthenticated(context.Context, *IARequest) (*IAResponse, error)
 	EndSession(context.Context, *ESRequest) (*Empty, error)
 	SetDefaultBrokerForUser(context.Context, *SDBFURequest) (*Empty, error)
+	CheckAccount(context.Context, *CARequest) (*CAResponse, error)
 	mustEmbedUnimplementedPAMServer()
 }
 
@@ -177,6 +298,9 @@ func (UnimplementedPAMServer) EndSession(context.Context, *ESRequest) (*Empty, e
 func (UnimplementedPAMServer) SetDefaultBrokerForUser(context.Context, *SDBFURequest) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetDefaultBrokerForUser not implemented")
 }
+func (UnimplementedPAMServer) CheckAccount(context.Context, *CARequest) (*CAResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckAccount not implemented")
+}
 func (UnimplementedPAMServer) mustEmbedUnimplementedPAMServer() {}
 func (UnimplementedPAMServer) testEmbeddedByValue()             {}
 
@@ -342,6 +466,24 @@ func _PAM_SetDefaultBrokerForUser_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PAM_CheckAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CARequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PAMServer).CheckAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PAM_CheckAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PAMServer).CheckAccount(ctx, req.(*CARequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PAM_ServiceDesc is the grpc.ServiceDesc for PAM service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -381,6 +523,10 @@ var PAM_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetDefaultBrokerForUser",
 			Handler:    _PAM_SetDefaultBrokerForUser_Handler,
 		},
+		{
+			MethodName: "CheckAccount",
+			Handler:    _PAM_CheckAccount_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "authd.proto",
@@ -753,3 +899,1017 @@ var NSS_ServiceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "authd.proto",
 }
+
+const (
+	UserService_ListIDCollisions_FullMethodName          = "/authd.UserService/ListIDCollisions"
+	UserService_RemapUserID_FullMethodName               = "/authd.UserService/RemapUserID"
+	UserService_RemapGroupID_FullMethodName              = "/authd.UserService/RemapGroupID"
+	UserService_ListIDTranslations_FullMethodName        = "/authd.UserService/ListIDTranslations"
+	UserService_PurgeUser_FullMethodName                 = "/authd.UserService/PurgeUser"
+	UserService_RunMaintenance_FullMethodName            = "/authd.UserService/RunMaintenance"
+	UserService_ListAuditEvents_FullMethodName           = "/authd.UserService/ListAuditEvents"
+	UserService_SetUserAttributes_FullMethodName         = "/authd.UserService/SetUserAttributes"
+	UserService_LockUser_FullMethodName                  = "/authd.UserService/LockUser"
+	UserService_UnlockUser_FullMethodName                = "/authd.UserService/UnlockUser"
+	UserService_GetLastLogin_FullMethodName              = "/authd.UserService/GetLastLogin"
+	UserService_GetMetrics_FullMethodName                = "/authd.UserService/GetMetrics"
+	UserService_GetUserExtendedAttributes_FullMethodName = "/authd.UserService/GetUserExtendedAttributes"
+	UserService_ListUsers_FullMethodName                 = "/authd.UserService/ListUsers"
+	UserService_ListGroups_FullMethodName                = "/authd.UserService/ListGroups"
+	UserService_AdoptUser_FullMethodName                 = "/authd.UserService/AdoptUser"
+	UserService_GetLogLevel_FullMethodName               = "/authd.UserService/GetLogLevel"
+	UserService_SetLogLevel_FullMethodName               = "/authd.UserService/SetLogLevel"
+	UserService_ListSessions_FullMethodName              = "/authd.UserService/ListSessions"
+	UserService_TerminateSession_FullMethodName          = "/authd.UserService/TerminateSession"
+	UserService_GetLocalGroupsDryRun_FullMethodName      = "/authd.UserService/GetLocalGroupsDryRun"
+	UserService_SetLocalGroupsDryRun_FullMethodName      = "/authd.UserService/SetLocalGroupsDryRun"
+	UserService_ReconcileLocalGroups_FullMethodName      = "/authd.UserService/ReconcileLocalGroups"
+	UserService_GetLockout_FullMethodName                = "/authd.UserService/GetLockout"
+	UserService_UnlockRemoteHost_FullMethodName          = "/authd.UserService/UnlockRemoteHost"
+)
+
+// UserServiceClient is the client API for UserService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UserServiceClient interface {
+	ListIDCollisions(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*IDCollisions, error)
+	RemapUserID(ctx context.Context, in *RemapIDRequest, opts ...grpc.CallOption) (*IDTranslation, error)
+	RemapGroupID(ctx context.Context, in *RemapIDRequest, opts ...grpc.CallOption) (*IDTranslation, error)
+	ListIDTranslations(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*IDTranslations, error)
+	PurgeUser(ctx context.Context, in *PurgeUserRequest, opts ...grpc.CallOption) (*Empty, error)
+	RunMaintenance(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*MaintenanceReport, error)
+	ListAuditEvents(ctx context.Context, in *AuditEventsRequest, opts ...grpc.CallOption) (*AuditEvents, error)
+	SetUserAttributes(ctx context.Context, in *SetUserAttributesRequest, opts ...grpc.CallOption) (*Empty, error)
+	LockUser(ctx context.Context, in *LockUserRequest, opts ...grpc.CallOption) (*Empty, error)
+	UnlockUser(ctx context.Context, in *LockUserRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetLastLogin(ctx context.Context, in *LastLoginRequest, opts ...grpc.CallOption) (*LastLogin, error)
+	GetMetrics(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Metrics, error)
+	GetUserExtendedAttributes(ctx context.Context, in *ExtendedAttributesRequest, opts ...grpc.CallOption) (*ExtendedAttributes, error)
+	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error)
+	ListGroups(ctx context.Context, in *ListGroupsRequest, opts ...grpc.CallOption) (*ListGroupsResponse, error)
+	AdoptUser(ctx context.Context, in *AdoptUserRequest, opts ...grpc.CallOption) (*PasswdEntry, error)
+	GetLogLevel(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*LogLevel, error)
+	SetLogLevel(ctx context.Context, in *LogLevel, opts ...grpc.CallOption) (*LogLevel, error)
+	ListSessions(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Sessions, error)
+	TerminateSession(ctx context.Context, in *TerminateSessionRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetLocalGroupsDryRun(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*LocalGroupsDryRun, error)
+	SetLocalGroupsDryRun(ctx context.Context, in *LocalGroupsDryRun, opts ...grpc.CallOption) (*LocalGroupsDryRun, error)
+	ReconcileLocalGroups(ctx context.Context, in *ReconcileLocalGroupsRequest, opts ...grpc.CallOption) (*LocalGroupsDrift, error)
+	GetLockout(ctx context.Context, in *LockoutRequest, opts ...grpc.CallOption) (*Lockout, error)
+	UnlockRemoteHost(ctx context.Context, in *UnlockRemoteHostRequest, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type userServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUserServiceClient(cc grpc.ClientConnInterface) UserServiceClient {
+	return &userServiceClient{cc}
+}
+
+func (c *userServiceClient) ListIDCollisions(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*IDCollisions, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IDCollisions)
+	err := c.cc.Invoke(ctx, UserService_ListIDCollisions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RemapUserID(ctx context.Context, in *RemapIDRequest, opts ...grpc.CallOption) (*IDTranslation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IDTranslation)
+	err := c.cc.Invoke(ctx, UserService_RemapUserID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RemapGroupID(ctx context.Context, in *RemapIDRequest, opts ...grpc.CallOption) (*IDTranslation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IDTranslation)
+	err := c.cc.Invoke(ctx, UserService_RemapGroupID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ListIDTranslations(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*IDTranslations, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IDTranslations)
+	err := c.cc.Invoke(ctx, UserService_ListIDTranslations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) PurgeUser(ctx context.Context, in *PurgeUserRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UserService_PurgeUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RunMaintenance(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*MaintenanceReport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MaintenanceReport)
+	err := c.cc.Invoke(ctx, UserService_RunMaintenance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ListAuditEvents(ctx context.Context, in *AuditEventsRequest, opts ...grpc.CallOption) (*AuditEvents, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuditEvents)
+	err := c.cc.Invoke(ctx, UserService_ListAuditEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) SetUserAttributes(ctx context.Context, in *SetUserAttributesRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UserService_SetUserAttributes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) LockUser(ctx context.Context, in *LockUserRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UserService_LockUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) UnlockUser(ctx context.Context, in *LockUserRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UserService_UnlockUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetLastLogin(ctx context.Context, in *LastLoginRequest, opts ...grpc.CallOption) (*LastLogin, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LastLogin)
+	err := c.cc.Invoke(ctx, UserService_GetLastLogin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetMetrics(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Metrics, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Metrics)
+	err := c.cc.Invoke(ctx, UserService_GetMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetUserExtendedAttributes(ctx context.Context, in *ExtendedAttributesRequest, opts ...grpc.CallOption) (*ExtendedAttributes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExtendedAttributes)
+	err := c.cc.Invoke(ctx, UserService_GetUserExtendedAttributes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListUsersResponse)
+	err := c.cc.Invoke(ctx, UserService_ListUsers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ListGroups(ctx context.Context, in *ListGroupsRequest, opts ...grpc.CallOption) (*ListGroupsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListGroupsResponse)
+	err := c.cc.Invoke(ctx, UserService_ListGroups_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) AdoptUser(ctx context.Context, in *AdoptUserRequest, opts ...grpc.CallOption) (*PasswdEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PasswdEntry)
+	err := c.cc.Invoke(ctx, UserService_AdoptUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetLogLevel(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*LogLevel, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LogLevel)
+	err := c.cc.Invoke(ctx, UserService_GetLogLevel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) SetLogLevel(ctx context.Context, in *LogLevel, opts ...grpc.CallOption) (*LogLevel, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LogLevel)
+	err := c.cc.Invoke(ctx, UserService_SetLogLevel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ListSessions(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Sessions, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Sessions)
+	err := c.cc.Invoke(ctx, UserService_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) TerminateSession(ctx context.Context, in *TerminateSessionRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UserService_TerminateSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetLocalGroupsDryRun(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*LocalGroupsDryRun, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LocalGroupsDryRun)
+	err := c.cc.Invoke(ctx, UserService_GetLocalGroupsDryRun_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) SetLocalGroupsDryRun(ctx context.Context, in *LocalGroupsDryRun, opts ...grpc.CallOption) (*LocalGroupsDryRun, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LocalGroupsDryRun)
+	err := c.cc.Invoke(ctx, UserService_SetLocalGroupsDryRun_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ReconcileLocalGroups(ctx context.Context, in *ReconcileLocalGroupsRequest, opts ...grpc.CallOption) (*LocalGroupsDrift, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LocalGroupsDrift)
+	err := c.cc.Invoke(ctx, UserService_ReconcileLocalGroups_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetLockout(ctx context.Context, in *LockoutRequest, opts ...grpc.CallOption) (*Lockout, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Lockout)
+	err := c.cc.Invoke(ctx, UserService_GetLockout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) UnlockRemoteHost(ctx context.Context, in *UnlockRemoteHostRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UserService_UnlockRemoteHost_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UserServiceServer is the server API for UserService service.
+// All implementations must embed UnimplementedUserServiceServer
+// for forward compatibility.
+type UserServiceServer interface {
+	ListIDCollisions(context.Context, *Empty) (*IDCollisions, error)
+	RemapUserID(context.Context, *RemapIDRequest) (*IDTranslation, error)
+	RemapGroupID(context.Context, *RemapIDRequest) (*IDTranslation, error)
+	ListIDTranslations(context.Context, *Empty) (*IDTranslations, error)
+	PurgeUser(context.Context, *PurgeUserRequest) (*Empty, error)
+	RunMaintenance(context.Context, *Empty) (*MaintenanceReport, error)
+	ListAuditEvents(context.Context, *AuditEventsRequest) (*AuditEvents, error)
+	SetUserAttributes(context.Context, *SetUserAttributesRequest) (*Empty, error)
+	LockUser(context.Context, *LockUserRequest) (*Empty, error)
+	UnlockUser(context.Context, *LockUserRequest) (*Empty, error)
+	GetLastLogin(context.Context, *LastLoginRequest) (*LastLogin, error)
+	GetMetrics(context.Context, *Empty) (*Metrics, error)
+	GetUserExtendedAttributes(context.Context, *ExtendedAttributesRequest) (*ExtendedAttributes, error)
+	ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error)
+	ListGroups(context.Context, *ListGroupsRequest) (*ListGroupsResponse, error)
+	AdoptUser(context.Context, *AdoptUserRequest) (*PasswdEntry, error)
+	GetLogLevel(context.Context, *Empty) (*LogLevel, error)
+	SetLogLevel(context.Context, *LogLevel) (*LogLevel, error)
+	ListSessions(context.Context, *Empty) (*Sessions, error)
+	TerminateSession(context.Context, *TerminateSessionRequest) (*Empty, error)
+	GetLocalGroupsDryRun(context.Context, *Empty) (*LocalGroupsDryRun, error)
+	SetLocalGroupsDryRun(context.Context, *LocalGroupsDryRun) (*LocalGroupsDryRun, error)
+	ReconcileLocalGroups(context.Context, *ReconcileLocalGroupsRequest) (*LocalGroupsDrift, error)
+	GetLockout(context.Context, *LockoutRequest) (*Lockout, error)
+	UnlockRemoteHost(context.Context, *UnlockRemoteHostRequest) (*Empty, error)
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+// UnimplementedUserServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedUserServiceServer struct{}
+
+func (UnimplementedUserServiceServer) ListIDCollisions(context.Context, *Empty) (*IDCollisions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListIDCollisions not implemented")
+}
+func (UnimplementedUserServiceServer) RemapUserID(context.Context, *RemapIDRequest) (*IDTranslation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemapUserID not implemented")
+}
+func (UnimplementedUserServiceServer) RemapGroupID(context.Context, *RemapIDRequest) (*IDTranslation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemapGroupID not implemented")
+}
+func (UnimplementedUserServiceServer) ListIDTranslations(context.Context, *Empty) (*IDTranslations, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListIDTranslations not implemented")
+}
+func (UnimplementedUserServiceServer) PurgeUser(context.Context, *PurgeUserRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeUser not implemented")
+}
+func (UnimplementedUserServiceServer) RunMaintenance(context.Context, *Empty) (*MaintenanceReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunMaintenance not implemented")
+}
+func (UnimplementedUserServiceServer) ListAuditEvents(context.Context, *AuditEventsRequest) (*AuditEvents, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEvents not implemented")
+}
+func (UnimplementedUserServiceServer) SetUserAttributes(context.Context, *SetUserAttributesRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetUserAttributes not implemented")
+}
+func (UnimplementedUserServiceServer) LockUser(context.Context, *LockUserRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LockUser not implemented")
+}
+func (UnimplementedUserServiceServer) UnlockUser(context.Context, *LockUserRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlockUser not implemented")
+}
+func (UnimplementedUserServiceServer) GetLastLogin(context.Context, *LastLoginRequest) (*LastLogin, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLastLogin not implemented")
+}
+func (UnimplementedUserServiceServer) GetMetrics(context.Context, *Empty) (*Metrics, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetrics not implemented")
+}
+func (UnimplementedUserServiceServer) GetUserExtendedAttributes(context.Context, *ExtendedAttributesRequest) (*ExtendedAttributes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserExtendedAttributes not implemented")
+}
+func (UnimplementedUserServiceServer) ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (UnimplementedUserServiceServer) ListGroups(context.Context, *ListGroupsRequest) (*ListGroupsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGroups not implemented")
+}
+func (UnimplementedUserServiceServer) AdoptUser(context.Context, *AdoptUserRequest) (*PasswdEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdoptUser not implemented")
+}
+func (UnimplementedUserServiceServer) GetLogLevel(context.Context, *Empty) (*LogLevel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLogLevel not implemented")
+}
+func (UnimplementedUserServiceServer) SetLogLevel(context.Context, *LogLevel) (*LogLevel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
+}
+func (UnimplementedUserServiceServer) ListSessions(context.Context, *Empty) (*Sessions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedUserServiceServer) TerminateSession(context.Context, *TerminateSessionRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TerminateSession not implemented")
+}
+func (UnimplementedUserServiceServer) GetLocalGroupsDryRun(context.Context, *Empty) (*LocalGroupsDryRun, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLocalGroupsDryRun not implemented")
+}
+func (UnimplementedUserServiceServer) SetLocalGroupsDryRun(context.Context, *LocalGroupsDryRun) (*LocalGroupsDryRun, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLocalGroupsDryRun not implemented")
+}
+func (UnimplementedUserServiceServer) ReconcileLocalGroups(context.Context, *ReconcileLocalGroupsRequest) (*LocalGroupsDrift, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReconcileLocalGroups not implemented")
+}
+func (UnimplementedUserServiceServer) GetLockout(context.Context, *LockoutRequest) (*Lockout, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLockout not implemented")
+}
+func (UnimplementedUserServiceServer) UnlockRemoteHost(context.Context, *UnlockRemoteHostRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlockRemoteHost not implemented")
+}
+func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
+func (UnimplementedUserServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UserServiceServer will
+// result in compilation errors.
+type UnsafeUserServiceServer interface {
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+func RegisterUserServiceServer(s grpc.ServiceRegistrar, srv UserServiceServer) {
+	// If the following call pancis, it indicates UnimplementedUserServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&UserService_ServiceDesc, srv)
+}
+
+func _UserService_ListIDCollisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListIDCollisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ListIDCollisions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListIDCollisions(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RemapUserID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemapIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RemapUserID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RemapUserID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RemapUserID(ctx, req.(*RemapIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RemapGroupID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemapIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RemapGroupID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RemapGroupID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RemapGroupID(ctx, req.(*RemapIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ListIDTranslations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListIDTranslations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ListIDTranslations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListIDTranslations(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_PurgeUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).PurgeUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_PurgeUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).PurgeUser(ctx, req.(*PurgeUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RunMaintenance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RunMaintenance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RunMaintenance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RunMaintenance(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ListAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ListAuditEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListAuditEvents(ctx, req.(*AuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_SetUserAttributes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetUserAttributesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).SetUserAttributes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_SetUserAttributes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).SetUserAttributes(ctx, req.(*SetUserAttributesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_LockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).LockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_LockUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).LockUser(ctx, req.(*LockUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_UnlockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).UnlockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_UnlockUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).UnlockUser(ctx, req.(*LockUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetLastLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LastLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetLastLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetLastLogin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetLastLogin(ctx, req.(*LastLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetMetrics(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetUserExtendedAttributes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExtendedAttributesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetUserExtendedAttributes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetUserExtendedAttributes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetUserExtendedAttributes(ctx, req.(*ExtendedAttributesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ListUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListUsers(ctx, req.(*ListUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ListGroups_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGroupsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListGroups(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ListGroups_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListGroups(ctx, req.(*ListGroupsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_AdoptUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdoptUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).AdoptUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_AdoptUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).AdoptUser(ctx, req.(*AdoptUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetLogLevel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetLogLevel(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogLevel)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_SetLogLevel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).SetLogLevel(ctx, req.(*LogLevel))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListSessions(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_TerminateSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TerminateSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).TerminateSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_TerminateSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).TerminateSession(ctx, req.(*TerminateSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetLocalGroupsDryRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetLocalGroupsDryRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetLocalGroupsDryRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetLocalGroupsDryRun(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_SetLocalGroupsDryRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LocalGroupsDryRun)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).SetLocalGroupsDryRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_SetLocalGroupsDryRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).SetLocalGroupsDryRun(ctx, req.(*LocalGroupsDryRun))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ReconcileLocalGroups_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReconcileLocalGroupsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ReconcileLocalGroups(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ReconcileLocalGroups_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ReconcileLocalGroups(ctx, req.(*ReconcileLocalGroupsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetLockout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetLockout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetLockout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetLockout(ctx, req.(*LockoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_UnlockRemoteHost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlockRemoteHostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).UnlockRemoteHost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_UnlockRemoteHost_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).UnlockRemoteHost(ctx, req.(*UnlockRemoteHostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "authd.UserService",
+	HandlerType: (*UserServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListIDCollisions",
+			Handler:    _UserService_ListIDCollisions_Handler,
+		},
+		{
+			MethodName: "RemapUserID",
+			Handler:    _UserService_RemapUserID_Handler,
+		},
+		{
+			MethodName: "RemapGroupID",
+			Handler:    _UserService_RemapGroupID_Handler,
+		},
+		{
+			MethodName: "ListIDTranslations",
+			Handler:    _UserService_ListIDTranslations_Handler,
+		},
+		{
+			MethodName: "PurgeUser",
+			Handler:    _UserService_PurgeUser_Handler,
+		},
+		{
+			MethodName: "RunMaintenance",
+			Handler:    _UserService_RunMaintenance_Handler,
+		},
+		{
+			MethodName: "ListAuditEvents",
+			Handler:    _UserService_ListAuditEvents_Handler,
+		},
+		{
+			MethodName: "SetUserAttributes",
+			Handler:    _UserService_SetUserAttributes_Handler,
+		},
+		{
+			MethodName: "LockUser",
+			Handler:    _UserService_LockUser_Handler,
+		},
+		{
+			MethodName: "UnlockUser",
+			Handler:    _UserService_UnlockUser_Handler,
+		},
+		{
+			MethodName: "GetLastLogin",
+			Handler:    _UserService_GetLastLogin_Handler,
+		},
+		{
+			MethodName: "GetMetrics",
+			Handler:    _UserService_GetMetrics_Handler,
+		},
+		{
+			MethodName: "GetUserExtendedAttributes",
+			Handler:    _UserService_GetUserExtendedAttributes_Handler,
+		},
+		{
+			MethodName: "ListUsers",
+			Handler:    _UserService_ListUsers_Handler,
+		},
+		{
+			MethodName: "ListGroups",
+			Handler:    _UserService_ListGroups_Handler,
+		},
+		{
+			MethodName: "AdoptUser",
+			Handler:    _UserService_AdoptUser_Handler,
+		},
+		{
+			MethodName: "GetLogLevel",
+			Handler:    _UserService_GetLogLevel_Handler,
+		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _UserService_SetLogLevel_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _UserService_ListSessions_Handler,
+		},
+		{
+			MethodName: "TerminateSession",
+			Handler:    _UserService_TerminateSession_Handler,
+		},
+		{
+			MethodName: "GetLocalGroupsDryRun",
+			Handler:    _UserService_GetLocalGroupsDryRun_Handler,
+		},
+		{
+			MethodName: "SetLocalGroupsDryRun",
+			Handler:    _UserService_SetLocalGroupsDryRun_Handler,
+		},
+		{
+			MethodName: "ReconcileLocalGroups",
+			Handler:    _UserService_ReconcileLocalGroups_Handler,
+		},
+		{
+			MethodName: "GetLockout",
+			Handler:    _UserService_GetLockout_Handler,
+		},
+		{
+			MethodName: "UnlockRemoteHost",
+			Handler:    _UserService_UnlockRemoteHost_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "authd.proto",
+}
@@ -0,0 +1,11 @@
+package sandbox
+
+// LandlockABI returns the version of the landlock ABI supported by the kernel, 0 if landlock is not supported.
+func LandlockABI() (int, error) {
+	return landlockABI()
+}
+
+// SetLandlockABI overrides the version of the landlock ABI supported by the kernel.
+func SetLandlockABI(abi int) {
+	landlockABI = func() (int, error) { return abi, nil }
+}
@@ -0,0 +1,122 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileAccess are the accesses which apply to files, as opposed to directories.
+const fileAccess = unix.LANDLOCK_ACCESS_FS_WRITE_FILE | unix.LANDLOCK_ACCESS_FS_TRUNCATE
+
+// replaceAccess are the accesses needed to replace the files of a directory by files written elsewhere, by linking or
+// renaming them there, without writing to the files of the directory.
+const replaceAccess = unix.LANDLOCK_ACCESS_FS_MAKE_REG | unix.LANDLOCK_ACCESS_FS_REMOVE_FILE | unix.LANDLOCK_ACCESS_FS_REFER
+
+// minLandlockABI is the first version of the landlock ABI which allows moving files between directories. The first
+// version denies it, so that the files of the replaceable directories, which are written in another directory, could
+// never be replaced.
+const minLandlockABI = 2
+
+// handledAccess returns the accesses restricted by landlock, for the version of its ABI supported by the kernel. Only
+// the writes are restricted: the daemon can read everywhere, as it runs the commands of the system.
+func handledAccess(abi int) uint64 {
+	access := uint64(unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM |
+		unix.LANDLOCK_ACCESS_FS_REFER)
+	if abi >= 3 {
+		access |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	return access
+}
+
+// landlockABI returns the version of the landlock ABI supported by the kernel, 0 if landlock is not supported. It can
+// be overridden in tests.
+var landlockABI = kernelLandlockABI
+
+// kernelLandlockABI returns the version of the landlock ABI supported by the kernel, 0 if landlock is not supported.
+func kernelLandlockABI() (int, error) {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno == unix.ENOSYS || errno == unix.EOPNOTSUPP {
+		return 0, nil
+	}
+	if errno != 0 {
+		return 0, fmt.Errorf("could not get landlock ABI version: %w", errno)
+	}
+	return int(abi), nil
+}
+
+// restrictPaths only allows the calling thread to write to the given paths, and to replace the files of the given
+// directories. It returns false if landlock is not supported by the kernel, or only its first version, which denies
+// replacing the files of a directory by files written in another one.
+func restrictPaths(writablePaths, replaceableDirs []string) (applied bool, err error) {
+	abi, err := landlockABI()
+	if err != nil {
+		return false, err
+	}
+	if abi < minLandlockABI {
+		return false, nil
+	}
+	access := handledAccess(abi)
+
+	attr := unix.LandlockRulesetAttr{Access_fs: access}
+	ruleset, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return false, fmt.Errorf("could not create landlock ruleset: %w", errno)
+	}
+	defer unix.Close(int(ruleset))
+
+	for _, path := range writablePaths {
+		if err := allowWrites(int(ruleset), path, access); err != nil {
+			return false, err
+		}
+	}
+	for _, path := range replaceableDirs {
+		if err := allowWrites(int(ruleset), path, access&replaceAccess); err != nil {
+			return false, err
+		}
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, ruleset, 0, 0); errno != 0 {
+		return false, fmt.Errorf("could not enforce landlock ruleset: %w", errno)
+	}
+	return true, nil
+}
+
+// allowWrites adds the rule allowing the given writes beneath path to the ruleset. Nothing is done if path doesn't exist.
+func allowWrites(ruleset int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if errors.Is(err, unix.ENOENT) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", path, err)
+	}
+	defer unix.Close(fd)
+
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return fmt.Errorf("could not stat %s: %w", path, err)
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &= fileAccess
+	}
+
+	rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset), unix.LANDLOCK_RULE_PATH_BENEATH,
+		uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("could not allow writes to %s: %w", path, errno)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Package sandbox restricts what the daemon can do once started, to reduce the impact of the compromise of the code
+// paths exposed to the brokers and to the clients.
+package sandbox
+
+import (
+	"context"
+	"os"
+	"runtime"
+
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+	"golang.org/x/sys/unix"
+)
+
+// sandboxedEnv is set when the daemon re-executes itself in the sandbox.
+const sandboxedEnv = "AUTHD_SANDBOXED"
+
+// Apply restricts the daemon with a seccomp filter denying the system calls it never needs, and with landlock rules
+// only allowing it to write to the given paths, and to replace the files of replaceableDirs by files written in the
+// writable paths, without writing to them. The paths which don't exist are ignored. The restrictions which are not
+// supported by the kernel are skipped.
+//
+// The restrictions are inherited by the commands run by the daemon, like setquota. As landlock only restricts the
+// calling thread and the ones it creates, the daemon re-executes itself from the restricted thread so that all its
+// threads are: Apply doesn't return on success, and returns right away once the daemon is re-executed.
+func Apply(ctx context.Context, writablePaths, replaceableDirs []string) (err error) {
+	defer decorate.OnError(&err, "could not sandbox the daemon")
+
+	if os.Getenv(sandboxedEnv) != "" {
+		log.Debug(ctx, "Running sandboxed")
+		return os.Unsetenv(sandboxedEnv)
+	}
+
+	// The thread is never unlocked once restricted, so that Go doesn't reuse it if the restrictions fail halfway.
+	runtime.LockOSThread()
+
+	// Unprivileged processes can only restrict themselves if they can't gain privileges anymore.
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return err
+	}
+
+	applied, err := restrictPaths(writablePaths, replaceableDirs)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		log.Warning(ctx, "Landlock is not supported by the kernel, or too old to replace files, the paths the daemon can write to are not restricted")
+	}
+
+	filtered, err := filterSyscalls()
+	if err != nil {
+		return err
+	}
+	if !filtered {
+		log.Warningf(ctx, "Seccomp filter is not supported on %s, the system calls of the daemon are not restricted", runtime.GOARCH)
+	}
+
+	if !applied && !filtered {
+		runtime.UnlockOSThread()
+		return nil
+	}
+
+	log.Debugf(ctx, "Re-executing in the sandbox, writable paths: %v, directories whose files can be replaced: %v", writablePaths, replaceableDirs)
+	return unix.Exec("/proc/self/exe", os.Args, append(os.Environ(), sandboxedEnv+"=1"))
+}
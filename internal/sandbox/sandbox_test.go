@@ -0,0 +1,115 @@
+package sandbox_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/sandbox"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	writableDirEnv    = "AUTHD_TEST_SANDBOX_WRITABLE_DIR"
+	replaceableDirEnv = "AUTHD_TEST_SANDBOX_REPLACEABLE_DIR"
+	readOnlyDirEnv    = "AUTHD_TEST_SANDBOX_READ_ONLY_DIR"
+	landlockABIEnv    = "AUTHD_TEST_SANDBOX_LANDLOCK_ABI"
+)
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		landlockABI int
+	}{
+		"Restrict_the_paths_the_process_can_write_to":               {},
+		"Do_not_restrict_the_paths_with_the_first_landlock_version": {landlockABI: 1},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// The files can only be moved between directories of the same file system.
+			dir := t.TempDir()
+			writableDir, replaceableDir, readOnlyDir := filepath.Join(dir, "writable"), filepath.Join(dir, "replaceable"), t.TempDir()
+			for _, d := range []string{writableDir, replaceableDir} {
+				require.NoError(t, os.Mkdir(d, 0700), "Setup: could not create directory")
+			}
+
+			// The sandbox can't be undone, so it's applied to another process.
+			//nolint:gosec // This runs the test binary itself.
+			cmd := exec.Command(os.Args[0], "-test.run=^TestSandboxedProcess$", "-test.v")
+			cmd.Env = slices.DeleteFunc(os.Environ(), func(e string) bool { return strings.HasPrefix(e, "GOCOVERDIR=") })
+			cmd.Env = append(cmd.Env, writableDirEnv+"="+writableDir, replaceableDirEnv+"="+replaceableDir, readOnlyDirEnv+"="+readOnlyDir)
+			if tc.landlockABI != 0 {
+				cmd.Env = append(cmd.Env, landlockABIEnv+"="+strconv.Itoa(tc.landlockABI))
+			}
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, "The sandboxed process should not fail: %s", out)
+			require.Contains(t, string(out), "--- PASS: TestSandboxedProcess", "The sandboxed process should run its checks")
+
+			require.FileExists(t, filepath.Join(writableDir, "file"), "The writable directory should be writable in the sandbox")
+			require.FileExists(t, filepath.Join(replaceableDir, "replaced"), "The files of the replaceable directory should be replaceable in the sandbox")
+			abi := tc.landlockABI
+			if abi == 0 {
+				abi, err = sandbox.LandlockABI()
+				require.NoError(t, err, "Setup: could not get landlock ABI version")
+			}
+			if abi < 2 {
+				require.FileExists(t, filepath.Join(readOnlyDir, "file"), "The paths should not be restricted without a supported landlock version")
+				return
+			}
+			require.NoFileExists(t, filepath.Join(readOnlyDir, "file"), "The other directories should not be writable in the sandbox")
+			// The file can be created, but not written.
+			d, _ := os.ReadFile(filepath.Join(replaceableDir, "file"))
+			require.Empty(t, d, "The files of the replaceable directory should not be writable in the sandbox")
+		})
+	}
+}
+
+// TestSandboxedProcess is the process sandboxed by TestApply.
+func TestSandboxedProcess(t *testing.T) {
+	writableDir, replaceableDir, readOnlyDir := os.Getenv(writableDirEnv), os.Getenv(replaceableDirEnv), os.Getenv(readOnlyDirEnv)
+	if writableDir == "" {
+		t.Skip("Only run as the process sandboxed by TestApply")
+	}
+	if v := os.Getenv(landlockABIEnv); v != "" {
+		abi, err := strconv.Atoi(v)
+		require.NoError(t, err, "Setup: invalid landlock ABI version")
+		sandbox.SetLandlockABI(abi)
+	}
+
+	// The process is re-executed in the sandbox, where Apply returns.
+	err := sandbox.Apply(context.Background(), []string{writableDir, filepath.Join(readOnlyDir, "doesnotexist")}, []string{replaceableDir})
+	require.NoError(t, err, "Apply should not return an error, but did")
+	require.Empty(t, os.Getenv("AUTHD_SANDBOXED"), "The commands run in the sandbox should not inherit the variable of the sandbox")
+
+	err = os.WriteFile(filepath.Join(writableDir, "file"), []byte("content"), 0600)
+	require.NoError(t, err, "Writing to a writable directory should not fail")
+
+	abi, err := sandbox.LandlockABI()
+	require.NoError(t, err, "Setup: could not get landlock ABI version")
+	err = os.WriteFile(filepath.Join(readOnlyDir, "file"), []byte("content"), 0600)
+	if abi < 2 {
+		require.NoError(t, err, "Writing to another directory should not fail without a supported landlock version")
+	} else {
+		require.ErrorIs(t, err, fs.ErrPermission, "Writing to another directory should be denied")
+		err = os.WriteFile(filepath.Join(replaceableDir, "file"), []byte("content"), 0600)
+		require.ErrorIs(t, err, fs.ErrPermission, "Writing to a directory whose files are replaced should be denied")
+	}
+
+	err = os.WriteFile(filepath.Join(writableDir, "replaced"), []byte("content"), 0600)
+	require.NoError(t, err, "Setup: could not write the file replacing the one of the replaceable directory")
+	err = os.Rename(filepath.Join(writableDir, "replaced"), filepath.Join(replaceableDir, "replaced"))
+	require.NoError(t, err, "Moving a file written in a writable directory to a directory whose files are replaced should not fail")
+
+	err = unix.Unshare(unix.CLONE_NEWNS)
+	require.ErrorIs(t, err, unix.EPERM, "Denied system calls should fail")
+}
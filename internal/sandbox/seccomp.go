@@ -0,0 +1,88 @@
+package sandbox
+
+import (
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// deniedSyscalls are the system calls the daemon and the commands it runs never need, and which would let a
+// compromised daemon take over the kernel, inspect other processes or escape its other restrictions.
+var deniedSyscalls = []uint32{
+	unix.SYS_ACCT,
+	unix.SYS_BPF,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_MOUNT,
+	unix.SYS_OPEN_BY_HANDLE_AT,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_PROCESS_VM_READV,
+	unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_PTRACE,
+	unix.SYS_REBOOT,
+	unix.SYS_SETNS,
+	unix.SYS_SWAPOFF,
+	unix.SYS_SWAPON,
+	unix.SYS_UMOUNT2,
+	unix.SYS_UNSHARE,
+	unix.SYS_USERFAULTFD,
+}
+
+// auditArchs are the architectures of the system calls checked by the filter, by GOARCH.
+var auditArchs = map[string]uint32{
+	"386":     unix.AUDIT_ARCH_I386,
+	"amd64":   unix.AUDIT_ARCH_X86_64,
+	"arm":     unix.AUDIT_ARCH_ARM,
+	"arm64":   unix.AUDIT_ARCH_AARCH64,
+	"ppc64":   unix.AUDIT_ARCH_PPC64,
+	"ppc64le": unix.AUDIT_ARCH_PPC64LE,
+	"riscv64": unix.AUDIT_ARCH_RISCV64,
+	"s390x":   unix.AUDIT_ARCH_S390X,
+}
+
+// x32SyscallBit is set in the numbers of the system calls of the x32 ABI, which share the architecture of amd64.
+const x32SyscallBit = 0x40000000
+
+// filterSyscalls installs the seccomp filter denying deniedSyscalls on the calling thread. It returns false if the
+// architecture is not supported.
+func filterSyscalls() (applied bool, err error) {
+	arch, ok := auditArchs[runtime.GOARCH]
+	if !ok {
+		return false, nil
+	}
+
+	filter := seccompFilter(arch)
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return false, err
+	}
+	runtime.KeepAlive(filter)
+	return true, nil
+}
+
+// seccompFilter returns the BPF program denying deniedSyscalls, and the system calls of the other architectures which
+// could be used to bypass it, with EPERM.
+func seccompFilter(arch uint32) []unix.SockFilter {
+	deny := unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)}
+
+	// The offsets are the ones of the arch and nr fields of struct seccomp_data.
+	filter := []unix.SockFilter{
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: 4},
+		{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, K: arch, Jt: 1},
+		deny,
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: 0},
+		{Code: unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K, K: x32SyscallBit, Jf: 1},
+		deny,
+	}
+	for _, nr := range deniedSyscalls {
+		filter = append(filter,
+			unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, K: nr, Jf: 1},
+			deny,
+		)
+	}
+	return append(filter, unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: unix.SECCOMP_RET_ALLOW})
+}
@@ -0,0 +1,126 @@
+// Package securemem holds secrets, like the passwords and the challenges of the authentications, in memory locked in
+// RAM, so that they are never written to the swap, and excluded from the core dumps. The secrets are wiped as soon as
+// they are not needed anymore, and can't be printed by mistake, like in the logs.
+//
+// The strings can't be wiped, so the secrets must be held in a buffer from where they are read, like the inputs of the
+// users, to where they are used, like their encryption, and never converted to strings in between.
+package securemem
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// redacted replaces the secrets when they are printed.
+const redacted = "[redacted]"
+
+// Buffer is a secret held in locked memory. It must be wiped once the secret is not needed anymore, after which its
+// bytes can't be used. It's not safe for concurrent use.
+type Buffer struct {
+	// mem is the memory mapped for the secret, rounded up to a page.
+	mem []byte
+	n   int
+	// mapped is whether mem is mapped for the buffer, or allocated on the heap if it couldn't be mapped.
+	mapped bool
+	locked bool
+}
+
+// New returns a buffer holding a copy of the secret, which is wiped.
+func New(secret []byte) *Buffer {
+	b := alloc(len(secret))
+	copy(b.mem, secret)
+	clear(secret)
+	return b
+}
+
+// alloc returns a buffer of n bytes, in memory locked if possible. The memory is allocated on the heap if it can't be
+// mapped, so that the secret can still be wiped.
+func alloc(n int) *Buffer {
+	b := &Buffer{n: n}
+	if n == 0 {
+		return b
+	}
+
+	size := (n + os.Getpagesize() - 1) / os.Getpagesize() * os.Getpagesize()
+	mem, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		b.mem = make([]byte, n)
+	} else {
+		b.mem, b.mapped = mem, true
+		// Locking the memory can fail if the process reached its limit of locked memory, the secret is then only
+		// excluded from the core dumps.
+		b.locked = unix.Mlock(mem) == nil
+		_ = unix.Madvise(mem, unix.MADV_DONTDUMP)
+	}
+
+	// The secret is wiped when the buffer is garbage collected, if it wasn't already.
+	runtime.SetFinalizer(b, (*Buffer).Wipe)
+	return b
+}
+
+// Bytes returns the secret. The slice must not be used after the buffer is wiped.
+func (b *Buffer) Bytes() []byte {
+	if b == nil || b.mem == nil {
+		return nil
+	}
+	return b.mem[:b.n]
+}
+
+// Len returns the length of the secret, 0 once it's wiped.
+func (b *Buffer) Len() int {
+	if b == nil || b.mem == nil {
+		return 0
+	}
+	return b.n
+}
+
+// Locked returns true if the secret is held in memory locked in RAM.
+func (b *Buffer) Locked() bool {
+	return b != nil && b.locked
+}
+
+// Wipe overwrites the secret with zeros and releases its memory. Wiping a buffer already wiped does nothing.
+func (b *Buffer) Wipe() {
+	if b == nil || b.mem == nil {
+		return
+	}
+	runtime.SetFinalizer(b, nil)
+
+	clear(b.mem)
+	if b.mapped {
+		if b.locked {
+			_ = unix.Munlock(b.mem)
+		}
+		_ = unix.Munmap(b.mem)
+	}
+	b.mem, b.n, b.mapped, b.locked = nil, 0, false, false
+}
+
+// String returns a placeholder instead of the secret.
+func (b *Buffer) String() string {
+	return redacted
+}
+
+// GoString returns a placeholder instead of the secret.
+func (b *Buffer) GoString() string {
+	return redacted
+}
+
+// Format prints a placeholder instead of the secret, whatever the verb.
+func (b *Buffer) Format(f fmt.State, _ rune) {
+	_, _ = f.Write([]byte(redacted))
+}
+
+// LogValue logs a placeholder instead of the secret.
+func (b *Buffer) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// MarshalJSON marshals a placeholder instead of the secret.
+func (b *Buffer) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
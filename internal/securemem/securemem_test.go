@@ -0,0 +1,76 @@
+package securemem_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/securemem"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		secret []byte
+	}{
+		"Hold_the_secret":            {secret: []byte("some secret")},
+		"Hold_an_empty_secret":       {secret: []byte{}},
+		"Hold_a_secret_of_a_page":    {secret: bytes.Repeat([]byte("s"), 4096)},
+		"Hold_a_secret_above_a_page": {secret: bytes.Repeat([]byte("s"), 5000)},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			want := bytes.Clone(tc.secret)
+			b := securemem.New(tc.secret)
+			t.Cleanup(b.Wipe)
+
+			require.Equal(t, want, append([]byte{}, b.Bytes()...), "Bytes should return the secret")
+			require.Equal(t, len(want), b.Len(), "Len should return the length of the secret")
+			require.Equal(t, make([]byte, len(want)), tc.secret, "New should wipe the original secret")
+		})
+	}
+}
+
+func TestWipe(t *testing.T) {
+	t.Parallel()
+
+	b := securemem.New([]byte("some secret"))
+	b.Wipe()
+	require.Nil(t, b.Bytes(), "Bytes should return nothing once wiped")
+	require.Zero(t, b.Len(), "Len should return 0 once wiped")
+	require.False(t, b.Locked(), "Buffer should not be locked once wiped")
+
+	require.NotPanics(t, b.Wipe, "Wiping twice should do nothing")
+
+	var nilBuffer *securemem.Buffer
+	require.NotPanics(t, nilBuffer.Wipe, "Wiping a nil buffer should do nothing")
+	require.Nil(t, nilBuffer.Bytes(), "Bytes of a nil buffer should return nothing")
+}
+
+func TestSecretIsNotPrinted(t *testing.T) {
+	t.Parallel()
+
+	b := securemem.New([]byte("some secret"))
+	t.Cleanup(b.Wipe)
+
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%X", "%d"} {
+		require.NotContains(t, fmt.Sprintf(verb, b), "some secret", "%s should not print the secret", verb)
+	}
+	require.NotContains(t, fmt.Sprint(b), "some secret", "Sprint should not print the secret")
+	require.NotContains(t, fmt.Sprintf("%v", struct{ Secret *securemem.Buffer }{b}), "some secret",
+		"Secret in a struct should not be printed")
+
+	j, err := json.Marshal(map[string]any{"secret": b})
+	require.NoError(t, err, "Marshal should not return an error")
+	require.NotContains(t, string(j), "some secret", "Marshal should not marshal the secret")
+
+	var logs bytes.Buffer
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("message", "secret", b)
+	require.NotContains(t, logs.String(), "some secret", "Logs should not contain the secret")
+}
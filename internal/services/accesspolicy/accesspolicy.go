@@ -0,0 +1,254 @@
+// Package accesspolicy evaluates the conditional access policy of the daemon before the authentications are granted,
+// like the hours the users can log in at or whether the machine complies with the policy of the organization, so that
+// they can be denied or require the user to prove their identity with more factors.
+package accesspolicy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Decision is the result of the evaluation of the policy.
+type Decision string
+
+const (
+	// Allow lets the broker authenticate the user as usual.
+	Allow Decision = "allow"
+	// StepUp lets the broker authenticate the user, but requires it to ask for all the factors of the user, without
+	// skipping any on a trusted device.
+	StepUp Decision = "step-up"
+	// Deny refuses the authentication before the broker is called.
+	Deny Decision = "deny"
+)
+
+// decisions are the decisions, from the least to the most restrictive.
+var decisions = []Decision{Allow, StepUp, Deny}
+
+// DefaultHookTimeout is how long the hook can run for when the policy doesn't set it.
+const DefaultHookTimeout = 5 * time.Second
+
+// compliantFlag is the content of the compliance file on a compliant machine.
+const compliantFlag = "compliant"
+
+// Policy is the conditional access policy of the authentications. The strictest of the decisions of its conditions
+// applies. An empty policy allows all the authentications.
+type Policy struct {
+	// Hours are the windows of local time the authentications are allowed in, like "08:00-18:00". A window ending
+	// before it starts spans midnight. Empty allows them at any time.
+	Hours []string
+	// OutsideHours is the decision outside of the hours, deny by default.
+	OutsideHours Decision `mapstructure:"outside_hours"`
+	// ComplianceFile is the file in which a local agent flags whether the machine complies with the policy of the
+	// organization, with "compliant" if it does. A missing file means that the machine doesn't comply.
+	ComplianceFile string `mapstructure:"compliance_file"`
+	// NonCompliant is the decision when the machine doesn't comply, deny by default.
+	NonCompliant Decision `mapstructure:"non_compliant"`
+	// Hook is an executable run before each authentication, which prints the decision on the first line of its
+	// output, optionally followed by the reason. The authentication is denied if it fails or doesn't print a decision.
+	Hook string
+	// HookTimeout is how long the hook can run for before the authentication is denied, DefaultHookTimeout if 0.
+	HookTimeout time.Duration `mapstructure:"hook_timeout"`
+}
+
+// Validate returns an error if a decision or a window of hours is invalid, or if the hook is not an absolute path.
+func (p Policy) Validate() error {
+	for _, h := range p.Hours {
+		if _, _, err := parseWindow(h); err != nil {
+			return fmt.Errorf("hours: %w", err)
+		}
+	}
+	if p.OutsideHours != "" && !slices.Contains(decisions, p.OutsideHours) {
+		return fmt.Errorf("outside_hours: unknown decision %q, must be one of %v", p.OutsideHours, decisions)
+	}
+	if p.NonCompliant != "" && !slices.Contains(decisions, p.NonCompliant) {
+		return fmt.Errorf("non_compliant: unknown decision %q, must be one of %v", p.NonCompliant, decisions)
+	}
+	if p.Hook != "" && !strings.HasPrefix(p.Hook, "/") {
+		return fmt.Errorf("hook: %q is not an absolute path", p.Hook)
+	}
+	if p.HookTimeout < 0 {
+		return errors.New("hook_timeout: can't be negative")
+	}
+	return nil
+}
+
+// IsEmpty returns true if the policy has no condition.
+func (p Policy) IsEmpty() bool {
+	return len(p.Hours) == 0 && p.ComplianceFile == "" && p.Hook == ""
+}
+
+// Request is the authentication the policy is evaluated for.
+type Request struct {
+	User   string
+	Broker string
+	// Mode is the mode of the session, "auth" or "passwd".
+	Mode  string
+	TTY   string
+	RHost string
+}
+
+// Result is the decision of the policy, with the reasons of the conditions which restricted the authentication.
+type Result struct {
+	Decision Decision
+	Reasons  []string
+}
+
+// Evaluator evaluates the policy, which can be changed while it's in use.
+type Evaluator struct {
+	policy atomic.Pointer[Policy]
+	now    func() time.Time
+}
+
+// New returns an evaluator of the policy.
+func New(policy Policy) *Evaluator {
+	e := &Evaluator{now: time.Now}
+	e.SetPolicy(policy)
+	return e
+}
+
+// SetPolicy changes the policy of the next evaluations.
+func (e *Evaluator) SetPolicy(policy Policy) {
+	e.policy.Store(&policy)
+}
+
+// Evaluate returns the decision of the policy for the authentication. The decision is empty if the policy has no
+// condition.
+func (e *Evaluator) Evaluate(ctx context.Context, req Request) Result {
+	p := *e.policy.Load()
+	if p.IsEmpty() {
+		return Result{}
+	}
+
+	r := Result{Decision: Allow}
+	restrict := func(d Decision, reason string) {
+		if d == "" {
+			d = Deny
+		}
+		if d == Allow {
+			return
+		}
+		if slices.Index(decisions, d) > slices.Index(decisions, r.Decision) {
+			r.Decision = d
+		}
+		r.Reasons = append(r.Reasons, reason)
+	}
+
+	if len(p.Hours) > 0 && !inHours(p.Hours, e.now()) {
+		restrict(p.OutsideHours, "outside of the allowed hours")
+	}
+	if p.ComplianceFile != "" {
+		if err := checkCompliance(p.ComplianceFile); err != nil {
+			restrict(p.NonCompliant, err.Error())
+		}
+	}
+	if p.Hook != "" {
+		d, reason := runHook(ctx, p.Hook, p.HookTimeout, req)
+		restrict(d, reason)
+	}
+
+	return r
+}
+
+// parseWindow returns the start and the end of the window of hours, in minutes since midnight.
+func parseWindow(window string) (start, end int, err error) {
+	s, e, ok := strings.Cut(window, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid window %q, it must be like \"08:00-18:00\"", window)
+	}
+	if start, err = parseTime(s); err != nil {
+		return 0, 0, fmt.Errorf("invalid window %q: %w", window, err)
+	}
+	if end, err = parseTime(e); err != nil {
+		return 0, 0, fmt.Errorf("invalid window %q: %w", window, err)
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("invalid window %q: it's empty", window)
+	}
+	return start, end, nil
+}
+
+// parseTime returns the minutes since midnight of a time like "08:00".
+func parseTime(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// inHours returns true if the time is in one of the windows of hours, which are already validated.
+func inHours(hours []string, now time.Time) bool {
+	m := now.Hour()*60 + now.Minute()
+	for _, h := range hours {
+		start, end, err := parseWindow(h)
+		if err != nil {
+			continue
+		}
+		if start < end && m >= start && m < end {
+			return true
+		}
+		// The window spans midnight.
+		if start > end && (m >= start || m < end) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCompliance returns an error if the compliance file doesn't flag the machine as compliant.
+func checkCompliance(path string) error {
+	flag, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("compliance of the machine unknown: %v", err)
+	}
+	if f := strings.TrimSpace(string(flag)); f != compliantFlag {
+		return fmt.Errorf("machine not compliant: %q", f)
+	}
+	return nil
+}
+
+// runHook runs the hook for the authentication and returns its decision, deny if it fails.
+func runHook(ctx context.Context, hook string, timeout time.Duration, req Request) (Decision, string) {
+	if timeout == 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, hook)
+	cmd.Env = []string{
+		"PATH=/usr/sbin:/usr/bin:/sbin:/bin",
+		"AUTHD_USER=" + req.User,
+		"AUTHD_BROKER=" + req.Broker,
+		"AUTHD_MODE=" + req.Mode,
+		"AUTHD_TTY=" + req.TTY,
+		"AUTHD_RHOST=" + req.RHost,
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() != nil {
+		return Deny, fmt.Sprintf("access policy hook timed out after %s", timeout)
+	}
+	if err != nil {
+		return Deny, fmt.Sprintf("access policy hook failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	line, _, _ := strings.Cut(string(out), "\n")
+	d, reason, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if !slices.Contains(decisions, Decision(d)) {
+		return Deny, fmt.Sprintf("access policy hook returned an unknown decision %q", d)
+	}
+	if reason = strings.TrimSpace(reason); reason == "" {
+		reason = "decided by the access policy hook"
+	}
+	return Decision(d), reason
+}
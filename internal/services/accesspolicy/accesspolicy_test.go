@@ -0,0 +1,169 @@
+package accesspolicy_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/services/accesspolicy"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		policy accesspolicy.Policy
+
+		wantErr bool
+	}{
+		"Empty_policy_is_valid": {},
+		"Valid_policy": {policy: accesspolicy.Policy{
+			Hours: []string{"08:00-12:00", "22:00-02:00"}, OutsideHours: accesspolicy.StepUp,
+			ComplianceFile: "/run/agent/compliance", NonCompliant: accesspolicy.Deny,
+			Hook: "/usr/libexec/access-hook", HookTimeout: time.Second,
+		}},
+
+		"Error_if_a_window_has_no_end":          {policy: accesspolicy.Policy{Hours: []string{"08:00"}}, wantErr: true},
+		"Error_if_a_window_has_an_invalid_time": {policy: accesspolicy.Policy{Hours: []string{"08:00-25:00"}}, wantErr: true},
+		"Error_if_a_window_is_empty":            {policy: accesspolicy.Policy{Hours: []string{"08:00-08:00"}}, wantErr: true},
+		"Error_if_the_decision_outside_of_the_hours_is_unknown": {
+			policy: accesspolicy.Policy{OutsideHours: "maybe"}, wantErr: true,
+		},
+		"Error_if_the_decision_when_not_compliant_is_unknown": {
+			policy: accesspolicy.Policy{NonCompliant: "maybe"}, wantErr: true,
+		},
+		"Error_if_the_hook_is_not_an_absolute_path": {policy: accesspolicy.Policy{Hook: "access-hook"}, wantErr: true},
+		"Error_if_the_hook_timeout_is_negative":     {policy: accesspolicy.Policy{HookTimeout: -time.Second}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.policy.Validate()
+			if tc.wantErr {
+				require.Error(t, err, "Validate should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "Validate should not return an error, but did")
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	noon := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	midnight := time.Date(2026, 1, 1, 0, 30, 0, 0, time.Local)
+
+	tests := map[string]struct {
+		policy     accesspolicy.Policy
+		now        time.Time
+		compliance string
+		hook       string
+
+		wantDecision accesspolicy.Decision
+		wantReasons  []string
+	}{
+		"No_decision_without_condition": {},
+
+		// hours
+		"Allow_in_the_hours":                   {policy: accesspolicy.Policy{Hours: []string{"08:00-18:00"}}, wantDecision: accesspolicy.Allow},
+		"Allow_in_one_of_the_hours":            {policy: accesspolicy.Policy{Hours: []string{"06:00-08:00", "11:00-13:00"}}, wantDecision: accesspolicy.Allow},
+		"Allow_in_the_hours_spanning_midnight": {policy: accesspolicy.Policy{Hours: []string{"22:00-02:00"}}, now: midnight, wantDecision: accesspolicy.Allow},
+		"Deny_outside_of_the_hours": {
+			policy: accesspolicy.Policy{Hours: []string{"13:00-18:00"}}, wantDecision: accesspolicy.Deny,
+			wantReasons: []string{"outside of the allowed hours"},
+		},
+		"Deny_outside_of_the_hours_spanning_midnight": {
+			policy: accesspolicy.Policy{Hours: []string{"22:00-02:00"}}, wantDecision: accesspolicy.Deny,
+			wantReasons: []string{"outside of the allowed hours"},
+		},
+		"Step_up_outside_of_the_hours_if_configured": {
+			policy:       accesspolicy.Policy{Hours: []string{"13:00-18:00"}, OutsideHours: accesspolicy.StepUp},
+			wantDecision: accesspolicy.StepUp, wantReasons: []string{"outside of the allowed hours"},
+		},
+
+		// compliance
+		"Allow_on_a_compliant_machine": {compliance: "compliant\n", wantDecision: accesspolicy.Allow},
+		"Deny_on_a_machine_not_compliant": {
+			compliance: "outdated", wantDecision: accesspolicy.Deny, wantReasons: []string{`machine not compliant: "outdated"`},
+		},
+		"Deny_if_the_compliance_is_unknown": {compliance: "-", wantDecision: accesspolicy.Deny},
+		"Step_up_on_a_machine_not_compliant_if_configured": {
+			policy: accesspolicy.Policy{NonCompliant: accesspolicy.StepUp}, compliance: "outdated", wantDecision: accesspolicy.StepUp,
+			wantReasons: []string{`machine not compliant: "outdated"`},
+		},
+
+		// hook
+		"Allow_if_the_hook_allows":     {hook: "allow", wantDecision: accesspolicy.Allow},
+		"Step_up_if_the_hook_steps_up": {hook: "step-up", wantDecision: accesspolicy.StepUp, wantReasons: []string{"unusual login time"}},
+		"Deny_if_the_hook_denies":      {hook: "deny", wantDecision: accesspolicy.Deny, wantReasons: []string{"not on the office network"}},
+		"Hook_gets_the_request_in_its_environment": {
+			hook: "environment", wantDecision: accesspolicy.StepUp, wantReasons: []string{"user1 broker1 auth pts/0 example.com"},
+		},
+		"Deny_if_the_hook_fails":                       {hook: "fail", wantDecision: accesspolicy.Deny},
+		"Deny_if_the_hook_returns_an_unknown_decision": {hook: "unknown", wantDecision: accesspolicy.Deny},
+		"Deny_if_the_hook_returns_no_decision":         {hook: "silent", wantDecision: accesspolicy.Deny},
+		"Deny_if_the_hook_times_out": {
+			policy: accesspolicy.Policy{HookTimeout: 100 * time.Millisecond}, hook: "slow", wantDecision: accesspolicy.Deny,
+			wantReasons: []string{"access policy hook timed out after 100ms"},
+		},
+
+		// several conditions
+		"Strictest_decision_applies": {
+			policy:     accesspolicy.Policy{Hours: []string{"13:00-18:00"}, OutsideHours: accesspolicy.StepUp},
+			compliance: "outdated", hook: "allow", wantDecision: accesspolicy.Deny,
+			wantReasons: []string{"outside of the allowed hours", `machine not compliant: "outdated"`},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.compliance != "" {
+				tc.policy.ComplianceFile = filepath.Join(t.TempDir(), "compliance")
+				if tc.compliance != "-" {
+					err := os.WriteFile(tc.policy.ComplianceFile, []byte(tc.compliance), 0600)
+					require.NoError(t, err, "Setup: could not write the compliance file")
+				}
+			}
+			if tc.hook != "" {
+				hook, err := filepath.Abs(filepath.Join("testdata", "hooks", tc.hook))
+				require.NoError(t, err, "Setup: could not get the path of the hook")
+				tc.policy.Hook = hook
+			}
+			if tc.now.IsZero() {
+				tc.now = noon
+			}
+
+			e := accesspolicy.New(tc.policy)
+			e.SetNow(func() time.Time { return tc.now })
+
+			got := e.Evaluate(context.Background(), accesspolicy.Request{
+				User: "user1", Broker: "broker1", Mode: "auth", TTY: "pts/0", RHost: "example.com",
+			})
+			require.Equal(t, tc.wantDecision, got.Decision, "Evaluate should return the expected decision")
+			if tc.wantReasons != nil {
+				require.Equal(t, tc.wantReasons, got.Reasons, "Evaluate should return the expected reasons")
+			}
+			if tc.wantDecision != accesspolicy.Allow {
+				return
+			}
+			require.Empty(t, got.Reasons, "Evaluate should not return any reason when allowing")
+		})
+	}
+}
+
+func TestSetPolicy(t *testing.T) {
+	t.Parallel()
+
+	e := accesspolicy.New(accesspolicy.Policy{})
+	require.Empty(t, e.Evaluate(context.Background(), accesspolicy.Request{}).Decision, "Empty policy should not decide")
+
+	e.SetPolicy(accesspolicy.Policy{ComplianceFile: filepath.Join(t.TempDir(), "missing")})
+	require.Equal(t, accesspolicy.Deny, e.Evaluate(context.Background(), accesspolicy.Request{}).Decision,
+		"Evaluate should apply the new policy")
+}
@@ -0,0 +1,8 @@
+package accesspolicy
+
+import "time"
+
+// SetNow sets the function returning the current time of the evaluator.
+func (e *Evaluator) SetNow(now func() time.Time) {
+	e.now = now
+}
@@ -0,0 +1,87 @@
+// Package apiversion negotiates the version of the authd API between the daemon and its clients, so that a client
+// and a daemon of different versions, as during upgrades, fail with a clear error instead of a protocol one.
+package apiversion
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Current is the version of the API implemented by this daemon and used by the clients built with it. It must be
+	// increased on any change of the API which older daemons or clients can't handle.
+	Current uint32 = 1
+	// Minimum is the oldest version of the API of the clients still served by the daemon.
+	Minimum uint32 = 1
+
+	// MetadataKey is the gRPC metadata key in which the clients send the version of the API they use. The clients
+	// which don't send it are considered to use the first version.
+	MetadataKey = "authd-api-version"
+)
+
+// infoMethodsPrefix is the prefix of the methods of the Info service, which are served to the clients of any version.
+const infoMethodsPrefix = "/authd.Info/"
+
+// ClientInterceptor sends the version of the API used by the client with each request. It explains the requests of
+// methods unknown to the daemon, which is then older than the client.
+func ClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, MetadataKey, strconv.FormatUint(uint64(Current), 10))
+
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if status.Code(err) == codes.Unimplemented {
+		return status.Errorf(codes.Unimplemented,
+			"%s is not supported by the authd daemon, which is likely older than this client: restart authd after upgrading it",
+			strings.TrimPrefix(method, "/"))
+	}
+	return err
+}
+
+// ServerInterceptor rejects the requests of the clients using a version of the API which the daemon doesn't support.
+func ServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !strings.HasPrefix(info.FullMethod, "/authd.") || strings.HasPrefix(info.FullMethod, infoMethodsPrefix) {
+		return handler(ctx, req)
+	}
+
+	version, err := FromContext(ctx)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := check(version); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	return handler(ctx, req)
+}
+
+// FromContext returns the version of the API used by the client which sent the request.
+func FromContext(ctx context.Context) (uint32, error) {
+	values := metadata.ValueFromIncomingContext(ctx, MetadataKey)
+	if len(values) == 0 {
+		return 1, nil
+	}
+
+	version, err := strconv.ParseUint(values[0], 10, 32)
+	if err != nil || version == 0 {
+		return 0, fmt.Errorf("invalid authd API version %q", values[0])
+	}
+	return uint32(version), nil
+}
+
+// check returns an error explaining which component to upgrade if the version of the API of the client isn't
+// supported.
+func check(version uint32) error {
+	if version < Minimum {
+		return fmt.Errorf("the client uses version %d of the authd API, but the daemon requires at least version %d: upgrade the client", version, Minimum)
+	}
+	if version > Current {
+		return fmt.Errorf("the client uses version %d of the authd API, but the daemon only supports up to version %d: restart authd after upgrading it", version, Current)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package apiversion_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/services/apiversion"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		values []string
+
+		want    uint32
+		wantErr bool
+	}{
+		"Returns_the_version_sent_by_the_client":       {values: []string{"2"}, want: 2},
+		"Returns_the_first_version_without_any_sent":   {want: 1},
+		"Returns_the_first_of_several_versions_sent":   {values: []string{"3", "2"}, want: 3},
+		"Error_when_version_is_not_a_number":           {values: []string{"one"}, wantErr: true},
+		"Error_when_version_is_zero":                   {values: []string{"0"}, wantErr: true},
+		"Error_when_version_is_negative":               {values: []string{"-1"}, wantErr: true},
+		"Error_when_version_is_too_large_for_a_uint32": {values: []string{"4294967296"}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			md := metadata.MD{}
+			for _, v := range tc.values {
+				md.Append(apiversion.MetadataKey, v)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+
+			got, err := apiversion.FromContext(ctx)
+			if tc.wantErr {
+				require.Error(t, err, "FromContext should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "FromContext should not return an error, but did")
+			require.Equal(t, tc.want, got, "FromContext should return the expected version")
+		})
+	}
+}
@@ -9,3 +9,8 @@ type ToDisplayError struct {
 func NewToDisplayError(err error) error {
 	return ToDisplayError{err}
 }
+
+// Unwrap returns the error to display.
+func (e ToDisplayError) Unwrap() error {
+	return e.error
+}
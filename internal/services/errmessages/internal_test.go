@@ -19,6 +19,7 @@ func TestRedactErrorInterceptor(t *testing.T) {
 		inputError error
 
 		wantMessage string
+		wantReason  Reason
 	}{
 		"Trim_input_down_to_ErrToDisplay": {
 			inputError:  fmt.Errorf("Error to be redacted: %w", ToDisplayError{errors.New("Error to be shown")}),
@@ -28,6 +29,11 @@ func TestRedactErrorInterceptor(t *testing.T) {
 			inputError:  errors.New("Not a redacted error"),
 			wantMessage: "Not a redacted error",
 		},
+		"Keep_the_status_of_a_redacted_error_with_a_well-defined_cause": {
+			inputError:  fmt.Errorf("Error to be redacted: %w", ToDisplayError{ErrUserLocked.Wrap(errors.New("Error to be shown"))}),
+			wantMessage: "rpc error: code = PermissionDenied desc = Error to be shown",
+			wantReason:  ReasonUserLocked,
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -36,6 +42,14 @@ func TestRedactErrorInterceptor(t *testing.T) {
 			_, err := RedactErrorInterceptor(context.TODO(), testRequest{tc.inputError}, nil, testHandler)
 			require.Error(t, err, "RedactErrorInterceptor should return an error")
 			require.Equal(t, tc.wantMessage, err.Error(), "RedactErrorInterceptor returned unexpected error message")
+
+			e, ok := FromError(err)
+			if tc.wantReason == "" {
+				require.False(t, ok, "RedactErrorInterceptor should not return an error with a well-defined cause")
+				return
+			}
+			require.True(t, ok, "RedactErrorInterceptor should return an error with a well-defined cause")
+			require.Equal(t, tc.wantReason, e.Reason, "RedactErrorInterceptor should keep the reason of the error")
 		})
 	}
 }
@@ -47,6 +61,7 @@ func TestFormatErrorMessage(t *testing.T) {
 		inputError error
 
 		wantMessage string
+		wantReason  Reason
 	}{
 		"Non-gRPC_error_is_left_untouched": {
 			inputError:  errors.New("Non-gRPC error"),
@@ -73,6 +88,11 @@ func TestFormatErrorMessage(t *testing.T) {
 			inputError:  status.Error(codes.Unknown, "Unknown error"),
 			wantMessage: "Unknown error",
 		},
+		"Keep_the_message_and_the_status_of_an_error_with_a_well-defined_cause": {
+			inputError:  ErrBrokerUnavailable.Wrap(errors.New("Broker is not running")).GRPCStatus().Err(),
+			wantMessage: "Broker is not running",
+			wantReason:  ReasonBrokerUnavailable,
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -81,6 +101,14 @@ func TestFormatErrorMessage(t *testing.T) {
 			err := FormatErrorMessage(context.TODO(), "", testRequest{tc.inputError}, nil, nil, testInvoker)
 			require.Error(t, err, "FormatErrorMessage should return an error")
 			require.Equal(t, tc.wantMessage, err.Error(), "FormatErrorMessage returned unexpected error message")
+
+			e, ok := FromError(err)
+			if tc.wantReason == "" {
+				require.False(t, ok, "FormatErrorMessage should not return an error with a well-defined cause")
+				return
+			}
+			require.True(t, ok, "FormatErrorMessage should return an error with a well-defined cause")
+			require.Equal(t, tc.wantReason, e.Reason, "FormatErrorMessage should keep the reason of the error")
 		})
 	}
 }
@@ -0,0 +1,169 @@
+package errmessages
+
+import (
+	"errors"
+	"maps"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Domain is the domain of the reasons of the errors of the daemon, in the details of their gRPC status.
+const Domain = "authd"
+
+// Reason is the well-defined cause of an error of the daemon, sent to the clients in the details of its gRPC status.
+type Reason string
+
+const (
+	// ReasonBrokerUnavailable is the reason of the errors of the brokers which can't be reached.
+	ReasonBrokerUnavailable Reason = "BROKER_UNAVAILABLE"
+	// ReasonUserUnknown is the reason of the errors of the users which don't exist.
+	ReasonUserUnknown Reason = "USER_UNKNOWN"
+	// ReasonRetryable is the reason of the errors which go away if the client retries later, like when the daemon is
+	// overloaded.
+	ReasonRetryable Reason = "RETRYABLE"
+	// ReasonUserLocked is the reason of the errors of the users who are locked out.
+	ReasonUserLocked Reason = "USER_LOCKED"
+	// ReasonSessionTimedOut is the reason of the errors of the authentication sessions which were ended because they
+	// were waiting for the user or the broker for too long.
+	ReasonSessionTimedOut Reason = "SESSION_TIMED_OUT"
+)
+
+const (
+	// MetadataUsername is the key of the metadata holding the name of the user an error is about.
+	MetadataUsername = "username"
+	// MetadataBroker is the key of the metadata holding the name of the broker an error is about.
+	MetadataBroker = "broker"
+	// MetadataLockedUntil is the key of the metadata holding the time, in RFC 3339 format, at which a locked out
+	// user can log in again. It's not set if the user is locked until an administrator unlocks them.
+	MetadataLockedUntil = "locked_until"
+)
+
+var (
+	// ErrBrokerUnavailable is returned when a broker can't be reached, like when it's not running.
+	ErrBrokerUnavailable = &Error{Code: codes.Unavailable, Reason: ReasonBrokerUnavailable}
+	// ErrUserUnknown is returned when a user doesn't exist.
+	ErrUserUnknown = &Error{Code: codes.NotFound, Reason: ReasonUserUnknown}
+	// ErrRetryable is returned when a request can't be handled now, but can be retried later.
+	ErrRetryable = &Error{Code: codes.Unavailable, Reason: ReasonRetryable}
+	// ErrUserLocked is returned when a user is locked out.
+	ErrUserLocked = &Error{Code: codes.PermissionDenied, Reason: ReasonUserLocked}
+	// ErrSessionTimedOut is returned when an authentication session was ended because it was pending for too long.
+	ErrSessionTimedOut = &Error{Code: codes.DeadlineExceeded, Reason: ReasonSessionTimedOut}
+)
+
+// Error is an error of the daemon with a well-defined cause. It's sent to the clients as a gRPC status with its code,
+// and with its reason, metadata and retry delay in the details, so that they can handle it without parsing its message.
+//
+// The errors are created from the sentinel errors, like ErrUserUnknown, which they match with errors.Is.
+type Error struct {
+	Code   codes.Code
+	Reason Reason
+	// Metadata describes the error, like the user or the broker it's about.
+	Metadata map[string]string
+	// RetryAfter, if set, is how long the client should wait before retrying the request.
+	RetryAfter time.Duration
+
+	err error
+}
+
+// Wrap returns a copy of the error with err as its cause.
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Metadata = maps.Clone(e.Metadata)
+	c.err = err
+	return &c
+}
+
+// WithMetadata returns a copy of the error with the metadata key set to value.
+func (e *Error) WithMetadata(key, value string) *Error {
+	c := *e
+	c.Metadata = maps.Clone(e.Metadata)
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string)
+	}
+	c.Metadata[key] = value
+	return &c
+}
+
+// WithRetryAfter returns a copy of the error telling the client to wait for d before retrying.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	c := *e
+	c.RetryAfter = d
+	return &c
+}
+
+// Error returns the message of the cause of the error, or its reason if it has none.
+func (e *Error) Error() string {
+	if e.err == nil {
+		return string(e.Reason)
+	}
+	return e.err.Error()
+}
+
+// Unwrap returns the cause of the error.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Is returns true if target is an error with the same reason, like one of the sentinel errors.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Reason == e.Reason
+}
+
+// GRPCStatus returns the gRPC status sent to the clients for the error.
+func (e *Error) GRPCStatus() *status.Status {
+	return e.status(e.Error())
+}
+
+// status returns the gRPC status of the error, with the given message.
+func (e *Error) status(msg string) *status.Status {
+	st := status.New(e.Code, msg)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: string(e.Reason), Domain: Domain, Metadata: e.Metadata}}
+	if e.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryAfter)})
+	}
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// FromError returns the error of the daemon with a well-defined cause which err, returned by a gRPC call, carries in
+// its status. It returns false if the cause of err is not well-defined.
+func FromError(err error) (*Error, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st == nil {
+		return nil, false
+	}
+
+	var info *errdetails.ErrorInfo
+	var retryAfter time.Duration
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			if d.GetDomain() == Domain {
+				info = d
+			}
+		case *errdetails.RetryInfo:
+			retryAfter = d.GetRetryDelay().AsDuration()
+		}
+	}
+	if info == nil {
+		return nil, false
+	}
+
+	return &Error{
+		Code:       st.Code(),
+		Reason:     Reason(info.GetReason()),
+		Metadata:   info.GetMetadata(),
+		RetryAfter: retryAfter,
+		err:        errors.New(st.Message()),
+	}, true
+}
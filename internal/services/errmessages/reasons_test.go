@@ -0,0 +1,88 @@
+package errmessages_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/services/errmessages"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFromError(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		err error
+
+		wantCode       codes.Code
+		wantReason     errmessages.Reason
+		wantMetadata   map[string]string
+		wantRetryAfter time.Duration
+		wantMessage    string
+		wantNoCause    bool
+	}{
+		"Error_with_a_well-defined_cause": {
+			err:         errmessages.ErrUserUnknown.Wrap(errors.New("user not found")),
+			wantCode:    codes.NotFound,
+			wantReason:  errmessages.ReasonUserUnknown,
+			wantMessage: "user not found",
+		},
+		"Error_with_metadata": {
+			err:          errmessages.ErrUserLocked.WithMetadata(errmessages.MetadataUsername, "user1"),
+			wantCode:     codes.PermissionDenied,
+			wantReason:   errmessages.ReasonUserLocked,
+			wantMetadata: map[string]string{errmessages.MetadataUsername: "user1"},
+			wantMessage:  string(errmessages.ReasonUserLocked),
+		},
+		"Error_with_a_retry_delay": {
+			err:            errmessages.ErrRetryable.Wrap(errors.New("busy")).WithRetryAfter(time.Second),
+			wantCode:       codes.Unavailable,
+			wantReason:     errmessages.ReasonRetryable,
+			wantRetryAfter: time.Second,
+			wantMessage:    "busy",
+		},
+		"Wrapped_error_with_a_well-defined_cause": {
+			err:         fmt.Errorf("could not do it: %w", errmessages.ErrBrokerUnavailable.Wrap(errors.New("broker is not running"))),
+			wantCode:    codes.Unavailable,
+			wantReason:  errmessages.ReasonBrokerUnavailable,
+			wantMessage: "could not do it: broker is not running",
+		},
+
+		"No_cause_for_a_gRPC_error_without_details": {err: status.Error(codes.NotFound, "not found"), wantNoCause: true},
+		"No_cause_for_a_non-gRPC_error":             {err: errors.New("some error"), wantNoCause: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// Send the error as the gRPC server does.
+			st, _ := status.FromError(tc.err)
+
+			got, ok := errmessages.FromError(st.Err())
+			if tc.wantNoCause {
+				require.False(t, ok, "FromError should not return an error with a well-defined cause")
+				return
+			}
+			require.True(t, ok, "FromError should return an error with a well-defined cause")
+			require.Equal(t, tc.wantCode, got.Code, "FromError should return the code of the error")
+			require.Equal(t, tc.wantReason, got.Reason, "FromError should return the reason of the error")
+			require.Equal(t, tc.wantMetadata, got.Metadata, "FromError should return the metadata of the error")
+			require.Equal(t, tc.wantRetryAfter, got.RetryAfter, "FromError should return the retry delay of the error")
+			require.Equal(t, tc.wantMessage, got.Error(), "FromError should return the message of the error")
+		})
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("wrapped: %w", errmessages.ErrUserUnknown.Wrap(errors.New("user not found")).WithMetadata("key", "value"))
+	require.ErrorIs(t, err, errmessages.ErrUserUnknown, "The error should match the sentinel error of its reason")
+	require.NotErrorIs(t, err, errmessages.ErrUserLocked, "The error should not match the sentinel errors of other reasons")
+
+	require.Nil(t, errmessages.ErrUserUnknown.Metadata, "Adding metadata should not change the sentinel error")
+}
@@ -15,6 +15,7 @@ import (
 // RedactErrorInterceptor redacts some of the attached errors before sending it to the client.
 //
 // It unwraps the error up to the first ErrToDisplay and sends it to the client. If none is found, it sends the original error.
+// The status of the errors with a well-defined cause is kept, with the redacted message.
 func RedactErrorInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 	m, err := handler(ctx, req)
 	if err != nil {
@@ -23,6 +24,10 @@ func RedactErrorInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInf
 		if !errors.As(err, &redactedError) {
 			return m, err
 		}
+		var e *Error
+		if errors.As(err, &e) {
+			return m, e.status(redactedError.Error()).Err()
+		}
 		return m, redactedError
 	}
 	return m, nil
@@ -30,7 +35,8 @@ func RedactErrorInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInf
 
 // FormatErrorMessage formats the error message received by the client to avoid printing useless information.
 //
-// It converts the gRPC error to a more human-readable error with a better message.
+// It converts the gRPC error to a more human-readable error with a better message. The status of the error is kept, so
+// that the cause of the errors of the daemon can still be found with FromError.
 func FormatErrorMessage(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 	err := invoker(ctx, method, req, reply, cc, opts...)
 	if err == nil {
@@ -41,6 +47,11 @@ func FormatErrorMessage(ctx context.Context, method string, req, reply any, cc *
 		return err
 	}
 
+	if _, ok := FromError(err); ok {
+		// The error of the daemon is well-defined, its message is already meant for the client.
+		return formattedError{msg: st.Message(), st: st}
+	}
+
 	switch st.Code() {
 	// no daemon
 	case codes.Unavailable:
@@ -60,3 +71,19 @@ func FormatErrorMessage(ctx context.Context, method string, req, reply any, cc *
 	}
 	return err
 }
+
+// formattedError is an error whose message is formatted for the client, which keeps the gRPC status it was created from.
+type formattedError struct {
+	msg string
+	st  *status.Status
+}
+
+// Error returns the formatted message.
+func (e formattedError) Error() string {
+	return e.msg
+}
+
+// GRPCStatus returns the gRPC status the error was created from.
+func (e formattedError) GRPCStatus() *status.Status {
+	return e.st
+}
@@ -0,0 +1,6 @@
+package services
+
+// RequestLogFields returns the log fields of the request of the given method.
+func (m Manager) RequestLogFields(req interface{}, method string) []any {
+	return requestLogFields(m, req, method)
+}
@@ -0,0 +1,63 @@
+// Package info implements the info grpc service, which lets the clients find out what the daemon supports.
+package info
+
+import (
+	"context"
+
+	"github.com/ubuntu/authd/internal/consts"
+	"github.com/ubuntu/authd/internal/features"
+	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/services/apiversion"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/log"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ authd.InfoServer = Service{}
+
+// Service is the implementation of the info service.
+type Service struct {
+	services []string
+	idRanges *authd.IDRanges
+
+	authd.UnimplementedInfoServer
+}
+
+// NewService returns a new info GRPC service, reporting the gRPC services served next to it and the ID ranges of the
+// users configuration, which can't change without restarting the daemon.
+func NewService(ctx context.Context, services []string, usersConfig users.Config) Service {
+	log.Debug(ctx, "Building new gRPC info service")
+
+	idRanges := &authd.IDRanges{
+		UidMin:             usersConfig.UIDMin,
+		UidMax:             usersConfig.UIDMax,
+		GidMin:             usersConfig.GIDMin,
+		GidMax:             usersConfig.GIDMax,
+		RemoteGroupsGidMin: usersConfig.RemoteGroupsGIDMin,
+		RemoteGroupsGidMax: usersConfig.RemoteGroupsGIDMax,
+	}
+	// The groups of the brokers use the range of the user private groups if they don't have their own.
+	if usersConfig.RemoteGroupsGIDMax == 0 {
+		idRanges.RemoteGroupsGidMin, idRanges.RemoteGroupsGidMax = usersConfig.GIDMin, usersConfig.GIDMax
+	}
+
+	return Service{services: services, idRanges: idRanges}
+}
+
+// GetCapabilities returns the version of the daemon and the ones of the API it supports, the services available, the
+// experimental features enabled and the ID ranges.
+func (s Service) GetCapabilities(ctx context.Context, _ *authd.Empty) (*authd.Capabilities, error) {
+	var enabled []string
+	for _, f := range features.List() {
+		enabled = append(enabled, string(f))
+	}
+
+	return &authd.Capabilities{
+		ApiVersion:    apiversion.Current,
+		MinApiVersion: apiversion.Minimum,
+		Services:      s.services,
+		Features:      enabled,
+		Version:       consts.Version,
+		IdRanges:      proto.Clone(s.idRanges).(*authd.IDRanges),
+	}, nil
+}
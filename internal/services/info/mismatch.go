@@ -0,0 +1,28 @@
+package info
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+// Mismatch returns a description of the daemon from its capabilities if its version or the one of its API differ from
+// the ones of the client, or an empty string if they match. The clients log it to help triaging the bugs involving
+// components of different versions, as during upgrades.
+func Mismatch(caps *authd.Capabilities, clientVersion string, clientAPIVersion uint32) string {
+	if caps.GetVersion() == clientVersion && caps.GetApiVersion() == clientAPIVersion {
+		return ""
+	}
+
+	features := "none"
+	if len(caps.GetFeatures()) > 0 {
+		features = strings.Join(caps.GetFeatures(), ", ")
+	}
+	r := caps.GetIdRanges()
+	return fmt.Sprintf("authd %s, serving versions %d to %d of the API, differs from this client %s, using version %d: "+
+		"services %s, features %s, UIDs %d-%d, GIDs %d-%d, GIDs of the groups of the brokers %d-%d",
+		caps.GetVersion(), caps.GetMinApiVersion(), caps.GetApiVersion(), clientVersion, clientAPIVersion,
+		strings.Join(caps.GetServices(), ", "), features,
+		r.GetUidMin(), r.GetUidMax(), r.GetGidMin(), r.GetGidMax(), r.GetRemoteGroupsGidMin(), r.GetRemoteGroupsGidMax())
+}
@@ -0,0 +1,67 @@
+package info_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/services/info"
+)
+
+func TestMismatch(t *testing.T) {
+	t.Parallel()
+
+	caps := &authd.Capabilities{
+		ApiVersion:    2,
+		MinApiVersion: 1,
+		Services:      []string{"authd.NSS", "authd.PAM"},
+		Features:      []string{"offline_auth"},
+		Version:       "1.0",
+		IdRanges: &authd.IDRanges{
+			UidMin: 1000, UidMax: 1999, GidMin: 2000, GidMax: 2999, RemoteGroupsGidMin: 3000, RemoteGroupsGidMax: 3999,
+		},
+	}
+
+	tests := map[string]struct {
+		clientVersion    string
+		clientAPIVersion uint32
+		noFeatures       bool
+
+		wantMismatch string
+	}{
+		"No_mismatch_when_the_versions_are_the_same": {clientVersion: "1.0", clientAPIVersion: 2},
+
+		"Mismatch_when_the_versions_differ": {
+			clientVersion: "0.9", clientAPIVersion: 1,
+			wantMismatch: "authd 1.0, serving versions 1 to 2 of the API, differs from this client 0.9, using version 1: " +
+				"services authd.NSS, authd.PAM, features offline_auth, UIDs 1000-1999, GIDs 2000-2999, " +
+				"GIDs of the groups of the brokers 3000-3999",
+		},
+		"Mismatch_when_only_the_API_versions_differ": {
+			clientVersion: "1.0", clientAPIVersion: 1, noFeatures: true,
+			wantMismatch: "authd 1.0, serving versions 1 to 2 of the API, differs from this client 1.0, using version 1: " +
+				"services authd.NSS, authd.PAM, features none, UIDs 1000-1999, GIDs 2000-2999, " +
+				"GIDs of the groups of the brokers 3000-3999",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &authd.Capabilities{
+				ApiVersion:    caps.ApiVersion,
+				MinApiVersion: caps.MinApiVersion,
+				Services:      caps.Services,
+				Features:      caps.Features,
+				Version:       caps.Version,
+				IdRanges:      caps.IdRanges,
+			}
+			if tc.noFeatures {
+				c.Features = nil
+			}
+
+			got := info.Mismatch(c, tc.clientVersion, tc.clientAPIVersion)
+			require.Equal(t, tc.wantMismatch, got, "Mismatch should describe the daemon only if its versions differ")
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/services/errmessages"
+	"github.com/ubuntu/authd/internal/services/permissions"
+	"github.com/ubuntu/authd/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// enumerationMethods are the methods returning all the users, groups or shadow entries, which hold the most memory.
+var enumerationMethods = map[string]bool{
+	authd.NSS_GetPasswdEntries_FullMethodName:   true,
+	authd.NSS_GetGroupEntries_FullMethodName:    true,
+	authd.NSS_GetShadowEntries_FullMethodName:   true,
+	authd.UserService_ListUsers_FullMethodName:  true,
+	authd.UserService_ListGroups_FullMethodName: true,
+}
+
+// loadShed rejects the requests of the authd services which can't wait for the requests in progress to finish, when
+// the daemon handles too many of them at once. The health checks are never rejected.
+func (m Manager) loadShed(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !strings.HasPrefix(info.FullMethod, "/authd.") {
+		return handler(ctx, req)
+	}
+
+	release, firstOverloaded, err := m.loadLimiter.Acquire(ctx, enumerationMethods[info.FullMethod])
+	if err != nil {
+		if firstOverloaded {
+			log.Warningf(ctx, "Rejecting requests, too many of them are in progress")
+		}
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		overloadedErr := errmessages.ErrRetryable.Wrap(fmt.Errorf("authd is handling too many requests, rejecting the one from %s", permissions.Caller(ctx)))
+		overloadedErr.Code = codes.ResourceExhausted
+		return nil, overloadedErr
+	}
+	defer release()
+
+	return handler(ctx, req)
+}
@@ -0,0 +1,169 @@
+// Package loadshed bounds the requests the daemon handles at once, and rejects the ones which can't wait for the others
+// to finish, so that authd doesn't run out of memory on a small machine when something floods it, like a process
+// enumerating all the users in a loop.
+package loadshed
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOverloaded is returned when a request can't wait for the requests in progress to finish.
+var ErrOverloaded = errors.New("authd is overloaded")
+
+// Limits are the requests handled at once, and the requests waiting for them to finish.
+type Limits struct {
+	// MaxRequests is the number of requests handled at once, 0 for no limit.
+	MaxRequests int `mapstructure:"max_requests"`
+	// MaxEnumerations is the number of enumerations of all the users, groups or shadow entries handled at once, which
+	// hold the most memory, 0 for no limit. They also count as requests.
+	MaxEnumerations int `mapstructure:"max_enumerations"`
+	// MaxQueued is the number of requests waiting for the ones in progress to finish, above which the requests are
+	// rejected at once.
+	MaxQueued int `mapstructure:"max_queued"`
+	// QueueTimeout is how long a request can wait for the ones in progress to finish, 0 for no limit.
+	QueueTimeout time.Duration `mapstructure:"queue_timeout"`
+}
+
+// DefaultLimits allow 512 requests at once, including 16 enumerations, and 1024 more requests waiting up to 5
+// seconds, which is far above what the NSS lookups and authentications of a busy machine need.
+var DefaultLimits = Limits{
+	MaxRequests:     512,
+	MaxEnumerations: 16,
+	MaxQueued:       1024,
+	QueueTimeout:    5 * time.Second,
+}
+
+// Validate returns an error if a limit is negative.
+func (l Limits) Validate() error {
+	if l.MaxRequests < 0 || l.MaxEnumerations < 0 || l.MaxQueued < 0 || l.QueueTimeout < 0 {
+		return errors.New("max_requests, max_enumerations, max_queued and queue_timeout can't be negative")
+	}
+	return nil
+}
+
+// Limiter bounds the requests handled at once, queueing the other ones.
+type Limiter struct {
+	limits Limits
+
+	requests     int
+	enumerations int
+	queued       int
+	// overloaded is whether the last request was rejected.
+	overloaded bool
+	// released is closed when a request finishes, to wake up the waiting ones.
+	released chan struct{}
+	mu       sync.Mutex
+}
+
+// New returns a Limiter applying the limits to the requests.
+func New(limits Limits) *Limiter {
+	return &Limiter{
+		limits:   limits,
+		released: make(chan struct{}),
+	}
+}
+
+// SetLimits changes the limits of the next requests. The requests in progress keep counting against the new limits.
+func (l *Limiter) SetLimits(limits Limits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits = limits
+	l.wakeUp()
+}
+
+// Acquire waits for a request to be handled, and returns the function to call once it's done. It returns
+// ErrOverloaded if too many requests are already waiting, or if the request waited for longer than the queue timeout.
+// firstOverloaded is true when the request is the first one rejected since the last one handled, so that only the
+// beginning of the rejections can be reported.
+func (l *Limiter) Acquire(ctx context.Context, enumeration bool) (release func(), firstOverloaded bool, err error) {
+	release = func() { l.release(enumeration) }
+
+	l.mu.Lock()
+	if l.tryAcquire(enumeration) {
+		l.mu.Unlock()
+		return release, false, nil
+	}
+	if l.queued >= l.limits.MaxQueued {
+		defer l.mu.Unlock()
+		return nil, l.reject(), ErrOverloaded
+	}
+	l.queued++
+	queueTimeout := l.limits.QueueTimeout
+	l.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if queueTimeout > 0 {
+		timer := time.NewTimer(queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	defer func() { l.queued-- }()
+	for {
+		if l.tryAcquire(enumeration) {
+			return release, false, nil
+		}
+
+		released := l.released
+		l.mu.Unlock()
+		select {
+		case <-released:
+			l.mu.Lock()
+		case <-timeout:
+			l.mu.Lock()
+			return nil, l.reject(), ErrOverloaded
+		case <-ctx.Done():
+			l.mu.Lock()
+			return nil, false, ctx.Err()
+		}
+	}
+}
+
+// tryAcquire counts the request as in progress if the limits allow it. It must be called with the lock held.
+func (l *Limiter) tryAcquire(enumeration bool) bool {
+	if l.limits.MaxRequests > 0 && l.requests >= l.limits.MaxRequests {
+		return false
+	}
+	if enumeration && l.limits.MaxEnumerations > 0 && l.enumerations >= l.limits.MaxEnumerations {
+		return false
+	}
+
+	l.requests++
+	if enumeration {
+		l.enumerations++
+	}
+	l.overloaded = false
+	return true
+}
+
+// reject records a rejected request, and returns whether it's the first one since the last request handled. It must
+// be called with the lock held.
+func (l *Limiter) reject() (firstOverloaded bool) {
+	firstOverloaded = !l.overloaded
+	l.overloaded = true
+	return firstOverloaded
+}
+
+// release records the end of a request, and wakes up the waiting ones.
+func (l *Limiter) release(enumeration bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.requests--
+	if enumeration {
+		l.enumerations--
+	}
+	l.wakeUp()
+}
+
+// wakeUp lets the waiting requests check the limits again. It must be called with the lock held.
+func (l *Limiter) wakeUp() {
+	close(l.released)
+	l.released = make(chan struct{})
+}
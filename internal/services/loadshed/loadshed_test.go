@@ -0,0 +1,164 @@
+package loadshed_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/services/loadshed"
+)
+
+func TestAcquire(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		limits loadshed.Limits
+		// inProgress are the requests in progress, true for the enumerations.
+		inProgress  []bool
+		enumeration bool
+		// releaseAfter releases the requests in progress after this long, if set.
+		releaseAfter time.Duration
+		cancel       bool
+
+		wantErr             error
+		wantFirstOverloaded bool
+	}{
+		"Handles_all_requests_without_limit":                    {inProgress: []bool{false, true, true}, enumeration: true},
+		"Handles_requests_below_the_limit":                      {limits: loadshed.Limits{MaxRequests: 2}, inProgress: []bool{false}},
+		"Handles_requests_once_the_ones_in_progress_finish":     {limits: loadshed.Limits{MaxRequests: 1, MaxQueued: 1}, inProgress: []bool{false}, releaseAfter: 100 * time.Millisecond},
+		"Handles_requests_while_enumerations_are_limited":       {limits: loadshed.Limits{MaxEnumerations: 1}, inProgress: []bool{true}},
+		"Handles_enumerations_once_the_ones_in_progress_finish": {limits: loadshed.Limits{MaxEnumerations: 1, MaxQueued: 1}, inProgress: []bool{true}, enumeration: true, releaseAfter: 100 * time.Millisecond},
+
+		"Error_when_too_many_requests_are_waiting":     {limits: loadshed.Limits{MaxRequests: 1}, inProgress: []bool{false}, wantErr: loadshed.ErrOverloaded, wantFirstOverloaded: true},
+		"Error_when_too_many_enumerations_are_waiting": {limits: loadshed.Limits{MaxEnumerations: 1}, inProgress: []bool{true}, enumeration: true, wantErr: loadshed.ErrOverloaded, wantFirstOverloaded: true},
+		"Error_when_the_request_waits_for_too_long":    {limits: loadshed.Limits{MaxRequests: 1, MaxQueued: 1, QueueTimeout: 100 * time.Millisecond}, inProgress: []bool{false}, wantErr: loadshed.ErrOverloaded, wantFirstOverloaded: true},
+		"Error_when_the_waiting_request_is_cancelled":  {limits: loadshed.Limits{MaxRequests: 1, MaxQueued: 1}, inProgress: []bool{false}, cancel: true, wantErr: context.Canceled},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			l := loadshed.New(tc.limits)
+			var releases []func()
+			for _, enumeration := range tc.inProgress {
+				release, _, err := l.Acquire(context.Background(), enumeration)
+				require.NoError(t, err, "Setup: could not acquire the requests in progress")
+				releases = append(releases, release)
+			}
+			var once sync.Once
+			releaseAll := func() {
+				once.Do(func() {
+					for _, release := range releases {
+						release()
+					}
+				})
+			}
+			t.Cleanup(releaseAll)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.releaseAfter > 0 {
+				time.AfterFunc(tc.releaseAfter, releaseAll)
+			}
+			if tc.cancel {
+				time.AfterFunc(100*time.Millisecond, cancel)
+			}
+
+			release, firstOverloaded, err := l.Acquire(ctx, tc.enumeration)
+			require.Equal(t, tc.wantFirstOverloaded, firstOverloaded, "Acquire should report the first rejected request")
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr, "Acquire should return the expected error")
+				return
+			}
+			require.NoError(t, err, "Acquire should not return an error, but did")
+			release()
+		})
+	}
+}
+
+func TestAcquireReportsOnlyTheFirstRejection(t *testing.T) {
+	t.Parallel()
+
+	l := loadshed.New(loadshed.Limits{MaxRequests: 1})
+	release, _, err := l.Acquire(context.Background(), false)
+	require.NoError(t, err, "Setup: could not acquire the request in progress")
+
+	_, firstOverloaded, err := l.Acquire(context.Background(), false)
+	require.ErrorIs(t, err, loadshed.ErrOverloaded, "Acquire should reject the request")
+	require.True(t, firstOverloaded, "Acquire should report the first rejected request")
+	_, firstOverloaded, err = l.Acquire(context.Background(), false)
+	require.ErrorIs(t, err, loadshed.ErrOverloaded, "Acquire should reject the request")
+	require.False(t, firstOverloaded, "Acquire should not report the next rejected requests")
+
+	release()
+	release, _, err = l.Acquire(context.Background(), false)
+	require.NoError(t, err, "Acquire should handle the request once the one in progress finished")
+	_, firstOverloaded, err = l.Acquire(context.Background(), false)
+	require.ErrorIs(t, err, loadshed.ErrOverloaded, "Acquire should reject the request")
+	require.True(t, firstOverloaded, "Acquire should report the first rejected request after a handled one")
+	release()
+}
+
+func TestSetLimits(t *testing.T) {
+	t.Parallel()
+
+	l := loadshed.New(loadshed.Limits{MaxRequests: 1, MaxQueued: 1})
+	release, _, err := l.Acquire(context.Background(), false)
+	require.NoError(t, err, "Setup: could not acquire the request in progress")
+	defer release()
+
+	done := make(chan error)
+	go func() {
+		release, _, err := l.Acquire(context.Background(), false)
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Acquire should wait for the request in progress to finish")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	l.SetLimits(loadshed.Limits{MaxRequests: 2})
+	select {
+	case err := <-done:
+		require.NoError(t, err, "Acquire should handle the waiting request once the limits are raised")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Acquire should handle the waiting request once the limits are raised")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		limits loadshed.Limits
+
+		wantErr bool
+	}{
+		"Default_limits_are_valid": {limits: loadshed.DefaultLimits},
+		"No_limits_are_valid":      {},
+
+		"Error_when_the_requests_limit_is_negative":     {limits: loadshed.Limits{MaxRequests: -1}, wantErr: true},
+		"Error_when_the_enumerations_limit_is_negative": {limits: loadshed.Limits{MaxEnumerations: -1}, wantErr: true},
+		"Error_when_the_queue_limit_is_negative":        {limits: loadshed.Limits{MaxQueued: -1}, wantErr: true},
+		"Error_when_the_queue_timeout_is_negative":      {limits: loadshed.Limits{QueueTimeout: -time.Second}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.limits.Validate()
+			if tc.wantErr {
+				require.Error(t, err, "Validate should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "Validate should not return an error, but did")
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/log"
+	"google.golang.org/grpc"
+)
+
+// logFields adds the service, the session, the user and the broker of the request to the fields of its logs.
+func (m Manager) logFields(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(log.WithFields(ctx, requestLogFields(m, req, info.FullMethod)...), req)
+}
+
+// requestLogFields returns the log fields of the request of the given method.
+func requestLogFields(m Manager, req interface{}, method string) (fields []any) {
+	switch {
+	case strings.HasPrefix(method, "/authd.PAM/"):
+		fields = append(fields, log.ComponentField, PAMServiceName)
+	case strings.HasPrefix(method, "/authd.NSS/"):
+		fields = append(fields, log.ComponentField, NSSServiceName)
+	case strings.HasPrefix(method, "/authd.UserService/"):
+		fields = append(fields, log.ComponentField, UserServiceName)
+	}
+
+	if r, ok := req.(interface{ GetSessionId() string }); ok && r.GetSessionId() != "" {
+		fields = append(fields, log.SessionIDField, r.GetSessionId())
+		if b, err := m.brokerManager.BrokerFromSessionID(r.GetSessionId()); err == nil {
+			fields = append(fields, log.BrokerField, b.ID)
+		}
+	}
+	if r, ok := req.(interface{ GetBrokerId() string }); ok && r.GetBrokerId() != "" {
+		fields = append(fields, log.BrokerField, r.GetBrokerId())
+	}
+
+	var user string
+	switch r := req.(type) {
+	case interface{ GetUsername() string }:
+		user = r.GetUsername()
+	// The name of these requests is the one of a user, not of a group.
+	case *authd.GetPasswdByNameRequest, *authd.GetShadowByNameRequest, *authd.LastLoginRequest,
+		*authd.LockUserRequest, *authd.PurgeUserRequest, *authd.AdoptUserRequest, *authd.SetUserAttributesRequest:
+		user = r.(interface{ GetName() string }).GetName()
+	}
+	if user != "" {
+		fields = append(fields, log.UserField, user)
+	}
+
+	return fields
+}
+
+// dumpPayloads logs the request and the response of the call if the payload dumps are enabled. Their secrets are
+// redacted by the logs.
+func dumpPayloads(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	log.Payloadf(ctx, "gRPC request %s: %v", info.FullMethod, req)
+	resp, err := handler(ctx, req)
+	if err != nil {
+		log.Payloadf(ctx, "gRPC error %s: %v", info.FullMethod, err)
+		return resp, err
+	}
+	log.Payloadf(ctx, "gRPC response %s: %v", info.FullMethod, resp)
+	return resp, nil
+}
@@ -0,0 +1,184 @@
+// Package management exposes a read-mostly D-Bus interface to manage authd from desktop components, such as settings
+// panels, without using its gRPC API: the available brokers and the broker assigned to each user. The users can only
+// query their own broker, and only root can query the one of other users or change it.
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/ubuntu/authd/internal/brokers"
+	"github.com/ubuntu/authd/internal/services/permissions"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+const (
+	// ObjectPath is the path of the management object.
+	ObjectPath = dbus.ObjectPath("/com/ubuntu/authd/Manager")
+	// Interface is the D-Bus interface of the management object.
+	Interface = "com.ubuntu.authd.Manager"
+
+	// SignalBrokersChanged is emitted when the available brokers were reloaded.
+	SignalBrokersChanged = "BrokersChanged"
+)
+
+// intro describes the methods and the signals of the interface. The changes of the broker assigned to a user are
+// signaled with the changes of the users.
+var intro = introspect.Node{
+	Name: string(ObjectPath),
+	Interfaces: []introspect.Interface{
+		introspect.IntrospectData,
+		{
+			Name: Interface,
+			Methods: []introspect.Method{
+				{Name: "AvailableBrokers", Args: []introspect.Arg{{Name: "brokers", Type: "a(sss)", Direction: "out"}}},
+				{Name: "UserBroker", Args: []introspect.Arg{
+					{Name: "name", Type: "s", Direction: "in"},
+					{Name: "broker_id", Type: "s", Direction: "out"},
+				}},
+				{Name: "SetUserBroker", Args: []introspect.Arg{
+					{Name: "name", Type: "s", Direction: "in"},
+					{Name: "broker_id", Type: "s", Direction: "in"},
+				}},
+			},
+			Signals: []introspect.Signal{
+				{Name: SignalBrokersChanged},
+			},
+		},
+	},
+}
+
+// Broker is the description of a broker returned by AvailableBrokers.
+type Broker struct {
+	ID        string
+	Name      string
+	BrandIcon string
+}
+
+// Service is the management object exported on the system bus.
+type Service struct {
+	conn              *dbus.Conn
+	userManager       *users.Manager
+	brokerManager     *brokers.Manager
+	permissionManager *permissions.Manager
+}
+
+// Export exports the management object on the connection, which should own the bus name of authd.
+func Export(conn *dbus.Conn, userManager *users.Manager, brokerManager *brokers.Manager, permissionManager *permissions.Manager) (s *Service, err error) {
+	defer decorate.OnError(&err, "can't export D-Bus management object")
+
+	s = &Service{
+		conn:              conn,
+		userManager:       userManager,
+		brokerManager:     brokerManager,
+		permissionManager: permissionManager,
+	}
+
+	if err := conn.ExportMethodTable(map[string]interface{}{
+		"AvailableBrokers": s.AvailableBrokers,
+		"UserBroker":       s.UserBroker,
+		"SetUserBroker":    s.SetUserBroker,
+	}, ObjectPath, Interface); err != nil {
+		return nil, err
+	}
+	if err := conn.Export(introspect.NewIntrospectable(&intro), ObjectPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// AvailableBrokers returns the available brokers in preference order.
+func (s *Service) AvailableBrokers() ([]Broker, *dbus.Error) {
+	var r []Broker
+	for _, b := range s.brokerManager.AvailableBrokers() {
+		r = append(r, Broker{ID: b.ID, Name: b.Name, BrandIcon: b.BrandIconPath})
+	}
+	return r, nil
+}
+
+// UserBroker returns the ID of the broker assigned to the user, or an empty string if none is. Only root and the user
+// itself can call it.
+func (s *Service) UserBroker(sender dbus.Sender, name string) (string, *dbus.Error) {
+	uid, dbusErr := s.callerUID(sender)
+	if dbusErr != nil {
+		return "", dbusErr
+	}
+	if err := s.permissionManager.IsUserRoot(uid); err != nil {
+		// The users not handled by authd are denied too, as they are not the caller.
+		if u, e := s.userManager.UserByName(name); e != nil || u.UID != uid {
+			return "", dbus.NewError("org.freedesktop.DBus.Error.AccessDenied", []interface{}{
+				fmt.Sprintf("permission denied: UID %d can't query the broker of user %q", uid, name)})
+		}
+	}
+
+	if b := s.brokerManager.BrokerForUser(name); b != nil {
+		return b.ID, nil
+	}
+
+	brokerID, err := s.userManager.BrokerForUser(name)
+	if errors.Is(err, users.NoDataFoundError{}) {
+		return "", nil
+	}
+	if err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+	return brokerID, nil
+}
+
+// SetUserBroker assigns the broker to the user, which must be handled by authd. Only root can call it.
+func (s *Service) SetUserBroker(sender dbus.Sender, name, brokerID string) *dbus.Error {
+	uid, dbusErr := s.callerUID(sender)
+	if dbusErr != nil {
+		return dbusErr
+	}
+	if err := s.permissionManager.IsUserRoot(uid); err != nil {
+		return dbus.NewError("org.freedesktop.DBus.Error.AccessDenied", []interface{}{err.Error()})
+	}
+
+	if name == "" {
+		return dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []interface{}{"no user name given"})
+	}
+	// As with PAM, the decision to use the local broker is made each time the user logs in.
+	if brokerID == brokers.LocalBrokerName {
+		return dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []interface{}{"can't set local broker as default"})
+	}
+
+	// The user provided by a broker can't be given to another one, so it's checked before the broker is selected.
+	if err := s.userManager.CheckBrokerForUser(name, brokerID); errors.Is(err, users.ErrUserOfOtherBroker) {
+		return dbus.NewError("org.freedesktop.DBus.Error.AccessDenied", []interface{}{
+			fmt.Sprintf("user %q is provided by another broker, it must be removed with 'authctl user purge' first", name)})
+	} else if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	if err := s.brokerManager.SetDefaultBrokerForUser(brokerID, name); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	if err := s.userManager.UpdateBrokerForUser(name, brokerID); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+
+	log.Infof(context.Background(), "Broker of user %q set to %q over D-Bus by UID %d", name, brokerID, uid)
+	return nil
+}
+
+// callerUID returns the UID of the sender of a method call, as known by the bus.
+func (s *Service) callerUID(sender dbus.Sender) (uint32, *dbus.Error) {
+	var uid uint32
+	if err := s.conn.BusObject().Call("org.freedesktop.DBus.GetConnectionUnixUser", 0, string(sender)).Store(&uid); err != nil {
+		return 0, dbus.MakeFailedError(err)
+	}
+	return uid, nil
+}
+
+// NotifyBrokersChanged emits the signal of the reload of the available brokers. Failures are only logged.
+func (s *Service) NotifyBrokersChanged() {
+	if err := s.conn.Emit(ObjectPath, Interface+"."+SignalBrokersChanged); err != nil {
+		log.Warningf(context.Background(), "Could not emit D-Bus signal %s: %v", SignalBrokersChanged, err)
+	}
+}
@@ -0,0 +1,238 @@
+package management_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/brokers"
+	"github.com/ubuntu/authd/internal/services/management"
+	"github.com/ubuntu/authd/internal/services/permissions"
+	"github.com/ubuntu/authd/internal/testutils"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestAvailableBrokers(t *testing.T) {
+	t.Parallel()
+
+	obj, _, brokerName := newManagementForTests(t, false)
+
+	var got []management.Broker
+	err := obj.Call(management.Interface+".AvailableBrokers", 0).Store(&got)
+	require.NoError(t, err, "AvailableBrokers should not return an error, but did")
+
+	var gotNames []string
+	for _, b := range got {
+		require.NotEmpty(t, b.ID, "The brokers should have an ID")
+		gotNames = append(gotNames, b.Name)
+	}
+	require.Equal(t, []string{brokers.LocalBrokerName, brokerName}, gotNames, "AvailableBrokers should return the brokers in preference order")
+}
+
+func TestUserBroker(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username string
+		notRoot  bool
+
+		wantBroker  bool
+		wantErrName string
+	}{
+		"Returns_the_broker_of_the_user":          {username: "user1", wantBroker: true},
+		"Returns_no_broker_for_user_without_one":  {username: "user2"},
+		"Returns_no_broker_for_user_not_in_authd": {username: "doesnotexist"},
+
+		"Error_when_caller_is_not_root_nor_the_user":          {username: "user1", notRoot: true, wantErrName: "org.freedesktop.DBus.Error.AccessDenied"},
+		"Error_when_caller_is_not_root_and_user_not_in_authd": {username: "doesnotexist", notRoot: true, wantErrName: "org.freedesktop.DBus.Error.AccessDenied"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			obj, brokerID, _ := newManagementForTests(t, !tc.notRoot)
+			if !tc.notRoot {
+				err := obj.Call(management.Interface+".SetUserBroker", 0, "user1", brokerID).Err
+				require.NoError(t, err, "Setup: could not set the broker of the user")
+			}
+
+			var got string
+			err := obj.Call(management.Interface+".UserBroker", 0, tc.username).Store(&got)
+			if tc.wantErrName != "" {
+				var dbusErr dbus.Error
+				require.True(t, errors.As(err, &dbusErr), "UserBroker should return a D-Bus error, got %v", err)
+				require.Equal(t, tc.wantErrName, dbusErr.Name, "UserBroker should return the expected error")
+				return
+			}
+			require.NoError(t, err, "UserBroker should not return an error, but did")
+
+			want := ""
+			if tc.wantBroker {
+				want = brokerID
+			}
+			require.Equal(t, want, got, "UserBroker should return the broker of the user")
+		})
+	}
+}
+
+func TestSetUserBroker(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username    string
+		brokerID    string
+		notRoot     bool
+		localBroker bool
+
+		wantErrName string
+	}{
+		"Sets_the_broker_of_the_user": {},
+
+		"Error_when_caller_is_not_root":         {notRoot: true, wantErrName: "org.freedesktop.DBus.Error.AccessDenied"},
+		"Error_when_user_name_is_empty":         {username: "-", wantErrName: "org.freedesktop.DBus.Error.InvalidArgs"},
+		"Error_when_broker_is_the_local_broker": {localBroker: true, wantErrName: "org.freedesktop.DBus.Error.InvalidArgs"},
+		"Error_when_broker_does_not_exist":      {brokerID: "doesnotexist", wantErrName: "org.freedesktop.DBus.Error.Failed"},
+		"Error_when_user_is_not_in_authd":       {username: "doesnotexist", wantErrName: "org.freedesktop.DBus.Error.Failed"},
+		"Error_when_user_is_provided_by_another_broker": {
+			username: "userofotherbroker", wantErrName: "org.freedesktop.DBus.Error.AccessDenied",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			obj, brokerID, _ := newManagementForTests(t, !tc.notRoot)
+
+			switch tc.username {
+			case "":
+				tc.username = "user1"
+			case "-":
+				tc.username = ""
+			}
+			if tc.brokerID == "" {
+				tc.brokerID = brokerID
+			}
+			if tc.localBroker {
+				tc.brokerID = brokers.LocalBrokerName
+			}
+
+			err := obj.Call(management.Interface+".SetUserBroker", 0, tc.username, tc.brokerID).Err
+			if tc.wantErrName != "" {
+				var dbusErr dbus.Error
+				require.True(t, errors.As(err, &dbusErr), "SetUserBroker should return a D-Bus error, got %v", err)
+				require.Equal(t, tc.wantErrName, dbusErr.Name, "SetUserBroker should return the expected error")
+				return
+			}
+			require.NoError(t, err, "SetUserBroker should not return an error, but did")
+
+			var got string
+			err = obj.Call(management.Interface+".UserBroker", 0, tc.username).Store(&got)
+			require.NoError(t, err, "UserBroker should not return an error, but did")
+			require.Equal(t, tc.brokerID, got, "The broker of the user should have been set")
+		})
+	}
+}
+
+func TestNotifyBrokersChanged(t *testing.T) {
+	t.Parallel()
+
+	conn, err := testutils.GetSystemBusConnection(t)
+	require.NoError(t, err, "Setup: could not connect to the system bus")
+	t.Cleanup(func() { _ = conn.Close() })
+
+	s, err := management.Export(conn, nil, nil, nil)
+	require.NoError(t, err, "Setup: could not export management object")
+
+	client, err := testutils.GetSystemBusConnection(t)
+	require.NoError(t, err, "Setup: could not connect to the system bus")
+	t.Cleanup(func() { _ = client.Close() })
+	err = client.AddMatchSignal(dbus.WithMatchSender(conn.Names()[0]), dbus.WithMatchObjectPath(management.ObjectPath))
+	require.NoError(t, err, "Setup: could not subscribe to the signals")
+	received := make(chan *dbus.Signal, 1)
+	client.Signal(received)
+
+	s.NotifyBrokersChanged()
+
+	select {
+	case sig := <-received:
+		require.Equal(t, management.Interface+"."+management.SignalBrokersChanged, sig.Name, "The brokers changed signal should be emitted")
+	case <-time.After(5 * time.Second):
+		t.Fatal("The brokers changed signal was not received")
+	}
+}
+
+// newManagementForTests exports a management object with a broker and two users, assigned to no broker yet. It returns the object as seen by a client, the ID and the name of the broker.
+func newManagementForTests(t *testing.T, currentUserAsRoot bool) (obj dbus.BusObject, brokerID, brokerName string) {
+	t.Helper()
+
+	brokersConfPath := t.TempDir()
+	brokerName = strings.ReplaceAll(t.Name(), "/", "_")
+	_, cleanup, err := testutils.StartBusBrokerMock(brokersConfPath, brokerName)
+	require.NoError(t, err, "Setup: could not start bus broker mock")
+	t.Cleanup(cleanup)
+
+	brokerManager, err := brokers.NewManager(context.Background(), brokersConfPath, nil)
+	require.NoError(t, err, "Setup: could not create broker manager")
+	for _, b := range brokerManager.AvailableBrokers() {
+		if b.Name == brokerName {
+			brokerID = b.ID
+		}
+	}
+
+	userManager, err := users.NewManager(users.DefaultConfig, t.TempDir())
+	require.NoError(t, err, "Setup: could not create user manager")
+	t.Cleanup(func() { _ = userManager.Stop() })
+	for _, name := range []string{"user1", "user2"} {
+		err := userManager.UpdateUser(context.Background(), types.UserInfo{
+			Name:   name,
+			Dir:    "/home/" + name,
+			Shell:  "/bin/bash",
+			Groups: []types.GroupInfo{{Name: "group1", UGID: "12345678"}},
+		}, "")
+		require.NoError(t, err, "Setup: could not create user %q", name)
+	}
+	err = userManager.UpdateUser(context.Background(), types.UserInfo{
+		Name:   "userofotherbroker",
+		Dir:    "/home/userofotherbroker",
+		Shell:  "/bin/bash",
+		Groups: []types.GroupInfo{{Name: "group1", UGID: "12345678"}},
+	}, "other-broker-id")
+	require.NoError(t, err, "Setup: could not create user of another broker")
+
+	var opts []permissions.Option
+	if currentUserAsRoot {
+		opts = append(opts, permissions.Z_ForTests_WithCurrentUserAsRoot())
+	}
+	permissionManager := permissions.New(opts...)
+
+	conn, err := testutils.GetSystemBusConnection(t)
+	require.NoError(t, err, "Setup: could not connect to the system bus")
+	t.Cleanup(func() { _ = conn.Close() })
+	_, err = management.Export(conn, userManager, brokerManager, &permissionManager)
+	require.NoError(t, err, "Setup: could not export management object")
+
+	client, err := testutils.GetSystemBusConnection(t)
+	require.NoError(t, err, "Setup: could not connect to the system bus")
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client.Object(conn.Names()[0], management.ObjectPath), brokerID, brokerName
+}
+
+func TestMain(m *testing.M) {
+	// Start system bus mock.
+	cleanup, err := testutils.StartSystemBusMock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+
+	m.Run()
+}
@@ -3,21 +3,42 @@ package services
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
 
 	"github.com/ubuntu/authd/internal/brokers"
+	"github.com/ubuntu/authd/internal/brokers/worker"
 	"github.com/ubuntu/authd/internal/consts"
+	"github.com/ubuntu/authd/internal/crash"
 	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/services/accesspolicy"
+	"github.com/ubuntu/authd/internal/services/apiversion"
 	"github.com/ubuntu/authd/internal/services/errmessages"
+	"github.com/ubuntu/authd/internal/services/info"
+	"github.com/ubuntu/authd/internal/services/loadshed"
+	"github.com/ubuntu/authd/internal/services/management"
 	"github.com/ubuntu/authd/internal/services/nss"
 	"github.com/ubuntu/authd/internal/services/pam"
 	"github.com/ubuntu/authd/internal/services/permissions"
+	"github.com/ubuntu/authd/internal/services/ratelimit"
+	"github.com/ubuntu/authd/internal/services/securityevents"
+	"github.com/ubuntu/authd/internal/services/signals"
+	"github.com/ubuntu/authd/internal/services/user"
+	"github.com/ubuntu/authd/internal/services/userdb"
+	"github.com/ubuntu/authd/internal/tracing"
 	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/internal/varlink"
 	"github.com/ubuntu/authd/log"
 	"github.com/ubuntu/decorate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 // Manager mediate the whole business logic of the application.
@@ -26,61 +47,424 @@ type Manager struct {
 	brokerManager *brokers.Manager
 	pamService    pam.Service
 	nssService    nss.Service
+	userService   user.Service
+	signals       *signals.Emitter
+	management    *management.Service
+
+	permissionManager *permissions.Manager
+	rateLimiter       *ratelimit.Limiter
+	loadLimiter       *loadshed.Limiter
+	accessPolicy      *accesspolicy.Evaluator
+	securityEvents    *securityevents.Notifier
+
+	reflection bool
+}
+
+type options struct {
+	rateLimits          ratelimit.Limits
+	authorizationPolicy permissions.Policy
+	brokerWorker        *worker.Client
+	reflection          bool
+	sessionIdleTimeout  time.Duration
+	brokerCallLimits    brokers.CallLimits
+	loadLimits          loadshed.Limits
+
+	sessionPendingTimeout time.Duration
+	accessPolicy          accesspolicy.Policy
+	sessionLimits         brokers.SessionLimits
+	securityEvents        securityevents.Config
+	confineSnaps          bool
+}
+
+// Option represents an optional function to override Manager default values.
+type Option func(*options)
+
+// WithRateLimits limits the rate of the requests of each user calling the services. By default, they are not
+// limited.
+func WithRateLimits(limits ratelimit.Limits) Option {
+	return func(o *options) {
+		o.rateLimits = limits
+	}
+}
+
+// WithLoadLimits bounds the requests handled at once, rejecting the ones which can't wait for the others to finish. By
+// default, they are not limited.
+func WithLoadLimits(limits loadshed.Limits) Option {
+	return func(o *options) {
+		o.loadLimits = limits
+	}
+}
+
+// WithAuthorizationPolicy grants the access to some of the methods restricted to root to other users.
+func WithAuthorizationPolicy(policy permissions.Policy) Option {
+	return func(o *options) {
+		o.authorizationPolicy = policy
+	}
+}
+
+// WithBrokerWorker calls the D-Bus brokers through the unprivileged worker.
+func WithBrokerWorker(w *worker.Client) Option {
+	return func(o *options) {
+		o.brokerWorker = w
+	}
+}
+
+// WithSessionIdleTimeout ends the authentication sessions without any activity for longer than timeout. By default,
+// they are only ended by their client or when the daemon stops.
+func WithSessionIdleTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.sessionIdleTimeout = timeout
+	}
+}
+
+// WithSessionPendingTimeout ends the authentication sessions still waiting for the user or the broker timeout after
+// they started, unless their broker overrides it. By default, they can stay pending until they are idle.
+func WithSessionPendingTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.sessionPendingTimeout = timeout
+	}
+}
+
+// WithAccessPolicy evaluates the conditional access policy before the authentications are granted. By default, they
+// are not restricted.
+func WithAccessPolicy(policy accesspolicy.Policy) Option {
+	return func(o *options) {
+		o.accessPolicy = policy
+	}
+}
+
+// WithBrokerCallLimits bounds the calls to each D-Bus broker. By default, they are not limited.
+func WithBrokerCallLimits(limits brokers.CallLimits) Option {
+	return func(o *options) {
+		o.brokerCallLimits = limits
+	}
+}
+
+// WithSessionLimits bounds the authentication sessions in progress of each user and of each source they log in from.
+// By default, they are not limited.
+func WithSessionLimits(limits brokers.SessionLimits) Option {
+	return func(o *options) {
+		o.sessionLimits = limits
+	}
+}
+
+// WithSecurityEvents notifies the security events, like the lockouts of the users, as configured. By default, they
+// are not notified.
+func WithSecurityEvents(config securityevents.Config) Option {
+	return func(o *options) {
+		o.securityEvents = config
+	}
+}
+
+// WithReflection serves the gRPC server reflection on the main socket, for tools like grpcurl to be used against the
+// daemon when debugging. It is restricted to root.
+func WithReflection() Option {
+	return func(o *options) {
+		o.reflection = true
+	}
+}
+
+// WithSnapConfinement restricts the clients confined in a snap to what the authorization policy grants their snap,
+// even if they run as root.
+func WithSnapConfinement() Option {
+	return func(o *options) {
+		o.confineSnaps = true
+	}
 }
 
 // NewManager returns a new manager after creating all necessary items for our business logic.
-func NewManager(ctx context.Context, cacheDir, brokersConfPath string, configuredBrokers []string, usersConfig users.Config) (m Manager, err error) {
+func NewManager(ctx context.Context, cacheDir, brokersConfPath string, configuredBrokers []string, usersConfig users.Config, args ...Option) (m Manager, err error) {
 	defer decorate.OnError(&err /*i18n.G(*/, "can't create authd object") //)
 
+	var opts options
+	for _, arg := range args {
+		arg(&opts)
+	}
+
 	log.Debug(ctx, "Building authd object")
 
-	brokerManager, err := brokers.NewManager(ctx, brokersConfPath, configuredBrokers)
+	brokerManagerOpts := []brokers.Option{
+		brokers.WithSessionIdleTimeout(opts.sessionIdleTimeout),
+		brokers.WithSessionPendingTimeout(opts.sessionPendingTimeout),
+		brokers.WithCallLimits(opts.brokerCallLimits),
+		brokers.WithSessionLimits(opts.sessionLimits),
+	}
+	if opts.brokerWorker != nil {
+		brokerManagerOpts = append(brokerManagerOpts, brokers.WithWorker(opts.brokerWorker))
+	}
+	brokerManager, err := brokers.NewManager(ctx, brokersConfPath, configuredBrokers, brokerManagerOpts...)
 	if err != nil {
 		return m, err
 	}
 
-	userManager, err := users.NewManager(usersConfig, cacheDir)
+	// The daemon works without the signals, which are only a convenience for other components.
+	var userManagerOpts []users.Option
+	emitter, err := signals.New(ctx)
+	if err != nil {
+		log.Warningf(ctx, "D-Bus signals on users changes are disabled: %v", err)
+	} else {
+		userManagerOpts = append(userManagerOpts, users.WithChangeHandler(emitter.Notify))
+	}
+
+	securityEvents := securityevents.New(opts.securityEvents)
+	userManagerOpts = append(userManagerOpts, users.WithSecurityEventHandler(securityEvents.Notify))
+
+	userManagerOpts = append(userManagerOpts, users.WithPreSyncSource(func(ctx context.Context) (map[string][]types.UserInfo, error) {
+		return brokersUsers(ctx, brokerManager)
+	}))
+
+	userManager, err := users.NewManager(usersConfig, cacheDir, userManagerOpts...)
 	if err != nil {
+		if emitter != nil {
+			_ = emitter.Close()
+		}
+		securityEvents.Close()
 		return m, err
 	}
 
-	permissionManager := permissions.New()
+	permissionOpts := []permissions.Option{permissions.WithPolicy(opts.authorizationPolicy)}
+	if opts.confineSnaps {
+		permissionOpts = append(permissionOpts, permissions.WithSnapConfinement())
+	}
+	permissionManager := permissions.New(permissionOpts...)
+	accessPolicy := accesspolicy.New(opts.accessPolicy)
 
 	nssService := nss.NewService(ctx, userManager, brokerManager, &permissionManager)
-	pamService := pam.NewService(ctx, userManager, brokerManager, &permissionManager)
+	pamService := pam.NewService(ctx, userManager, brokerManager, &permissionManager, accessPolicy)
+	userService := user.NewService(ctx, userManager, brokerManager, &permissionManager)
+
+	// The management interface is exported on the connection owning the bus name of authd.
+	var managementService *management.Service
+	if emitter != nil {
+		managementService, err = management.Export(emitter.Conn(), userManager, brokerManager, &permissionManager)
+		if err != nil {
+			log.Warningf(ctx, "D-Bus management interface is disabled: %v", err)
+		}
+	}
 
 	return Manager{
 		userManager:   userManager,
 		brokerManager: brokerManager,
 		nssService:    nssService,
 		pamService:    pamService,
+		userService:   userService,
+		signals:       emitter,
+		management:    managementService,
+
+		permissionManager: &permissionManager,
+		rateLimiter:       ratelimit.New(opts.rateLimits),
+		loadLimiter:       loadshed.New(opts.loadLimits),
+		accessPolicy:      accessPolicy,
+		securityEvents:    securityEvents,
+
+		reflection: opts.reflection,
 	}, nil
 }
 
-// RegisterGRPCServices returns a new grpc Server after registering both NSS and PAM services.
+// Reload applies a new configuration of the brokers and of the users to the running services, without interrupting
+// the requests and sessions in progress.
+func (m Manager) Reload(ctx context.Context, configuredBrokers []string, usersConfig users.Config) error {
+	log.Debug(ctx, "Reloading authd configuration")
+
+	brokersErr := m.brokerManager.Reload(ctx, configuredBrokers)
+	if brokersErr == nil && m.management != nil {
+		m.management.NotifyBrokersChanged()
+	}
+
+	return errors.Join(brokersErr, m.userManager.Reload(usersConfig))
+}
+
+// SelfCheck returns an error if the daemon can't serve the requests because its database or the system bus can't be
+// reached.
+func (m Manager) SelfCheck(ctx context.Context) error {
+	return errors.Join(m.userManager.CheckDatabase(), m.brokerManager.CheckBus(ctx))
+}
+
+// SetRateLimits changes the rate limits of the next requests.
+func (m Manager) SetRateLimits(limits ratelimit.Limits) {
+	m.rateLimiter.SetLimits(limits)
+}
+
+// SetLoadLimits changes the limits of the requests handled at once.
+func (m Manager) SetLoadLimits(limits loadshed.Limits) {
+	m.loadLimiter.SetLimits(limits)
+}
+
+// SetSessionIdleTimeout changes the duration without any activity after which the authentication sessions are ended.
+func (m Manager) SetSessionIdleTimeout(timeout time.Duration) {
+	m.brokerManager.SetSessionIdleTimeout(timeout)
+}
+
+// SetSessionPendingTimeout changes the duration after which the authentication sessions still waiting for the user or
+// the broker are ended, for the brokers which don't override it.
+func (m Manager) SetSessionPendingTimeout(timeout time.Duration) {
+	m.brokerManager.SetSessionPendingTimeout(timeout)
+}
+
+// SetSessionLimits changes the limits of the authentication sessions in progress of each user and of each source.
+func (m Manager) SetSessionLimits(limits brokers.SessionLimits) {
+	m.brokerManager.SetSessionLimits(limits)
+}
+
+// SetSecurityEvents changes where the next security events are notified.
+func (m Manager) SetSecurityEvents(config securityevents.Config) {
+	m.securityEvents.SetConfig(config)
+}
+
+// SetAuthorizationPolicy changes the authorization policy of the next requests.
+func (m Manager) SetAuthorizationPolicy(policy permissions.Policy) {
+	m.permissionManager.SetPolicy(policy)
+}
+
+// SetAccessPolicy changes the conditional access policy of the next authentications.
+func (m Manager) SetAccessPolicy(policy accesspolicy.Policy) {
+	m.accessPolicy.SetPolicy(policy)
+}
+
+// brokersUsers returns the users expected by the brokers which support listing them, by broker ID.
+func brokersUsers(ctx context.Context, brokerManager *brokers.Manager) (usersByBroker map[string][]types.UserInfo, err error) {
+	usersByBroker = make(map[string][]types.UserInfo)
+	for _, b := range brokerManager.AvailableBrokers() {
+		if b.ID == brokers.LocalBrokerName {
+			continue
+		}
+
+		users, e := b.ListUsers(ctx)
+		if errors.Is(e, brokers.ErrListUsersNotSupported) {
+			log.Debugf(ctx, "Broker %q does not support listing its users, they are not pre-synced", b.Name)
+			continue
+		}
+		if e != nil {
+			err = errors.Join(err, e)
+			continue
+		}
+		// Apply the users configuration of the broker, as on login. The default shell of the broker is set on the first
+		// login of the users.
+		for i := range users {
+			b.Users.Apply(&users[i])
+		}
+		usersByBroker[b.ID] = users
+	}
+
+	return usersByBroker, err
+}
+
+// Names of the services which can be served on a socket.
+const (
+	NSSServiceName  = "nss"
+	PAMServiceName  = "pam"
+	UserServiceName = "user"
+)
+
+// RegisterGRPCServices returns a new grpc Server after registering the NSS, PAM and user services.
 func (m Manager) RegisterGRPCServices(ctx context.Context) *grpc.Server {
-	log.Debug(ctx, "Registering gRPC services")
+	grpcServer := m.registerGRPCServices(ctx, m.permissionManager.UnixPeerCreds(), NSSServiceName, PAMServiceName, UserServiceName)
+	if m.reflection {
+		log.Warning(ctx, "gRPC server reflection is enabled on the main socket")
+		reflection.Register(grpcServer)
+	}
+	return grpcServer
+}
+
+// GRPCServicesRegisterer returns a function registering only the given services on a new grpc Server, so that they
+// can be served on a separate socket.
+func (m Manager) GRPCServicesRegisterer(names ...string) (func(context.Context) *grpc.Server, error) {
+	if err := checkServiceNames(names); err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) *grpc.Server {
+		return m.registerGRPCServices(ctx, m.permissionManager.UnixPeerCreds(), names...)
+	}, nil
+}
+
+// TLSGRPCServicesRegisterer returns a function registering only the given services on a new grpc Server, which
+// authenticates the remote clients with their TLS certificate. The clients named in rootClients are allowed what root
+// is.
+func (m Manager) TLSGRPCServicesRegisterer(config *tls.Config, rootClients []string, names ...string) (func(context.Context) *grpc.Server, error) {
+	if err := checkServiceNames(names); err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) *grpc.Server {
+		return m.registerGRPCServices(ctx, permissions.WithTLSClientCerts(config, rootClients), names...)
+	}, nil
+}
 
-	opts := []grpc.ServerOption{permissions.WithUnixPeerCreds(), grpc.ChainUnaryInterceptor(m.globalPermissions, errmessages.RedactErrorInterceptor)}
+// checkServiceNames returns an error if no service or an unknown one is given.
+func checkServiceNames(names []string) error {
+	if len(names) == 0 {
+		return errors.New("no services to register")
+	}
+	for _, name := range names {
+		if !slices.Contains([]string{NSSServiceName, PAMServiceName, UserServiceName}, name) {
+			return fmt.Errorf("unknown service %q", name)
+		}
+	}
+	return nil
+}
+
+// registerGRPCServices returns a new grpc Server after registering the given services, with the credentials of the
+// clients given by creds.
+func (m Manager) registerGRPCServices(ctx context.Context, creds grpc.ServerOption, names ...string) *grpc.Server {
+	log.Debugf(ctx, "Registering gRPC services %v", names)
+
+	// Waiting for the handlers on stop ensures that no request uses the cache once it's closed.
+	opts := []grpc.ServerOption{creds, grpc.WaitForHandlers(true), grpc.ChainUnaryInterceptor(tracing.UnaryServerInterceptor, crash.UnaryServerInterceptor, m.logFields, dumpPayloads, apiversion.ServerInterceptor, m.rateLimit, m.loadShed, m.globalPermissions, errmessages.RedactErrorInterceptor), grpc.ChainStreamInterceptor(m.reflectionPermissions)}
 	grpcServer := grpc.NewServer(opts...)
 
 	healthCheck := health.NewServer()
 	healthgrpc.RegisterHealthServer(grpcServer, healthCheck)
 
-	// We may provide status per each service, but for now we only care about the global state.
-	// Also, we're serving by default because all the brokers have been initialized at this
+	// The global state and the one of each registered service, by its gRPC name, are reported.
+	// We're serving by default because all the brokers have been initialized at this
 	// point, so no need to start in NOT_SERVING mode and then update it accordingly.
 	defer healthCheck.SetServingStatus(consts.ServiceName, healthpb.HealthCheckResponse_SERVING)
 
-	authd.RegisterNSSServer(grpcServer, m.nssService)
-	authd.RegisterPAMServer(grpcServer, m.pamService)
+	var served []string
+	if slices.Contains(names, NSSServiceName) {
+		authd.RegisterNSSServer(grpcServer, m.nssService)
+		healthCheck.SetServingStatus(authd.NSS_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+		served = append(served, authd.NSS_ServiceDesc.ServiceName)
+	}
+	if slices.Contains(names, PAMServiceName) {
+		authd.RegisterPAMServer(grpcServer, m.pamService)
+		healthCheck.SetServingStatus(authd.PAM_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+		served = append(served, authd.PAM_ServiceDesc.ServiceName)
+	}
+	if slices.Contains(names, UserServiceName) {
+		authd.RegisterUserServiceServer(grpcServer, m.userService)
+		healthCheck.SetServingStatus(authd.UserService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+		served = append(served, authd.UserService_ServiceDesc.ServiceName)
+	}
+
+	// The clients can always find out what is served on the socket.
+	authd.RegisterInfoServer(grpcServer, info.NewService(ctx, served, m.userManager.Config()))
 
 	return grpcServer
 }
 
-// stop stops the underlying cache.
+// UserDBServer returns the varlink server of the io.systemd.UserDatabase interface, serving the users and groups of the
+// database to the systemd components.
+func (m Manager) UserDBServer(ctx context.Context) *varlink.Server {
+	return userdb.NewService(ctx, m.userManager).NewServer()
+}
+
+// StopSessions refuses any new authentication session, for authd to shut down once the sessions in progress are done.
+func (m Manager) StopSessions() {
+	m.brokerManager.StopSessions()
+}
+
+// stop ends the sessions still in progress and stops the underlying cache.
 func (m *Manager) stop() error {
 	log.Debug(context.TODO(), "Closing gRPC manager and cache")
 
-	return m.userManager.Stop()
+	err := m.brokerManager.EndAllSessions()
+	err = errors.Join(err, m.userManager.Stop())
+	// The security events already recorded are still notified before the daemon exits.
+	m.securityEvents.Close()
+	if m.signals != nil {
+		err = errors.Join(err, m.signals.Close())
+	}
+	return err
 }
@@ -8,17 +8,26 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/consts"
 	"github.com/ubuntu/authd/internal/proto/authd"
 	"github.com/ubuntu/authd/internal/services"
+	"github.com/ubuntu/authd/internal/services/apiversion"
 	"github.com/ubuntu/authd/internal/services/errmessages"
 	"github.com/ubuntu/authd/internal/testutils"
 	"github.com/ubuntu/authd/internal/testutils/golden"
 	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
 )
 
 func TestNewManager(t *testing.T) {
@@ -72,6 +81,252 @@ func TestRegisterGRPCServices(t *testing.T) {
 	golden.CheckOrUpdateYAML(t, got)
 }
 
+func TestGRPCServicesRegisterer(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		services []string
+
+		wantServices []string
+		wantErr      bool
+	}{
+		"Register_only_NSS_service":      {services: []string{"nss"}, wantServices: []string{"authd.NSS"}},
+		"Register_PAM_and_user_services": {services: []string{"pam", "user"}, wantServices: []string{"authd.PAM", "authd.UserService"}},
+		"Error_when_no_service_is_given": {wantErr: true},
+		"Error_when_service_is_unknown":  {services: []string{"nss", "doesnotexist"}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := services.NewManager(context.Background(), t.TempDir(), t.TempDir(), nil, users.DefaultConfig)
+			require.NoError(t, err, "Setup: could not create manager for the test")
+			defer require.NoError(t, m.Stop(), "Teardown: Stop should not have returned an error, but did")
+
+			register, err := m.GRPCServicesRegisterer(tc.services...)
+			if tc.wantErr {
+				require.Error(t, err, "GRPCServicesRegisterer should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "GRPCServicesRegisterer should not return an error, but did")
+
+			var got []string
+			for name := range register(context.Background()).GetServiceInfo() {
+				got = append(got, name)
+			}
+			want := append([]string{"grpc.health.v1.Health", "authd.Info"}, tc.wantServices...)
+			require.ElementsMatch(t, want, got, "Only the requested services, the health and the info ones should be registered")
+		})
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	m, err := services.NewManager(context.Background(), t.TempDir(), t.TempDir(), nil, users.DefaultConfig)
+	require.NoError(t, err, "Setup: could not create manager for the test")
+	defer require.NoError(t, m.Stop(), "Teardown: Stop should not have returned an error, but did")
+
+	register, err := m.GRPCServicesRegisterer(services.NSSServiceName, services.UserServiceName)
+	require.NoError(t, err, "Setup: could not create services registerer")
+	grpcServer := register(context.Background())
+
+	// socket path is limited in length.
+	tmpDir, err := os.MkdirTemp("", "authd-socket-dir")
+	require.NoError(t, err, "Setup: could not setup temporary socket dir path")
+	defer os.RemoveAll(tmpDir)
+	socketPath := filepath.Join(tmpDir, "authd.sock")
+	lis, err := net.Listen("unix", socketPath)
+	require.NoError(t, err, "Setup: could not create unix socket")
+	defer lis.Close()
+
+	serverDone := make(chan (error))
+	go func() { serverDone <- grpcServer.Serve(lis) }()
+	defer func() {
+		grpcServer.Stop()
+		require.NoError(t, <-serverDone, "gRPC server should not return an error from serving")
+	}()
+
+	conn, err := grpc.NewClient("unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err, "Setup: could not dial the server")
+	defer conn.Close()
+	healthClient := healthpb.NewHealthClient(conn)
+
+	tests := map[string]struct {
+		service string
+
+		wantStatus healthpb.HealthCheckResponse_ServingStatus
+		wantErr    bool
+	}{
+		"Global_status_is_serving":             {service: consts.ServiceName, wantStatus: healthpb.HealthCheckResponse_SERVING},
+		"Registered_service_is_serving":        {service: "authd.NSS", wantStatus: healthpb.HealthCheckResponse_SERVING},
+		"Other_registered_service_is_serving":  {service: "authd.UserService", wantStatus: healthpb.HealthCheckResponse_SERVING},
+		"Error_when_service_is_not_registered": {service: "authd.PAM", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Not parallel: the server is stopped once the parent test returns.
+			r, err := healthClient.Check(context.Background(), &healthpb.HealthCheckRequest{Service: tc.service})
+			if tc.wantErr {
+				require.Equal(t, codes.NotFound, status.Code(err), "Check should return a not found error")
+				return
+			}
+			require.NoError(t, err, "Check should not return an error, but did")
+			require.Equal(t, tc.wantStatus, r.GetStatus(), "Check should return the expected status")
+		})
+	}
+}
+
+func TestSelfCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		stopped bool
+
+		wantErr bool
+	}{
+		"Passes_when_database_and_bus_are_reachable": {},
+
+		"Error_when_database_is_closed": {stopped: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := services.NewManager(context.Background(), t.TempDir(), t.TempDir(), nil, users.DefaultConfig)
+			require.NoError(t, err, "Setup: could not create manager for the test")
+			if tc.stopped {
+				require.NoError(t, m.Stop(), "Setup: Stop should not have returned an error, but did")
+			} else {
+				defer func() { require.NoError(t, m.Stop(), "Teardown: Stop should not have returned an error, but did") }()
+			}
+
+			err = m.SelfCheck(context.Background())
+			if tc.wantErr {
+				require.Error(t, err, "SelfCheck should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "SelfCheck should not return an error, but did")
+		})
+	}
+}
+
+func TestAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	m, err := services.NewManager(context.Background(), t.TempDir(), t.TempDir(), nil, users.DefaultConfig)
+	require.NoError(t, err, "Setup: could not create manager for the test")
+	defer func() { require.NoError(t, m.Stop(), "Teardown: Stop should not have returned an error, but did") }()
+
+	register, err := m.GRPCServicesRegisterer(services.NSSServiceName, services.UserServiceName)
+	require.NoError(t, err, "Setup: could not create services registerer")
+	grpcServer := register(context.Background())
+
+	// socket path is limited in length.
+	tmpDir, err := os.MkdirTemp("", "authd-socket-dir")
+	require.NoError(t, err, "Setup: could not setup temporary socket dir path")
+	defer os.RemoveAll(tmpDir)
+	socketPath := filepath.Join(tmpDir, "authd.sock")
+	lis, err := net.Listen("unix", socketPath)
+	require.NoError(t, err, "Setup: could not create unix socket")
+	defer lis.Close()
+
+	serverDone := make(chan (error))
+	go func() { serverDone <- grpcServer.Serve(lis) }()
+	defer func() {
+		grpcServer.Stop()
+		require.NoError(t, <-serverDone, "gRPC server should not return an error from serving")
+	}()
+
+	conn, err := grpc.NewClient("unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err, "Setup: could not dial the server")
+	defer conn.Close()
+
+	caps, err := authd.NewInfoClient(conn).GetCapabilities(context.Background(), &authd.Empty{})
+	require.NoError(t, err, "GetCapabilities should not return an error, but did")
+	require.Equal(t, apiversion.Current, caps.GetApiVersion(), "GetCapabilities should return the version of the API of the daemon")
+	require.Equal(t, apiversion.Minimum, caps.GetMinApiVersion(), "GetCapabilities should return the oldest version of the API supported")
+	require.Equal(t, []string{"authd.NSS", "authd.UserService"}, caps.GetServices(), "GetCapabilities should return the services served on the socket")
+	require.Empty(t, caps.GetFeatures(), "GetCapabilities should not return experimental features, as none is enabled")
+	require.Equal(t, consts.Version, caps.GetVersion(), "GetCapabilities should return the version of the daemon")
+	cfg := users.DefaultConfig
+	require.Equal(t, []uint32{cfg.UIDMin, cfg.UIDMax, cfg.GIDMin, cfg.GIDMax, cfg.GIDMin, cfg.GIDMax},
+		[]uint32{caps.GetIdRanges().GetUidMin(), caps.GetIdRanges().GetUidMax(), caps.GetIdRanges().GetGidMin(),
+			caps.GetIdRanges().GetGidMax(), caps.GetIdRanges().GetRemoteGroupsGidMin(), caps.GetIdRanges().GetRemoteGroupsGidMax()},
+		"GetCapabilities should return the ID ranges, the groups of the brokers using the GID range by default")
+
+	tests := map[string]struct {
+		version string
+		info    bool
+
+		wantCode codes.Code
+	}{
+		"Serves_client_without_version": {version: "-", wantCode: codes.OK},
+		"Serves_client_of_same_version": {wantCode: codes.OK},
+		"Serves_info_to_newer_client":   {version: "1000", info: true, wantCode: codes.OK},
+		"Error_when_client_is_newer":    {version: "1000", wantCode: codes.FailedPrecondition},
+		"Error_when_version_is_invalid": {version: "one", wantCode: codes.InvalidArgument},
+		"Error_when_version_is_zero":    {version: "0", wantCode: codes.InvalidArgument},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Not parallel: the server is stopped once the parent test returns.
+			ctx := context.Background()
+			switch tc.version {
+			case "":
+				ctx = metadata.AppendToOutgoingContext(ctx, apiversion.MetadataKey, strconv.FormatUint(uint64(apiversion.Current), 10))
+			case "-":
+			default:
+				ctx = metadata.AppendToOutgoingContext(ctx, apiversion.MetadataKey, tc.version)
+			}
+
+			if tc.info {
+				_, err = authd.NewInfoClient(conn).GetCapabilities(ctx, &authd.Empty{})
+			} else {
+				_, err = authd.NewNSSClient(conn).GetPasswdEntries(ctx, &authd.Empty{})
+			}
+			require.Equal(t, tc.wantCode, status.Code(err), "The request should return the expected code, got %v", err)
+		})
+	}
+}
+
+func TestUnimplementedMethodError(t *testing.T) {
+	t.Parallel()
+
+	m, err := services.NewManager(context.Background(), t.TempDir(), t.TempDir(), nil, users.DefaultConfig)
+	require.NoError(t, err, "Setup: could not create manager for the test")
+	defer func() { require.NoError(t, m.Stop(), "Teardown: Stop should not have returned an error, but did") }()
+
+	register, err := m.GRPCServicesRegisterer(services.NSSServiceName)
+	require.NoError(t, err, "Setup: could not create services registerer")
+	grpcServer := register(context.Background())
+
+	// socket path is limited in length.
+	tmpDir, err := os.MkdirTemp("", "authd-socket-dir")
+	require.NoError(t, err, "Setup: could not setup temporary socket dir path")
+	defer os.RemoveAll(tmpDir)
+	socketPath := filepath.Join(tmpDir, "authd.sock")
+	lis, err := net.Listen("unix", socketPath)
+	require.NoError(t, err, "Setup: could not create unix socket")
+	defer lis.Close()
+
+	serverDone := make(chan (error))
+	go func() { serverDone <- grpcServer.Serve(lis) }()
+	defer func() {
+		grpcServer.Stop()
+		require.NoError(t, <-serverDone, "gRPC server should not return an error from serving")
+	}()
+
+	conn, err := grpc.NewClient("unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(errmessages.FormatErrorMessage, apiversion.ClientInterceptor))
+	require.NoError(t, err, "Setup: could not dial the server")
+	defer conn.Close()
+
+	// The PAM service is not served on this socket, as if the daemon was too old to implement it.
+	_, err = authd.NewPAMClient(conn).AvailableBrokers(context.Background(), &authd.Empty{})
+	require.ErrorContains(t, err, "authd.PAM/AvailableBrokers is not supported by the authd daemon", "The error should explain that the daemon is older than the client")
+}
+
 func TestAccessAuthorization(t *testing.T) {
 	t.Parallel()
 
@@ -114,6 +369,120 @@ func TestAccessAuthorization(t *testing.T) {
 	require.NoError(t, err, "Teardown: could not close the client connection")
 }
 
+func TestReflection(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		reflection bool
+
+		wantCode codes.Code
+	}{
+		"Reflection_is_not_served_by_default": {wantCode: codes.Unimplemented},
+
+		"Error_when_reflection_is_requested_by_a_non_root_user": {reflection: true, wantCode: codes.PermissionDenied},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts []services.Option
+			if tc.reflection {
+				opts = append(opts, services.WithReflection())
+			}
+			m, err := services.NewManager(context.Background(), t.TempDir(), t.TempDir(), nil, users.DefaultConfig, opts...)
+			require.NoError(t, err, "Setup: could not create manager for the test")
+			t.Cleanup(func() { require.NoError(t, m.Stop(), "Teardown: Stop should not have returned an error, but did") })
+
+			grpcServer := m.RegisterGRPCServices(context.Background())
+
+			// socket path is limited in length.
+			tmpDir, err := os.MkdirTemp("", "authd-socket-dir")
+			require.NoError(t, err, "Setup: could not setup temporary socket dir path")
+			defer os.RemoveAll(tmpDir)
+			socketPath := filepath.Join(tmpDir, "authd.sock")
+			lis, err := net.Listen("unix", socketPath)
+			require.NoError(t, err, "Setup: could not create unix socket")
+			defer lis.Close()
+
+			serverDone := make(chan (error))
+			go func() { serverDone <- grpcServer.Serve(lis) }()
+			defer func() {
+				grpcServer.Stop()
+				require.NoError(t, <-serverDone, "gRPC server should not return an error from serving")
+			}()
+
+			conn, err := grpc.NewClient("unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			require.NoError(t, err, "Setup: could not dial the server")
+			defer conn.Close()
+
+			stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+			require.NoError(t, err, "Setup: could not open the reflection stream")
+			err = stream.Send(&reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+			})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			require.Equal(t, tc.wantCode, status.Code(err), "The reflection request should fail with the expected code")
+		})
+	}
+}
+
+func TestRequestLogFields(t *testing.T) {
+	t.Parallel()
+
+	m, err := services.NewManager(context.Background(), t.TempDir(), t.TempDir(), nil, users.DefaultConfig)
+	require.NoError(t, err, "Setup: could not create manager for the test")
+	t.Cleanup(func() { require.NoError(t, m.Stop(), "Teardown: Stop should not have returned an error, but did") })
+
+	tests := map[string]struct {
+		method string
+		req    interface{}
+
+		want []any
+	}{
+		"PAM_request_with_user_and_broker": {
+			method: "/authd.PAM/SelectBroker",
+			req:    &authd.SBRequest{BrokerId: "broker-id", Username: "user1"},
+			want:   []any{log.ComponentField, services.PAMServiceName, log.BrokerField, "broker-id", log.UserField, "user1"},
+		},
+		"PAM_request_with_unknown_session": {
+			method: "/authd.PAM/IsAuthenticated",
+			req:    &authd.IARequest{SessionId: "some-session"},
+			want:   []any{log.ComponentField, services.PAMServiceName, log.SessionIDField, "some-session"},
+		},
+		"NSS_request_of_a_user": {
+			method: "/authd.NSS/GetPasswdByName",
+			req:    &authd.GetPasswdByNameRequest{Name: "user1"},
+			want:   []any{log.ComponentField, services.NSSServiceName, log.UserField, "user1"},
+		},
+		"User_service_request_of_a_user": {
+			method: "/authd.UserService/LockUser",
+			req:    &authd.LockUserRequest{Name: "user1"},
+			want:   []any{log.ComponentField, services.UserServiceName, log.UserField, "user1"},
+		},
+
+		"Group_name_is_not_logged_as_user": {
+			method: "/authd.NSS/GetGroupByName",
+			req:    &authd.GetGroupByNameRequest{Name: "group1"},
+			want:   []any{log.ComponentField, services.NSSServiceName},
+		},
+		"Empty_values_are_not_logged": {
+			method: "/authd.PAM/SelectBroker",
+			req:    &authd.SBRequest{},
+			want:   []any{log.ComponentField, services.PAMServiceName},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := m.RequestLogFields(tc.req, tc.method)
+			require.Equal(t, tc.want, got, "RequestLogFields should return the expected fields")
+		})
+	}
+}
+
 func TestMain(m *testing.M) {
 	// Start system bus mock.
 	cleanup, err := testutils.StartSystemBusMock()
@@ -127,7 +127,7 @@ func (s Service) GetGroupEntries(ctx context.Context, req *authd.Empty) (*authd.
 
 // GetShadowByName returns the shadow entry for the given username.
 func (s Service) GetShadowByName(ctx context.Context, req *authd.GetShadowByNameRequest) (*authd.ShadowEntry, error) {
-	if err := s.permissionManager.IsRequestFromRoot(ctx); err != nil {
+	if err := s.permissionManager.IsRequestAllowed(ctx, authd.NSS_GetShadowByName_FullMethodName); err != nil {
 		return nil, err
 	}
 
@@ -144,7 +144,7 @@ func (s Service) GetShadowByName(ctx context.Context, req *authd.GetShadowByName
 
 // GetShadowEntries returns all shadow entries.
 func (s Service) GetShadowEntries(ctx context.Context, req *authd.Empty) (*authd.ShadowEntries, error) {
-	if err := s.permissionManager.IsRequestFromRoot(ctx); err != nil {
+	if err := s.permissionManager.IsRequestAllowed(ctx, authd.NSS_GetShadowEntries_FullMethodName); err != nil {
 		return nil, err
 	}
 
@@ -222,9 +222,15 @@ func nssGroupFromUsersGroup(g types.GroupEntry) *authd.GroupEntry {
 
 // nssShadowFromUsersShadow returns a ShadowEntry from users.ShadowEntry.
 func nssShadowFromUsersShadow(u types.ShadowEntry) *authd.ShadowEntry {
+	passwd := "x"
+	if u.Locked {
+		// Like `passwd --lock`, prefix the password with an exclamation mark.
+		passwd = "!" + passwd
+	}
+
 	return &authd.ShadowEntry{
 		Name:               u.Name,
-		Passwd:             "x",
+		Passwd:             passwd,
 		LastChange:         convertToNumberOfDays(u.LastPwdChange),
 		ChangeMinDays:      convertToNumberOfDays(u.MinPwdAge),
 		ChangeMaxDays:      convertToNumberOfDays(u.MaxPwdAge),
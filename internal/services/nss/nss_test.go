@@ -68,8 +68,8 @@ func TestGetPasswdByName(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about gpasswd output here as it's already covered in the cache unit tests.
-			_ = localgroupstestutils.SetupGPasswdMock(t, filepath.Join("testdata", "empty.group"))
+			// We don't care about the local groups here as they're already covered in the cache unit tests.
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "empty.group"))
 
 			client := newNSSClient(t, tc.sourceDB, false)
 
@@ -96,8 +96,8 @@ func TestGetPasswdByUID(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about gpasswd output here as it's already covered in the cache unit tests.
-			_ = localgroupstestutils.SetupGPasswdMock(t, filepath.Join("testdata", "empty.group"))
+			// We don't care about the local groups here as they're already covered in the cache unit tests.
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "empty.group"))
 
 			client := newNSSClient(t, tc.sourceDB, false)
 
@@ -120,8 +120,8 @@ func TestGetPasswdEntries(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about gpasswd output here as it's already covered in the cache unit tests.
-			_ = localgroupstestutils.SetupGPasswdMock(t, filepath.Join("testdata", "empty.group"))
+			// We don't care about the local groups here as they're already covered in the cache unit tests.
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "empty.group"))
 
 			client := newNSSClient(t, tc.sourceDB, false)
 
@@ -148,8 +148,8 @@ func TestGetGroupByName(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about gpasswd output here as it's already covered in the cache unit tests.
-			_ = localgroupstestutils.SetupGPasswdMock(t, filepath.Join("testdata", "empty.group"))
+			// We don't care about the local groups here as they're already covered in the cache unit tests.
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "empty.group"))
 
 			client := newNSSClient(t, tc.sourceDB, false)
 
@@ -176,8 +176,8 @@ func TestGetGroupByGID(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about gpasswd output here as it's already covered in the cache unit tests.
-			_ = localgroupstestutils.SetupGPasswdMock(t, filepath.Join("testdata", "empty.group"))
+			// We don't care about the local groups here as they're already covered in the cache unit tests.
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "empty.group"))
 
 			client := newNSSClient(t, tc.sourceDB, false)
 
@@ -200,8 +200,8 @@ func TestGetGroupEntries(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about gpasswd output here as it's already covered in the cache unit tests.
-			_ = localgroupstestutils.SetupGPasswdMock(t, filepath.Join("testdata", "empty.group"))
+			// We don't care about the local groups here as they're already covered in the cache unit tests.
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "empty.group"))
 
 			client := newNSSClient(t, tc.sourceDB, false)
 
@@ -230,8 +230,8 @@ func TestGetShadowByName(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about gpasswd output here as it's already covered in the cache unit tests.
-			_ = localgroupstestutils.SetupGPasswdMock(t, filepath.Join("testdata", "empty.group"))
+			// We don't care about the local groups here as they're already covered in the cache unit tests.
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "empty.group"))
 
 			client := newNSSClient(t, tc.sourceDB, tc.currentUserNotRoot)
 
@@ -256,8 +256,8 @@ func TestGetShadowEntries(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about gpasswd output here as it's already covered in the cache unit tests.
-			_ = localgroupstestutils.SetupGPasswdMock(t, filepath.Join("testdata", "empty.group"))
+			// We don't care about the local groups here as they're already covered in the cache unit tests.
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "empty.group"))
 
 			client := newNSSClient(t, tc.sourceDB, tc.currentUserNotRoot)
 
@@ -267,10 +267,6 @@ func TestGetShadowEntries(t *testing.T) {
 	}
 }
 
-func TestMockgpasswd(t *testing.T) {
-	localgroupstestutils.Mockgpasswd(t)
-}
-
 // newNSSClient returns a new GRPC PAM client for tests with the provided sourceDB as its initial cache.
 func newNSSClient(t *testing.T, sourceDB string, currentUserNotRoot bool) (client authd.NSSClient) {
 	t.Helper()
@@ -292,7 +288,7 @@ func newNSSClient(t *testing.T, sourceDB string, currentUserNotRoot bool) (clien
 
 	service := nss.NewService(context.Background(), newUserManagerForTests(t, sourceDB), newBrokersManagerForTests(t), &pm)
 
-	grpcServer := grpc.NewServer(permissions.WithUnixPeerCreds(), grpc.ChainUnaryInterceptor(enableCheckGlobalAccess(service), errmessages.RedactErrorInterceptor))
+	grpcServer := grpc.NewServer(permissions.New().UnixPeerCreds(), grpc.ChainUnaryInterceptor(enableCheckGlobalAccess(service), errmessages.RedactErrorInterceptor))
 	authd.RegisterNSSServer(grpcServer, service)
 	done := make(chan struct{})
 	go func() {
@@ -396,11 +392,6 @@ func requireExpectedEntriesResult[T authd.PasswdEntry | authd.GroupEntry | authd
 }
 
 func TestMain(m *testing.M) {
-	// Needed to skip the test setup when running the gpasswd mock.
-	if os.Getenv("GO_WANT_HELPER_PROCESS") != "" {
-		os.Exit(m.Run())
-	}
-
 	log.SetLevel(log.DebugLevel)
 
 	cleanup, err := testutils.StartSystemBusMock()
@@ -7,16 +7,26 @@ import (
 	"errors"
 	"fmt"
 	"os/user"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ubuntu/authd/internal/brokers"
 	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/authd/internal/brokers/challenge"
 	"github.com/ubuntu/authd/internal/brokers/layouts"
+	"github.com/ubuntu/authd/internal/linuxaudit"
 	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/services/accesspolicy"
+	"github.com/ubuntu/authd/internal/services/errmessages"
 	"github.com/ubuntu/authd/internal/services/permissions"
+	"github.com/ubuntu/authd/internal/tracing"
 	"github.com/ubuntu/authd/internal/users"
 	"github.com/ubuntu/authd/internal/users/types"
 	"github.com/ubuntu/authd/log"
 	"github.com/ubuntu/decorate"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
@@ -29,18 +39,35 @@ type Service struct {
 	userManager       *users.Manager
 	brokerManager     *brokers.Manager
 	permissionManager *permissions.Manager
+	accessPolicy      *accesspolicy.Evaluator
+
+	// loginSources are the terminal, the remote host and the mode of the sessions. The terminal and the remote host
+	// are recorded on successful logins.
+	loginSources *sync.Map
 
 	authd.UnimplementedPAMServer
 }
 
+// loginSource is where the user of a session logs in from.
+type loginSource struct {
+	username string
+	tty      string
+	rhost    string
+	mode     string
+	// newPassword is true if the selected authentication mode of the session sets a new password.
+	newPassword bool
+}
+
 // NewService returns a new PAM GRPC service.
-func NewService(ctx context.Context, userManager *users.Manager, brokerManager *brokers.Manager, permissionManager *permissions.Manager) Service {
+func NewService(ctx context.Context, userManager *users.Manager, brokerManager *brokers.Manager, permissionManager *permissions.Manager, accessPolicy *accesspolicy.Evaluator) Service {
 	log.Debug(ctx, "Building new gRPC PAM service")
 
 	return Service{
 		userManager:       userManager,
 		brokerManager:     brokerManager,
 		permissionManager: permissionManager,
+		accessPolicy:      accessPolicy,
+		loginSources:      &sync.Map{},
 	}
 }
 
@@ -149,17 +176,49 @@ func (s Service) SelectBroker(ctx context.Context, req *authd.SBRequest) (resp *
 	}
 
 	// Create a session and Memorize selected broker for it.
-	sessionID, encryptionKey, err := s.brokerManager.NewSession(brokerID, username, lang, mode)
+	sessionID, encryptionKey, err := s.brokerManager.NewSession(ctx, brokerID, username, lang, mode, sessionSource(ctx, req))
+	if errors.Is(err, brokers.ErrShuttingDown) {
+		return nil, errmessages.ErrRetryable.Wrap(err)
+	}
+	if errors.Is(err, brokers.ErrTooManySessions) {
+		limitedErr := errmessages.ErrRetryable.Wrap(err)
+		limitedErr.Code = codes.ResourceExhausted
+		return nil, limitedErr
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	// The broker may support several versions of the encryption of the challenges: give the client the key of the most
+	// recent one it supports.
+	encryptionKey, err = challenge.SelectKey(encryptionKey, challenge.Version(req.GetEncryptionVersion()))
+	if err != nil {
+		if e := s.brokerManager.EndSession(sessionID); e != nil {
+			log.Warningf(ctx, "Could not end session %q: %v", sessionID, e)
+		}
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	s.loginSources.Store(sessionID, loginSource{username: username, tty: req.GetTty(), rhost: req.GetRhost(), mode: mode})
+
 	return &authd.SBResponse{
 		SessionId:     sessionID,
 		EncryptionKey: encryptionKey,
 	}, err
 }
 
+// sessionSource returns the source the sessions are limited per: the remote host the user logs in from, or the remote
+// client of the daemon. The local logins have no source, as they all come from the same processes, like login or the
+// display manager.
+func sessionSource(ctx context.Context, req *authd.SBRequest) string {
+	if rhost := req.GetRhost(); rhost != "" {
+		return fmt.Sprintf("remote host %q", rhost)
+	}
+	if name, _, ok := permissions.RemoteClient(ctx); ok {
+		return fmt.Sprintf("remote client %q", name)
+	}
+	return ""
+}
+
 // GetAuthenticationModes fetches a list of authentication modes supported by the broker depending on the session information.
 func (s Service) GetAuthenticationModes(ctx context.Context, req *authd.GAMRequest) (resp *authd.GAMResponse, err error) {
 	defer decorate.OnError(&err, "could not get authentication modes")
@@ -169,7 +228,7 @@ func (s Service) GetAuthenticationModes(ctx context.Context, req *authd.GAMReque
 		return nil, status.Error(codes.InvalidArgument, "no session ID provided")
 	}
 
-	broker, err := s.brokerManager.BrokerFromSessionID(sessionID)
+	broker, err := s.brokerFromSessionID(sessionID)
 	if err != nil {
 		return nil, err
 	}
@@ -215,7 +274,7 @@ func (s Service) SelectAuthenticationMode(ctx context.Context, req *authd.SAMReq
 		return nil, status.Error(codes.InvalidArgument, "no authentication mode provided")
 	}
 
-	broker, err := s.brokerManager.BrokerFromSessionID(sessionID)
+	broker, err := s.brokerFromSessionID(sessionID)
 	if err != nil {
 		return nil, err
 	}
@@ -225,6 +284,14 @@ func (s Service) SelectAuthenticationMode(ctx context.Context, req *authd.SAMReq
 		return nil, err
 	}
 
+	// The brokers can offer other modes than a new password to the passwd sessions, like registering a device, which
+	// don't change the password of the user.
+	if v, ok := s.loginSources.Load(sessionID); ok {
+		source := v.(loginSource)
+		source.newPassword = uiLayoutInfo[layouts.Type] == layouts.NewPassword
+		s.loginSources.Store(sessionID, source)
+	}
+
 	return &authd.SAMResponse{
 		UiLayoutInfo: mapToUILayout(uiLayoutInfo),
 	}, nil
@@ -239,40 +306,220 @@ func (s Service) IsAuthenticated(ctx context.Context, req *authd.IARequest) (res
 		return nil, status.Error(codes.InvalidArgument, "no session ID provided")
 	}
 
-	broker, err := s.brokerManager.BrokerFromSessionID(sessionID)
+	broker, err := s.brokerFromSessionID(sessionID)
 	if err != nil {
 		return nil, err
 	}
 
+	var source loginSource
+	if v, ok := s.loginSources.Load(sessionID); ok {
+		source = v.(loginSource)
+	}
+
+	// The final result of the authentication is recorded in the Linux audit log, like the other PAM authentications.
+	var audit bool
+	defer func() {
+		if !audit {
+			return
+		}
+		t, op := linuxaudit.UserAuth, "PAM:authentication"
+		if source.mode == auth.SessionModePasswd {
+			t, op = linuxaudit.UserChauthtok, "PAM:chauthtok"
+		}
+		auditLog(ctx, linuxaudit.Record{
+			Type:     t,
+			Op:       op,
+			Account:  source.username,
+			Broker:   broker.Name,
+			Session:  sessionID,
+			Rhost:    source.rhost,
+			Terminal: source.tty,
+			Success:  err == nil && resp.GetAccess() == auth.Granted,
+		})
+	}()
+
+	// The users locked by an administrator can't authenticate, so the broker is not asked to authenticate them.
+	locked, err := s.userManager.UserLocked(source.username)
+	if err != nil {
+		return nil, err
+	}
+	if locked {
+		audit = true
+		log.Infof(ctx, "%s: Denying authentication of locked user %q", sessionID, source.username)
+		return nil, lockedError(source.username)
+	}
+
+	// The users locked out after too many failed attempts can't try again until their lockout ends.
+	if lockout := s.userManager.Lockout(source.username); lockout.LockedOut {
+		audit = true
+		log.Infof(ctx, "%s: Denying authentication of user %q, locked out after too many failed attempts", sessionID, source.username)
+		return nil, lockedOutError(source.username, lockout)
+	}
+	// Neither can the users logging in from a remote host locked out after too many failed attempts of any user.
+	if lockout := s.userManager.RemoteHostLockout(source.rhost); lockout.LockedOut {
+		audit = true
+		log.Infof(ctx, "%s: Denying authentication of user %q from remote host %q, locked out after too many failed attempts", sessionID, source.username, source.rhost)
+		return nil, sourceLockedOutError(source.username, lockout)
+	}
+
+	// The conditional access policy can deny the authentication before the broker is called, or require it to ask for
+	// all the factors of the user.
+	policy := s.accessPolicy.Evaluate(ctx, accesspolicy.Request{
+		User:   source.username,
+		Broker: broker.Name,
+		Mode:   source.mode,
+		TTY:    source.tty,
+		RHost:  source.rhost,
+	})
+	if policy.Decision == accesspolicy.Deny {
+		audit = true
+		log.Infof(ctx, "%s: Denying authentication of user %q by the access policy: %s", sessionID, source.username,
+			strings.Join(policy.Reasons, ", "))
+		msg, err := json.Marshal(map[string]string{"message": "Authentication denied by the access policy of this machine"})
+		if err != nil {
+			return nil, err
+		}
+		return &authd.IAResponse{Access: auth.Denied, Msg: string(msg)}, nil
+	}
+
 	authenticationDataJSON, err := protojson.Marshal(req.GetAuthenticationData())
 	if err != nil {
 		return nil, err
 	}
+	authenticationData := string(authenticationDataJSON)
+
+	if policy.Decision != "" {
+		log.Debugf(ctx, "%s: Access policy decision for user %q: %s %v", sessionID, source.username, policy.Decision, policy.Reasons)
+		authenticationData, err = brokers.WithAccessContext(authenticationData, string(policy.Decision), strings.Join(policy.Reasons, ", "))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The device token the broker issued to the user at a previous login on this machine lets it skip the second
+	// factor, unless the access policy requires all the factors.
+	token := s.userManager.DeviceToken(broker.ID, source.username)
+	if token.Len() > 0 && policy.Decision != accesspolicy.StepUp {
+		authenticationData, err = brokers.WithDeviceToken(authenticationData, string(token.Bytes()))
+	}
+	token.Wipe()
+	if err != nil {
+		return nil, err
+	}
 
-	access, data, err := broker.IsAuthenticated(ctx, sessionID, string(authenticationDataJSON))
+	access, data, err := broker.IsAuthenticated(ctx, sessionID, authenticationData)
 	if err != nil {
 		return nil, err
 	}
+	if access == auth.Cancelled {
+		// The authentication is cancelled when the session is ended because it was pending for too long.
+		if _, err := s.brokerFromSessionID(sessionID); errors.Is(err, errmessages.ErrSessionTimedOut) {
+			return nil, err
+		}
+	}
 
 	log.Debugf(ctx, "%s: Authentication result: %s", sessionID, access)
+	audit = access == auth.Granted || access == auth.Denied || access == auth.Retry
 
+	if access == auth.Denied || access == auth.Retry {
+		s.userManager.RecordFailedLogin(source.username, source.tty, source.rhost)
+		if lockout := s.userManager.Lockout(source.username); lockout.LockedOut {
+			return nil, lockedOutError(source.username, lockout)
+		}
+		if lockout := s.userManager.RemoteHostLockout(source.rhost); lockout.LockedOut {
+			return nil, sourceLockedOutError(source.username, lockout)
+		}
+	}
 	if access != auth.Granted {
+		// The broker can rotate the encryption key of the session between its challenges, like on retries or on the
+		// stages of a multi-factor authentication.
+		var encryptionKey string
+		if access == auth.Retry || access == auth.Next {
+			if encryptionKey, data, err = brokers.RotatedEncryptionKey(data); err != nil {
+				return nil, err
+			}
+		}
+		if encryptionKey != "" {
+			log.Debugf(ctx, "%s: Encryption key rotated by the broker", sessionID)
+		}
 		return &authd.IAResponse{
-			Access: access,
-			Msg:    data,
+			Access:        access,
+			Msg:           data,
+			EncryptionKey: encryptionKey,
 		}, nil
 	}
 
+	deviceToken, data, err := brokers.DeviceToken(data)
+	if err != nil {
+		return nil, err
+	}
+
 	var uInfo types.UserInfo
 	if err := json.Unmarshal([]byte(data), &uInfo); err != nil {
 		return nil, fmt.Errorf("user data from broker invalid: %v", err)
 	}
 
-	// Update database and local groups on granted auth.
-	if err := s.userManager.UpdateUser(uInfo); err != nil {
+	s.loginSources.Delete(sessionID)
+
+	uInfo.Shell = s.userManager.ResolveShell(uInfo.Shell, broker.Users.DefaultShell)
+	broker.Users.Apply(&uInfo)
+	// A successful passwd session with a new password means that the user changed their password, which is not expired
+	// anymore.
+	uInfo.PasswordChanged = source.mode == auth.SessionModePasswd && source.newPassword
+
+	// Update database and local groups on granted auth. The operations of the update are traced as children of its span.
+	updateCtx, span := tracing.Start(ctx, "users.UpdateUser", attribute.String("user.name", uInfo.Name))
+	err = s.userManager.UpdateUser(updateCtx, uInfo, broker.ID)
+	tracing.End(span, err)
+	if errors.Is(err, users.ErrUserLocked) {
+		// The user was locked during the authentication, or the broker returned another name for it.
+		log.Infof(ctx, "%s: Denying authentication of locked user %q", sessionID, uInfo.Name)
+		return nil, lockedError(uInfo.Name)
+	}
+	if errors.Is(err, users.ErrReadOnly) {
+		log.Infof(ctx, "%s: Denying authentication of user %q because the users database is read-only", sessionID, uInfo.Name)
+		return deniedResponse("Logging in with authd is disabled because it's in read-only mode.")
+	}
+	if errors.Is(err, users.ErrUserOfOtherBroker) {
+		log.Infof(ctx, "%s: Denying authentication of user %q provided by another broker", sessionID, uInfo.Name)
+		return deniedResponse("This account is provided by another broker. Please log in with that broker.")
+	}
+	if err != nil {
 		return nil, err
 	}
 
+	if !uInfo.PasswordChanged && s.userManager.DenyExpiredPasswords() {
+		expired, err := s.userManager.PasswordExpired(uInfo.Name)
+		if err != nil {
+			return nil, err
+		}
+		if expired {
+			log.Infof(ctx, "%s: Denying authentication of user %q whose password expired", sessionID, uInfo.Name)
+			return deniedResponse("Your password has expired. Please change it with your identity provider.")
+		}
+	}
+
+	if err := s.userManager.CreateHomeDir(uInfo.Name, broker.Users.CreateHomeDir); err != nil {
+		return nil, err
+	}
+	if err := s.userManager.ApplyQuota(uInfo.Name, broker.Users.QuotaProfile); err != nil {
+		// The quotas are not required to use the account, so don't deny the login.
+		log.Warningf(ctx, "%s: %v", sessionID, err)
+	}
+
+	if err := s.userManager.RecordLogin(uInfo.Name, source.tty, source.rhost); err != nil {
+		// The login is already granted, the record is only informative.
+		log.Warningf(ctx, "%s: %v", sessionID, err)
+	}
+	s.userManager.ResetFailedLogins(source.username)
+
+	if deviceToken != "" {
+		if err := s.userManager.SetDeviceToken(broker.ID, source.username, deviceToken); err != nil {
+			// The user will have to authenticate with all the factors again at their next login.
+			log.Warningf(ctx, "%s: %v", sessionID, err)
+		}
+	}
+
 	return &authd.IAResponse{
 		Access: access,
 		Msg:    "",
@@ -293,6 +540,10 @@ func (s Service) SetDefaultBrokerForUser(ctx context.Context, req *authd.SDBFURe
 		return nil, status.Error(codes.InvalidArgument, "can't set local broker as default")
 	}
 
+	// The user provided by a broker can't be given to another one, so it's checked before the broker is selected.
+	if err = s.userManager.CheckBrokerForUser(req.GetUsername(), req.GetBrokerId()); err != nil {
+		return &authd.Empty{}, err
+	}
 	if err = s.brokerManager.SetDefaultBrokerForUser(req.GetBrokerId(), req.GetUsername()); err != nil {
 		return &authd.Empty{}, err
 	}
@@ -304,6 +555,97 @@ func (s Service) SetDefaultBrokerForUser(ctx context.Context, req *authd.SDBFURe
 	return &authd.Empty{}, nil
 }
 
+// CheckAccount returns whether the user must change their password, because it expired according to their broker.
+func (s Service) CheckAccount(ctx context.Context, req *authd.CARequest) (resp *authd.CAResponse, err error) {
+	defer decorate.OnError(&err, "can't check account of user %q", req.GetUsername())
+
+	if req.GetUsername() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no user name given")
+	}
+
+	// Like libpam does, the account check fails in the Linux audit log if the user must change their password.
+	defer func() {
+		brokerID, _ := s.userManager.BrokerForUser(req.GetUsername())
+		auditLog(ctx, linuxaudit.Record{
+			Type:    linuxaudit.UserAcct,
+			Op:      "PAM:accounting",
+			Account: req.GetUsername(),
+			Broker:  s.brokerName(brokerID),
+			Success: err == nil && !resp.GetPasswordExpired(),
+		})
+	}()
+
+	expired, err := s.userManager.PasswordExpired(req.GetUsername())
+	if errors.Is(err, users.NoDataFoundError{}) {
+		return nil, errmessages.ErrUserUnknown.Wrap(fmt.Errorf("user %q not found", req.GetUsername())).
+			WithMetadata(errmessages.MetadataUsername, req.GetUsername())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The user may log in without authenticating with authd, for example with an SSH key, in which case its broker is
+	// not queried: make sure that it still knows the user if its information expired.
+	if err := s.revalidateUser(ctx, req.GetUsername()); err != nil {
+		return nil, err
+	}
+
+	return &authd.CAResponse{PasswordExpired: expired}, nil
+}
+
+// revalidateUser checks with its broker that a stale user still exists, in which case its information is updated with
+// the one returned by the broker and is fresh again. The user is kept stale if the broker can't be reached.
+func (s Service) revalidateUser(ctx context.Context, username string) error {
+	stale, err := s.userManager.UserIsStale(username)
+	if err != nil || !stale {
+		return err
+	}
+
+	brokerID, err := s.userManager.BrokerForUser(username)
+	if err != nil {
+		return err
+	}
+	available := s.brokerManager.AvailableBrokers()
+	i := slices.IndexFunc(available, func(b *brokers.Broker) bool { return b.ID == brokerID })
+	if i == -1 || brokerID == brokers.LocalBrokerName {
+		log.Warningf(ctx, "Can't re-validate stale user %q: broker %q is not available", username, brokerID)
+		return nil
+	}
+	broker := available[i]
+
+	uInfo, err := broker.UserPreCheckInfo(ctx, username)
+	if err != nil {
+		log.Warningf(ctx, "Can't re-validate stale user %q: %v", username, err)
+		return nil
+	}
+	if uInfo.Name == "" {
+		return status.Errorf(codes.PermissionDenied, "user %q is not known by its broker anymore", username)
+	}
+	if !strings.EqualFold(uInfo.Name, username) {
+		log.Warningf(ctx, "Can't re-validate stale user %q: broker %q returned user %q", username, brokerID, uInfo.Name)
+		return nil
+	}
+
+	// The user is only fresh again once its information is the one of the broker, as on login.
+	uInfo.Shell = s.userManager.ResolveShell(uInfo.Shell, broker.Users.DefaultShell)
+	broker.Users.Apply(&uInfo)
+	if err := s.userManager.UpdateUser(ctx, uInfo, broker.ID); err != nil {
+		return err
+	}
+	return s.userManager.ConfirmUser(uInfo.Name)
+}
+
+// brokerFromSessionID returns the broker of the session, or an error to display if the session was ended because it was
+// pending for too long.
+func (s Service) brokerFromSessionID(sessionID string) (*brokers.Broker, error) {
+	broker, err := s.brokerManager.BrokerFromSessionID(sessionID)
+	if errors.Is(err, brokers.ErrSessionTimedOut) {
+		timedOutErr := errmessages.ErrSessionTimedOut.Wrap(errors.New("the authentication took too long, please try again"))
+		return nil, errmessages.NewToDisplayError(timedOutErr)
+	}
+	return broker, err
+}
+
 // EndSession asks the broker associated with the sessionID to end the session.
 func (s Service) EndSession(ctx context.Context, req *authd.ESRequest) (empty *authd.Empty, err error) {
 	defer decorate.OnError(&err, "could not abort session")
@@ -313,6 +655,7 @@ func (s Service) EndSession(ctx context.Context, req *authd.ESRequest) (empty *a
 		return nil, status.Error(codes.InvalidArgument, "no session id given")
 	}
 
+	s.loginSources.Delete(sessionID)
 	return &authd.Empty{}, s.brokerManager.EndSession(sessionID)
 }
 
@@ -376,3 +719,65 @@ func mapToUILayout(layout map[string]string) (r *authd.UILayout) {
 		Code:    &code,
 	}
 }
+
+// deniedResponse returns a response denying the authentication with the given message.
+func deniedResponse(message string) (*authd.IAResponse, error) {
+	msg, err := json.Marshal(map[string]string{"message": message})
+	if err != nil {
+		return nil, err
+	}
+	return &authd.IAResponse{
+		Access: auth.Denied,
+		Msg:    string(msg),
+	}, nil
+}
+
+// lockedError returns the error denying the authentication of a user locked by an administrator.
+func lockedError(username string) error {
+	lockedErr := errmessages.ErrUserLocked.Wrap(errors.New("this account is locked, please contact your administrator"))
+	return errmessages.NewToDisplayError(lockedErr.WithMetadata(errmessages.MetadataUsername, username))
+}
+
+// lockedOutError returns the error denying the authentication of a user locked out after too many failed attempts,
+// telling until when it's locked out, if it's not until an administrator unlocks it.
+func lockedOutError(username string, lockout types.Lockout) error {
+	lockedErr := errmessages.ErrUserLocked.Wrap(errors.New("this account is locked after too many failed authentication attempts, please contact your administrator"))
+	if !lockout.LockedUntil.IsZero() {
+		lockedErr = errmessages.ErrUserLocked.Wrap(fmt.Errorf("this account is locked after too many failed authentication attempts, please try again after %s",
+			lockout.LockedUntil.Format(time.DateTime)))
+		lockedErr = lockedErr.WithMetadata(errmessages.MetadataLockedUntil, lockout.LockedUntil.Format(time.RFC3339)).
+			WithRetryAfter(time.Until(lockout.LockedUntil))
+	}
+	return errmessages.NewToDisplayError(lockedErr.WithMetadata(errmessages.MetadataUsername, username))
+}
+
+// sourceLockedOutError returns the error denying the authentication of a user from a remote host locked out after too
+// many failed attempts of any user from it. The time it ends is only in the metadata, as it moves with the attempts.
+func sourceLockedOutError(username string, lockout types.Lockout) error {
+	lockedErr := errmessages.ErrUserLocked.Wrap(errors.New("too many failed authentication attempts from this location, please try again later"))
+	lockedErr = lockedErr.WithMetadata(errmessages.MetadataLockedUntil, lockout.LockedUntil.Format(time.RFC3339)).
+		WithRetryAfter(time.Until(lockout.LockedUntil))
+	return errmessages.NewToDisplayError(lockedErr.WithMetadata(errmessages.MetadataUsername, username))
+}
+
+// brokerName returns the name of the broker with the given ID, or the ID if it's not available.
+func (s Service) brokerName(id string) string {
+	for _, b := range s.brokerManager.AvailableBrokers() {
+		if b.ID == id {
+			return b.Name
+		}
+	}
+	return id
+}
+
+// auditLog records the authentication event in the Linux audit log, if it's available.
+func auditLog(ctx context.Context, r linuxaudit.Record) {
+	err := linuxaudit.Log(r)
+	if errors.Is(err, linuxaudit.ErrUnavailable) {
+		log.Debugf(ctx, "Not recording %q of user %q in the Linux audit log: %v", r.Op, r.Account, err)
+		return
+	}
+	if err != nil {
+		log.Warningf(ctx, "%v", err)
+	}
+}
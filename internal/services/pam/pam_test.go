@@ -17,8 +17,10 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/ubuntu/authd/internal/brokers"
 	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/authd/internal/brokers/challenge"
 	"github.com/ubuntu/authd/internal/brokers/layouts"
 	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/services/accesspolicy"
 	"github.com/ubuntu/authd/internal/services/errmessages"
 	"github.com/ubuntu/authd/internal/services/pam"
 	"github.com/ubuntu/authd/internal/services/permissions"
@@ -26,16 +28,21 @@ import (
 	"github.com/ubuntu/authd/internal/testutils/golden"
 	"github.com/ubuntu/authd/internal/users"
 	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/devicetokens"
 	"github.com/ubuntu/authd/internal/users/idgenerator"
 	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
 	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
 	"github.com/ubuntu/authd/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 var (
 	globalBrokerManager   *brokers.Manager
+	globalBrokersConfPath string
 	mockBrokerGeneratedID string
 )
 
@@ -74,7 +81,7 @@ func TestNewService(t *testing.T) {
 	require.NoError(t, err, "Setup: could not create user manager")
 
 	pm := permissions.New()
-	service := pam.NewService(context.Background(), m, globalBrokerManager, &pm)
+	service := pam.NewService(context.Background(), m, globalBrokerManager, &pm, accesspolicy.New(accesspolicy.Policy{}))
 
 	brokers, err := service.AvailableBrokers(context.Background(), &authd.Empty{})
 	require.NoError(t, err, "can’t create the service directly")
@@ -189,9 +196,10 @@ func TestSelectBroker(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
-		brokerID    string
-		username    string
-		sessionMode string
+		brokerID          string
+		username          string
+		sessionMode       string
+		encryptionVersion challenge.Version
 
 		currentUserNotRoot bool
 
@@ -200,6 +208,11 @@ func TestSelectBroker(t *testing.T) {
 		"Successfully_select_a_broker_and_creates_auth_session":   {username: "success", sessionMode: auth.SessionModeAuth},
 		"Successfully_select_a_broker_and_creates_passwd_session": {username: "success", sessionMode: auth.SessionModePasswd},
 
+		"Successfully_select_the_key_of_the_latest_encryption_version_supported_by_the_client": {
+			username: "NS_multiple_keys", encryptionVersion: challenge.LatestVersion,
+		},
+		"Successfully_select_the_RSA_key_for_a_client_not_negotiating_the_encryption_version": {username: "NS_multiple_keys"},
+
 		"Error_when_not_root":                             {username: "success", currentUserNotRoot: true, wantErr: true},
 		"Error_when_username_is_empty":                    {wantErr: true},
 		"Error_when_mode_is_empty":                        {sessionMode: "-", wantErr: true},
@@ -208,6 +221,9 @@ func TestSelectBroker(t *testing.T) {
 		"Error_when_broker_does_not_exist":                {username: "no broker", brokerID: "does not exist", wantErr: true},
 		"Error_when_broker_does_not_provide_a_session_ID": {username: "NS_no_id", wantErr: true},
 		"Error_when_starting_the_session":                 {username: "NS_error", wantErr: true},
+		"Error_when_broker_has_no_key_supported_by_the_client": {
+			username: "NS_only_x25519_keys", encryptionVersion: challenge.VersionRSA, wantErr: true,
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -238,9 +254,10 @@ func TestSelectBroker(t *testing.T) {
 			}
 
 			sbRequest := &authd.SBRequest{
-				BrokerId: tc.brokerID,
-				Username: tc.username,
-				Mode:     sessionMode,
+				BrokerId:          tc.brokerID,
+				Username:          tc.username,
+				Mode:              sessionMode,
+				EncryptionVersion: uint32(tc.encryptionVersion),
 			}
 			sbResp, err := client.SelectBroker(context.Background(), sbRequest)
 			if tc.wantErr {
@@ -257,6 +274,40 @@ func TestSelectBroker(t *testing.T) {
 	}
 }
 
+func TestSelectBrokerSessionLimits(t *testing.T) {
+	t.Parallel()
+
+	brokerManager, err := brokers.NewManager(context.Background(), globalBrokersConfPath, nil,
+		brokers.WithSessionLimits(brokers.SessionLimits{PerSource: 1}))
+	require.NoError(t, err, "Setup: could not create broker manager")
+	t.Cleanup(func() { _ = brokerManager.EndAllSessions() })
+	brokerID, err := getMockBrokerGeneratedID(brokerManager)
+	require.NoError(t, err, "Setup: could not get the ID of the mock broker")
+
+	pm := newPermissionManager(t, false)
+	client := newPamClient(t, nil, brokerManager, &pm)
+	selectBroker := func(username, rhost string) error {
+		_, err := client.SelectBroker(context.Background(), &authd.SBRequest{
+			BrokerId: brokerID,
+			Username: t.Name() + testutils.IDSeparator + username,
+			Mode:     authd.SessionMode_AUTH,
+			Rhost:    rhost,
+		})
+		return err
+	}
+
+	require.NoError(t, selectBroker("user1", "host1"), "Setup: could not start session")
+	require.NoError(t, selectBroker("user2", ""), "SelectBroker should not limit the local logins per source")
+	require.NoError(t, selectBroker("user3", "host2"), "SelectBroker should not limit the sessions from another source")
+
+	err = selectBroker("user4", "host1")
+	require.Error(t, err, "SelectBroker should return an error above the limit of the source")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err), "SelectBroker should tell the resources are exhausted")
+	e, ok := errmessages.FromError(err)
+	require.True(t, ok, "SelectBroker should return an error with a well-defined cause")
+	require.Equal(t, errmessages.ReasonRetryable, e.Reason, "SelectBroker should tell the client to retry later")
+}
+
 func TestGetAuthenticationModes(t *testing.T) {
 	t.Parallel()
 
@@ -421,13 +472,70 @@ func TestIsAuthenticated(t *testing.T) {
 		localGroupsFile    string
 		currentUserNotRoot bool
 
+		expiredPasswordAction string
+
+		lockoutDeny            uint32
+		lockoutSourceDeny      uint32
+		previousFailures       int
+		previousSourceFailures int
+		rhost                  string
+		lockedUser             bool
+
+		deviceTokenLifetime time.Duration
+		previousDeviceToken string
+
+		// accessPolicyHook is the hook of the access policy in the test data, if any.
+		accessPolicyHook string
+
+		wantFailedLogins int
+		wantDeviceToken  string
+
 		// There is no wantErr as it's stored in the golden file.
 	}{
-		"Successfully_authenticate":                           {username: "success"},
-		"Successfully_authenticate_if_first_call_is_canceled": {username: "IA_second_call", secondCall: true, cancelFirstCall: true},
-		"Denies_authentication_when_broker_times_out":         {username: "IA_timeout"},
-		"Update_existing_DB_on_success":                       {username: "success", existingDB: "cache-with-user.db"},
-		"Update_local_groups":                                 {username: "success_with_local_groups", localGroupsFile: "valid.group"},
+		"Successfully_authenticate":                               {username: "success"},
+		"Successfully_authenticate_if_first_call_is_canceled":     {username: "IA_second_call", secondCall: true, cancelFirstCall: true},
+		"Denies_authentication_when_broker_times_out":             {username: "IA_timeout"},
+		"Update_existing_DB_on_success":                           {username: "success", existingDB: "cache-with-user.db"},
+		"Update_local_groups":                                     {username: "success_with_local_groups", localGroupsFile: "valid.group"},
+		"Deny_authentication_of_user_provided_by_another_broker":  {username: "success", existingDB: "cache-with-user-of-other-broker.db"},
+		"Successfully_authenticate_user_with_expired_password":    {username: "success_with_expired_password"},
+		"Deny_authentication_of_user_with_expired_password":       {username: "success_with_expired_password", expiredPasswordAction: users.ExpiredPasswordActionDeny},
+		"Successfully_authenticate_user_with_extended_attributes": {username: "success_with_extended_attributes"},
+		"Rotate_the_encryption_key_on_retry":                      {username: "IA_retry_with_rotated_key"},
+		"Rotate_the_encryption_key_on_next":                       {username: "IA_next_with_rotated_key"},
+
+		// lockout
+		"Lock_out_user_after_too_many_failed_attempts":       {username: "IA_denied", lockoutDeny: 1, wantFailedLogins: 1},
+		"Deny_authentication_of_locked_out_user":             {username: "success", lockoutDeny: 1, previousFailures: 1, wantFailedLogins: 1},
+		"Count_failed_attempts_before_locking_out_user":      {username: "IA_denied", lockoutDeny: 3, previousFailures: 1, wantFailedLogins: 2},
+		"Reset_failed_attempts_on_successful_authentication": {username: "success", lockoutDeny: 3, previousFailures: 2},
+		"Deny_authentication_of_locked_user_without_broker":  {username: "IA_denied", lockedUser: true},
+		"Deny_authentication_from_locked_out_source":         {username: "success", lockoutSourceDeny: 2, previousSourceFailures: 2, rhost: "192.0.2.1"},
+		"Lock_out_source_after_too_many_failed_attempts":     {username: "IA_denied", lockoutDeny: 2, lockoutSourceDeny: 2, previousSourceFailures: 1, rhost: "192.0.2.1", wantFailedLogins: 1},
+		"Do_not_lock_out_source_if_source_lockout_is_unset":  {username: "success", lockoutDeny: 2, previousSourceFailures: 2, rhost: "192.0.2.1"},
+
+		// device tokens
+		"Store_the_device_token_issued_by_the_broker": {
+			username: "IA_granted_with_device_token", deviceTokenLifetime: time.Hour, wantDeviceToken: "BrokerMock-device-token",
+		},
+		"Ignore_the_device_token_if_device_tokens_are_disabled": {username: "IA_granted_with_device_token"},
+		"Present_the_device_token_to_skip_the_second_factor": {
+			username: "IA_next_without_device_token", deviceTokenLifetime: time.Hour, previousDeviceToken: "BrokerMock-device-token",
+			wantDeviceToken: "BrokerMock-device-token",
+		},
+		"Require_the_second_factor_without_device_token": {username: "IA_next_without_device_token", deviceTokenLifetime: time.Hour},
+		"Require_the_second_factor_with_unknown_device_token": {
+			username: "IA_next_without_device_token", deviceTokenLifetime: time.Hour, previousDeviceToken: "unknown-device-token",
+			wantDeviceToken: "unknown-device-token",
+		},
+
+		// access policy
+		"Deny_authentication_by_the_access_policy": {username: "success", accessPolicyHook: "deny-access-policy-hook"},
+		"Give_the_access_context_to_the_broker":    {username: "IA_retry_with_access_context", accessPolicyHook: "allow-access-policy-hook"},
+		"Require_the_second_factor_on_step_up_by_the_access_policy": {
+			username: "IA_next_without_device_token", deviceTokenLifetime: time.Hour, previousDeviceToken: "BrokerMock-device-token",
+			accessPolicyHook: "step-up-access-policy-hook", wantDeviceToken: "BrokerMock-device-token",
+		},
 
 		// service errors
 		"Error_when_not_root":           {username: "success", currentUserNotRoot: true},
@@ -441,6 +549,7 @@ func TestIsAuthenticated(t *testing.T) {
 		"Error_when_broker_returns_invalid_data":            {username: "IA_invalid_data"},
 		"Error_when_broker_returns_invalid_userinfo":        {username: "IA_invalid_userinfo"},
 		"Error_when_calling_second_time_without_cancelling": {username: "IA_second_call", secondCall: true},
+		"Error_when_broker_returns_invalid_device_token":    {username: "IA_granted_with_invalid_device_token", deviceTokenLifetime: time.Hour},
 
 		// local group error
 		"Error_on_updating_local_groups_with_unexisting_file": {username: "success_with_local_groups", localGroupsFile: "does_not_exists.group"},
@@ -451,9 +560,9 @@ func TestIsAuthenticated(t *testing.T) {
 				t.Parallel()
 			}
 
-			var destCmdsFile string
+			var groupsFile string
 			if tc.localGroupsFile != "" {
-				destCmdsFile = localgroupstestutils.SetupGPasswdMock(t, filepath.Join(testutils.TestFamilyPath(t), tc.localGroupsFile))
+				groupsFile = localgroupstestutils.SetupGroupMock(t, filepath.Join(testutils.TestFamilyPath(t), tc.localGroupsFile))
 			}
 
 			cacheDir := t.TempDir()
@@ -466,20 +575,53 @@ func TestIsAuthenticated(t *testing.T) {
 					UIDsToGenerate: []uint32{1111},
 					GIDsToGenerate: []uint32{1111, 2222},
 				}),
+				users.WithDeviceTokenKeyring(devicetokens.Z_ForTests_NewKeyring(t)),
 			}
 
-			m, err := users.NewManager(users.DefaultConfig, cacheDir, managerOpts...)
+			config := users.DefaultConfig
+			if tc.expiredPasswordAction != "" {
+				config.ExpiredPasswordAction = tc.expiredPasswordAction
+			}
+			config.LockoutDeny = tc.lockoutDeny
+			config.LockoutSourceDeny = tc.lockoutSourceDeny
+			// Lock out the users until they are unlocked, for the message not to depend on the time.
+			config.LockoutUnlockTime = 0
+			config.DeviceTokenLifetime = tc.deviceTokenLifetime
+			m, err := users.NewManager(config, cacheDir, managerOpts...)
 			require.NoError(t, err, "Setup: could not create user manager")
 			t.Cleanup(func() { _ = m.Stop() })
+
+			// The users are prefixed by the test name by startSession.
+			username := t.Name() + testutils.IDSeparator + tc.username
+			for range tc.previousFailures {
+				m.RecordFailedLogin(username, "", "")
+			}
+			// The failed attempts of other users from the same source.
+			for i := range tc.previousSourceFailures {
+				m.RecordFailedLogin(fmt.Sprintf("%s-other%d", username, i), "", tc.rhost)
+			}
+			if tc.lockedUser {
+				err := m.UpdateUser(context.Background(), types.UserInfo{Name: username, Dir: "/home/" + username, Shell: "/bin/sh"}, mockBrokerGeneratedID)
+				require.NoError(t, err, "Setup: could not create the user")
+				require.NoError(t, m.LockUser(username, "test"), "Setup: could not lock the user")
+			}
+			if tc.previousDeviceToken != "" {
+				err := m.SetDeviceToken(mockBrokerGeneratedID, username, tc.previousDeviceToken)
+				require.NoError(t, err, "Setup: could not store the device token")
+			}
+			var policy accesspolicy.Policy
+			if tc.accessPolicyHook != "" {
+				policy.Hook = filepath.Join(testutils.TestFamilyPath(t), tc.accessPolicyHook)
+			}
 			pm := newPermissionManager(t, false) // Allow starting the session (current user considered root)
-			client := newPamClient(t, m, globalBrokerManager, &pm)
+			client := newPamClientWithAccessPolicy(t, m, globalBrokerManager, &pm, policy)
 
 			switch tc.sessionID {
 			case "invalid-session":
 			case "-":
 				tc.sessionID = ""
 			default:
-				id := startSession(t, client, tc.username)
+				id := startSessionFrom(t, client, tc.username, tc.rhost)
 				if tc.sessionID == "" {
 					tc.sessionID = id
 				}
@@ -505,6 +647,9 @@ func TestIsAuthenticated(t *testing.T) {
 					iaResp.GetMsg(),
 					err,
 				)
+				if key := iaResp.GetEncryptionKey(); key != "" {
+					firstCall += fmt.Sprintf("\tencryption key: %s\n", key)
+				}
 			}()
 			// Give some time for the first call to block
 			time.Sleep(time.Second)
@@ -537,7 +682,10 @@ func TestIsAuthenticated(t *testing.T) {
 			require.NoError(t, err, "Setup: failed to dump database for comparing")
 			golden.CheckOrUpdate(t, gotDB, golden.WithPath("cache.db"))
 
-			localgroupstestutils.RequireGPasswdOutput(t, destCmdsFile, filepath.Join(golden.Path(t), "gpasswd.output"))
+			localgroupstestutils.RequireGroupFile(t, groupsFile, filepath.Join(golden.Path(t), "group"))
+
+			require.Len(t, m.Lockout(username).FailedLogins, tc.wantFailedLogins, "The failed attempts of the user should be recorded")
+			require.Equal(t, tc.wantDeviceToken, string(m.DeviceToken(mockBrokerGeneratedID, username).Bytes()), "The device token of the user should be stored")
 		})
 	}
 }
@@ -596,14 +744,14 @@ func TestSetDefaultBrokerForUser(t *testing.T) {
 
 		wantErr bool
 	}{
-		"Set_default_broker_for_existing_user_with_no_broker":   {username: "usersetbroker"},
-		"Update_default_broker_for_existing_user_with_a_broker": {username: "userupdatebroker"},
+		"Set_default_broker_for_existing_user_with_no_broker": {username: "usersetbroker"},
 
 		"Error_when_setting_default_broker_to_local_broker": {username: "userlocalbroker", brokerID: brokers.LocalBrokerName, wantErr: true},
 		"Error_when_not_root":                               {username: "usersetbroker", currentUserNotRoot: true, wantErr: true},
 		"Error_when_username_is_empty":                      {wantErr: true},
 		"Error_when_user_does_not_exist":                    {username: "doesnotexist", wantErr: true},
-		"Error_when_broker_does_not_exist":                  {username: "userwithbroker", brokerID: "does not exist", wantErr: true},
+		"Error_when_broker_does_not_exist":                  {username: "usersetbroker", brokerID: "does not exist", wantErr: true},
+		"Error_when_user_is_provided_by_another_broker":     {username: "userupdatebroker", wantErr: true},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -645,6 +793,92 @@ func TestSetDefaultBrokerForUser(t *testing.T) {
 	}
 }
 
+func TestCheckAccount(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username           string
+		currentUserNotRoot bool
+
+		wantPasswordExpired bool
+		wantErr             bool
+	}{
+		"Password_of_user_expired":                 {username: "userexpired", wantPasswordExpired: true},
+		"Password_of_user_did_not_expire_yet":      {username: "usernotexpired"},
+		"Password_of_user_without_expiration_date": {username: "usernoexpiry"},
+
+		"Error_when_not_root":            {username: "userexpired", currentUserNotRoot: true, wantErr: true},
+		"Error_when_username_is_empty":   {wantErr: true},
+		"Error_when_user_does_not_exist": {username: "doesnotexist", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join(testutils.TestFamilyPath(t), "check-account.db"), cacheDir)
+
+			m, err := users.NewManager(users.DefaultConfig, cacheDir)
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+			pm := newPermissionManager(t, tc.currentUserNotRoot)
+			client := newPamClient(t, m, globalBrokerManager, &pm)
+
+			resp, err := client.CheckAccount(context.Background(), &authd.CARequest{Username: tc.username})
+			if tc.wantErr {
+				require.Error(t, err, "CheckAccount should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "CheckAccount should not return an error, but did")
+			require.Equal(t, tc.wantPasswordExpired, resp.GetPasswordExpired(), "CheckAccount did not return the expected password expiration")
+		})
+	}
+}
+
+func TestCheckAccountRevalidatesStaleUser(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username string
+
+		wantGecos string
+		wantStale bool
+	}{
+		"Update_stale_user_with_information_of_broker": {username: "user-pre-check", wantGecos: "gecos for user-pre-check"},
+		"Keep_user_stale_if_broker_can_not_check_it":   {username: "userunknown", wantGecos: "old gecos", wantStale: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cacheDir := t.TempDir()
+			d, err := os.ReadFile(filepath.Join(testutils.TestFamilyPath(t), "stale-users.db"))
+			require.NoError(t, err, "Setup: could not read fixture database file")
+			d = bytes.ReplaceAll(d, []byte("MOCKBROKERID"), []byte(mockBrokerGeneratedID))
+			err = cache.Z_ForTests_FromYAML(bytes.NewBuffer(d), cacheDir)
+			require.NoError(t, err, "Setup: could not prepare cache database file")
+
+			config := users.DefaultConfig
+			config.UserInfoTTL = time.Hour
+			m, err := users.NewManager(config, cacheDir)
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+			pm := newPermissionManager(t, false)
+			client := newPamClient(t, m, globalBrokerManager, &pm)
+
+			_, err = client.CheckAccount(context.Background(), &authd.CARequest{Username: tc.username})
+			require.NoError(t, err, "CheckAccount should not return an error, but did")
+
+			u, err := m.UserByName(tc.username)
+			require.NoError(t, err, "User should still exist")
+			require.Equal(t, tc.wantGecos, u.Gecos, "User should have the expected information")
+			stale, err := m.UserIsStale(tc.username)
+			require.NoError(t, err, "UserIsStale should not return an error")
+			require.Equal(t, tc.wantStale, stale, "User should only be fresh again if it was updated")
+		})
+	}
+}
+
 func TestEndSession(t *testing.T) {
 	t.Parallel()
 
@@ -697,10 +931,6 @@ func TestEndSession(t *testing.T) {
 	}
 }
 
-func TestMockgpasswd(t *testing.T) {
-	localgroupstestutils.Mockgpasswd(t)
-}
-
 // initBrokers starts dbus mock brokers on the system bus. It returns its config path.
 func initBrokers() (brokerConfigPath string, cleanup func(), err error) {
 	tmpDir, err := os.MkdirTemp("", "authd-internal-pam-tests-")
@@ -731,6 +961,14 @@ func initBrokers() (brokerConfigPath string, cleanup func(), err error) {
 func newPamClient(t *testing.T, m *users.Manager, brokerManager *brokers.Manager, pm *permissions.Manager) (client authd.PAMClient) {
 	t.Helper()
 
+	return newPamClientWithAccessPolicy(t, m, brokerManager, pm, accesspolicy.Policy{})
+}
+
+// newPamClientWithAccessPolicy returns a new GRPC PAM client like newPamClient, with the service evaluating the
+// access policy.
+func newPamClientWithAccessPolicy(t *testing.T, m *users.Manager, brokerManager *brokers.Manager, pm *permissions.Manager, policy accesspolicy.Policy) (client authd.PAMClient) {
+	t.Helper()
+
 	// socket path is limited in length.
 	tmpDir, err := os.MkdirTemp("", "authd-socket-dir")
 	require.NoError(t, err, "Setup: could not setup temporary socket dir path")
@@ -746,9 +984,9 @@ func newPamClient(t *testing.T, m *users.Manager, brokerManager *brokers.Manager
 		t.Cleanup(func() { _ = m.Stop() })
 	}
 
-	service := pam.NewService(context.Background(), m, brokerManager, pm)
+	service := pam.NewService(context.Background(), m, brokerManager, pm, accesspolicy.New(policy))
 
-	grpcServer := grpc.NewServer(permissions.WithUnixPeerCreds(), grpc.ChainUnaryInterceptor(enableCheckGlobalAccess(service), errmessages.RedactErrorInterceptor))
+	grpcServer := grpc.NewServer(permissions.New().UnixPeerCreds(), grpc.ChainUnaryInterceptor(enableCheckGlobalAccess(service), errmessages.RedactErrorInterceptor))
 	authd.RegisterPAMServer(grpcServer, service)
 	done := make(chan struct{})
 	go func() {
@@ -806,6 +1044,13 @@ func getMockBrokerGeneratedID(brokerManager *brokers.Manager) (string, error) {
 func startSession(t *testing.T, client authd.PAMClient, username string) string {
 	t.Helper()
 
+	return startSessionFrom(t, client, username, "")
+}
+
+// startSessionFrom is like startSession, with the user logging in from the remote host rhost if it's not empty.
+func startSessionFrom(t *testing.T, client authd.PAMClient, username, rhost string) string {
+	t.Helper()
+
 	// Prefixes the username to avoid concurrency issues.
 	username = t.Name() + testutils.IDSeparator + username
 
@@ -813,6 +1058,7 @@ func startSession(t *testing.T, client authd.PAMClient, username string) string
 		BrokerId: mockBrokerGeneratedID,
 		Username: username,
 		Mode:     authd.SessionMode_AUTH,
+		Rhost:    rhost,
 	})
 	require.NoError(t, err, "Setup: failed to create session for tests")
 	return sbResp.GetSessionId()
@@ -841,6 +1087,7 @@ func setupGlobalBrokerMock() (cleanup func(), err error) {
 	}
 
 	// Get manager shared across grpc services.
+	globalBrokersConfPath = brokersConfPath
 	globalBrokerManager, err = brokers.NewManager(context.Background(), brokersConfPath, nil)
 	if err != nil {
 		return cleanup, err
@@ -854,10 +1101,6 @@ func setupGlobalBrokerMock() (cleanup func(), err error) {
 }
 
 func TestMain(m *testing.M) {
-	if os.Getenv("GO_WANT_HELPER_PROCESS") != "" {
-		os.Exit(m.Run())
-	}
-
 	log.SetLevel(log.DebugLevel)
 
 	cleanup, err := setupGlobalBrokerMock()
@@ -2,7 +2,8 @@ package pam
 
 import "context"
 
-// CheckGlobalAccess denies all requests not coming from the root user.
+// CheckGlobalAccess denies all requests not coming from the root user, or from a user granted by the authorization
+// policy.
 func (s Service) CheckGlobalAccess(ctx context.Context, method string) error {
-	return s.permissionManager.IsRequestFromRoot(ctx)
+	return s.permissionManager.IsRequestAllowed(ctx, method)
 }
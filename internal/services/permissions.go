@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func (m Manager) globalPermissions(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
@@ -16,7 +18,22 @@ func (m Manager) globalPermissions(ctx context.Context, req interface{}, info *g
 		if err := m.nssService.CheckGlobalAccess(ctx, info.FullMethod); err != nil {
 			return nil, err
 		}
+	} else if strings.HasPrefix(info.FullMethod, "/authd.UserService/") {
+		if err := m.userService.CheckGlobalAccess(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
 	}
 
 	return handler(ctx, req)
 }
+
+// reflectionPermissions restricts the gRPC server reflection, which is the only streaming service, to root.
+func (m Manager) reflectionPermissions(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if strings.HasPrefix(info.FullMethod, "/grpc.reflection.") {
+		if err := m.permissionManager.IsRequestFromRoot(ss.Context()); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+
+	return handler(srv, ss)
+}
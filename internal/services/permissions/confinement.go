@@ -0,0 +1,56 @@
+package permissions
+
+import (
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// snapLabelPrefix is the prefix of the AppArmor labels of the processes confined in a snap, which are like
+// "snap.firefox.firefox (enforce)" for the firefox application of the firefox snap.
+const snapLabelPrefix = "snap."
+
+// peerLabelSize is the size of the buffer in which the security label is read first. The kernel tells the size of the
+// label if it's longer, like with the stacked LSMs.
+const peerLabelSize = 256
+
+// peerLabel returns the security label of the peer of the socket fd, like its AppArmor profile, reading it in a buffer
+// of size bytes first. It's empty if no LSM labels the processes.
+func peerLabel(fd, size int) (string, error) {
+	for {
+		buf := make([]byte, size)
+		n := uint32(size)
+		_, _, errno := unix.Syscall6(unix.SYS_GETSOCKOPT, uintptr(fd), unix.SOL_SOCKET, unix.SO_PEERSEC,
+			uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&n)), 0)
+		if errno == unix.ERANGE && int(n) > size {
+			size = int(n)
+			continue
+		}
+		if errno == unix.ENOPROTOOPT {
+			return "", nil
+		}
+		if errno != 0 {
+			return "", errno
+		}
+		return strings.TrimRight(string(buf[:n]), "\x00"), nil
+	}
+}
+
+// snapFromLabel returns the name of the snap, or of its instance, in which the process with the AppArmor label is
+// confined. It's empty if the process is not confined in a snap, like the processes of the classic snaps, which run
+// unconfined.
+func snapFromLabel(label string) string {
+	// The mode of the profile, like "(enforce)", follows the name of the profile.
+	profile, _, _ := strings.Cut(label, " ")
+	rest, ok := strings.CutPrefix(profile, snapLabelPrefix)
+	if !ok {
+		return ""
+	}
+	// The name of the snap is followed by the one of its application or hook.
+	name, _, ok := strings.Cut(rest, ".")
+	if !ok {
+		return ""
+	}
+	return name
+}
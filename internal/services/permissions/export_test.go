@@ -7,6 +7,11 @@ func NewTestPeerCredsInfo(uid uint32, pid int32) PeerCredsInfo {
 	return PeerCredsInfo{uid: uid, pid: pid}
 }
 
+//nolint:revive // This is a false positive as we returned a typed alias and not the private type.
+func NewTestSnapPeerCredsInfo(uid uint32, pid int32, snap string) PeerCredsInfo {
+	return PeerCredsInfo{uid: uid, pid: pid, snap: snap}
+}
+
 var (
 	CurrentUserUID = currentUserUID
 )
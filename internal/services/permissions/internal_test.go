@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
 	"google.golang.org/grpc/credentials"
 )
 
@@ -24,58 +25,113 @@ func TestPeerCredsInfoAuthType(t *testing.T) {
 	require.Equal(t, "uid: 11111, pid: 22222", p.AuthType(), "AuthType returns expected uid and pid")
 }
 
+func TestSnapFromLabel(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		label string
+
+		want string
+	}{
+		"Snap_of_application":         {label: "snap.firefox.firefox (enforce)", want: "firefox"},
+		"Snap_of_hook":                {label: "snap.greeter.hook.configure (enforce)", want: "greeter"},
+		"Snap_in_complain_mode":       {label: "snap.greeter.daemon (complain)", want: "greeter"},
+		"Instance_of_snap":            {label: "snap.greeter_test.daemon (enforce)", want: "greeter_test"},
+		"No_snap_when_unconfined":     {label: "unconfined"},
+		"No_snap_without_label":       {label: ""},
+		"No_snap_for_other_profile":   {label: "/usr/sbin/cupsd (enforce)"},
+		"No_snap_without_application": {label: "snap.greeter (enforce)"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.want, snapFromLabel(tc.label), "snapFromLabel should return the expected snap")
+		})
+	}
+}
+
 func TestServerPeerCredsHandshake(t *testing.T) {
 	t.Parallel()
 
-	s := serverPeerCreds{}
+	tests := map[string]struct {
+		readLabel bool
+	}{
+		"Handshake_without_reading_the_label": {},
+		"Handshake_reading_the_label":         {readLabel: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := serverPeerCreds{readLabel: tc.readLabel}
+
+			socket := filepath.Join(t.TempDir(), "authd.sock")
+			l, err := net.Listen("unix", socket)
+			require.NoError(t, err, "couldn't listen on socket")
+			defer l.Close()
+
+			wg := sync.WaitGroup{}
+			wg.Add(1)
+			var clientErr error
+			go func() {
+				defer wg.Done()
+				unixAddr, err := net.ResolveUnixAddr("unix", socket)
+				if err != nil {
+					clientErr = fmt.Errorf("Couldn't resolve client socket address: %w", err)
+					return
+				}
+				conn, err := net.DialUnix("unix", nil, unixAddr)
+				if err != nil {
+					clientErr = fmt.Errorf("Couldn't contact unix socket: %w", err)
+					return
+				}
+				defer conn.Close()
+			}()
+
+			conn, err := l.Accept()
+			require.NoError(t, err, "Should accept connexion from client")
+
+			// ServerHandshake status check.
+			c, i, err := s.ServerHandshake(conn)
+
+			require.NoError(t, err, "ServerHandshake should not fail")
+			require.Equal(t, conn, c, "Connexion should match given connection")
+			uid := currentUserUID()
+			require.Equal(t, fmt.Sprintf("uid: %d, pid: %d", uid, os.Getpid()),
+				i.AuthType(), "uid or pid received doesn't match what we expected")
+
+			// ClientHandshake status check.
+			c, i, err = s.ClientHandshake(context.Background(), "unused", conn)
+
+			require.NoError(t, err, "ClientHandshake should not fail")
+			require.Equal(t, conn, c, "Connexion should match given connection")
+			require.Nil(t, i, "No authInfo should be returned")
+
+			err = l.Close()
+			require.NoError(t, err, "Teardown: should close listener successfully")
+			wg.Wait()
+
+			require.NoError(t, clientErr, "Client should not return an error")
+		})
+	}
+}
+
+func TestPeerLabel(t *testing.T) {
+	t.Parallel()
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM, 0)
+	require.NoError(t, err, "Setup: could not create socket pair")
+	defer unix.Close(fds[0])
+	defer unix.Close(fds[1])
+
+	want, err := peerLabel(fds[0], peerLabelSize)
+	require.NoError(t, err, "peerLabel should not return an error")
 
-	socket := filepath.Join(t.TempDir(), "authd.sock")
-	l, err := net.Listen("unix", socket)
-	require.NoError(t, err, "couldn't listen on socket")
-	defer l.Close()
-
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	var clientErr error
-	go func() {
-		defer wg.Done()
-		unixAddr, err := net.ResolveUnixAddr("unix", socket)
-		if err != nil {
-			clientErr = fmt.Errorf("Couldn't resolve client socket address: %w", err)
-			return
-		}
-		conn, err := net.DialUnix("unix", nil, unixAddr)
-		if err != nil {
-			clientErr = fmt.Errorf("Couldn't contact unix socket: %w", err)
-			return
-		}
-		defer conn.Close()
-	}()
-
-	conn, err := l.Accept()
-	require.NoError(t, err, "Should accept connexion from client")
-
-	// ServerHandshake status check.
-	c, i, err := s.ServerHandshake(conn)
-
-	require.NoError(t, err, "ServerHandshake should not fail")
-	require.Equal(t, conn, c, "Connexion should match given connection")
-	uid := currentUserUID()
-	require.Equal(t, fmt.Sprintf("uid: %d, pid: %d", uid, os.Getpid()),
-		i.AuthType(), "uid or pid received doesn't match what we expected")
-
-	// ClientHandshake status check.
-	c, i, err = s.ClientHandshake(context.Background(), "unused", conn)
-
-	require.NoError(t, err, "ClientHandshake should not fail")
-	require.Equal(t, conn, c, "Connexion should match given connection")
-	require.Nil(t, i, "No authInfo should be returned")
-
-	err = l.Close()
-	require.NoError(t, err, "Teardown: should close listener successfully")
-	wg.Wait()
-
-	require.NoError(t, clientErr, "Client should not return an error")
+	// The label is read again in a larger buffer if it doesn't fit.
+	got, err := peerLabel(fds[0], 1)
+	require.NoError(t, err, "peerLabel should not return an error when the buffer is too small")
+	require.Equal(t, want, got, "peerLabel should return the whole label when the buffer is too small")
 }
 
 func TestServerPeerCredsInvalidSocket(t *testing.T) {
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/ubuntu/decorate"
 	"google.golang.org/grpc/peer"
@@ -12,13 +13,24 @@ import (
 
 var permErrorFmt = "this action is only allowed for root users. Current user is %d"
 
+var remotePermErrorFmt = "this action is not allowed for remote client %q"
+
+var snapPermErrorFmt = "this action is not allowed for snap %q"
+
 // Manager is an abstraction of permission process.
 type Manager struct {
 	rootUID uint32
+	// confineSnaps restricts the processes confined in a snap to what the policy grants their snap.
+	confineSnaps bool
+
+	// policy is shared by the copies of the manager, so that it can be changed for all of them.
+	policy *atomic.Pointer[Policy]
 }
 
 type options struct {
-	rootUID uint32
+	rootUID      uint32
+	policy       Policy
+	confineSnaps bool
 }
 
 var defaultOptions = options{
@@ -28,6 +40,21 @@ var defaultOptions = options{
 // Option represents an optional function to override Manager default values.
 type Option func(*options)
 
+// WithPolicy grants the access to some of the methods restricted to root to other users.
+func WithPolicy(policy Policy) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// WithSnapConfinement restricts the processes confined in a snap to what the policy grants their snap, even if they
+// run as root. Otherwise, they are allowed what their user is, like the processes which are not confined.
+func WithSnapConfinement() Option {
+	return func(o *options) {
+		o.confineSnaps = true
+	}
+}
+
 // New returns a new Manager.
 func New(args ...Option) Manager {
 	opts := defaultOptions
@@ -35,17 +62,32 @@ func New(args ...Option) Manager {
 		arg(&opts)
 	}
 
-	//nolint:gosimple // S1016 Those structs are not the same conceptually.
-	return Manager{
-		rootUID: opts.rootUID,
+	m := Manager{
+		rootUID:      opts.rootUID,
+		confineSnaps: opts.confineSnaps,
+		policy:       &atomic.Pointer[Policy]{},
 	}
+	m.policy.Store(&opts.policy)
+	return m
+}
+
+// SetPolicy changes the authorization policy of the next requests.
+func (m Manager) SetPolicy(policy Policy) {
+	m.policy.Store(&policy)
 }
 
-// IsRequestFromRoot returns nil if the request was performed by a root user.
+// IsRequestFromRoot returns nil if the request was performed by a root user, or by a remote client allowed what root is.
 // The pid and uid are extracted from peerCredsInfo in the gRPC context.
 func (m Manager) IsRequestFromRoot(ctx context.Context) (err error) {
 	defer decorate.OnError(&err, "permission denied")
 
+	if name, isRoot, ok := RemoteClient(ctx); ok {
+		if !isRoot {
+			return fmt.Errorf(remotePermErrorFmt, name)
+		}
+		return nil
+	}
+
 	p, ok := peer.FromContext(ctx)
 	if !ok {
 		return errors.New("context request doesn't have gRPC peer information")
@@ -58,6 +100,107 @@ func (m Manager) IsRequestFromRoot(ctx context.Context) (err error) {
 	if pci.uid != m.rootUID {
 		return fmt.Errorf(permErrorFmt, pci.uid)
 	}
+	// The processes confined in a snap are restricted by its confinement, even if they run as root.
+	if snap := m.snapOf(pci); snap != "" {
+		return fmt.Errorf(snapPermErrorFmt, snap)
+	}
 
 	return nil
 }
+
+// IsRequestAllowed returns nil if the request was performed by a root user, or by a user granted the method, as the
+// full gRPC method name, by the authorization policy.
+func (m Manager) IsRequestAllowed(ctx context.Context, method string) (err error) {
+	defer decorate.OnError(&err, "permission denied")
+
+	if name, isRoot, ok := RemoteClient(ctx); ok {
+		if isRoot {
+			return nil
+		}
+		if m.policy != nil {
+			if p := m.policy.Load(); p != nil && p.grantsClient(method, name) {
+				return nil
+			}
+		}
+		return fmt.Errorf(remotePermErrorFmt, name)
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return errors.New("context request doesn't have gRPC peer information")
+	}
+	pci, ok := p.AuthInfo.(peerCredsInfo)
+	if !ok {
+		return errors.New("context request doesn't have valid gRPC peer credential information")
+	}
+	// The processes confined in a snap are restricted by its confinement, even if they run as root, so they are only
+	// allowed what the policy grants to their snap.
+	snap := m.snapOf(pci)
+	if pci.uid == m.rootUID && snap == "" {
+		return nil
+	}
+
+	if m.policy != nil {
+		if p := m.policy.Load(); p != nil && p.grants(method, pci.uid, snap) {
+			return nil
+		}
+	}
+
+	if snap != "" {
+		return fmt.Errorf(snapPermErrorFmt, snap)
+	}
+	return fmt.Errorf(permErrorFmt, pci.uid)
+}
+
+// snapOf returns the snap the caller is confined in, if the snaps are confined by the manager.
+func (m Manager) snapOf(pci peerCredsInfo) string {
+	if !m.confineSnaps {
+		return ""
+	}
+	return pci.snap
+}
+
+// IsUserRoot returns nil if the user with this UID is root. It checks the callers which are not gRPC peers, like the
+// D-Bus ones.
+func (m Manager) IsUserRoot(uid uint32) error {
+	if uid != m.rootUID {
+		return fmt.Errorf("permission denied: "+permErrorFmt, uid)
+	}
+	return nil
+}
+
+// PeerCredentials returns the UID and the PID of the peer which performed the request, extracted from peerCredsInfo in
+// the gRPC context.
+func PeerCredentials(ctx context.Context) (uid uint32, pid int32, err error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return 0, 0, errors.New("context request doesn't have gRPC peer information")
+	}
+	pci, ok := p.AuthInfo.(peerCredsInfo)
+	if !ok {
+		return 0, 0, errors.New("context request doesn't have valid gRPC peer credential information")
+	}
+	return pci.uid, pci.pid, nil
+}
+
+// Caller returns a description of the peer which performed the request, extracted from peerCredsInfo in the gRPC
+// context.
+func Caller(ctx context.Context) string {
+	if name, _, ok := RemoteClient(ctx); ok {
+		return fmt.Sprintf("remote client %q", name)
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown"
+	}
+	pci, ok := p.AuthInfo.(peerCredsInfo)
+	if !ok {
+		return "unknown"
+	}
+
+	if pci.snap != "" {
+		return fmt.Sprintf("UID %d (PID %d) in snap %q", pci.uid, pci.pid, pci.snap)
+	}
+	return fmt.Sprintf("UID %d (PID %d)", pci.uid, pci.pid)
+}
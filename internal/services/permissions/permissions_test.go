@@ -26,16 +26,20 @@ func TestIsRequestFromRoot(t *testing.T) {
 
 	tests := map[string]struct {
 		currentUserNotRoot bool
+		snap               string
+		noSnapConfinement  bool
 		noPeerCredsInfo    bool
 		noAuthInfo         bool
 
 		wantErr bool
 	}{
-		"Granted_if_current_user_considered_as_root": {},
+		"Granted_if_current_user_considered_as_root":  {},
+		"Granted_to_root_in_snap_without_confinement": {snap: "greeter", noSnapConfinement: true},
 
 		"Error_as_deny_when_current_user_is_not_root": {currentUserNotRoot: true, wantErr: true},
 		"Error_as_deny_when_missing_peer_creds_Info":  {noPeerCredsInfo: true, wantErr: true},
 		"Error_as_deny_when_missing_auth_info_creds":  {noAuthInfo: true, wantErr: true},
+		"Error_as_deny_when_root_is_confined_in_snap": {snap: "greeter", wantErr: true},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -52,7 +56,7 @@ func TestIsRequestFromRoot(t *testing.T) {
 						t.Fatalf("Setup: pid is too large to be converted to int32: %d", pid)
 					}
 					//nolint:gosec // we did check the conversion check beforehand.
-					authInfo = permissions.NewTestPeerCredsInfo(uid, int32(os.Getpid()))
+					authInfo = permissions.NewTestSnapPeerCredsInfo(uid, int32(os.Getpid()), tc.snap)
 				}
 				p := peer.Peer{
 					AuthInfo: authInfo,
@@ -64,6 +68,9 @@ func TestIsRequestFromRoot(t *testing.T) {
 			if !tc.currentUserNotRoot {
 				opts = append(opts, permissions.Z_ForTests_WithCurrentUserAsRoot())
 			}
+			if !tc.noSnapConfinement {
+				opts = append(opts, permissions.WithSnapConfinement())
+			}
 			pm := permissions.New(opts...)
 
 			err := pm.IsRequestFromRoot(ctx)
@@ -77,10 +84,44 @@ func TestIsRequestFromRoot(t *testing.T) {
 	}
 }
 
-func TestWithUnixPeerCreds(t *testing.T) {
+func TestCaller(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		snap            string
+		noPeerCredsInfo bool
+		noAuthInfo      bool
+
+		want string
+	}{
+		"Describe_caller_from_peer_creds_info": {want: "UID 1234 (PID 5678)"},
+		"Describe_caller_confined_in_snap":     {snap: "greeter", want: `UID 1234 (PID 5678) in snap "greeter"`},
+
+		"Unknown_caller_when_missing_peer_creds_info": {noPeerCredsInfo: true, want: "unknown"},
+		"Unknown_caller_when_missing_auth_info_creds": {noAuthInfo: true, want: "unknown"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			if !tc.noPeerCredsInfo {
+				var authInfo credentials.AuthInfo
+				if !tc.noAuthInfo {
+					authInfo = permissions.NewTestSnapPeerCredsInfo(1234, 5678, tc.snap)
+				}
+				ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: authInfo})
+			}
+
+			require.Equal(t, tc.want, permissions.Caller(ctx), "Caller should return the expected description")
+		})
+	}
+}
+
+func TestUnixPeerCreds(t *testing.T) {
 	t.Parallel()
 
-	g := grpc.NewServer(permissions.WithUnixPeerCreds())
+	g := grpc.NewServer(permissions.New().UnixPeerCreds())
 
 	require.NotNil(t, g, "New gRPC with Unix Peer Creds is created")
 }
@@ -0,0 +1,132 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ubuntu/authd/log"
+)
+
+// Rule grants the access to some gRPC methods, otherwise only allowed for root, to other users.
+type Rule struct {
+	// Methods are the gRPC methods granted, like "authd.UserService/GetMetrics", or "authd.UserService/*" for all the
+	// methods of a service.
+	Methods []string
+	// UIDs are the users granted.
+	UIDs []uint32 `mapstructure:"uids"`
+	// Groups are the groups whose members are granted.
+	Groups []string
+	// Clients are the names of the remote clients granted, as in their TLS certificate.
+	Clients []string
+	// Snaps are the names of the snaps whose confined processes are granted, restricted to the ones of the users given
+	// in UIDs and Groups if any. With the snap confinement, the processes confined in a snap are only granted the
+	// methods by the rules listing their snap, even if they run as root.
+	Snaps []string
+}
+
+// Policy is the list of rules granting the access to the methods restricted to root. It can't restrict the methods
+// allowed to everyone.
+type Policy []Rule
+
+// Validate returns an error if a rule grants nothing or to nobody.
+func (p Policy) Validate() error {
+	for i, r := range p {
+		if len(r.Methods) == 0 {
+			return fmt.Errorf("rule %d: no methods given", i)
+		}
+		for _, method := range r.Methods {
+			service, name, ok := strings.Cut(method, "/")
+			if !ok || service == "" || name == "" || strings.Contains(name, "/") {
+				return fmt.Errorf("rule %d: invalid method %q, it must be like \"authd.UserService/GetMetrics\" or \"authd.UserService/*\"", i, method)
+			}
+		}
+		if len(r.UIDs) == 0 && len(r.Groups) == 0 && len(r.Clients) == 0 && len(r.Snaps) == 0 {
+			return fmt.Errorf("rule %d: no uids, groups, clients nor snaps given", i)
+		}
+	}
+	return nil
+}
+
+// grants returns whether a rule grants the method, as the full gRPC method name, to the user, confined in the snap if
+// not empty.
+func (p Policy) grants(method string, uid uint32, snap string) bool {
+	method = strings.TrimPrefix(method, "/")
+
+	// The groups of the user are only looked up if a rule needs them.
+	var gids []string
+	var gidsLoaded bool
+	for _, r := range p {
+		if !slices.ContainsFunc(r.Methods, func(m string) bool { return methodMatches(m, method) }) {
+			continue
+		}
+		// The rules listing snaps only apply to their processes, and the other ones to the processes not confined.
+		if snap != "" && !slices.Contains(r.Snaps, snap) || snap == "" && len(r.Snaps) > 0 {
+			continue
+		}
+		if snap != "" && len(r.UIDs) == 0 && len(r.Groups) == 0 {
+			return true
+		}
+		if slices.Contains(r.UIDs, uid) {
+			return true
+		}
+		if len(r.Groups) == 0 {
+			continue
+		}
+
+		if !gidsLoaded {
+			gids = userGroups(uid)
+			gidsLoaded = true
+		}
+		for _, name := range r.Groups {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				log.Debugf(context.Background(), "Ignoring group %q of the authorization policy: %v", name, err)
+				continue
+			}
+			if slices.Contains(gids, g.Gid) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// grantsClient returns whether a rule grants the method, as the full gRPC method name, to the remote client.
+func (p Policy) grantsClient(method, name string) bool {
+	method = strings.TrimPrefix(method, "/")
+
+	for _, r := range p {
+		if slices.ContainsFunc(r.Methods, func(m string) bool { return methodMatches(m, method) }) && slices.Contains(r.Clients, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// methodMatches returns whether the method of the policy, which can be all the ones of a service, matches the called
+// one.
+func methodMatches(policyMethod, method string) bool {
+	if service, ok := strings.CutSuffix(policyMethod, "/*"); ok {
+		return strings.HasPrefix(method, service+"/")
+	}
+	return policyMethod == method
+}
+
+// userGroups returns the GIDs of the groups of the user, or none if they can't be found.
+func userGroups(uid uint32) []string {
+	u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10))
+	if err != nil {
+		log.Debugf(context.Background(), "Could not find user of UID %d: %v", uid, err)
+		return nil
+	}
+	gids, err := u.GroupIds()
+	if err != nil {
+		log.Debugf(context.Background(), "Could not find groups of user %q: %v", u.Username, err)
+		return nil
+	}
+	return gids
+}
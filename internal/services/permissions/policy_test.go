@@ -0,0 +1,129 @@
+package permissions_test
+
+import (
+	"context"
+	"os/user"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/services/permissions"
+	"google.golang.org/grpc/peer"
+)
+
+func TestIsRequestAllowed(t *testing.T) {
+	t.Parallel()
+
+	uid := permissions.CurrentUserUID()
+	u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10))
+	require.NoError(t, err, "Setup: could not find current user")
+	g, err := user.LookupGroupId(u.Gid)
+	require.NoError(t, err, "Setup: could not find group of current user")
+
+	const method = "/authd.UserService/GetMetrics"
+
+	tests := map[string]struct {
+		currentUserAsRoot bool
+		snap              string
+		noSnapConfinement bool
+		policy            permissions.Policy
+		newPolicy         permissions.Policy
+
+		wantErr bool
+	}{
+		"Granted_if_current_user_considered_as_root": {currentUserAsRoot: true},
+		"Granted_by_uid":     {policy: permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, UIDs: []uint32{uid}}}},
+		"Granted_by_group":   {policy: permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, Groups: []string{g.Name}}}},
+		"Granted_by_service": {policy: permissions.Policy{{Methods: []string{"authd.UserService/*"}, UIDs: []uint32{uid}}}},
+		"Granted_by_any_rule": {policy: permissions.Policy{
+			{Methods: []string{"authd.UserService/GetMetrics"}, UIDs: []uint32{uid + 1}},
+			{Methods: []string{"authd.PAM/*", "authd.UserService/GetMetrics"}, Groups: []string{"doesnotexist", g.Name}},
+		}},
+		"Granted_by_changed_policy": {newPolicy: permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, UIDs: []uint32{uid}}}},
+		"Granted_by_snap":           {snap: "greeter", policy: permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, Snaps: []string{"greeter"}}}},
+		"Granted_by_snap_and_uid":   {snap: "greeter", policy: permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, UIDs: []uint32{uid}, Snaps: []string{"greeter"}}}},
+		"Granted_by_snap_to_root":   {currentUserAsRoot: true, snap: "greeter", policy: permissions.Policy{{Methods: []string{"authd.UserService/*"}, Snaps: []string{"greeter"}}}},
+
+		"Granted_to_root_in_snap_without_confinement": {currentUserAsRoot: true, snap: "greeter", noSnapConfinement: true},
+		"Granted_by_uid_in_snap_without_confinement":  {snap: "greeter", noSnapConfinement: true, policy: permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, UIDs: []uint32{uid}}}},
+
+		"Error_as_deny_without_policy":              {wantErr: true},
+		"Error_as_deny_when_uid_is_not_granted":     {policy: permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, UIDs: []uint32{uid + 1}}}, wantErr: true},
+		"Error_as_deny_when_method_is_not_granted":  {policy: permissions.Policy{{Methods: []string{"authd.UserService/ListUsers"}, UIDs: []uint32{uid}}}, wantErr: true},
+		"Error_as_deny_when_service_is_not_granted": {policy: permissions.Policy{{Methods: []string{"authd.User/*"}, UIDs: []uint32{uid}}}, wantErr: true},
+		"Error_as_deny_when_group_does_not_exist":   {policy: permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, Groups: []string{"doesnotexist"}}}, wantErr: true},
+
+		"Error_as_deny_when_root_is_confined_in_snap":       {currentUserAsRoot: true, snap: "greeter", wantErr: true},
+		"Error_as_deny_when_snap_is_not_granted":            {snap: "other", policy: permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, Snaps: []string{"greeter"}}}, wantErr: true},
+		"Error_as_deny_when_uid_is_granted_outside_of_snap": {snap: "greeter", policy: permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, UIDs: []uint32{uid}}}, wantErr: true},
+		"Error_as_deny_when_uid_is_not_granted_in_snap":     {snap: "greeter", policy: permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, UIDs: []uint32{uid + 1}, Snaps: []string{"greeter"}}}, wantErr: true},
+		"Error_as_deny_when_uid_is_only_granted_in_snap":    {policy: permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, UIDs: []uint32{uid}, Snaps: []string{"greeter"}}}, wantErr: true},
+		"Error_as_deny_when_policy_is_removed": {
+			policy:    permissions.Policy{{Methods: []string{"authd.UserService/GetMetrics"}, UIDs: []uint32{uid}}},
+			newPolicy: permissions.Policy{},
+			wantErr:   true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: permissions.NewTestSnapPeerCredsInfo(uid, 1234, tc.snap)})
+
+			opts := []permissions.Option{permissions.WithPolicy(tc.policy)}
+			if tc.currentUserAsRoot {
+				opts = append(opts, permissions.Z_ForTests_WithCurrentUserAsRoot())
+			}
+			if !tc.noSnapConfinement {
+				opts = append(opts, permissions.WithSnapConfinement())
+			}
+			pm := permissions.New(opts...)
+			if tc.newPolicy != nil {
+				pm.SetPolicy(tc.newPolicy)
+			}
+
+			err := pm.IsRequestAllowed(ctx, method)
+			if tc.wantErr {
+				require.Error(t, err, "IsRequestAllowed should deny access but didn't")
+				return
+			}
+			require.NoError(t, err, "IsRequestAllowed should allow access but didn't")
+		})
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		policy permissions.Policy
+
+		wantErr bool
+	}{
+		"Empty_policy_is_valid": {},
+		"Policy_is_valid": {policy: permissions.Policy{
+			{Methods: []string{"authd.UserService/GetMetrics", "authd.NSS/*"}, UIDs: []uint32{998}},
+			{Methods: []string{"authd.UserService/ListUsers"}, Groups: []string{"monitoring"}},
+			{Methods: []string{"authd.PAM/*"}, Clients: []string{"thin-client"}},
+			{Methods: []string{"authd.PAM/*"}, Snaps: []string{"greeter"}},
+		}},
+
+		"Error_on_rule_without_methods":          {policy: permissions.Policy{{UIDs: []uint32{998}}}, wantErr: true},
+		"Error_on_rule_without_users_nor_groups": {policy: permissions.Policy{{Methods: []string{"authd.NSS/*"}}}, wantErr: true},
+		"Error_on_method_without_service":        {policy: permissions.Policy{{Methods: []string{"GetMetrics"}, UIDs: []uint32{998}}}, wantErr: true},
+		"Error_on_method_with_empty_name":        {policy: permissions.Policy{{Methods: []string{"authd.NSS/"}, UIDs: []uint32{998}}}, wantErr: true},
+		"Error_on_method_with_leading_slash":     {policy: permissions.Policy{{Methods: []string{"/authd.NSS/GetShadowEntries"}, UIDs: []uint32{998}}}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.policy.Validate()
+			if tc.wantErr {
+				require.Error(t, err, "Validate should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "Validate should not return an error, but did")
+		})
+	}
+}
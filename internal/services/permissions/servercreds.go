@@ -13,15 +13,19 @@ import (
 	"google.golang.org/grpc/credentials"
 )
 
-// WithUnixPeerCreds returns the credentials of the caller.
-func WithUnixPeerCreds() grpc.ServerOption {
-	return grpc.Creds(serverPeerCreds{})
+// UnixPeerCreds returns the credentials of the callers on unix sockets. Their security label, telling whether they are
+// confined in a snap, is only read with the snap confinement.
+func (m Manager) UnixPeerCreds() grpc.ServerOption {
+	return grpc.Creds(serverPeerCreds{readLabel: m.confineSnaps})
 }
 
 // serverPeerCreds encapsulates a TransportCredentials which extracts uid and pid of caller via Unix Socket SO_PEERCRED.
-type serverPeerCreds struct{}
+type serverPeerCreds struct {
+	// readLabel reads the security label of the caller via SO_PEERSEC, to tell whether it's confined in a snap.
+	readLabel bool
+}
 
-func (serverPeerCreds) ServerHandshake(conn net.Conn) (n net.Conn, c credentials.AuthInfo, err error) {
+func (s serverPeerCreds) ServerHandshake(conn net.Conn) (n net.Conn, c credentials.AuthInfo, err error) {
 	defer decorate.OnError(&err, "server handshake failed")
 
 	var cred *unix.Ucred
@@ -41,6 +45,7 @@ func (serverPeerCreds) ServerHandshake(conn net.Conn) (n net.Conn, c credentials
 	// In order to capture errors, we wrap already defined variable 'errClosure'.
 	// 'err' is then the error returned by Control() itself.
 	var errClosure error
+	var label string
 	err = raw.Control(func(fd uintptr) {
 		if fd > math.MaxInt {
 			errClosure = fmt.Errorf("file descriptor value %d is too large to convert to int", fd)
@@ -49,15 +54,27 @@ func (serverPeerCreds) ServerHandshake(conn net.Conn) (n net.Conn, c credentials
 		cred, errClosure = unix.GetsockoptUcred(int(fd),
 			unix.SOL_SOCKET,
 			unix.SO_PEERCRED)
+		if errClosure != nil {
+			errClosure = fmt.Errorf("GetsockoptUcred() error: %v", errClosure)
+			return
+		}
+		if !s.readLabel {
+			return
+		}
+		// The label tells whether the caller is confined in a snap.
+		label, errClosure = peerLabel(int(fd), peerLabelSize)
+		if errClosure != nil {
+			errClosure = fmt.Errorf("could not get the security label of the caller: %v", errClosure)
+		}
 	})
 	if errClosure != nil {
-		return nil, nil, fmt.Errorf("GetsockoptUcred() error: %v", errClosure)
+		return nil, nil, errClosure
 	}
 	if err != nil {
 		return nil, nil, fmt.Errorf("Control() error: %v", err)
 	}
 
-	return conn, peerCredsInfo{uid: cred.Uid, pid: cred.Pid}, nil
+	return conn, peerCredsInfo{uid: cred.Uid, pid: cred.Pid, snap: snapFromLabel(label)}, nil
 }
 func (serverPeerCreds) ClientHandshake(_ context.Context, _ string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
 	return conn, nil, nil
@@ -69,6 +86,8 @@ func (serverPeerCreds) OverrideServerName(_ string) error       { return nil }
 type peerCredsInfo struct {
 	uid uint32
 	pid int32
+	// snap is the name of the snap the caller is confined in, if any.
+	snap string
 }
 
 // AuthType returns a string encrypting uid and pid of caller.
@@ -0,0 +1,145 @@
+package permissions
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+
+	"github.com/ubuntu/decorate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// NewServerTLSConfig returns the TLS configuration of a server using the certificate and the key, and requiring the
+// clients to present a certificate signed by one of the authorities of the clientCA file.
+func NewServerTLSConfig(certFile, keyFile, clientCAFile string) (config *tls.Config, err error) {
+	defer decorate.OnError(&err, "can't load TLS configuration")
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCAs, err := loadCertPool(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS13,
+	}, nil
+}
+
+// NewClientTLSConfig returns the TLS configuration of a client presenting the certificate and the key, and trusting the
+// servers whose certificate is signed by one of the authorities of the CA file.
+func NewClientTLSConfig(certFile, keyFile, caFile string) (config *tls.Config, err error) {
+	defer decorate.OnError(&err, "can't load TLS configuration")
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	rootCAs, err := loadCertPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      rootCAs,
+		MinVersion:   tls.VersionTLS13,
+	}, nil
+}
+
+// loadCertPool returns the pool of the certificates of the PEM file.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", path)
+	}
+	return pool, nil
+}
+
+// WithTLSClientCerts authenticates the remote clients with their TLS certificate, instead of the credentials of a
+// local peer. The clients named in rootClients are allowed what root is.
+func WithTLSClientCerts(config *tls.Config, rootClients []string) grpc.ServerOption {
+	return grpc.Creds(tlsClientCreds{TransportCredentials: credentials.NewTLS(config), rootClients: rootClients})
+}
+
+// tlsClientCreds encapsulates the TLS TransportCredentials to identify the client by the name of its certificate.
+type tlsClientCreds struct {
+	credentials.TransportCredentials
+	rootClients []string
+}
+
+func (c tlsClientCreds) ServerHandshake(rawConn net.Conn) (conn net.Conn, authInfo credentials.AuthInfo, err error) {
+	defer decorate.OnError(&err, "server handshake failed")
+
+	conn, authInfo, err = c.TransportCredentials.ServerHandshake(rawConn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsInfo, ok := authInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		_ = conn.Close()
+		return nil, nil, errors.New("no client certificate")
+	}
+	name := clientName(tlsInfo.State.PeerCertificates[0])
+	if name == "" {
+		_ = conn.Close()
+		return nil, nil, errors.New("the client certificate has no name")
+	}
+
+	return conn, remoteClientInfo{TLSInfo: tlsInfo, name: name, isRoot: slices.Contains(c.rootClients, name)}, nil
+}
+
+func (c tlsClientCreds) Clone() credentials.TransportCredentials {
+	return tlsClientCreds{TransportCredentials: c.TransportCredentials.Clone(), rootClients: slices.Clone(c.rootClients)}
+}
+
+// clientName returns the common name of the certificate, or its first DNS name if it has none.
+func clientName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return ""
+}
+
+// remoteClientInfo is a client connected over TLS, identified by the name of its certificate.
+type remoteClientInfo struct {
+	credentials.TLSInfo
+	name   string
+	isRoot bool
+}
+
+// RemoteClient returns the name of the remote client which performed the request, and whether it's allowed what root
+// is. ok is false if the request wasn't performed by a remote client.
+func RemoteClient(ctx context.Context) (name string, isRoot, ok bool) {
+	p, found := peer.FromContext(ctx)
+	if !found {
+		return "", false, false
+	}
+	rci, found := p.AuthInfo.(remoteClientInfo)
+	if !found {
+		return "", false, false
+	}
+	return rci.name, rci.isRoot, true
+}
@@ -0,0 +1,213 @@
+package permissions_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/services/permissions"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestWithTLSClientCerts(t *testing.T) {
+	t.Parallel()
+
+	const method = "/grpc.health.v1.Health/Check"
+
+	tests := map[string]struct {
+		clientName    string
+		otherClientCA bool
+
+		wantCaller    string
+		wantHandshake bool
+		wantErr       bool
+	}{
+		"Granted_to_root_client":      {clientName: "root-client", wantCaller: `remote client "root-client"`},
+		"Granted_to_client_by_policy": {clientName: "granted-client", wantCaller: `remote client "granted-client"`},
+
+		"Error_as_deny_when_client_is_not_granted":           {clientName: "other-client", wantCaller: `remote client "other-client"`, wantErr: true},
+		"Error_on_handshake_when_certificate_has_no_name":    {wantHandshake: true, wantErr: true},
+		"Error_on_handshake_when_certificate_is_not_trusted": {clientName: "root-client", otherClientCA: true, wantHandshake: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			ca := newTestCA(t)
+			ca.writeCert(t, dir, "ca", nil)
+			ca.writeCert(t, dir, "server", &x509.Certificate{
+				Subject:     pkix.Name{CommonName: "authd"},
+				IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+
+			clientCA := ca
+			if tc.otherClientCA {
+				clientCA = newTestCA(t)
+			}
+			clientCA.writeCert(t, dir, "client", &x509.Certificate{
+				Subject:     pkix.Name{CommonName: tc.clientName},
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			})
+
+			serverConfig, err := permissions.NewServerTLSConfig(
+				filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.crt"))
+			require.NoError(t, err, "Setup: could not load server TLS configuration")
+
+			pm := permissions.New(permissions.WithPolicy(permissions.Policy{
+				{Methods: []string{"grpc.health.v1.Health/*"}, Clients: []string{"granted-client"}},
+			}))
+			callers := make(chan string, 1)
+			checkPermissions := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				callers <- permissions.Caller(ctx)
+				if err := pm.IsRequestAllowed(ctx, info.FullMethod); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}
+
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err, "Setup: could not listen")
+			s := grpc.NewServer(permissions.WithTLSClientCerts(serverConfig, []string{"root-client"}), grpc.UnaryInterceptor(checkPermissions))
+			healthgrpc.RegisterHealthServer(s, health.NewServer())
+			go func() { _ = s.Serve(lis) }()
+			t.Cleanup(s.Stop)
+
+			clientConfig, err := permissions.NewClientTLSConfig(
+				filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"), filepath.Join(dir, "ca.crt"))
+			require.NoError(t, err, "Setup: could not load client TLS configuration")
+			conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(clientConfig)))
+			require.NoError(t, err, "Setup: could not create client")
+			t.Cleanup(func() { _ = conn.Close() })
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, err = healthgrpc.NewHealthClient(conn).Check(ctx, &healthgrpc.HealthCheckRequest{})
+			if tc.wantHandshake {
+				require.Error(t, err, "Check should fail as the handshake is refused, but did not")
+				require.Empty(t, callers, "The request should not reach the server")
+				return
+			}
+			require.Equal(t, tc.wantCaller, <-callers, "Caller should describe the remote client")
+			if tc.wantErr {
+				require.Error(t, err, "Check %s should be denied, but was not", method)
+				return
+			}
+			require.NoError(t, err, "Check %s should be allowed, but was not", method)
+		})
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		noCert    bool
+		noKey     bool
+		noCA      bool
+		invalidCA bool
+	}{
+		"Error_when_certificate_is_missing":     {noCert: true},
+		"Error_when_key_is_missing":             {noKey: true},
+		"Error_when_ca_is_missing":              {noCA: true},
+		"Error_when_ca_contains_no_certificate": {invalidCA: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			ca := newTestCA(t)
+			ca.writeCert(t, dir, "ca", nil)
+			ca.writeCert(t, dir, "cert", &x509.Certificate{Subject: pkix.Name{CommonName: "authd"}})
+
+			certFile, keyFile, caFile := filepath.Join(dir, "cert.crt"), filepath.Join(dir, "cert.key"), filepath.Join(dir, "ca.crt")
+			if tc.noCert {
+				certFile = filepath.Join(dir, "doesnotexist.crt")
+			}
+			if tc.noKey {
+				keyFile = filepath.Join(dir, "doesnotexist.key")
+			}
+			if tc.noCA {
+				caFile = filepath.Join(dir, "doesnotexist.crt")
+			}
+			if tc.invalidCA {
+				require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0600), "Setup: could not write invalid CA")
+			}
+
+			_, err := permissions.NewServerTLSConfig(certFile, keyFile, caFile)
+			require.Error(t, err, "NewServerTLSConfig should return an error, but did not")
+			_, err = permissions.NewClientTLSConfig(certFile, keyFile, caFile)
+			require.Error(t, err, "NewClientTLSConfig should return an error, but did not")
+		})
+	}
+}
+
+// testCA is a certificate authority signing the certificates of the tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "Setup: could not generate CA key")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "authd tests CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err, "Setup: could not create CA certificate")
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err, "Setup: could not parse CA certificate")
+
+	return &testCA{cert: cert, key: key}
+}
+
+// writeCert writes the certificate of the template signed by the CA, and its key, as name.crt and name.key in dir. A
+// nil template writes the certificate of the CA itself.
+func (ca *testCA) writeCert(t *testing.T, dir, name string, template *x509.Certificate) {
+	t.Helper()
+
+	der, key := ca.cert.Raw, ca.key
+	if template != nil {
+		var err error
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err, "Setup: could not generate key")
+		template.SerialNumber = big.NewInt(time.Now().UnixNano())
+		template.NotBefore = time.Now().Add(-time.Hour)
+		template.NotAfter = time.Now().Add(time.Hour)
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		der, err = x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+		require.NoError(t, err, "Setup: could not create certificate")
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err, "Setup: could not marshal key")
+
+	err = os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	require.NoError(t, err, "Setup: could not write certificate")
+	err = os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	require.NoError(t, err, "Setup: could not write key")
+}
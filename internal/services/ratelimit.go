@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ubuntu/authd/internal/services/errmessages"
+	"github.com/ubuntu/authd/internal/services/permissions"
+	"github.com/ubuntu/authd/internal/services/ratelimit"
+	"github.com/ubuntu/authd/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// rateLimit rejects the requests of the authd services of the callers exceeding their rate limit. The health checks
+// are never limited.
+func (m Manager) rateLimit(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !strings.HasPrefix(info.FullMethod, "/authd.") {
+		return handler(ctx, req)
+	}
+
+	var caller ratelimit.Caller
+	var isRoot bool
+	if name, isRemoteRoot, ok := permissions.RemoteClient(ctx); ok {
+		caller, isRoot = ratelimit.Caller{Client: name}, isRemoteRoot
+	} else {
+		// The permissions of the requests without credentials are checked by the services.
+		uid, _, err := permissions.PeerCredentials(ctx)
+		if err != nil {
+			return handler(ctx, req)
+		}
+		// With the snap confinement, the root processes confined in a snap have the limits of the other users.
+		caller, isRoot = ratelimit.Caller{UID: uid}, m.permissionManager.IsRequestFromRoot(ctx) == nil
+	}
+
+	allowed, firstLimited := m.rateLimiter.Allow(caller, isRoot)
+	if !allowed {
+		if firstLimited {
+			log.Warningf(ctx, "Rate limiting the requests of %s", permissions.Caller(ctx))
+		}
+		limitedErr := errmessages.ErrRetryable.Wrap(fmt.Errorf("too many requests from %s", permissions.Caller(ctx)))
+		limitedErr.Code = codes.ResourceExhausted
+		return nil, limitedErr.WithRetryAfter(m.rateLimiter.RetryAfter(isRoot))
+	}
+
+	return handler(ctx, req)
+}
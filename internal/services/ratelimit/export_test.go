@@ -0,0 +1,18 @@
+package ratelimit
+
+import "time"
+
+// SetNow sets the function returning the current time of the limiter.
+func (l *Limiter) SetNow(now func() time.Time) {
+	l.now = now
+}
+
+// MaxCallers is the number of callers above which the idle ones are forgotten.
+const MaxCallers = maxCallers
+
+// Callers returns the number of callers the limiter remembers.
+func (l *Limiter) Callers() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
@@ -0,0 +1,161 @@
+// Package ratelimit limits the rate of the requests of each caller of the daemon, so that a runaway process can't
+// hammer it with lookups or authentication attempts.
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxCallers is the number of callers above which the ones which didn't hit their limit recently are forgotten.
+const maxCallers = 1024
+
+// Limit is the rate of requests allowed for a caller, replenished continuously.
+type Limit struct {
+	// Rate is the number of requests allowed per second, 0 for no limit.
+	Rate float64 `mapstructure:"rate"`
+	// Burst is the number of requests allowed at once, above the rate.
+	Burst int `mapstructure:"burst"`
+}
+
+// Limits are the rates of requests allowed for root and for each of the other users, shared by all their processes.
+type Limits struct {
+	Root   Limit `mapstructure:"root"`
+	Others Limit `mapstructure:"others"`
+}
+
+// DefaultLimits don't limit root, and allow each of the other users to do 100 requests per second, up to 1000 at once,
+// which is far above what the NSS lookups and authentications of its processes need.
+var DefaultLimits = Limits{
+	Others: Limit{Rate: 100, Burst: 1000},
+}
+
+// Validate returns an error if a limit is negative, or allows no request at all.
+func (l Limits) Validate() error {
+	for name, limit := range map[string]Limit{"root": l.Root, "others": l.Others} {
+		if limit.Rate < 0 || limit.Burst < 0 {
+			return fmt.Errorf("%s: the rate and the burst can't be negative", name)
+		}
+		if limit.Rate > 0 && limit.Burst < 1 {
+			return fmt.Errorf("%s: the burst must allow at least one request", name)
+		}
+	}
+	return nil
+}
+
+// Caller identifies the user doing the requests, whichever of its processes they come from so that it can't escape
+// its limit by forking, or the remote client by its name.
+type Caller struct {
+	UID    uint32
+	Client string
+}
+
+// bucketKey identifies the bucket of a caller. The root processes which don't have the root limit, like the ones
+// confined in a snap, don't share the bucket of root.
+type bucketKey struct {
+	Caller
+	isRoot bool
+}
+
+// bucket holds the requests which can still be done by a caller.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// limited is whether the last request of the caller was rejected.
+	limited bool
+}
+
+// Limiter limits the rate of the requests of each caller, with separate limits for root and the other users.
+type Limiter struct {
+	limits Limits
+
+	buckets map[bucketKey]*bucket
+	mu      sync.Mutex
+
+	now func() time.Time
+}
+
+// New returns a Limiter applying the limits to the requests.
+func New(limits Limits) *Limiter {
+	return &Limiter{
+		limits:  limits,
+		buckets: make(map[bucketKey]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetLimits changes the limits of the next requests.
+func (l *Limiter) SetLimits(limits Limits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits = limits
+	l.buckets = make(map[bucketKey]*bucket)
+}
+
+// Allow returns whether the caller can do a request now, consuming it. firstLimited is true when the request is the
+// first one rejected since the caller was last allowed, so that only the beginning of the rejections can be reported.
+func (l *Limiter) Allow(c Caller, isRoot bool) (allowed, firstLimited bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limits.Others
+	if isRoot {
+		limit = l.limits.Root
+	}
+	if limit.Rate <= 0 {
+		return true, false
+	}
+
+	now := l.now()
+	key := bucketKey{Caller: c, isRoot: isRoot}
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxCallers {
+			l.forgetIdleCallers(now)
+		}
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		firstLimited = !b.limited
+		b.limited = true
+		return false, firstLimited
+	}
+
+	b.tokens--
+	b.limited = false
+	return true, false
+}
+
+// RetryAfter returns how long a caller whose request was rejected waits at most before its next request is allowed.
+func (l *Limiter) RetryAfter(isRoot bool) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limits.Others
+	if isRoot {
+		limit = l.limits.Root
+	}
+	if limit.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / limit.Rate)
+}
+
+// forgetIdleCallers removes the callers whose requests would all be allowed again, as if they never did any.
+func (l *Limiter) forgetIdleCallers(now time.Time) {
+	for k, b := range l.buckets {
+		limit := l.limits.Others
+		if k.isRoot {
+			limit = l.limits.Root
+		}
+		if b.tokens+now.Sub(b.last).Seconds()*limit.Rate >= float64(limit.Burst) {
+			delete(l.buckets, k)
+		}
+	}
+}
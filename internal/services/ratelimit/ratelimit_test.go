@@ -0,0 +1,185 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/services/ratelimit"
+)
+
+func TestAllow(t *testing.T) {
+	t.Parallel()
+
+	caller := ratelimit.Caller{UID: 1000}
+	otherUser := ratelimit.Caller{UID: 1001}
+
+	type request struct {
+		caller ratelimit.Caller
+		isRoot bool
+		// after is the time elapsed since the previous request.
+		after time.Duration
+
+		wantAllowed      bool
+		wantFirstLimited bool
+	}
+	tests := map[string]struct {
+		limits   ratelimit.Limits
+		requests []request
+	}{
+		"Allows_all_requests_without_limit": {
+			requests: []request{{caller: caller, wantAllowed: true}, {caller: caller, wantAllowed: true}, {caller: caller, wantAllowed: true}},
+		},
+		"Allows_the_burst_and_rejects_the_next_requests": {
+			limits: ratelimit.Limits{Others: ratelimit.Limit{Rate: 1, Burst: 2}},
+			requests: []request{
+				{caller: caller, wantAllowed: true},
+				{caller: caller, wantAllowed: true},
+				{caller: caller, wantFirstLimited: true},
+				{caller: caller},
+			},
+		},
+		"Allows_requests_again_once_replenished": {
+			limits: ratelimit.Limits{Others: ratelimit.Limit{Rate: 2, Burst: 1}},
+			requests: []request{
+				{caller: caller, wantAllowed: true},
+				{caller: caller, wantFirstLimited: true},
+				{caller: caller, after: 100 * time.Millisecond},
+				{caller: caller, after: 400 * time.Millisecond, wantAllowed: true},
+				{caller: caller, wantFirstLimited: true},
+			},
+		},
+		"Does_not_replenish_above_the_burst": {
+			limits: ratelimit.Limits{Others: ratelimit.Limit{Rate: 10, Burst: 1}},
+			requests: []request{
+				{caller: caller, after: time.Hour, wantAllowed: true},
+				{caller: caller, after: time.Hour, wantAllowed: true},
+				{caller: caller, wantFirstLimited: true},
+			},
+		},
+		"Limits_each_user_separately": {
+			limits: ratelimit.Limits{Others: ratelimit.Limit{Rate: 1, Burst: 1}},
+			requests: []request{
+				{caller: caller, wantAllowed: true},
+				{caller: caller, wantFirstLimited: true},
+				{caller: otherUser, wantAllowed: true},
+			},
+		},
+		"Does_not_share_the_limit_of_root_with_root_processes_without_it": {
+			limits: ratelimit.Limits{Others: ratelimit.Limit{Rate: 1, Burst: 1}},
+			requests: []request{
+				{caller: ratelimit.Caller{}, wantAllowed: true},
+				{caller: ratelimit.Caller{}, wantFirstLimited: true},
+				{caller: ratelimit.Caller{}, isRoot: true, wantAllowed: true},
+			},
+		},
+		"Uses_the_root_limit_for_root": {
+			limits: ratelimit.Limits{Root: ratelimit.Limit{Rate: 1, Burst: 2}, Others: ratelimit.Limit{Rate: 1, Burst: 1}},
+			requests: []request{
+				{caller: ratelimit.Caller{}, isRoot: true, wantAllowed: true},
+				{caller: ratelimit.Caller{}, isRoot: true, wantAllowed: true},
+				{caller: ratelimit.Caller{}, isRoot: true, wantFirstLimited: true},
+			},
+		},
+		"Does_not_limit_root_without_root_limit": {
+			limits: ratelimit.Limits{Others: ratelimit.Limit{Rate: 1, Burst: 1}},
+			requests: []request{
+				{caller: ratelimit.Caller{}, isRoot: true, wantAllowed: true},
+				{caller: ratelimit.Caller{}, isRoot: true, wantAllowed: true},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Now()
+			l := ratelimit.New(tc.limits)
+			l.SetNow(func() time.Time { return now })
+
+			for i, r := range tc.requests {
+				now = now.Add(r.after)
+				allowed, firstLimited := l.Allow(r.caller, r.isRoot)
+				require.Equal(t, r.wantAllowed, allowed, "Request %d should be allowed: %v", i, r.wantAllowed)
+				require.Equal(t, r.wantFirstLimited, firstLimited, "Request %d should be the first limited one: %v", i, r.wantFirstLimited)
+			}
+		})
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	t.Parallel()
+
+	caller := ratelimit.Caller{UID: 1000}
+	l := ratelimit.New(ratelimit.Limits{Others: ratelimit.Limit{Rate: 1, Burst: 1}})
+	l.SetNow(func() time.Time { return time.Unix(0, 0) })
+
+	allowed, _ := l.Allow(caller, false)
+	require.True(t, allowed, "Setup: the first request should be allowed")
+	allowed, _ = l.Allow(caller, false)
+	require.False(t, allowed, "Setup: the second request should be rejected")
+
+	l.SetLimits(ratelimit.Limits{Others: ratelimit.Limit{Rate: 1, Burst: 2}})
+	allowed, _ = l.Allow(caller, false)
+	require.True(t, allowed, "The requests should be allowed again with the new limits")
+	allowed, _ = l.Allow(caller, false)
+	require.True(t, allowed, "The burst of the new limits should be applied")
+}
+
+func TestForgetIdleCallers(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	l := ratelimit.New(ratelimit.Limits{Others: ratelimit.Limit{Rate: 1, Burst: 1}})
+	l.SetNow(func() time.Time { return now })
+
+	for uid := range ratelimit.MaxCallers {
+		allowed, _ := l.Allow(ratelimit.Caller{UID: uint32(uid)}, false)
+		require.True(t, allowed, "Setup: the first request of each user should be allowed")
+	}
+	require.Equal(t, ratelimit.MaxCallers, l.Callers(), "Setup: all the callers should be remembered")
+
+	// Once replenished, the callers are forgotten when a new one comes.
+	now = now.Add(time.Second)
+	allowed, _ := l.Allow(ratelimit.Caller{UID: ratelimit.MaxCallers}, false)
+	require.True(t, allowed, "The request of a new user should be allowed")
+	require.Equal(t, 1, l.Callers(), "The idle callers should have been forgotten")
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	l := ratelimit.New(ratelimit.Limits{Others: ratelimit.Limit{Rate: 4, Burst: 1}})
+	require.Equal(t, 250*time.Millisecond, l.RetryAfter(false), "RetryAfter should return the time to replenish a request")
+	require.Zero(t, l.RetryAfter(true), "RetryAfter should return 0 without limit")
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		limits ratelimit.Limits
+
+		wantErr bool
+	}{
+		"Default_limits_are_valid": {limits: ratelimit.DefaultLimits},
+		"No_limits_are_valid":      {},
+		"Limits_are_valid":         {limits: ratelimit.Limits{Root: ratelimit.Limit{Rate: 0.5, Burst: 1}, Others: ratelimit.Limit{Rate: 10, Burst: 20}}},
+
+		"Error_on_negative_rate":          {limits: ratelimit.Limits{Root: ratelimit.Limit{Rate: -1, Burst: 1}}, wantErr: true},
+		"Error_on_negative_burst":         {limits: ratelimit.Limits{Others: ratelimit.Limit{Burst: -1}}, wantErr: true},
+		"Error_on_rate_without_any_burst": {limits: ratelimit.Limits{Others: ratelimit.Limit{Rate: 10}}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.limits.Validate()
+			if tc.wantErr {
+				require.Error(t, err, "Validate should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "Validate should not return an error, but did")
+		})
+	}
+}
@@ -0,0 +1,250 @@
+// Package securityevents notifies the security events of the daemon, like the lockouts of the users, to an executable
+// or to a webhook, so that a SIEM can ingest them without parsing the logs.
+package securityevents
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+)
+
+// DefaultTimeout is how long the executable or the webhook can take to handle an event when the configuration doesn't
+// set it.
+const DefaultTimeout = 10 * time.Second
+
+// queueSize is the number of events waiting to be notified, above which the new ones are dropped, so that a slow
+// webhook can't hold the authentications.
+const queueSize = 256
+
+// Config is where the security events are notified. Nothing is notified if neither Exec nor Webhook is set.
+type Config struct {
+	// Exec is an executable run for each event, with the event as JSON on its standard input.
+	Exec string
+	// Webhook is the HTTP or HTTPS URL the events are posted to, as JSON.
+	Webhook string
+	// WebhookHeaders are added to the requests to the webhook, like the one authenticating the daemon.
+	WebhookHeaders map[string]string `mapstructure:"webhook_headers"`
+	// Events are the actions of the events notified, all the security events if empty.
+	Events []string
+	// Timeout is how long the executable or the webhook can take for each event, DefaultTimeout if 0.
+	Timeout time.Duration
+}
+
+// Validate returns an error if the executable is not an absolute path, if the webhook is not an HTTP or HTTPS URL, or
+// if an event is unknown.
+func (c Config) Validate() error {
+	if c.Exec != "" && !strings.HasPrefix(c.Exec, "/") {
+		return fmt.Errorf("exec: %q is not an absolute path", c.Exec)
+	}
+	if c.Webhook != "" {
+		u, err := url.Parse(c.Webhook)
+		if err != nil {
+			return fmt.Errorf("webhook: %v", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook: %q is not an HTTP or HTTPS URL", c.Webhook)
+		}
+	}
+	for _, e := range c.Events {
+		if !slices.Contains(users.SecurityEvents, e) {
+			return fmt.Errorf("events: unknown event %q, must be one of %v", e, users.SecurityEvents)
+		}
+	}
+	if c.Timeout < 0 {
+		return errors.New("timeout: can't be negative")
+	}
+	return nil
+}
+
+// Event is a security event, as it's sent to the executable and to the webhook.
+type Event struct {
+	Time time.Time `json:"time"`
+	// Host is the name of the machine the event happened on.
+	Host string `json:"host"`
+	// Action is what happened, one of the security events, like "user-locked-out".
+	Action string `json:"action"`
+	// Actor is who did it, like "lockout" for the lockouts after too many failed authentication attempts.
+	Actor string `json:"actor"`
+	// User is the name of the user the event is about.
+	User    string `json:"user"`
+	Details string `json:"details"`
+}
+
+// Notifier notifies the security events in the background, in the order they happened. Its configuration can be
+// changed while it's in use.
+type Notifier struct {
+	config atomic.Pointer[Config]
+	host   string
+	client *http.Client
+
+	events chan Event
+	// dropping is set once an event was dropped because the queue was full, until an event is queued again.
+	dropping  atomic.Bool
+	quit      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New returns a notifier of the security events, which runs until it's closed.
+func New(config Config) *Notifier {
+	host, err := os.Hostname()
+	if err != nil {
+		log.Warningf(context.Background(), "Could not get the host name of the security events: %v", err)
+	}
+
+	n := &Notifier{
+		host:   host,
+		client: &http.Client{},
+		events: make(chan Event, queueSize),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	n.SetConfig(config)
+	go n.run()
+	return n
+}
+
+// SetConfig changes where the next events are notified.
+func (n *Notifier) SetConfig(config Config) {
+	n.config.Store(&config)
+}
+
+// Notify queues the event to be notified, if it's one of the configured events. It never blocks: the event is dropped
+// if too many are waiting to be notified.
+func (n *Notifier) Notify(e types.AuditEvent) {
+	c := n.config.Load()
+	if c.Exec == "" && c.Webhook == "" {
+		return
+	}
+	if len(c.Events) > 0 && !slices.Contains(c.Events, e.Action) {
+		return
+	}
+
+	select {
+	case n.events <- Event{Time: e.Time, Host: n.host, Action: e.Action, Actor: e.Actor, User: e.Target, Details: e.Details}:
+		n.dropping.Store(false)
+	default:
+		if !n.dropping.Swap(true) {
+			log.Warningf(context.Background(), "Dropping security events: too many are waiting to be notified")
+		}
+	}
+}
+
+// Close stops the notifier once the queued events are notified, or dropped if they can't be within the timeout.
+func (n *Notifier) Close() {
+	n.closeOnce.Do(func() { close(n.quit) })
+	<-n.done
+}
+
+// run notifies the queued events until the notifier is closed.
+func (n *Notifier) run() {
+	defer close(n.done)
+
+	for {
+		select {
+		case e := <-n.events:
+			n.send(context.Background(), e)
+		case <-n.quit:
+			// The events already queued, like the ones of the users purged when stopping, are still notified, but the
+			// daemon doesn't wait for them for longer than a single event.
+			ctx, cancel := context.WithTimeout(context.Background(), cmp.Or(n.config.Load().Timeout, DefaultTimeout))
+			defer cancel()
+			for {
+				select {
+				case e := <-n.events:
+					n.send(ctx, e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// send notifies the event to the executable and to the webhook. The failures are only logged.
+func (n *Notifier) send(ctx context.Context, e Event) {
+	c := *n.config.Load()
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(c.Timeout, DefaultTimeout))
+	defer cancel()
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		log.Warningf(context.Background(), "Could not encode security event %q: %v", e.Action, err)
+		return
+	}
+
+	if c.Exec != "" {
+		err = errors.Join(err, runExec(ctx, c.Exec, e, payload))
+	}
+	if c.Webhook != "" {
+		err = errors.Join(err, n.post(ctx, c, payload))
+	}
+	if err != nil {
+		logCtx := log.WithFields(context.Background(), log.UserField, e.User)
+		log.Warningf(logCtx, "Could not notify security event %q of user %q: %v", e.Action, e.User, err)
+	}
+}
+
+// runExec runs the executable for the event, with the event as JSON on its standard input and its main fields in
+// its environment.
+func runExec(ctx context.Context, path string, e Event, payload []byte) error {
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Env = []string{
+		"PATH=/usr/sbin:/usr/bin:/sbin:/bin",
+		"AUTHD_EVENT=" + e.Action,
+		"AUTHD_ACTOR=" + e.Actor,
+		"AUTHD_USER=" + e.User,
+		"AUTHD_DETAILS=" + e.Details,
+	}
+	cmd.Stdin = bytes.NewReader(payload)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s timed out", path)
+		}
+		return fmt.Errorf("%s failed: %v: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// post posts the event to the webhook, which must answer with a success status.
+func (n *Notifier) post(ctx context.Context, c Config, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Webhook, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("webhook: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "authd")
+	for k, v := range c.WebhookHeaders {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
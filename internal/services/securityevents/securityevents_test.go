@@ -0,0 +1,193 @@
+package securityevents_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/services/securityevents"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config securityevents.Config
+
+		wantErr bool
+	}{
+		"Empty_configuration_is_valid": {},
+		"Valid_configuration": {config: securityevents.Config{
+			Exec: "/usr/libexec/forward-event", Webhook: "https://siem.example.com/events",
+			WebhookHeaders: map[string]string{"Authorization": "Bearer some-token"},
+			Events:         []string{users.AuditUserLockedOut, users.EventLoginFailed}, Timeout: time.Second,
+		}},
+
+		"Error_if_the_executable_is_not_an_absolute_path": {config: securityevents.Config{Exec: "forward-event"}, wantErr: true},
+		"Error_if_the_webhook_is_not_an_URL":              {config: securityevents.Config{Webhook: "siem.example.com"}, wantErr: true},
+		"Error_if_the_webhook_is_not_an_HTTP_URL":         {config: securityevents.Config{Webhook: "ftp://siem.example.com"}, wantErr: true},
+		"Error_if_an_event_is_unknown":                    {config: securityevents.Config{Events: []string{"user-added"}}, wantErr: true},
+		"Error_if_the_timeout_is_negative":                {config: securityevents.Config{Timeout: -time.Second}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.config.Validate()
+			if tc.wantErr {
+				require.Error(t, err, "Validate should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "Validate should not return an error, but did")
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	t.Parallel()
+
+	lockedOut := types.AuditEvent{
+		Time: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), Actor: users.ActorLockout, Action: users.AuditUserLockedOut,
+		Target: "user1", Details: "3 failed authentication attempts within 15m0s",
+	}
+	failed := types.AuditEvent{
+		Time: time.Date(2026, 1, 1, 11, 59, 0, 0, time.UTC), Actor: users.ActorLogin, Action: users.EventLoginFailed,
+		Target: "user1", Details: "from host1",
+	}
+
+	tests := map[string]struct {
+		events        []string
+		webhookStatus int
+		failingExec   bool
+
+		wantActions []string
+	}{
+		"Notify_all_the_security_events":       {wantActions: []string{users.EventLoginFailed, users.AuditUserLockedOut}},
+		"Notify_only_the_configured_events":    {events: []string{users.AuditUserLockedOut}, wantActions: []string{users.AuditUserLockedOut}},
+		"Notify_the_webhook_if_the_exec_fails": {failingExec: true, wantActions: []string{users.EventLoginFailed, users.AuditUserLockedOut}},
+		"Notify_the_next_events_if_the_webhook_fails": {
+			webhookStatus: http.StatusInternalServerError, wantActions: []string{users.EventLoginFailed, users.AuditUserLockedOut},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var mu sync.Mutex
+			var posted []securityevents.Event
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				assert := func(want, got, msg string) {
+					if want != got {
+						t.Errorf("%s: want %q, got %q", msg, want, got)
+					}
+				}
+				assert(http.MethodPost, r.Method, "The events should be posted")
+				assert("application/json", r.Header.Get("Content-Type"), "The events should be posted as JSON")
+				assert("Bearer some-token", r.Header.Get("Authorization"), "The configured headers should be sent")
+
+				var e securityevents.Event
+				if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+					t.Errorf("Could not decode the posted event: %v", err)
+				}
+				posted = append(posted, e)
+				if tc.webhookStatus != 0 {
+					w.WriteHeader(tc.webhookStatus)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			dir := t.TempDir()
+			hook := newRecordingExec(t, dir, tc.failingExec)
+
+			n := securityevents.New(securityevents.Config{
+				Exec:           hook,
+				Webhook:        server.URL,
+				WebhookHeaders: map[string]string{"Authorization": "Bearer some-token"},
+				Events:         tc.events,
+			})
+			n.Notify(failed)
+			n.Notify(lockedOut)
+			n.Close()
+
+			host, err := os.Hostname()
+			require.NoError(t, err, "Setup: could not get the host name")
+			var want []securityevents.Event
+			for _, e := range []types.AuditEvent{failed, lockedOut} {
+				for _, a := range tc.wantActions {
+					if a == e.Action {
+						want = append(want, securityevents.Event{
+							Time: e.Time, Host: host, Action: e.Action, Actor: e.Actor, User: e.Target, Details: e.Details,
+						})
+					}
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			require.Equal(t, want, posted, "The events should be posted to the webhook")
+
+			if tc.failingExec {
+				return
+			}
+			var got []securityevents.Event
+			var gotEnv []string
+			for i := range want {
+				d, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("event-%d.json", i+1)))
+				require.NoError(t, err, "The executable should have been run for event %d", i+1)
+				var e securityevents.Event
+				require.NoError(t, json.Unmarshal(d, &e), "The executable should get the event as JSON")
+				got = append(got, e)
+				env, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("event-%d.env", i+1)))
+				require.NoError(t, err, "Could not read the environment of the executable")
+				gotEnv = append(gotEnv, strings.TrimSpace(string(env)))
+			}
+			require.Equal(t, want, got, "The executable should be run for each event")
+			for i, e := range want {
+				require.Equal(t, fmt.Sprintf("%s %s %s", e.Action, e.Actor, e.User), gotEnv[i],
+					"The executable should get the event in its environment")
+			}
+		})
+	}
+}
+
+func TestNotifyWithoutDestination(t *testing.T) {
+	t.Parallel()
+
+	n := securityevents.New(securityevents.Config{})
+	n.Notify(types.AuditEvent{Action: users.AuditUserLockedOut, Target: "user1"})
+	n.Close()
+	n.Close()
+}
+
+// newRecordingExec returns an executable writing each event it gets, and its environment, to dir. It fails without
+// writing anything if failing is set.
+func newRecordingExec(t *testing.T, dir string, failing bool) string {
+	t.Helper()
+
+	script := fmt.Sprintf(`#!/bin/sh
+n=$(ls %[1]s | grep -c '\.json$')
+n=$((n+1))
+cat > %[1]s/event-$n.json
+echo "$AUTHD_EVENT $AUTHD_ACTOR $AUTHD_USER" > %[1]s/event-$n.env
+`, dir)
+	if failing {
+		script = "#!/bin/sh\necho 'could not forward the event' >&2\nexit 1\n"
+	}
+
+	path := filepath.Join(t.TempDir(), "record-event")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0700), "Setup: could not write the executable")
+	return path
+}
@@ -0,0 +1,91 @@
+// Package signals emits D-Bus signals on the system bus when the authd users and groups change, so that other
+// components can react to the changes without polling.
+package signals
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/ubuntu/authd/internal/consts"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+const (
+	// BusName is the name requested by the daemon on the system bus.
+	BusName = consts.ServiceName
+	// ObjectPath is the path of the object emitting the signals.
+	ObjectPath = dbus.ObjectPath("/com/ubuntu/authd/Users")
+	// Interface is the D-Bus interface of the signals.
+	Interface = "com.ubuntu.authd.Users"
+)
+
+// intro describes the signals of the interface. Each of them has the name of the user or group as argument.
+var intro = introspect.Node{
+	Name: string(ObjectPath),
+	Interfaces: []introspect.Interface{
+		introspect.IntrospectData,
+		{
+			Name: Interface,
+			Signals: []introspect.Signal{
+				{Name: users.ChangeUserAdded, Args: []introspect.Arg{{Name: "name", Type: "s"}}},
+				{Name: users.ChangeUserUpdated, Args: []introspect.Arg{{Name: "name", Type: "s"}}},
+				{Name: users.ChangeUserRemoved, Args: []introspect.Arg{{Name: "name", Type: "s"}}},
+				{Name: users.ChangeGroupChanged, Args: []introspect.Arg{{Name: "name", Type: "s"}}},
+				{Name: users.ChangeUserBrokerChanged, Args: []introspect.Arg{{Name: "name", Type: "s"}}},
+			},
+		},
+	},
+}
+
+// Emitter emits the D-Bus signals of the changes of the users and groups.
+type Emitter struct {
+	conn *dbus.Conn
+}
+
+// New connects to the system bus and returns an Emitter. The signals are emitted even if the bus name can't be
+// acquired, in which case they are sent from the unique name of the connection.
+func New(ctx context.Context) (e *Emitter, err error) {
+	defer decorate.OnError(&err, "can't create D-Bus signals emitter")
+
+	// Don't call dbus.SystemBus which caches globally system dbus (issues in tests)
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Export(introspect.NewIntrospectable(&intro), ObjectPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	reply, err := conn.RequestName(BusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		log.Warningf(ctx, "Could not request D-Bus name %q: %v", BusName, err)
+	} else if reply != dbus.RequestNameReplyPrimaryOwner {
+		log.Warningf(ctx, "D-Bus name %q is already owned", BusName)
+	}
+
+	return &Emitter{conn: conn}, nil
+}
+
+// Notify emits the signal of the change. Failures are only logged, as the change was already done.
+func (e *Emitter) Notify(c types.Change) {
+	if err := e.conn.Emit(ObjectPath, fmt.Sprintf("%s.%s", Interface, c.Kind), c.Name); err != nil {
+		log.Warningf(context.Background(), "Could not emit D-Bus signal %s for %q: %v", c.Kind, c.Name, err)
+	}
+}
+
+// Conn returns the connection to the system bus, owning the bus name of authd, on which other objects can be exported.
+func (e *Emitter) Conn() *dbus.Conn {
+	return e.conn
+}
+
+// Close closes the connection to the system bus.
+func (e *Emitter) Close() error {
+	return e.conn.Close()
+}
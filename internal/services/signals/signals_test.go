@@ -0,0 +1,62 @@
+package signals_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/services/signals"
+	"github.com/ubuntu/authd/internal/testutils"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestNotify(t *testing.T) {
+	e, err := signals.New(context.Background())
+	require.NoError(t, err, "Setup: New should not return an error")
+	t.Cleanup(func() { _ = e.Close() })
+
+	conn, err := testutils.GetSystemBusConnection(t)
+	require.NoError(t, err, "Setup: could not connect to the system bus")
+	t.Cleanup(func() { _ = conn.Close() })
+	require.NoError(t, conn.AddMatchSignal(dbus.WithMatchObjectPath(signals.ObjectPath), dbus.WithMatchInterface(signals.Interface)),
+		"Setup: could not subscribe to the signals")
+	received := make(chan *dbus.Signal, 10)
+	conn.Signal(received)
+
+	changes := []types.Change{
+		{Kind: users.ChangeUserAdded, Name: "user1"},
+		{Kind: users.ChangeUserUpdated, Name: "user1"},
+		{Kind: users.ChangeGroupChanged, Name: "group1"},
+		{Kind: users.ChangeUserRemoved, Name: "user1"},
+	}
+	for _, c := range changes {
+		e.Notify(c)
+	}
+
+	for _, c := range changes {
+		select {
+		case s := <-received:
+			require.Equal(t, signals.Interface+"."+c.Kind, s.Name, "Notify should emit the signal of the change")
+			require.Equal(t, []any{c.Name}, s.Body, "The signal should have the name of the user or group as argument")
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Signal %s was not received", c.Kind)
+		}
+	}
+}
+
+func TestMain(m *testing.M) {
+	// Start system bus mock.
+	cleanup, err := testutils.StartSystemBusMock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+
+	m.Run()
+}
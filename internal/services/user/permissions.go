@@ -0,0 +1,9 @@
+package user
+
+import "context"
+
+// CheckGlobalAccess denies all requests not coming from the root user, or from a user granted by the authorization
+// policy.
+func (s Service) CheckGlobalAccess(ctx context.Context, method string) error {
+	return s.permissionManager.IsRequestAllowed(ctx, method)
+}
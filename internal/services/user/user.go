@@ -0,0 +1,576 @@
+// Package user implements the user grpc service protocol to the daemon, used to manage the authd users and groups.
+package user
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ubuntu/authd/internal/brokers"
+	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/services/permissions"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Service is the implementation of the user management service.
+type Service struct {
+	userManager       *users.Manager
+	brokerManager     *brokers.Manager
+	permissionManager *permissions.Manager
+
+	authd.UnimplementedUserServiceServer
+}
+
+// NewService returns a new user management GRPC service.
+func NewService(ctx context.Context, userManager *users.Manager, brokerManager *brokers.Manager, permissionManager *permissions.Manager) Service {
+	log.Debug(ctx, "Building new gRPC user service")
+
+	return Service{
+		userManager:       userManager,
+		brokerManager:     brokerManager,
+		permissionManager: permissionManager,
+	}
+}
+
+// ListIDCollisions returns the UIDs and GIDs which are used by more than one user or group.
+func (s Service) ListIDCollisions(ctx context.Context, req *authd.Empty) (*authd.IDCollisions, error) {
+	collisions, err := s.userManager.IDCollisions()
+	if err != nil {
+		return nil, err
+	}
+
+	var r authd.IDCollisions
+	for _, c := range collisions {
+		r.Collisions = append(r.Collisions, &authd.IDCollision{
+			Kind:       c.Kind,
+			Id:         c.ID,
+			AuthdNames: c.AuthdNames,
+			LocalNames: c.LocalNames,
+		})
+	}
+
+	return &r, nil
+}
+
+// RemapUserID changes the UID of an authd user.
+func (s Service) RemapUserID(ctx context.Context, req *authd.RemapIDRequest) (*authd.IDTranslation, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no user name provided")
+	}
+
+	t, err := s.userManager.RemapUserID(req.GetName(), req.GetNewId(), req.GetChownHome(), permissions.Caller(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return idTranslationFromUsersIDTranslation(t), nil
+}
+
+// RemapGroupID changes the GID of an authd group.
+func (s Service) RemapGroupID(ctx context.Context, req *authd.RemapIDRequest) (*authd.IDTranslation, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no group name provided")
+	}
+
+	t, err := s.userManager.RemapGroupID(req.GetName(), req.GetNewId(), req.GetChownHome(), permissions.Caller(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return idTranslationFromUsersIDTranslation(t), nil
+}
+
+// ListIDTranslations returns all the UID and GID remappings done so far.
+func (s Service) ListIDTranslations(ctx context.Context, req *authd.Empty) (*authd.IDTranslations, error) {
+	translations, err := s.userManager.IDTranslations()
+	if err != nil {
+		return nil, err
+	}
+
+	var r authd.IDTranslations
+	for _, t := range translations {
+		r.Translations = append(r.Translations, idTranslationFromUsersIDTranslation(t))
+	}
+
+	return &r, nil
+}
+
+// PurgeUser fully removes an authd user from the database.
+func (s Service) PurgeUser(ctx context.Context, req *authd.PurgeUserRequest) (*authd.Empty, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no user name provided")
+	}
+
+	err := s.userManager.PurgeUser(req.GetName(), permissions.Caller(ctx))
+	if errors.Is(err, users.NoDataFoundError{}) {
+		return nil, status.Errorf(codes.NotFound, "user %q not found", req.GetName())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &authd.Empty{}, nil
+}
+
+// RunMaintenance prunes orphaned references from the database, rebuilds its indexes and compacts it.
+func (s Service) RunMaintenance(ctx context.Context, req *authd.Empty) (*authd.MaintenanceReport, error) {
+	r, err := s.userManager.RunMaintenance()
+	if err != nil {
+		return nil, err
+	}
+
+	return &authd.MaintenanceReport{
+		PrunedReferences:    uint32(r.PrunedReferences),
+		RebuiltIndexEntries: uint32(r.RebuiltIndexEntries),
+		SizeBefore:          r.SizeBefore,
+		SizeAfter:           r.SizeAfter,
+	}, nil
+}
+
+// ListAuditEvents returns the changes done to the authd users and groups, from the oldest to the newest.
+func (s Service) ListAuditEvents(ctx context.Context, req *authd.AuditEventsRequest) (*authd.AuditEvents, error) {
+	filter := types.AuditFilter{
+		Target: req.GetTarget(),
+		Action: req.GetAction(),
+		Limit:  int(req.GetLimit()),
+	}
+	if req.GetSince() > 0 {
+		filter.Since = time.Unix(req.GetSince(), 0)
+	}
+
+	events, err := s.userManager.AuditEvents(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var r authd.AuditEvents
+	for _, e := range events {
+		r.Events = append(r.Events, &authd.AuditEvent{
+			Time:    e.Time.Unix(),
+			Actor:   e.Actor,
+			Action:  e.Action,
+			Target:  e.Target,
+			Details: e.Details,
+		})
+	}
+
+	return &r, nil
+}
+
+// SetUserAttributes modifies the GECOS, home directory and shell of an authd user locally.
+func (s Service) SetUserAttributes(ctx context.Context, req *authd.SetUserAttributesRequest) (*authd.Empty, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no user name provided")
+	}
+
+	attrs := types.UserAttributes{Gecos: req.Gecos, Dir: req.Home, Shell: req.Shell}
+	err := s.userManager.SetUserAttributes(req.GetName(), attrs, permissions.Caller(ctx))
+	if errors.Is(err, users.NoDataFoundError{}) {
+		return nil, status.Errorf(codes.NotFound, "user %q not found", req.GetName())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &authd.Empty{}, nil
+}
+
+// idTranslationFromUsersIDTranslation returns an IDTranslation from types.IDTranslation.
+func idTranslationFromUsersIDTranslation(t types.IDTranslation) *authd.IDTranslation {
+	return &authd.IDTranslation{
+		Kind:  t.Kind,
+		Name:  t.Name,
+		OldId: t.OldID,
+		NewId: t.NewID,
+		Time:  t.Time.Unix(),
+	}
+}
+
+// LockUser locks an authd user, so that it can't authenticate anymore.
+func (s Service) LockUser(ctx context.Context, req *authd.LockUserRequest) (*authd.Empty, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no user name provided")
+	}
+
+	err := s.userManager.LockUser(req.GetName(), permissions.Caller(ctx))
+	if errors.Is(err, users.NoDataFoundError{}) {
+		return nil, status.Errorf(codes.NotFound, "user %q not found", req.GetName())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &authd.Empty{}, nil
+}
+
+// UnlockUser unlocks an authd user previously locked with LockUser.
+func (s Service) UnlockUser(ctx context.Context, req *authd.LockUserRequest) (*authd.Empty, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no user name provided")
+	}
+
+	err := s.userManager.UnlockUser(req.GetName(), permissions.Caller(ctx))
+	if errors.Is(err, users.NoDataFoundError{}) {
+		return nil, status.Errorf(codes.NotFound, "user %q not found", req.GetName())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &authd.Empty{}, nil
+}
+
+// GetLastLogin returns the last successful login of an authd user.
+func (s Service) GetLastLogin(ctx context.Context, req *authd.LastLoginRequest) (*authd.LastLogin, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no user name provided")
+	}
+
+	l, err := s.userManager.LastLogin(req.GetName())
+	if errors.Is(err, users.NoDataFoundError{}) {
+		return nil, status.Errorf(codes.NotFound, "user %q not found", req.GetName())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := &authd.LastLogin{Source: l.Source, BrokerId: l.BrokerID}
+	if !l.Time.IsZero() {
+		r.Time = l.Time.Unix()
+	}
+	return r, nil
+}
+
+// GetLockout returns whether a user is locked out after too many failed authentication attempts, and its recent
+// failed attempts.
+func (s Service) GetLockout(ctx context.Context, req *authd.LockoutRequest) (*authd.Lockout, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no user name provided")
+	}
+
+	l := s.userManager.Lockout(req.GetName())
+
+	r := &authd.Lockout{LockedOut: l.LockedOut}
+	if !l.LockedUntil.IsZero() {
+		r.LockedUntil = l.LockedUntil.Unix()
+	}
+	for _, f := range l.FailedLogins {
+		r.FailedLogins = append(r.FailedLogins, &authd.FailedLogin{Time: f.Time.Unix(), Source: f.Source})
+	}
+	return r, nil
+}
+
+// UnlockRemoteHost ends the lockout of a remote host after too many failed authentication attempts of any user from it.
+func (s Service) UnlockRemoteHost(ctx context.Context, req *authd.UnlockRemoteHostRequest) (*authd.Empty, error) {
+	if req.GetHost() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no remote host provided")
+	}
+
+	if !s.userManager.UnlockRemoteHost(req.GetHost(), permissions.Caller(ctx)) {
+		return nil, status.Errorf(codes.NotFound, "no failed authentication attempts from remote host %q", req.GetHost())
+	}
+
+	return &authd.Empty{}, nil
+}
+
+// GetMetrics returns statistics about the users and groups, about the database and about the sessions.
+func (s Service) GetMetrics(ctx context.Context, req *authd.Empty) (*authd.Metrics, error) {
+	m, err := s.userManager.Metrics()
+	if err != nil {
+		return nil, err
+	}
+
+	return &authd.Metrics{
+		Users:                         uint32(m.Users),
+		Groups:                        uint32(m.Groups),
+		UsersCreatedLastHour:          uint32(m.UsersCreatedLastHour),
+		DatabaseSize:                  m.DatabaseSize,
+		WriteTransactions:             m.WriteTransactions,
+		AverageWriteTransactionTimeUs: m.AverageWriteTransactionTime.Microseconds(),
+		MaxWriteTransactionTimeUs:     m.MaxWriteTransactionTime.Microseconds(),
+		ReapedSessions:                s.brokerManager.ReapedSessions(),
+		TimedOutSessions:              s.brokerManager.TimedOutSessions(),
+	}, nil
+}
+
+// GetUserExtendedAttributes returns the extended attributes attached to an authd user by its broker.
+func (s Service) GetUserExtendedAttributes(ctx context.Context, req *authd.ExtendedAttributesRequest) (*authd.ExtendedAttributes, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no user name provided")
+	}
+
+	attributes, err := s.userManager.ExtendedAttributes(req.GetName())
+	if errors.Is(err, users.NoDataFoundError{}) {
+		return nil, status.Errorf(codes.NotFound, "user %q not found", req.GetName())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &authd.ExtendedAttributes{Attributes: attributes}, nil
+}
+
+// AdoptUser moves a user of the local passwd file under the management of authd, provided by the requested broker.
+// The local entry of the user must then be removed by the caller, as the passwd file is read-only for the daemon.
+func (s Service) AdoptUser(ctx context.Context, req *authd.AdoptUserRequest) (*authd.PasswdEntry, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no user name provided")
+	}
+	if req.GetBroker() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no broker provided")
+	}
+
+	var brokerID string
+	for _, b := range s.brokerManager.AvailableBrokers() {
+		if b.ID == req.GetBroker() || b.Name == req.GetBroker() {
+			brokerID = b.ID
+			break
+		}
+	}
+	if brokerID == "" {
+		return nil, status.Errorf(codes.NotFound, "broker %q not found", req.GetBroker())
+	}
+	if brokerID == brokers.LocalBrokerName {
+		return nil, status.Error(codes.InvalidArgument, "users can't be adopted by the local broker")
+	}
+
+	u, err := s.userManager.AdoptUser(req.GetName(), brokerID, permissions.Caller(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &authd.PasswdEntry{
+		Name:    u.Name,
+		Passwd:  "x",
+		Uid:     u.UID,
+		Gid:     u.GID,
+		Gecos:   u.Gecos,
+		Homedir: u.Dir,
+		Shell:   u.Shell,
+	}, nil
+}
+
+// ListUsers returns a page of the authd users matching the filters of the request, sorted by UID.
+func (s Service) ListUsers(ctx context.Context, req *authd.ListUsersRequest) (*authd.ListUsersResponse, error) {
+	page, err := pageFromRequest(req.GetPageSize(), req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	filter := types.UserFilter{
+		BrokerID: req.GetBrokerId(),
+		Locked:   req.Locked,
+		Stale:    req.Stale,
+		MinUID:   req.GetMinUid(),
+		MaxUID:   req.GetMaxUid(),
+	}
+	if req.GetLastLoginBefore() > 0 {
+		filter.LastLoginBefore = time.Unix(req.GetLastLoginBefore(), 0)
+	}
+
+	list, more, err := s.userManager.ListUsers(filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	var r authd.ListUsersResponse
+	for _, u := range list {
+		summary := &authd.UserSummary{
+			Name:     u.Name,
+			Uid:      u.UID,
+			Gid:      u.GID,
+			Gecos:    u.Gecos,
+			Homedir:  u.Dir,
+			Shell:    u.Shell,
+			BrokerId: u.BrokerID,
+			Locked:   u.Locked,
+			Stale:    u.Stale,
+		}
+		if !u.LastLogin.IsZero() {
+			summary.LastLogin = u.LastLogin.Unix()
+		}
+		r.Users = append(r.Users, summary)
+	}
+	if more {
+		r.NextPageToken = strconv.FormatUint(uint64(list[len(list)-1].UID), 10)
+	}
+
+	return &r, nil
+}
+
+// ListGroups returns a page of the authd groups matching the filters of the request, sorted by GID.
+func (s Service) ListGroups(ctx context.Context, req *authd.ListGroupsRequest) (*authd.ListGroupsResponse, error) {
+	page, err := pageFromRequest(req.GetPageSize(), req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	filter := types.GroupFilter{
+		Member: req.GetMember(),
+		MinGID: req.GetMinGid(),
+		MaxGID: req.GetMaxGid(),
+	}
+
+	list, more, err := s.userManager.ListGroups(filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	var r authd.ListGroupsResponse
+	for _, g := range list {
+		r.Groups = append(r.Groups, &authd.GroupEntry{
+			Name:    g.Name,
+			Passwd:  g.Passwd,
+			Gid:     g.GID,
+			Members: g.Users,
+		})
+	}
+	if more {
+		r.NextPageToken = strconv.FormatUint(uint64(list[len(list)-1].GID), 10)
+	}
+
+	return &r, nil
+}
+
+// pageFromRequest returns the page selected by the size and the token of a listing request. The token is the last ID
+// of the previous page.
+func pageFromRequest(size uint32, token string) (types.Page, error) {
+	page := types.Page{Size: int(size)}
+	if token == "" {
+		return page, nil
+	}
+
+	after, err := strconv.ParseUint(token, 10, 32)
+	if err != nil {
+		return types.Page{}, status.Errorf(codes.InvalidArgument, "invalid page token %q", token)
+	}
+	page.After = uint32(after)
+	return page, nil
+}
+
+// GetLogLevel returns the level of the logs of the daemon and the users and brokers whose debug logs are printed
+// whatever the level.
+func (s Service) GetLogLevel(ctx context.Context, req *authd.Empty) (*authd.LogLevel, error) {
+	scope := log.DebugScope()
+	return &authd.LogLevel{
+		Level:        strings.ToLower(log.GetLevel().String()),
+		DebugUsers:   scope[log.UserField],
+		DebugBrokers: scope[log.BrokerField],
+	}, nil
+}
+
+// SetLogLevel changes the level of the logs of the daemon, and the users and brokers whose debug logs are printed
+// whatever the level, without restarting it. The level is reset to the configured verbosity when the configuration is
+// reloaded.
+func (s Service) SetLogLevel(ctx context.Context, req *authd.LogLevel) (*authd.LogLevel, error) {
+	level := log.GetLevel()
+	if req.GetLevel() != "" {
+		if err := level.UnmarshalText([]byte(req.GetLevel())); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid log level %q, must be one of debug, info, warn or error", req.GetLevel())
+		}
+	}
+
+	var brokerIDs []string
+	for _, name := range req.GetDebugBrokers() {
+		var brokerID string
+		for _, b := range s.brokerManager.AvailableBrokers() {
+			if b.ID == name || b.Name == name {
+				brokerID = b.ID
+				break
+			}
+		}
+		if brokerID == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "broker %q not found", name)
+		}
+		brokerIDs = append(brokerIDs, brokerID)
+	}
+
+	log.Infof(ctx, "Log level set to %s by %s, debug logs enabled for users %v and brokers %v",
+		level, permissions.Caller(ctx), req.GetDebugUsers(), brokerIDs)
+	log.SetLevel(level)
+	log.SetDebugScope(map[string][]string{
+		log.UserField:   req.GetDebugUsers(),
+		log.BrokerField: brokerIDs,
+	})
+
+	return s.GetLogLevel(ctx, &authd.Empty{})
+}
+
+// ListSessions returns the authentication sessions in progress, the oldest first.
+func (s Service) ListSessions(ctx context.Context, req *authd.Empty) (*authd.Sessions, error) {
+	var sessions []*authd.Session
+	for _, session := range s.brokerManager.Sessions() {
+		sessions = append(sessions, &authd.Session{
+			Id:         session.ID,
+			Username:   session.Username,
+			BrokerId:   session.BrokerID,
+			BrokerName: session.BrokerName,
+			Mode:       session.Mode,
+			AuthMode:   session.AuthMode,
+			State:      string(session.State),
+			Started:    session.Started.Unix(),
+		})
+	}
+
+	return &authd.Sessions{Sessions: sessions}, nil
+}
+
+// TerminateSession ends an authentication session in progress, for example a stuck one, without restarting the
+// daemon. Its client gets an error on its next request.
+func (s Service) TerminateSession(ctx context.Context, req *authd.TerminateSessionRequest) (*authd.Empty, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no session ID provided")
+	}
+
+	err := s.brokerManager.TerminateSession(ctx, req.GetId())
+	if errors.Is(err, brokers.ErrSessionNotFound) {
+		return nil, status.Errorf(codes.NotFound, "session %q not found", req.GetId())
+	}
+	log.Infof(ctx, "Session %q terminated by %s", req.GetId(), permissions.Caller(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &authd.Empty{}, nil
+}
+
+// GetLocalGroupsDryRun returns whether the changes of the local groups are only logged.
+func (s Service) GetLocalGroupsDryRun(ctx context.Context, req *authd.Empty) (*authd.LocalGroupsDryRun, error) {
+	return &authd.LocalGroupsDryRun{Enabled: s.userManager.Config().LocalGroupsDryRun}, nil
+}
+
+// SetLocalGroupsDryRun changes whether the changes of the local groups are only logged, without restarting the daemon.
+// It's reset to the configured value when the configuration is reloaded.
+func (s Service) SetLocalGroupsDryRun(ctx context.Context, req *authd.LocalGroupsDryRun) (*authd.LocalGroupsDryRun, error) {
+	s.userManager.SetLocalGroupsDryRun(req.GetEnabled())
+	log.Infof(ctx, "Dry run of the local groups changes set to %t by %s", req.GetEnabled(), permissions.Caller(ctx))
+
+	return s.GetLocalGroupsDryRun(ctx, &authd.Empty{})
+}
+
+// ReconcileLocalGroups reports the differences between the memberships in local groups granted by authd and the local
+// group file, and repairs them if requested.
+func (s Service) ReconcileLocalGroups(ctx context.Context, req *authd.ReconcileLocalGroupsRequest) (*authd.LocalGroupsDrift, error) {
+	drift, err := s.userManager.ReconcileLocalGroups(req.GetRepair(), permissions.Caller(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*authd.LocalGroupDrift
+	for _, d := range drift {
+		res = append(res, &authd.LocalGroupDrift{
+			User:     d.User,
+			Group:    d.Group,
+			Kind:     d.Kind,
+			Repaired: d.Repaired,
+		})
+	}
+
+	return &authd.LocalGroupsDrift{Drift: res}, nil
+}
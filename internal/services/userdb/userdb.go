@@ -0,0 +1,273 @@
+// Package userdb implements the io.systemd.UserDatabase varlink interface, so that the systemd components, like
+// userdbctl or systemd-homed, can query the users and groups of authd natively, in addition to the NSS module.
+package userdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/internal/varlink"
+	"github.com/ubuntu/authd/log"
+)
+
+// ServiceName is the name of the service of authd in the user database of systemd, which is also the name of its
+// socket in the directory of the services, /run/systemd/userdb.
+const ServiceName = "com.ubuntu.authd"
+
+// The errors of the io.systemd.UserDatabase interface.
+const (
+	errNoRecordFound          = "io.systemd.UserDatabase.NoRecordFound"
+	errBadService             = "io.systemd.UserDatabase.BadService"
+	errConflictingRecordFound = "io.systemd.UserDatabase.ConflictingRecordFound"
+)
+
+// disposition is the disposition of the users and groups of authd, which are regular ones, in the JSON user records of
+// systemd.
+const disposition = "regular"
+
+// Service is the io.systemd.UserDatabase varlink service of authd, backed by the users of its database.
+type Service struct {
+	userManager *users.Manager
+}
+
+// NewService returns a new io.systemd.UserDatabase varlink service.
+func NewService(ctx context.Context, userManager *users.Manager) Service {
+	log.Debug(ctx, "Building new varlink user database service")
+
+	return Service{userManager: userManager}
+}
+
+// NewServer returns a varlink server of the service.
+func (s Service) NewServer() *varlink.Server {
+	return varlink.NewServer(map[string]varlink.MethodHandler{
+		"io.systemd.UserDatabase.GetUserRecord":  s.GetUserRecord,
+		"io.systemd.UserDatabase.GetGroupRecord": s.GetGroupRecord,
+		"io.systemd.UserDatabase.GetMemberships": s.GetMemberships,
+	})
+}
+
+// userRecord is a JSON user record of systemd, with the fields provided by authd.
+type userRecord struct {
+	UserName      string `json:"userName"`
+	UID           uint32 `json:"uid"`
+	GID           uint32 `json:"gid"`
+	RealName      string `json:"realName,omitempty"`
+	HomeDirectory string `json:"homeDirectory,omitempty"`
+	Shell         string `json:"shell,omitempty"`
+	Disposition   string `json:"disposition"`
+	Service       string `json:"service"`
+}
+
+// groupRecord is a JSON group record of systemd, with the fields provided by authd.
+type groupRecord struct {
+	GroupName   string   `json:"groupName"`
+	GID         uint32   `json:"gid"`
+	Members     []string `json:"members,omitempty"`
+	Disposition string   `json:"disposition"`
+	Service     string   `json:"service"`
+}
+
+// recordReply is the reply of the lookup of a user or group record. The records of authd are always complete, as they
+// have no privileged section.
+type recordReply struct {
+	Record     any  `json:"record"`
+	Incomplete bool `json:"incomplete"`
+}
+
+// membershipReply is a reply of the lookup of the memberships.
+type membershipReply struct {
+	UserName  string `json:"userName"`
+	GroupName string `json:"groupName"`
+}
+
+// GetUserRecord returns the record of the user with the given UID or name, or all of them if none is given.
+func (s Service) GetUserRecord(_ context.Context, call varlink.Call, reply varlink.Reply) error {
+	var params struct {
+		serviceParameters
+		UID      *uint32 `json:"uid"`
+		UserName string  `json:"userName"`
+	}
+	if err := parseParameters(call, &params); err != nil {
+		return err
+	}
+
+	var u types.UserEntry
+	var err error
+	switch {
+	case params.UID == nil && params.UserName == "":
+		all, err := s.userManager.AllUsers()
+		if err != nil {
+			return err
+		}
+		records := make([]any, 0, len(all))
+		for _, u := range all {
+			records = append(records, recordReply{Record: newUserRecord(u)})
+		}
+		return replyAll(call, reply, records)
+	case params.UID != nil:
+		u, err = s.userManager.UserByID(*params.UID)
+	default:
+		u, err = s.userManager.UserByName(params.UserName)
+	}
+	if err != nil {
+		return lookupError(err)
+	}
+	if params.UserName != "" && u.Name != params.UserName {
+		return varlink.Error{Name: errConflictingRecordFound}
+	}
+
+	return reply(recordReply{Record: newUserRecord(u)}, false)
+}
+
+// GetGroupRecord returns the record of the group with the given GID or name, or all of them if none is given.
+func (s Service) GetGroupRecord(_ context.Context, call varlink.Call, reply varlink.Reply) error {
+	var params struct {
+		serviceParameters
+		GID       *uint32 `json:"gid"`
+		GroupName string  `json:"groupName"`
+	}
+	if err := parseParameters(call, &params); err != nil {
+		return err
+	}
+
+	var g types.GroupEntry
+	var err error
+	switch {
+	case params.GID == nil && params.GroupName == "":
+		all, err := s.userManager.AllGroups()
+		if err != nil {
+			return err
+		}
+		records := make([]any, 0, len(all))
+		for _, g := range all {
+			records = append(records, recordReply{Record: newGroupRecord(g)})
+		}
+		return replyAll(call, reply, records)
+	case params.GID != nil:
+		g, err = s.userManager.GroupByID(*params.GID)
+	default:
+		g, err = s.userManager.GroupByName(params.GroupName)
+	}
+	if err != nil {
+		return lookupError(err)
+	}
+	if params.GroupName != "" && g.Name != params.GroupName {
+		return varlink.Error{Name: errConflictingRecordFound}
+	}
+
+	return reply(recordReply{Record: newGroupRecord(g)}, false)
+}
+
+// GetMemberships returns the groups of the user, the members of the group, or all the memberships if neither is given.
+func (s Service) GetMemberships(_ context.Context, call varlink.Call, reply varlink.Reply) error {
+	var params struct {
+		serviceParameters
+		UserName  string `json:"userName"`
+		GroupName string `json:"groupName"`
+	}
+	if err := parseParameters(call, &params); err != nil {
+		return err
+	}
+
+	var groups []types.GroupEntry
+	if params.GroupName != "" {
+		g, err := s.userManager.GroupByName(params.GroupName)
+		if err != nil {
+			return lookupError(err)
+		}
+		groups = []types.GroupEntry{g}
+	} else {
+		var err error
+		if groups, err = s.userManager.AllGroups(); err != nil {
+			return err
+		}
+	}
+
+	var memberships []any
+	for _, g := range groups {
+		for _, name := range g.Users {
+			if params.UserName != "" && name != params.UserName {
+				continue
+			}
+			memberships = append(memberships, membershipReply{UserName: name, GroupName: g.Name})
+		}
+	}
+	return replyAll(call, reply, memberships)
+}
+
+// serviceParameters is the parameter common to all the methods, the service the call is addressed to.
+type serviceParameters struct {
+	Service string `json:"service"`
+}
+
+func (p serviceParameters) service() string {
+	return p.Service
+}
+
+// parseParameters decodes the parameters of the call into params, and checks that the call is addressed to the
+// service of authd.
+func parseParameters(call varlink.Call, params interface{ service() string }) error {
+	if len(call.Parameters) > 0 {
+		if err := json.Unmarshal(call.Parameters, params); err != nil {
+			return varlink.Error{Name: varlink.ErrInvalidParameter, Parameters: map[string]string{"parameter": err.Error()}}
+		}
+	}
+	if params.service() != ServiceName {
+		return varlink.Error{Name: errBadService}
+	}
+	return nil
+}
+
+// replyAll sends all the replies of a call asking for more. There must be at least one, as systemd expects
+// NoRecordFound otherwise.
+func replyAll(call varlink.Call, reply varlink.Reply, replies []any) error {
+	if len(replies) == 0 {
+		return varlink.Error{Name: errNoRecordFound}
+	}
+	if len(replies) > 1 && !call.More {
+		return varlink.Error{Name: varlink.ErrExpectedMore}
+	}
+	for i, r := range replies {
+		if err := reply(r, i < len(replies)-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupError returns the varlink error of a failed lookup of a user or group.
+func lookupError(err error) error {
+	if errors.Is(err, users.NoDataFoundError{}) {
+		return varlink.Error{Name: errNoRecordFound}
+	}
+	return err
+}
+
+// newUserRecord returns the JSON user record of the user.
+func newUserRecord(u types.UserEntry) userRecord {
+	return userRecord{
+		UserName:      u.Name,
+		UID:           u.UID,
+		GID:           u.GID,
+		RealName:      u.Gecos,
+		HomeDirectory: u.Dir,
+		Shell:         u.Shell,
+		Disposition:   disposition,
+		Service:       ServiceName,
+	}
+}
+
+// newGroupRecord returns the JSON group record of the group.
+func newGroupRecord(g types.GroupEntry) groupRecord {
+	return groupRecord{
+		GroupName:   g.Name,
+		GID:         g.GID,
+		Members:     slices.Clone(g.Users),
+		Disposition: disposition,
+		Service:     ServiceName,
+	}
+}
@@ -0,0 +1,191 @@
+package userdb_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/services/userdb"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/varlink"
+	"github.com/ubuntu/authd/log"
+)
+
+func TestGetUserRecord(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		parameters string
+		more       bool
+		sourceDB   string
+
+		wantErr string
+	}{
+		"Get_user_by_name":            {parameters: `"userName":"user1"`},
+		"Get_user_by_uid":             {parameters: `"uid":2222`},
+		"Get_user_by_name_and_uid":    {parameters: `"uid":3333,"userName":"user3"`},
+		"Get_all_users_with_more":     {more: true},
+		"Get_single_user_with_more":   {parameters: `"userName":"user1"`, more: true},
+		"Get_all_users_in_single_one": {sourceDB: "single.db.yaml"},
+
+		"Error_when_service_is_not_authd":        {parameters: `"userName":"user1","service":"io.systemd.Multiplexer"`, wantErr: "io.systemd.UserDatabase.BadService"},
+		"Error_when_user_does_not_exist":         {parameters: `"userName":"doesnotexist"`, wantErr: "io.systemd.UserDatabase.NoRecordFound"},
+		"Error_when_uid_does_not_exist":          {parameters: `"uid":4242`, wantErr: "io.systemd.UserDatabase.NoRecordFound"},
+		"Error_when_uid_and_name_do_not_match":   {parameters: `"uid":1111,"userName":"user2"`, wantErr: "io.systemd.UserDatabase.ConflictingRecordFound"},
+		"Error_when_all_users_without_more":      {wantErr: "org.varlink.service.ExpectedMore"},
+		"Error_when_there_is_no_user":            {more: true, sourceDB: "empty.db.yaml", wantErr: "io.systemd.UserDatabase.NoRecordFound"},
+		"Error_when_parameters_are_not_expected": {parameters: `"uid":"user1"`, wantErr: "org.varlink.service.InvalidParameter"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := userdb.NewService(context.Background(), newUserManagerForTests(t, tc.sourceDB))
+			got, err := call(t, s.GetUserRecord, tc.parameters, tc.more)
+			requireExpectedReplies(t, got, err, tc.wantErr)
+		})
+	}
+}
+
+func TestGetGroupRecord(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		parameters string
+		more       bool
+		sourceDB   string
+
+		wantErr string
+	}{
+		"Get_group_by_name":         {parameters: `"groupName":"commongroup"`},
+		"Get_group_by_gid":          {parameters: `"gid":11111`},
+		"Get_group_by_name_and_gid": {parameters: `"gid":22222,"groupName":"group2"`},
+		"Get_all_groups_with_more":  {more: true},
+
+		"Error_when_service_is_not_authd":      {parameters: `"groupName":"group1","service":"io.systemd.Multiplexer"`, wantErr: "io.systemd.UserDatabase.BadService"},
+		"Error_when_group_does_not_exist":      {parameters: `"groupName":"doesnotexist"`, wantErr: "io.systemd.UserDatabase.NoRecordFound"},
+		"Error_when_gid_and_name_do_not_match": {parameters: `"gid":11111,"groupName":"group2"`, wantErr: "io.systemd.UserDatabase.ConflictingRecordFound"},
+		"Error_when_all_groups_without_more":   {wantErr: "org.varlink.service.ExpectedMore"},
+		"Error_when_there_is_no_group":         {more: true, sourceDB: "empty.db.yaml", wantErr: "io.systemd.UserDatabase.NoRecordFound"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := userdb.NewService(context.Background(), newUserManagerForTests(t, tc.sourceDB))
+			got, err := call(t, s.GetGroupRecord, tc.parameters, tc.more)
+			requireExpectedReplies(t, got, err, tc.wantErr)
+		})
+	}
+}
+
+func TestGetMemberships(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		parameters string
+		more       bool
+
+		wantErr string
+	}{
+		"Get_groups_of_user":          {parameters: `"userName":"user2"`, more: true},
+		"Get_members_of_group":        {parameters: `"groupName":"commongroup"`, more: true},
+		"Get_membership_of_user":      {parameters: `"userName":"user3","groupName":"commongroup"`},
+		"Get_all_memberships":         {more: true},
+		"Get_single_group_of_user":    {parameters: `"userName":"user1"`},
+		"Get_single_member_of_group":  {parameters: `"groupName":"group1"`},
+		"Get_all_groups_of_user_more": {parameters: `"userName":"user1"`, more: true},
+
+		"Error_when_service_is_not_authd":     {parameters: `"userName":"user1","service":"io.systemd.Multiplexer"`, wantErr: "io.systemd.UserDatabase.BadService"},
+		"Error_when_group_does_not_exist":     {parameters: `"groupName":"doesnotexist"`, wantErr: "io.systemd.UserDatabase.NoRecordFound"},
+		"Error_when_user_is_not_in_any_group": {parameters: `"userName":"doesnotexist"`, more: true, wantErr: "io.systemd.UserDatabase.NoRecordFound"},
+		"Error_when_user_is_not_in_the_group": {parameters: `"userName":"user1","groupName":"commongroup"`, wantErr: "io.systemd.UserDatabase.NoRecordFound"},
+		"Error_when_several_without_more":     {parameters: `"userName":"user2"`, wantErr: "org.varlink.service.ExpectedMore"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := userdb.NewService(context.Background(), newUserManagerForTests(t, ""))
+			got, err := call(t, s.GetMemberships, tc.parameters, tc.more)
+			requireExpectedReplies(t, got, err, tc.wantErr)
+		})
+	}
+}
+
+// reply is a reply of a method recorded by the tests.
+type reply struct {
+	Parameters map[string]any
+	Continues  bool
+}
+
+// call calls the method with the parameters, which are the members of a JSON object addressed to authd unless they set
+// the service, and returns its replies.
+func call(t *testing.T, method varlink.MethodHandler, parameters string, more bool) (replies []reply, err error) {
+	t.Helper()
+
+	// The last member of a JSON object wins when it's duplicated.
+	params := `{"service":"` + userdb.ServiceName + `"`
+	if parameters != "" {
+		params += "," + parameters
+	}
+	params += "}"
+
+	err = method(context.Background(), varlink.Call{Parameters: json.RawMessage(params), More: more}, func(parameters any, continues bool) error {
+		d, err := json.Marshal(parameters)
+		require.NoError(t, err, "Replies should be encodable")
+		r := reply{Continues: continues}
+		require.NoError(t, json.Unmarshal(d, &r.Parameters), "Replies should be JSON objects")
+		replies = append(replies, r)
+		return nil
+	})
+	return replies, err
+}
+
+// requireExpectedReplies asserts that the method failed with the expected varlink error, or that its replies match the
+// golden file.
+func requireExpectedReplies(t *testing.T, got []reply, err error, wantErr string) {
+	t.Helper()
+
+	if wantErr != "" {
+		var verr varlink.Error
+		require.True(t, errors.As(err, &verr), "The method should return a varlink error, got: %v", err)
+		require.Equal(t, wantErr, verr.Name, "The method should return the expected error")
+		require.Empty(t, got, "The method should not reply when it fails")
+		return
+	}
+	require.NoError(t, err, "The method should not return an error")
+	for i, r := range got {
+		require.Equal(t, i < len(got)-1, r.Continues, "All the replies but the last one should continue")
+	}
+
+	golden.CheckOrUpdateYAML(t, got)
+}
+
+// newUserManagerForTests returns a user manager with the users of the database in testdata.
+func newUserManagerForTests(t *testing.T, sourceDB string) *users.Manager {
+	t.Helper()
+
+	cacheDir := t.TempDir()
+	if sourceDB == "" {
+		sourceDB = "cache.db.yaml"
+	}
+	cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", sourceDB), cacheDir)
+
+	m, err := users.NewManager(users.DefaultConfig, cacheDir)
+	require.NoError(t, err, "Setup: could not create user manager")
+
+	t.Cleanup(func() { _ = m.Stop() })
+	return m
+}
+
+func TestMain(m *testing.M) {
+	log.SetLevel(log.DebugLevel)
+
+	m.Run()
+}
@@ -4,6 +4,8 @@ package services
 
 // Stop calls the brokerManager function that stops and cleans the examplebroker files.
 func (m *Manager) Stop() error {
+	// The sessions are ended before stopping the example brokers.
+	err := m.stop()
 	m.brokerManager.Stop()
-	return m.stop()
+	return err
 }
@@ -3,16 +3,20 @@ package testutils
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"html/template"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/godbus/dbus/v5"
 	"github.com/godbus/dbus/v5/introspect"
+	"github.com/ubuntu/authd/internal/brokers/challenge"
 	"github.com/ubuntu/authd/internal/brokers/layouts"
 )
 
@@ -38,6 +42,14 @@ const (
 	authNext = "next"
 )
 
+const (
+	// mockRSAEncryptionKey and the mockX25519EncryptionKey ones are public keys of the versions of the encryption of the
+	// challenges, for the sessions of the brokers supporting several of them.
+	mockRSAEncryptionKey     = "MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQC7NpcIqJrpy9bnzNfnRAj+YQCtgxoyt54UijM6Vj+6JhIoM8qgC8pZXCJ/UsWeeW+v9FGQ9XB8Y+TY3stpaykGV2ORh/C6bCiqiCewpu9aYgipFq2wFmVIHsio1h8sLQIuWEFZoa0yLGu7fDhDRzJH9QaNh9aOzy5ZTx5w9ucy2QIDAQAB"
+	mockX25519EncryptionKey  = "MCowBQYDK2VuAyEAmr6n0xjb9TAVC8t/ZF1fIEATvYBaehrFNfrP0UPP1kM="
+	mockX25519EncryptionKey2 = "MCowBQYDK2VuAyEA2m5dzfwmEB3S+SWd1neYii+Ynnx0SryKCC+Zo5pZYAg="
+)
+
 var brokerConfigTemplate = `[authd]
 name = %s
 brand_icon = mock_icon.png
@@ -130,6 +142,12 @@ func (b *BrokerBusMock) NewSession(username, lang, mode string) (sessionID, encr
 	if parsedUsername == "NS_no_id" {
 		return "", username + "_key", nil
 	}
+	if parsedUsername == "NS_multiple_keys" {
+		return GenerateSessionID(username), mockX25519EncryptionKey + challenge.KeysSeparator + mockRSAEncryptionKey, nil
+	}
+	if parsedUsername == "NS_only_x25519_keys" {
+		return GenerateSessionID(username), mockX25519EncryptionKey + challenge.KeysSeparator + mockX25519EncryptionKey2, nil
+	}
 	return GenerateSessionID(username), GenerateEncryptionKey(b.name), nil
 }
 
@@ -284,6 +302,10 @@ func (b *BrokerBusMock) IsAuthenticated(sessionID, authenticationData string) (a
 	case "IA_invalid_userinfo":
 		data = `{"userinfo": "not valid"}`
 
+	case "IA_denied":
+		access = authDenied
+		data = `{"message": "denied by the broker"}`
+
 	case "IA_denied_without_data":
 		access = authDenied
 		data = ""
@@ -292,6 +314,41 @@ func (b *BrokerBusMock) IsAuthenticated(sessionID, authenticationData string) (a
 		access = authRetry
 		data = ""
 
+	case "IA_retry_with_rotated_key":
+		access = authRetry
+		data = fmt.Sprintf(`{"message": "try again", "encryption_key": "%s-rotated-key"}`, b.name)
+
+	case "IA_next_with_rotated_key":
+		access = authNext
+		data = fmt.Sprintf(`{"encryption_key": "%s-rotated-key"}`, b.name)
+
+	case "IA_next_with_invalid_rotated_key":
+		access = authNext
+		data = `{"encryption_key": 42}`
+
+	case "IA_granted_with_device_token":
+		data = fmt.Sprintf(`{"userinfo": %s, "device_token": "%s-device-token"}`, userInfoFromName(sessionID, nil), b.name)
+
+	case "IA_granted_with_invalid_device_token":
+		data = fmt.Sprintf(`{"userinfo": %s, "device_token": ""}`, userInfoFromName(sessionID, nil))
+
+	case "IA_next_without_device_token":
+		// The second factor is skipped if the device token issued by the broker is presented.
+		var d map[string]any
+		if err := json.Unmarshal([]byte(authenticationData), &d); err != nil || d["device_token"] != b.name+"-device-token" {
+			access = authNext
+			data = ""
+		}
+
+	case "IA_retry_with_access_context":
+		// The context of the access policy given to the broker is returned in the message, for the tests to check it.
+		var d map[string]string
+		if err := json.Unmarshal([]byte(authenticationData), &d); err != nil {
+			return "", "", dbus.MakeFailedError(err)
+		}
+		access = authRetry
+		data = fmt.Sprintf(`{"message": "access decision: %s, reasons: %s"}`, d["access_decision"], d["access_reasons"])
+
 	case "IA_next_with_data":
 		access = authNext
 		data = `{"message": "there should not be a message here"}`
@@ -333,6 +390,18 @@ func (b *BrokerBusMock) UserPreCheck(username string) (userinfo string, dbusErr
 	return userInfoFromName(username, nil), nil
 }
 
+// ListUsers returns the users expected by the broker, including an invalid one, or an error if requested. Brokers
+// whose name contains "ListUsers_not_supported" behave as if they didn't implement the method.
+func (b *BrokerBusMock) ListUsers() (usersinfo string, dbusErr *dbus.Error) {
+	if strings.Contains(b.name, "ListUsers_not_supported") {
+		return "", dbus.NewError("org.freedesktop.DBus.Error.UnknownMethod", []interface{}{"Unknown method ListUsers"})
+	}
+	if strings.Contains(b.name, "ListUsers_error") {
+		return "", dbus.MakeFailedError(fmt.Errorf("broker %q: ListUsers errored out", b.name))
+	}
+	return fmt.Sprintf(`[%s, %s, {"name": ""}]`, userInfoFromName("user-presync-1", nil), userInfoFromName("user-presync-2", nil)), nil
+}
+
 // parseSessionID is wrapper around the sessionID to remove some values appended during the tests.
 //
 // The sessionID can have multiple values appended to differentiate between subtests and avoid concurrency conflicts,
@@ -361,6 +430,8 @@ func userInfoFromName(sessionID string, extraGroups []groupJSONInfo) string {
 	shell := "/bin/sh/" + parsedID
 	gecos := "gecos for " + parsedID
 	ugid := "ugid-" + parsedID
+	var passwordExpiresAt int64
+	var extendedAttributes map[string]string
 
 	switch parsedID {
 	case "IA_info_empty_user_name":
@@ -379,6 +450,12 @@ func userInfoFromName(sessionID string, extraGroups []groupJSONInfo) string {
 		home = "this is not a homedir"
 	case "IA_info_invalid_shell":
 		shell = "this is not a valid shell"
+	case "IA_info_invalid_extended_attribute":
+		extendedAttributes = map[string]string{"not a valid key": "value"}
+	case "success_with_expired_password":
+		passwordExpiresAt = 1000
+	case "success_with_extended_attributes":
+		extendedAttributes = map[string]string{"department": "engineering", "employee_id": "1234"}
 	}
 
 	groups := []groupJSONInfo{{Name: group, UGID: ugid}}
@@ -395,13 +472,18 @@ func userInfoFromName(sessionID string, extraGroups []groupJSONInfo) string {
 	}
 
 	user := struct {
-		Name   string
-		UUID   string
-		Home   string
-		Shell  string
-		Groups []groupJSONInfo
-		Gecos  string
-	}{Name: name, Home: home, Shell: shell, Groups: groups, Gecos: gecos}
+		Name               string
+		UUID               string
+		Home               string
+		Shell              string
+		Groups             []groupJSONInfo
+		Gecos              string
+		PasswordExpiresAt  int64
+		ExtendedAttributes [][2]string
+	}{Name: name, Home: home, Shell: shell, Groups: groups, Gecos: gecos, PasswordExpiresAt: passwordExpiresAt}
+	for _, k := range slices.Sorted(maps.Keys(extendedAttributes)) {
+		user.ExtendedAttributes = append(user.ExtendedAttributes, [2]string{k, extendedAttributes[k]})
+	}
 
 	// only used for tests, we can ignore the template execution error as the returned data will be failing.
 	var buf bytes.Buffer
@@ -411,6 +493,15 @@ func userInfoFromName(sessionID string, extraGroups []groupJSONInfo) string {
 		"gecos": "{{.Gecos}}",
 		"dir": "{{.Home}}",
 		"shell": "{{.Shell}}",
+		{{- if .PasswordExpiresAt}}
+		"password_expires_at": {{.PasswordExpiresAt}},
+		{{- end}}
+		{{- if .ExtendedAttributes}}
+		"extended_attributes": { {{range $index, $a := .ExtendedAttributes}}
+			{{- if $index}}, {{end -}}
+			"{{index $a 0}}": "{{index $a 1}}"
+		{{- end}} },
+		{{- end}}
 		"avatar": "avatar for {{.Name}}",
 		"groups": [ {{range $index, $g := .Groups}}
 			{{- if $index}}, {{end -}}
@@ -89,6 +89,9 @@ verbosity: 2
 paths:
   cache: %s
   socket: %s
+# The coverage and the files of the tests are written outside of the paths allowed by the sandbox.
+sandbox:
+  enabled: false
 `, opts.cachePath, opts.socketPath)
 
 	configPath := filepath.Join(tempDir, "testconfig.yaml")
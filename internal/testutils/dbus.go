@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -116,7 +117,14 @@ func StartBusMock() (string, func(), error) {
 //
 // This function uses t.Setenv to set the DBUS_SYSTEM_BUS_ADDRESS environment, so it shouldn't be used in parallel tests
 // that rely on the mentioned variable.
+//
+// The workers running the inputs of the fuzz targets inherit the environment of the test process, and so share its
+// system bus mock instead of starting their own.
 func StartSystemBusMock() (func(), error) {
+	if isFuzzWorker() {
+		return func() {}, nil
+	}
+
 	busAddress, busCancel, err := StartBusMock()
 	if err != nil {
 		return nil, err
@@ -148,6 +156,11 @@ func GetSystemBusConnection(t *testing.T) (*dbus.Conn, error) {
 	return conn, nil
 }
 
+// isFuzzWorker returns whether the process is a worker started by the test process to run the inputs of a fuzz target.
+func isFuzzWorker() bool {
+	return slices.Contains(os.Args[1:], "-test.fuzzworker")
+}
+
 // isRunning checks if the system bus mock is running.
 func isRunning() bool {
 	busAddr := os.Getenv("DBUS_SYSTEM_BUS_ADDRESS")
@@ -0,0 +1,201 @@
+// Package vhs generates the tapes of VHS, the terminal recorder running the integration tests of the clients, so that
+// the tests don't need a hand-written tape for each case.
+package vhs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Settings of the terminal recording a tape.
+const (
+	Width       = "Width"
+	Height      = "Height"
+	FontFamily  = "FontFamily"
+	FontSize    = "FontSize"
+	Padding     = "Padding"
+	Margin      = "Margin"
+	Shell       = "Shell"
+	WaitTimeout = "WaitTimeout"
+	WaitPattern = "WaitPattern"
+	TypingSpeed = "TypingSpeed"
+)
+
+// Key is a key of the keyboard pressed in a tape.
+type Key string
+
+// Keys of the keyboard pressed in the tapes.
+const (
+	Enter     Key = "Enter"
+	Tab       Key = "Tab"
+	Escape    Key = "Escape"
+	Space     Key = "Space"
+	Backspace Key = "Backspace"
+	Up        Key = "Up"
+	Down      Key = "Down"
+	Left      Key = "Left"
+	Right     Key = "Right"
+)
+
+// Ctrl returns the key pressed with Ctrl, like Ctrl("C") to interrupt the command.
+func Ctrl(key string) Key {
+	return Key("Ctrl+" + key)
+}
+
+// Setting is a setting of the terminal recording a tape.
+type Setting struct {
+	Key   string
+	Value any
+}
+
+// Tape is a tape being generated. Its methods append a command to it, and return it for the commands to be chained.
+type Tape struct {
+	settings []Setting
+	lines    []string
+}
+
+// NewTape returns an empty tape.
+func NewTape() *Tape {
+	return &Tape{}
+}
+
+// Var returns the reference to the variable, to be used in the commands of a tape and replaced when it's run.
+func Var(name string) string {
+	return fmt.Sprintf("${%s}", name)
+}
+
+// Set sets the setting of the terminal, replacing its previous value.
+func (t *Tape) Set(key string, value any) *Tape {
+	for i, s := range t.settings {
+		if s.Key == key {
+			t.settings[i].Value = value
+			return t
+		}
+	}
+	t.settings = append(t.settings, Setting{Key: key, Value: value})
+	return t
+}
+
+// Settings returns the settings of the terminal, in the order they were first set.
+func (t *Tape) Settings() []Setting {
+	return t.settings
+}
+
+// Type types the text.
+func (t *Tape) Type(text string) *Tape {
+	return t.Command("Type " + quote(text))
+}
+
+// Press presses the key.
+func (t *Tape) Press(key Key) *Tape {
+	return t.Command(string(key))
+}
+
+// PressN presses the key n times.
+func (t *Tape) PressN(key Key, n int) *Tape {
+	return t.Command(fmt.Sprintf("%s %d", key, n))
+}
+
+// Wait waits for the prompt of the shell, or for the WaitPattern setting if it's set.
+func (t *Tape) Wait() *Tape {
+	return t.Command("Wait")
+}
+
+// WaitFor waits for the last line of the terminal to match the regular expression.
+func (t *Tape) WaitFor(pattern string) *Tape {
+	return t.Command(fmt.Sprintf("Wait /%s/", escapeSlashes(pattern)))
+}
+
+// WaitForText waits for the last line of the terminal to contain the text.
+func (t *Tape) WaitForText(text string) *Tape {
+	return t.WaitFor(regexp.QuoteMeta(text))
+}
+
+// WaitScreen waits for the whole terminal to match the regular expression.
+func (t *Tape) WaitScreen(pattern string) *Tape {
+	return t.Command(fmt.Sprintf("Wait+Screen /%s/", escapeSlashes(pattern)))
+}
+
+// WaitScreenText waits for the whole terminal to contain the text.
+func (t *Tape) WaitScreenText(text string) *Tape {
+	return t.WaitScreen(regexp.QuoteMeta(text))
+}
+
+// Sleep waits for the duration.
+func (t *Tape) Sleep(d time.Duration) *Tape {
+	return t.Command(fmt.Sprintf("Sleep %dms", d.Milliseconds()))
+}
+
+// Env sets the environment variable of the shell of the terminal.
+func (t *Tape) Env(name, value string) *Tape {
+	return t.Command(fmt.Sprintf("Env %s %s", name, quote(value)))
+}
+
+// Hide stops recording the terminal, until Show is called.
+func (t *Tape) Hide() *Tape {
+	return t.Command("Hide")
+}
+
+// Show records the terminal again.
+func (t *Tape) Show() *Tape {
+	return t.Command("Show")
+}
+
+// Hidden runs the commands added by f without recording them, and records the terminal once they are done, so that
+// only their result is part of the recording. It's followed by an empty line, separating the steps of the tape.
+func (t *Tape) Hidden(f func(t *Tape)) *Tape {
+	t.Hide()
+	f(t)
+	t.Show()
+	t.lines = append(t.lines, "")
+	return t
+}
+
+// Command appends a raw command, like the ones VHS is extended with by the tests.
+func (t *Tape) Command(command string) *Tape {
+	t.lines = append(t.lines, command)
+	return t
+}
+
+// Commands returns the commands of the tape, without its settings.
+func (t *Tape) Commands() string {
+	return strings.Join(t.lines, "\n")
+}
+
+// String returns the tape, with its settings followed by its commands.
+func (t *Tape) String() string {
+	var settings []string
+	for _, s := range t.settings {
+		v := s.Value
+		switch vv := v.(type) {
+		case time.Duration:
+			v = fmt.Sprintf("%dms", vv.Milliseconds())
+		case string:
+			// The wait pattern is a regular expression, it's not quoted.
+			if s.Key != WaitPattern {
+				v = quote(vv)
+			}
+		}
+		settings = append(settings, fmt.Sprintf("Set %s %v", s.Key, v))
+	}
+	return strings.Join(append(settings, t.lines...), "\n")
+}
+
+// quote quotes the text with the first of the quotes of VHS which it doesn't contain, as the strings of the tapes can't
+// be escaped.
+func quote(text string) string {
+	for _, q := range []string{`"`, `'`, "`"} {
+		if !strings.Contains(text, q) {
+			return q + text + q
+		}
+	}
+	panic(fmt.Sprintf("can't quote %q in a tape: it contains all the quotes", text))
+}
+
+// escapeSlashes replaces the slashes of the regular expression by their code, as they end the regular expressions of
+// the tapes even if they are escaped.
+func escapeSlashes(pattern string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(pattern, `\/`, "/"), "/", `\x2f`)
+}
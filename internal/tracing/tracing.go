@@ -0,0 +1,88 @@
+// Package tracing records the spans of the requests of the daemon, like the calls to the brokers during a login, and
+// exports them to an OpenTelemetry collector.
+package tracing
+
+import (
+	"context"
+
+	"github.com/ubuntu/decorate"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+const tracerName = "github.com/ubuntu/authd"
+
+// Config is the OpenTelemetry collector the spans are exported to.
+type Config struct {
+	// Endpoint is the address of the OTLP gRPC endpoint of the collector, like "localhost:4317". The spans are not
+	// recorded if empty.
+	Endpoint string
+	// Insecure connects to the collector without TLS.
+	Insecure bool
+	// SampleRatio is the ratio of the requests traced, between 0 and 1. All are traced by default.
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// Setup exports the spans to the collector of the configuration. The returned function exports the spans recorded but
+// not exported yet, and then stops exporting them.
+func Setup(ctx context.Context, config Config, version string) (shutdown func(context.Context) error, err error) {
+	defer decorate.OnError(&err, "can't set up tracing")
+
+	if config.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	// The exporter connects lazily, so that authd starts even if the collector is not available.
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName("authd"),
+			semconv.ServiceVersion(version),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// Start starts a span named after the operation, as a child of the span of ctx if any. The returned context holds the
+// new span.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the span, recording the error of the operation if any.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// UnaryServerInterceptor records a span for each gRPC request, parent of the spans of the operations it performs.
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, info.FullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.RPCSystemGRPC, attribute.String("rpc.method", info.FullMethod)))
+	resp, err := handler(ctx, req)
+	End(span, err)
+	return resp, err
+}
@@ -0,0 +1,118 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// recorder records the spans ended by the tests.
+var recorder = tracetest.NewSpanRecorder()
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		handlerErr error
+
+		wantStatus codes.Code
+	}{
+		"Records_request_and_its_operations": {wantStatus: codes.Unset},
+
+		"Records_error_of_request": {handlerErr: errors.New("some error"), wantStatus: codes.Error},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			method := "/authd.PAM/" + name
+			var traceID trace.TraceID
+			handler := func(ctx context.Context, req any) (any, error) {
+				_, span := tracing.Start(ctx, "broker.NewSession", attribute.String("broker.name", "some broker"))
+				traceID = span.SpanContext().TraceID()
+				tracing.End(span, nil)
+				return req, tc.handlerErr
+			}
+
+			resp, err := tracing.UnaryServerInterceptor(context.Background(), "request", &grpc.UnaryServerInfo{FullMethod: method}, handler)
+			require.ErrorIs(t, err, tc.handlerErr, "UnaryServerInterceptor should return the error of the handler")
+			require.Equal(t, "request", resp, "UnaryServerInterceptor should return the response of the handler")
+
+			spans := spansOfTrace(traceID)
+			require.Len(t, spans, 2, "The request and the operation should be recorded in the same trace")
+			child, parent := spans[0], spans[1]
+			require.Equal(t, "broker.NewSession", child.Name(), "The operation should be recorded first, as it ends first")
+			require.Equal(t, method, parent.Name(), "The request should be named after its method")
+			require.Equal(t, parent.SpanContext().SpanID(), child.Parent().SpanID(), "The operation should be a child of the request")
+			require.Equal(t, trace.SpanKindServer, parent.SpanKind(), "The request should be recorded as served")
+			require.Contains(t, child.Attributes(), attribute.String("broker.name", "some broker"), "The operation should have its attributes")
+			require.Equal(t, tc.wantStatus, parent.Status().Code, "The status of the request should be the expected one")
+		})
+	}
+}
+
+func TestEnd(t *testing.T) {
+	t.Parallel()
+
+	_, span := tracing.Start(context.Background(), "users.UpdateUser")
+	tracing.End(span, errors.New("some error"))
+
+	spans := spansOfTrace(span.SpanContext().TraceID())
+	require.Len(t, spans, 1, "The span should be recorded once ended")
+	require.Equal(t, codes.Error, spans[0].Status().Code, "The span should have an error status")
+	require.Equal(t, "some error", spans[0].Status().Description, "The status should describe the error")
+	require.Len(t, spans[0].Events(), 1, "The error should be recorded as an event of the span")
+}
+
+func TestSetup(t *testing.T) {
+	// This test replaces the global tracer provider, so it can't run in parallel with the other ones.
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	tests := map[string]struct {
+		config tracing.Config
+	}{
+		"Does_nothing_without_endpoint": {},
+		"Exports_to_endpoint":           {config: tracing.Config{Endpoint: "localhost:4317", Insecure: true, SampleRatio: 1}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			shutdown, err := tracing.Setup(context.Background(), tc.config, "1.0")
+			require.NoError(t, err, "Setup should not return an error, but did")
+
+			if tc.config.Endpoint != "" {
+				require.NotEqual(t, previous, otel.GetTracerProvider(), "Setup should install a tracer provider")
+			}
+
+			// The collector is not running, which doesn't matter as no span was recorded.
+			require.NoError(t, shutdown(context.Background()), "Shutdown should not return an error, but did")
+		})
+	}
+}
+
+// spansOfTrace returns the ended spans of the trace, in the order they ended.
+func spansOfTrace(traceID trace.TraceID) (spans []sdktrace.ReadOnlySpan) {
+	for _, s := range recorder.Ended() {
+		if s.SpanContext().TraceID() == traceID {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+func TestMain(m *testing.M) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	os.Exit(m.Run())
+}
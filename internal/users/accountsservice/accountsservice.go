@@ -0,0 +1,226 @@
+// Package accountsservice keeps the AccountsService data of the authd users in sync, so that the greeter and the
+// settings of the desktop display them properly.
+//
+// AccountsService reads the real name of the users from their GECOS, through NSS, and their account type from their
+// membership to the administrator groups, which are managed as local groups. The other data is stored in a key file
+// per user, in which we set the icon provided by the broker and mark the user as a regular one.
+package accountsservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	dbusName      = "org.freedesktop.Accounts"
+	dbusPath      = "/org/freedesktop/Accounts"
+	dbusInterface = "org.freedesktop.Accounts"
+
+	// maxIconSize is the maximum size of the icons, as AccountsService rejects the bigger ones.
+	maxIconSize = 1024 * 1024
+)
+
+// iconTypes are the content types of the icons which are copied.
+var iconTypes = []string{"image/png", "image/jpeg", "image/gif", "image/bmp", "image/webp"}
+
+type options struct {
+	refresh  func(method, name string) error
+	iconDirs []string
+}
+
+// Option represents an optional function to override AccountsService default values.
+type Option func(*options)
+
+// WithIconDirs sets the directories from which the icons of the users can be copied.
+func WithIconDirs(dirs []string) Option {
+	return func(o *options) {
+		o.iconDirs = dirs
+	}
+}
+
+// Update writes the AccountsService data of the user to dir, usually /var/lib/AccountsService. If icon is the path of
+// an image in one of the icon directories, it's copied as the icon of the user. The other keys of the user, which may
+// have been set from the desktop settings, are kept.
+func Update(dir, name, icon string, args ...Option) (err error) {
+	defer decorate.OnError(&err, "could not update AccountsService data of user %q", name)
+
+	opts := options{refresh: callAccountsService}
+	for _, arg := range args {
+		arg(&opts)
+	}
+
+	usersDir := filepath.Join(dir, "users")
+	if err := os.MkdirAll(usersDir, 0700); err != nil {
+		return err
+	}
+
+	keys := [][2]string{{"SystemAccount", "false"}}
+	if icon != "" {
+		// A broken icon must not prevent the other data from being updated.
+		iconPath, err := copyIcon(dir, name, icon, opts.iconDirs)
+		if err != nil {
+			log.Warningf(context.Background(), "Could not set icon %q of user %q: %v", icon, name, err)
+		} else {
+			keys = append(keys, [2]string{"Icon", iconPath})
+		}
+	}
+
+	keyFile := filepath.Join(usersDir, name)
+	content, err := os.ReadFile(keyFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	updated := setKeys(content, "User", keys)
+	if string(updated) == string(content) {
+		return nil
+	}
+	if err := writeFileAtomically(keyFile, updated, 0600); err != nil {
+		return err
+	}
+
+	// AccountsService only reads the key files when a user is loaded, so ask it to load the user again.
+	if err := opts.refresh("CacheUser", name); err != nil {
+		log.Debugf(context.Background(), "Could not notify AccountsService of the update of user %q: %v", name, err)
+	}
+	return nil
+}
+
+// Remove removes the AccountsService data of the user from dir.
+func Remove(dir, name string, args ...Option) (err error) {
+	defer decorate.OnError(&err, "could not remove AccountsService data of user %q", name)
+
+	opts := options{refresh: callAccountsService}
+	for _, arg := range args {
+		arg(&opts)
+	}
+
+	if err := opts.refresh("UncacheUser", name); err != nil {
+		log.Debugf(context.Background(), "Could not notify AccountsService of the removal of user %q: %v", name, err)
+	}
+
+	for _, path := range []string{filepath.Join(dir, "users", name), filepath.Join(dir, "icons", name)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyIcon copies the icon to the icons directory of AccountsService and returns its new path.
+//
+// The icon is provided by the broker and read as root, so it must be an image in one of the icon directories and must
+// not be a symlink, so that no other file can be exposed to the users.
+func copyIcon(dir, name, icon string, iconDirs []string) (string, error) {
+	if !filepath.IsAbs(icon) {
+		return "", errors.New("the icon must be an absolute path")
+	}
+
+	data, err := readIcon(icon, iconDirs)
+	if err != nil {
+		return "", err
+	}
+
+	iconsDir := filepath.Join(dir, "icons")
+	if err := os.MkdirAll(iconsDir, 0755); err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(iconsDir, name)
+	// The greeter reads the icons as an unprivileged user.
+	if err := writeFileAtomically(dest, data, 0644); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// readIcon reads the icon if it's an image in one of the icon directories.
+func readIcon(icon string, iconDirs []string) ([]byte, error) {
+	// The parent directories are resolved so that a symlink can't point outside of the icon directories, and the icon
+	// itself is opened without following symlinks.
+	parent, err := filepath.EvalSymlinks(filepath.Dir(icon))
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(iconDirs, func(d string) bool { return isInDir(parent, d) }) {
+		return nil, fmt.Errorf("the icon must be in one of the icon directories %v", iconDirs)
+	}
+
+	f, err := os.OpenFile(filepath.Join(parent, filepath.Base(icon)), os.O_RDONLY|unix.O_NOFOLLOW|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, errors.New("the icon must be a regular file")
+	}
+	if info.Size() > maxIconSize {
+		return nil, fmt.Errorf("the icon must not be bigger than %d bytes", maxIconSize)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, maxIconSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxIconSize {
+		return nil, fmt.Errorf("the icon must not be bigger than %d bytes", maxIconSize)
+	}
+	if t := http.DetectContentType(data); !slices.Contains(iconTypes, t) {
+		return nil, fmt.Errorf("the icon must be an image, not %q", t)
+	}
+	return data, nil
+}
+
+// isInDir returns whether path is in dir, once the symlinks of dir are resolved.
+func isInDir(path, dir string) bool {
+	if !filepath.IsAbs(dir) {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// writeFileAtomically writes the data to a temporary file which replaces path, so that AccountsService never reads a
+// partially written file.
+func writeFileAtomically(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".authd-tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Join(err, os.Remove(tmp))
+	}
+	return nil
+}
+
+// callAccountsService calls a method of AccountsService with the user name as argument.
+func callAccountsService(method, name string) error {
+	// Don't call dbus.SystemBus which caches globally system dbus (issues in tests)
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Object(dbusName, dbusPath).Call(dbusInterface+"."+method, 0, name).Err
+}
@@ -0,0 +1,125 @@
+package accountsservice_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users/accountsservice"
+)
+
+// png is the start of a PNG image, which is enough for its content type to be detected.
+const png = "\x89PNG\r\n\x1a\npicture"
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		existingKeyFile string
+		icon            string
+		iconContent     string
+		iconSymlink     bool
+		iconOutsideDirs bool
+
+		wantIcon    bool
+		wantRefresh bool
+	}{
+		"Create_key_file_of_new_user":                  {wantRefresh: true},
+		"Set_icon_of_user":                             {icon: "icon.png", wantIcon: true, wantRefresh: true},
+		"Keep_other_keys_of_existing_user":             {existingKeyFile: "existing_user", wantRefresh: true},
+		"Add_section_to_key_file_without_user_section": {existingKeyFile: "without_user_section", wantRefresh: true},
+		"Do_not_refresh_if_nothing_changed":            {existingKeyFile: "up_to_date_user"},
+		"Ignore_icon_which_is_not_an_absolute_path":    {icon: "avatar for user1", wantRefresh: true},
+		"Ignore_icon_which_does_not_exist":             {icon: "/does/not/exist.png", wantRefresh: true},
+		"Ignore_icon_outside_of_icon_dirs":             {icon: "icon.png", iconOutsideDirs: true, wantRefresh: true},
+		"Ignore_icon_which_is_a_symlink":               {icon: "icon.png", iconSymlink: true, wantRefresh: true},
+		"Ignore_icon_which_is_not_an_image":            {icon: "icon.png", iconContent: "#!/bin/sh", wantRefresh: true},
+		"Ignore_icon_which_is_too_big":                 {icon: "icon.png", iconContent: png + strings.Repeat("x", 1024*1024), wantRefresh: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			if tc.existingKeyFile != "" {
+				require.NoError(t, os.MkdirAll(filepath.Join(dir, "users"), 0700), "Setup: could not create users directory")
+				content, err := os.ReadFile(filepath.Join("testdata", tc.existingKeyFile))
+				require.NoError(t, err, "Setup: could not read key file")
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "users", "user1"), content, 0600), "Setup: could not write key file")
+			}
+			icon := tc.icon
+			iconDir := t.TempDir()
+			if icon == "icon.png" {
+				if tc.iconContent == "" {
+					tc.iconContent = png
+				}
+				icon = filepath.Join(iconDir, "icon.png")
+				if tc.iconOutsideDirs {
+					icon = filepath.Join(t.TempDir(), "icon.png")
+				}
+				require.NoError(t, os.WriteFile(icon, []byte(tc.iconContent), 0600), "Setup: could not write icon")
+			}
+			if tc.iconSymlink {
+				require.NoError(t, os.Rename(icon, filepath.Join(t.TempDir(), "target.png")), "Setup: could not move icon")
+				require.NoError(t, os.Symlink(filepath.Join(filepath.Dir(icon), "target.png"), icon), "Setup: could not create symlink")
+			}
+
+			var refreshed []string
+			refresh := accountsservice.WithRefreshFunc(func(method, name string) error {
+				refreshed = append(refreshed, method+" "+name)
+				return nil
+			})
+
+			err := accountsservice.Update(dir, "user1", icon, refresh, accountsservice.WithIconDirs([]string{iconDir}))
+			require.NoError(t, err, "Update should not return an error")
+
+			got, err := os.ReadFile(filepath.Join(dir, "users", "user1"))
+			require.NoError(t, err, "The key file of the user should exist")
+			// The path of the icon depends on the temporary directory.
+			golden.CheckOrUpdate(t, strings.ReplaceAll(string(got), dir, "DIR"))
+
+			if tc.wantIcon {
+				data, err := os.ReadFile(filepath.Join(dir, "icons", "user1"))
+				require.NoError(t, err, "The icon of the user should have been copied")
+				require.Equal(t, png, string(data), "The icon of the user should have been copied")
+			} else {
+				require.NoFileExists(t, filepath.Join(dir, "icons", "user1"), "No icon should have been copied")
+			}
+
+			if tc.wantRefresh {
+				require.Equal(t, []string{"CacheUser user1"}, refreshed, "AccountsService should have been notified")
+			} else {
+				require.Empty(t, refreshed, "AccountsService should not have been notified")
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var refreshed []string
+	refresh := accountsservice.WithRefreshFunc(func(method, name string) error {
+		refreshed = append(refreshed, method+" "+name)
+		return nil
+	})
+
+	iconDir := t.TempDir()
+	icon := filepath.Join(iconDir, "icon.png")
+	require.NoError(t, os.WriteFile(icon, []byte(png), 0600), "Setup: could not write icon")
+	require.NoError(t, accountsservice.Update(dir, "user1", icon, refresh, accountsservice.WithIconDirs([]string{iconDir})),
+		"Setup: Update should not return an error")
+	require.FileExists(t, filepath.Join(dir, "icons", "user1"), "Setup: the icon of the user should have been copied")
+
+	require.NoError(t, accountsservice.Remove(dir, "user1", refresh), "Remove should not return an error")
+	require.NoFileExists(t, filepath.Join(dir, "users", "user1"), "Remove should remove the key file of the user")
+	require.NoFileExists(t, filepath.Join(dir, "icons", "user1"), "Remove should remove the icon of the user")
+	require.Equal(t, []string{"CacheUser user1", "UncacheUser user1"}, refreshed, "AccountsService should have been notified")
+
+	// Removing the data of a user without any is not an error.
+	require.NoError(t, accountsservice.Remove(dir, "user1", refresh), "Remove should not return an error if there is no data")
+}
@@ -0,0 +1,8 @@
+package accountsservice
+
+// WithRefreshFunc overrides the calls to AccountsService for tests.
+func WithRefreshFunc(f func(method, name string) error) Option {
+	return func(o *options) {
+		o.refresh = f
+	}
+}
@@ -0,0 +1,70 @@
+package accountsservice
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// setKeys returns the content of the key file with the keys of the section set to the given values. The other lines
+// are kept as is. The section is created if it doesn't exist.
+func setKeys(content []byte, section string, keys [][2]string) []byte {
+	var lines []string
+	if len(content) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	}
+
+	header := fmt.Sprintf("[%s]", section)
+	start := -1
+	for i, l := range lines {
+		if strings.TrimSpace(l) == header {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, header)
+		start = len(lines) - 1
+	}
+
+	// The section ends at the next header or at the end of the file. The new keys are added after its last key, before
+	// the empty lines separating it from the next section.
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "[") {
+			end = i
+			break
+		}
+	}
+	for end > start+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+
+	for _, kv := range keys {
+		line := kv[0] + "=" + kv[1]
+		found := false
+		for i := start + 1; i < end; i++ {
+			k, _, ok := strings.Cut(lines[i], "=")
+			if ok && strings.TrimSpace(k) == kv[0] {
+				lines[i] = line
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		lines = append(lines[:end], append([]string{line}, lines[end:]...)...)
+		end++
+	}
+
+	var buf bytes.Buffer
+	for _, l := range lines {
+		buf.WriteString(l)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
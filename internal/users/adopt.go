@@ -0,0 +1,94 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/localentries"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/decorate"
+)
+
+// AdoptUser adds a user of the local passwd file to the database, as a user provided by the broker, keeping its UID,
+// its user private group and its home directory. Its other local groups are kept as local groups.
+//
+// The local entry of the user is not modified: it's up to the caller to remove it once the user is adopted, otherwise
+// it takes precedence over the one provided by authd.
+func (m *Manager) AdoptUser(name, brokerID, actor string) (u types.UserEntry, err error) {
+	defer decorate.OnError(&err, "failed to adopt user %q", name)
+
+	if err := m.checkWritable(); err != nil {
+		return types.UserEntry{}, err
+	}
+
+	m.updateUserMu.Lock()
+	defer m.updateUserMu.Unlock()
+
+	if _, err := m.cache.UserByName(name); err == nil {
+		return types.UserEntry{}, errors.New("the user is already managed by authd")
+	} else if !errors.Is(err, cache.NoDataFoundError{}) {
+		return types.UserEntry{}, err
+	}
+
+	localUsers, err := localentries.LocalUsers()
+	if err != nil {
+		return types.UserEntry{}, err
+	}
+	i := slices.IndexFunc(localUsers, func(u localentries.Passwd) bool { return u.Name == name })
+	if i == -1 {
+		return types.UserEntry{}, errors.New("the user is not a local user")
+	}
+	local := localUsers[i]
+
+	// The primary group is adopted as the user private group, which has the name of the user.
+	localGroups, err := localentries.LocalGroups()
+	if err != nil {
+		return types.UserEntry{}, err
+	}
+	i = slices.IndexFunc(localGroups, func(g localentries.Group) bool { return g.GID == local.GID })
+	if i == -1 || localGroups[i].Name != name {
+		return types.UserEntry{}, fmt.Errorf("the primary group with GID %d is not a user private group", local.GID)
+	}
+
+	if _, err := m.cache.UserByID(local.UID); err == nil {
+		return types.UserEntry{}, fmt.Errorf("UID %d is already used by an authd user", local.UID)
+	}
+	if _, err := m.cache.GroupByID(local.GID); err == nil {
+		return types.UserEntry{}, fmt.Errorf("GID %d is already used by an authd group", local.GID)
+	}
+	if _, err := m.cache.GroupByName(name); err == nil {
+		return types.UserEntry{}, fmt.Errorf("group %q already exists in the database", name)
+	}
+
+	supplementaryGroups, err := localentries.LocalUserGroups(name)
+	if err != nil {
+		return types.UserEntry{}, err
+	}
+	supplementaryGroups = slices.DeleteFunc(supplementaryGroups, func(g string) bool { return g == name })
+
+	newUser := cache.NewUserDB(name, local.UID, local.GID, local.Gecos, local.Dir, local.Shell)
+	m.config().applyShadowAging(&newUser, types.ShadowAging{})
+
+	events := []types.AuditEvent{{
+		Action:  AuditUserAdopted,
+		Target:  name,
+		Details: fmt.Sprintf("UID %d, GID %d, home %q, shell %q, broker %q", local.UID, local.GID, local.Dir, local.Shell, brokerID),
+	}}
+	stored, err := m.cache.ApplyUserUpdate(cache.UserUpdate{
+		User:        newUser,
+		AuthdGroups: []cache.GroupDB{cache.NewGroupDB(name, local.GID, name, nil)},
+		LocalGroups: supplementaryGroups,
+		BrokerID:    brokerID,
+		AuditEntries: func(cache.UserDB) []cache.AuditEntry {
+			return auditEntries(actor, events...)
+		},
+	})
+	if err != nil {
+		return types.UserEntry{}, err
+	}
+	m.notify(append(changesOf(events...), groupChanges(append([]string{name}, supplementaryGroups...)...)...)...)
+
+	return userEntryFromUserDB(stored), nil
+}
@@ -0,0 +1,68 @@
+package users_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestAdoptUser(t *testing.T) {
+	tests := map[string]struct {
+		username   string
+		passwdFile string
+
+		wantErr bool
+	}{
+		"Adopt_local_user": {username: "localuser"},
+
+		"Error_if_user_is_not_a_local_user":             {username: "doesnotexist", wantErr: true},
+		"Error_if_user_is_already_an_authd_user":        {username: "user1", wantErr: true},
+		"Error_if_primary_group_is_not_a_private_group": {username: "localuserwithsharedgroup", wantErr: true},
+		"Error_if_UID_is_used_by_an_authd_user":         {username: "collidinguid", wantErr: true},
+		"Error_if_passwd_file_is_missing":               {username: "localuser", passwdFile: "does_not_exist.passwd", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.passwdFile == "" {
+				tc.passwdFile = "local_users_to_adopt.passwd"
+			}
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "local_users_to_adopt.group"))
+			localgroupstestutils.SetPasswdPath(filepath.Join("testdata", "passwd", tc.passwdFile))
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "multiple_users_and_groups.db.yaml"), cacheDir)
+
+			var changes []types.Change
+			m := newManagerForTests(t, cacheDir, users.WithChangeHandler(func(c types.Change) { changes = append(changes, c) }))
+
+			u, err := m.AdoptUser(tc.username, "broker-id", "test")
+			if tc.wantErr {
+				require.Error(t, err, "AdoptUser should return an error, but did not")
+				require.Empty(t, changes, "AdoptUser should not notify any change on error")
+				return
+			}
+			require.NoError(t, err, "AdoptUser should not return an error, but did")
+			require.Equal(t, types.UserEntry{
+				Name:  "localuser",
+				UID:   5555,
+				GID:   5555,
+				Gecos: "Local User,,,",
+				Dir:   "/home/localuser",
+				Shell: "/bin/bash",
+			}, u, "AdoptUser should keep the attributes of the local user")
+			require.Contains(t, changes, types.Change{Kind: users.ChangeUserAdded, Name: "localuser"},
+				"AdoptUser should notify the addition of the user")
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(userstestutils.GetManagerCache(m))
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// SetUserAttributes modifies the GECOS, home directory and shell of the user locally. The modified attributes are kept
+// on the next logins, even if the broker provides different ones. The actor is recorded in the audit log as the
+// requester of the change.
+//
+// The home directory itself is not moved.
+func (m *Manager) SetUserAttributes(name string, attrs types.UserAttributes, actor string) (err error) {
+	defer decorate.OnError(&err, "failed to set attributes of user %q", name)
+
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	if err := validateUserAttributes(attrs); err != nil {
+		return err
+	}
+
+	// Prevent concurrent logins of the user from overriding the modifications.
+	m.updateUserMu.Lock()
+	defer m.updateUserMu.Unlock()
+
+	oldUser, err := m.cache.UserByName(name)
+	if err != nil {
+		return err
+	}
+
+	newUser := oldUser
+	if attrs.Gecos != nil {
+		newUser.Gecos = *attrs.Gecos
+	}
+	if attrs.Dir != nil {
+		newUser.Dir = *attrs.Dir
+	}
+	if attrs.Shell != nil {
+		newUser.Shell = *attrs.Shell
+	}
+
+	if err := m.cache.UpdateUserAttributes(newUser); err != nil {
+		return err
+	}
+
+	log.Infof(context.Background(), "Updated attributes of user %q", name)
+	m.audit(actor, userUpdateEvents(oldUser, newUser, nil, nil)...)
+	return nil
+}
+
+// validateUserAttributes returns an error if the attributes can't be used in the passwd entry of a user.
+func validateUserAttributes(attrs types.UserAttributes) error {
+	if attrs.Gecos != nil && strings.Contains(*attrs.Gecos, ":") {
+		return errors.New("GECOS can't contain ':'")
+	}
+	if attrs.Dir != nil && !filepath.IsAbs(*attrs.Dir) {
+		return fmt.Errorf("home directory %q is not an absolute path", *attrs.Dir)
+	}
+	if attrs.Shell != nil && !filepath.IsAbs(*attrs.Shell) {
+		return fmt.Errorf("shell %q is not an absolute path", *attrs.Shell)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package users_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users/cache"
+	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestSetUserAttributes(t *testing.T) {
+	t.Parallel()
+
+	ptr := func(s string) *string { return &s }
+
+	tests := map[string]struct {
+		username string
+		attrs    types.UserAttributes
+		dbFile   string
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Set_all_attributes":   {attrs: types.UserAttributes{Gecos: ptr("New gecos"), Dir: ptr("/home/new"), Shell: ptr("/bin/zsh")}},
+		"Set_only_shell":       {attrs: types.UserAttributes{Shell: ptr("/bin/zsh")}},
+		"Set_empty_gecos":      {attrs: types.UserAttributes{Gecos: ptr("")}},
+		"No_attributes_to_set": {},
+
+		"Error_if_user_does_not_exist":    {username: "doesnotexist", attrs: types.UserAttributes{Shell: ptr("/bin/zsh")}, wantErrType: cache.NoDataFoundError{}},
+		"Error_if_gecos_contains_a_colon": {attrs: types.UserAttributes{Gecos: ptr("invalid:gecos")}, wantErr: true},
+		"Error_if_home_is_not_absolute":   {attrs: types.UserAttributes{Dir: ptr("home/new")}, wantErr: true},
+		"Error_if_shell_is_not_absolute":  {attrs: types.UserAttributes{Shell: ptr("zsh")}, wantErr: true},
+		"Error_if_db_has_invalid_entry":   {attrs: types.UserAttributes{Shell: ptr("/bin/zsh")}, dbFile: "invalid_entry_in_userByName", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.username == "" {
+				tc.username = "user1"
+			}
+			if tc.dbFile == "" {
+				tc.dbFile = "one_user_and_group"
+			}
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
+			m := newManagerForTests(t, cacheDir)
+
+			err := m.SetUserAttributes(tc.username, tc.attrs, "test")
+			requireErrorAssertions(t, err, tc.wantErrType, tc.wantErr)
+			if tc.wantErrType != nil || tc.wantErr {
+				return
+			}
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(userstestutils.GetManagerCache(m))
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
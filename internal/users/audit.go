@@ -0,0 +1,194 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditUserAdded          = "user-added"
+	AuditUserUpdated        = "user-updated"
+	AuditUserDeleted        = "user-deleted"
+	AuditUserAdopted        = "user-adopted"
+	AuditUserDisabled       = "user-disabled"
+	AuditUserLocked         = "user-locked"
+	AuditUserUnlocked       = "user-unlocked"
+	AuditGroupAdded         = "group-added"
+	AuditGroupDeleted       = "group-deleted"
+	AuditMembershipsChanged = "group-memberships-changed"
+	AuditUIDRemapped        = "uid-remapped"
+	AuditGIDRemapped        = "gid-remapped"
+	AuditFilesArchived      = "files-archived"
+	AuditFilesDeleted       = "files-deleted"
+	AuditQuotaApplied       = "quota-applied"
+	AuditUserLockedOut      = "user-locked-out"
+	AuditSourceLockedOut    = "source-locked-out"
+	AuditSourceUnlocked     = "source-unlocked"
+	AuditDeviceTrusted      = "device-trusted"
+)
+
+// EventLoginFailed is the security event of a failed authentication attempt of a user. Unlike the other security
+// events, it's not recorded in the audit log.
+const EventLoginFailed = "login-failed"
+
+// SecurityEvents are the actions of the events notified to the security event handler: the failed authentication
+// attempts, the lockouts they caused and the unlocks of the sources, the devices trusted to skip the second factor, and
+// the locks and deletions of the users.
+var SecurityEvents = []string{
+	EventLoginFailed,
+	AuditUserLockedOut,
+	AuditSourceLockedOut,
+	AuditSourceUnlocked,
+	AuditDeviceTrusted,
+	AuditUserLocked,
+	AuditUserUnlocked,
+	AuditUserDeleted,
+}
+
+// Actors of the changes which are not requested by a client of the daemon.
+const (
+	// ActorLogin is the actor of the changes applied from the user information provided by the broker on login.
+	ActorLogin = "login"
+	// ActorStaleUsersPolicy is the actor of the changes done by the stale users policy.
+	ActorStaleUsersPolicy = "stale users policy"
+	// ActorPreSync is the actor of the users created from the list of users expected by the brokers.
+	ActorPreSync = "presync"
+	// ActorLocalGroupsExpiry is the actor of the revocations of the expired memberships in local groups.
+	ActorLocalGroupsExpiry = "local groups expiry"
+	// ActorLockout is the actor of the lockouts of the users and sources after too many failed authentication attempts.
+	ActorLockout = "lockout"
+)
+
+// AuditEvents returns the events of the audit log matching the filter, from the oldest to the newest.
+func (m *Manager) AuditEvents(filter types.AuditFilter) ([]types.AuditEvent, error) {
+	entries, err := m.cache.AuditEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var events []types.AuditEvent
+	for _, e := range entries {
+		if filter.Target != "" && e.Target != filter.Target {
+			continue
+		}
+		if filter.Action != "" && e.Action != filter.Action {
+			continue
+		}
+		if e.Time.Before(filter.Since) {
+			continue
+		}
+		events = append(events, types.AuditEvent(e))
+	}
+
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[len(events)-filter.Limit:]
+	}
+
+	return events, nil
+}
+
+// audit records the events in the audit log. The changes were already done, so failures are only logged.
+func (m *Manager) audit(actor string, events ...types.AuditEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	entries := auditEntries(actor, events...)
+	if err := m.cache.AppendAuditEntries(entries...); err != nil {
+		log.Errorf(context.Background(), "Could not record changes in audit log: %v", err)
+	}
+	m.notify(changesOf(events...)...)
+	for _, e := range entries {
+		m.securityEvent(types.AuditEvent(e))
+	}
+}
+
+// securityEvent calls the security event handler for the event if it's one of SecurityEvents.
+func (m *Manager) securityEvent(e types.AuditEvent) {
+	if m.securityEventHandler == nil || !slices.Contains(SecurityEvents, e.Action) {
+		return
+	}
+	m.securityEventHandler(e)
+}
+
+// auditEntries returns the entries of the audit log recording the events done now by the actor.
+func auditEntries(actor string, events ...types.AuditEvent) []cache.AuditEntry {
+	now := time.Now()
+	entries := make([]cache.AuditEntry, 0, len(events))
+	for _, e := range events {
+		e.Time = now
+		e.Actor = actor
+		entries = append(entries, cache.AuditEntry(e))
+	}
+	return entries
+}
+
+// userUpdateEvents returns the audit events describing the changes between the old and the new user entries and group
+// memberships. oldUser is empty if the user is new.
+func userUpdateEvents(oldUser, newUser cache.UserDB, oldGroups, newGroups []string) (events []types.AuditEvent) {
+	if oldUser.Name == "" {
+		events = append(events, types.AuditEvent{
+			Action:  AuditUserAdded,
+			Target:  newUser.Name,
+			Details: fmt.Sprintf("UID %d, GID %d, home %q, shell %q", newUser.UID, newUser.GID, newUser.Dir, newUser.Shell),
+		})
+	} else {
+		var changes []string
+		if oldUser.Gecos != newUser.Gecos {
+			changes = append(changes, fmt.Sprintf("GECOS changed from %q to %q", oldUser.Gecos, newUser.Gecos))
+		}
+		if oldUser.Dir != newUser.Dir {
+			changes = append(changes, fmt.Sprintf("home changed from %q to %q", oldUser.Dir, newUser.Dir))
+		}
+		if oldUser.Shell != newUser.Shell {
+			changes = append(changes, fmt.Sprintf("shell changed from %q to %q", oldUser.Shell, newUser.Shell))
+		}
+		if oldUser.GID != newUser.GID {
+			changes = append(changes, fmt.Sprintf("primary GID changed from %d to %d", oldUser.GID, newUser.GID))
+		}
+		if len(changes) > 0 {
+			events = append(events, types.AuditEvent{
+				Action:  AuditUserUpdated,
+				Target:  newUser.Name,
+				Details: strings.Join(changes, ", "),
+			})
+		}
+	}
+
+	var changes []string
+	if added := difference(newGroups, oldGroups); len(added) > 0 {
+		changes = append(changes, fmt.Sprintf("added to %s", strings.Join(added, ",")))
+	}
+	if removed := difference(oldGroups, newGroups); len(removed) > 0 {
+		changes = append(changes, fmt.Sprintf("removed from %s", strings.Join(removed, ",")))
+	}
+	if len(changes) > 0 {
+		events = append(events, types.AuditEvent{
+			Action:  AuditMembershipsChanged,
+			Target:  newUser.Name,
+			Details: strings.Join(changes, ", "),
+		})
+	}
+
+	return events
+}
+
+// difference returns the sorted elements of a which are not in b.
+func difference(a, b []string) []string {
+	var diff []string
+	for _, e := range a {
+		if !slices.Contains(b, e) && !slices.Contains(diff, e) {
+			diff = append(diff, e)
+		}
+	}
+	slices.Sort(diff)
+	return diff
+}
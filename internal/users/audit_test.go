@@ -0,0 +1,111 @@
+package users_test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestAuditEvents(t *testing.T) {
+	tests := map[string]struct {
+		filter types.AuditFilter
+	}{
+		"All_events":                  {},
+		"Events_of_a_target":          {filter: types.AuditFilter{Target: "user1"}},
+		"Events_of_an_action":         {filter: types.AuditFilter{Action: users.AuditUserAdded}},
+		"Events_since_a_date":         {filter: types.AuditFilter{Since: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}},
+		"Most_recent_events":          {filter: types.AuditFilter{Limit: 2}},
+		"No_events_matching_a_filter": {filter: types.AuditFilter{Target: "doesnotexist"}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "audit_log.db.yaml"), cacheDir)
+			m := newManagerForTests(t, cacheDir)
+
+			got, err := m.AuditEvents(tc.filter)
+			require.NoError(t, err, "AuditEvents should not return an error, but did")
+
+			golden.CheckOrUpdateYAML(t, got)
+		})
+	}
+}
+
+func TestSecurityEventHandler(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var got []types.AuditEvent
+	handler := func(e types.AuditEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		require.False(t, e.Time.IsZero(), "The security events should have a time")
+		e.Time = time.Time{}
+		got = append(got, e)
+	}
+	requireEvents := func(want ...types.AuditEvent) {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, want, got, "The security event handler was not called with the expected events")
+		got = nil
+	}
+
+	cacheDir := t.TempDir()
+	cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "one_user_and_group.db.yaml"), cacheDir)
+	m := newManagerForTests(t, cacheDir, users.WithSecurityEventHandler(handler))
+	t.Cleanup(func() { _ = m.Stop() })
+
+	// The failed attempts are notified even if the lockout is disabled.
+	m.RecordFailedLogin("user1", "", "")
+	requireEvents(types.AuditEvent{Actor: users.ActorLogin, Action: users.EventLoginFailed, Target: "user1", Details: "from an unknown source"})
+
+	config := users.DefaultConfig
+	config.LockoutDeny = 2
+	config.LockoutSourceDeny = 2
+	config.DeviceTokenLifetime = time.Hour
+	require.NoError(t, m.Reload(config), "Setup: could not reload the user manager")
+
+	m.RecordFailedLogin("user1", "pts/0", "192.0.2.1")
+	m.RecordFailedLogin("user1", "pts/0", "192.0.2.1")
+	requireEvents(
+		types.AuditEvent{Actor: users.ActorLogin, Action: users.EventLoginFailed, Target: "user1",
+			Details: "from 192.0.2.1, 1 failed authentication attempts within 15m0s"},
+		types.AuditEvent{Actor: users.ActorLogin, Action: users.EventLoginFailed, Target: "user1",
+			Details: "from 192.0.2.1, 2 failed authentication attempts within 15m0s"},
+		types.AuditEvent{Actor: users.ActorLockout, Action: users.AuditSourceLockedOut, Target: "192.0.2.1",
+			Details: "2 failed authentication attempts within 15m0s"},
+		types.AuditEvent{Actor: users.ActorLockout, Action: users.AuditUserLockedOut, Target: "user1",
+			Details: "2 failed authentication attempts within 15m0s, locked out for 10m0s"},
+	)
+
+	require.True(t, m.UnlockRemoteHost("192.0.2.1", "test"), "UnlockRemoteHost should end the lockout of the remote host")
+	requireEvents(types.AuditEvent{Actor: "test", Action: users.AuditSourceUnlocked, Target: "192.0.2.1"})
+
+	require.NoError(t, m.SetDeviceToken("broker-id", "user1", "some-token"), "SetDeviceToken should not return an error")
+	requireEvents(types.AuditEvent{Actor: users.ActorLogin, Action: users.AuditDeviceTrusted, Target: "user1", Details: "for 1h0m0s"})
+
+	require.NoError(t, m.LockUser("user1", "test"), "LockUser should not return an error")
+	require.NoError(t, m.UnlockUser("user1", "test"), "UnlockUser should not return an error")
+	mu.Lock()
+	actions := make([]string, 0, len(got))
+	for _, e := range got {
+		actions = append(actions, e.Action)
+	}
+	got = nil
+	mu.Unlock()
+	require.Equal(t, []string{users.AuditUserLocked, users.AuditUserUnlocked}, actions, "The locks of the user should be notified")
+
+	// The deletion of the group of the user is not a security event.
+	require.NoError(t, m.PurgeUser("user1", "test"), "PurgeUser should not return an error")
+	requireEvents(types.AuditEvent{Actor: "test", Action: users.AuditUserDeleted, Target: "user1", Details: "UID 1111"})
+}
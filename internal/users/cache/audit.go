@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ubuntu/decorate"
+	"go.etcd.io/bbolt"
+)
+
+// AuditEntry is a record of a change done to the users and groups of the database.
+type AuditEntry struct {
+	Time    time.Time
+	Actor   string
+	Action  string
+	Target  string
+	Details string
+}
+
+// AppendAuditEntries adds the entries at the end of the audit log. Entries of the audit log can't be modified, they
+// are only removed by PruneAuditEntries once they are too old.
+func (c *Cache) AppendAuditEntries(entries ...AuditEntry) (err error) {
+	defer decorate.OnError(&err, "could not append entries to the audit log")
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.update(func(tx *bbolt.Tx) error {
+		bucket, err := getBucket(tx, auditLogBucketName)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			if err := appendToBucket(bucket, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AuditEntries returns all the entries of the audit log, from the oldest to the newest.
+func (c *Cache) AuditEntries() (entries []AuditEntry, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err = c.db.View(func(tx *bbolt.Tx) error {
+		bucket, err := getBucket(tx, auditLogBucketName)
+		if err != nil {
+			return err
+		}
+
+		return forEachEntry(bucket, func(e AuditEntry) { entries = append(entries, e) })
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// PruneAuditEntries removes the entries of the audit log recorded before the given time, if not zero, and the oldest
+// entries above maxEntries, if not 0, so that the audit log doesn't grow without bound. It returns the number of
+// removed entries.
+func (c *Cache) PruneAuditEntries(before time.Time, maxEntries int) (pruned int, err error) {
+	defer decorate.OnError(&err, "could not prune the audit log")
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err = c.update(func(tx *bbolt.Tx) error {
+		bucket, err := getBucket(tx, auditLogBucketName)
+		if err != nil {
+			return err
+		}
+
+		// The entries are stored in chronological order, so the oldest ones come first.
+		excess := 0
+		if maxEntries > 0 {
+			excess = max(bucket.Stats().KeyN-maxEntries, 0)
+		}
+		var keys [][]byte
+		err = bucket.ForEach(func(k, v []byte) error {
+			if len(keys) < excess {
+				keys = append(keys, k)
+				return nil
+			}
+			if before.IsZero() {
+				return errStopIteration
+			}
+			var e AuditEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("can't unmarshal entry in bucket %q for key %s: %v", bucket.name, k, err)
+			}
+			if !e.Time.Before(before) {
+				return errStopIteration
+			}
+			keys = append(keys, k)
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopIteration) {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := bucket.Delete(k); err != nil {
+				return fmt.Errorf("can't delete entry in bucket %q for key %s: %v", bucket.name, k, err)
+			}
+		}
+		pruned = len(keys)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return pruned, nil
+}
+
+// errStopIteration stops the iteration over the entries of a bucket.
+var errStopIteration = errors.New("stop iteration")
+
+// appendToBucket stores the value under the next sequence number of the bucket.
+func appendToBucket(bucket bucketWithName, value any) error {
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return fmt.Errorf("can't get next sequence in bucket %q: %v", bucket.name, err)
+	}
+	// Pad the key so that the lexicographical order of the keys matches the insertion order.
+	updateBucket(bucket, fmt.Sprintf("%020d", seq), value)
+	return nil
+}
@@ -0,0 +1,78 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users/cache"
+)
+
+func TestAuditEntries(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, "")
+
+	entries, err := c.AuditEntries()
+	require.NoError(t, err, "AuditEntries should not return an error on an empty audit log")
+	require.Empty(t, entries, "Audit log should be empty")
+
+	first := cache.AuditEntry{Time: time.Unix(1000, 0).UTC(), Actor: "login", Action: "user-added", Target: "user1"}
+	second := cache.AuditEntry{Time: time.Unix(2000, 0).UTC(), Actor: "login", Action: "user-updated", Target: "user1"}
+	third := cache.AuditEntry{Time: time.Unix(3000, 0).UTC(), Actor: "UID 0 (PID 1)", Action: "user-deleted", Target: "user1"}
+
+	require.NoError(t, c.AppendAuditEntries(first, second), "AppendAuditEntries should not return an error")
+	require.NoError(t, c.AppendAuditEntries(third), "AppendAuditEntries should not return an error")
+
+	entries, err = c.AuditEntries()
+	require.NoError(t, err, "AuditEntries should not return an error")
+	require.Equal(t, []cache.AuditEntry{first, second, third}, entries, "Audit entries should be returned in insertion order")
+}
+
+func TestPruneAuditEntries(t *testing.T) {
+	t.Parallel()
+
+	entries := []cache.AuditEntry{
+		{Time: time.Unix(1000, 0).UTC(), Actor: "login", Action: "user-added", Target: "user1"},
+		{Time: time.Unix(2000, 0).UTC(), Actor: "login", Action: "user-updated", Target: "user1"},
+		{Time: time.Unix(3000, 0).UTC(), Actor: "login", Action: "user-added", Target: "user2"},
+		{Time: time.Unix(4000, 0).UTC(), Actor: "UID 0 (PID 1)", Action: "user-deleted", Target: "user1"},
+	}
+
+	tests := map[string]struct {
+		before     time.Time
+		maxEntries int
+
+		want []cache.AuditEntry
+	}{
+		"Keep_all_entries_without_limits":          {want: entries},
+		"Keep_all_entries_within_the_limits":       {before: time.Unix(1000, 0), maxEntries: 4, want: entries},
+		"Remove_the_entries_recorded_before":       {before: time.Unix(2500, 0), want: entries[2:]},
+		"Remove_the_oldest_entries_above_the_max":  {maxEntries: 1, want: entries[3:]},
+		"Remove_the_entries_of_the_strictest_rule": {before: time.Unix(2500, 0), maxEntries: 3, want: entries[2:]},
+		"Remove_all_entries_recorded_before":       {before: time.Unix(5000, 0), maxEntries: 3},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, "")
+			require.NoError(t, c.AppendAuditEntries(entries...), "Setup: AppendAuditEntries should not return an error")
+
+			pruned, err := c.PruneAuditEntries(tc.before, tc.maxEntries)
+			require.NoError(t, err, "PruneAuditEntries should not return an error")
+			require.Equal(t, len(entries)-len(tc.want), pruned, "PruneAuditEntries should return the number of removed entries")
+
+			got, err := c.AuditEntries()
+			require.NoError(t, err, "AuditEntries should not return an error")
+			require.Equal(t, tc.want, got, "PruneAuditEntries should only keep the newest entries")
+
+			// The entries appended after the pruning still come after the kept ones.
+			next := cache.AuditEntry{Time: time.Unix(6000, 0).UTC(), Actor: "login", Action: "user-added", Target: "user3"}
+			require.NoError(t, c.AppendAuditEntries(next), "AppendAuditEntries should not return an error")
+			got, err = c.AuditEntries()
+			require.NoError(t, err, "AuditEntries should not return an error")
+			require.Equal(t, append(append([]cache.AuditEntry(nil), tc.want...), next), got, "New entries should be appended after the kept ones")
+		})
+	}
+}
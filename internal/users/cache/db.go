@@ -3,12 +3,14 @@ package cache
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/ubuntu/decorate"
 	"go.etcd.io/bbolt"
@@ -19,15 +21,19 @@ var (
 )
 
 const (
-	userByNameBucketName        = "UserByName"
-	userByIDBucketName          = "UserByID"
-	groupByNameBucketName       = "GroupByName"
-	groupByIDBucketName         = "GroupByID"
-	groupByUGIDBucketName       = "GroupByUGID"
-	userToGroupsBucketName      = "UserToGroups"
-	groupToUsersBucketName      = "GroupToUsers"
-	userToBrokerBucketName      = "UserToBroker"
-	userToLocalGroupsBucketName = "UserToLocalGroups"
+	userByNameBucketName             = "UserByName"
+	userByIDBucketName               = "UserByID"
+	groupByNameBucketName            = "GroupByName"
+	groupByIDBucketName              = "GroupByID"
+	groupByUGIDBucketName            = "GroupByUGID"
+	userToGroupsBucketName           = "UserToGroups"
+	groupToUsersBucketName           = "GroupToUsers"
+	userToBrokerBucketName           = "UserToBroker"
+	userToLocalGroupsBucketName      = "UserToLocalGroups"
+	idTranslationsBucketName         = "IDTranslations"
+	metadataBucketName               = "Metadata"
+	auditLogBucketName               = "AuditLog"
+	userExtendedAttributesBucketName = "UserExtendedAttributes"
 )
 
 var (
@@ -36,7 +42,9 @@ var (
 		[]byte(groupByNameBucketName), []byte(groupByIDBucketName),
 		[]byte(groupByUGIDBucketName), []byte(userToGroupsBucketName),
 		[]byte(groupToUsersBucketName), []byte(userToBrokerBucketName),
-		[]byte(userToLocalGroupsBucketName),
+		[]byte(userToLocalGroupsBucketName), []byte(idTranslationsBucketName),
+		[]byte(metadataBucketName), []byte(auditLogBucketName),
+		[]byte(userExtendedAttributesBucketName),
 	}
 )
 
@@ -44,6 +52,8 @@ var (
 type Cache struct {
 	db *bbolt.DB
 	mu sync.RWMutex
+
+	writeStats writeStats
 }
 
 // UserDB is the public type that is shared to external packages.
@@ -62,6 +72,17 @@ type UserDB struct {
 	PwdInactivity  int
 	MinPwdAge      int
 	ExpirationDate int
+
+	// Locked is true if the user was locked by an administrator and can't authenticate anymore.
+	Locked bool `json:",omitempty"`
+
+	// PasswordExpiresAt is the Unix time at which the password of the user expires, as provided by the broker.
+	// 0 means that no expiration is known.
+	PasswordExpiresAt int64 `json:",omitempty"`
+
+	// LocalGroupsExpiry are the Unix times at which the memberships of the user in local groups expire, by group. The
+	// memberships in the other local groups don't expire.
+	LocalGroupsExpiry map[string]int64 `json:",omitempty"`
 }
 
 // GroupDB is the struct stored in json format in the bucket.
@@ -94,22 +115,119 @@ func New(cacheDir string) (cache *Cache, err error) {
 		return nil, err
 	}
 
-	// Commit dfc4191ae73cd1f27483798e21093934f23d5059 released in 0.3.5 potentially messed up the database by adding a
-	// user with the same name but different UID to the UserByID bucket, and overwriting the existing user with the same
-	// name in the UserByName bucket. To clean this up, we remove users from the UserByID bucket that are not in the
-	// UserByName bucket.
-	if err = deleteOrphanedUsers(db); err != nil {
+	return &Cache{db: db}, nil
+}
+
+// NewReadOnly opens an existing database in read-only mode. All the methods modifying the database fail.
+func NewReadOnly(cacheDir string) (cache *Cache, err error) {
+	// The database can't be opened while a daemon has it opened in read-write mode.
+	return newReadOnly(cacheDir, func(path string) (*bbolt.DB, error) { return openDB(path, true) })
+}
+
+// NewReadOnlyIfUnlocked opens an existing database in read-only mode, like NewReadOnly, but returns a LockedError right
+// away if another process, like a running daemon, opened it in read-write mode, instead of waiting for it.
+func NewReadOnlyIfUnlocked(cacheDir string) (cache *Cache, err error) {
+	return newReadOnly(cacheDir, func(path string) (*bbolt.DB, error) {
+		db, err := bbolt.Open(path, 0600, &bbolt.Options{ReadOnly: true, Timeout: integrityCheckLockTimeout})
+		if errors.Is(err, bbolt.ErrTimeout) {
+			return nil, LockedError{path: path}
+		}
+		return db, err
+	})
+}
+
+// newReadOnly opens an existing database in read-only mode with open, and checks that its schema is up to date.
+func newReadOnly(cacheDir string, open func(path string) (*bbolt.DB, error)) (cache *Cache, err error) {
+	dbPath := filepath.Join(cacheDir, dbName)
+	defer decorate.OnError(&err, "could not open database at %q in read-only mode", dbPath)
+
+	// bbolt creates the file if it doesn't exist, even in read-only mode.
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, err
+	}
+
+	db, err := open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The buckets can't be created nor the database migrated, so check that the database is already up to date.
+	err = db.View(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+		version, err := getSchemaVersion(buckets[metadataBucketName])
+		if err != nil {
+			return err
+		}
+		if version != currentSchemaVersion() {
+			return fmt.Errorf("database schema version %d is not the supported version %d", version, currentSchemaVersion())
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
 		return nil, err
 	}
 
-	return &Cache{db: db, mu: sync.RWMutex{}}, nil
+	return &Cache{db: db}, nil
 }
 
+// CheckIntegrity checks the consistency of the pages of the database and that it has the buckets and the schema
+// version of this version of authd. It returns a LockedError right away if another process, like a running daemon,
+// opened the database in read-write mode.
+func CheckIntegrity(cacheDir string) (err error) {
+	dbPath := filepath.Join(cacheDir, dbName)
+	defer decorate.OnError(&err, "database %q", dbPath)
+
+	// bbolt creates the file if it doesn't exist, even in read-only mode.
+	if _, err := os.Stat(dbPath); err != nil {
+		return err
+	}
+
+	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{ReadOnly: true, Timeout: integrityCheckLockTimeout})
+	if errors.Is(err, bbolt.ErrTimeout) {
+		return LockedError{path: dbPath}
+	}
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bbolt.Tx) error {
+		var errs error
+		for err := range tx.Check() {
+			errs = errors.Join(errs, err)
+		}
+		if errs != nil {
+			return fmt.Errorf("corrupted pages: %w", errs)
+		}
+
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+		version, err := getSchemaVersion(buckets[metadataBucketName])
+		if err != nil {
+			return err
+		}
+		if version != currentSchemaVersion() {
+			return fmt.Errorf("schema version %d is not the supported version %d", version, currentSchemaVersion())
+		}
+		return nil
+	})
+}
+
+// integrityCheckLockTimeout is the time to wait for the lock of the database when checking its integrity, or when
+// opening it without waiting for another process.
+var integrityCheckLockTimeout = 100 * time.Millisecond
+
 // openAndInitDB open a pre-existing database and potentially initializes its buckets.
 func openAndInitDB(path string) (*bbolt.DB, error) {
-	db, err := bbolt.Open(path, 0600, nil)
+	db, err := openDB(path, false)
 	if err != nil {
-		return nil, fmt.Errorf("can't open database file: %v", err)
+		return nil, err
 	}
 	// Fail if permissions are not 0600
 	fileInfo, err := os.Stat(path)
@@ -131,6 +249,12 @@ func openAndInitDB(path string) (*bbolt.DB, error) {
 			}
 		}
 
+		// Upgrade the database to the current schema before unknown buckets are removed, so that migrations can still
+		// access them.
+		if err := migrate(tx); err != nil {
+			return err
+		}
+
 		// Clear up any unknown buckets
 		var bucketNamesToDelete [][]byte
 		err = tx.ForEach(func(name []byte, _ *bbolt.Bucket) error {
@@ -151,6 +275,7 @@ func openAndInitDB(path string) (*bbolt.DB, error) {
 		return nil
 	})
 	if err != nil {
+		_ = db.Close()
 		return nil, err
 	}
 
@@ -6,6 +6,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/ubuntu/authd/internal/testutils/golden"
@@ -29,11 +30,14 @@ func TestNew(t *testing.T) {
 		"New_with_already_existing_database":                     {dbFile: "multiple_users_and_groups"},
 		"New_recreates_any_missing_buckets_and_delete_unknowns":  {dbFile: "database_with_unknown_bucket"},
 		"New_removes_orphaned_user_records_from_UserByID_bucket": {dbFile: "orphaned_user_record"},
+		"New_does_not_rerun_migrations_of_current_schema":        {dbFile: "orphaned_user_record_current_schema"},
 
 		"Error_on_cacheDir_non_existent_cacheDir":      {dbFile: "-", wantErr: true},
 		"Error_on_corrupted_db_file":                   {corruptedDbFile: true, wantErr: true},
 		"Error_on_invalid_permission_on_database_file": {dbFile: "multiple_users_and_groups", perm: &perm0644, wantErr: true},
 		"Error_on_unreadable_database_file":            {dbFile: "multiple_users_and_groups", perm: &perm0000, wantErr: true},
+		"Error_on_newer_schema_version":                {dbFile: "newer_schema_version", wantErr: true},
+		"Error_on_invalid_schema_version":              {dbFile: "invalid_schema_version", wantErr: true},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -88,6 +92,226 @@ func TestNew(t *testing.T) {
 	}
 }
 
+//nolint:tparallel // The timeout of the lock of the database is global.
+func TestOpenLockedDatabase(t *testing.T) {
+	cache.SetLockTimeout(t, 10*time.Millisecond, 2)
+
+	tests := map[string]struct {
+		firstReadOnly  bool
+		secondReadOnly bool
+
+		wantErr bool
+	}{
+		"Open_database_read_only_twice": {firstReadOnly: true, secondReadOnly: true},
+
+		"Error_opening_database_read_write_twice":           {wantErr: true},
+		"Error_opening_database_read_only_while_read_write": {secondReadOnly: true, wantErr: true},
+		"Error_opening_database_read_write_while_read_only": {firstReadOnly: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cacheDir := t.TempDir()
+			c, err := cache.New(cacheDir)
+			require.NoError(t, err, "Setup: could not create the database")
+			require.NoError(t, c.Close(), "Setup: could not close the database")
+
+			open := func(readOnly bool) (*cache.Cache, error) {
+				if readOnly {
+					return cache.NewReadOnly(cacheDir)
+				}
+				return cache.New(cacheDir)
+			}
+
+			first, err := open(tc.firstReadOnly)
+			require.NoError(t, err, "Setup: could not open the database")
+			defer first.Close()
+
+			second, err := open(tc.secondReadOnly)
+			if tc.wantErr {
+				require.ErrorIs(t, err, cache.LockedError{}, "Opening a locked database should return a LockedError")
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, second.Close(), "Closing the database should not fail")
+		})
+	}
+}
+
+func TestNewReadOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile     string
+		notCreated bool
+
+		wantErr bool
+	}{
+		"Open_existing_database_read_only": {dbFile: "multiple_users_and_groups"},
+
+		"Error_on_missing_database":        {notCreated: true, wantErr: true},
+		"Error_on_outdated_schema_version": {dbFile: "multiple_users_and_groups", notCreated: true, wantErr: true},
+		"Error_on_newer_schema_version":    {dbFile: "newer_schema_version", notCreated: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cacheDir := t.TempDir()
+			if tc.dbFile != "" {
+				cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", tc.dbFile+".db.yaml"), cacheDir)
+			}
+			if !tc.notCreated {
+				// Let authd create the database with the current schema.
+				c, err := cache.New(cacheDir)
+				require.NoError(t, err, "Setup: could not create the database")
+				require.NoError(t, c.Close(), "Setup: could not close the database")
+			}
+
+			c, err := cache.NewReadOnly(cacheDir)
+			if tc.wantErr {
+				require.Error(t, err, "NewReadOnly should return an error but didn't")
+				return
+			}
+			require.NoError(t, err)
+			defer c.Close()
+
+			u, err := c.UserByName("user1")
+			require.NoError(t, err, "UserByName should work on a read-only database")
+			require.Equal(t, uint32(1111), u.UID, "UserByName should return the stored user")
+
+			err = c.SetUserLocked(u.UID, true)
+			require.Error(t, err, "Modifying a read-only database should fail")
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestNewReadOnlyIfUnlocked(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		notCreated bool
+		locked     bool
+
+		wantErr       bool
+		wantErrLocked bool
+	}{
+		"Open_unlocked_database_read_only": {},
+
+		"Error_on_missing_database": {notCreated: true, wantErr: true},
+		"Error_on_locked_database":  {locked: true, wantErr: true, wantErrLocked: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cacheDir := t.TempDir()
+			if !tc.notCreated {
+				c, err := cache.New(cacheDir)
+				require.NoError(t, err, "Setup: could not create the database")
+				if tc.locked {
+					defer c.Close()
+				} else {
+					require.NoError(t, c.Close(), "Setup: could not close the database")
+				}
+			}
+
+			c, err := cache.NewReadOnlyIfUnlocked(cacheDir)
+			if tc.wantErrLocked {
+				require.ErrorIs(t, err, cache.LockedError{}, "Opening a locked database should return a LockedError")
+				return
+			}
+			if tc.wantErr {
+				require.Error(t, err, "NewReadOnlyIfUnlocked should return an error but didn't")
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, c.Close(), "Closing the database should not fail")
+		})
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile     string
+		notCreated bool
+		corrupted  bool
+		locked     bool
+
+		wantErr       bool
+		wantLockedErr bool
+	}{
+		"Check_database_with_current_schema": {dbFile: "multiple_users_and_groups"},
+
+		"Error_on_missing_database":                   {notCreated: true, wantErr: true},
+		"Error_on_outdated_schema_version":            {dbFile: "multiple_users_and_groups", notCreated: true, wantErr: true},
+		"Error_on_corrupted_database":                 {corrupted: true, wantErr: true},
+		"Error_on_database_opened_by_another_process": {locked: true, wantErr: true, wantLockedErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cacheDir := t.TempDir()
+			if tc.dbFile != "" {
+				cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", tc.dbFile+".db.yaml"), cacheDir)
+			}
+			if !tc.notCreated {
+				c, err := cache.New(cacheDir)
+				require.NoError(t, err, "Setup: could not create the database")
+				if tc.locked {
+					defer c.Close()
+				} else {
+					require.NoError(t, c.Close(), "Setup: could not close the database")
+				}
+			}
+			if tc.corrupted {
+				err := os.WriteFile(filepath.Join(cacheDir, cache.Z_ForTests_DBName()), []byte("not a database"), 0600)
+				require.NoError(t, err, "Setup: could not corrupt the database")
+			}
+
+			err := cache.CheckIntegrity(cacheDir)
+			if tc.wantLockedErr {
+				require.ErrorIs(t, err, cache.LockedError{}, "CheckIntegrity should return a LockedError")
+				return
+			}
+			if tc.wantErr {
+				require.Error(t, err, "CheckIntegrity should return an error but didn't")
+				return
+			}
+			require.NoError(t, err, "CheckIntegrity should not return an error")
+		})
+	}
+}
+
+func TestSchemaVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile string
+	}{
+		"New_database_has_current_schema_version":      {},
+		"Database_without_schema_version_is_migrated":  {dbFile: "multiple_users_and_groups"},
+		"Database_with_current_schema_version_is_kept": {dbFile: "orphaned_user_record_current_schema"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, tc.dbFile)
+
+			got, err := c.SchemaVersion()
+			require.NoError(t, err, "SchemaVersion should not return an error")
+			require.Equal(t, uint32(1), got, "SchemaVersion should return the current schema version")
+		})
+	}
+}
+
 func TestUpdateUserEntry(t *testing.T) {
 	t.Parallel()
 
@@ -169,7 +393,8 @@ func TestUpdateUserEntry(t *testing.T) {
 
 		// User and Group updates
 		"Update_user_by_changing_attributes":                      {userCase: "user1-new-attributes", dbFile: "one_user_and_group"},
-		"Update_user_does_not_change_homedir_if_it_exists":        {userCase: "user1-new-homedir", dbFile: "one_user_and_group"},
+		"Update_user_by_changing_homedir":                         {userCase: "user1-new-homedir", dbFile: "one_user_and_group"},
+		"Update_user_keeps_locally_modified_attributes":           {userCase: "user1-new-attributes", dbFile: "locally_modified_user"},
 		"Update_user_by_removing_optional_gecos_field_if_not_set": {userCase: "user1-without-gecos", dbFile: "one_user_and_group"},
 
 		// Group updates
@@ -315,6 +540,35 @@ func TestAllUsers(t *testing.T) {
 	}
 }
 
+func TestUsersLastLoggedInBefore(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile string
+		before string
+
+		wantErr bool
+	}{
+		"Get_users_which_logged_in_before_date": {dbFile: "multiple_users_and_groups", before: "2010-01-01T00:00:00Z"},
+		"Get_no_users_if_all_logged_in_after":   {dbFile: "multiple_users_and_groups", before: "2000-01-01T00:00:00Z"},
+
+		"Error_on_some_invalid_users_entry": {dbFile: "invalid_entries_but_user_and_group1", before: "2010-01-01T00:00:00Z", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, tc.dbFile)
+
+			before, err := time.Parse(time.RFC3339, tc.before)
+			require.NoError(t, err, "Setup: invalid date")
+
+			got, err := c.UsersLastLoggedInBefore(before)
+			requireGetAssertions(t, got, tc.wantErr, nil, err)
+		})
+	}
+}
+
 func TestGroupByID(t *testing.T) {
 	t.Parallel()
 
@@ -440,6 +694,150 @@ func TestUpdateBrokerForUser(t *testing.T) {
 	require.Error(t, err, "UpdateBrokerForUser for a nonexistent user should return an error")
 }
 
+func TestApplyUserUpdate(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, "one_user_and_group")
+
+	entry := cache.AuditEntry{Time: time.Unix(1000, 0).UTC(), Actor: "login", Action: "user-updated", Target: "user1"}
+	update := cache.UserUpdate{
+		User: cache.UserDB{
+			Name:  "user1",
+			UID:   1111,
+			GID:   11111,
+			Gecos: "New user1 gecos",
+			Dir:   "/home/user1",
+			Shell: "/bin/dash",
+		},
+		AuthdGroups: []cache.GroupDB{{Name: "group1", GID: 11111, UGID: "12345678"}},
+		LocalGroups: []string{"localgroup1"},
+		BrokerID:    "new-broker-id",
+		AuditEntries: func(stored cache.UserDB) []cache.AuditEntry {
+			return []cache.AuditEntry{entry}
+		},
+	}
+
+	stored, err := c.ApplyUserUpdate(update)
+	require.NoError(t, err, "ApplyUserUpdate should not return an error")
+	require.Equal(t, "New user1 gecos", stored.Gecos, "ApplyUserUpdate should return the stored user")
+
+	brokerID, err := c.BrokerForUser("user1")
+	require.NoError(t, err, "BrokerForUser should not return an error")
+	require.Equal(t, "new-broker-id", brokerID, "ApplyUserUpdate should update the broker of the user")
+	localGroups, err := c.UserLocalGroups(1111)
+	require.NoError(t, err, "UserLocalGroups should not return an error")
+	require.Equal(t, []string{"localgroup1"}, localGroups, "ApplyUserUpdate should update the local groups of the user")
+	entries, err := c.AuditEntries()
+	require.NoError(t, err, "AuditEntries should not return an error")
+	require.Equal(t, []cache.AuditEntry{entry}, entries, "ApplyUserUpdate should append the audit entries")
+
+	// Nothing is written if the update fails
+	update.User.Name = "otheruser"
+	update.BrokerID = "other-broker-id"
+	_, err = c.ApplyUserUpdate(update)
+	require.Error(t, err, "ApplyUserUpdate with a UID used by a different user should return an error")
+
+	brokerID, err = c.BrokerForUser("user1")
+	require.NoError(t, err, "BrokerForUser should not return an error")
+	require.Equal(t, "new-broker-id", brokerID, "A failed ApplyUserUpdate should not update the broker of the user")
+	entries, err = c.AuditEntries()
+	require.NoError(t, err, "AuditEntries should not return an error")
+	require.Equal(t, []cache.AuditEntry{entry}, entries, "A failed ApplyUserUpdate should not append audit entries")
+}
+
+func TestApplyUserUpdates(t *testing.T) {
+	t.Parallel()
+
+	user := func(name string, uid uint32, groups ...cache.GroupDB) cache.UserUpdate {
+		return cache.UserUpdate{
+			User: cache.UserDB{
+				Name: name, UID: uid, GID: groups[0].GID, Dir: "/home/" + name, Shell: "/bin/bash",
+				LastPwdChange: -1, MaxPwdAge: -1, PwdWarnPeriod: -1, PwdInactivity: -1, MinPwdAge: -1, ExpirationDate: -1,
+			},
+			AuthdGroups: groups,
+			BrokerID:    "broker-id",
+		}
+	}
+	group1 := cache.GroupDB{Name: "group1", GID: 11111, UGID: "12345678"}
+	group2 := cache.GroupDB{Name: "group2", GID: 22222, UGID: "56781234"}
+	commongroup := cache.GroupDB{Name: "commongroup", GID: 99999, UGID: "87654321"}
+
+	tests := map[string]struct {
+		updates []cache.UserUpdate
+
+		wantErr bool
+	}{
+		"Apply_updates_of_existing_and_new_users": {updates: []cache.UserUpdate{
+			user("user1", 1111, group1, commongroup),
+			user("user2", 2222, group2, commongroup),
+		}},
+
+		"Error_if_there_are_no_updates":                {wantErr: true},
+		"Error_if_a_user_has_no_name":                  {updates: []cache.UserUpdate{user("", 2222, group2)}, wantErr: true},
+		"Error_if_a_user_is_updated_twice":             {updates: []cache.UserUpdate{user("user2", 2222, group2), user("user2", 3333, group2)}, wantErr: true},
+		"Error_if_a_UID_is_used_by_two_users":          {updates: []cache.UserUpdate{user("user2", 2222, group2), user("user3", 2222, group2)}, wantErr: true},
+		"Error_if_a_GID_is_used_by_two_groups":         {updates: []cache.UserUpdate{user("user2", 2222, group2), user("user3", 3333, cache.GroupDB{Name: "group3", GID: 22222})}, wantErr: true},
+		"Error_and_no_update_applied_if_one_fails":     {updates: []cache.UserUpdate{user("user2", 2222, group2), user("otheruser", 1111, group1)}, wantErr: true},
+		"Error_and_no_update_applied_if_a_group_fails": {updates: []cache.UserUpdate{user("user2", 2222, group2), user("user3", 3333, cache.GroupDB{Name: "group3", GID: 11111, UGID: "other"})}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, "one_user_and_group")
+			before, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Setup: could not dump the database")
+
+			stored, err := c.ApplyUserUpdates(tc.updates...)
+			if tc.wantErr {
+				require.Error(t, err, "ApplyUserUpdates should return an error, but did not")
+				after, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+				require.NoError(t, err, "Could not dump the database")
+				require.Equal(t, before, after, "ApplyUserUpdates should not modify the database on error")
+				return
+			}
+			require.NoError(t, err, "ApplyUserUpdates should not return an error, but did")
+			require.Len(t, stored, len(tc.updates), "ApplyUserUpdates should return all the stored users")
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestUserExtendedAttributes(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, "one_user_and_group")
+
+	attributes, err := c.UserExtendedAttributes(1111)
+	require.NoError(t, err, "UserExtendedAttributes should not return an error for a user without attributes")
+	require.Empty(t, attributes, "UserExtendedAttributes should return no attributes for a user without attributes")
+
+	update := cache.UserUpdate{
+		User:               cache.UserDB{Name: "user1", UID: 1111, GID: 11111, Dir: "/home/user1", Shell: "/bin/bash"},
+		AuthdGroups:        []cache.GroupDB{{Name: "group1", GID: 11111, UGID: "12345678"}},
+		BrokerID:           "broker-id",
+		ExtendedAttributes: map[string]string{"department": "engineering", "employee_id": "1234"},
+	}
+	_, err = c.ApplyUserUpdate(update)
+	require.NoError(t, err, "ApplyUserUpdate should not return an error")
+
+	attributes, err = c.UserExtendedAttributes(1111)
+	require.NoError(t, err, "UserExtendedAttributes should not return an error")
+	require.Equal(t, update.ExtendedAttributes, attributes, "UserExtendedAttributes should return the stored attributes")
+
+	// The attributes are removed when the broker doesn't provide them anymore.
+	update.ExtendedAttributes = nil
+	_, err = c.ApplyUserUpdate(update)
+	require.NoError(t, err, "ApplyUserUpdate should not return an error")
+
+	attributes, err = c.UserExtendedAttributes(1111)
+	require.NoError(t, err, "UserExtendedAttributes should not return an error")
+	require.Empty(t, attributes, "UserExtendedAttributes should return no attributes once they were removed")
+}
+
 func TestBrokerForUser(t *testing.T) {
 	t.Parallel()
 
@@ -514,6 +912,295 @@ func TestDeleteUser(t *testing.T) {
 	}
 }
 
+func TestDeleteGroup(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile string
+		gid    uint32
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Deleting_group_without_members": {dbFile: "group_without_members", gid: 11111},
+
+		"Error_on_missing_group":          {gid: 11111, wantErrType: cache.NoDataFoundError{}},
+		"Error_on_group_with_members":     {dbFile: "one_user_and_group", gid: 11111, wantErr: true},
+		"Error_on_invalid_database_entry": {dbFile: "invalid_entry_in_groupByID", gid: 11111, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, tc.dbFile)
+
+			err := c.DeleteGroup(tc.gid)
+			if tc.wantErrType != nil {
+				require.ErrorIs(t, err, tc.wantErrType, "DeleteGroup should return expected error")
+				return
+			}
+			if tc.wantErr {
+				require.Error(t, err, "DeleteGroup should return an error but didn't")
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestDisableUser(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile string
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Disable_existing_user": {dbFile: "multiple_users_and_groups"},
+
+		"Error_on_missing_user":           {wantErrType: cache.NoDataFoundError{}},
+		"Error_on_invalid_database_entry": {dbFile: "invalid_entry_in_userByID", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, tc.dbFile)
+
+			err := c.DisableUser(1111)
+			if tc.wantErr {
+				require.Error(t, err, "DisableUser should return an error but didn't")
+				return
+			}
+			if tc.wantErrType != nil {
+				require.ErrorIs(t, err, tc.wantErrType, "DisableUser should return expected error")
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestUpdateUserAttributes(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile string
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Update_attributes_of_existing_user":         {dbFile: "one_user_and_group"},
+		"Update_attributes_of_locally_modified_user": {dbFile: "locally_modified_user"},
+
+		"Error_on_missing_user":           {wantErrType: cache.NoDataFoundError{}},
+		"Error_on_invalid_database_entry": {dbFile: "invalid_entry_in_userByID", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, tc.dbFile)
+
+			u := cache.NewUserDB("user1", 1111, 11111, "Modified gecos", "/home/modified", "/bin/zsh")
+			err := c.UpdateUserAttributes(u)
+			if tc.wantErr {
+				require.Error(t, err, "UpdateUserAttributes should return an error but didn't")
+				return
+			}
+			if tc.wantErrType != nil {
+				require.ErrorIs(t, err, tc.wantErrType, "UpdateUserAttributes should return expected error")
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestSetUserLocked(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile string
+		locked bool
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Lock_existing_user":   {dbFile: "one_user_and_group", locked: true},
+		"Unlock_existing_user": {dbFile: "one_user_and_group"},
+
+		"Error_on_missing_user":           {locked: true, wantErrType: cache.NoDataFoundError{}},
+		"Error_on_invalid_database_entry": {dbFile: "invalid_entry_in_userByID", locked: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, tc.dbFile)
+
+			err := c.SetUserLocked(1111, tc.locked)
+			if tc.wantErr {
+				require.Error(t, err, "SetUserLocked should return an error but didn't")
+				return
+			}
+			if tc.wantErrType != nil {
+				require.ErrorIs(t, err, tc.wantErrType, "SetUserLocked should return expected error")
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestSetQuotaProfile(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile  string
+		profile string
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Set_quota_profile_of_existing_user":   {dbFile: "one_user_and_group", profile: "students"},
+		"Unset_quota_profile_of_existing_user": {dbFile: "one_user_and_group"},
+
+		"Error_on_missing_user":           {profile: "students", wantErrType: cache.NoDataFoundError{}},
+		"Error_on_invalid_database_entry": {dbFile: "invalid_entry_in_userByID", profile: "students", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, tc.dbFile)
+
+			err := c.SetQuotaProfile(1111, tc.profile)
+			if tc.wantErr {
+				require.Error(t, err, "SetQuotaProfile should return an error but didn't")
+				return
+			}
+			if tc.wantErrType != nil {
+				require.ErrorIs(t, err, tc.wantErrType, "SetQuotaProfile should return expected error")
+				return
+			}
+			require.NoError(t, err)
+
+			profile, err := c.QuotaProfile("user1")
+			require.NoError(t, err, "QuotaProfile should not return an error")
+			require.Equal(t, tc.profile, profile, "QuotaProfile should return the profile which was set")
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestRecordLogin(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile string
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Record_login_of_existing_user": {dbFile: "one_user_and_group"},
+
+		"Error_on_missing_user":           {wantErrType: cache.NoDataFoundError{}},
+		"Error_on_invalid_database_entry": {dbFile: "invalid_entry_in_userByID", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, tc.dbFile)
+
+			loginTime := time.Date(2010, 10, 10, 10, 10, 0, 0, time.UTC)
+			err := c.RecordLogin(1111, loginTime, "192.168.1.2")
+			if tc.wantErr {
+				require.Error(t, err, "RecordLogin should return an error but didn't")
+				return
+			}
+			if tc.wantErrType != nil {
+				require.ErrorIs(t, err, tc.wantErrType, "RecordLogin should return expected error")
+				return
+			}
+			require.NoError(t, err)
+
+			gotTime, gotSource, err := c.LastLogin("user1")
+			require.NoError(t, err, "LastLogin should not return an error")
+			require.True(t, loginTime.Equal(gotTime), "LastLogin should return the recorded time")
+			require.Equal(t, "192.168.1.2", gotSource, "LastLogin should return the recorded source")
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestSetUserRefreshed(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile string
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Set_refresh_time_of_existing_user": {dbFile: "one_user_and_group"},
+
+		"Error_on_missing_user":           {wantErrType: cache.NoDataFoundError{}},
+		"Error_on_invalid_database_entry": {dbFile: "invalid_entry_in_userByID", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, tc.dbFile)
+
+			refreshTime := time.Date(2010, 10, 10, 10, 10, 0, 0, time.UTC)
+			err := c.SetUserRefreshed(1111, refreshTime)
+			if tc.wantErr {
+				require.Error(t, err, "SetUserRefreshed should return an error but didn't")
+				return
+			}
+			if tc.wantErrType != nil {
+				require.ErrorIs(t, err, tc.wantErrType, "SetUserRefreshed should return expected error")
+				return
+			}
+			require.NoError(t, err)
+
+			gotTime, err := c.RefreshedAt("user1")
+			require.NoError(t, err, "RefreshedAt should not return an error")
+			require.True(t, refreshTime.Equal(gotTime), "RefreshedAt should return the recorded time")
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
 // initCache returns a new cache ready to be used alongside its cache directory.
 func initCache(t *testing.T, dbFile string) (c *cache.Cache) {
 	t.Helper()
@@ -18,7 +18,7 @@ func (c *Cache) DeleteUser(uid uint32) error {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return c.db.Update(func(tx *bbolt.Tx) error {
+	return c.update(func(tx *bbolt.Tx) error {
 		buckets, err := getAllBuckets(tx)
 		if err != nil {
 			return err
@@ -31,6 +31,50 @@ func (c *Cache) DeleteUser(uid uint32) error {
 	})
 }
 
+// DeleteGroup removes the group from the database. It fails if the group still has members.
+func (c *Cache) DeleteGroup(gid uint32) (err error) {
+	defer decorate.OnError(&err, "could not remove group %d from db", gid)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.update(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		g, err := getFromBucket[groupDB](buckets[groupByIDBucketName], gid)
+		if err != nil {
+			return err
+		}
+
+		members, err := getFromBucket[groupToUsersDB](buckets[groupToUsersBucketName], gid)
+		if err != nil && !errors.Is(err, NoDataFoundError{}) {
+			return err
+		}
+		if len(members.UIDs) > 0 {
+			return fmt.Errorf("group %q still has %d members", g.Name, len(members.UIDs))
+		}
+
+		log.Debugf(context.TODO(), "Removing group %q (GID: %d)", g.Name, g.GID)
+
+		// Delete calls fail if the transaction is read only, so we should panic if this function is called in that context.
+		deleteFromBucket(buckets[groupByIDBucketName], gid)
+		deleteFromBucket(buckets[groupToUsersBucketName], gid)
+		if err = buckets[groupByNameBucketName].Delete([]byte(g.Name)); err != nil {
+			panic(fmt.Sprintf("programming error: delete is not allowed in a RO transaction: %v", err))
+		}
+		if g.UGID == "" {
+			return nil
+		}
+		if err = buckets[groupByUGIDBucketName].Delete([]byte(g.UGID)); err != nil {
+			panic(fmt.Sprintf("programming error: delete is not allowed in a RO transaction: %v", err))
+		}
+		return nil
+	})
+}
+
 // deleteUserFromGroup removes the uid from the group.
 // If the group is empty after the uid gets removed, the group is deleted from the database.
 func deleteUserFromGroup(buckets map[string]bucketWithName, uid, gid uint32) error {
@@ -83,43 +127,50 @@ func deleteUser(buckets map[string]bucketWithName, uid uint32) (err error) {
 	if err = buckets[userToBrokerBucketName].Delete(uidKey); err != nil {
 		panic(fmt.Sprintf("programming error: delete is not allowed in a RO transaction: %v", err))
 	}
+	if err = buckets[userToLocalGroupsBucketName].Delete(uidKey); err != nil {
+		panic(fmt.Sprintf("programming error: delete is not allowed in a RO transaction: %v", err))
+	}
+	if err = buckets[userExtendedAttributesBucketName].Delete(uidKey); err != nil {
+		panic(fmt.Sprintf("programming error: delete is not allowed in a RO transaction: %v", err))
+	}
 	return nil
 }
 
 // deleteOrphanedUsers removes users from the UserByID bucket that are not in the UserByName bucket.
-func deleteOrphanedUsers(db *bbolt.DB) error {
+func deleteOrphanedUsers(buckets map[string]bucketWithName) error {
 	log.Debug(context.TODO(), "Cleaning up orphaned user records")
 
-	err := db.Update(func(tx *bbolt.Tx) error {
-		buckets, err := getAllBuckets(tx)
-		if err != nil {
-			return err
+	var orphans []uint32
+	err := buckets[userByIDBucketName].ForEach(func(k, v []byte) error {
+		var user UserDB
+		if err := json.Unmarshal(v, &user); err != nil {
+			log.Warningf(context.TODO(), "Error loading user record {%s: %s}: %v", k, v, err)
+			return nil
 		}
 
-		return buckets[userByIDBucketName].ForEach(func(k, v []byte) error {
-			var user UserDB
-			if err := json.Unmarshal(v, &user); err != nil {
-				log.Warningf(context.TODO(), "Error loading user record {%s: %s}: %v", k, v, err)
-				return nil
-			}
-
-			user2, err := getFromBucket[userDB](buckets[userByNameBucketName], user.Name)
-			if err != nil && !errors.Is(err, NoDataFoundError{}) {
-				log.Warningf(context.TODO(), "Error loading user record %q: %v", user.Name, err)
-				return nil
-			}
-			if errors.Is(err, NoDataFoundError{}) || user2.UID != user.UID {
-				log.Warningf(context.TODO(), "Removing orphaned user record %q with UID %d", user.Name, user.UID)
-				return deleteOrphanedUser(buckets, user.UID)
-			}
-
+		user2, err := getFromBucket[userDB](buckets[userByNameBucketName], user.Name)
+		if err != nil && !errors.Is(err, NoDataFoundError{}) {
+			log.Warningf(context.TODO(), "Error loading user record %q: %v", user.Name, err)
 			return nil
-		})
+		}
+		if errors.Is(err, NoDataFoundError{}) || user2.UID != user.UID {
+			log.Warningf(context.TODO(), "Removing orphaned user record %q with UID %d", user.Name, user.UID)
+			orphans = append(orphans, user.UID)
+		}
+
+		return nil
 	})
 	if err != nil {
 		return err
 	}
 
+	// Records can't be deleted while iterating over the bucket.
+	for _, uid := range orphans {
+		if err := deleteOrphanedUser(buckets, uid); err != nil {
+			return err
+		}
+	}
+
 	log.Debug(context.TODO(), "Done cleaning up orphaned user records")
 	return nil
 }
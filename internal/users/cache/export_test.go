@@ -1,6 +1,34 @@
 package cache
 
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
 // DbPath exposes the path to the database file for testing.
 func (c *Cache) DbPath() string {
 	return c.db.Path()
 }
+
+// SetLockTimeout changes the time to wait for the lock of the database and the number of attempts for testing.
+func SetLockTimeout(t *testing.T, timeout time.Duration, attempts int) {
+	t.Helper()
+
+	origTimeout, origAttempts := lockAttemptTimeout, lockAttempts
+	t.Cleanup(func() { lockAttemptTimeout, lockAttempts = origTimeout, origAttempts })
+	lockAttemptTimeout, lockAttempts = timeout, attempts
+}
+
+// Z_ForTests_FailReopen makes the reopening of the database after its compaction fail.
+//
+//nolint:revive,nolintlint // We want to use underscores in the function name here.
+func Z_ForTests_FailReopen(t *testing.T) {
+	t.Helper()
+
+	orig := reopenDB
+	t.Cleanup(func() { reopenDB = orig })
+	reopenDB = func(string) (*bbolt.DB, error) { return nil, errors.New("error requested in tests") }
+}
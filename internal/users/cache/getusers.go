@@ -1,19 +1,44 @@
 package cache
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"go.etcd.io/bbolt"
 )
 
+// DisabledExpirationDate is the expiration date, in days since the epoch, set on disabled accounts.
+// Like `usermod --expiredate 1`, it marks the account as expired since the first day after the epoch.
+const DisabledExpirationDate = 1
+
 // userDB is the struct stored in json format in the bucket.
 //
 // It prevents leaking of lastLogin, which is only relevant to the cache.
 type userDB struct {
 	UserDB
 	LastLogin time.Time
+	// LastLoginSource is the remote host or the terminal of the last login, if known.
+	LastLoginSource string `json:",omitempty"`
+	// QuotaProfile is the name of the quota profile applied to the user, if any.
+	QuotaProfile string `json:",omitempty"`
+	// RefreshedAt is the last time the information of the user was provided or confirmed by its broker.
+	RefreshedAt time.Time
+
+	// Provided are the attributes of the user as provided by the broker on the last login. It's used to detect the
+	// attributes which were modified locally since then. It's not set for users which didn't log in since the
+	// provided attributes are recorded.
+	Provided *providedAttributes `json:",omitempty"`
+}
+
+// providedAttributes are the attributes of a user which are provided by the broker and can be modified locally.
+type providedAttributes struct {
+	Gecos string
+	Dir   string
+	Shell string
 }
 
 // NewUserDB creates a new UserDB.
@@ -46,6 +71,25 @@ func (c *Cache) UserByName(name string) (UserDB, error) {
 	return u.UserDB, err
 }
 
+// LastLogin returns the time and the source of the last login of the user. The time is zero if no login was recorded.
+func (c *Cache) LastLogin(name string) (t time.Time, source string, err error) {
+	u, err := getUser(c, userByNameBucketName, name)
+	return u.LastLogin, u.LastLoginSource, err
+}
+
+// RefreshedAt returns the last time the information of the user was provided or confirmed by its broker. The time is
+// zero if it was never recorded.
+func (c *Cache) RefreshedAt(name string) (time.Time, error) {
+	u, err := getUser(c, userByNameBucketName, name)
+	return u.RefreshedAt, err
+}
+
+// QuotaProfile returns the name of the quota profile applied to the user, which is empty if none was applied.
+func (c *Cache) QuotaProfile(name string) (string, error) {
+	u, err := getUser(c, userByNameBucketName, name)
+	return u.QuotaProfile, err
+}
+
 // AllUsers returns all users or an error if the database is corrupted.
 func (c *Cache) AllUsers() (all []UserDB, err error) {
 	c.mu.RLock()
@@ -73,6 +117,84 @@ func (c *Cache) AllUsers() (all []UserDB, err error) {
 	return all, nil
 }
 
+// UserRecord is a user with its broker, its last login and the last refresh of its information.
+type UserRecord struct {
+	UserDB
+	BrokerID    string
+	LastLogin   time.Time
+	RefreshedAt time.Time
+}
+
+// ListUsers returns the users for which match returns true, sorted by UID, or an error if the database is corrupted.
+func (c *Cache) ListUsers(match func(UserRecord) bool) (users []UserRecord, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	err = c.db.View(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		return buckets[userByIDBucketName].ForEach(func(key, value []byte) error {
+			var e userDB
+			if err := json.Unmarshal(value, &e); err != nil {
+				return fmt.Errorf("can't unmarshal user in bucket %q for key %v: %v", userByIDBucketName, key, err)
+			}
+
+			brokerID, err := getFromBucket[string](buckets[userToBrokerBucketName], e.UID)
+			// Users without an assigned broker yet are listed with an empty broker ID.
+			if err != nil && !errors.Is(err, NoDataFoundError{}) {
+				return err
+			}
+
+			r := UserRecord{UserDB: e.UserDB, BrokerID: brokerID, LastLogin: e.LastLogin, RefreshedAt: e.RefreshedAt}
+			if match(r) {
+				users = append(users, r)
+			}
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The keys are the decimal UIDs, which are not ordered numerically by the database.
+	slices.SortFunc(users, func(a, b UserRecord) int { return cmp.Compare(a.UID, b.UID) })
+	return users, nil
+}
+
+// UsersLastLoggedInBefore returns all users whose last login is older than t, or an error if the database is corrupted.
+// Users without any recorded login are not returned.
+func (c *Cache) UsersLastLoggedInBefore(t time.Time) (users []UserDB, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	err = c.db.View(func(tx *bbolt.Tx) error {
+		bucket, err := getBucket(tx, userByIDBucketName)
+		if err != nil {
+			return err
+		}
+
+		return bucket.ForEach(func(key, value []byte) error {
+			var e userDB
+			if err := json.Unmarshal(value, &e); err != nil {
+				return fmt.Errorf("can't unmarshal user in bucket %q for key %v: %v", userByIDBucketName, key, err)
+			}
+			if e.LastLogin.IsZero() || !e.LastLogin.Before(t) {
+				return nil
+			}
+			users = append(users, e.UserDB)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
 // getUser returns an user matching the key or an error if the database is corrupted or no entry was found.
 func getUser[K uint32 | string](c *Cache, bucketName string, key K) (u userDB, err error) {
 	c.mu.RLock()
@@ -97,3 +219,29 @@ func getUser[K uint32 | string](c *Cache, bucketName string, key K) (u userDB, e
 
 	return u, nil
 }
+
+// UserExtendedAttributes returns the extended attributes of the user with the given UID. The map is empty if the user
+// has no extended attributes.
+func (c *Cache) UserExtendedAttributes(uid uint32) (attributes map[string]string, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err = c.db.View(func(tx *bbolt.Tx) error {
+		bucket, err := getBucket(tx, userExtendedAttributesBucketName)
+		if err != nil {
+			return err
+		}
+
+		attributes, err = getFromBucket[map[string]string](bucket, uid)
+		if errors.Is(err, NoDataFoundError{}) {
+			attributes = map[string]string{}
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attributes, nil
+}
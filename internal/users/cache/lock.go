@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ubuntu/authd/log"
+	"go.etcd.io/bbolt"
+)
+
+// The database file is protected by an advisory lock taken by bbolt when opening it: an exclusive one in read-write
+// mode and a shared one in read-only mode. Only one process can then modify the database, while several tools can read
+// it at the same time when it's not opened in read-write mode.
+var (
+	// lockAttemptTimeout is the time to wait for the lock of the database in each attempt to open it.
+	lockAttemptTimeout = 5 * time.Second
+	// lockAttempts is the number of attempts to open the database while it's locked by another process.
+	lockAttempts = 3
+)
+
+// LockedError is returned when the database can't be opened because another process holds its lock.
+type LockedError struct {
+	path string
+}
+
+// Error implements the error interface to return the path of the locked database.
+func (err LockedError) Error() string {
+	return fmt.Sprintf("database %q is locked by another process, is authd or another tool using it?", err.path)
+}
+
+// Is makes this error insensitive to the path of the database.
+func (LockedError) Is(target error) bool { return target == LockedError{} }
+
+// openDB opens the database file, retrying while it's locked by another process. It returns a LockedError if the
+// lock can't be acquired in time.
+func openDB(path string, readOnly bool) (db *bbolt.DB, err error) {
+	for i := range lockAttempts {
+		db, err = bbolt.Open(path, 0600, &bbolt.Options{ReadOnly: readOnly, Timeout: lockAttemptTimeout})
+		if !errors.Is(err, bbolt.ErrTimeout) {
+			break
+		}
+		if i < lockAttempts-1 {
+			log.Warningf(context.Background(), "Database %q is locked by another process, retrying", path)
+		}
+	}
+	if errors.Is(err, bbolt.ErrTimeout) {
+		return nil, LockedError{path: path}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't open database file: %v", err)
+	}
+
+	return db, nil
+}
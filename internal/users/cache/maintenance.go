@@ -0,0 +1,332 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+	"go.etcd.io/bbolt"
+)
+
+// MaintenanceReport is the summary of a maintenance of the database.
+type MaintenanceReport struct {
+	// PrunedReferences is the number of references to users or groups which don't exist anymore which were removed.
+	PrunedReferences int
+	// RebuiltIndexEntries is the number of entries of the secondary indexes which were added, updated or removed.
+	RebuiltIndexEntries int
+	// SizeBefore is the size in bytes of the database file before the compaction.
+	SizeBefore int64
+	// SizeAfter is the size in bytes of the database file after the compaction.
+	SizeAfter int64
+}
+
+// Maintenance prunes orphaned references, rebuilds the secondary indexes from the primary buckets and compacts the
+// database.
+func (c *Cache) Maintenance() (r MaintenanceReport, err error) {
+	defer decorate.OnError(&err, "could not run database maintenance")
+
+	r.PrunedReferences, err = c.pruneOrphanedReferences()
+	if err != nil {
+		return MaintenanceReport{}, err
+	}
+
+	r.RebuiltIndexEntries, err = c.rebuildIndexes()
+	if err != nil {
+		return MaintenanceReport{}, err
+	}
+
+	r.SizeBefore, r.SizeAfter, err = c.compact()
+	if err != nil {
+		return MaintenanceReport{}, err
+	}
+
+	return r, nil
+}
+
+// pruneOrphanedReferences removes the references to users and groups which are not in the UserByID and GroupByID
+// buckets anymore. It returns the number of removed references.
+func (c *Cache) pruneOrphanedReferences() (pruned int, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err = c.update(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		userExists := func(uid uint32) bool { return keyExists(buckets[userByIDBucketName], uid) }
+		groupExists := func(gid uint32) bool { return keyExists(buckets[groupByIDBucketName], gid) }
+
+		// Groups to users.
+		var groupsToUsers []groupToUsersDB
+		if err := forEachEntry(buckets[groupToUsersBucketName], func(g groupToUsersDB) { groupsToUsers = append(groupsToUsers, g) }); err != nil {
+			return err
+		}
+		for _, g := range groupsToUsers {
+			if !groupExists(g.GID) {
+				log.Debugf(context.TODO(), "Pruning members of unknown group %d", g.GID)
+				deleteFromBucket(buckets[groupToUsersBucketName], g.GID)
+				pruned++
+				continue
+			}
+			n := len(g.UIDs)
+			g.UIDs = slices.DeleteFunc(g.UIDs, func(uid uint32) bool { return !userExists(uid) })
+			if n == len(g.UIDs) {
+				continue
+			}
+			log.Debugf(context.TODO(), "Pruning %d unknown users from group %d", n-len(g.UIDs), g.GID)
+			pruned += n - len(g.UIDs)
+			updateBucket(buckets[groupToUsersBucketName], g.GID, g)
+		}
+
+		// Users to groups.
+		var usersToGroups []userToGroupsDB
+		if err := forEachEntry(buckets[userToGroupsBucketName], func(u userToGroupsDB) { usersToGroups = append(usersToGroups, u) }); err != nil {
+			return err
+		}
+		for _, u := range usersToGroups {
+			if !userExists(u.UID) {
+				log.Debugf(context.TODO(), "Pruning groups of unknown user %d", u.UID)
+				deleteFromBucket(buckets[userToGroupsBucketName], u.UID)
+				pruned++
+				continue
+			}
+			n := len(u.GIDs)
+			u.GIDs = slices.DeleteFunc(u.GIDs, func(gid uint32) bool { return !groupExists(gid) })
+			if n == len(u.GIDs) {
+				continue
+			}
+			log.Debugf(context.TODO(), "Pruning %d unknown groups from user %d", n-len(u.GIDs), u.UID)
+			pruned += n - len(u.GIDs)
+			updateBucket(buckets[userToGroupsBucketName], u.UID, u)
+		}
+
+		// Other buckets indexed by UID.
+		for _, name := range []string{userToBrokerBucketName, userToLocalGroupsBucketName, userExtendedAttributesBucketName} {
+			var orphans []uint32
+			err := buckets[name].ForEach(func(k, _ []byte) error {
+				uid, err := strconv.ParseUint(string(k), 10, 32)
+				if err != nil {
+					return fmt.Errorf("invalid key %q in bucket %q: %v", k, name, err)
+				}
+				if !userExists(uint32(uid)) {
+					orphans = append(orphans, uint32(uid))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, uid := range orphans {
+				log.Debugf(context.TODO(), "Pruning entry of unknown user %d from bucket %q", uid, name)
+				deleteFromBucket(buckets[name], uid)
+				pruned++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return pruned, nil
+}
+
+// rebuildIndexes rebuilds the UserByName, GroupByName and GroupByUGID buckets from the UserByID and GroupByID ones.
+// It returns the number of index entries which were added, updated or removed.
+func (c *Cache) rebuildIndexes() (rebuilt int, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err = c.update(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		n, err := rebuildIndex(buckets[userByIDBucketName], buckets[userByNameBucketName], func(v []byte) (string, error) {
+			var u userDB
+			err := json.Unmarshal(v, &u)
+			return u.Name, err
+		})
+		if err != nil {
+			return err
+		}
+		rebuilt += n
+
+		n, err = rebuildIndex(buckets[groupByIDBucketName], buckets[groupByNameBucketName], func(v []byte) (string, error) {
+			var g groupDB
+			err := json.Unmarshal(v, &g)
+			return g.Name, err
+		})
+		if err != nil {
+			return err
+		}
+		rebuilt += n
+
+		n, err = rebuildIndex(buckets[groupByIDBucketName], buckets[groupByUGIDBucketName], func(v []byte) (string, error) {
+			var g groupDB
+			err := json.Unmarshal(v, &g)
+			return g.UGID, err
+		})
+		if err != nil {
+			return err
+		}
+		rebuilt += n
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return rebuilt, nil
+}
+
+// rebuildIndex makes the index bucket contain exactly the entries of the primary bucket, keyed by indexKey.
+// Entries with an empty index key are not indexed.
+func rebuildIndex(primary, index bucketWithName, indexKey func([]byte) (string, error)) (changes int, err error) {
+	want := make(map[string][]byte)
+	err = primary.ForEach(func(k, v []byte) error {
+		key, err := indexKey(v)
+		if err != nil {
+			return fmt.Errorf("can't unmarshal entry in bucket %q for key %s: %v", primary.name, k, err)
+		}
+		if key == "" {
+			return nil
+		}
+		want[key] = bytes.Clone(v)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var toDelete [][]byte
+	err = index.ForEach(func(k, v []byte) error {
+		w, ok := want[string(k)]
+		if !ok {
+			toDelete = append(toDelete, bytes.Clone(k))
+			return nil
+		}
+		if bytes.Equal(w, v) {
+			delete(want, string(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Delete calls fail if the transaction is read only, so we should panic if this function is called in that context.
+	for _, k := range toDelete {
+		log.Debugf(context.TODO(), "Removing stale entry %q from bucket %q", k, index.name)
+		if err := index.Delete(k); err != nil {
+			panic(fmt.Sprintf("programming error: delete is not allowed in a RO transaction: %v", err))
+		}
+	}
+	for k, v := range want {
+		log.Debugf(context.TODO(), "Rebuilding entry %q of bucket %q", k, index.name)
+		if err := index.Put([]byte(k), v); err != nil {
+			panic(fmt.Sprintf("programming error: Put is not executed in a RW transaction: %v", err))
+		}
+	}
+
+	return len(toDelete) + len(want), nil
+}
+
+// reopenDB opens the compacted database. It's only overridden in tests.
+var reopenDB = func(path string) (*bbolt.DB, error) {
+	return openDB(path, false)
+}
+
+// compact rewrites the database to a new file to reclaim the free pages, and replaces the current database with it.
+// It returns the sizes of the database file before and after the compaction.
+func (c *Cache) compact() (sizeBefore, sizeAfter int64, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.db.Path()
+	tmpPath := path + ".compact"
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	sizeBefore = fi.Size()
+
+	dst, err := bbolt.Open(tmpPath, 0600, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("can't create compacted database: %v", err)
+	}
+	if err := bbolt.Compact(dst, c.db, 0); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(tmpPath)
+		return 0, 0, fmt.Errorf("can't compact database: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, 0, err
+	}
+
+	// The original database is kept under another name until the compacted one is opened, so that it can be restored
+	// if the compacted one can't be opened. The current handle stays open until then, as the database must not be left
+	// closed.
+	backupPath := path + ".orig"
+	_ = os.Remove(backupPath)
+	if err := os.Link(path, backupPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, 0, fmt.Errorf("can't keep original database: %v", err)
+	}
+	defer func() { _ = os.Remove(backupPath) }()
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, 0, fmt.Errorf("can't replace database with compacted one: %v", err)
+	}
+	db, err := reopenDB(path)
+	if err != nil {
+		if restoreErr := os.Rename(backupPath, path); restoreErr != nil {
+			// The database file is the compacted one, which can't be opened, so authd would fail to start.
+			return 0, 0, fmt.Errorf("can't open compacted database: %w, and can't restore the original one: %v", err, restoreErr)
+		}
+		return 0, 0, fmt.Errorf("can't open compacted database, the original one is kept: %w", err)
+	}
+	if err := c.db.Close(); err != nil {
+		log.Warningf(context.Background(), "Could not close the database before compaction: %v", err)
+	}
+	c.db = db
+
+	fi, err = os.Stat(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	sizeAfter = fi.Size()
+
+	log.Debugf(context.TODO(), "Compacted database from %d to %d bytes", sizeBefore, sizeAfter)
+	return sizeBefore, sizeAfter, nil
+}
+
+// keyExists returns true if the bucket has an entry for this ID.
+func keyExists(bucket bucketWithName, id uint32) bool {
+	return bucket.Get([]byte(strconv.FormatUint(uint64(id), 10))) != nil
+}
+
+// forEachEntry calls fn with each value of the bucket unmarshalled to T.
+func forEachEntry[T any](bucket bucketWithName, fn func(T)) error {
+	return bucket.ForEach(func(k, v []byte) error {
+		var e T
+		if err := json.Unmarshal(v, &e); err != nil {
+			return fmt.Errorf("can't unmarshal entry in bucket %q for key %s: %v", bucket.name, k, err)
+		}
+		fn(e)
+		return nil
+	})
+}
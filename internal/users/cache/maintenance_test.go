@@ -0,0 +1,77 @@
+package cache_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users/cache"
+)
+
+func TestMaintenance(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile string
+
+		wantPrunedReferences    int
+		wantRebuiltIndexEntries int
+		wantErr                 bool
+	}{
+		"Consistent_database_is_unchanged":                   {dbFile: "multiple_users_and_groups"},
+		"Orphaned_references_are_pruned_and_indexes_rebuilt": {dbFile: "inconsistent_references", wantPrunedReferences: 6, wantRebuiltIndexEntries: 8},
+
+		"Error_on_invalid_database_entry": {dbFile: "invalid_entry_in_groupToUsers", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, tc.dbFile)
+
+			r, err := c.Maintenance()
+			if tc.wantErr {
+				require.Error(t, err, "Maintenance should return an error but didn't")
+				return
+			}
+			require.NoError(t, err, "Maintenance should not return an error")
+
+			require.Equal(t, tc.wantPrunedReferences, r.PrunedReferences, "Maintenance should prune the expected references")
+			require.Equal(t, tc.wantRebuiltIndexEntries, r.RebuiltIndexEntries, "Maintenance should rebuild the expected index entries")
+			require.Positive(t, r.SizeAfter, "Compacted database should not be empty")
+			require.LessOrEqual(t, r.SizeAfter, r.SizeBefore, "Compacted database should not be bigger than the original one")
+
+			// The compacted database should still be usable.
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestMaintenanceKeepsDatabaseIfReopenFails(t *testing.T) {
+	// The reopening of the database is overridden, so this test can't run in parallel.
+	c := initCache(t, "multiple_users_and_groups")
+	want, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+	require.NoError(t, err, "Setup: could not dump the database")
+
+	cache.Z_ForTests_FailReopen(t)
+	_, err = c.Maintenance()
+	require.Error(t, err, "Maintenance should return an error if the compacted database can't be opened")
+
+	// The original database is still open and usable, and is the one on disk.
+	got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+	require.NoError(t, err, "The database should still be usable")
+	require.Equal(t, want, got, "The database should be unchanged")
+	require.NoError(t, c.UpdateBrokerForUser("user1", "ExampleBrokerID"), "The database should still be writable")
+	cacheDir := filepath.Dir(c.DbPath())
+	require.NoError(t, c.Close(), "Setup: could not close the database")
+
+	reopened, err := cache.New(cacheDir)
+	require.NoError(t, err, "The original database should be kept on disk")
+	t.Cleanup(func() { _ = reopened.Close() })
+	broker, err := reopened.BrokerForUser("user1")
+	require.NoError(t, err, "BrokerForUser should not return an error")
+	require.Equal(t, "ExampleBrokerID", broker, "The changes after the failed compaction should be kept")
+}
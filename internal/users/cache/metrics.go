@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Metrics are statistics about the database.
+type Metrics struct {
+	// Users and Groups are the number of users and groups in the database.
+	Users  int
+	Groups int
+	// Size is the size in bytes of the database.
+	Size int64
+	// WriteTransactions is the number of write transactions since the database was opened.
+	WriteTransactions uint64
+	// WriteTransactionsTime is the total duration of these write transactions, including the commits.
+	WriteTransactionsTime time.Duration
+	// MaxWriteTransactionTime is the duration of the longest of these write transactions.
+	MaxWriteTransactionTime time.Duration
+}
+
+// writeStats records the number and the duration of the write transactions.
+type writeStats struct {
+	mu    sync.Mutex
+	count uint64
+	total time.Duration
+	max   time.Duration
+}
+
+func (s *writeStats) record(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.count++
+	s.total += d
+	s.max = max(s.max, d)
+}
+
+// update runs fn in a write transaction and records its duration.
+func (c *Cache) update(fn func(tx *bbolt.Tx) error) error {
+	start := time.Now()
+	defer func() { c.writeStats.record(time.Since(start)) }()
+
+	return c.db.Update(fn)
+}
+
+// Metrics returns statistics about the database.
+func (c *Cache) Metrics() (m Metrics, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err = c.db.View(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		m.Users = buckets[userByIDBucketName].Stats().KeyN
+		m.Groups = buckets[groupByIDBucketName].Stats().KeyN
+		m.Size = tx.Size()
+		return nil
+	})
+	if err != nil {
+		return Metrics{}, err
+	}
+
+	c.writeStats.mu.Lock()
+	defer c.writeStats.mu.Unlock()
+	m.WriteTransactions = c.writeStats.count
+	m.WriteTransactionsTime = c.writeStats.total
+	m.MaxWriteTransactionTime = c.writeStats.max
+
+	return m, nil
+}
+
+// CountAuditEntriesSince returns the number of entries of the audit log with the given action which were recorded
+// after the given time.
+func (c *Cache) CountAuditEntriesSince(action string, since time.Time) (n int, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err = c.db.View(func(tx *bbolt.Tx) error {
+		bucket, err := getBucket(tx, auditLogBucketName)
+		if err != nil {
+			return err
+		}
+
+		// The entries are stored in chronological order, so walk them from the newest one and stop at the first one
+		// which is too old.
+		cur := bucket.Cursor()
+		for k, v := cur.Last(); k != nil; k, v = cur.Prev() {
+			var e AuditEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("can't unmarshal entry in bucket %q for key %s: %v", bucket.name, k, err)
+			}
+			if !e.Time.After(since) {
+				return nil
+			}
+			if e.Action == action {
+				n++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,52 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users/cache"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, "multiple_users_and_groups")
+
+	m, err := c.Metrics()
+	require.NoError(t, err, "Metrics should not return an error")
+	require.Equal(t, 4, m.Users, "Metrics should count the users")
+	require.Equal(t, 5, m.Groups, "Metrics should count the groups")
+	require.Positive(t, m.Size, "Metrics should return the size of the database")
+	require.Zero(t, m.WriteTransactions, "No write transaction should have been recorded")
+
+	require.NoError(t, c.AppendAuditEntries(cache.AuditEntry{Time: time.Now(), Action: "user-added"}), "Setup: AppendAuditEntries should not return an error")
+	require.NoError(t, c.UpdateBrokerForUser("user1", "ExampleBrokerID"), "Setup: UpdateBrokerForUser should not return an error")
+
+	m, err = c.Metrics()
+	require.NoError(t, err, "Metrics should not return an error")
+	require.Equal(t, uint64(2), m.WriteTransactions, "Metrics should count the write transactions")
+	require.GreaterOrEqual(t, m.WriteTransactionsTime, m.MaxWriteTransactionTime, "Total time of the write transactions should include the longest one")
+	require.Positive(t, m.MaxWriteTransactionTime, "Metrics should record the duration of the write transactions")
+}
+
+func TestCountAuditEntriesSince(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, "")
+
+	n, err := c.CountAuditEntriesSince("user-added", time.Unix(0, 0))
+	require.NoError(t, err, "CountAuditEntriesSince should not return an error on an empty audit log")
+	require.Zero(t, n, "CountAuditEntriesSince should not count entries of an empty audit log")
+
+	require.NoError(t, c.AppendAuditEntries(
+		cache.AuditEntry{Time: time.Unix(1000, 0), Action: "user-added", Target: "user1"},
+		cache.AuditEntry{Time: time.Unix(2000, 0), Action: "user-added", Target: "user2"},
+		cache.AuditEntry{Time: time.Unix(3000, 0), Action: "user-updated", Target: "user2"},
+		cache.AuditEntry{Time: time.Unix(4000, 0), Action: "user-added", Target: "user3"},
+	), "Setup: AppendAuditEntries should not return an error")
+
+	n, err = c.CountAuditEntriesSince("user-added", time.Unix(1000, 0))
+	require.NoError(t, err, "CountAuditEntriesSince should not return an error")
+	require.Equal(t, 2, n, "CountAuditEntriesSince should only count the entries of the action after the given time")
+}
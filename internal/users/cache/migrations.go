@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ubuntu/authd/log"
+	"go.etcd.io/bbolt"
+)
+
+const schemaVersionKey = "SchemaVersion"
+
+// migration upgrades the database from the previous schema version to its version.
+type migration struct {
+	version     uint32
+	description string
+	migrate     func(buckets map[string]bucketWithName) error
+}
+
+// migrations are all the database migrations, ordered by version.
+//
+// To change the format of the database, append a new migration with the next version. Migrations run in the same
+// transaction as the creation of the buckets and before unknown buckets are removed, so they can still read data from
+// buckets which are not part of the schema anymore.
+var migrations = []migration{
+	{
+		version: 1,
+		// Commit dfc4191ae73cd1f27483798e21093934f23d5059 released in 0.3.5 potentially messed up the database by
+		// adding a user with the same name but different UID to the UserByID bucket, and overwriting the existing user
+		// with the same name in the UserByName bucket.
+		description: "remove orphaned user records of authd 0.3.5",
+		migrate:     deleteOrphanedUsers,
+	},
+}
+
+// currentSchemaVersion returns the version of the database schema supported by this version of authd.
+func currentSchemaVersion() uint32 {
+	return migrations[len(migrations)-1].version
+}
+
+// SchemaVersion returns the version of the schema of the database.
+func (c *Cache) SchemaVersion() (version uint32, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err = c.db.View(func(tx *bbolt.Tx) error {
+		bucket, err := getBucket(tx, metadataBucketName)
+		if err != nil {
+			return err
+		}
+		version, err = getSchemaVersion(bucket)
+		return err
+	})
+	return version, err
+}
+
+// migrate runs all the migrations newer than the schema version of the database and updates its version.
+// It fails if the database was created by a newer version of authd.
+func migrate(tx *bbolt.Tx) error {
+	buckets, err := getAllBuckets(tx)
+	if err != nil {
+		return err
+	}
+
+	version, err := getSchemaVersion(buckets[metadataBucketName])
+	if err != nil {
+		return err
+	}
+	if version > currentSchemaVersion() {
+		return fmt.Errorf("database schema version %d is newer than the supported version %d", version, currentSchemaVersion())
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		log.Infof(context.TODO(), "Migrating database to schema version %d: %s", m.version, m.description)
+		if err := m.migrate(buckets); err != nil {
+			return fmt.Errorf("migration to schema version %d failed: %w", m.version, err)
+		}
+		version = m.version
+	}
+
+	updateBucket(buckets[metadataBucketName], schemaVersionKey, version)
+	return nil
+}
+
+// getSchemaVersion returns the schema version stored in the metadata bucket.
+// Databases created before the introduction of the schema version have version 0.
+func getSchemaVersion(bucket bucketWithName) (uint32, error) {
+	data := bucket.Get([]byte(schemaVersionKey))
+	if data == nil {
+		return 0, nil
+	}
+
+	var version uint32
+	if err := json.Unmarshal(data, &version); err != nil {
+		return 0, fmt.Errorf("invalid database schema version %q: %v", data, err)
+	}
+	return version, nil
+}
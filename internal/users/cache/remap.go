@@ -0,0 +1,318 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// UserIDTranslation is the kind of a translation recorded when a UID was remapped.
+	UserIDTranslation = "user"
+	// GroupIDTranslation is the kind of a translation recorded when a GID was remapped.
+	GroupIDTranslation = "group"
+)
+
+// IDTranslation is a record of an ID which was remapped to a new value.
+type IDTranslation struct {
+	Kind  string
+	Name  string
+	OldID uint32
+	NewID uint32
+	Time  time.Time
+}
+
+// IDCollision is an ID which is used by more than one entry of the database.
+type IDCollision struct {
+	ID    uint32
+	Names []string
+}
+
+// RemapUserID changes the UID of the user with the given name and records the translation.
+func (c *Cache) RemapUserID(name string, newUID uint32) (t IDTranslation, err error) {
+	defer decorate.OnError(&err, "could not remap UID of user %q", name)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err = c.update(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		u, err := getFromBucket[userDB](buckets[userByNameBucketName], name)
+		if err != nil {
+			return err
+		}
+		oldUID := u.UID
+		if oldUID == newUID {
+			return fmt.Errorf("user already has UID %d", newUID)
+		}
+
+		_, err = getFromBucket[userDB](buckets[userByIDBucketName], newUID)
+		if err == nil {
+			return fmt.Errorf("UID %d is already in use", newUID)
+		}
+		if !errors.Is(err, NoDataFoundError{}) {
+			return err
+		}
+
+		log.Debugf(context.TODO(), "Remapping UID of user %q from %d to %d", name, oldUID, newUID)
+
+		u.UID = newUID
+		deleteFromBucket(buckets[userByIDBucketName], oldUID)
+		updateBucket(buckets[userByIDBucketName], newUID, u)
+		updateBucket(buckets[userByNameBucketName], u.Name, u)
+
+		groups, err := getFromBucket[userToGroupsDB](buckets[userToGroupsBucketName], oldUID)
+		if err != nil && !errors.Is(err, NoDataFoundError{}) {
+			return err
+		}
+		hasGroups := err == nil
+		for _, gid := range groups.GIDs {
+			groupToUsers, err := getFromBucket[groupToUsersDB](buckets[groupToUsersBucketName], gid)
+			if err != nil && !errors.Is(err, NoDataFoundError{}) {
+				return err
+			}
+			groupToUsers.UIDs = replaceID(groupToUsers.UIDs, oldUID, newUID)
+			updateBucket(buckets[groupToUsersBucketName], gid, groupToUsers)
+		}
+		if hasGroups {
+			groups.UID = newUID
+			deleteFromBucket(buckets[userToGroupsBucketName], oldUID)
+			updateBucket(buckets[userToGroupsBucketName], newUID, groups)
+		}
+
+		if err := moveKey[string](buckets[userToBrokerBucketName], oldUID, newUID); err != nil {
+			return err
+		}
+		if err := moveKey[[]string](buckets[userToLocalGroupsBucketName], oldUID, newUID); err != nil {
+			return err
+		}
+		if err := moveKey[map[string]string](buckets[userExtendedAttributesBucketName], oldUID, newUID); err != nil {
+			return err
+		}
+
+		t = IDTranslation{Kind: UserIDTranslation, Name: name, OldID: oldUID, NewID: newUID, Time: time.Now()}
+		return appendToBucket(buckets[idTranslationsBucketName], t)
+	})
+	if err != nil {
+		return IDTranslation{}, err
+	}
+
+	return t, nil
+}
+
+// RemapGroupID changes the GID of the group with the given name and records the translation.
+// Users having this group as primary group are updated accordingly.
+func (c *Cache) RemapGroupID(name string, newGID uint32) (t IDTranslation, err error) {
+	defer decorate.OnError(&err, "could not remap GID of group %q", name)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err = c.update(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		g, err := getFromBucket[groupDB](buckets[groupByNameBucketName], name)
+		if err != nil {
+			return err
+		}
+		oldGID := g.GID
+		if oldGID == newGID {
+			return fmt.Errorf("group already has GID %d", newGID)
+		}
+
+		_, err = getFromBucket[groupDB](buckets[groupByIDBucketName], newGID)
+		if err == nil {
+			return fmt.Errorf("GID %d is already in use", newGID)
+		}
+		if !errors.Is(err, NoDataFoundError{}) {
+			return err
+		}
+
+		log.Debugf(context.TODO(), "Remapping GID of group %q from %d to %d", name, oldGID, newGID)
+
+		g.GID = newGID
+		deleteFromBucket(buckets[groupByIDBucketName], oldGID)
+		updateBucket(buckets[groupByIDBucketName], newGID, g)
+		updateBucket(buckets[groupByNameBucketName], g.Name, g)
+		if g.UGID != "" {
+			updateBucket(buckets[groupByUGIDBucketName], g.UGID, g)
+		}
+
+		members, err := getFromBucket[groupToUsersDB](buckets[groupToUsersBucketName], oldGID)
+		if err != nil && !errors.Is(err, NoDataFoundError{}) {
+			return err
+		}
+		for _, uid := range members.UIDs {
+			userToGroups, err := getFromBucket[userToGroupsDB](buckets[userToGroupsBucketName], uid)
+			if err != nil && !errors.Is(err, NoDataFoundError{}) {
+				return err
+			}
+			userToGroups.GIDs = replaceID(userToGroups.GIDs, oldGID, newGID)
+			updateBucket(buckets[userToGroupsBucketName], uid, userToGroups)
+		}
+		members.GID = newGID
+		deleteFromBucket(buckets[groupToUsersBucketName], oldGID)
+		updateBucket(buckets[groupToUsersBucketName], newGID, members)
+
+		// Update the primary group of all users using it.
+		var usersToUpdate []userDB
+		err = buckets[userByIDBucketName].ForEach(func(_, v []byte) error {
+			var u userDB
+			if err := json.Unmarshal(v, &u); err != nil {
+				return fmt.Errorf("can't unmarshal user in bucket %q: %v", userByIDBucketName, err)
+			}
+			if u.GID == oldGID {
+				usersToUpdate = append(usersToUpdate, u)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, u := range usersToUpdate {
+			u.GID = newGID
+			updateBucket(buckets[userByIDBucketName], u.UID, u)
+			updateBucket(buckets[userByNameBucketName], u.Name, u)
+		}
+
+		t = IDTranslation{Kind: GroupIDTranslation, Name: name, OldID: oldGID, NewID: newGID, Time: time.Now()}
+		return appendToBucket(buckets[idTranslationsBucketName], t)
+	})
+	if err != nil {
+		return IDTranslation{}, err
+	}
+
+	return t, nil
+}
+
+// IDTranslations returns all the ID translations recorded in the database, from the oldest to the newest.
+func (c *Cache) IDTranslations() (translations []IDTranslation, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err = c.db.View(func(tx *bbolt.Tx) error {
+		bucket, err := getBucket(tx, idTranslationsBucketName)
+		if err != nil {
+			return err
+		}
+
+		// Keys are sequence numbers, so iterating over the bucket returns the translations in order.
+		return bucket.ForEach(func(k, _ []byte) error {
+			t, err := getFromBucket[IDTranslation](bucket, string(k))
+			if err != nil {
+				return err
+			}
+			translations = append(translations, t)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return translations, nil
+}
+
+// UserIDCollisions returns the UIDs which are used by more than one user name.
+func (c *Cache) UserIDCollisions() ([]IDCollision, error) {
+	return idCollisions(c, userByNameBucketName, func(v []byte) (uint32, string, error) {
+		var u UserDB
+		err := json.Unmarshal(v, &u)
+		return u.UID, u.Name, err
+	})
+}
+
+// GroupIDCollisions returns the GIDs which are used by more than one group name.
+func (c *Cache) GroupIDCollisions() ([]IDCollision, error) {
+	return idCollisions(c, groupByNameBucketName, func(v []byte) (uint32, string, error) {
+		var g groupDB
+		err := json.Unmarshal(v, &g)
+		return g.GID, g.Name, err
+	})
+}
+
+// idCollisions returns the IDs which are used by more than one entry of the given bucket.
+func idCollisions(c *Cache, bucketName string, idAndName func([]byte) (uint32, string, error)) (collisions []IDCollision, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	namesByID := make(map[uint32][]string)
+	var ids []uint32
+	err = c.db.View(func(tx *bbolt.Tx) error {
+		bucket, err := getBucket(tx, bucketName)
+		if err != nil {
+			return err
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			id, name, err := idAndName(v)
+			if err != nil {
+				return fmt.Errorf("can't unmarshal entry in bucket %q for key %s: %v", bucketName, k, err)
+			}
+			if _, ok := namesByID[id]; !ok {
+				ids = append(ids, id)
+			}
+			namesByID[id] = append(namesByID[id], name)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(ids)
+	for _, id := range ids {
+		if len(namesByID[id]) < 2 {
+			continue
+		}
+		collisions = append(collisions, IDCollision{ID: id, Names: namesByID[id]})
+	}
+
+	return collisions, nil
+}
+
+// moveKey moves the value stored under oldID to newID, if any.
+func moveKey[T any](bucket bucketWithName, oldID, newID uint32) error {
+	v, err := getFromBucket[T](bucket, oldID)
+	if errors.Is(err, NoDataFoundError{}) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	deleteFromBucket(bucket, oldID)
+	updateBucket(bucket, newID, v)
+	return nil
+}
+
+// deleteFromBucket removes the given key from the bucket. It panics if we call it in RO transaction.
+func deleteFromBucket(bucket bucketWithName, id uint32) {
+	if err := bucket.Delete([]byte(strconv.FormatUint(uint64(id), 10))); err != nil {
+		panic(fmt.Sprintf("programming error: delete is not allowed in a RO transaction: %v", err))
+	}
+}
+
+// replaceID returns ids with every occurrence of oldID replaced by newID.
+func replaceID(ids []uint32, oldID, newID uint32) []uint32 {
+	for i, id := range ids {
+		if id == oldID {
+			ids[i] = newID
+		}
+	}
+	return ids
+}
@@ -0,0 +1,169 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users/cache"
+)
+
+func TestRemapUserID(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile string
+		name   string
+		newUID uint32
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Remap_user_with_groups_and_broker": {name: "user1", newUID: 5555},
+		"Remap_user_without_broker":         {name: "userwithoutbroker", newUID: 5555},
+
+		"Error_on_missing_user":           {name: "doesnotexist", newUID: 5555, wantErrType: cache.NoDataFoundError{}},
+		"Error_if_UID_is_unchanged":       {name: "user1", newUID: 1111, wantErr: true},
+		"Error_if_UID_is_already_in_use":  {name: "user1", newUID: 2222, wantErr: true},
+		"Error_on_invalid_database_entry": {dbFile: "invalid_entry_in_userByName", name: "user1", newUID: 5555, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.dbFile == "" {
+				tc.dbFile = "multiple_users_and_groups"
+			}
+			c := initCache(t, tc.dbFile)
+
+			translation, err := c.RemapUserID(tc.name, tc.newUID)
+			if tc.wantErrType != nil {
+				require.ErrorIs(t, err, tc.wantErrType, "RemapUserID should return expected error")
+				return
+			}
+			if tc.wantErr {
+				require.Error(t, err, "RemapUserID should return an error but didn't")
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.newUID, translation.NewID, "RemapUserID should return the new UID")
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestRemapGroupID(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile string
+		name   string
+		newGID uint32
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Remap_primary_group":   {name: "group1", newGID: 55555},
+		"Remap_secondary_group": {name: "commongroup", newGID: 55555},
+
+		"Error_on_missing_group":          {name: "doesnotexist", newGID: 55555, wantErrType: cache.NoDataFoundError{}},
+		"Error_if_GID_is_unchanged":       {name: "group1", newGID: 11111, wantErr: true},
+		"Error_if_GID_is_already_in_use":  {name: "group1", newGID: 22222, wantErr: true},
+		"Error_on_invalid_database_entry": {dbFile: "invalid_entry_in_groupByName", name: "group1", newGID: 55555, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.dbFile == "" {
+				tc.dbFile = "multiple_users_and_groups"
+			}
+			c := initCache(t, tc.dbFile)
+
+			translation, err := c.RemapGroupID(tc.name, tc.newGID)
+			if tc.wantErrType != nil {
+				require.ErrorIs(t, err, tc.wantErrType, "RemapGroupID should return expected error")
+				return
+			}
+			if tc.wantErr {
+				require.Error(t, err, "RemapGroupID should return an error but didn't")
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.newGID, translation.NewID, "RemapGroupID should return the new GID")
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestIDTranslations(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, "multiple_users_and_groups")
+
+	got, err := c.IDTranslations()
+	require.NoError(t, err, "IDTranslations should not return an error on an empty table")
+	require.Empty(t, got, "IDTranslations should return no translation before any remapping")
+
+	_, err = c.RemapUserID("user1", 5555)
+	require.NoError(t, err, "Setup: RemapUserID should not return an error")
+	_, err = c.RemapGroupID("group2", 55555)
+	require.NoError(t, err, "Setup: RemapGroupID should not return an error")
+	_, err = c.RemapUserID("user1", 6666)
+	require.NoError(t, err, "Setup: RemapUserID should not return an error")
+
+	got, err = c.IDTranslations()
+	require.NoError(t, err, "IDTranslations should not return an error")
+	require.Len(t, got, 3, "IDTranslations should return all the remappings")
+
+	// The time of the remapping is not deterministic, so we only check that it's set.
+	var gotFields []cache.IDTranslation
+	for _, tr := range got {
+		require.False(t, tr.Time.IsZero(), "The time of the remapping should be recorded")
+		gotFields = append(gotFields, cache.IDTranslation{Kind: tr.Kind, Name: tr.Name, OldID: tr.OldID, NewID: tr.NewID})
+	}
+	golden.CheckOrUpdateYAML(t, gotFields)
+}
+
+func TestIDCollisions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dbFile string
+
+		wantErr bool
+	}{
+		"No_collisions_in_consistent_database": {dbFile: "multiple_users_and_groups"},
+		"Collisions_between_cached_entries":    {dbFile: "colliding_ids"},
+
+		"Error_on_invalid_database_entry": {dbFile: "invalid_entry_in_userByName", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := initCache(t, tc.dbFile)
+
+			userCollisions, err := c.UserIDCollisions()
+			if tc.wantErr {
+				require.Error(t, err, "UserIDCollisions should return an error but didn't")
+				return
+			}
+			require.NoError(t, err, "UserIDCollisions should not return an error")
+
+			groupCollisions, err := c.GroupIDCollisions()
+			require.NoError(t, err, "GroupIDCollisions should not return an error")
+
+			golden.CheckOrUpdateYAML(t, map[string][]cache.IDCollision{
+				"users":  userCollisions,
+				"groups": groupCollisions,
+			})
+		})
+	}
+}
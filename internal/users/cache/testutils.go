@@ -32,32 +32,24 @@ var redactedTimes = map[string]string{
 func redactTime(line string) string {
 	testsdetection.MustBeTesting()
 
-	re := regexp.MustCompile(`"LastLogin":"(.*?)"`)
-	match := re.FindSubmatch([]byte(line))
-
-	if len(match) <= 1 {
-		// Not found
-		return line
-	}
+	re := regexp.MustCompile(`"(?:LastLogin|RefreshedAt|Time)":"(.*?)"`)
+	return re.ReplaceAllStringFunc(line, func(field string) string {
+		value := re.FindStringSubmatch(field)[1]
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return field
+		}
+		isNow := time.Since(t) < time.Minute*5
 
-	lastLogin := string(match[1])
-	lastLoginTime, err := time.Parse(time.RFC3339, lastLogin)
-	if err != nil {
-		return line
-	}
-	var isNow bool
-	if time.Since(lastLoginTime) < time.Minute*5 {
-		isNow = true
-	}
+		for redacted, time := range redactedTimes {
+			if value != time && (time != "now" || !isNow) {
+				continue
+			}
 
-	for redacted, time := range redactedTimes {
-		if lastLogin != time && (time != "now" || !isNow) {
-			continue
+			return strings.Replace(field, value, redacted, 1)
 		}
-
-		return strings.Replace(line, lastLogin, redacted, 1)
-	}
-	return line
+		return field
+	})
 }
 
 // Z_ForTests_DumpNormalizedYAML gets the content of the database, normalizes it
@@ -139,7 +131,7 @@ func Z_ForTests_FromYAML(r io.Reader, destDir string) error {
 						if t == "now" {
 							t = time.Now().Format(time.RFC3339)
 						}
-						val = strings.Replace(val, redacted, t, 1)
+						val = strings.ReplaceAll(val, redacted, t)
 					}
 				}
 				if err := bucket.Put([]byte(key), []byte(val)); err != nil {
@@ -13,50 +13,171 @@ import (
 	"go.etcd.io/bbolt"
 )
 
+// UserUpdate is a set of changes to a user which are applied in a single transaction.
+type UserUpdate struct {
+	User        UserDB
+	AuthdGroups []GroupDB
+	LocalGroups []string
+	// BrokerID, if not empty, is recorded as the broker providing the user.
+	BrokerID string
+	// ExtendedAttributes replace the extended attributes of the user.
+	ExtendedAttributes map[string]string
+	// KeepLastLogin, if set, doesn't record the update as a login of the user.
+	KeepLastLogin bool
+	// AuditEntries, if set, returns the entries to append to the audit log, given the user as stored, which keeps the
+	// attributes modified locally.
+	AuditEntries func(stored UserDB) []AuditEntry
+}
+
 // UpdateUserEntry inserts or updates user and group buckets from the user information.
 func (c *Cache) UpdateUserEntry(usr UserDB, authdGroups []GroupDB, localGroups []string) error {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	_, err := c.ApplyUserUpdate(UserUpdate{User: usr, AuthdGroups: authdGroups, LocalGroups: localGroups})
+	return err
+}
 
-	userDB := userDB{
-		UserDB:    usr,
-		LastLogin: time.Now(),
+// ApplyUserUpdate inserts or updates the user, its groups, its broker and the audit log in a single transaction, so
+// that either all the changes are applied or none is. It returns the user as stored.
+func (c *Cache) ApplyUserUpdate(update UserUpdate) (stored UserDB, err error) {
+	users, err := c.ApplyUserUpdates(update)
+	if err != nil {
+		return UserDB{}, err
+	}
+	return users[0], nil
+}
+
+// ApplyUserUpdates applies a batch of updates in a single transaction: if the batch is invalid or any of the updates
+// fails, none of them is applied. The updates are applied in order, and the users are returned as stored.
+func (c *Cache) ApplyUserUpdates(updates ...UserUpdate) (stored []UserDB, err error) {
+	if err := validateUserUpdates(updates); err != nil {
+		return nil, err
 	}
 
-	err := c.db.Update(func(tx *bbolt.Tx) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	err = c.update(func(tx *bbolt.Tx) error {
 		buckets, err := getAllBuckets(tx)
 		if err != nil {
 			return err
 		}
 
-		previousGroupsForCurrentUser, err := getFromBucket[userToGroupsDB](buckets[userToGroupsBucketName], userDB.UID)
-		// No data is valid and means this is the first insertion.
-		if err != nil && !errors.Is(err, NoDataFoundError{}) {
-			return err
+		for _, update := range updates {
+			u, err := applyUserUpdate(buckets, update, now)
+			if err != nil {
+				return fmt.Errorf("could not update user %q: %w", update.User.Name, err)
+			}
+			stored = append(stored, u)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		/* 1. Handle user update */
-		if err := updateUser(buckets, userDB); err != nil {
-			return err
+	return stored, nil
+}
+
+// validateUserUpdates checks that the updates of a batch don't conflict with each other.
+func validateUserUpdates(updates []UserUpdate) error {
+	if len(updates) == 0 {
+		return errors.New("no user update to apply")
+	}
+
+	names := make(map[string]uint32)
+	uids := make(map[uint32]string)
+	groups := make(map[uint32]GroupDB)
+	for _, update := range updates {
+		u := update.User
+		if u.Name == "" {
+			return fmt.Errorf("no name for user with UID %d", u.UID)
+		}
+		if _, ok := names[u.Name]; ok {
+			return fmt.Errorf("user %q is updated more than once", u.Name)
 		}
+		if name, ok := uids[u.UID]; ok {
+			return fmt.Errorf("UID %d is used by both user %q and user %q", u.UID, name, u.Name)
+		}
+		names[u.Name] = u.UID
+		uids[u.UID] = u.Name
 
-		/* 2. Handle groups update */
-		if err := updateGroups(buckets, authdGroups); err != nil {
-			return err
+		for _, g := range update.AuthdGroups {
+			if other, ok := groups[g.GID]; ok && (other.Name != g.Name || other.UGID != g.UGID) {
+				return fmt.Errorf("GID %d is used by both group %q and group %q", g.GID, other.Name, g.Name)
+			}
+			groups[g.GID] = g
 		}
+	}
 
-		/* 3. Users and groups mapping buckets */
-		if err := updateUsersAndGroups(buckets, userDB.UID, authdGroups, previousGroupsForCurrentUser.GIDs); err != nil {
-			return err
+	return nil
+}
+
+// applyUserUpdate applies the update in the transaction of the buckets and returns the user as stored.
+func applyUserUpdate(buckets map[string]bucketWithName, update UserUpdate, now time.Time) (stored UserDB, err error) {
+	newUser := userDB{
+		UserDB:      update.User,
+		LastLogin:   now,
+		RefreshedAt: now,
+	}
+	if update.KeepLastLogin {
+		existingUser, err := getFromBucket[userDB](buckets[userByIDBucketName], newUser.UID)
+		if err != nil && !errors.Is(err, NoDataFoundError{}) {
+			return UserDB{}, err
 		}
+		newUser.LastLogin = existingUser.LastLogin
+	}
 
-		/* 4. Update user to local groups bucket */
-		updateBucket(buckets[userToLocalGroupsBucketName], userDB.UID, localGroups)
+	previousGroupsForCurrentUser, err := getFromBucket[userToGroupsDB](buckets[userToGroupsBucketName], newUser.UID)
+	// No data is valid and means this is the first insertion.
+	if err != nil && !errors.Is(err, NoDataFoundError{}) {
+		return UserDB{}, err
+	}
 
-		return nil
-	})
+	/* 1. Handle user update */
+	if err := updateUser(buckets, newUser); err != nil {
+		return UserDB{}, err
+	}
 
-	return err
+	/* 2. Handle groups update */
+	if err := updateGroups(buckets, update.AuthdGroups); err != nil {
+		return UserDB{}, err
+	}
+
+	/* 3. Users and groups mapping buckets */
+	if err := updateUsersAndGroups(buckets, newUser.UID, update.AuthdGroups, previousGroupsForCurrentUser.GIDs); err != nil {
+		return UserDB{}, err
+	}
+
+	/* 4. Update user to local groups bucket */
+	updateBucket(buckets[userToLocalGroupsBucketName], newUser.UID, update.LocalGroups)
+
+	/* 5. Update user to broker bucket */
+	if update.BrokerID != "" {
+		updateBucket(buckets[userToBrokerBucketName], newUser.UID, update.BrokerID)
+	}
+
+	/* 6. Update user extended attributes bucket */
+	if len(update.ExtendedAttributes) > 0 {
+		updateBucket(buckets[userExtendedAttributesBucketName], newUser.UID, update.ExtendedAttributes)
+	} else {
+		deleteFromBucket(buckets[userExtendedAttributesBucketName], newUser.UID)
+	}
+
+	/* 7. Append the changes to the audit log */
+	u, err := getFromBucket[userDB](buckets[userByIDBucketName], newUser.UID)
+	if err != nil {
+		return UserDB{}, err
+	}
+	if update.AuditEntries == nil {
+		return u.UserDB, nil
+	}
+	for _, e := range update.AuditEntries(u.UserDB) {
+		if err := appendToBucket(buckets[auditLogBucketName], e); err != nil {
+			return UserDB{}, err
+		}
+	}
+
+	return u.UserDB, nil
 }
 
 // updateUser updates both user buckets with userContent.
@@ -72,11 +193,18 @@ func updateUser(buckets map[string]bucketWithName, userContent userDB) error {
 		return errors.New("UID already in use by a different user")
 	}
 
-	// Ensure that we use the same homedir as the one we have in cache.
-	if existingUser.Dir != "" && existingUser.Dir != userContent.Dir {
-		log.Warningf(context.TODO(), "User %q already has a homedir. The existing %q one will be kept instead of %q", userContent.Name, existingUser.Dir, userContent.Dir)
-		userContent.Dir = existingUser.Dir
+	// The lock of the user can only be changed by an administrator.
+	userContent.Locked = existingUser.Locked
+	// The source of the login and the quota profile are recorded separately.
+	userContent.LastLoginSource = existingUser.LastLoginSource
+	userContent.QuotaProfile = existingUser.QuotaProfile
+
+	// Record the attributes provided by the broker before applying the local modifications.
+	provided := providedAttributes{Gecos: userContent.Gecos, Dir: userContent.Dir, Shell: userContent.Shell}
+	if existingUser.Provided != nil {
+		keepLocalModifications(existingUser, &userContent)
 	}
+	userContent.Provided = &provided
 
 	// Update user buckets
 	log.Debug(context.Background(), fmt.Sprintf("Updating entry of user %q (UID: %d)", userContent.Name, userContent.UID))
@@ -86,6 +214,22 @@ func updateUser(buckets map[string]bucketWithName, userContent userDB) error {
 	return nil
 }
 
+// keepLocalModifications keeps the attributes of the existing user which were modified locally since the last login
+// instead of the ones provided by the broker.
+func keepLocalModifications(existingUser userDB, userContent *userDB) {
+	keep := func(attr string, existing, provided string, value *string) {
+		if existing == provided || existing == *value {
+			return
+		}
+		log.Infof(context.TODO(), "Keeping locally modified %s %q of user %q instead of %q", attr, existing, userContent.Name, *value)
+		*value = existing
+	}
+
+	keep("GECOS", existingUser.Gecos, existingUser.Provided.Gecos, &userContent.Gecos)
+	keep("home directory", existingUser.Dir, existingUser.Provided.Dir, &userContent.Dir)
+	keep("shell", existingUser.Shell, existingUser.Provided.Shell, &userContent.Shell)
+}
+
 // updateUser updates all group buckets with groupContent.
 func updateGroups(buckets map[string]bucketWithName, groupContents []GroupDB) error {
 	for _, groupContent := range groupContents {
@@ -189,7 +333,7 @@ func (c *Cache) UpdateBrokerForUser(username, brokerID string) error {
 		return err
 	}
 
-	err = c.db.Update(func(tx *bbolt.Tx) error {
+	err = c.update(func(tx *bbolt.Tx) error {
 		bucket, err := getBucket(tx, userToBrokerBucketName)
 		if err != nil {
 			return err
@@ -200,3 +344,189 @@ func (c *Cache) UpdateBrokerForUser(username, brokerID string) error {
 
 	return err
 }
+
+// DisableUser marks the account of the user as expired, so that it can't be used to log in anymore.
+func (c *Cache) DisableUser(uid uint32) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.update(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		u, err := getFromBucket[userDB](buckets[userByIDBucketName], uid)
+		if err != nil {
+			return err
+		}
+
+		log.Debugf(context.TODO(), "Disabling user %q (UID: %d)", u.Name, u.UID)
+		u.ExpirationDate = DisabledExpirationDate
+		updateBucket(buckets[userByIDBucketName], u.UID, u)
+		updateBucket(buckets[userByNameBucketName], u.Name, u)
+		return nil
+	})
+}
+
+// SetUserLocked locks or unlocks the user. Locked users are still returned, but can't authenticate anymore.
+func (c *Cache) SetUserLocked(uid uint32, locked bool) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.update(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		u, err := getFromBucket[userDB](buckets[userByIDBucketName], uid)
+		if err != nil {
+			return err
+		}
+
+		log.Debugf(context.TODO(), "Setting lock of user %q (UID: %d) to %t", u.Name, u.UID, locked)
+		u.Locked = locked
+		updateBucket(buckets[userByIDBucketName], u.UID, u)
+		updateBucket(buckets[userByNameBucketName], u.Name, u)
+		return nil
+	})
+}
+
+// SetQuotaProfile records the name of the quota profile applied to the user.
+func (c *Cache) SetQuotaProfile(uid uint32, profile string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.update(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		u, err := getFromBucket[userDB](buckets[userByIDBucketName], uid)
+		if err != nil {
+			return err
+		}
+
+		log.Debugf(context.TODO(), "Setting quota profile of user %q (UID: %d) to %q", u.Name, u.UID, profile)
+		u.QuotaProfile = profile
+		updateBucket(buckets[userByIDBucketName], u.UID, u)
+		updateBucket(buckets[userByNameBucketName], u.Name, u)
+		return nil
+	})
+}
+
+// RevokeLocalGroups removes the local groups from the ones the user was added to, with their expiry.
+func (c *Cache) RevokeLocalGroups(uid uint32, groups []string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.update(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		u, err := getFromBucket[userDB](buckets[userByIDBucketName], uid)
+		if err != nil {
+			return err
+		}
+		localGroups, err := getFromBucket[[]string](buckets[userToLocalGroupsBucketName], uid)
+		if err != nil && !errors.Is(err, NoDataFoundError{}) {
+			return err
+		}
+
+		log.Debugf(context.TODO(), "Revoking local groups %v of user %q (UID: %d)", groups, u.Name, u.UID)
+		localGroups = slices.DeleteFunc(localGroups, func(g string) bool { return slices.Contains(groups, g) })
+		updateBucket(buckets[userToLocalGroupsBucketName], u.UID, localGroups)
+
+		for _, g := range groups {
+			delete(u.LocalGroupsExpiry, g)
+		}
+		if len(u.LocalGroupsExpiry) == 0 {
+			u.LocalGroupsExpiry = nil
+		}
+		updateBucket(buckets[userByIDBucketName], u.UID, u)
+		updateBucket(buckets[userByNameBucketName], u.Name, u)
+		return nil
+	})
+}
+
+// RecordLogin records the time and the source of the last login of the user.
+func (c *Cache) RecordLogin(uid uint32, t time.Time, source string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.update(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		u, err := getFromBucket[userDB](buckets[userByIDBucketName], uid)
+		if err != nil {
+			return err
+		}
+
+		log.Debugf(context.TODO(), "Recording login of user %q (UID: %d) from %q", u.Name, u.UID, source)
+		u.LastLogin = t
+		u.LastLoginSource = source
+		updateBucket(buckets[userByIDBucketName], u.UID, u)
+		updateBucket(buckets[userByNameBucketName], u.Name, u)
+		return nil
+	})
+}
+
+// SetUserRefreshed records that the information of the user was confirmed by its broker at t.
+func (c *Cache) SetUserRefreshed(uid uint32, t time.Time) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.update(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		u, err := getFromBucket[userDB](buckets[userByIDBucketName], uid)
+		if err != nil {
+			return err
+		}
+
+		log.Debugf(context.TODO(), "Recording refresh of user %q (UID: %d)", u.Name, u.UID)
+		u.RefreshedAt = t
+		updateBucket(buckets[userByIDBucketName], u.UID, u)
+		updateBucket(buckets[userByNameBucketName], u.Name, u)
+		return nil
+	})
+}
+
+// UpdateUserAttributes modifies the GECOS, home directory and shell of the user locally. The modified attributes take
+// precedence over the ones provided by the broker on the next logins.
+func (c *Cache) UpdateUserAttributes(usr UserDB) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.update(func(tx *bbolt.Tx) error {
+		buckets, err := getAllBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		u, err := getFromBucket[userDB](buckets[userByIDBucketName], usr.UID)
+		if err != nil {
+			return err
+		}
+		if u.Provided == nil {
+			// Consider the current attributes as the provided ones, so that the modifications are detected.
+			u.Provided = &providedAttributes{Gecos: u.Gecos, Dir: u.Dir, Shell: u.Shell}
+		}
+
+		log.Debugf(context.TODO(), "Updating attributes of user %q (UID: %d)", u.Name, u.UID)
+		u.Gecos, u.Dir, u.Shell = usr.Gecos, usr.Dir, usr.Shell
+		updateBucket(buckets[userByIDBucketName], u.UID, u)
+		updateBucket(buckets[userByNameBucketName], u.Name, u)
+		return nil
+	})
+}
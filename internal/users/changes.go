@@ -0,0 +1,67 @@
+package users
+
+import (
+	"slices"
+
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+// Kinds of the changes notified to the change handler.
+const (
+	ChangeUserAdded    = "UserAdded"
+	ChangeUserUpdated  = "UserUpdated"
+	ChangeUserRemoved  = "UserRemoved"
+	ChangeGroupChanged = "GroupChanged"
+	// ChangeUserBrokerChanged is notified when the broker assigned to a user changes.
+	ChangeUserBrokerChanged = "UserBrokerChanged"
+)
+
+// changeKinds maps the actions of the audit log modifying the database to the kinds of changes they notify.
+var changeKinds = map[string]string{
+	AuditUserAdded:          ChangeUserAdded,
+	AuditUserAdopted:        ChangeUserAdded,
+	AuditUserUpdated:        ChangeUserUpdated,
+	AuditUserDisabled:       ChangeUserUpdated,
+	AuditUserLocked:         ChangeUserUpdated,
+	AuditUserUnlocked:       ChangeUserUpdated,
+	AuditUIDRemapped:        ChangeUserUpdated,
+	AuditMembershipsChanged: ChangeUserUpdated,
+	AuditUserDeleted:        ChangeUserRemoved,
+	AuditGroupAdded:         ChangeGroupChanged,
+	AuditGroupDeleted:       ChangeGroupChanged,
+	AuditGIDRemapped:        ChangeGroupChanged,
+}
+
+// changesOf returns the changes of the database recorded by the audit events.
+func changesOf(events ...types.AuditEvent) (changes []types.Change) {
+	for _, e := range events {
+		if kind, ok := changeKinds[e.Action]; ok {
+			changes = append(changes, types.Change{Kind: kind, Name: e.Target})
+		}
+	}
+	return changes
+}
+
+// groupChanges returns the changes of the groups whose members changed.
+func groupChanges(groups ...string) (changes []types.Change) {
+	for _, g := range groups {
+		changes = append(changes, types.Change{Kind: ChangeGroupChanged, Name: g})
+	}
+	return changes
+}
+
+// notify calls the change handler for each change. Duplicated changes are only notified once.
+func (m *Manager) notify(changes ...types.Change) {
+	if m.changeHandler == nil {
+		return
+	}
+
+	var notified []types.Change
+	for _, c := range changes {
+		if slices.Contains(notified, c) {
+			continue
+		}
+		notified = append(notified, c)
+		m.changeHandler(c)
+	}
+}
@@ -0,0 +1,74 @@
+package users_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/devicetokens"
+	"github.com/ubuntu/authd/internal/users/idgenerator"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestChangeHandler(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var got []types.Change
+	handler := func(c types.Change) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, c)
+	}
+	requireChanges := func(want ...types.Change) {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, want, got, "The change handler was not called with the expected changes")
+		got = nil
+	}
+
+	m, err := users.NewManager(users.DefaultConfig, t.TempDir(),
+		users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+			UIDsToGenerate: []uint32{1111},
+			GIDsToGenerate: []uint32{11110, 22220},
+		}),
+		users.WithChangeHandler(handler),
+		users.WithDeviceTokenKeyring(devicetokens.Z_ForTests_NewKeyring(t)),
+	)
+	require.NoError(t, err, "Setup: could not create user manager")
+	t.Cleanup(func() { _ = m.Stop() })
+
+	u := types.UserInfo{Name: "user1", Dir: "/home/user1", Shell: "/bin/bash", Groups: []types.GroupInfo{{Name: "group1", UGID: "1"}}}
+	require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "UpdateUser should not return an error")
+	requireChanges(
+		types.Change{Kind: users.ChangeGroupChanged, Name: "user1"},
+		types.Change{Kind: users.ChangeGroupChanged, Name: "group1"},
+		types.Change{Kind: users.ChangeUserAdded, Name: "user1"},
+		types.Change{Kind: users.ChangeUserUpdated, Name: "user1"},
+	)
+
+	// Nothing is notified if nothing changed.
+	require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "UpdateUser should not return an error")
+	requireChanges()
+
+	// The user can't be given to another broker.
+	require.ErrorIs(t, m.UpdateBrokerForUser("user1", "other-broker-id"), users.ErrUserOfOtherBroker,
+		"UpdateBrokerForUser should not give the user to another broker")
+	requireChanges()
+
+	// Nothing is notified if the broker of the user did not change.
+	require.NoError(t, m.UpdateBrokerForUser("user1", "broker-id"), "UpdateBrokerForUser should not return an error")
+	requireChanges()
+
+	require.NoError(t, m.LockUser("user1", "test"), "LockUser should not return an error")
+	requireChanges(types.Change{Kind: users.ChangeUserUpdated, Name: "user1"})
+
+	require.NoError(t, m.PurgeUser("user1", "test"), "PurgeUser should not return an error")
+	requireChanges(
+		types.Change{Kind: users.ChangeUserRemoved, Name: "user1"},
+		types.Change{Kind: users.ChangeGroupChanged, Name: "user1"},
+	)
+}
@@ -0,0 +1,336 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"syscall"
+
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/localentries"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+const (
+	// UserKind is the kind of collisions and translations of UIDs.
+	UserKind = cache.UserIDTranslation
+	// GroupKind is the kind of collisions and translations of GIDs.
+	GroupKind = cache.GroupIDTranslation
+
+	// maxIDGenerationAttempts is the number of IDs we try before giving up finding a free one.
+	maxIDGenerationAttempts = 100
+)
+
+// IDCollisions returns all UIDs and GIDs which are used by more than one authd entry, or both by an authd entry and
+// an entry of the local passwd or group files or of the other NSS sources, like extrausers or LDAP.
+func (m *Manager) IDCollisions() (collisions []types.IDCollision, err error) {
+	defer decorate.OnError(&err, "could not check for ID collisions")
+
+	userCollisions, err := m.cache.UserIDCollisions()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range userCollisions {
+		collisions = append(collisions, types.IDCollision{Kind: UserKind, ID: c.ID, AuthdNames: c.Names})
+	}
+
+	groupCollisions, err := m.cache.GroupIDCollisions()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range groupCollisions {
+		collisions = append(collisions, types.IDCollision{Kind: GroupKind, ID: c.ID, AuthdNames: c.Names})
+	}
+
+	authdUsers, err := m.cache.AllUsers()
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range authdUsers {
+		localUsers, err := localentries.UsersByUID(u.UID)
+		if err != nil {
+			return nil, err
+		}
+		if len(localUsers) == 0 {
+			continue
+		}
+		var localNames []string
+		for _, lu := range localUsers {
+			localNames = append(localNames, lu.Name)
+		}
+		collisions = append(collisions, types.IDCollision{Kind: UserKind, ID: u.UID, AuthdNames: []string{u.Name}, LocalNames: localNames})
+	}
+
+	authdGroups, err := m.cache.AllGroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range authdGroups {
+		localGroups, err := localentries.GroupsByGID(g.GID)
+		if err != nil {
+			return nil, err
+		}
+		if len(localGroups) == 0 {
+			continue
+		}
+		var localNames []string
+		for _, lg := range localGroups {
+			localNames = append(localNames, lg.Name)
+		}
+		collisions = append(collisions, types.IDCollision{Kind: GroupKind, ID: g.GID, AuthdNames: []string{g.Name}, LocalNames: localNames})
+	}
+
+	return collisions, nil
+}
+
+// RemapUserID changes the UID of the given authd user. If newUID is 0, a free UID is generated.
+//
+// If chownHome is true, the files of the home directory of the user which are owned by the old UID are changed to be
+// owned by the new one. They are changed before the UID, and changed back if either fails, so that the UID is only
+// remapped with its files. The actor is recorded in the audit log as the requester of the change.
+func (m *Manager) RemapUserID(name string, newUID uint32, chownHome bool, actor string) (t types.IDTranslation, err error) {
+	defer decorate.OnError(&err, "failed to remap UID of user %q", name)
+
+	if err := m.checkWritable(); err != nil {
+		return types.IDTranslation{}, err
+	}
+
+	// Prevent concurrent logins of the user to use the old UID while we are remapping it.
+	m.updateUserMu.Lock()
+	defer m.updateUserMu.Unlock()
+
+	u, err := m.cache.UserByName(name)
+	if err != nil {
+		return types.IDTranslation{}, err
+	}
+
+	if newUID == 0 {
+		newUID, err = m.generateFreeID(m.idGenerator.GenerateUID, isUIDFree)
+		if err != nil {
+			return types.IDTranslation{}, err
+		}
+	} else if err := isUIDFree(newUID); err != nil {
+		return types.IDTranslation{}, err
+	}
+
+	var homes []string
+	if chownHome {
+		homes = []string{u.Dir}
+	}
+	if err := chownTrees(homes, int(u.UID), int(newUID), -1, -1); err != nil {
+		return types.IDTranslation{}, err
+	}
+
+	ct, err := m.cache.RemapUserID(name, newUID)
+	if err != nil {
+		return types.IDTranslation{}, errors.Join(err, chownTrees(homes, int(newUID), int(u.UID), -1, -1))
+	}
+	log.Infof(context.Background(), "Remapped UID of user %q from %d to %d", name, ct.OldID, ct.NewID)
+	m.audit(actor, types.AuditEvent{
+		Action:  AuditUIDRemapped,
+		Target:  name,
+		Details: fmt.Sprintf("UID changed from %d to %d", ct.OldID, ct.NewID),
+	})
+
+	return types.IDTranslation(ct), nil
+}
+
+// RemapGroupID changes the GID of the given authd group. If newGID is 0, a free GID is generated.
+//
+// If chownHome is true, the files of the home directories of the users having this group as primary group which are
+// owned by the old GID are changed to be owned by the new one. As for RemapUserID, they are changed before the GID, and
+// changed back if either fails. The actor is recorded in the audit log as the requester of the change.
+func (m *Manager) RemapGroupID(name string, newGID uint32, chownHome bool, actor string) (t types.IDTranslation, err error) {
+	defer decorate.OnError(&err, "failed to remap GID of group %q", name)
+
+	if err := m.checkWritable(); err != nil {
+		return types.IDTranslation{}, err
+	}
+
+	m.updateUserMu.Lock()
+	defer m.updateUserMu.Unlock()
+
+	if newGID == 0 {
+		newGID, err = m.generateFreeID(m.idGenerator.GenerateGID, isGIDFree)
+		if err != nil {
+			return types.IDTranslation{}, err
+		}
+	} else if err := isGIDFree(newGID); err != nil {
+		return types.IDTranslation{}, err
+	}
+
+	g, err := m.cache.GroupByName(name)
+	if err != nil {
+		return types.IDTranslation{}, err
+	}
+
+	var homes []string
+	if chownHome {
+		allUsers, err := m.cache.AllUsers()
+		if err != nil {
+			return types.IDTranslation{}, err
+		}
+		for _, u := range allUsers {
+			if u.GID == g.GID {
+				homes = append(homes, u.Dir)
+			}
+		}
+	}
+	if err := chownTrees(homes, -1, -1, int(g.GID), int(newGID)); err != nil {
+		return types.IDTranslation{}, err
+	}
+
+	ct, err := m.cache.RemapGroupID(name, newGID)
+	if err != nil {
+		return types.IDTranslation{}, errors.Join(err, chownTrees(homes, -1, -1, int(newGID), int(g.GID)))
+	}
+	log.Infof(context.Background(), "Remapped GID of group %q from %d to %d", name, ct.OldID, ct.NewID)
+	m.audit(actor, types.AuditEvent{
+		Action:  AuditGIDRemapped,
+		Target:  name,
+		Details: fmt.Sprintf("GID changed from %d to %d", ct.OldID, ct.NewID),
+	})
+
+	return types.IDTranslation(ct), nil
+}
+
+// IDTranslations returns all the UID and GID remappings done so far, from the oldest to the newest.
+func (m *Manager) IDTranslations() ([]types.IDTranslation, error) {
+	translations, err := m.cache.IDTranslations()
+	if err != nil {
+		return nil, err
+	}
+
+	var r []types.IDTranslation
+	for _, t := range translations {
+		r = append(r, types.IDTranslation(t))
+	}
+	return r, nil
+}
+
+// generateFreeID generates IDs until one is not used by any user or group on the system.
+func (m *Manager) generateFreeID(generate func() (uint32, error), isFree func(uint32) error) (uint32, error) {
+	for range maxIDGenerationAttempts {
+		id, err := generate()
+		if err != nil {
+			return 0, err
+		}
+		if err := isFree(id); err == nil {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("could not find a free ID after %d attempts", maxIDGenerationAttempts)
+}
+
+// isUIDFree returns an error if the UID is used by a user of the local passwd file or by any NSS source.
+func isUIDFree(uid uint32) error {
+	localUsers, err := localentries.CachedLocalUsers()
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(localUsers, func(u localentries.Passwd) bool { return u.UID == uid }) {
+		return fmt.Errorf("UID %d is already in use by a local user", uid)
+	}
+
+	existingUser, err := user.LookupId(strconv.FormatUint(uint64(uid), 10))
+	var unknownUserErr user.UnknownUserIdError
+	if errors.As(err, &unknownUserErr) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not check if UID %d is in use: %w", uid, err)
+	}
+	return fmt.Errorf("UID %d is already in use by user %q", uid, existingUser.Username)
+}
+
+// isGIDFree returns an error if the GID is used by a group of the local group file or by any NSS source.
+func isGIDFree(gid uint32) error {
+	localGroups, err := localentries.CachedLocalGroups()
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(localGroups, func(g localentries.Group) bool { return g.GID == gid }) {
+		return fmt.Errorf("GID %d is already in use by a local group", gid)
+	}
+
+	existingGroup, err := user.LookupGroupId(strconv.FormatUint(uint64(gid), 10))
+	var unknownGroupErr user.UnknownGroupIdError
+	if errors.As(err, &unknownGroupErr) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not check if GID %d is in use: %w", gid, err)
+	}
+	return fmt.Errorf("GID %d is already in use by group %q", gid, existingGroup.Name)
+}
+
+// chownTrees changes the owner of the files of the directories like chownTree. If it fails, the files which were
+// changed are changed back, so that they keep matching the IDs in the database.
+func chownTrees(dirs []string, fromUID, toUID, fromGID, toGID int) error {
+	for i, dir := range dirs {
+		err := chownTree(dir, fromUID, toUID, fromGID, toGID)
+		if err == nil {
+			continue
+		}
+		for _, d := range dirs[:i+1] {
+			if e := chownTree(d, toUID, fromUID, toGID, fromGID); e != nil {
+				err = errors.Join(err, fmt.Errorf("could not restore ownership: %w", e))
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// chownTree changes the owner of all files in dir which are owned by fromUID to toUID, and the group of all files
+// which are owned by fromGID to toGID, similar to `chown -R --from`. A value of -1 means that the ID is not changed.
+func chownTree(dir string, fromUID, toUID, fromGID, toGID int) error {
+	if dir == "" {
+		return nil
+	}
+
+	err := filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		fi, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+		sys, ok := fi.Sys().(*syscall.Stat_t)
+		if !ok {
+			return fmt.Errorf("failed to get file info of %q", path)
+		}
+
+		uid, gid := -1, -1
+		if fromUID >= 0 && int(sys.Uid) == fromUID {
+			uid = toUID
+		}
+		if fromGID >= 0 && int(sys.Gid) == fromGID {
+			gid = toGID
+		}
+		if uid == -1 && gid == -1 {
+			return nil
+		}
+
+		return os.Lchown(path, uid, gid)
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		log.Infof(context.Background(), "Home directory %q does not exist, no files to chown", dir)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not change ownership of files in %q: %w", dir, err)
+	}
+
+	return nil
+}
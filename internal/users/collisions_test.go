@@ -0,0 +1,195 @@
+package users_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/idgenerator"
+	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+)
+
+func TestIDCollisions(t *testing.T) {
+	tests := map[string]struct {
+		dbFile     string
+		passwdFile string
+		groupFile  string
+
+		wantErr bool
+	}{
+		"No_collisions":                             {},
+		"Collisions_with_local_users_and_groups":    {passwdFile: "users_with_colliding_uid.passwd", groupFile: "groups_with_colliding_gid.group"},
+		"Collisions_between_authd_users_and_groups": {dbFile: "colliding_ids"},
+
+		"Error_if_passwd_file_is_missing": {passwdFile: "does_not_exist.passwd", wantErr: true},
+		"Error_if_group_file_is_missing":  {groupFile: "does_not_exist.group", wantErr: true},
+		"Error_if_db_has_invalid_entry":   {dbFile: "invalid_entry_in_userByName", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.dbFile == "" {
+				tc.dbFile = "multiple_users_and_groups"
+			}
+			if tc.passwdFile == "" {
+				tc.passwdFile = "no_colliding_uid.passwd"
+			}
+			if tc.groupFile == "" {
+				tc.groupFile = "empty.group"
+			}
+
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", tc.groupFile))
+			localgroupstestutils.SetPasswdPath(filepath.Join("testdata", "passwd", tc.passwdFile))
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
+			m := newManagerForTests(t, cacheDir)
+
+			got, err := m.IDCollisions()
+			requireErrorAssertions(t, err, nil, tc.wantErr)
+			if tc.wantErr {
+				return
+			}
+
+			golden.CheckOrUpdateYAML(t, got)
+		})
+	}
+}
+
+func TestRemapUserID(t *testing.T) {
+	tests := map[string]struct {
+		username   string
+		newUID     uint32
+		generated  []uint32
+		passwdFile string
+		chownHome  bool
+		// invalidHome is whether the home directory of user1 can't be walked.
+		invalidHome bool
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Remap_user_to_given_UID":                {username: "user1", newUID: 5555},
+		"Remap_user_to_generated_UID":            {username: "user1", generated: []uint32{5555}},
+		"Generated_UID_skips_local_users":        {username: "user1", generated: []uint32{6666, 5555}, passwdFile: "users_with_colliding_uid.passwd"},
+		"Missing_home_directory_is_not_an_error": {username: "user1", newUID: 5555, chownHome: true},
+
+		"Error_and_keep_UID_if_home_can_not_be_chowned": {username: "user1", newUID: 5555, chownHome: true, invalidHome: true, wantErr: true},
+
+		"Error_if_user_does_not_exist":            {username: "doesnotexist", newUID: 5555, wantErrType: cache.NoDataFoundError{}},
+		"Error_if_UID_is_used_by_a_local_user":    {username: "user1", newUID: 6666, passwdFile: "users_with_colliding_uid.passwd", wantErr: true},
+		"Error_if_UID_is_used_by_another_user":    {username: "user1", newUID: 2222, wantErr: true},
+		"Error_if_no_free_UID_could_be_generated": {username: "user1", generated: []uint32{6666}, passwdFile: "users_with_colliding_uid.passwd", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.passwdFile == "" {
+				tc.passwdFile = "no_colliding_uid.passwd"
+			}
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "empty.group"))
+			localgroupstestutils.SetPasswdPath(filepath.Join("testdata", "passwd", tc.passwdFile))
+
+			cacheDir := t.TempDir()
+			createDBWithHomeOfUser1(t, cacheDir, tc.invalidHome)
+
+			m := newManagerForTests(t, cacheDir, users.WithIDGenerator(&idgenerator.IDGeneratorMock{UIDsToGenerate: tc.generated}))
+
+			_, err := m.RemapUserID(tc.username, tc.newUID, tc.chownHome, "test")
+			requireErrorAssertions(t, err, tc.wantErrType, tc.wantErr)
+			if tc.invalidHome {
+				u, err := m.UserByName(tc.username)
+				require.NoError(t, err, "UserByName should not return an error, but did")
+				require.Equal(t, uint32(1111), u.UID, "The UID should not be remapped if the home directory can't be chowned")
+			}
+			if tc.wantErrType != nil || tc.wantErr {
+				return
+			}
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(userstestutils.GetManagerCache(m))
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestRemapGroupID(t *testing.T) {
+	tests := map[string]struct {
+		groupname string
+		newGID    uint32
+		generated []uint32
+		groupFile string
+		chownHome bool
+		// invalidHome is whether the home directory of user1, whose primary group is group1, can't be walked.
+		invalidHome bool
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Remap_group_to_given_GID":               {groupname: "group1", newGID: 55555},
+		"Remap_group_to_generated_GID":           {groupname: "group1", generated: []uint32{55555}},
+		"Generated_GID_skips_local_groups":       {groupname: "group1", generated: []uint32{66666, 55555}, groupFile: "groups_with_colliding_gid.group"},
+		"Missing_home_directory_is_not_an_error": {groupname: "group1", newGID: 55555, chownHome: true},
+
+		"Error_and_keep_GID_if_home_can_not_be_chowned": {groupname: "group1", newGID: 55555, chownHome: true, invalidHome: true, wantErr: true},
+
+		"Error_if_group_does_not_exist":           {groupname: "doesnotexist", newGID: 55555, wantErrType: cache.NoDataFoundError{}},
+		"Error_if_GID_is_used_by_a_local_group":   {groupname: "group1", newGID: 66666, groupFile: "groups_with_colliding_gid.group", wantErr: true},
+		"Error_if_GID_is_used_by_another_group":   {groupname: "group1", newGID: 22222, wantErr: true},
+		"Error_if_no_free_GID_could_be_generated": {groupname: "group1", generated: []uint32{66666}, groupFile: "groups_with_colliding_gid.group", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.groupFile == "" {
+				tc.groupFile = "empty.group"
+			}
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", tc.groupFile))
+			localgroupstestutils.SetPasswdPath(filepath.Join("testdata", "passwd", "no_colliding_uid.passwd"))
+
+			cacheDir := t.TempDir()
+			createDBWithHomeOfUser1(t, cacheDir, tc.invalidHome)
+			m := newManagerForTests(t, cacheDir, users.WithIDGenerator(&idgenerator.IDGeneratorMock{GIDsToGenerate: tc.generated}))
+
+			_, err := m.RemapGroupID(tc.groupname, tc.newGID, tc.chownHome, "test")
+			requireErrorAssertions(t, err, tc.wantErrType, tc.wantErr)
+			if tc.invalidHome {
+				g, err := m.GroupByName(tc.groupname)
+				require.NoError(t, err, "GroupByName should not return an error, but did")
+				require.Equal(t, uint32(11111), g.GID, "The GID should not be remapped if the home directory can't be chowned")
+			}
+			if tc.wantErrType != nil || tc.wantErr {
+				return
+			}
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(userstestutils.GetManagerCache(m))
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+// createDBWithHomeOfUser1 creates the database with multiple users and groups in cacheDir. If invalidHome is set, the
+// home directory of user1 is below a regular file, so that it can't be walked, even by root.
+func createDBWithHomeOfUser1(t *testing.T, cacheDir string, invalidHome bool) {
+	t.Helper()
+
+	src := filepath.Join("testdata", "db", "multiple_users_and_groups.db.yaml")
+	if !invalidHome {
+		cache.Z_ForTests_CreateDBFromYAML(t, src, cacheDir)
+		return
+	}
+
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0600), "Setup: could not create file")
+	d, err := os.ReadFile(src)
+	require.NoError(t, err, "Setup: could not read database")
+	d = bytes.ReplaceAll(d, []byte(`"/home/user1"`), []byte(fmt.Sprintf("%q", filepath.Join(file, "user1"))))
+	src = filepath.Join(t.TempDir(), "db.yaml")
+	require.NoError(t, os.WriteFile(src, d, 0600), "Setup: could not write database")
+	cache.Z_ForTests_CreateDBFromYAML(t, src, cacheDir)
+}
@@ -27,6 +27,7 @@ func shadowEntryFromUserDB(u cache.UserDB) types.ShadowEntry {
 		PwdInactivity:  u.PwdInactivity,
 		MinPwdAge:      u.MinPwdAge,
 		ExpirationDate: u.ExpirationDate,
+		Locked:         u.Locked,
 	}
 }
 
@@ -0,0 +1,61 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ubuntu/authd/internal/securemem"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+)
+
+// validateDeviceTokens checks that the lifetime of the device tokens is valid.
+func validateDeviceTokens(config Config) error {
+	if config.DeviceTokenLifetime < 0 {
+		return fmt.Errorf("invalid device token lifetime %s, must not be negative", config.DeviceTokenLifetime)
+	}
+	return nil
+}
+
+// DeviceToken returns the device token the broker issued to the user at a previous login on this machine, to be
+// presented to the broker for it to skip the second factor. It's nil if there is none, if it expired, or if the
+// device tokens are disabled, and must be wiped after use.
+func (m *Manager) DeviceToken(brokerID, name string) *securemem.Buffer {
+	if m.config().DeviceTokenLifetime == 0 || name == "" {
+		return nil
+	}
+
+	token, err := m.deviceTokens.Get(brokerID, name)
+	if err != nil {
+		// The user can still authenticate with all the factors.
+		log.Warningf(context.Background(), "%v", err)
+		return nil
+	}
+	return token
+}
+
+// SetDeviceToken stores the device token the broker issued to the user after a multi-factor authentication, for the
+// configured lifetime. It's ignored if the device tokens are disabled.
+func (m *Manager) SetDeviceToken(brokerID, name, token string) error {
+	lifetime := m.config().DeviceTokenLifetime
+	if lifetime == 0 || name == "" {
+		return nil
+	}
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	expiry := time.Now().Add(lifetime)
+	if err := m.deviceTokens.Set(brokerID, name, token, expiry); err != nil {
+		return err
+	}
+
+	log.Infof(context.Background(), "This device is trusted for user %q until %s", name, expiry.Format(time.DateTime))
+	m.audit(ActorLogin, types.AuditEvent{
+		Action:  AuditDeviceTrusted,
+		Target:  name,
+		Details: fmt.Sprintf("for %s", lifetime),
+	})
+	return nil
+}
@@ -0,0 +1,295 @@
+// Package devicetokens stores the device tokens issued by the brokers after a multi-factor authentication, which are
+// presented to them at the next logins of the users on this machine, for them to skip the second factor until the
+// tokens expire.
+//
+// The tokens are stored in the persistent kernel keyrings of the users when a keyring is given to the store, so that
+// they are never written to the disk. Otherwise, or if the keyring can't be used, they are stored in a single file,
+// encrypted with AES-256-GCM with a key kept in a separate file, so that they can't be read from the file of the
+// tokens alone, like from a copy of it. Both files are only readable by root.
+package devicetokens
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ubuntu/authd/internal/securemem"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+const (
+	tokensFileName = "device-tokens"
+	keyFileName    = "device-tokens.key"
+
+	keySize = 32
+)
+
+// additionalData binds the encrypted tokens to their use.
+var additionalData = []byte("authd device tokens")
+
+// Store is the store of the device tokens in a directory, usually the cache directory of the daemon.
+type Store struct {
+	dir     string
+	keyring *Keyring
+	mu      sync.Mutex
+}
+
+// entry is a token issued by a broker to a user.
+type entry struct {
+	Broker string    `json:"broker"`
+	User   string    `json:"user"`
+	Token  string    `json:"token"`
+	Expiry time.Time `json:"expiry"`
+}
+
+type options struct {
+	keyring *Keyring
+}
+
+// Option is a supplementary argument of New.
+type Option func(*options)
+
+// WithKeyring makes the store keep the tokens in the keyring, and only fall back to the files of the directory when it
+// can't be used.
+func WithKeyring(k *Keyring) Option {
+	return func(o *options) {
+		o.keyring = k
+	}
+}
+
+// New returns the store of the device tokens in dir. The files are only created once a token is stored in them.
+func New(dir string, args ...Option) *Store {
+	opts := options{}
+	for _, arg := range args {
+		arg(&opts)
+	}
+	return &Store{dir: dir, keyring: opts.keyring}
+}
+
+// Get returns the token the broker issued to the user, or nil if there is none or if it expired. It must be wiped after
+// use.
+func (s *Store) Get(brokerID, user string) (token *securemem.Buffer, err error) {
+	defer decorate.OnError(&err, "could not get device token of user %q", user)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keyring != nil {
+		token, err := s.keyring.get(user, brokerID)
+		if err == nil && token.Len() > 0 {
+			return token, nil
+		}
+		if err != nil {
+			log.Debugf(context.Background(), "Looking up device token of user %q in the file: %v", user, err)
+		}
+	}
+
+	entries, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, e := range entries {
+		if e.Broker == brokerID && e.User == user && e.Expiry.After(now) {
+			return securemem.New([]byte(e.Token)), nil
+		}
+	}
+	return nil, nil
+}
+
+// Set stores the token the broker issued to the user until expiry, replacing the previous one. The expired tokens are
+// removed.
+func (s *Store) Set(brokerID, user, token string, expiry time.Time) (err error) {
+	defer decorate.OnError(&err, "could not store device token of user %q", user)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inKeyring := false
+	if s.keyring != nil {
+		err := s.keyring.set(user, brokerID, token, expiry)
+		if err != nil {
+			log.Warningf(context.Background(), "Storing device token of user %q in the file: %v", user, err)
+		}
+		inKeyring = err == nil
+	}
+
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	remaining := slices.DeleteFunc(slices.Clone(entries), func(e entry) bool {
+		return !e.Expiry.After(now) || (e.Broker == brokerID && e.User == user)
+	})
+	if inKeyring {
+		// The previous token of the user must not be returned instead of the one of the keyring once it expired.
+		if len(remaining) == len(entries) {
+			return nil
+		}
+		return s.save(remaining)
+	}
+	remaining = append(remaining, entry{Broker: brokerID, User: user, Token: token, Expiry: expiry})
+	return s.save(remaining)
+}
+
+// Delete removes the tokens issued to the user by all the brokers.
+func (s *Store) Delete(user string) (err error) {
+	defer decorate.OnError(&err, "could not delete device tokens of user %q", user)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keyring != nil {
+		if err := s.keyring.delete(user); err != nil {
+			log.Debugf(context.Background(), "Not deleting device tokens of user %q from the keyring: %v", user, err)
+		}
+	}
+
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	remaining := slices.DeleteFunc(slices.Clone(entries), func(e entry) bool { return e.User == user })
+	if len(remaining) == len(entries) {
+		return nil
+	}
+	return s.save(remaining)
+}
+
+// load returns the stored tokens, decrypted.
+func (s *Store) load() ([]entry, error) {
+	ciphertext, err := os.ReadFile(filepath.Join(s.dir, tokensFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := os.ReadFile(filepath.Join(s.dir, keyFileName))
+	if err != nil {
+		return nil, fmt.Errorf("could not read encryption key: %w", err)
+	}
+	key := securemem.New(k)
+	defer key.Wipe()
+	aead, err := newAEAD(key.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("device tokens file is too short")
+	}
+	plaintext, err := aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt device tokens: %w", err)
+	}
+	defer clear(plaintext)
+
+	var entries []entry
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return nil, fmt.Errorf("invalid device tokens: %w", err)
+	}
+	return entries, nil
+}
+
+// save encrypts the tokens and replaces the stored ones with them, creating the encryption key if needed. The file of
+// the tokens is removed if there is none.
+func (s *Store) save(entries []entry) error {
+	tokensPath := filepath.Join(s.dir, tokensFileName)
+	if len(entries) == 0 {
+		if err := os.Remove(tokensPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	key, err := s.key()
+	if err != nil {
+		return err
+	}
+	defer key.Wipe()
+	aead, err := newAEAD(key.Bytes())
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	defer clear(plaintext)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	ciphertext := aead.Seal(nonce, nonce, plaintext, additionalData)
+
+	// The tokens are written to a temporary file renamed over the previous one, so that they are never partially
+	// written.
+	tmp := tokensPath + ".tmp"
+	if err := os.WriteFile(tmp, ciphertext, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, tokensPath); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// key returns the encryption key of the tokens, creating it if it doesn't exist yet. It must be wiped after use.
+func (s *Store) key() (*securemem.Buffer, error) {
+	keyPath := filepath.Join(s.dir, keyFileName)
+	k, err := os.ReadFile(keyPath)
+	if err == nil {
+		return securemem.New(k), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("could not read encryption key: %w", err)
+	}
+
+	key := securemem.New(make([]byte, keySize))
+	if _, err := rand.Read(key.Bytes()); err != nil {
+		key.Wipe()
+		return nil, err
+	}
+	f, err := os.OpenFile(keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		key.Wipe()
+		return nil, fmt.Errorf("could not create encryption key: %w", err)
+	}
+	if _, err := f.Write(key.Bytes()); err != nil {
+		key.Wipe()
+		_ = f.Close()
+		_ = os.Remove(keyPath)
+		return nil, fmt.Errorf("could not write encryption key: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		key.Wipe()
+		return nil, err
+	}
+	return key, nil
+}
+
+// newAEAD returns the AES-256-GCM cipher of the tokens.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("invalid encryption key of %d bytes, expected %d", len(key), keySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
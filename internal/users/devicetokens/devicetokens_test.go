@@ -0,0 +1,185 @@
+package devicetokens_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users/devicetokens"
+	"golang.org/x/sys/unix"
+)
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		broker string
+		user   string
+		expiry time.Duration
+
+		corruptTokens bool
+		removeKey     bool
+
+		wantToken string
+		wantErr   bool
+	}{
+		"Get_the_token_issued_to_the_user": {wantToken: "some-token"},
+
+		"No_token_if_none_was_issued_to_the_user":   {user: "other-user"},
+		"No_token_if_none_was_issued_by_the_broker": {broker: "other-broker"},
+		"No_token_if_it_expired":                    {expiry: -time.Minute},
+		"No_token_if_none_was_issued_to_any_user":   {broker: "-"},
+
+		"Error_if_the_tokens_are_corrupted":      {corruptTokens: true, wantErr: true},
+		"Error_if_the_encryption_key_is_missing": {removeKey: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			s := devicetokens.New(dir)
+
+			if tc.broker != "-" {
+				if tc.expiry == 0 {
+					tc.expiry = time.Hour
+				}
+				err := s.Set("some-broker", "some-user", "some-token", time.Now().Add(tc.expiry))
+				require.NoError(t, err, "Setup: could not store the token")
+			}
+			if tc.broker == "" || tc.broker == "-" {
+				tc.broker = "some-broker"
+			}
+			if tc.user == "" {
+				tc.user = "some-user"
+			}
+			if tc.corruptTokens {
+				err := os.WriteFile(filepath.Join(dir, "device-tokens"), []byte("corrupted tokens"), 0600)
+				require.NoError(t, err, "Setup: could not corrupt the tokens")
+			}
+			if tc.removeKey {
+				require.NoError(t, os.Remove(filepath.Join(dir, "device-tokens.key")), "Setup: could not remove the key")
+			}
+
+			token, err := devicetokens.New(dir).Get(tc.broker, tc.user)
+			if tc.wantErr {
+				require.Error(t, err, "Get should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "Get should not return an error, but did")
+			require.Equal(t, tc.wantToken, string(token.Bytes()), "Get should return the expected token")
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	s := devicetokens.New(dir)
+
+	require.NoError(t, s.Set("broker", "user1", "token1", time.Now().Add(time.Hour)), "Set should not return an error")
+	require.NoError(t, s.Set("broker", "user2", "token2", time.Now().Add(time.Hour)), "Set should not return an error")
+	require.NoError(t, s.Set("broker", "user1", "new-token1", time.Now().Add(time.Hour)), "Set should not return an error")
+
+	for _, f := range []string{"device-tokens", "device-tokens.key"} {
+		fi, err := os.Stat(filepath.Join(dir, f))
+		require.NoError(t, err, "Set should create %s", f)
+		require.Equal(t, os.FileMode(0600), fi.Mode().Perm(), "%s should only be readable by its owner", f)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "device-tokens"))
+	require.NoError(t, err, "Could not read the tokens")
+	require.NotContains(t, string(content), "token1", "The tokens should be encrypted")
+
+	token, err := s.Get("broker", "user1")
+	require.NoError(t, err, "Get should not return an error")
+	require.Equal(t, "new-token1", string(token.Bytes()), "Set should replace the previous token of the user")
+	token, err = s.Get("broker", "user2")
+	require.NoError(t, err, "Get should not return an error")
+	require.Equal(t, "token2", string(token.Bytes()), "Set should keep the tokens of the other users")
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	s := devicetokens.New(dir)
+
+	require.NoError(t, s.Delete("user1"), "Delete should not return an error if there is no token")
+
+	require.NoError(t, s.Set("broker1", "user1", "token1", time.Now().Add(time.Hour)), "Setup: could not store token")
+	require.NoError(t, s.Set("broker2", "user1", "token2", time.Now().Add(time.Hour)), "Setup: could not store token")
+	require.NoError(t, s.Set("broker1", "user2", "token3", time.Now().Add(time.Hour)), "Setup: could not store token")
+
+	require.NoError(t, s.Delete("user1"), "Delete should not return an error")
+	for _, b := range []string{"broker1", "broker2"} {
+		token, err := s.Get(b, "user1")
+		require.NoError(t, err, "Get should not return an error")
+		require.Empty(t, token.Bytes(), "Delete should remove the tokens of the user issued by %s", b)
+	}
+	token, err := s.Get("broker1", "user2")
+	require.NoError(t, err, "Get should not return an error")
+	require.Equal(t, "token3", string(token.Bytes()), "Delete should keep the tokens of the other users")
+
+	require.NoError(t, s.Delete("user2"), "Delete should not return an error")
+	require.NoFileExists(t, filepath.Join(dir, "device-tokens"), "Delete should remove the file once there is no token")
+}
+
+func TestKeyring(t *testing.T) {
+	t.Parallel()
+
+	if _, err := unix.KeyctlGetKeyringID(unix.KEY_SPEC_PROCESS_KEYRING, true); err != nil {
+		t.Skipf("Kernel keyrings can't be used: %v", err)
+	}
+
+	dir := t.TempDir()
+	s := devicetokens.New(dir, devicetokens.WithKeyring(devicetokens.Z_ForTests_NewKeyring(t)))
+
+	require.NoError(t, s.Set("broker1", "user1", "token1", time.Now().Add(time.Hour)), "Set should not return an error")
+	require.NoError(t, s.Set("broker2", "user1", "token2", time.Now().Add(time.Hour)), "Set should not return an error")
+	require.NoError(t, s.Set("broker1", "user2", "token3", time.Now().Add(time.Hour)), "Set should not return an error")
+	require.NoFileExists(t, filepath.Join(dir, "device-tokens"), "Set should not store the tokens in the file")
+
+	token, err := s.Get("broker1", "user1")
+	require.NoError(t, err, "Get should not return an error")
+	require.Equal(t, "token1", string(token.Bytes()), "Get should return the token of the keyring")
+
+	require.NoError(t, s.Set("broker1", "user1", "new-token1", time.Now().Add(time.Hour)), "Set should not return an error")
+	token, err = s.Get("broker1", "user1")
+	require.NoError(t, err, "Get should not return an error")
+	require.Equal(t, "new-token1", string(token.Bytes()), "Set should replace the previous token of the user")
+
+	require.NoError(t, s.Set("broker1", "user2", "expired-token3", time.Now().Add(-time.Minute)), "Set should not return an error")
+	token, err = s.Get("broker1", "user2")
+	require.NoError(t, err, "Get should not return an error")
+	require.Empty(t, token.Bytes(), "Set should remove the token of the user if the new one already expired")
+
+	require.NoError(t, s.Delete("user1"), "Delete should not return an error")
+	for _, b := range []string{"broker1", "broker2"} {
+		token, err := s.Get(b, "user1")
+		require.NoError(t, err, "Get should not return an error")
+		require.Empty(t, token.Bytes(), "Delete should remove the tokens of the user issued by %s", b)
+	}
+}
+
+func TestKeyringFallback(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	// The keyring can't be found for any user, like when the kernel keyrings are not available.
+	keyring := devicetokens.NewKeyring(func(string) (uint32, error) { return 0, errors.New("no keyring") })
+	s := devicetokens.New(dir, devicetokens.WithKeyring(keyring))
+
+	require.NoError(t, s.Set("broker", "user1", "token1", time.Now().Add(time.Hour)), "Set should not return an error")
+	require.FileExists(t, filepath.Join(dir, "device-tokens"), "Set should store the token in the file")
+
+	token, err := s.Get("broker", "user1")
+	require.NoError(t, err, "Get should not return an error")
+	require.Equal(t, "token1", string(token.Bytes()), "Get should return the token of the file")
+
+	require.NoError(t, s.Delete("user1"), "Delete should not return an error")
+	require.NoFileExists(t, filepath.Join(dir, "device-tokens"), "Delete should remove the token from the file")
+}
@@ -0,0 +1,198 @@
+package devicetokens
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ubuntu/authd/internal/securemem"
+	"github.com/ubuntu/decorate"
+	"golang.org/x/sys/unix"
+)
+
+// KeyPrefix is the prefix of the description of the keys holding the device tokens in the persistent keyrings of the
+// users, followed by the ID of the broker which issued them.
+const KeyPrefix = "authd:device-token:"
+
+// keyType is the type of the keys holding the device tokens.
+const keyType = "user"
+
+// The permissions of the keys: the daemon, which possesses them, can do anything with them, and their user can read
+// them.
+const (
+	keyPossessorAll = 0x3f000000
+	keyUserView     = 0x00010000
+	keyUserRead     = 0x00020000
+	keyUserSearch   = 0x00080000
+)
+
+// Keyring stores the device tokens in the persistent kernel keyrings of the users, so that they are never written to
+// the disk, and can be retrieved by the tools of the sessions of the users with Lookup.
+//
+// The keys are accessed through the keyrings linked to the process keyring of the calling thread, which is not shared
+// with the threads started before it was created. The operations are locked to their thread for the keyrings to stay
+// reachable.
+type Keyring struct {
+	// keyringOf returns the ID of the keyring the tokens of the user are stored in, and the UID of the user.
+	keyringOf func(user string) (ring int, uid uint32, err error)
+}
+
+// NewKeyring returns the keyring storing the tokens in the persistent keyrings of the users, whose UIDs are returned
+// by uidOf.
+func NewKeyring(uidOf func(user string) (uint32, error)) *Keyring {
+	return &Keyring{keyringOf: func(user string) (int, uint32, error) {
+		uid, err := uidOf(user)
+		if err != nil {
+			return 0, 0, err
+		}
+		ring, err := persistentKeyring(uid)
+		return ring, uid, err
+	}}
+}
+
+// Lookup returns the device token the broker issued to the calling user, from the persistent keyring of the user, or
+// nil if there is none or if it expired. It's meant for the tools of the sessions of the user, as the tokens stored in
+// the file when the keyring can't be used are only readable by root. The token must be wiped after use.
+func Lookup(brokerID string) (*securemem.Buffer, error) {
+	uid := uint32(os.Getuid())
+	return NewKeyring(func(string) (uint32, error) { return uid, nil }).get("", brokerID)
+}
+
+// persistentKeyring returns the ID of the persistent keyring of the user, which outlives the sessions of the user.
+func persistentKeyring(uid uint32) (int, error) {
+	ring, err := unix.KeyctlInt(unix.KEYCTL_GET_PERSISTENT, int(uid), unix.KEY_SPEC_PROCESS_KEYRING, 0, 0)
+	if errors.Is(err, unix.EPERM) && uid != uint32(os.Geteuid()) {
+		return 0, fmt.Errorf("could not get persistent keyring of UID %d, which requires CAP_SETUID: %w", uid, err)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("could not get persistent keyring of UID %d: %w", uid, err)
+	}
+	return ring, nil
+}
+
+// get returns the token the broker issued to the user, or nil if there is none or if it expired. It must be wiped after
+// use.
+func (k *Keyring) get(user, brokerID string) (token *securemem.Buffer, err error) {
+	defer decorate.OnError(&err, "could not get device token from keyring")
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ring, _, err := k.keyringOf(user)
+	if err != nil {
+		return nil, err
+	}
+	id, err := unix.KeyctlSearch(ring, keyType, KeyPrefix+brokerID, 0)
+	if errors.Is(err, unix.ENOKEY) || errors.Is(err, unix.EKEYEXPIRED) || errors.Is(err, unix.EKEYREVOKED) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	defer clear(buf)
+	n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, buf, 0)
+	if err != nil {
+		return nil, err
+	}
+	return securemem.New(buf[:min(n, size)]), nil
+}
+
+// set stores the token the broker issued to the user until expiry, replacing the previous one. The key is owned by the
+// user, for the tools of their sessions to read it.
+func (k *Keyring) set(user, brokerID, token string, expiry time.Time) (err error) {
+	defer decorate.OnError(&err, "could not store device token in keyring")
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ring, uid, err := k.keyringOf(user)
+	if err != nil {
+		return err
+	}
+	// The timeout of the keys is in seconds, a token expiring sooner is already expired.
+	timeout := int(time.Until(expiry) / time.Second)
+	if timeout <= 0 {
+		return invalidate(ring, func(description string) bool { return description == KeyPrefix+brokerID })
+	}
+
+	id, err := unix.AddKey(keyType, KeyPrefix+brokerID, []byte(token), ring)
+	if err != nil {
+		return err
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_SET_TIMEOUT, id, timeout, 0, 0); err != nil {
+		_, _ = unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0)
+		return err
+	}
+	if err := unix.KeyctlSetperm(id, keyPossessorAll|keyUserView|keyUserRead|keyUserSearch); err != nil {
+		_, _ = unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0)
+		return err
+	}
+	if uid == uint32(os.Geteuid()) {
+		return nil
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_CHOWN, id, int(uid), -1, 0); err != nil {
+		_, _ = unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0)
+		if errors.Is(err, unix.EPERM) {
+			return fmt.Errorf("could not give the key to UID %d, which requires CAP_SYS_ADMIN: %w", uid, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// delete removes the tokens issued to the user by all the brokers.
+func (k *Keyring) delete(user string) (err error) {
+	defer decorate.OnError(&err, "could not delete device tokens from keyring")
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ring, _, err := k.keyringOf(user)
+	if err != nil {
+		return err
+	}
+	return invalidate(ring, func(description string) bool { return strings.HasPrefix(description, KeyPrefix) })
+}
+
+// invalidate invalidates the device tokens of the keyring whose description matches.
+func invalidate(ring int, match func(description string) bool) error {
+	size, err := unix.KeyctlBuffer(unix.KEYCTL_READ, ring, nil, 0)
+	if err != nil {
+		return err
+	}
+	// The payload of a keyring is the list of the IDs of its keys, as 32-bit integers in the native byte order.
+	buf := make([]byte, size)
+	n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, ring, buf, 0)
+	if err != nil {
+		return err
+	}
+	buf = buf[:min(n, size)]
+
+	for i := 0; i+4 <= len(buf); i += 4 {
+		id := int(int32(binary.NativeEndian.Uint32(buf[i:])))
+		// The description is "type;uid;gid;perm;description".
+		d, err := unix.KeyctlString(unix.KEYCTL_DESCRIBE, id)
+		if err != nil {
+			// The key expired or was removed since the keyring was read.
+			continue
+		}
+		parts := strings.SplitN(d, ";", 5)
+		if len(parts) != 5 || parts[0] != keyType || !match(parts[4]) {
+			continue
+		}
+		if _, err := unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
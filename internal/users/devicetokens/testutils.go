@@ -0,0 +1,48 @@
+package devicetokens
+
+// All those functions and methods are only for tests.
+// They are not exported, and guarded by testing assertions.
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/ubuntu/authd/internal/testsdetection"
+	"golang.org/x/sys/unix"
+)
+
+// keyUserAll is the permission of the user owning a key to do anything with it.
+const keyUserAll = 0x003f0000
+
+// Z_ForTests_NewKeyring returns a keyring storing the tokens of each user in a keyring of the test, instead of in the
+// persistent keyrings of the users, which are shared by all the processes. The tokens are stored as the current user.
+// The keyring returns an error if the kernel keyrings can't be used, like in some containers.
+//
+// nolint:revive,nolintlint // We want to use underscores in the function name here.
+func Z_ForTests_NewKeyring(t *testing.T) *Keyring {
+	t.Helper()
+	testsdetection.MustBeTesting()
+
+	ring, err := unix.AddKey("keyring", "authd-tests:"+t.Name(), nil, unix.KEY_SPEC_PROCESS_KEYRING)
+	if err == nil {
+		t.Cleanup(func() { _, _ = unix.KeyctlInt(unix.KEYCTL_INVALIDATE, ring, 0, 0, 0) })
+		// The keyring is linked to the process keyring of the threads using it, which requires the current user to be
+		// able to, as the other threads don't possess it yet.
+		err = unix.KeyctlSetperm(ring, keyPossessorAll|keyUserAll)
+	}
+
+	return &Keyring{keyringOf: func(user string) (int, uint32, error) {
+		if err != nil {
+			return 0, 0, err
+		}
+		if _, err := unix.KeyctlInt(unix.KEYCTL_LINK, ring, unix.KEY_SPEC_PROCESS_KEYRING, 0, 0); err != nil {
+			return 0, 0, err
+		}
+		userRing, err := unix.KeyctlSearch(ring, "keyring", user, 0)
+		if errors.Is(err, unix.ENOKEY) {
+			userRing, err = unix.AddKey("keyring", user, nil, ring)
+		}
+		return userRing, uint32(os.Geteuid()), err
+	}}
+}
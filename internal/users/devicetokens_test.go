@@ -0,0 +1,74 @@
+package users_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/devicetokens"
+	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestDeviceTokens(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		lifetime        time.Duration
+		disableOnReload bool
+		purge           bool
+
+		wantToken   string
+		wantAudited bool
+	}{
+		"Store_device_token_for_the_lifetime":      {lifetime: time.Hour, wantToken: "some-token", wantAudited: true},
+		"Ignore_device_token_if_they_are_disabled": {},
+		"Do_not_return_device_token_once_disabled": {lifetime: time.Hour, disableOnReload: true, wantAudited: true},
+		"Do_not_return_expired_device_token":       {lifetime: time.Nanosecond, wantAudited: true},
+		"Delete_device_tokens_when_user_is_purged": {lifetime: time.Hour, purge: true, wantAudited: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if !tc.purge {
+				t.Parallel()
+			} else {
+				localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+			}
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "users_with_private_groups.db.yaml"), cacheDir)
+
+			config := users.DefaultConfig
+			config.DeviceTokenLifetime = tc.lifetime
+			m, err := users.NewManager(config, cacheDir, users.WithDeviceTokenKeyring(devicetokens.Z_ForTests_NewKeyring(t)))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			err = m.SetDeviceToken("some-broker", "user1", "some-token")
+			require.NoError(t, err, "SetDeviceToken should not return an error, but did")
+
+			if tc.disableOnReload {
+				config.DeviceTokenLifetime = 0
+				require.NoError(t, m.Reload(config), "Setup: could not reload user manager")
+			}
+			if tc.purge {
+				require.NoError(t, m.PurgeUser("user1", "test"), "Setup: could not purge user")
+			}
+
+			require.Equal(t, tc.wantToken, string(m.DeviceToken("some-broker", "user1").Bytes()), "DeviceToken should return the expected token")
+			require.Empty(t, m.DeviceToken("other-broker", "user1").Bytes(), "DeviceToken should not return the token of another broker")
+
+			events, err := m.AuditEvents(types.AuditFilter{Action: users.AuditDeviceTrusted})
+			require.NoError(t, err, "AuditEvents should not return an error, but did")
+			if !tc.wantAudited {
+				require.Empty(t, events, "No trusted device should be audited")
+				return
+			}
+			require.Len(t, events, 1, "The trusted device should be audited once")
+			require.Equal(t, "user1", events[0].Target, "The trusted device should be audited for the user")
+		})
+	}
+}
@@ -7,3 +7,11 @@ import (
 func (m *Manager) TemporaryRecords() *tempentries.TemporaryRecords {
 	return m.temporaryRecords
 }
+
+var ArchivePath = archivePath
+
+var DelayUntil = delayUntil
+
+var RefreshedHomeDir = refreshedHomeDir
+
+var FlattenGroups = flattenGroups
@@ -0,0 +1,17 @@
+package users
+
+import (
+	"github.com/ubuntu/decorate"
+)
+
+// ExtendedAttributes returns the key/value attributes attached to the user by its broker on its last login.
+func (m *Manager) ExtendedAttributes(name string) (attributes map[string]string, err error) {
+	defer decorate.OnError(&err, "failed to get extended attributes of user %q", name)
+
+	u, err := m.cache.UserByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.cache.UserExtendedAttributes(u.UID)
+}
@@ -0,0 +1,59 @@
+package users_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/idgenerator"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestExtendedAttributes(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username   string
+		attributes map[string]string
+
+		wantErrType error
+	}{
+		"Get_extended_attributes_of_user":          {attributes: map[string]string{"department": "engineering", "employee_id": "1234"}},
+		"Get_no_extended_attributes_if_none_given": {},
+
+		"Error_if_user_does_not_exist": {username: "doesnotexist", wantErrType: cache.NoDataFoundError{}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.username == "" {
+				tc.username = "user1"
+			}
+
+			m, err := users.NewManager(users.DefaultConfig, t.TempDir(), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+				UIDsToGenerate: []uint32{1111},
+				GIDsToGenerate: []uint32{11110},
+			}))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			u := types.UserInfo{Name: "user1", Dir: "/home/user1", Shell: "/bin/bash", ExtendedAttributes: tc.attributes}
+			require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "Setup: UpdateUser should not return an error")
+
+			got, err := m.ExtendedAttributes(tc.username)
+			requireErrorAssertions(t, err, tc.wantErrType, false)
+			if tc.wantErrType != nil {
+				return
+			}
+
+			if tc.attributes == nil {
+				require.Empty(t, got, "ExtendedAttributes should return no attributes")
+				return
+			}
+			require.Equal(t, tc.attributes, got, "ExtendedAttributes did not return the expected attributes")
+		})
+	}
+}
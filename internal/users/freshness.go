@@ -0,0 +1,46 @@
+package users
+
+import (
+	"time"
+
+	"github.com/ubuntu/decorate"
+)
+
+// UserIsStale returns true if the information of the user was not provided or confirmed by its broker for longer than
+// the configured TTL. Users are never stale if no TTL is configured.
+func (m *Manager) UserIsStale(name string) (stale bool, err error) {
+	defer decorate.OnError(&err, "failed to check freshness of user %q", name)
+
+	t, err := m.cache.RefreshedAt(name)
+	if err != nil {
+		return false, err
+	}
+
+	return m.isStale(t), nil
+}
+
+// ConfirmUser records that the broker of the user confirmed its information, which is then fresh again.
+func (m *Manager) ConfirmUser(name string) (err error) {
+	defer decorate.OnError(&err, "failed to confirm user %q", name)
+
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	u, err := m.cache.UserByName(name)
+	if err != nil {
+		return err
+	}
+
+	return m.cache.SetUserRefreshed(u.UID, time.Now())
+}
+
+// isStale returns true if information refreshed at t is older than the configured TTL. Information which was never
+// refreshed is stale.
+func (m *Manager) isStale(t time.Time) bool {
+	config := m.config()
+	if config.UserInfoTTL <= 0 {
+		return false
+	}
+	return t.IsZero() || time.Since(t) > config.UserInfoTTL
+}
@@ -0,0 +1,77 @@
+package users_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestUserIsStale(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username    string
+		userInfoTTL time.Duration
+		update      bool
+		confirm     bool
+
+		wantStale   bool
+		wantErrType error
+	}{
+		"User_is_not_stale_without_TTL":               {},
+		"User_never_refreshed_is_stale":               {userInfoTTL: time.Hour, wantStale: true},
+		"User_is_not_stale_after_an_update":           {userInfoTTL: time.Hour, update: true},
+		"User_is_not_stale_after_being_confirmed":     {userInfoTTL: time.Hour, confirm: true},
+		"User_is_stale_once_the_TTL_expired":          {userInfoTTL: time.Nanosecond, update: true, wantStale: true},
+		"Error_if_user_does_not_exist":                {username: "doesnotexist", userInfoTTL: time.Hour, wantErrType: cache.NoDataFoundError{}},
+		"Error_confirming_a_user_that_does_not_exist": {username: "doesnotexist", confirm: true, wantErrType: cache.NoDataFoundError{}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.username == "" {
+				tc.username = "user1"
+			}
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "one_user_and_group.db.yaml"), cacheDir)
+
+			config := users.DefaultConfig
+			config.UserInfoTTL = tc.userInfoTTL
+			m, err := users.NewManager(config, cacheDir)
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			if tc.update {
+				err := m.UpdateUser(context.Background(), types.UserInfo{
+					Name:   "user1",
+					Dir:    "/home/user1",
+					Shell:  "/bin/bash",
+					Groups: []types.GroupInfo{{Name: "group1", UGID: "12345678"}},
+				}, "broker-id")
+				require.NoError(t, err, "Setup: could not update user")
+			}
+			if tc.confirm {
+				err := m.ConfirmUser(tc.username)
+				requireErrorAssertions(t, err, tc.wantErrType, false)
+				if tc.wantErrType != nil {
+					return
+				}
+			}
+
+			got, err := m.UserIsStale(tc.username)
+			requireErrorAssertions(t, err, tc.wantErrType, false)
+			if tc.wantErrType != nil {
+				return
+			}
+			require.Equal(t, tc.wantStale, got, "UserIsStale did not return the expected value")
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+const (
+	// HomeDirCleanupKeep leaves the home directory and the mail spool of removed users untouched.
+	HomeDirCleanupKeep = "keep"
+	// HomeDirCleanupArchive archives the home directory and the mail spool of removed users before deleting them.
+	HomeDirCleanupArchive = "archive"
+	// HomeDirCleanupDelete deletes the home directory and the mail spool of removed users.
+	HomeDirCleanupDelete = "delete"
+)
+
+// validateHomeDirCleanup checks the home directory cleanup configuration.
+func validateHomeDirCleanup(config Config) error {
+	switch config.HomeDirCleanup {
+	case "", HomeDirCleanupKeep, HomeDirCleanupDelete:
+	case HomeDirCleanupArchive:
+		if !filepath.IsAbs(config.HomeDirArchiveDir) {
+			return fmt.Errorf("home_dir_archive_dir %q must be an absolute path to archive home directories", config.HomeDirArchiveDir)
+		}
+	default:
+		return fmt.Errorf("invalid home directory cleanup %q, must be %q, %q or %q",
+			config.HomeDirCleanup, HomeDirCleanupKeep, HomeDirCleanupArchive, HomeDirCleanupDelete)
+	}
+	return nil
+}
+
+// homeDirCleanup returns the configured home directory cleanup policy.
+func (s *settings) homeDirCleanup() string {
+	if s.HomeDirCleanup == "" {
+		return HomeDirCleanupKeep
+	}
+	return s.HomeDirCleanup
+}
+
+// cleanUpUserFiles applies the home directory cleanup policy to the home directory and the mail spool of the user,
+// which is being removed. In dry-run mode, what would be done is only logged.
+func (m *Manager) cleanUpUserFiles(u cache.UserDB, actor string) (err error) {
+	defer decorate.OnError(&err, "could not clean up files of user %q", u.Name)
+
+	config := m.config()
+	policy := config.homeDirCleanup()
+	if policy == HomeDirCleanupKeep {
+		return nil
+	}
+
+	paths := []string{u.Dir}
+	if config.MailSpoolDir != "" {
+		paths = append(paths, filepath.Join(config.MailSpoolDir, u.Name))
+	}
+
+	for i, path := range paths {
+		if ok, err := isOwnedByUser(path, u.UID); err != nil || !ok {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		archiveName := u.Name
+		if i > 0 {
+			archiveName = u.Name + "-mail"
+		}
+
+		if config.HomeDirCleanupDryRun {
+			if policy == HomeDirCleanupArchive {
+				log.Infof(context.Background(), "Dry run: would archive %q to %q", path, config.HomeDirArchiveDir)
+			}
+			log.Infof(context.Background(), "Dry run: would delete %q of user %q", path, u.Name)
+			continue
+		}
+
+		action, details := AuditFilesDeleted, path
+		if policy == HomeDirCleanupArchive {
+			if err := archivePath(path, config.HomeDirArchiveDir, archiveName); err != nil {
+				// Don't delete what could not be archived.
+				return err
+			}
+			action, details = AuditFilesArchived, fmt.Sprintf("%s archived to %s", path, config.HomeDirArchiveDir)
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		log.Infof(context.Background(), "Deleted %q of user %q", path, u.Name)
+		m.audit(actor, types.AuditEvent{Action: action, Target: u.Name, Details: details})
+	}
+
+	return nil
+}
+
+// isOwnedByUser returns true if the file at path exists and is owned by the given UID. Files which are not owned by
+// the user, like a shared or a misconfigured home directory, are never removed.
+func isOwnedByUser(path string, uid uint32) (bool, error) {
+	if !filepath.IsAbs(path) || filepath.Clean(path) == "/" {
+		log.Warningf(context.Background(), "Not cleaning up %q which is not a valid path", path)
+		return false, nil
+	}
+
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	sys, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false, fmt.Errorf("failed to get file info of %q", path)
+	}
+	if sys.Uid != uid {
+		log.Warningf(context.Background(), "Not cleaning up %q which is owned by UID %d instead of %d", path, sys.Uid, uid)
+		return false, nil
+	}
+
+	return true, nil
+}
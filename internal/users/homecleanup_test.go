@@ -0,0 +1,123 @@
+package users_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/devicetokens"
+	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestPurgeUserCleansUpFiles(t *testing.T) {
+	tests := map[string]struct {
+		cleanup           string
+		dryRun            bool
+		homeOfOtherUser   bool
+		noMailSpool       bool
+		invalidArchiveDir bool
+
+		wantDeleted  bool
+		wantArchives []string
+		wantAudit    string
+		wantErr      bool
+	}{
+		"Keep_files_by_default":            {},
+		"Delete_home_and_mail_spool":       {cleanup: users.HomeDirCleanupDelete, wantDeleted: true, wantAudit: users.AuditFilesDeleted},
+		"Archive_home_and_mail_spool":      {cleanup: users.HomeDirCleanupArchive, wantDeleted: true, wantArchives: []string{"user1-2*.tar.gz", "user1-mail-*.tar.gz"}, wantAudit: users.AuditFilesArchived},
+		"Delete_home_without_mail_spool":   {cleanup: users.HomeDirCleanupDelete, noMailSpool: true, wantDeleted: true, wantAudit: users.AuditFilesDeleted},
+		"Keep_files_in_dry_run_mode":       {cleanup: users.HomeDirCleanupArchive, dryRun: true},
+		"Keep_files_not_owned_by_the_user": {cleanup: users.HomeDirCleanupDelete, homeOfOtherUser: true},
+
+		"Error_and_keep_user_if_files_cannot_be_archived": {cleanup: users.HomeDirCleanupArchive, invalidArchiveDir: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "empty.group"))
+
+			tmpDir := t.TempDir()
+			home := filepath.Join(tmpDir, "home", "user1")
+			mailSpoolDir := filepath.Join(tmpDir, "mail")
+			archiveDir := filepath.Join(tmpDir, "archives")
+			require.NoError(t, os.MkdirAll(home, 0700), "Setup: could not create home directory")
+			require.NoError(t, os.WriteFile(filepath.Join(home, "file"), []byte("content"), 0600), "Setup: could not create file")
+			require.NoError(t, os.MkdirAll(mailSpoolDir, 0700), "Setup: could not create mail spool directory")
+			mailSpool := filepath.Join(mailSpoolDir, "user1")
+			if !tc.noMailSpool {
+				require.NoError(t, os.WriteFile(mailSpool, []byte("mail"), 0600), "Setup: could not create mail spool")
+			}
+			if tc.invalidArchiveDir {
+				require.NoError(t, os.WriteFile(archiveDir, nil, 0600), "Setup: could not create file instead of archive directory")
+			}
+
+			config := users.DefaultConfig
+			if tc.cleanup != "" {
+				config.HomeDirCleanup = tc.cleanup
+			}
+			config.HomeDirCleanupDryRun = tc.dryRun
+			config.HomeDirArchiveDir = archiveDir
+			config.MailSpoolDir = mailSpoolDir
+			m, err := users.NewManager(config, t.TempDir(), users.WithDeviceTokenKeyring(devicetokens.Z_ForTests_NewKeyring(t)))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			// The files are owned by the current user, so that the test can run unprivileged.
+			uid, gid := uint32(os.Getuid()), uint32(os.Getgid())
+			if tc.homeOfOtherUser {
+				uid++
+			}
+			u := cache.UserDB{Name: "user1", UID: uid, GID: gid, Dir: home, Shell: "/bin/bash"}
+			err = userstestutils.GetManagerCache(m).UpdateUserEntry(u, nil, nil)
+			require.NoError(t, err, "Setup: could not add user")
+
+			err = m.PurgeUser("user1", "test")
+			if tc.wantErr {
+				require.Error(t, err, "PurgeUser should return an error, but did not")
+				_, err = m.UserByName("user1")
+				require.NoError(t, err, "User should not be purged if its files could not be cleaned up")
+				require.DirExists(t, home, "Home directory should not be deleted if it could not be archived")
+				return
+			}
+			require.NoError(t, err, "PurgeUser should not return an error, but did")
+
+			if tc.wantDeleted {
+				require.NoDirExists(t, home, "Home directory should be deleted")
+				require.NoFileExists(t, mailSpool, "Mail spool should be deleted")
+			} else {
+				require.DirExists(t, home, "Home directory should be kept")
+				if !tc.noMailSpool {
+					require.FileExists(t, mailSpool, "Mail spool should be kept")
+				}
+			}
+
+			var gotArchives int
+			for _, pattern := range tc.wantArchives {
+				archives, err := filepath.Glob(filepath.Join(archiveDir, pattern))
+				require.NoError(t, err, "Glob should not return an error")
+				require.Len(t, archives, 1, "Exactly one archive should match %q", pattern)
+				gotArchives++
+			}
+			entries, _ := os.ReadDir(archiveDir)
+			require.Len(t, entries, gotArchives, "Unexpected archives were created")
+
+			events, err := m.AuditEvents(types.AuditFilter{Target: "user1", Action: tc.wantAudit})
+			require.NoError(t, err, "AuditEvents should not return an error")
+			if tc.wantAudit == "" {
+				for _, e := range events {
+					require.NotContains(t, []string{users.AuditFilesArchived, users.AuditFilesDeleted}, e.Action, "No files should be recorded as cleaned up")
+				}
+				return
+			}
+			wantEvents := 2
+			if tc.noMailSpool {
+				wantEvents = 1
+			}
+			require.Len(t, events, wantEvents, "Cleaned up files should be recorded in the audit log")
+		})
+	}
+}
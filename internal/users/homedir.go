@@ -0,0 +1,152 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ubuntu/authd/internal/users/homedir"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// homeDirTemplateData is the data available in the templates of the subdirectories of the home directories.
+type homeDirTemplateData struct {
+	// Name is the name of the user.
+	Name string
+	// Domain is the part of the name of the user after the "@", like the tenant of the identity provider. It's empty
+	// if the name has no domain.
+	Domain string
+}
+
+// parseHomeDirConfig validates the home directories configuration and returns the options of their creation, without
+// the subdirectories, and the parsed templates of the subdirectories.
+func parseHomeDirConfig(config Config) (opts homedir.Options, subdirs []*template.Template, err error) {
+	defer decorate.OnError(&err, "invalid home directories configuration")
+
+	if config.SkelDir != "" && !filepath.IsAbs(config.SkelDir) {
+		return homedir.Options{}, nil, fmt.Errorf("skel_dir %q must be an absolute path", config.SkelDir)
+	}
+
+	mode := homedir.DefaultMode
+	if config.HomeDirMode != "" {
+		if mode, err = parseFileMode(config.HomeDirMode); err != nil {
+			return homedir.Options{}, nil, fmt.Errorf("home_dir_mode: %v", err)
+		}
+	}
+	// The owner must be able to use their home directory.
+	if mode&0700 != 0700 {
+		return homedir.Options{}, nil, fmt.Errorf("home_dir_mode %q must give full access to the owner", config.HomeDirMode)
+	}
+
+	var umask fs.FileMode
+	if config.HomeDirUmask != "" {
+		if umask, err = parseFileMode(config.HomeDirUmask); err != nil {
+			return homedir.Options{}, nil, fmt.Errorf("home_dir_umask: %v", err)
+		}
+	}
+
+	if err := homedir.ValidateACL(config.HomeDirACL); err != nil {
+		return homedir.Options{}, nil, fmt.Errorf("home_dir_acl: %v", err)
+	}
+
+	for _, s := range config.HomeDirSubdirs {
+		tmpl, err := template.New(s).Option("missingkey=error").Parse(s)
+		if err != nil {
+			return homedir.Options{}, nil, fmt.Errorf("home_dir_subdirs: %v", err)
+		}
+		// Check that the template only uses known fields and stays in the home directory.
+		subdir, err := renderSubdir(tmpl, "user@example.com")
+		if err != nil {
+			return homedir.Options{}, nil, fmt.Errorf("home_dir_subdirs: %v", err)
+		}
+		if err := homedir.ValidateSubdir(subdir); err != nil {
+			return homedir.Options{}, nil, fmt.Errorf("home_dir_subdirs: %v", err)
+		}
+		subdirs = append(subdirs, tmpl)
+	}
+
+	return homedir.Options{
+		SkelDir:        config.SkelDir,
+		Mode:           mode,
+		Umask:          umask,
+		ACL:            config.HomeDirACL,
+		SELinuxRelabel: config.HomeDirSELinuxRelabel,
+	}, subdirs, nil
+}
+
+// parseFileMode parses a file mode, or a umask, written in octal.
+func parseFileMode(s string) (fs.FileMode, error) {
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || m > 0777 {
+		return 0, fmt.Errorf("%q is not an octal file mode", s)
+	}
+	return fs.FileMode(m), nil
+}
+
+// renderSubdir returns the subdirectory of the home directory of the user described by the template.
+func renderSubdir(tmpl *template.Template, name string) (string, error) {
+	data := homeDirTemplateData{Name: name}
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		data.Domain = name[i+1:]
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// CreateHomeDir creates the home directory of the user if it doesn't exist yet, owned by the user and its private
+// group and with the files of the skeleton directory.
+//
+// brokerPolicy is the policy of the broker of the user. If set, it overrides the create_home_dirs configuration.
+func (m *Manager) CreateHomeDir(name string, brokerPolicy *bool) (err error) {
+	defer decorate.OnError(&err, "failed to create home directory of user %q", name)
+
+	config := m.config()
+	enabled := config.CreateHomeDirs
+	if brokerPolicy != nil {
+		enabled = *brokerPolicy
+	}
+	if !enabled {
+		return nil
+	}
+
+	u, err := m.cache.UserByName(name)
+	if err != nil {
+		return err
+	}
+	if u.Dir == "" {
+		return nil
+	}
+
+	opts := config.homeDirOpts
+	for _, tmpl := range config.homeDirSubdirs {
+		subdir, err := renderSubdir(tmpl, name)
+		if err != nil {
+			return err
+		}
+		// The subdirectories using the domain are skipped for users without a domain, as they have an empty element.
+		if slices.Contains(strings.Split(subdir, "/"), "") {
+			continue
+		}
+		opts.Subdirs = append(opts.Subdirs, subdir)
+	}
+
+	created, err := homedir.Create(u.Dir, u.UID, u.GID, opts)
+	if err != nil {
+		return err
+	}
+	if created {
+		log.Infof(context.Background(), "Created home directory %q of user %q", u.Dir, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,7 @@
+package homedir
+
+// SetCommands sets the commands used to set the ACL and the SELinux context of home directories.
+func SetCommands(setfacl, restorecon string) {
+	setfaclCmd = setfacl
+	restoreconCmd = restorecon
+}
@@ -0,0 +1,293 @@
+// Package homedir creates the home directories of the users, like pam_mkhomedir does.
+package homedir
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ubuntu/decorate"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// DefaultSkelDir is the directory whose content is copied to new home directories.
+	DefaultSkelDir = "/etc/skel"
+	// DefaultMode is the mode of new home directories.
+	DefaultMode fs.FileMode = 0750
+)
+
+var (
+	// setfaclCmd and restoreconCmd are the commands used to set the ACL and the SELinux context of home directories.
+	setfaclCmd    = "setfacl"
+	restoreconCmd = "restorecon"
+
+	aclEntryRegexp = regexp.MustCompile(`^((d|default):)?(u|user|g|group|m|mask|o|other):[^:,\s]*:[rwxX-]{1,4}$`)
+)
+
+// Options are the options of the creation of a home directory.
+type Options struct {
+	// SkelDir is the directory whose content is copied to the home directory. If empty or if it doesn't exist, the home
+	// directory is created empty.
+	SkelDir string
+	// Mode is the mode of the home directory and of its subdirectories.
+	Mode fs.FileMode
+	// Umask is applied to the permissions of the files copied from the skeleton directory.
+	Umask fs.FileMode
+	// Subdirs are subdirectories, relative to the home directory, created in it.
+	Subdirs []string
+	// ACL are ACL entries, in the setfacl(1) syntax, set on the home directory.
+	ACL []string
+	// SELinuxRelabel restores the default SELinux context of the home directory and its content with restorecon(8).
+	SELinuxRelabel bool
+}
+
+// ValidateACL checks that the ACL entries are in the syntax of setfacl(1).
+func ValidateACL(entries []string) error {
+	for _, e := range entries {
+		if !aclEntryRegexp.MatchString(e) {
+			return fmt.Errorf("invalid ACL entry %q", e)
+		}
+	}
+	return nil
+}
+
+// ValidateSubdir checks that the subdirectory is a relative path that stays inside the home directory.
+func ValidateSubdir(subdir string) error {
+	if subdir == "" || filepath.IsAbs(subdir) || !filepath.IsLocal(subdir) {
+		return fmt.Errorf("subdirectory %q must be a relative path inside the home directory", subdir)
+	}
+	return nil
+}
+
+// Create creates the home directory at path, owned by the given UID and GID, copies the content of the skeleton
+// directory into it and applies the given options.
+//
+// It returns false if the home directory already exists, in which case it's left untouched.
+func Create(path string, uid, gid uint32, opts Options) (created bool, err error) {
+	defer decorate.OnError(&err, "could not create home directory %q", path)
+
+	if !filepath.IsAbs(path) {
+		return false, errors.New("home directory must be an absolute path")
+	}
+	for _, subdir := range opts.Subdirs {
+		if err := ValidateSubdir(subdir); err != nil {
+			return false, err
+		}
+	}
+
+	// The parent directories are owned by root, like the ones created by useradd.
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+
+	err = os.Mkdir(path, opts.Mode)
+	if errors.Is(err, fs.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := populate(path, uid, gid, opts); err != nil {
+		// Don't leave a partial home directory behind, so that the creation is retried on the next login.
+		if rmErr := os.RemoveAll(path); rmErr != nil {
+			err = errors.Join(err, rmErr)
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// populate sets the mode of the new home directory, copies the skeleton files into it, creates the subdirectories,
+// applies the ACL and the SELinux context and gives them to the user.
+//
+// The home directory is only given to the user once it's populated, as the daemon can't write into a directory owned
+// by someone else without CAP_DAC_OVERRIDE, nor set the ACL of a file owned by someone else without CAP_FOWNER.
+func populate(home string, uid, gid uint32, opts Options) error {
+	// The mode passed to Mkdir is filtered by the process umask.
+	if err := os.Chmod(home, opts.Mode); err != nil {
+		return err
+	}
+
+	if err := copySkel(home, opts.SkelDir, opts.Umask); err != nil {
+		return err
+	}
+
+	for _, subdir := range opts.Subdirs {
+		if err := mkdirAll(home, subdir, opts.Mode); err != nil {
+			return err
+		}
+	}
+
+	if len(opts.ACL) > 0 {
+		if err := run(setfaclCmd, "-m", strings.Join(opts.ACL, ","), home); err != nil {
+			return err
+		}
+	}
+	if opts.SELinuxRelabel {
+		if err := run(restoreconCmd, "-R", home); err != nil {
+			return err
+		}
+	}
+
+	return chownTree(home, uid, gid)
+}
+
+// copySkel copies the content of the skeleton directory, if any, into the home directory.
+func copySkel(home, skelDir string, umask fs.FileMode) error {
+	if skelDir == "" {
+		return nil
+	}
+	if _, err := os.Stat(skelDir); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return filepath.WalkDir(skelDir, func(src string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(skelDir, src)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		return copyEntry(src, filepath.Join(home, rel), d, umask)
+	})
+}
+
+// mkdirAll creates the subdirectory of the home directory and its missing parents with the given mode.
+func mkdirAll(home, subdir string, mode fs.FileMode) error {
+	dir := home
+	for _, elem := range strings.Split(filepath.Clean(subdir), string(filepath.Separator)) {
+		dir = filepath.Join(dir, elem)
+		err := os.Mkdir(dir, mode)
+		if errors.Is(err, fs.ErrExist) {
+			// The directory may come from the skeleton directory.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(dir, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chownTree gives the home directory and its content to the user. The files are changed relative to their opened
+// parent directory and the symbolic links are not followed, so that a link in the home directory can't make the daemon
+// change the owner of a file outside of it.
+func chownTree(home string, uid, gid uint32) error {
+	fd, err := unix.Open(home, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return &fs.PathError{Op: "open", Path: home, Err: err}
+	}
+	return chownDirAt(fd, home, uid, gid)
+}
+
+// chownDirAt gives the content of the directory opened as fd to the user, then the directory itself, and closes fd.
+func chownDirAt(fd int, path string, uid, gid uint32) error {
+	dir := os.NewFile(uintptr(fd), path)
+	defer dir.Close()
+
+	entries, err := dir.ReadDir(-1)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		p := filepath.Join(path, e.Name())
+		if !e.IsDir() {
+			if err := unix.Fchownat(fd, e.Name(), int(uid), int(gid), unix.AT_SYMLINK_NOFOLLOW); err != nil {
+				return &fs.PathError{Op: "fchownat", Path: p, Err: err}
+			}
+			continue
+		}
+
+		childFd, err := unix.Openat(fd, e.Name(), unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return &fs.PathError{Op: "openat", Path: p, Err: err}
+		}
+		if err := chownDirAt(childFd, p, uid, gid); err != nil {
+			return err
+		}
+	}
+
+	if err := unix.Fchown(fd, int(uid), int(gid)); err != nil {
+		return &fs.PathError{Op: "fchown", Path: path, Err: err}
+	}
+	return nil
+}
+
+// run runs the command, returning its output in the error if it fails.
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// copyEntry copies a directory, a regular file or a symbolic link of the skeleton directory, keeping its permissions
+// filtered by the umask. Other file types are not expected in a skeleton directory and are rejected.
+func copyEntry(src, dst string, d fs.DirEntry, umask fs.FileMode) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	perm := info.Mode().Perm() &^ umask
+
+	switch {
+	case d.IsDir():
+		if err := os.Mkdir(dst, perm); err != nil {
+			return err
+		}
+		return os.Chmod(dst, perm)
+	case d.Type()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case d.Type().IsRegular():
+		return copyFile(src, dst, perm)
+	default:
+		return fmt.Errorf("unsupported file type of %q in skeleton directory", src)
+	}
+}
+
+// copyFile copies the content of the regular file src to the new file dst.
+func copyFile(src, dst string, perm fs.FileMode) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Chmod(perm)
+}
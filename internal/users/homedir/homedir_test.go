@@ -0,0 +1,227 @@
+package homedir_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users/homedir"
+)
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		existingHome   bool
+		noSkel         bool
+		emptySkel      bool
+		relativePath   bool
+		fifoInSkel     bool
+		umask          fs.FileMode
+		subdirs        []string
+		acl            []string
+		selinuxRelabel bool
+
+		wantCreated  bool
+		wantFiles    []string
+		wantCommands string
+		wantErr      bool
+	}{
+		"Create_home_with_skeleton_files":                        {wantCreated: true, wantFiles: []string{".bashrc", ".config", ".config/app.conf", ".profile", "link"}},
+		"Create_empty_home_if_skeleton_directory_does_not_exist": {noSkel: true, wantCreated: true},
+		"Create_empty_home_if_no_skeleton_directory_is_set":      {emptySkel: true, wantCreated: true},
+		"Create_home_with_umask_applied_to_skeleton_files":       {umask: 0077, wantCreated: true, wantFiles: []string{".bashrc", ".config", ".config/app.conf", ".profile", "link"}},
+		"Create_home_with_subdirectories":                        {emptySkel: true, subdirs: []string{"tenant/projects", "tenant"}, wantCreated: true, wantFiles: []string{"tenant", "tenant/projects"}},
+		"Create_home_with_subdirectory_of_skeleton_directory":    {subdirs: []string{".config/tenant"}, wantCreated: true, wantFiles: []string{".bashrc", ".config", ".config/app.conf", ".config/tenant", ".profile", "link"}},
+		"Create_home_with_ACL":                                   {emptySkel: true, acl: []string{"u:admin:rx", "d:g:staff:r"}, wantCreated: true, wantCommands: "setfacl -m u:admin:rx,d:g:staff:r user\n"},
+		"Create_home_with_SELinux_context":                       {emptySkel: true, selinuxRelabel: true, wantCreated: true, wantCommands: "restorecon -R user\n"},
+
+		"Do_nothing_if_home_already_exists": {existingHome: true},
+
+		"Error_if_path_is_relative":                              {relativePath: true, wantErr: true},
+		"Error_and_remove_home_if_skeleton_has_unsupported_file": {fifoInSkel: true, wantErr: true},
+		"Error_if_subdirectory_is_outside_of_home":               {subdirs: []string{"../other"}, wantErr: true},
+		"Error_and_remove_home_if_ACL_cannot_be_set":             {acl: []string{"u:fail:rx"}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			skelDir := filepath.Join(tmpDir, "skel")
+			home := filepath.Join(tmpDir, "home", "user")
+
+			if !tc.noSkel {
+				require.NoError(t, os.MkdirAll(filepath.Join(skelDir, ".config"), 0700), "Setup: could not create skeleton directory")
+				require.NoError(t, os.WriteFile(filepath.Join(skelDir, ".bashrc"), []byte("bashrc"), 0644), "Setup: could not create file")
+				require.NoError(t, os.WriteFile(filepath.Join(skelDir, ".profile"), []byte("profile"), 0600), "Setup: could not create file")
+				require.NoError(t, os.WriteFile(filepath.Join(skelDir, ".config", "app.conf"), []byte("conf"), 0640), "Setup: could not create file")
+				require.NoError(t, os.Symlink(".bashrc", filepath.Join(skelDir, "link")), "Setup: could not create symlink")
+			}
+			if tc.fifoInSkel {
+				require.NoError(t, syscall.Mkfifo(filepath.Join(skelDir, "fifo"), 0600), "Setup: could not create FIFO")
+			}
+			if tc.existingHome {
+				require.NoError(t, os.MkdirAll(home, 0700), "Setup: could not create home directory")
+			}
+			if tc.emptySkel {
+				skelDir = ""
+			}
+			if tc.relativePath {
+				home = "home/user"
+			}
+
+			uid, gid := uint32(os.Getuid()), uint32(os.Getgid())
+			opts := homedir.Options{
+				SkelDir:        skelDir,
+				Mode:           0750,
+				Umask:          tc.umask,
+				Subdirs:        tc.subdirs,
+				ACL:            tc.acl,
+				SELinuxRelabel: tc.selinuxRelabel,
+			}
+			created, err := homedir.Create(home, uid, gid, opts)
+			if tc.wantErr {
+				require.Error(t, err, "Create should return an error, but did not")
+				if !tc.relativePath {
+					require.NoDirExists(t, home, "Create should remove the partially created home directory")
+				}
+				return
+			}
+			require.NoError(t, err, "Create should not return an error, but did")
+			require.Equal(t, tc.wantCreated, created, "Create did not report the expected creation")
+
+			fi, err := os.Stat(home)
+			require.NoError(t, err, "Home directory should exist")
+			if !tc.wantCreated {
+				require.Equal(t, fs.FileMode(0700), fi.Mode().Perm(), "Existing home directory should be left untouched")
+				entries, err := os.ReadDir(home)
+				require.NoError(t, err, "Could not read home directory")
+				require.Empty(t, entries, "Existing home directory should be left untouched")
+				return
+			}
+			require.Equal(t, fs.FileMode(0750), fi.Mode().Perm(), "Home directory should have the requested mode")
+
+			var gotFiles []string
+			err = filepath.WalkDir(home, func(path string, d fs.DirEntry, err error) error {
+				require.NoError(t, err, "Could not walk home directory")
+				if path == home {
+					return nil
+				}
+				rel, err := filepath.Rel(home, path)
+				require.NoError(t, err, "Could not get relative path")
+				gotFiles = append(gotFiles, rel)
+
+				dst, err := os.Lstat(path)
+				require.NoError(t, err, "Could not stat copied file")
+				wantMode := fs.ModeDir | 0750
+				if src, err := os.Lstat(filepath.Join(skelDir, rel)); err == nil {
+					wantMode = src.Mode()
+					if src.Mode()&fs.ModeSymlink == 0 {
+						wantMode &^= tc.umask
+					}
+				}
+				require.Equal(t, wantMode, dst.Mode(), "Created file should have the mode of the skeleton file filtered by the umask")
+				require.Equal(t, uid, dst.Sys().(*syscall.Stat_t).Uid, "Copied file should be owned by the user")
+				require.Equal(t, gid, dst.Sys().(*syscall.Stat_t).Gid, "Copied file should be owned by the group of the user")
+				return nil
+			})
+			require.NoError(t, err, "Could not walk home directory")
+			require.Equal(t, tc.wantFiles, gotFiles, "Home directory should contain the skeleton files")
+
+			commands, err := os.ReadFile(filepath.Join(filepath.Dir(home), "commands"))
+			if tc.wantCommands == "" {
+				require.ErrorIs(t, err, fs.ErrNotExist, "No command should have been run")
+			} else {
+				require.NoError(t, err, "Could not read the run commands")
+				require.Equal(t, tc.wantCommands, string(commands), "Unexpected commands were run")
+			}
+
+			content, err := os.ReadFile(filepath.Join(home, ".config", "app.conf"))
+			if !tc.emptySkel && !tc.noSkel {
+				require.NoError(t, err, "Could not read copied file")
+				require.Equal(t, "conf", string(content), "Copied file should have the content of the skeleton file")
+			}
+		})
+	}
+}
+
+func TestCreateForAnotherUser(t *testing.T) {
+	t.Parallel()
+
+	if os.Geteuid() != 0 {
+		t.Skip("Can't give files to another user without being root")
+	}
+
+	tmpDir := t.TempDir()
+	skelDir := filepath.Join(tmpDir, "skel")
+	home := filepath.Join(tmpDir, "home", "user")
+	outside := filepath.Join(tmpDir, "outside")
+	require.NoError(t, os.MkdirAll(filepath.Join(skelDir, ".config"), 0700), "Setup: could not create skeleton directory")
+	require.NoError(t, os.WriteFile(filepath.Join(skelDir, ".config", "app.conf"), []byte("conf"), 0600), "Setup: could not create file")
+	require.NoError(t, os.WriteFile(outside, []byte("outside"), 0600), "Setup: could not create file")
+	require.NoError(t, os.Symlink(outside, filepath.Join(skelDir, "link")), "Setup: could not create symlink")
+
+	const uid, gid = 65534, 65534
+	created, err := homedir.Create(home, uid, gid, homedir.Options{
+		SkelDir:        skelDir,
+		Mode:           0700,
+		Subdirs:        []string{"tenant/projects"},
+		ACL:            []string{"g:admins:rx"},
+		SELinuxRelabel: true,
+	})
+	require.NoError(t, err, "Create should not return an error, but did")
+	require.True(t, created, "Create should report the creation")
+
+	for _, rel := range []string{".", ".config", ".config/app.conf", "link", "tenant", "tenant/projects"} {
+		fi, err := os.Lstat(filepath.Join(home, rel))
+		require.NoError(t, err, "File %q should exist", rel)
+		require.Equal(t, uint32(uid), fi.Sys().(*syscall.Stat_t).Uid, "File %q should be owned by the user", rel)
+		require.Equal(t, uint32(gid), fi.Sys().(*syscall.Stat_t).Gid, "File %q should be owned by the group of the user", rel)
+	}
+	fi, err := os.Stat(outside)
+	require.NoError(t, err, "File outside of the home directory should exist")
+	require.Equal(t, uint32(0), fi.Sys().(*syscall.Stat_t).Uid, "The symbolic links should not be followed")
+}
+
+func TestValidateACL(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		entries []string
+
+		wantErr bool
+	}{
+		"Valid_entries":         {entries: []string{"u:admin:rwx", "user:admin:r-x", "g::rx", "d:g:staff:r", "default:o::-", "m::rX"}},
+		"No_entries_are_valid":  {},
+		"Error_on_missing_tag":  {entries: []string{"admin:rwx"}, wantErr: true},
+		"Error_on_unknown_tag":  {entries: []string{"x:admin:rwx"}, wantErr: true},
+		"Error_on_invalid_perm": {entries: []string{"u:admin:rwz"}, wantErr: true},
+		"Error_on_list_entry":   {entries: []string{"u:admin:rx,g:staff:r"}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := homedir.ValidateACL(tc.entries)
+			if tc.wantErr {
+				require.Error(t, err, "ValidateACL should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "ValidateACL should not return an error, but did")
+		})
+	}
+}
+
+func TestMain(m *testing.M) {
+	testdata, err := filepath.Abs("testdata")
+	if err != nil {
+		panic(err)
+	}
+	homedir.SetCommands(filepath.Join(testdata, "setfacl"), filepath.Join(testdata, "restorecon"))
+
+	os.Exit(m.Run())
+}
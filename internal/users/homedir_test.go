@@ -0,0 +1,98 @@
+package users_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+)
+
+func TestCreateHomeDir(t *testing.T) {
+	t.Parallel()
+
+	enabled, disabled := true, false
+
+	tests := map[string]struct {
+		username       string
+		createHomeDirs bool
+		brokerPolicy   *bool
+		existingHome   bool
+		subdirs        []string
+
+		wantCreated   bool
+		wantSubdirs   []string
+		wantNoSubdirs []string
+		wantErrType   error
+	}{
+		"Create_home_directory_if_enabled_in_configuration":       {createHomeDirs: true, wantCreated: true},
+		"Create_home_directory_if_enabled_by_broker":              {brokerPolicy: &enabled, wantCreated: true},
+		"Keep_existing_home_directory_untouched":                  {createHomeDirs: true, existingHome: true},
+		"Create_home_directory_with_subdirectories":               {username: "user1@example.com", createHomeDirs: true, subdirs: []string{"work/{{.Domain}}", "{{.Name}}"}, wantCreated: true, wantSubdirs: []string{"work/example.com", "user1@example.com"}},
+		"Skip_subdirectories_using_domain_of_user_without_domain": {createHomeDirs: true, subdirs: []string{"work/{{.Domain}}", "{{.Name}}"}, wantCreated: true, wantSubdirs: []string{"user1"}, wantNoSubdirs: []string{"work"}},
+
+		"Do_not_create_home_directory_by_default":            {},
+		"Do_not_create_home_directory_if_disabled_by_broker": {createHomeDirs: true, brokerPolicy: &disabled},
+
+		"Error_if_user_does_not_exist": {username: "doesnotexist", createHomeDirs: true, wantErrType: cache.NoDataFoundError{}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.username == "" {
+				tc.username = "user1"
+			}
+
+			home := filepath.Join(t.TempDir(), "home", "user1")
+			skelDir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(skelDir, ".bashrc"), []byte("bashrc"), 0600), "Setup: could not create skeleton file")
+			if tc.existingHome {
+				require.NoError(t, os.MkdirAll(home, 0700), "Setup: could not create home directory")
+			}
+
+			config := users.DefaultConfig
+			config.CreateHomeDirs = tc.createHomeDirs
+			config.SkelDir = skelDir
+			config.HomeDirSubdirs = tc.subdirs
+			m, err := users.NewManager(config, t.TempDir())
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			dbUsername := tc.username
+			if tc.wantErrType != nil {
+				dbUsername = "user1"
+			}
+			// The user is the current one, so that the home directory can be chowned without privileges.
+			uid, gid := uint32(os.Getuid()), uint32(os.Getgid())
+			u := cache.UserDB{Name: dbUsername, UID: uid, GID: gid, Dir: home, Shell: "/bin/bash"}
+			err = userstestutils.GetManagerCache(m).UpdateUserEntry(u, []cache.GroupDB{{Name: dbUsername, GID: gid}}, nil)
+			require.NoError(t, err, "Setup: could not add user")
+
+			err = m.CreateHomeDir(tc.username, tc.brokerPolicy)
+			requireErrorAssertions(t, err, tc.wantErrType, false)
+			if tc.wantErrType != nil {
+				return
+			}
+
+			if tc.existingHome {
+				require.NoFileExists(t, filepath.Join(home, ".bashrc"), "Existing home directory should be left untouched")
+				return
+			}
+			if !tc.wantCreated {
+				require.NoDirExists(t, home, "Home directory should not have been created")
+				return
+			}
+			require.FileExists(t, filepath.Join(home, ".bashrc"), "Home directory should contain the skeleton files")
+			for _, subdir := range tc.wantSubdirs {
+				require.DirExists(t, filepath.Join(home, subdir), "Home directory should contain the configured subdirectories")
+			}
+			for _, subdir := range tc.wantNoSubdirs {
+				require.NoDirExists(t, filepath.Join(home, subdir), "Home directory should not contain skipped subdirectories")
+			}
+		})
+	}
+}
@@ -12,6 +12,10 @@ type IDGenerator struct {
 	UIDMax uint32
 	GIDMin uint32
 	GIDMax uint32
+	// RemoteGroupGIDMin and RemoteGroupGIDMax are the range of the GIDs of the groups provided by the brokers. If
+	// RemoteGroupGIDMax is 0, the GIDs of those groups are generated in the GIDMin-GIDMax range.
+	RemoteGroupGIDMin uint32
+	RemoteGroupGIDMax uint32
 }
 
 // GenerateUID generates a random UID in the configured range.
@@ -24,6 +28,14 @@ func (g *IDGenerator) GenerateGID() (uint32, error) {
 	return generateID(g.GIDMin, g.GIDMax)
 }
 
+// GenerateRemoteGroupGID generates a random GID for a group provided by a broker in the configured range.
+func (g *IDGenerator) GenerateRemoteGroupGID() (uint32, error) {
+	if g.RemoteGroupGIDMax == 0 {
+		return g.GenerateGID()
+	}
+	return generateID(g.RemoteGroupGIDMin, g.RemoteGroupGIDMax)
+}
+
 func generateID(minID, maxID uint32) (uint32, error) {
 	diff := int64(maxID - minID)
 	// Generate a cryptographically secure random number between 0 and diff
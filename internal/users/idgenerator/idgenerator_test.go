@@ -29,3 +29,35 @@ func TestGenerateID(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateRemoteGroupGID(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		remoteGroupGIDMin uint32
+		remoteGroupGIDMax uint32
+
+		wantMin uint32
+		wantMax uint32
+	}{
+		"Generated_GID_is_within_the_remote_groups_range":         {remoteGroupGIDMin: 3000, remoteGroupGIDMax: 4000, wantMin: 3000, wantMax: 4000},
+		"Generated_GID_is_within_the_GID_range_if_range_is_unset": {wantMin: 1000, wantMax: 2000},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			g := IDGenerator{
+				GIDMin:            1000,
+				GIDMax:            2000,
+				RemoteGroupGIDMin: tc.remoteGroupGIDMin,
+				RemoteGroupGIDMax: tc.remoteGroupGIDMax,
+			}
+			gid, err := g.GenerateRemoteGroupGID()
+			require.NoError(t, err, "GenerateRemoteGroupGID should not have failed")
+
+			require.GreaterOrEqual(t, gid, tc.wantMin, "GenerateRemoteGroupGID should return a GID greater or equal to the minimum")
+			require.LessOrEqual(t, gid, tc.wantMax, "GenerateRemoteGroupGID should return a GID less or equal to the maximum")
+		})
+	}
+}
@@ -28,3 +28,8 @@ func (g *IDGeneratorMock) GenerateGID() (uint32, error) {
 	g.GIDsToGenerate = g.GIDsToGenerate[1:]
 	return gid, nil
 }
+
+// GenerateRemoteGroupGID generates a GID for a group provided by a broker. It uses the same GIDs as GenerateGID.
+func (g *IDGeneratorMock) GenerateRemoteGroupGID() (uint32, error) {
+	return g.GenerateGID()
+}
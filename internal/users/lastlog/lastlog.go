@@ -0,0 +1,72 @@
+// Package lastlog updates the lastlog file, which records the last login of each user and is read by lastlog(8) and
+// login(1).
+package lastlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ubuntu/decorate"
+)
+
+const (
+	lineSize = 32
+	hostSize = 256
+)
+
+// record is the struct lastlog of lastlog.h. Records are indexed by UID in the file.
+type record struct {
+	Time int32
+	Line [lineSize]byte
+	Host [hostSize]byte
+}
+
+// recordSize is the size of a record in the file.
+var recordSize = int64(binary.Size(record{}))
+
+// Update records the login of the user with the given UID at the given time, from the given terminal and remote host.
+//
+// The file is not created if it doesn't exist, as it means that lastlog is not used on this system. The file stores
+// the times on 32 bits, so an error is returned for the times after 2038: use lastlog2 on those systems.
+func Update(path string, uid uint32, t time.Time, tty, rhost string) (err error) {
+	defer decorate.OnError(&err, "could not update lastlog file %q for UID %d", path, uid)
+
+	if t.Unix() > math.MaxInt32 || t.Unix() < math.MinInt32 {
+		return fmt.Errorf("time %v can't be stored in the lastlog file", t)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := record{
+		//nolint:gosec // The time was checked to fit in 32 bits above.
+		Time: int32(t.Unix()),
+	}
+	copy(r.Line[:lineSize-1], strings.TrimPrefix(tty, "/dev/"))
+	copy(r.Host[:hostSize-1], rhost)
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.NativeEndian, r); err != nil {
+		return fmt.Errorf("could not encode record: %v", err)
+	}
+
+	// The file is sparse, so writing the record of a high UID doesn't allocate the records of the lower ones.
+	if _, err := f.WriteAt(buf.Bytes(), int64(uid)*recordSize); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,115 @@
+package lastlog
+
+/*
+#cgo LDFLAGS: -ldl
+#include <dlfcn.h>
+#include <stdint.h>
+#include <stdlib.h>
+
+typedef void *(*ll2_new_context_fn)(const char *);
+typedef void (*ll2_unref_context_fn)(void *);
+typedef int (*ll2_write_entry_fn)(void *, const char *, const int64_t, const char *, const char *, const char *, char **);
+
+static void *call_new_context(void *f, const char *db_path) { return ((ll2_new_context_fn)f)(db_path); }
+static void call_unref_context(void *f, void *context) { ((ll2_unref_context_fn)f)(context); }
+static int call_write_entry(void *f, void *context, const char *user, int64_t ll_time, const char *tty,
+                            const char *rhost, const char *pam_service, char **error) {
+	return ((ll2_write_entry_fn)f)(context, user, ll_time, tty, rhost, pam_service, error);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/ubuntu/decorate"
+)
+
+// lastlog2Library is the library of lastlog2, loaded when it's used so that authd doesn't depend on it.
+const lastlog2Library = "liblastlog2.so.2"
+
+// lastlog2Funcs are the functions of the lastlog2 library.
+type lastlog2Funcs struct {
+	newContext   unsafe.Pointer
+	unrefContext unsafe.Pointer
+	writeEntry   unsafe.Pointer
+}
+
+// loadLastlog2 loads the lastlog2 library once. It returns nil if the library is not installed.
+var loadLastlog2 = sync.OnceValue(func() *lastlog2Funcs {
+	lib := C.CString(lastlog2Library)
+	defer C.free(unsafe.Pointer(lib))
+
+	handle := C.dlopen(lib, C.RTLD_NOW|C.RTLD_LOCAL)
+	if handle == nil {
+		return nil
+	}
+
+	var funcs lastlog2Funcs
+	for name, f := range map[string]*unsafe.Pointer{
+		"ll2_new_context":   &funcs.newContext,
+		"ll2_unref_context": &funcs.unrefContext,
+		"ll2_write_entry":   &funcs.writeEntry,
+	} {
+		cName := C.CString(name)
+		*f = C.dlsym(handle, cName)
+		C.free(unsafe.Pointer(cName))
+		if *f == nil {
+			return nil
+		}
+	}
+	return &funcs
+})
+
+// Update2 records the login of the user at the given time, from the given terminal and remote host, in the lastlog2
+// database at path, which stores the times on 64 bits and is read by lastlog2(8) and pam_lastlog2.
+//
+// It returns false if lastlog2 is not used on this system, because its library is not installed or because the
+// database doesn't exist, which is not created.
+func Update2(path, name string, t time.Time, tty, rhost string) (updated bool, err error) {
+	defer decorate.OnError(&err, "could not update lastlog2 database %q for user %q", path, name)
+
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	funcs := loadLastlog2()
+	if funcs == nil {
+		return false, nil
+	}
+
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+	context := C.call_new_context(funcs.newContext, cPath)
+	if context == nil {
+		return false, errors.New("could not create lastlog2 context")
+	}
+	defer C.call_unref_context(funcs.unrefContext, context)
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	cTTY := C.CString(strings.TrimPrefix(tty, "/dev/"))
+	defer C.free(unsafe.Pointer(cTTY))
+	cRhost := C.CString(rhost)
+	defer C.free(unsafe.Pointer(cRhost))
+
+	var cErr *C.char
+	if C.call_write_entry(funcs.writeEntry, context, cName, C.int64_t(t.Unix()), cTTY, cRhost, nil, &cErr) != 0 {
+		msg := "unknown error"
+		if cErr != nil {
+			msg = C.GoString(cErr)
+			C.free(unsafe.Pointer(cErr))
+		}
+		return false, fmt.Errorf("could not write entry: %s", msg)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,98 @@
+package lastlog_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users/lastlog"
+)
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	const recordSize = 4 + 32 + 256
+
+	tests := map[string]struct {
+		uid       uint32
+		tty       string
+		rhost     string
+		loginTime time.Time
+		noFile    bool
+
+		wantTTY   string
+		wantRhost string
+		wantErr   bool
+	}{
+		"Record_login_from_terminal":    {uid: 3, tty: "/dev/pts/1", wantTTY: "pts/1"},
+		"Record_login_from_remote_host": {uid: 1, tty: "ssh", rhost: "192.168.1.2", wantTTY: "ssh", wantRhost: "192.168.1.2"},
+		"Record_login_of_high_UID":      {uid: 1000000000, tty: "tty1", wantTTY: "tty1"},
+		"Record_truncated_remote_host":  {uid: 2, rhost: strings.Repeat("h", 300), wantRhost: strings.Repeat("h", 255)},
+
+		"Do_nothing_if_file_does_not_exist": {uid: 1, noFile: true},
+
+		"Error_if_time_does_not_fit_in_32_bits": {uid: 1, loginTime: time.Unix(1<<31, 0), wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "lastlog")
+			if !tc.noFile {
+				require.NoError(t, os.WriteFile(path, nil, 0600), "Setup: could not create lastlog file")
+			}
+
+			loginTime := tc.loginTime
+			if loginTime.IsZero() {
+				loginTime = time.Unix(1700000000, 0)
+			}
+			err := lastlog.Update(path, tc.uid, loginTime, tc.tty, tc.rhost)
+			if tc.wantErr {
+				require.Error(t, err, "Update should return an error")
+				return
+			}
+			require.NoError(t, err, "Update should not return an error")
+
+			if tc.noFile {
+				require.NoFileExists(t, path, "Update should not create the lastlog file")
+				return
+			}
+
+			f, err := os.Open(path)
+			require.NoError(t, err, "Could not open lastlog file")
+			defer f.Close()
+
+			data := make([]byte, recordSize)
+			_, err = f.ReadAt(data, int64(tc.uid)*recordSize)
+			require.NoError(t, err, "Could not read the record of the user")
+
+			require.Equal(t, uint32(loginTime.Unix()), binary.NativeEndian.Uint32(data[:4]), "Time of the record is not the expected one")
+			require.Equal(t, tc.wantTTY, trimNul(data[4:36]), "Terminal of the record is not the expected one")
+			require.Equal(t, tc.wantRhost, trimNul(data[36:]), "Remote host of the record is not the expected one")
+		})
+	}
+}
+
+// trimNul returns the NUL-terminated string at the start of b.
+func trimNul(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		return string(b[:i])
+	}
+	return string(b)
+}
+
+func TestUpdate2(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "lastlog2.db")
+
+	updated, err := lastlog.Update2(path, "user1", time.Now(), "/dev/pts/0", "")
+	require.NoError(t, err, "Update2 should not return an error")
+	require.False(t, updated, "Update2 should not update a database which does not exist")
+	require.NoFileExists(t, path, "Update2 should not create the lastlog2 database")
+}
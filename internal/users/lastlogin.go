@@ -0,0 +1,73 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/ubuntu/authd/internal/users/lastlog"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// RecordLogin records a successful login of the user from the given terminal and remote host, which can be empty if
+// they are unknown. If configured, the lastlog2 database, or else the lastlog file, is updated too.
+func (m *Manager) RecordLogin(name, tty, rhost string) (err error) {
+	defer decorate.OnError(&err, "failed to record login of user %q", name)
+
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	u, err := m.cache.UserByName(name)
+	if err != nil {
+		return err
+	}
+
+	source := rhost
+	if source == "" {
+		source = tty
+	}
+
+	now := time.Now()
+	if err := m.cache.RecordLogin(u.UID, now, source); err != nil {
+		return err
+	}
+
+	// The lastlog files are only informative, so don't fail the login if they can't be updated.
+	config := m.config()
+	if config.Lastlog2Database != "" {
+		updated, err := lastlog.Update2(config.Lastlog2Database, u.Name, now, tty, rhost)
+		if err != nil {
+			log.Warningf(context.Background(), "%v", err)
+		}
+		if updated || err != nil {
+			return nil
+		}
+	}
+	if config.LastlogFile == "" {
+		return nil
+	}
+	if err := lastlog.Update(config.LastlogFile, u.UID, now, tty, rhost); err != nil {
+		log.Warningf(context.Background(), "%v", err)
+	}
+
+	return nil
+}
+
+// LastLogin returns the last successful login of the user.
+func (m *Manager) LastLogin(name string) (l types.LastLogin, err error) {
+	defer decorate.OnError(&err, "failed to get last login of user %q", name)
+
+	t, source, err := m.cache.LastLogin(name)
+	if err != nil {
+		return types.LastLogin{}, err
+	}
+
+	brokerID, err := m.cache.BrokerForUser(name)
+	if err != nil {
+		return types.LastLogin{}, err
+	}
+
+	return types.LastLogin{Time: t, Source: source, BrokerID: brokerID}, nil
+}
@@ -0,0 +1,95 @@
+package users_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+)
+
+func TestRecordLogin(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username string
+		tty      string
+		rhost    string
+		lastlog  bool
+		lastlog2 bool
+
+		wantSource  string
+		wantErrType error
+	}{
+		"Record_login_from_remote_host":   {tty: "ssh", rhost: "192.168.1.2", wantSource: "192.168.1.2"},
+		"Record_login_from_terminal":      {tty: "/dev/tty1", wantSource: "/dev/tty1"},
+		"Record_login_from_unknown_place": {},
+		"Record_login_updates_lastlog":    {tty: "/dev/pts/0", rhost: "example.com", lastlog: true, wantSource: "example.com"},
+		"Record_login_updates_lastlog_if_lastlog2_database_does_not_exist": {
+			tty: "/dev/pts/0", lastlog: true, lastlog2: true, wantSource: "/dev/pts/0",
+		},
+
+		"Error_if_user_does_not_exist": {username: "doesnotexist", wantErrType: cache.NoDataFoundError{}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.username == "" {
+				tc.username = "user1"
+			}
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "one_user_and_group.db.yaml"), cacheDir)
+
+			config := users.DefaultConfig
+			if tc.lastlog {
+				config.LastlogFile = filepath.Join(t.TempDir(), "lastlog")
+				require.NoError(t, os.WriteFile(config.LastlogFile, nil, 0600), "Setup: could not create lastlog file")
+			}
+			if tc.lastlog2 {
+				config.Lastlog2Database = filepath.Join(t.TempDir(), "lastlog2.db")
+			}
+			m, err := users.NewManager(config, cacheDir)
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			before := time.Now()
+			err = m.RecordLogin(tc.username, tc.tty, tc.rhost)
+			requireErrorAssertions(t, err, tc.wantErrType, false)
+			if tc.wantErrType != nil {
+				return
+			}
+
+			l, err := m.LastLogin(tc.username)
+			require.NoError(t, err, "LastLogin should not return an error")
+			require.False(t, l.Time.Before(before.Truncate(time.Second)), "LastLogin should return the time of the recorded login")
+			require.Equal(t, tc.wantSource, l.Source, "LastLogin should return the source of the recorded login")
+			require.Equal(t, "broker-id", l.BrokerID, "LastLogin should return the broker of the user")
+
+			if !tc.lastlog {
+				return
+			}
+			info, err := os.Stat(config.LastlogFile)
+			require.NoError(t, err, "Could not stat the lastlog file")
+			require.Greater(t, info.Size(), int64(0), "The lastlog file should have been updated")
+			if tc.lastlog2 {
+				require.NoFileExists(t, config.Lastlog2Database, "The lastlog2 database should not be created")
+			}
+		})
+	}
+}
+
+func TestLastLoginOfUnknownUser(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+	cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "one_user_and_group.db.yaml"), cacheDir)
+	m := newManagerForTests(t, cacheDir)
+
+	_, err := m.LastLogin("doesnotexist")
+	require.ErrorIs(t, err, cache.NoDataFoundError{}, "LastLogin should fail for a user which does not exist")
+}
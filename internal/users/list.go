@@ -0,0 +1,88 @@
+package users
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/decorate"
+)
+
+// ListUsers returns a page of the users matching the filter, sorted by UID. more is true if there are matching users
+// after this page.
+func (m *Manager) ListUsers(filter types.UserFilter, page types.Page) (users []types.UserSummary, more bool, err error) {
+	defer decorate.OnError(&err, "failed to list users")
+
+	records, err := m.cache.ListUsers(func(u cache.UserRecord) bool {
+		if u.UID <= page.After || !inIDRange(u.UID, filter.MinUID, filter.MaxUID) {
+			return false
+		}
+		if filter.BrokerID != "" && u.BrokerID != filter.BrokerID {
+			return false
+		}
+		if !filter.LastLoginBefore.IsZero() && (u.LastLogin.IsZero() || !u.LastLogin.Before(filter.LastLoginBefore)) {
+			return false
+		}
+		if filter.Locked != nil && u.Locked != *filter.Locked {
+			return false
+		}
+		if filter.Stale != nil && m.isStale(u.RefreshedAt) != *filter.Stale {
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	records, more = paginate(records, page.Size)
+	for _, u := range records {
+		users = append(users, types.UserSummary{
+			UserEntry: userEntryFromUserDB(u.UserDB),
+			BrokerID:  u.BrokerID,
+			LastLogin: u.LastLogin,
+			Locked:    u.Locked,
+			Stale:     m.isStale(u.RefreshedAt),
+		})
+	}
+	return users, more, nil
+}
+
+// ListGroups returns a page of the groups matching the filter, sorted by GID. more is true if there are matching
+// groups after this page.
+func (m *Manager) ListGroups(filter types.GroupFilter, page types.Page) (groups []types.GroupEntry, more bool, err error) {
+	defer decorate.OnError(&err, "failed to list groups")
+
+	all, err := m.cache.AllGroups()
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, g := range all {
+		if g.GID <= page.After || !inIDRange(g.GID, filter.MinGID, filter.MaxGID) {
+			continue
+		}
+		if filter.Member != "" && !slices.Contains(g.Users, filter.Member) {
+			continue
+		}
+		groups = append(groups, groupEntryFromGroupDB(g))
+	}
+	slices.SortFunc(groups, func(a, b types.GroupEntry) int { return cmp.Compare(a.GID, b.GID) })
+
+	groups, more = paginate(groups, page.Size)
+	return groups, more, nil
+}
+
+// inIDRange returns true if id is in the inclusive range [low, high]. A zero high has no upper bound.
+func inIDRange(id, low, high uint32) bool {
+	return id >= low && (high == 0 || id <= high)
+}
+
+// paginate returns the first size entries, and whether there are more entries. A zero size returns all the entries.
+func paginate[T any](entries []T, size int) ([]T, bool) {
+	if size <= 0 || len(entries) <= size {
+		return entries, false
+	}
+	return entries[:size], true
+}
@@ -0,0 +1,111 @@
+package users_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestListUsers(t *testing.T) {
+	t.Parallel()
+
+	locked, unlocked := true, false
+	stale, fresh := true, false
+
+	tests := map[string]struct {
+		filter      types.UserFilter
+		page        types.Page
+		userInfoTTL time.Duration
+
+		wantUsers []string
+		wantMore  bool
+	}{
+		"List_all_users":                   {wantUsers: []string{"user1", "user2", "user3", "userwithoutbroker"}},
+		"List_users_of_broker":             {filter: types.UserFilter{BrokerID: "broker-id"}, wantUsers: []string{"user1", "user2", "user3"}},
+		"List_users_not_logged_in_since":   {filter: types.UserFilter{LastLoginBefore: time.Date(2005, 1, 1, 0, 0, 0, 0, time.UTC)}, wantUsers: []string{"user1"}},
+		"List_locked_users":                {filter: types.UserFilter{Locked: &locked}, wantUsers: []string{"user2"}},
+		"List_unlocked_users":              {filter: types.UserFilter{Locked: &unlocked}, wantUsers: []string{"user1", "user3", "userwithoutbroker"}},
+		"List_stale_users":                 {filter: types.UserFilter{Stale: &stale}, userInfoTTL: time.Hour, wantUsers: []string{"user2", "user3", "userwithoutbroker"}},
+		"List_fresh_users":                 {filter: types.UserFilter{Stale: &fresh}, userInfoTTL: time.Hour, wantUsers: []string{"user1"}},
+		"List_no_stale_users_without_TTL":  {filter: types.UserFilter{Stale: &stale}},
+		"List_users_in_UID_range":          {filter: types.UserFilter{MinUID: 2000, MaxUID: 3333}, wantUsers: []string{"user2", "user3"}},
+		"List_users_from_minimum_UID":      {filter: types.UserFilter{MinUID: 3000}, wantUsers: []string{"user3", "userwithoutbroker"}},
+		"List_first_page_of_users":         {page: types.Page{Size: 2}, wantUsers: []string{"user1", "user2"}, wantMore: true},
+		"List_next_page_of_users":          {page: types.Page{After: 2222, Size: 2}, wantUsers: []string{"user3", "userwithoutbroker"}},
+		"List_page_of_filtered_users":      {filter: types.UserFilter{BrokerID: "broker-id"}, page: types.Page{After: 1111, Size: 1}, wantUsers: []string{"user2"}, wantMore: true},
+		"List_no_users_if_none_match":      {filter: types.UserFilter{BrokerID: "other-broker-id"}},
+		"List_no_users_after_the_last_one": {page: types.Page{After: 4444}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "multiple_users_and_groups.db.yaml"), cacheDir)
+			config := users.DefaultConfig
+			config.UserInfoTTL = tc.userInfoTTL
+			m, err := users.NewManager(config, cacheDir)
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+			require.NoError(t, m.LockUser("user2", "test"), "Setup: could not lock user")
+			require.NoError(t, m.ConfirmUser("user1"), "Setup: could not confirm user")
+
+			got, more, err := m.ListUsers(tc.filter, tc.page)
+			require.NoError(t, err, "ListUsers should not return an error")
+
+			var names []string
+			for _, u := range got {
+				names = append(names, u.Name)
+				require.Equal(t, tc.userInfoTTL > 0 && u.Name != "user1", u.Stale, "Stale is not set as expected for user %q", u.Name)
+			}
+			require.Equal(t, tc.wantUsers, names, "ListUsers did not return the expected users")
+			require.Equal(t, tc.wantMore, more, "ListUsers did not return the expected value for more")
+		})
+	}
+}
+
+func TestListGroups(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		filter types.GroupFilter
+		page   types.Page
+
+		wantGroups []string
+		wantMore   bool
+	}{
+		"List_all_groups":                   {wantGroups: []string{"group1", "group2", "group3", "group4", "commongroup"}},
+		"List_groups_of_member":             {filter: types.GroupFilter{Member: "user2"}, wantGroups: []string{"group2", "commongroup"}},
+		"List_groups_in_GID_range":          {filter: types.GroupFilter{MinGID: 20000, MaxGID: 40000}, wantGroups: []string{"group2", "group3"}},
+		"List_first_page_of_groups":         {page: types.Page{Size: 3}, wantGroups: []string{"group1", "group2", "group3"}, wantMore: true},
+		"List_next_page_of_groups":          {page: types.Page{After: 33333, Size: 3}, wantGroups: []string{"group4", "commongroup"}},
+		"List_no_groups_if_none_match":      {filter: types.GroupFilter{Member: "doesnotexist"}},
+		"List_no_groups_after_the_last_one": {page: types.Page{After: 99999}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "multiple_users_and_groups.db.yaml"), cacheDir)
+			m, err := users.NewManager(users.DefaultConfig, cacheDir)
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			got, more, err := m.ListGroups(tc.filter, tc.page)
+			require.NoError(t, err, "ListGroups should not return an error")
+
+			var names []string
+			for _, g := range got {
+				names = append(names, g.Name)
+			}
+			require.Equal(t, tc.wantGroups, names, "ListGroups did not return the expected groups")
+			require.Equal(t, tc.wantMore, more, "ListGroups did not return the expected value for more")
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package localentries
 
+import "testing"
+
 // WithGroupPath overrides the default /etc/group path for tests.
 func WithGroupPath(p string) Option {
 	return func(o *options) {
@@ -7,16 +9,28 @@ func WithGroupPath(p string) Option {
 	}
 }
 
-// WithGpasswdCmd overrides gpasswd call with specific commands for tests.
-func WithGpasswdCmd(cmds []string) Option {
+// WithGetUsersFunc overrides the getusers func with a custom one for tests.
+func WithGetUsersFunc(getUsersFunc func() ([]string, error)) Option {
 	return func(o *options) {
-		o.gpasswdCmd = cmds
+		o.getUsersFunc = getUsersFunc
 	}
 }
 
-// WithGetUsersFunc overrides the getusers func with a custom one for tests.
-func WithGetUsersFunc(getUsersFunc func() ([]string, error)) Option {
+// WithNSSwitchPath overrides the default /etc/nsswitch.conf path for tests.
+func WithNSSwitchPath(p string) Option {
 	return func(o *options) {
-		o.getUsersFunc = getUsersFunc
+		o.nsswitchPath = p
 	}
 }
+
+// Z_ForTests_SetSkippedNSSSources overrides the sources of nsswitch.conf which are not queried, so that the NSS module
+// of the files source can be queried like the ones of the other sources.
+//
+// nolint:revive,nolintlint // We want to use underscores in the function name here.
+func Z_ForTests_SetSkippedNSSSources(t *testing.T, sources []string) {
+	t.Helper()
+
+	orig := skippedNSSSources
+	skippedNSSSources = sources
+	t.Cleanup(func() { skippedNSSSources = orig })
+}
@@ -23,7 +23,10 @@ import (
 type Passwd struct {
 	Name  string
 	UID   uint32
+	GID   uint32
 	Gecos string
+	Dir   string
+	Shell string
 }
 
 var getpwentMu sync.Mutex
@@ -73,7 +76,10 @@ func GetPasswdEntries() ([]Passwd, error) {
 		entries = append(entries, Passwd{
 			Name:  C.GoString(cPasswd.pw_name),
 			UID:   uint32(cPasswd.pw_uid),
+			GID:   uint32(cPasswd.pw_gid),
 			Gecos: C.GoString(cPasswd.pw_gecos),
+			Dir:   C.GoString(cPasswd.pw_dir),
+			Shell: C.GoString(cPasswd.pw_shell),
 		})
 	}
 
@@ -0,0 +1,478 @@
+package localentries
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ubuntu/authd/internal/sliceutils"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+	"golang.org/x/sys/unix"
+)
+
+// The locks and the files are named like the ones of shadow-utils, so that the tools editing the local users and groups,
+// like gpasswd or vigr, and authd don't edit the files at the same time.
+const (
+	// passwdLockName is the lock of all the files of the users and groups, taken by lckpwdf.
+	passwdLockName = ".pwd.lock"
+	// gshadowName is the shadow file of the group file, in the same directory.
+	gshadowName = "gshadow"
+	// lockSuffix is the suffix of the lock of a file, which is a link to a file containing the PID of its owner.
+	lockSuffix = ".lock"
+	// backupSuffix is the suffix of the backup of the file before its last modification.
+	backupSuffix = "-"
+	// newSuffix is the suffix of the new content of the file, renamed over it once written.
+	newSuffix = "+"
+	// stagingDirName is the directory, next to the files, in which their new content, their backups and their locks are
+	// written before being moved next to them, so that the sandbox of the daemon only allows it to replace the files
+	// of their directory rather than to write to them.
+	stagingDirName = ".authd-groups"
+)
+
+// passwdLockTimeout is how long to wait for the other tools to release the lock of the files, like lckpwdf does.
+var passwdLockTimeout = 15 * time.Second
+
+// editGroupFiles edits the members of the groups of the group file and of its shadow file, if any, with the same
+// locking and atomicity than shadow-utils: the files are locked while they are edited, and each file is replaced at
+// once by a new one with the same permissions, after a backup of it is made. edit returns the new members of a group,
+// or its members unchanged. The files are not written if no group changes.
+//
+// The groups of create which don't exist are created, with the members edit returns for them.
+//
+// Each change of the members is logged to the local-groups audit channel, with its result. In dry-run mode, the changes
+// are only logged.
+func editGroupFiles(ctx context.Context, groupPath string, dryRun bool, create *groupsCreation, edit func(group string, members []string) []string) (err error) {
+	defer decorate.OnError(&err, "could not edit local groups")
+
+	if dryRun {
+		return logGroupChanges(groupPath, create, edit)
+	}
+
+	dir := filepath.Dir(groupPath)
+	paths := []string{groupPath}
+	gshadowPath := filepath.Join(dir, gshadowName)
+	if fileExists(gshadowPath) {
+		paths = append(paths, gshadowPath)
+	}
+
+	unlock, err := lockPasswdFiles(dir)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, unlock()) }()
+
+	for _, p := range paths {
+		unlock, err := lockFile(p)
+		if err != nil {
+			return err
+		}
+		//nolint:gocritic // The files are unlocked once all of them are edited.
+		defer func() { err = errors.Join(err, unlock()) }()
+	}
+
+	// The members of the groups are edited in the group file, and the same changes are then made in the shadow file,
+	// so that the two files don't diverge.
+	var changes []memberChanges
+	defer func() { auditGroupChanges(ctx, changes, err) }()
+	var groups []string
+	var gids []uint32
+	if err := editColonFile(groupPath, 4, func(elems []string) {
+		groups = append(groups, elems[0])
+		if gid, err := strconv.ParseUint(elems[2], 10, 32); err == nil {
+			gids = append(gids, uint32(gid))
+		}
+
+		members := splitMembers(elems[3])
+		newMembers := edit(elems[0], members)
+		if slices.Equal(newMembers, members) {
+			return
+		}
+		elems[3] = strings.Join(newMembers, ",")
+		changes = append(changes, memberChanges{
+			group:   elems[0],
+			members: newMembers,
+			added:   sliceutils.Difference(newMembers, members),
+			removed: sliceutils.Difference(members, newMembers),
+		})
+	}, func() ([]string, error) {
+		if create == nil {
+			return nil, nil
+		}
+		created, lines, err := create.newGroups(groups, gids, edit)
+		changes = append(changes, created...)
+		return lines, err
+	}); err != nil {
+		return err
+	}
+
+	if len(paths) == 1 || len(changes) == 0 {
+		return nil
+	}
+	return editGShadowFile(gshadowPath, changes)
+}
+
+// auditGroupChanges logs each change of the members of the local groups to the local-groups audit channel, with its
+// result, so that the privileges granted and revoked by authd are traceable.
+func auditGroupChanges(ctx context.Context, changes []memberChanges, err error) {
+	result := "success"
+	if err != nil {
+		result = fmt.Sprintf("failure: %v", err)
+	}
+
+	audit := func(action, preposition, user, group string) {
+		ctx := log.WithFields(ctx, log.AuditField, "local-groups", log.UserField, user, log.GroupField, group,
+			"action", action, log.ResultField, result)
+		if err != nil {
+			log.Warningf(ctx, "Could not %s user %q %s local group %q: %v", action, user, preposition, group, err)
+			return
+		}
+		log.Infof(ctx, "Local groups: %s user %q %s local group %q", action, user, preposition, group)
+	}
+	for _, c := range changes {
+		if c.created {
+			ctx := log.WithFields(ctx, log.AuditField, "local-groups", log.GroupField, c.group, "action", "create",
+				log.ResultField, result)
+			if err != nil {
+				log.Warningf(ctx, "Could not create local group %q: %v", c.group, err)
+			} else {
+				log.Infof(ctx, "Local groups: create local group %q with GID %d", c.group, c.gid)
+			}
+		}
+		for _, u := range c.added {
+			audit("add", "to", u, c.group)
+		}
+		for _, u := range c.removed {
+			audit("remove", "from", u, c.group)
+		}
+	}
+}
+
+// memberChanges are the changes of the members of a group of the group file.
+type memberChanges struct {
+	group string
+	// created is true if the group was created, with the GID gid.
+	created bool
+	gid     uint32
+	// members are the new members of the group.
+	members []string
+	added   []string
+	removed []string
+}
+
+// editGShadowFile makes the changes of the members of the groups of the group file in its shadow file, whose fourth
+// field also lists the members of the groups. Like userdel does, the users removed from a group are removed from its
+// administrators too, so that they can't add themselves back with gpasswd. Like gpasswd does, an entry is added for the
+// groups which don't have one.
+func editGShadowFile(path string, changes []memberChanges) error {
+	missing := slices.Clone(changes)
+	return editColonFile(path, 4, func(elems []string) {
+		i := slices.IndexFunc(changes, func(c memberChanges) bool { return c.group == elems[0] })
+		if i < 0 {
+			return
+		}
+		c := changes[i]
+		missing = slices.DeleteFunc(missing, func(m memberChanges) bool { return m.group == c.group })
+
+		admins := splitMembers(elems[2])
+		if newAdmins := sliceutils.Difference(admins, c.removed); !slices.Equal(newAdmins, admins) {
+			elems[2] = strings.Join(newAdmins, ",")
+		}
+
+		members := splitMembers(elems[3])
+		newMembers := sliceutils.Difference(members, c.removed)
+		for _, m := range c.added {
+			if !slices.Contains(newMembers, m) {
+				newMembers = append(newMembers, m)
+			}
+		}
+		if !slices.Equal(newMembers, members) {
+			elems[3] = strings.Join(newMembers, ",")
+		}
+	}, func() ([]string, error) {
+		// The password of the new entries is locked, like the one of the groups created by groupadd.
+		var lines []string
+		for _, c := range missing {
+			lines = append(lines, strings.Join([]string{c.group, "!", "", strings.Join(c.members, ",")}, ":"))
+		}
+		return lines, nil
+	})
+}
+
+// logGroupChanges logs the changes of the members of the groups of the group file that edit would make, without
+// making them. The shadow file has the same members, so it's not read.
+func logGroupChanges(groupPath string, create *groupsCreation, edit func(group string, members []string) []string) error {
+	var groups []string
+	err := parseColonFile(groupPath, 4, func(elems []string) error {
+		groups = append(groups, elems[0])
+		members := splitMembers(elems[3])
+		newMembers := edit(elems[0], members)
+		if added := sliceutils.Difference(newMembers, members); len(added) > 0 {
+			log.Infof(context.TODO(), "Dry run: would add %s to local group %q", strings.Join(added, ", "), elems[0])
+		}
+		if removed := sliceutils.Difference(members, newMembers); len(removed) > 0 {
+			log.Infof(context.TODO(), "Dry run: would remove %s from local group %q", strings.Join(removed, ", "), elems[0])
+		}
+		return nil
+	})
+	if err != nil || create == nil {
+		return err
+	}
+
+	for _, g := range sliceutils.Difference(create.groups, groups) {
+		log.Infof(context.TODO(), "Dry run: would create local group %q with members %s", g, strings.Join(edit(g, nil), ", "))
+	}
+	return nil
+}
+
+// splitMembers returns the members of a group from the field listing them.
+func splitMembers(field string) []string {
+	var members []string
+	for _, m := range strings.Split(field, ",") {
+		if m != "" {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+// editColonFile calls edit with the fields of each non empty line of a colon separated file, which it can change, then
+// appends the lines returned by extraLines, if any, and replaces the file if any line changed.
+func editColonFile(path string, numFields int, edit func(elems []string), extraLines func() ([]string, error)) error {
+	d, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var changed bool
+	var lines []string
+	if len(d) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(d), "\n"), "\n")
+	}
+	for i, l := range lines {
+		t := strings.TrimSpace(l)
+		if t == "" {
+			continue
+		}
+		elems := strings.Split(t, ":")
+		if len(elems) != numFields {
+			return fmt.Errorf("malformed entry in %s (should have %d separators): %q", path, numFields-1, t)
+		}
+		edit(elems)
+		if newLine := strings.Join(elems, ":"); newLine != t {
+			lines[i] = newLine
+			changed = true
+		}
+	}
+	if extraLines != nil {
+		extra, err := extraLines()
+		if err != nil {
+			return err
+		}
+		if len(extra) > 0 {
+			lines = append(lines, extra...)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	return replaceFile(path, d, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// PrepareSandbox creates what the edits of the local groups write to, which must exist before the daemon is sandboxed,
+// as the sandbox only allows writing to existing paths. It returns the paths they write to, which are the lock of the
+// files of the users and groups and the staging directory, and the directory of the files whose files they replace.
+func PrepareSandbox() (writablePaths []string, replacedDir string, err error) {
+	defer decorate.OnError(&err, "could not prepare the edits of the local groups")
+
+	dir := filepath.Dir(defaultOptions.groupPath)
+	staging, err := stagingDir(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	// The lock is written in place, like lckpwdf does, and is never removed.
+	lockPath := filepath.Join(dir, passwdLockName)
+	f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := f.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return []string{lockPath, staging}, dir, nil
+}
+
+// stagingDir returns the staging directory of the files of dir, creating it if it doesn't exist.
+func stagingDir(dir string) (string, error) {
+	staging := filepath.Join(dir, stagingDirName)
+	if err := os.Mkdir(staging, 0700); err != nil && !errors.Is(err, fs.ErrExist) {
+		return "", err
+	}
+	return staging, nil
+}
+
+// replaceFile replaces the content of the file, which was oldContent, with newContent. Like shadow-utils, the old
+// content is kept in a backup, and the new one is written to a temporary file with the owner and the permissions of the
+// file, which is then renamed over it. Both are written in the staging directory, and then moved next to the file.
+func replaceFile(path string, oldContent, newContent []byte) (err error) {
+	defer decorate.OnError(&err, "could not write %s", path)
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	staging, err := stagingDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(path)
+
+	backupPath := filepath.Join(staging, name+backupSuffix)
+	if err := writeFileLike(backupPath, oldContent, fi); err != nil {
+		return err
+	}
+	if err := os.Rename(backupPath, path+backupSuffix); err != nil {
+		_ = os.Remove(backupPath)
+		return err
+	}
+	newPath := filepath.Join(staging, name+newSuffix)
+	if err := writeFileLike(newPath, newContent, fi); err != nil {
+		return err
+	}
+	if err := os.Rename(newPath, path); err != nil {
+		_ = os.Remove(newPath)
+		return err
+	}
+
+	// The rename must be on disk too.
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// writeFileLike writes the content to the file at path, with the same owner and permissions as the file described by
+// fi, and syncs it to disk.
+func writeFileLike(path string, content []byte, fi os.FileInfo) (err error) {
+	_ = os.Remove(path)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}()
+
+	// The owner is set before the permissions, as changing it can clear some of them.
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && (int(st.Uid) != os.Geteuid() || int(st.Gid) != os.Getegid()) {
+		if err := f.Chown(int(st.Uid), int(st.Gid)); err != nil {
+			return err
+		}
+	}
+	if err := f.Chmod(fi.Mode().Perm()); err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, bytes.NewReader(content)); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// lockPasswdFiles takes the lock of all the files of the users and groups in dir, like lckpwdf does, waiting for the
+// other tools to release it for up to passwdLockTimeout. The lock is only released by the returned function.
+func lockPasswdFiles(dir string) (unlock func() error, err error) {
+	lockPath := filepath.Join(dir, passwdLockName)
+	f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := unix.Flock_t{Type: unix.F_WRLCK, Whence: io.SeekStart}
+	deadline := time.Now().Add(passwdLockTimeout)
+	for {
+		err := unix.FcntlFlock(f.Fd(), unix.F_SETLK, &lock)
+		if err == nil {
+			return f.Close, nil
+		}
+		if !errors.Is(err, unix.EAGAIN) && !errors.Is(err, unix.EACCES) {
+			_ = f.Close()
+			return nil, fmt.Errorf("could not lock %s: %v", lockPath, err)
+		}
+		if time.Now().After(deadline) {
+			_ = f.Close()
+			return nil, fmt.Errorf("%s is locked by another process", lockPath)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// lockFile takes the lock of the file, like shadow-utils does: it's a link to a file containing the PID of the process
+// owning it, which is taken over if that process doesn't exist anymore. The file containing the PID is written in the
+// staging directory.
+func lockFile(path string) (unlock func() error, err error) {
+	lockPath := path + lockSuffix
+	staging, err := stagingDir(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("could not create lock of %s: %v", path, err)
+	}
+	pidPath := filepath.Join(staging, fmt.Sprintf("%s.%d", filepath.Base(path), os.Getpid()))
+	if err := os.WriteFile(pidPath, []byte(strconv.Itoa(os.Getpid())), 0600); err != nil {
+		return nil, fmt.Errorf("could not create lock of %s: %v", path, err)
+	}
+	defer os.Remove(pidPath)
+
+	for range 2 {
+		err := os.Link(pidPath, lockPath)
+		if err == nil {
+			return func() error { return os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("could not lock %s: %v", path, err)
+		}
+		if !isStaleLock(lockPath) {
+			break
+		}
+		if err := os.Remove(lockPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("could not remove stale lock of %s: %v", path, err)
+		}
+	}
+
+	return nil, fmt.Errorf("%s is locked by another process", path)
+}
+
+// isStaleLock returns true if the process owning the lock doesn't exist anymore.
+func isStaleLock(lockPath string) bool {
+	d, err := os.ReadFile(lockPath)
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(d)))
+	if err != nil || pid <= 0 {
+		return false
+	}
+	return errors.Is(unix.Kill(pid, 0), unix.ESRCH)
+}
+
+// fileExists returns true if the file at path exists.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
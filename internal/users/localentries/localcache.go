@@ -0,0 +1,103 @@
+package localentries
+
+import (
+	"os"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// fileState identifies a version of a file. The files of the users and groups are replaced by renaming a new file over
+// them, which changes the inode, and are otherwise modified in place, which changes the modification time or the size.
+type fileState struct {
+	ino     uint64
+	size    int64
+	modTime time.Time
+}
+
+// statFile returns the state of the file at path.
+func statFile(path string) (fileState, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	s := fileState{size: fi.Size(), modTime: fi.ModTime()}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		s.ino = st.Ino
+	}
+	return s, nil
+}
+
+// fileCache caches the entries parsed from files, by path, until the files change.
+type fileCache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]cachedEntries[T]
+}
+
+type cachedEntries[T any] struct {
+	state   fileState
+	entries []T
+}
+
+// get returns the entries of the file at path, only calling parse if the file changed since it was last parsed.
+func (c *fileCache[T]) get(path string, parse func() ([]T, error)) ([]T, error) {
+	state, err := statFile(path)
+	if err != nil {
+		// Let parse return the error, if the file can't be read.
+		return parse()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.entries[path]; ok && cached.state == state {
+		return slices.Clone(cached.entries), nil
+	}
+
+	entries, err := parse()
+	if err != nil {
+		return nil, err
+	}
+
+	// The file could have been changed while being parsed, in which case the entries are not cached, so that they are
+	// parsed again on the next call.
+	if after, err := statFile(path); err != nil || after != state {
+		delete(c.entries, path)
+		return entries, nil
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[string]cachedEntries[T])
+	}
+	c.entries[path] = cachedEntries[T]{state: state, entries: entries}
+	return slices.Clone(entries), nil
+}
+
+var (
+	usersCache  fileCache[Passwd]
+	groupsCache fileCache[Group]
+)
+
+// CachedLocalUsers returns the users defined in the local passwd file, like LocalUsers does, but only parses the file
+// again if it changed since the last call. It's meant for the checks done on every login, like the collision checks.
+func CachedLocalUsers(args ...Option) ([]Passwd, error) {
+	opts := defaultOptions
+	for _, arg := range args {
+		arg(&opts)
+	}
+
+	return usersCache.get(opts.passwdPath, func() ([]Passwd, error) { return LocalUsers(args...) })
+}
+
+// CachedLocalGroups returns the groups defined in the local group file, like LocalGroups does, but only parses the
+// file again if it changed since the last call. It's meant for the checks done on every login, like the collision
+// checks.
+func CachedLocalGroups(args ...Option) ([]Group, error) {
+	opts := defaultOptions
+	for _, arg := range args {
+		arg(&opts)
+	}
+
+	return groupsCache.get(opts.groupPath, func() ([]Group, error) { return LocalGroups(args...) })
+}
@@ -0,0 +1,134 @@
+package localentries_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users/localentries"
+)
+
+func TestCachedLocalUsers(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		passwdFilePath string
+		change         string
+
+		wantErr bool
+	}{
+		"Return_all_users_of_the_passwd_file":         {passwdFilePath: "valid.passwd"},
+		"Return_new_users_if_file_is_changed":         {passwdFilePath: "valid.passwd", change: "append"},
+		"Return_new_users_if_file_is_replaced":        {passwdFilePath: "valid.passwd", change: "replace"},
+		"Return_copy_of_users_which_can_be_modified":  {passwdFilePath: "valid.passwd", change: "modify_result"},
+		"Error_if_file_is_removed_after_being_cached": {passwdFilePath: "valid.passwd", change: "remove", wantErr: true},
+
+		"Error_on_missing_passwd_file":        {passwdFilePath: "does_not_exists.passwd", wantErr: true},
+		"Error_when_passwd_file_is_malformed": {passwdFilePath: "malformed_file.passwd", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			passwdPath := filepath.Join(t.TempDir(), "passwd")
+			if content, err := os.ReadFile(filepath.Join("testdata", tc.passwdFilePath)); err == nil {
+				require.NoError(t, os.WriteFile(passwdPath, content, 0600), "Setup: could not copy passwd file")
+			}
+			opt := localentries.WithPasswdPath(passwdPath)
+
+			if tc.change != "" {
+				got, err := localentries.CachedLocalUsers(opt)
+				require.NoError(t, err, "Setup: CachedLocalUsers should not have failed")
+				changeFile(t, passwdPath, tc.change, "newuser:x:2000:2000::/home/newuser:/bin/bash\n")
+				if tc.change == "modify_result" {
+					got[0].Name = "modified"
+				}
+			}
+
+			got, err := localentries.CachedLocalUsers(opt)
+			if tc.wantErr {
+				require.Error(t, err, "CachedLocalUsers should have failed")
+				return
+			}
+			require.NoError(t, err, "CachedLocalUsers should not have failed")
+
+			want, err := localentries.LocalUsers(opt)
+			require.NoError(t, err, "Setup: LocalUsers should not have failed")
+			require.Equal(t, want, got, "CachedLocalUsers should return the users of the passwd file")
+		})
+	}
+}
+
+func TestCachedLocalGroups(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		groupFilePath string
+		change        string
+
+		wantErr bool
+	}{
+		"Return_all_groups_of_the_group_file":         {groupFilePath: "users_in_our_groups.group"},
+		"Return_new_groups_if_file_is_changed":        {groupFilePath: "users_in_our_groups.group", change: "append"},
+		"Return_new_groups_if_file_is_replaced":       {groupFilePath: "users_in_our_groups.group", change: "replace"},
+		"Return_copy_of_groups_which_can_be_modified": {groupFilePath: "users_in_our_groups.group", change: "modify_result"},
+		"Error_if_file_is_removed_after_being_cached": {groupFilePath: "users_in_our_groups.group", change: "remove", wantErr: true},
+
+		"Error_on_missing_group_file":        {groupFilePath: "does_not_exists.group", wantErr: true},
+		"Error_when_group_file_is_malformed": {groupFilePath: "malformed_file.group", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			groupPath := filepath.Join(t.TempDir(), "group")
+			if content, err := os.ReadFile(filepath.Join("testdata", tc.groupFilePath)); err == nil {
+				require.NoError(t, os.WriteFile(groupPath, content, 0600), "Setup: could not copy group file")
+			}
+			opt := localentries.WithGroupPath(groupPath)
+
+			if tc.change != "" {
+				got, err := localentries.CachedLocalGroups(opt)
+				require.NoError(t, err, "Setup: CachedLocalGroups should not have failed")
+				changeFile(t, groupPath, tc.change, "newgroup:x:2000:\n")
+				if tc.change == "modify_result" {
+					got[0].Name = "modified"
+				}
+			}
+
+			got, err := localentries.CachedLocalGroups(opt)
+			if tc.wantErr {
+				require.Error(t, err, "CachedLocalGroups should have failed")
+				return
+			}
+			require.NoError(t, err, "CachedLocalGroups should not have failed")
+
+			want, err := localentries.LocalGroups(opt)
+			require.NoError(t, err, "Setup: LocalGroups should not have failed")
+			require.Equal(t, want, got, "CachedLocalGroups should return the groups of the group file")
+		})
+	}
+}
+
+// changeFile changes the file after its entries were cached: "append" appends the line in place, "replace" renames a
+// new file with the line over it, and "remove" removes it.
+func changeFile(t *testing.T, path, change, line string) {
+	t.Helper()
+
+	switch change {
+	case "append":
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		require.NoError(t, err, "Setup: could not open file")
+		_, err = f.WriteString(line)
+		require.NoError(t, err, "Setup: could not append to file")
+		require.NoError(t, f.Close(), "Setup: could not close file")
+	case "replace":
+		content, err := os.ReadFile(path)
+		require.NoError(t, err, "Setup: could not read file")
+		require.NoError(t, os.WriteFile(path+".new", append(content, line...), 0600), "Setup: could not write new file")
+		require.NoError(t, os.Rename(path+".new", path), "Setup: could not replace file")
+	case "remove":
+		require.NoError(t, os.Remove(path), "Setup: could not remove file")
+	}
+}
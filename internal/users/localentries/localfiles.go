@@ -0,0 +1,126 @@
+package localentries
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ubuntu/decorate"
+)
+
+// WithPasswdPath overrides the default /etc/passwd path.
+func WithPasswdPath(p string) Option {
+	return func(o *options) {
+		o.passwdPath = p
+	}
+}
+
+// LocalUsers returns the users defined in the local passwd file.
+//
+// Contrary to GetPasswdEntries, it doesn't go through NSS, so it only returns the users which are managed by the system
+// and not the ones provided by authd or any other NSS source.
+func LocalUsers(args ...Option) (users []Passwd, err error) {
+	opts := defaultOptions
+	for _, arg := range args {
+		arg(&opts)
+	}
+
+	defer decorate.OnError(&err, "could not read local users from %q", opts.passwdPath)
+
+	// Format of a line composing the passwd file is:
+	// name:password:uid:gid:gecos:home:shell
+	err = parseColonFile(opts.passwdPath, 7, func(elems []string) error {
+		uid, err := strconv.ParseUint(elems[2], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid UID for user %q: %v", elems[0], err)
+		}
+		gid, err := strconv.ParseUint(elems[3], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid GID for user %q: %v", elems[0], err)
+		}
+		users = append(users, Passwd{
+			Name:  elems[0],
+			UID:   uint32(uid),
+			GID:   uint32(gid),
+			Gecos: elems[4],
+			Dir:   elems[5],
+			Shell: elems[6],
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// LocalGroups returns the groups defined in the local group file.
+//
+// Contrary to GetGroupEntries, it doesn't go through NSS, so it only returns the groups which are managed by the system
+// and not the ones provided by authd or any other NSS source.
+func LocalGroups(args ...Option) (groups []Group, err error) {
+	opts := defaultOptions
+	for _, arg := range args {
+		arg(&opts)
+	}
+
+	defer decorate.OnError(&err, "could not read local groups from %q", opts.groupPath)
+
+	localGroupsMu.RLock()
+	defer localGroupsMu.RUnlock()
+
+	// Format of a line composing the group file is:
+	// group_name:password:group_id:user1,…,usern
+	err = parseColonFile(opts.groupPath, 4, func(elems []string) error {
+		gid, err := strconv.ParseUint(elems[2], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid GID for group %q: %v", elems[0], err)
+		}
+		groups = append(groups, Group{Name: elems[0], GID: uint32(gid), Passwd: elems[1]})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// LocalUserGroups returns the groups of the local group file which have the user as member.
+func LocalUserGroups(user string, args ...Option) ([]string, error) {
+	opts := defaultOptions
+	for _, arg := range args {
+		arg(&opts)
+	}
+
+	return existingLocalGroups(user, opts.groupPath)
+}
+
+// parseColonFile calls parseLine with the fields of each non empty line of a colon separated file like /etc/passwd.
+func parseColonFile(path string, numFields int, parseLine func(elems []string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		t := strings.TrimSpace(scanner.Text())
+		if t == "" {
+			continue
+		}
+		elems := strings.Split(t, ":")
+		if len(elems) != numFields {
+			return fmt.Errorf("malformed entry in %s (should have %d separators): %q", path, numFields-1, t)
+		}
+		if err := parseLine(elems); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,71 @@
+package localentries_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users/localentries"
+)
+
+func TestLocalUsers(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		passwdFilePath string
+
+		wantErr bool
+	}{
+		"Return_all_users_of_the_passwd_file": {passwdFilePath: "valid.passwd"},
+
+		"Error_on_missing_passwd_file":        {passwdFilePath: "does_not_exists.passwd", wantErr: true},
+		"Error_when_passwd_file_is_malformed": {passwdFilePath: "malformed_file.passwd", wantErr: true},
+		"Error_when_passwd_file_has_bad_UID":  {passwdFilePath: "invalid_uid.passwd", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := localentries.LocalUsers(localentries.WithPasswdPath(filepath.Join("testdata", tc.passwdFilePath)))
+			if tc.wantErr {
+				require.Error(t, err, "LocalUsers should have failed")
+				return
+			}
+			require.NoError(t, err, "LocalUsers should not have failed")
+
+			golden.CheckOrUpdateYAML(t, got)
+		})
+	}
+}
+
+func TestLocalGroups(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		groupFilePath string
+
+		wantErr bool
+	}{
+		"Return_all_groups_of_the_group_file":   {groupFilePath: "users_in_our_groups.group"},
+		"Group_file_with_empty_line_is_ignored": {groupFilePath: "empty_line.group"},
+
+		"Error_on_missing_group_file":        {groupFilePath: "does_not_exists.group", wantErr: true},
+		"Error_when_group_file_is_malformed": {groupFilePath: "malformed_file.group", wantErr: true},
+		"Error_when_group_file_has_bad_GID":  {groupFilePath: "invalid_gid.group", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := localentries.LocalGroups(localentries.WithGroupPath(filepath.Join("testdata", tc.groupFilePath)))
+			if tc.wantErr {
+				require.Error(t, err, "LocalGroups should have failed")
+				return
+			}
+			require.NoError(t, err, "LocalGroups should not have failed")
+
+			golden.CheckOrUpdateYAML(t, got)
+		})
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"slices"
 	"strings"
 	"sync"
@@ -19,24 +18,42 @@ import (
 
 var defaultOptions = options{
 	groupPath:    "/etc/group",
-	gpasswdCmd:   []string{"gpasswd"},
 	getUsersFunc: getPasswdUsernames,
+	passwdPath:   "/etc/passwd",
+	nsswitchPath: "/etc/nsswitch.conf",
 }
 
 type options struct {
+	// groupPath is the path of the group file, next to which are its shadow file and the locks of the files of the
+	// users and groups.
 	groupPath    string
-	gpasswdCmd   []string
 	getUsersFunc func() ([]string, error)
+	passwdPath   string
+	nsswitchPath string
+	dryRun       bool
+
+	missingGroupAction func(group string) MissingGroupAction
+	createdGIDMin      uint32
+	createdGIDMax      uint32
 }
 
 // Option represents an optional function to override UpdateLocalGroups default values.
 type Option func(*options)
 
+// WithDryRun only logs the changes of the local groups, without making them.
+func WithDryRun(dryRun bool) Option {
+	return func(o *options) {
+		o.dryRun = dryRun
+	}
+}
+
 var localGroupsMu = &sync.RWMutex{}
 
-// Update synchronizes for the given user the local group list with the current group list from UserInfo.
-func Update(username string, newGroups []string, oldGroups []string, args ...Option) (err error) {
-	log.Debugf(context.TODO(), "Updating local groups for user %q, new groups: %v, old groups: %v", username, newGroups, oldGroups)
+// Update synchronizes for the given user the local group list with the current group list from UserInfo. All the
+// groups are changed in a single locked edit of the group files, rather than group by group, so that the changes of
+// a login can't be interleaved with other edits of the files.
+func Update(ctx context.Context, username string, newGroups []string, oldGroups []string, args ...Option) (err error) {
+	log.Debugf(ctx, "Updating local groups for user %q, new groups: %v, old groups: %v", username, newGroups, oldGroups)
 	defer decorate.OnError(&err, "could not update local groups for user %q", username)
 
 	opts := defaultOptions
@@ -48,6 +65,10 @@ func Update(username string, newGroups []string, oldGroups []string, args ...Opt
 	if err != nil {
 		return err
 	}
+	create, err := groupsToCreate(username, sliceutils.Difference(newGroups, currentGroups), opts)
+	if err != nil {
+		return err
+	}
 
 	localGroupsMu.Lock()
 	defer localGroupsMu.Unlock()
@@ -58,24 +79,73 @@ func Update(username string, newGroups []string, oldGroups []string, args ...Opt
 	groupsToRemove = sliceutils.Intersection(groupsToRemove, currentGroups)
 	log.Debugf(context.TODO(), "Removing from groups: %v", groupsToRemove)
 
-	for _, g := range groupsToRemove {
-		args := opts.gpasswdCmd[1:]
-		args = append(args, "--delete", username, g)
-		if err := runGPasswd(opts.gpasswdCmd[0], args...); err != nil {
-			return err
-		}
+	if len(groupsToAdd) == 0 && len(groupsToRemove) == 0 {
+		return nil
 	}
-	for _, g := range groupsToAdd {
-		args := opts.gpasswdCmd[1:]
-		args = append(args, "--add", username, g)
-		if err := runGPasswd(opts.gpasswdCmd[0], args...); err != nil {
-			return err
+
+	missingGroups := slices.Clone(groupsToAdd)
+	err = editGroupFiles(ctx, opts.groupPath, opts.dryRun, create, func(group string, members []string) []string {
+		missingGroups = slices.DeleteFunc(missingGroups, func(g string) bool { return g == group })
+		if slices.Contains(groupsToAdd, group) && !slices.Contains(members, username) {
+			return append(slices.Clone(members), username)
 		}
+		if slices.Contains(groupsToRemove, group) {
+			return removeMember(members, username)
+		}
+		return members
+	})
+	if err != nil {
+		return err
+	}
+
+	// Like gpasswd did, the groups which don't exist and are not created are ignored.
+	if len(missingGroups) > 0 {
+		log.Infof(context.TODO(), "Ignoring local groups which don't exist: %v", missingGroups)
 	}
 
 	return nil
 }
 
+// groupsToCreate returns the groups the user is added to which don't exist and are created, according to the action
+// of the options for the missing groups, or nil if there is none. It fails if the action for one of them is to fail.
+func groupsToCreate(username string, groups []string, opts options) (*groupsCreation, error) {
+	if opts.missingGroupAction == nil {
+		return nil, nil
+	}
+
+	withOpts := func(o *options) { *o = opts }
+	localGroups, err := LocalGroups(withOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	create := groupsCreation{gidMin: opts.createdGIDMin, gidMax: opts.createdGIDMax}
+	for _, g := range groups {
+		if slices.ContainsFunc(localGroups, func(l Group) bool { return l.Name == g }) {
+			continue
+		}
+		switch opts.missingGroupAction(g) {
+		case CreateMissingGroup:
+			create.groups = append(create.groups, g)
+		case FailOnMissingGroup:
+			return nil, fmt.Errorf("local group %q of user %q does not exist", g, username)
+		}
+	}
+	if len(create.groups) == 0 {
+		return nil, nil
+	}
+
+	// The created groups can't get the GID of a group of another NSS source either.
+	create.otherGIDUsed = func(gid uint32) bool { return len(otherGroupsByGID(opts.nsswitchPath, gid)) > 0 }
+
+	return &create, nil
+}
+
+// removeMember returns the members of a group without the user.
+func removeMember(members []string, user string) []string {
+	return slices.DeleteFunc(slices.Clone(members), func(m string) bool { return m == user })
+}
+
 // getPasswdUsernames gets the passwd entries and returns their usernames.
 func getPasswdUsernames() ([]string, error) {
 	var usernames []string
@@ -145,18 +215,49 @@ func CleanUser(user string, args ...Option) (err error) {
 	if err != nil {
 		return err
 	}
+	if len(groups) == 0 {
+		return nil
+	}
 
 	localGroupsMu.Lock()
 	defer localGroupsMu.Unlock()
-	for _, group := range groups {
-		args := opts.gpasswdCmd[1:]
-		args = append(args, "--delete", user, group)
-		if err := runGPasswd(opts.gpasswdCmd[0], args...); err != nil {
-			return err
-		}
+
+	return editGroupFiles(context.TODO(), opts.groupPath, opts.dryRun, nil, func(_ string, members []string) []string {
+		return removeMember(members, user)
+	})
+}
+
+// RemoveUser removes the user from the given local groups, if it's still a member of them.
+func RemoveUser(ctx context.Context, user string, groups []string, args ...Option) (err error) {
+	defer decorate.OnError(&err, "could not remove user %q from local groups %v", user, groups)
+
+	if len(groups) == 0 {
+		return nil
 	}
 
-	return nil
+	opts := defaultOptions
+	for _, arg := range args {
+		arg(&opts)
+	}
+
+	currentGroups, err := existingLocalGroups(user, opts.groupPath)
+	if err != nil {
+		return err
+	}
+	groups = sliceutils.Intersection(groups, currentGroups)
+	if len(groups) == 0 {
+		return nil
+	}
+
+	localGroupsMu.Lock()
+	defer localGroupsMu.Unlock()
+
+	return editGroupFiles(ctx, opts.groupPath, opts.dryRun, nil, func(group string, members []string) []string {
+		if !slices.Contains(groups, group) {
+			return members
+		}
+		return removeMember(members, user)
+	})
 }
 
 // Clean removes all unexistent users from the local groups.
@@ -186,66 +287,11 @@ func Clean(args ...Option) (err error) {
 		return errors.New("no existing users found, local groups won't be cleaned")
 	}
 
-	// Get the list of local groups
-	f, err := os.Open(opts.groupPath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	// Format of a line composing the group file is:
-	// group_name:password:group_id:user1,…,usern
-	var delOps [][]string
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		t := strings.TrimSpace(scanner.Text())
-		if t == "" {
-			continue
-		}
-		elems := strings.Split(t, ":")
-		if len(elems) != 4 {
-			return fmt.Errorf("malformed entry in group file (should have 4 separators): %q", t)
-		}
-
-		groupName := elems[0]
-		users := strings.Split(elems[3], ",")
-		for _, user := range users {
-			if _, ok := existingUsers[user]; ok {
-				continue
-			}
-
+	return editGroupFiles(context.TODO(), opts.groupPath, opts.dryRun, nil, func(_ string, members []string) []string {
+		return slices.DeleteFunc(slices.Clone(members), func(m string) bool {
 			// User doesn't exist anymore, remove it from the group
-			args := opts.gpasswdCmd[1:]
-			delOps = append(delOps, append(args, "--delete", user, groupName))
-		}
-	}
-	if scanner.Err() != nil {
-		return scanner.Err()
-	}
-	f.Close()
-
-	// Execute the deletion operations
-	for _, op := range delOps {
-		if cmdErr := runGPasswd(opts.gpasswdCmd[0], op...); cmdErr != nil {
-			err = errors.Join(err, cmdErr)
-		}
-	}
-
-	return err
-}
-
-// runGPasswd is a wrapper to cmdName ignoring exit code 3, meaning that the group doesn't exist.
-// Note: it’s the same return code for user not existing, but it’s something we are in control of as we
-// are responsible for the user itself and parsing the output is not desired.
-func runGPasswd(cmdName string, args ...string) error {
-	cmd := exec.Command(cmdName, args...)
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		if cmd.ProcessState.ExitCode() == 3 {
-			log.Infof(context.TODO(), "ignoring gpasswd error: %s", out)
-			return nil
-		}
-		return fmt.Errorf("%q returned: %v\nOutput: %s", strings.Join(cmd.Args, " "), err, out)
-	}
-	return nil
+			_, ok := existingUsers[m]
+			return !ok
+		})
+	})
 }
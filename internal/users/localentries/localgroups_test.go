@@ -1,14 +1,21 @@
 package localentries_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/ubuntu/authd/internal/testutils/golden"
 	"github.com/ubuntu/authd/internal/users/localentries"
 	localentriestestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+	"github.com/ubuntu/authd/log"
 )
 
 func TestUpdatelocalentries(t *testing.T) {
@@ -17,9 +24,13 @@ func TestUpdatelocalentries(t *testing.T) {
 	tests := map[string]struct {
 		username string
 
-		newGroups     []string
-		oldGroups     []string
-		groupFilePath string
+		newGroups       []string
+		oldGroups       []string
+		groupFilePath   string
+		gshadowFilePath string
+		lockedBy        string
+		dryRun          bool
+		missingGroups   localentries.MissingGroupAction
 
 		wantErr bool
 	}{
@@ -50,11 +61,28 @@ func TestUpdatelocalentries(t *testing.T) {
 		"User_is_removed_from_old_groups_but_not_from_other_groups": {newGroups: []string{}, oldGroups: []string{"localgroup3"}, groupFilePath: "user_in_both_groups.group"},
 		"User_is_not_removed_from_groups_they_are_not_part_of":      {newGroups: []string{}, oldGroups: []string{"localgroup2"}, groupFilePath: "user_in_one_group.group"},
 
+		// Shadow file and locks
+		"Update_user_in_group_and_shadow_files":  {groupFilePath: "user_in_many_groups.group", gshadowFilePath: "user_in_many_groups.gshadow"},
+		"Update_user_when_group_file_lock_stale": {groupFilePath: "user_in_many_groups.group", lockedBy: "999999999"},
+
+		"Remove_user_from_administrators_of_its_old_groups_in_shadow_file": {oldGroups: []string{"localgroup2"}, groupFilePath: "user_in_many_groups.group", gshadowFilePath: "user_in_many_groups.gshadow"},
+		"Add_missing_entry_of_group_to_shadow_file":                        {groupFilePath: "user_in_many_groups.group", gshadowFilePath: "missing_group_entry.gshadow"},
+
+		// Missing groups
+		"Create_missing_group_in_group_and_shadow_files": {groupFilePath: "missing_group.group", gshadowFilePath: "missing_group_entry.gshadow", missingGroups: localentries.CreateMissingGroup},
+		"Dry_run_does_not_create_missing_group":          {groupFilePath: "missing_group.group", missingGroups: localentries.CreateMissingGroup, dryRun: true},
+		"Error_if_missing_group_fails_update":            {groupFilePath: "missing_group.group", missingGroups: localentries.FailOnMissingGroup, wantErr: true},
+
+		// Dry run
+		"Dry_run_does_not_change_group_and_shadow_files": {groupFilePath: "user_in_many_groups.group", gshadowFilePath: "user_in_many_groups.gshadow", dryRun: true},
+		"Dry_run_does_not_wait_for_lock_of_group_file":   {groupFilePath: "user_in_many_groups.group", lockedBy: "1", dryRun: true},
+
 		// Error cases
-		"Error_on_missing_groups_file":                {groupFilePath: "does_not_exists.group", wantErr: true},
-		"Error_when_groups_file_is_malformed":         {groupFilePath: "malformed_file.group", wantErr: true},
-		"Error_on_any_unignored_add_gpasswd_error":    {username: "gpasswdfail", groupFilePath: "no_users.group", wantErr: true},
-		"Error_on_any_unignored_delete_gpasswd_error": {username: "gpasswdfail", groupFilePath: "gpasswdfail_in_deleted_group.group", wantErr: true},
+		"Error_on_missing_groups_file":          {groupFilePath: "does_not_exists.group", wantErr: true},
+		"Error_when_groups_file_is_malformed":   {groupFilePath: "malformed_file.group", wantErr: true},
+		"Error_when_groups_file_is_locked":      {groupFilePath: "user_in_many_groups.group", lockedBy: "1", wantErr: true},
+		"Error_when_groups_file_lock_is_broken": {groupFilePath: "user_in_many_groups.group", lockedBy: "notapid", wantErr: true},
+		"Error_on_missing_groups_file_dry_run":  {groupFilePath: "does_not_exists.group", dryRun: true, wantErr: true},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -71,22 +99,107 @@ func TestUpdatelocalentries(t *testing.T) {
 				tc.username = ""
 			}
 
-			destCmdsFile := filepath.Join(t.TempDir(), "gpasswd.output")
+			groupFilePath := copyGroupFiles(t, tc.groupFilePath, tc.gshadowFilePath)
+			if tc.lockedBy != "" {
+				err := os.WriteFile(groupFilePath+".lock", []byte(tc.lockedBy), 0600)
+				require.NoError(t, err, "Setup: could not lock group file")
+			}
 
-			groupFilePath := filepath.Join("testdata", tc.groupFilePath)
-			cmdArgs := []string{"env", "GO_WANT_HELPER_PROCESS=1",
-				os.Args[0], "-test.run=TestMockgpasswd", "--",
-				groupFilePath, destCmdsFile,
+			opts := []localentries.Option{localentries.WithGroupPath(groupFilePath), localentries.WithDryRun(tc.dryRun)}
+			if tc.missingGroups != localentries.IgnoreMissingGroup {
+				opts = append(opts, localentries.WithMissingGroups(func(string) localentries.MissingGroupAction { return tc.missingGroups }, 100, 999),
+					localentries.WithNSSwitchPath(filepath.Join(t.TempDir(), "nsswitch.conf")))
 			}
 
-			err := localentries.Update(tc.username, tc.newGroups, tc.oldGroups, localentries.WithGroupPath(groupFilePath), localentries.WithGpasswdCmd(cmdArgs))
+			err := localentries.Update(context.Background(), tc.username, tc.newGroups, tc.oldGroups, opts...)
 			if tc.wantErr {
 				require.Error(t, err, "Updatelocalentries should have failed")
 			} else {
 				require.NoError(t, err, "Updatelocalentries should not have failed")
 			}
+			if !tc.wantErr && tc.lockedBy == "" {
+				require.NoFileExists(t, groupFilePath+".lock", "Updatelocalentries should release the lock of the group file")
+			}
 
-			localentriestestutils.RequireGPasswdOutput(t, destCmdsFile, golden.Path(t))
+			localentriestestutils.RequireGroupFile(t, groupFilePath, golden.Path(t))
+			if tc.gshadowFilePath != "" {
+				localentriestestutils.RequireGroupFile(t, filepath.Join(filepath.Dir(groupFilePath), "gshadow"), golden.Path(t)+".gshadow")
+			}
+		})
+	}
+}
+
+func TestUpdatelocalentriesInSingleEdit(t *testing.T) {
+	t.Parallel()
+
+	groupFilePath := copyGroupFiles(t, "user_in_many_groups.group", "user_in_many_groups.gshadow")
+	gshadowFilePath := filepath.Join(filepath.Dir(groupFilePath), "gshadow")
+	wantGroup, err := os.ReadFile(groupFilePath)
+	require.NoError(t, err, "Setup: could not read group file")
+	wantGShadow, err := os.ReadFile(gshadowFilePath)
+	require.NoError(t, err, "Setup: could not read gshadow file")
+
+	err = localentries.Update(context.Background(), "myuser", []string{"localgroup1", "localgroup3", "localgroup4"},
+		[]string{"localgroup2"}, localentries.WithGroupPath(groupFilePath))
+	require.NoError(t, err, "Update should not have failed")
+
+	// Each file is backed up before being replaced, so the backups only have the original content if all the groups
+	// were changed at once.
+	gotGroup, err := os.ReadFile(groupFilePath + "-")
+	require.NoError(t, err, "The group file should have been backed up")
+	require.Equal(t, string(wantGroup), string(gotGroup), "All the groups should be changed in a single edit of the group file")
+	gotGShadow, err := os.ReadFile(gshadowFilePath + "-")
+	require.NoError(t, err, "The gshadow file should have been backed up")
+	require.Equal(t, string(wantGShadow), string(gotGShadow), "All the groups should be changed in a single edit of the gshadow file")
+}
+
+func TestUpdatelocalentriesAudit(t *testing.T) {
+	// The audit logs are captured from the default logger, so this test can't run in parallel.
+	tests := map[string]struct {
+		gshadowFilePath string
+
+		wantEvents []string
+		wantErr    bool
+	}{
+		"Audit_additions_and_removals": {wantEvents: []string{
+			"remove myuser localgroup2 success session1 broker1",
+			"add myuser localgroup3 success session1 broker1",
+		}},
+		"Audit_failure_of_changes": {gshadowFilePath: "malformed_file.group", wantErr: true, wantEvents: []string{
+			"remove myuser localgroup2 failure session1 broker1",
+			"add myuser localgroup3 failure session1 broker1",
+		}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			defaultLogger := slog.Default()
+			slog.SetDefault(slog.New(slog.NewJSONHandler(&out, nil)))
+			t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+			groupFilePath := copyGroupFiles(t, "user_in_many_groups.group", tc.gshadowFilePath)
+			ctx := log.WithFields(context.Background(), log.SessionIDField, "session1", log.BrokerField, "broker1")
+			err := localentries.Update(ctx, "myuser", []string{"localgroup1", "localgroup3"}, []string{"localgroup2"},
+				localentries.WithGroupPath(groupFilePath))
+			if tc.wantErr {
+				require.Error(t, err, "Update should have failed")
+			} else {
+				require.NoError(t, err, "Update should not have failed")
+			}
+
+			var events []string
+			dec := json.NewDecoder(&out)
+			for dec.More() {
+				var r map[string]string
+				require.NoError(t, dec.Decode(&r), "Logs should be valid JSON")
+				if r[log.AuditField] != "local-groups" {
+					continue
+				}
+				result, _, _ := strings.Cut(r[log.ResultField], ":")
+				events = append(events, strings.Join([]string{r["action"], r[log.UserField], r[log.GroupField], result,
+					r[log.SessionIDField], r[log.BrokerField]}, " "))
+			}
+			require.Equal(t, tc.wantEvents, events, "Unexpected audited changes of the local groups")
 		})
 	}
 }
@@ -107,28 +220,21 @@ func TestCleanlocalentries(t *testing.T) {
 		"Cleans_up_multiple_users_from_group":           {groupFilePath: "inactive_users_in_one_group.group"},
 		"Cleans_up_multiple_users_from_multiple_groups": {groupFilePath: "inactive_users_in_many_groups.group"},
 
-		"Error_if_there_is_no_active_user":            {groupFilePath: "user_in_many_groups.group", getUsersReturn: []string{}, wantErr: true},
-		"Error_on_missing_groups_file":                {groupFilePath: "does_not_exists.group", wantErr: true},
-		"Error_when_groups_file_is_malformed":         {groupFilePath: "malformed_file.group", wantErr: true},
-		"Error_on_any_unignored_delete_gpasswd_error": {groupFilePath: "gpasswdfail_in_deleted_group.group", wantErr: true},
+		"Error_if_there_is_no_active_user":    {groupFilePath: "user_in_many_groups.group", getUsersReturn: []string{}, wantErr: true},
+		"Error_on_missing_groups_file":        {groupFilePath: "does_not_exists.group", wantErr: true},
+		"Error_when_groups_file_is_malformed": {groupFilePath: "malformed_file.group", wantErr: true},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			destCmdsFile := filepath.Join(t.TempDir(), "gpasswd.output")
-			groupFilePath := filepath.Join("testdata", tc.groupFilePath)
-			gpasswdCmd := []string{"env", "GO_WANT_HELPER_PROCESS=1",
-				os.Args[0], "-test.run=TestMockgpasswd", "--",
-				groupFilePath, destCmdsFile,
-			}
+			groupFilePath := copyGroupFiles(t, tc.groupFilePath, "")
 
 			if tc.getUsersReturn == nil {
 				tc.getUsersReturn = []string{"myuser", "otheruser", "otheruser2", "otheruser3", "otheruser4"}
 			}
 
 			cleanupOptions := []localentries.Option{
-				localentries.WithGpasswdCmd(gpasswdCmd),
 				localentries.WithGroupPath(groupFilePath),
 				localentries.WithGetUsersFunc(func() ([]string, error) { return tc.getUsersReturn, nil }),
 			}
@@ -139,7 +245,7 @@ func TestCleanlocalentries(t *testing.T) {
 				require.NoError(t, err, "Cleanuplocalentries should not have failed")
 			}
 
-			localentriestestutils.RequireGPasswdOutput(t, destCmdsFile, golden.Path(t))
+			localentriestestutils.RequireGroupFile(t, groupFilePath, golden.Path(t))
 		})
 	}
 }
@@ -150,8 +256,8 @@ func TestCleanUserFromlocalentries(t *testing.T) {
 	tests := map[string]struct {
 		username string
 
-		groupFilePath   string
-		wantMockFailure bool
+		groupFilePath string
+		locked        bool
 
 		wantErr bool
 	}{
@@ -159,9 +265,9 @@ func TestCleanUserFromlocalentries(t *testing.T) {
 		"Cleans_up_user_from_multiple_groups":         {groupFilePath: "user_in_many_groups.group"},
 		"No_op_if_user_does_not_belong_to_any_groups": {username: "groupless"},
 
-		"Error_on_missing_groups_file":                {groupFilePath: "does_not_exists.group", wantErr: true},
-		"Error_when_groups_file_is_malformed":         {groupFilePath: "malformed_file.group", wantErr: true},
-		"Error_on_any_unignored_delete_gpasswd_error": {wantMockFailure: true, wantErr: true},
+		"Error_on_missing_groups_file":        {groupFilePath: "does_not_exists.group", wantErr: true},
+		"Error_when_groups_file_is_malformed": {groupFilePath: "malformed_file.group", wantErr: true},
+		"Error_when_groups_file_is_locked":    {locked: true, wantErr: true},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -174,18 +280,13 @@ func TestCleanUserFromlocalentries(t *testing.T) {
 				tc.groupFilePath = "user_in_one_group.group"
 			}
 
-			destCmdsFile := filepath.Join(t.TempDir(), "gpasswd.output")
-			groupFilePath := filepath.Join("testdata", tc.groupFilePath)
-			gpasswdCmd := []string{"env", "GO_WANT_HELPER_PROCESS=1",
-				os.Args[0], "-test.run=TestMockgpasswd", "--",
-				groupFilePath, destCmdsFile,
-			}
-			if tc.wantMockFailure {
-				gpasswdCmd = append(gpasswdCmd, "gpasswdfail")
+			groupFilePath := copyGroupFiles(t, tc.groupFilePath, "")
+			if tc.locked {
+				err := os.WriteFile(groupFilePath+".lock", []byte("1"), 0600)
+				require.NoError(t, err, "Setup: could not lock group file")
 			}
 
 			cleanupOptions := []localentries.Option{
-				localentries.WithGpasswdCmd(gpasswdCmd),
 				localentries.WithGroupPath(groupFilePath),
 			}
 			err := localentries.CleanUser(tc.username, cleanupOptions...)
@@ -195,11 +296,77 @@ func TestCleanUserFromlocalentries(t *testing.T) {
 				require.NoError(t, err, "CleanUserFromlocalentries should not have failed")
 			}
 
-			localentriestestutils.RequireGPasswdOutput(t, destCmdsFile, golden.Path(t))
+			localentriestestutils.RequireGroupFile(t, groupFilePath, golden.Path(t))
 		})
 	}
 }
 
-func TestMockgpasswd(t *testing.T) {
-	localentriestestutils.Mockgpasswd(t)
+func TestRemoveUserFromlocalentries(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		groups []string
+
+		groupFilePath string
+		locked        bool
+
+		wantErr bool
+	}{
+		"Removes_user_from_group":                             {groups: []string{"localgroup1"}},
+		"Removes_user_from_multiple_groups":                   {groups: []string{"localgroup1", "localgroup2"}},
+		"Removes_user_only_from_given_groups":                 {groups: []string{"localgroup2"}},
+		"No_op_if_user_does_not_belong_to_given_groups":       {groups: []string{"localgroup3", "doesnotexist"}},
+		"No_op_if_no_groups_are_given_even_with_missing_file": {groupFilePath: "does_not_exists.group"},
+
+		"Error_on_missing_groups_file":        {groups: []string{"localgroup1"}, groupFilePath: "does_not_exists.group", wantErr: true},
+		"Error_when_groups_file_is_malformed": {groups: []string{"localgroup1"}, groupFilePath: "malformed_file.group", wantErr: true},
+		"Error_when_groups_file_is_locked":    {groups: []string{"localgroup1"}, locked: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.groupFilePath == "" {
+				tc.groupFilePath = "user_in_many_groups.group"
+			}
+
+			groupFilePath := copyGroupFiles(t, tc.groupFilePath, "")
+			if tc.locked {
+				err := os.WriteFile(groupFilePath+".lock", []byte("1"), 0600)
+				require.NoError(t, err, "Setup: could not lock group file")
+			}
+
+			err := localentries.RemoveUser(context.Background(), "myuser", tc.groups, localentries.WithGroupPath(groupFilePath))
+			if tc.wantErr {
+				require.Error(t, err, "RemoveUser should have failed")
+			} else {
+				require.NoError(t, err, "RemoveUser should not have failed")
+			}
+
+			localentriestestutils.RequireGroupFile(t, groupFilePath, golden.Path(t))
+		})
+	}
+}
+
+// copyGroupFiles copies the group file, and the gshadow file if not empty, of testdata in a temporary directory, where
+// they are edited, and returns the path of the group file.
+func copyGroupFiles(t *testing.T, groupFile, gshadowFile string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	groupFilePath := filepath.Join(dir, "group")
+	files := map[string]string{groupFile: groupFilePath}
+	if gshadowFile != "" {
+		files[gshadowFile] = filepath.Join(dir, "gshadow")
+	}
+	for src, dst := range files {
+		d, err := os.ReadFile(filepath.Join("testdata", src))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		require.NoError(t, err, "Setup: could not read group file")
+		require.NoError(t, os.WriteFile(dst, d, 0644), "Setup: could not copy group file")
+	}
+
+	return groupFilePath
 }
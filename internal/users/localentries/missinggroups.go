@@ -0,0 +1,70 @@
+package localentries
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MissingGroupAction is what Update does when the user is added to a local group which doesn't exist.
+type MissingGroupAction int
+
+const (
+	// IgnoreMissingGroup doesn't add the user to the group, like gpasswd did.
+	IgnoreMissingGroup MissingGroupAction = iota
+	// CreateMissingGroup creates the group, with the user as its member.
+	CreateMissingGroup
+	// FailOnMissingGroup fails the update of the local groups of the user.
+	FailOnMissingGroup
+)
+
+// WithMissingGroups makes Update call action for each local group the user is added to which doesn't exist, to
+// decide whether it's ignored, created or fails the update. The groups are created with the highest free GID between
+// gidMin and gidMax, like groupadd --system does.
+func WithMissingGroups(action func(group string) MissingGroupAction, gidMin, gidMax uint32) Option {
+	return func(o *options) {
+		o.missingGroupAction = action
+		o.createdGIDMin = gidMin
+		o.createdGIDMax = gidMax
+	}
+}
+
+// groupsCreation are the groups editGroupFiles creates if they don't exist, and the GIDs they can get.
+type groupsCreation struct {
+	groups []string
+	gidMin uint32
+	gidMax uint32
+	// otherGIDUsed returns true if a group of the other NSS sources has the GID, which the created groups can't get
+	// either. The sources are queried for each GID, as enumerating them can be slow on large directories.
+	otherGIDUsed func(gid uint32) bool
+}
+
+// newGroups returns the changes and the lines of the group file of the groups to create which are not among the
+// existing groups, with the members edit returns for them and GIDs which are not used.
+func (c groupsCreation) newGroups(existing []string, usedGIDs []uint32, edit func(group string, members []string) []string) (changes []memberChanges, lines []string, err error) {
+	used := func(gid uint32) bool {
+		return slices.Contains(usedGIDs, gid) || (c.otherGIDUsed != nil && c.otherGIDUsed(gid))
+	}
+
+	gid := c.gidMax
+	for _, g := range c.groups {
+		if slices.Contains(existing, g) {
+			continue
+		}
+
+		for used(gid) && gid > c.gidMin {
+			gid--
+		}
+		if used(gid) {
+			return nil, nil, fmt.Errorf("no free GID between %d and %d for local group %q", c.gidMin, c.gidMax, g)
+		}
+		usedGIDs = append(usedGIDs, gid)
+
+		// Like groupadd does, the password of the group is in its shadow entry.
+		members := edit(g, nil)
+		lines = append(lines, strings.Join([]string{g, "x", fmt.Sprint(gid), strings.Join(members, ",")}, ":"))
+		changes = append(changes, memberChanges{group: g, created: true, gid: gid, members: members, added: members})
+	}
+
+	return changes, lines, nil
+}
@@ -0,0 +1,325 @@
+package localentries
+
+/*
+#cgo LDFLAGS: -ldl
+#include <dlfcn.h>
+#include <errno.h>
+#include <grp.h>
+#include <nss.h>
+#include <pwd.h>
+#include <stdlib.h>
+
+typedef enum nss_status (*nss_getpwuid_r_fn)(uid_t, struct passwd *, char *, size_t, int *);
+typedef enum nss_status (*nss_getgrgid_r_fn)(gid_t, struct group *, char *, size_t, int *);
+typedef enum nss_status (*nss_getgrnam_r_fn)(const char *, struct group *, char *, size_t, int *);
+
+static enum nss_status call_getpwuid_r(void *f, uid_t uid, struct passwd *pwd, char *buf, size_t len, int *errnop) {
+	return ((nss_getpwuid_r_fn)f)(uid, pwd, buf, len, errnop);
+}
+static enum nss_status call_getgrgid_r(void *f, gid_t gid, struct group *grp, char *buf, size_t len, int *errnop) {
+	return ((nss_getgrgid_r_fn)f)(gid, grp, buf, len, errnop);
+}
+static enum nss_status call_getgrnam_r(void *f, const char *name, struct group *grp, char *buf, size_t len, int *errnop) {
+	return ((nss_getgrnam_r_fn)f)(name, grp, buf, len, errnop);
+}
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// skippedNSSSources are the sources of nsswitch.conf which are not queried: files, whose files are read directly, compat,
+// which reads the same files, authd itself, to not resolve its users through itself, and systemd, which also serves
+// the users of authd through its userdb interface. The users systemd provides itself are dynamic users of services,
+// whose IDs are outside of the ranges of authd.
+var skippedNSSSources = []string{"files", "compat", "authd", "systemd"}
+
+// UsersByUID returns the users of the local passwd file with the UID, followed by the ones of the other sources of the
+// passwd database in nsswitch.conf, like extrausers or LDAP, except authd itself. Like NSS does, the first user with a
+// given name is kept, so the users of the passwd file take precedence.
+//
+// The other sources are queried for this UID only, as enumerating them can be slow, or not allowed, on large
+// directories. A source which is unavailable, like an LDAP server which can't be reached, is skipped.
+func UsersByUID(uid uint32, args ...Option) (users []Passwd, err error) {
+	defer decorate.OnError(&err, "could not get the users of UID %d of the NSS sources", uid)
+
+	opts := defaultOptions
+	for _, arg := range args {
+		arg(&opts)
+	}
+
+	localUsers, err := CachedLocalUsers(args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range localUsers {
+		if u.UID == uid {
+			users = append(users, u)
+		}
+	}
+
+	for _, source := range nssSources(opts.nsswitchPath, "passwd") {
+		u, found, err := nssUserByUID(source, uid)
+		if err != nil {
+			log.Warningf(context.Background(), "Skipping NSS source %q of the passwd database: %v", source, err)
+			continue
+		}
+		if found && !slices.ContainsFunc(users, func(e Passwd) bool { return e.Name == u.Name }) {
+			users = append(users, u)
+		}
+	}
+
+	return users, nil
+}
+
+// GroupsByGID returns the groups of the local group file with the GID, followed by the ones of the other sources of
+// the group database in nsswitch.conf, like extrausers or LDAP, except authd itself. Like NSS does, the first group with
+// a given name is kept, so the groups of the group file take precedence.
+//
+// The other sources are queried for this GID only, and skipped if they are unavailable, like in UsersByUID.
+func GroupsByGID(gid uint32, args ...Option) (groups []Group, err error) {
+	defer decorate.OnError(&err, "could not get the groups of GID %d of the NSS sources", gid)
+
+	opts := defaultOptions
+	for _, arg := range args {
+		arg(&opts)
+	}
+
+	localGroups, err := CachedLocalGroups(args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range localGroups {
+		if g.GID == gid {
+			groups = append(groups, g)
+		}
+	}
+
+	for _, g := range otherGroupsByGID(opts.nsswitchPath, gid) {
+		if !slices.ContainsFunc(groups, func(e Group) bool { return e.Name == g.Name }) {
+			groups = append(groups, g)
+		}
+	}
+
+	return groups, nil
+}
+
+// GroupByName returns the group with the name of the local group file, or else of the first of the other sources of
+// the group database in nsswitch.conf which has it, except authd itself. found is false if no source has it.
+//
+// The other sources are queried for this name only, and skipped if they are unavailable, like in UsersByUID.
+func GroupByName(name string, args ...Option) (group Group, found bool, err error) {
+	defer decorate.OnError(&err, "could not get the group %q of the NSS sources", name)
+
+	opts := defaultOptions
+	for _, arg := range args {
+		arg(&opts)
+	}
+
+	localGroups, err := CachedLocalGroups(args...)
+	if err != nil {
+		return Group{}, false, err
+	}
+	if i := slices.IndexFunc(localGroups, func(g Group) bool { return g.Name == name }); i >= 0 {
+		return localGroups[i], true, nil
+	}
+
+	for _, source := range nssSources(opts.nsswitchPath, "group") {
+		g, found, err := nssGroupByName(source, name)
+		if err != nil {
+			log.Warningf(context.Background(), "Skipping NSS source %q of the group database: %v", source, err)
+			continue
+		}
+		if found {
+			return g, true, nil
+		}
+	}
+
+	return Group{}, false, nil
+}
+
+// otherGroupsByGID returns the groups with the GID of the sources of the group database in nsswitch.conf which are
+// queried through their NSS module. A source which is unavailable is skipped.
+func otherGroupsByGID(nsswitchPath string, gid uint32) (groups []Group) {
+	for _, source := range nssSources(nsswitchPath, "group") {
+		g, found, err := nssGroupByGID(source, gid)
+		if err != nil {
+			log.Warningf(context.Background(), "Skipping NSS source %q of the group database: %v", source, err)
+			continue
+		}
+		if found {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// nssSources returns the sources of the database in the nsswitch.conf file which are queried through their NSS
+// module, in order. If the file can't be read, there is none, as the default source of glibc is files.
+func nssSources(nsswitchPath, database string) (sources []string) {
+	d, err := os.ReadFile(nsswitchPath)
+	if err != nil {
+		log.Debugf(context.Background(), "Could not read the NSS sources of the %s database: %v", database, err)
+		return nil
+	}
+
+	// Format of a line of the database in the nsswitch.conf file is:
+	// database: source1 [STATUS=action] source2 # comment
+	var line string
+	for _, l := range strings.Split(string(d), "\n") {
+		l, _, _ = strings.Cut(l, "#")
+		if db, s, ok := strings.Cut(l, ":"); ok && strings.TrimSpace(db) == database {
+			line = s
+		}
+	}
+
+	var inAction bool
+	for _, s := range strings.Fields(line) {
+		// The actions on the results of the sources, like [NOTFOUND=return], are not sources.
+		if strings.HasPrefix(s, "[") {
+			inAction = true
+		}
+		if inAction {
+			inAction = !strings.HasSuffix(s, "]")
+			continue
+		}
+		if slices.Contains(skippedNSSSources, s) {
+			continue
+		}
+		sources = append(sources, s)
+	}
+	return sources
+}
+
+var (
+	nssModulesMu sync.Mutex
+	// nssModules are the handles of the loaded NSS modules, by source. Like glibc does, they are never unloaded.
+	nssModules = make(map[string]unsafe.Pointer)
+)
+
+// nssFunction returns the function of the NSS module of the source, like _nss_ldap_getpwuid_r for the getpwuid_r
+// function of the ldap source. nssModulesMu must be held.
+func nssFunction(source, function string) (unsafe.Pointer, error) {
+	handle, ok := nssModules[source]
+	if !ok {
+		lib := C.CString(fmt.Sprintf("libnss_%s.so.2", source))
+		defer C.free(unsafe.Pointer(lib))
+		handle = C.dlopen(lib, C.RTLD_LAZY)
+		if handle == nil {
+			return nil, fmt.Errorf("could not load NSS module: %s", C.GoString(C.dlerror()))
+		}
+		nssModules[source] = handle
+	}
+
+	name := C.CString(fmt.Sprintf("_nss_%s_%s", source, function))
+	defer C.free(unsafe.Pointer(name))
+	f := C.dlsym(handle, name)
+	if f == nil {
+		return nil, fmt.Errorf("NSS module has no %s function", function)
+	}
+	return f, nil
+}
+
+// nssLookup looks an entry up in the source, calling lookup with the function of the module and a buffer for the
+// strings of the entry. The buffer is grown when it's too small. found is false if the source has no such entry.
+func nssLookup(source, function string, lookup func(f unsafe.Pointer, buf *C.char, size C.size_t, errnop *C.int) C.enum_nss_status) (found bool, err error) {
+	nssModulesMu.Lock()
+	defer nssModulesMu.Unlock()
+
+	f, err := nssFunction(source, function)
+	if err != nil {
+		return false, err
+	}
+
+	size := C.size_t(1024)
+	for {
+		buf := (*C.char)(C.malloc(size))
+		var errnop C.int
+		status := lookup(f, buf, size, &errnop)
+		C.free(unsafe.Pointer(buf))
+
+		switch status {
+		case C.NSS_STATUS_SUCCESS:
+			return true, nil
+		case C.NSS_STATUS_NOTFOUND:
+			return false, nil
+		case C.NSS_STATUS_TRYAGAIN:
+			if errnop != C.ERANGE || size >= 1<<20 {
+				return false, errors.New("source is temporarily unavailable")
+			}
+			size *= 2
+		default:
+			return false, fmt.Errorf("%s failed with status %d", function, status)
+		}
+	}
+}
+
+// nssUserByUID returns the user with the UID of the source.
+func nssUserByUID(source string, uid uint32) (u Passwd, found bool, err error) {
+	var pwd C.struct_passwd
+	found, err = nssLookup(source, "getpwuid_r", func(f unsafe.Pointer, buf *C.char, size C.size_t, errnop *C.int) C.enum_nss_status {
+		status := C.call_getpwuid_r(f, C.uid_t(uid), &pwd, buf, size, errnop)
+		if status == C.NSS_STATUS_SUCCESS {
+			// The strings of the entry are in the buffer, which is freed once this returns.
+			u = Passwd{
+				Name:  C.GoString(pwd.pw_name),
+				UID:   uint32(pwd.pw_uid),
+				GID:   uint32(pwd.pw_gid),
+				Gecos: C.GoString(pwd.pw_gecos),
+				Dir:   C.GoString(pwd.pw_dir),
+				Shell: C.GoString(pwd.pw_shell),
+			}
+		}
+		return status
+	})
+	return u, found, err
+}
+
+// nssGroupByGID returns the group with the GID of the source.
+func nssGroupByGID(source string, gid uint32) (g Group, found bool, err error) {
+	var grp C.struct_group
+	found, err = nssLookup(source, "getgrgid_r", func(f unsafe.Pointer, buf *C.char, size C.size_t, errnop *C.int) C.enum_nss_status {
+		status := C.call_getgrgid_r(f, C.gid_t(gid), &grp, buf, size, errnop)
+		if status == C.NSS_STATUS_SUCCESS {
+			g = groupFromC(&grp)
+		}
+		return status
+	})
+	return g, found, err
+}
+
+// nssGroupByName returns the group with the name of the source.
+func nssGroupByName(source, name string) (g Group, found bool, err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	var grp C.struct_group
+	found, err = nssLookup(source, "getgrnam_r", func(f unsafe.Pointer, buf *C.char, size C.size_t, errnop *C.int) C.enum_nss_status {
+		status := C.call_getgrnam_r(f, cName, &grp, buf, size, errnop)
+		if status == C.NSS_STATUS_SUCCESS {
+			g = groupFromC(&grp)
+		}
+		return status
+	})
+	return g, found, err
+}
+
+// groupFromC returns the group of the entry returned by an NSS module.
+func groupFromC(grp *C.struct_group) Group {
+	return Group{
+		Name:   C.GoString(grp.gr_name),
+		GID:    uint32(grp.gr_gid),
+		Passwd: C.GoString(grp.gr_passwd),
+	}
+}
@@ -0,0 +1,191 @@
+package localentries_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users/localentries"
+)
+
+func TestUsersByUID(t *testing.T) {
+	// The skipped NSS sources are overridden, so this test can't run in parallel.
+	tests := map[string]struct {
+		nsswitch       string
+		passwdFilePath string
+		uid            uint32
+		queryFiles     bool
+
+		wantSystemUsers bool
+		wantErr         bool
+	}{
+		"Only_local_users_without_other_sources":         {nsswitch: "passwd: files systemd authd\n"},
+		"Only_local_users_if_nsswitch_is_missing":        {},
+		"No_users_for_an_unused_UID":                     {nsswitch: "passwd: files\n", uid: 4242, queryFiles: true},
+		"Add_users_of_other_sources":                     {nsswitch: "passwd: files\n", uid: 2, queryFiles: true, wantSystemUsers: true},
+		"Keep_the_local_users_of_a_same_name":            {nsswitch: "passwd: files\n", queryFiles: true, wantSystemUsers: true},
+		"Skip_actions_and_unavailable_sources":           {nsswitch: "passwd: files [NOTFOUND=return] [ UNAVAIL=return ] doesnotexist\n"},
+		"Ignore_comments_and_sources_of_other_databases": {nsswitch: "# passwd: files\ngroup: files\n", uid: 2, queryFiles: true},
+
+		"Error_when_passwd_file_is_malformed": {passwdFilePath: "malformed_file.passwd", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.passwdFilePath == "" {
+				tc.passwdFilePath = "valid.passwd"
+			}
+			if tc.queryFiles {
+				localentries.Z_ForTests_SetSkippedNSSSources(t, []string{"authd"})
+			}
+			nsswitchPath := filepath.Join(t.TempDir(), "nsswitch.conf")
+			if tc.nsswitch != "" {
+				require.NoError(t, os.WriteFile(nsswitchPath, []byte(tc.nsswitch), 0600), "Setup: could not write nsswitch.conf")
+			}
+			passwdPath := localentries.WithPasswdPath(filepath.Join("testdata", tc.passwdFilePath))
+
+			got, err := localentries.UsersByUID(tc.uid, passwdPath, localentries.WithNSSwitchPath(nsswitchPath))
+			if tc.wantErr {
+				require.Error(t, err, "UsersByUID should have failed")
+				return
+			}
+			require.NoError(t, err, "UsersByUID should not have failed")
+
+			localUsers, err := localentries.LocalUsers(passwdPath)
+			require.NoError(t, err, "Setup: could not read local users")
+			var want []localentries.Passwd
+			for _, u := range localUsers {
+				if u.UID == tc.uid {
+					want = append(want, u)
+				}
+			}
+			if tc.wantSystemUsers {
+				// The system users are the ones the files source returns, which reads /etc/passwd. It returns the first
+				// one with the UID.
+				systemUsers, err := localentries.LocalUsers(localentries.WithPasswdPath("/etc/passwd"))
+				require.NoError(t, err, "Setup: could not read system users")
+				i := slices.IndexFunc(systemUsers, func(u localentries.Passwd) bool { return u.UID == tc.uid })
+				require.GreaterOrEqual(t, i, 0, "Setup: the system should have a user with UID %d", tc.uid)
+				if !slices.ContainsFunc(want, func(u localentries.Passwd) bool { return u.Name == systemUsers[i].Name }) {
+					want = append(want, systemUsers[i])
+				}
+			}
+			require.Equal(t, want, got, "UsersByUID should return the users with the UID, the local ones first")
+		})
+	}
+}
+
+func TestGroupsByGID(t *testing.T) {
+	// The skipped NSS sources are overridden, so this test can't run in parallel.
+	tests := map[string]struct {
+		nsswitch      string
+		groupFilePath string
+		gid           uint32
+		queryFiles    bool
+
+		wantSystemGroups bool
+		wantErr          bool
+	}{
+		"Only_local_groups_without_other_sources":  {nsswitch: "group: files systemd authd\n", gid: 41},
+		"Only_local_groups_if_nsswitch_is_missing": {gid: 41},
+		"No_groups_for_an_unused_GID":              {nsswitch: "group: files\n", gid: 4242, queryFiles: true},
+		"Add_groups_of_other_sources":              {nsswitch: "group: files\n", queryFiles: true, wantSystemGroups: true},
+		"Skip_actions_and_unavailable_sources":     {nsswitch: "group: files [NOTFOUND=return] doesnotexist\n"},
+
+		"Error_when_group_file_is_malformed": {groupFilePath: "malformed_file.group", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.groupFilePath == "" {
+				tc.groupFilePath = "users_in_our_groups.group"
+			}
+			if tc.queryFiles {
+				localentries.Z_ForTests_SetSkippedNSSSources(t, []string{"authd"})
+			}
+			nsswitchPath := filepath.Join(t.TempDir(), "nsswitch.conf")
+			if tc.nsswitch != "" {
+				require.NoError(t, os.WriteFile(nsswitchPath, []byte(tc.nsswitch), 0600), "Setup: could not write nsswitch.conf")
+			}
+			groupPath := localentries.WithGroupPath(filepath.Join("testdata", tc.groupFilePath))
+
+			got, err := localentries.GroupsByGID(tc.gid, groupPath, localentries.WithNSSwitchPath(nsswitchPath))
+			if tc.wantErr {
+				require.Error(t, err, "GroupsByGID should have failed")
+				return
+			}
+			require.NoError(t, err, "GroupsByGID should not have failed")
+
+			localGroups, err := localentries.LocalGroups(groupPath)
+			require.NoError(t, err, "Setup: could not read local groups")
+			var want []localentries.Group
+			for _, g := range localGroups {
+				if g.GID == tc.gid {
+					want = append(want, g)
+				}
+			}
+			if tc.wantSystemGroups {
+				// The system groups are the ones the files source returns, which reads /etc/group.
+				systemGroups, err := localentries.LocalGroups(localentries.WithGroupPath("/etc/group"))
+				require.NoError(t, err, "Setup: could not read system groups")
+				i := slices.IndexFunc(systemGroups, func(g localentries.Group) bool { return g.GID == tc.gid })
+				require.GreaterOrEqual(t, i, 0, "Setup: the system should have a group with GID %d", tc.gid)
+				want = append(want, systemGroups[i])
+			}
+			require.Len(t, got, len(want), "GroupsByGID should return the groups with the GID")
+			for i := range want {
+				require.Equal(t, want[i].Name, got[i].Name, "GroupsByGID should return the groups with the GID, the local ones first")
+				require.Equal(t, want[i].GID, got[i].GID, "GroupsByGID should return the groups with the GID")
+			}
+		})
+	}
+}
+
+func TestGroupByName(t *testing.T) {
+	// The skipped NSS sources are overridden, so this test can't run in parallel.
+	tests := map[string]struct {
+		nsswitch      string
+		groupFilePath string
+		name          string
+		queryFiles    bool
+
+		wantGID   uint32
+		wantFound bool
+		wantErr   bool
+	}{
+		"Local_group":                        {nsswitch: "group: files\n", name: "localgroup1", queryFiles: true, wantGID: 41, wantFound: true},
+		"Group_of_other_sources":             {nsswitch: "group: files\n", name: "root", queryFiles: true, wantGID: 0, wantFound: true},
+		"No_group_of_other_sources_skipped":  {nsswitch: "group: files systemd authd\n", name: "root"},
+		"No_group_with_an_unknown_name":      {nsswitch: "group: files\n", name: "doesnotexist", queryFiles: true},
+		"Skip_unavailable_sources":           {nsswitch: "group: doesnotexist\n", name: "localgroup1", wantGID: 41, wantFound: true},
+		"Error_when_group_file_is_malformed": {groupFilePath: "malformed_file.group", name: "localgroup1", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.groupFilePath == "" {
+				tc.groupFilePath = "users_in_our_groups.group"
+			}
+			if tc.queryFiles {
+				localentries.Z_ForTests_SetSkippedNSSSources(t, []string{"authd"})
+			}
+			nsswitchPath := filepath.Join(t.TempDir(), "nsswitch.conf")
+			if tc.nsswitch != "" {
+				require.NoError(t, os.WriteFile(nsswitchPath, []byte(tc.nsswitch), 0600), "Setup: could not write nsswitch.conf")
+			}
+			groupPath := localentries.WithGroupPath(filepath.Join("testdata", tc.groupFilePath))
+
+			got, found, err := localentries.GroupByName(tc.name, groupPath, localentries.WithNSSwitchPath(nsswitchPath))
+			if tc.wantErr {
+				require.Error(t, err, "GroupByName should have failed")
+				return
+			}
+			require.NoError(t, err, "GroupByName should not have failed")
+			require.Equal(t, tc.wantFound, found, "GroupByName should return whether the group was found")
+			if !tc.wantFound {
+				return
+			}
+			require.Equal(t, tc.name, got.Name, "GroupByName should return the group with the name")
+			require.Equal(t, tc.wantGID, got.GID, "GroupByName should return the GID of the group")
+		})
+	}
+}
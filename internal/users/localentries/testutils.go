@@ -13,7 +13,8 @@ func Z_ForTests_RestoreDefaultOptions() {
 	defaultOptions = originalDefaultOptions
 }
 
-// Z_ForTests_SetGroupPath sets the groupPath for the defaultOptions.
+// Z_ForTests_SetGroupPath sets the groupPath for the defaultOptions. The shadow file and the locks of the group file
+// are in the same directory.
 // Tests using this can't be run in parallel.
 // Call Z_ForTests_RestoreDefaultOptions to restore the original value.
 //
@@ -24,13 +25,13 @@ func Z_ForTests_SetGroupPath(groupPath string) {
 	defaultOptions.groupPath = groupPath
 }
 
-// Z_ForTests_SetGpasswdCmd sets the gpasswdCmd for the defaultOptions.
+// Z_ForTests_SetPasswdPath sets the passwdPath for the defaultOptions.
 // Tests using this can't be run in parallel.
 // Call Z_ForTests_RestoreDefaultOptions to restore the original value.
 //
 // nolint:revive,nolintlint // We want to use underscores in the function name here.
-func Z_ForTests_SetGpasswdCmd(gpasswdCmd []string) {
+func Z_ForTests_SetPasswdPath(passwdPath string) {
 	testsdetection.MustBeTesting()
 
-	defaultOptions.gpasswdCmd = gpasswdCmd
+	defaultOptions.passwdPath = passwdPath
 }
@@ -0,0 +1,85 @@
+// Package localgrouptestutils export users test functionalities used by other packages to change cmdline and group file.
+package localgrouptestutils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users/localentries"
+)
+
+// SetupGroupMock makes the local groups be edited in a copy of the group file, in a temporary directory, and returns
+// the path of the copy. If the group file doesn't exist, its path is used as is.
+//
+// Tests that require this can not be run in parallel.
+func SetupGroupMock(t *testing.T, groupsFilePath string) string {
+	t.Helper()
+
+	t.Cleanup(localentries.Z_ForTests_RestoreDefaultOptions)
+
+	destGroupsFile := groupsFilePath
+	if _, err := os.Stat(groupsFilePath); err == nil {
+		destGroupsFile = filepath.Join(t.TempDir(), "group")
+		copyGroupFile(t, groupsFilePath, destGroupsFile)
+	}
+	localentries.Z_ForTests_SetGroupPath(destGroupsFile)
+
+	return destGroupsFile
+}
+
+// AuthdIntegrationTestsEnvWithGroupMock copies the group file to outputFilePath and returns the environment to pass
+// to the authd daemon to edit the local groups in the copy. In order to enable it, the authd binary must be built with
+// the tag integrationtests.
+func AuthdIntegrationTestsEnvWithGroupMock(t *testing.T, outputFilePath, groupsFilePath string) []string {
+	t.Helper()
+
+	copyGroupFile(t, groupsFilePath, outputFilePath)
+
+	return []string{
+		"AUTHD_INTEGRATIONTESTS_GROUP_FILE_PATH=" + outputFilePath,
+	}
+}
+
+// RequireGroupFile compares the group file edited by authd with the golden file. The golden file doesn't exist if the
+// group file must not be edited.
+func RequireGroupFile(t *testing.T, groupsFilePath, goldenGroupPath string) {
+	t.Helper()
+
+	// The group file is only backed up when it's edited.
+	edited := true
+	if _, err := os.Stat(groupsFilePath + "-"); errors.Is(err, os.ErrNotExist) {
+		edited = false
+	}
+
+	if golden.UpdateEnabled() && !edited {
+		// The file may already not exists.
+		_ = os.Remove(goldenGroupPath)
+	}
+	if _, err := os.Stat(goldenGroupPath); errors.Is(err, os.ErrNotExist) && !golden.UpdateEnabled() {
+		require.False(t, edited, "The group file should not be edited but was")
+		return
+	}
+	if !edited {
+		return
+	}
+
+	got, err := os.ReadFile(groupsFilePath)
+	require.NoError(t, err, "Teardown: could not read group file")
+	golden.CheckOrUpdate(t, string(got), golden.WithPath(goldenGroupPath))
+}
+
+// copyGroupFile copies the group file at src to dst, if it exists.
+func copyGroupFile(t *testing.T, src, dst string) {
+	t.Helper()
+
+	d, err := os.ReadFile(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	require.NoError(t, err, "Setup: could not read group file")
+	require.NoError(t, os.WriteFile(dst, d, 0600), "Setup: could not copy group file")
+}
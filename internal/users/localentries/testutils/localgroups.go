@@ -20,8 +20,8 @@ var (
 	//go:linkname defaultOptions github.com/ubuntu/authd/internal/users/localentries.defaultOptions
 	defaultOptions struct {
 		groupPath    string
-		gpasswdCmd   []string
 		getUsersFunc func() []string
+		passwdPath   string
 	}
 )
 
@@ -31,8 +31,8 @@ func SetGroupPath(groupPath string) {
 	defaultOptions.groupPath = groupPath
 }
 
-// SetGpasswdCmd sets the gpasswdCmd for the defaultOptions.
+// SetPasswdPath sets the passwdPath for the defaultOptions.
 // Tests using this can't be run in parallel.
-func SetGpasswdCmd(gpasswdCmd []string) {
-	defaultOptions.gpasswdCmd = gpasswdCmd
+func SetPasswdPath(passwdPath string) {
+	defaultOptions.passwdPath = passwdPath
 }
@@ -0,0 +1,239 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/ubuntu/authd/internal/sliceutils"
+	"github.com/ubuntu/authd/internal/users/localentries"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+)
+
+const (
+	// LocalGroupsSyncLogin reconciles the local groups of the users with the ones declared by their broker on every
+	// login, adding them back to the groups they were removed from by an administrator.
+	LocalGroupsSyncLogin = "login"
+	// LocalGroupsSyncChange only reconciles the local groups of the users when the ones declared by their broker
+	// change, keeping the changes of the administrators until then.
+	LocalGroupsSyncChange = "change"
+	// LocalGroupsSyncFirstLogin only adds the users to the local groups declared by their broker when they are
+	// created.
+	LocalGroupsSyncFirstLogin = "first_login"
+)
+
+const (
+	// MissingLocalGroupIgnore doesn't add the users to the local groups which don't exist.
+	MissingLocalGroupIgnore = "ignore"
+	// MissingLocalGroupCreate creates the local groups which don't exist, with a GID of the range of the created local
+	// groups.
+	MissingLocalGroupCreate = "create"
+	// MissingLocalGroupFail fails the login of the users added to local groups which don't exist.
+	MissingLocalGroupFail = "fail"
+)
+
+// MissingLocalGroupRule is what happens when the users are added to local groups which don't exist and whose names
+// match a pattern.
+type MissingLocalGroupRule struct {
+	// Pattern is a shell pattern of the names of the groups, as in path.Match, like "dev-*".
+	Pattern string `mapstructure:"pattern"`
+	// Action is either ignore, create or fail.
+	Action string `mapstructure:"action"`
+}
+
+// LocalGroupRule adds the users having a role or a claim, which the brokers provide as one of their groups, to local
+// groups.
+type LocalGroupRule struct {
+	// Claim is the name of the group provided by the broker, like "admin".
+	Claim string `mapstructure:"claim"`
+	// Groups are the local groups the users having the claim are added to, like "sudo" and "adm".
+	Groups []string `mapstructure:"groups"`
+}
+
+// validateLocalGroupsFilter checks that the allowlist and the denylist of the local groups have no empty group.
+func validateLocalGroupsFilter(config Config) error {
+	if slices.Contains(config.LocalGroupsAllowlist, "") {
+		return errors.New("empty group in the allowlist of the local groups")
+	}
+	if slices.Contains(config.LocalGroupsDenylist, "") {
+		return errors.New("empty group in the denylist of the local groups")
+	}
+	return nil
+}
+
+// localGroupAllowed returns true if the users can be added to the local group: it's not in the denylist and, if
+// there's an allowlist, it's in it.
+func localGroupAllowed(group string, config Config) bool {
+	if slices.Contains(config.LocalGroupsDenylist, group) {
+		return false
+	}
+	return len(config.LocalGroupsAllowlist) == 0 || slices.Contains(config.LocalGroupsAllowlist, group)
+}
+
+// filterLocalGroups returns the local groups the user can be added to, so that a broker can't add its users to
+// privileged groups, like sudo or docker, which are not allowed.
+func (s *settings) filterLocalGroups(name string, groups []string) []string {
+	return slices.DeleteFunc(groups, func(g string) bool {
+		if localGroupAllowed(g, s.Config) {
+			return false
+		}
+		log.Warningf(context.Background(), "Not adding user %q to local group %q, which is not allowed", name, g)
+		return true
+	})
+}
+
+// validateMissingLocalGroups checks that the rules of the missing local groups are valid, and that the range of the
+// GIDs of the created local groups doesn't overlap the ones of the authd groups.
+func validateMissingLocalGroups(config Config) error {
+	var create bool
+	for i, r := range config.LocalGroupsMissing {
+		if r.Pattern == "" {
+			return fmt.Errorf("missing local group rule %d has no pattern", i+1)
+		}
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q of missing local group rule %d: %v", r.Pattern, i+1, err)
+		}
+		switch r.Action {
+		case MissingLocalGroupIgnore, MissingLocalGroupFail:
+		case MissingLocalGroupCreate:
+			create = true
+		default:
+			return fmt.Errorf("invalid action %q of missing local group rule %d, must be %q, %q or %q", r.Action, i+1,
+				MissingLocalGroupIgnore, MissingLocalGroupCreate, MissingLocalGroupFail)
+		}
+	}
+	if !create {
+		return nil
+	}
+
+	gidMin, gidMax := config.LocalGroupsGIDMin, config.LocalGroupsGIDMax
+	if gidMin == 0 || gidMin > gidMax {
+		return fmt.Errorf("invalid GID range of the created local groups %d-%d", gidMin, gidMax)
+	}
+	overlaps := func(min, max uint32) bool { return max != 0 && gidMin <= max && min <= gidMax }
+	if overlaps(config.GIDMin, config.GIDMax) || overlaps(config.RemoteGroupsGIDMin, config.RemoteGroupsGIDMax) {
+		return fmt.Errorf("GID range of the created local groups %d-%d overlaps the GIDs of the authd groups", gidMin, gidMax)
+	}
+
+	return nil
+}
+
+// missingLocalGroupAction returns the action of the first rule of the missing local groups matching the name of the
+// group, or ignore if none matches.
+func missingLocalGroupAction(group string, config Config) string {
+	for _, r := range config.LocalGroupsMissing {
+		if ok, _ := path.Match(r.Pattern, group); ok {
+			return r.Action
+		}
+	}
+	return MissingLocalGroupIgnore
+}
+
+// missingLocalGroupAction returns what happens when a user is added to the local group, which doesn't exist.
+func (s *settings) missingLocalGroupAction(group string) localentries.MissingGroupAction {
+	switch missingLocalGroupAction(group, s.Config) {
+	case MissingLocalGroupCreate:
+		return localentries.CreateMissingGroup
+	case MissingLocalGroupFail:
+		return localentries.FailOnMissingGroup
+	default:
+		return localentries.IgnoreMissingGroup
+	}
+}
+
+// validateLocalGroupRules checks that the rules are complete and that the local groups they map to exist, or are
+// created, and are allowed.
+func validateLocalGroupRules(config Config) error {
+	rules := config.LocalGroupRules
+	if len(rules) == 0 {
+		return nil
+	}
+
+	localGroups, err := localentries.LocalGroups()
+	if err != nil {
+		return err
+	}
+
+	for i, r := range rules {
+		if r.Claim == "" {
+			return fmt.Errorf("local group rule %d has no claim", i+1)
+		}
+		if len(r.Groups) == 0 {
+			return fmt.Errorf("local group rule of claim %q has no groups", r.Claim)
+		}
+		for _, g := range r.Groups {
+			if g == "" {
+				return fmt.Errorf("local group rule of claim %q has an empty group", r.Claim)
+			}
+			if !slices.ContainsFunc(localGroups, func(l localentries.Group) bool { return l.Name == g }) &&
+				missingLocalGroupAction(g, config) != MissingLocalGroupCreate {
+				return fmt.Errorf("local group %q of claim %q does not exist", g, r.Claim)
+			}
+			if !localGroupAllowed(g, config) {
+				return fmt.Errorf("local group %q of claim %q is not allowed", g, r.Claim)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateLocalGroupsSync checks that the sync policy of the local groups is valid.
+func validateLocalGroupsSync(policy string) error {
+	switch policy {
+	case LocalGroupsSyncLogin, LocalGroupsSyncChange, LocalGroupsSyncFirstLogin:
+		return nil
+	default:
+		return fmt.Errorf("invalid local groups sync policy %q, must be %q, %q or %q", policy, LocalGroupsSyncLogin, LocalGroupsSyncChange, LocalGroupsSyncFirstLogin)
+	}
+}
+
+// syncLocalGroups returns whether the local groups of the user are reconciled with the ones declared by its broker,
+// according to the sync policy of the local groups.
+func (s *settings) syncLocalGroups(newUser bool, localGroups, oldLocalGroups []string) bool {
+	switch s.LocalGroupsSync {
+	case LocalGroupsSyncChange:
+		return newUser || len(sliceutils.Difference(localGroups, oldLocalGroups)) > 0 ||
+			len(sliceutils.Difference(oldLocalGroups, localGroups)) > 0
+	case LocalGroupsSyncFirstLogin:
+		return newUser
+	default:
+		return true
+	}
+}
+
+// applyLocalGroupRules returns the groups followed by the local groups the rules map their claims to, which the user
+// isn't already a member of.
+func applyLocalGroupRules(groups []types.GroupInfo, rules []LocalGroupRule) []types.GroupInfo {
+	hasGroup := func(name string, local bool) bool {
+		return slices.ContainsFunc(groups, func(g types.GroupInfo) bool { return g.Name == name && (!local || g.UGID == "") })
+	}
+
+	for _, r := range rules {
+		if !hasGroup(r.Claim, false) {
+			continue
+		}
+		for _, g := range r.Groups {
+			if !hasGroup(g, true) {
+				groups = append(groups, types.GroupInfo{Name: g})
+			}
+		}
+	}
+
+	return groups
+}
+
+// SetLocalGroupsDryRun changes whether the changes of the local groups are only logged, until the configuration is
+// reloaded.
+func (m *Manager) SetLocalGroupsDryRun(dryRun bool) {
+	// Don't store back a copy of the settings a concurrent reload is replacing.
+	m.settingsMu.Lock()
+	defer m.settingsMu.Unlock()
+
+	s := *m.config()
+	s.LocalGroupsDryRun = dryRun
+	m.settings.Store(&s)
+}
@@ -0,0 +1,228 @@
+package users_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/idgenerator"
+	"github.com/ubuntu/authd/internal/users/localentries"
+	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestUpdateUserLocalGroupRules(t *testing.T) {
+	rules := []users.LocalGroupRule{
+		{Claim: "admin", Groups: []string{"localgroup1", "localgroup3"}},
+		{Claim: "developer", Groups: []string{"localgroup3"}},
+	}
+
+	tests := map[string]struct {
+		groups         []types.GroupInfo
+		previousGroups []types.GroupInfo
+		dryRun         bool
+	}{
+		"Add_user_to_local_groups_of_its_claims":             {groups: []types.GroupInfo{{Name: "admin", UGID: "1"}, {Name: "developer", UGID: "2"}}},
+		"Add_user_to_local_groups_of_its_local_claims":       {groups: []types.GroupInfo{{Name: "developer"}}},
+		"Add_user_to_local_groups_of_its_nested_claims":      {groups: []types.GroupInfo{{Name: "group1", UGID: "1", Parents: []types.GroupInfo{{Name: "admin", UGID: "2"}}}}},
+		"Keep_local_groups_provided_by_broker":               {groups: []types.GroupInfo{{Name: "admin", UGID: "1"}, {Name: "localgroup2"}}},
+		"Do_not_add_user_to_local_groups_without_claims":     {groups: []types.GroupInfo{{Name: "group1", UGID: "1"}}},
+		"Remove_user_from_local_groups_of_claims_it_lost":    {groups: []types.GroupInfo{{Name: "developer", UGID: "2"}}, previousGroups: []types.GroupInfo{{Name: "admin", UGID: "1"}}},
+		"Keep_user_in_local_groups_of_claims_it_still_has":   {groups: []types.GroupInfo{{Name: "admin", UGID: "1"}}, previousGroups: []types.GroupInfo{{Name: "admin", UGID: "1"}}},
+		"Remove_user_from_local_groups_when_it_has_no_claim": {previousGroups: []types.GroupInfo{{Name: "admin", UGID: "1"}, {Name: "developer", UGID: "2"}}},
+
+		"Do_not_add_user_to_local_groups_in_dry_run": {groups: []types.GroupInfo{{Name: "admin", UGID: "1"}}, dryRun: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			groupsFile := localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+
+			config := users.DefaultConfig
+			config.LocalGroupRules = rules
+			config.LocalGroupsDryRun = tc.dryRun
+			m, err := users.NewManager(config, t.TempDir(), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+				UIDsToGenerate: []uint32{1111},
+				GIDsToGenerate: []uint32{11110, 11111, 11112},
+			}))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			u := types.UserInfo{Name: "user4", Dir: "/home/user4", Shell: "/bin/bash"}
+			if tc.previousGroups != nil {
+				u.Groups = tc.previousGroups
+				require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "Setup: could not create user")
+			}
+
+			u.Groups = tc.groups
+			require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "UpdateUser should not return an error, but did")
+
+			localgroupstestutils.RequireGroupFile(t, groupsFile, golden.Path(t)+".group")
+		})
+	}
+}
+
+func TestSetLocalGroupsDryRun(t *testing.T) {
+	groupsFile := localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+
+	config := users.DefaultConfig
+	config.LocalGroupRules = []users.LocalGroupRule{{Claim: "admin", Groups: []string{"localgroup3"}}}
+	m, err := users.NewManager(config, t.TempDir(), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+		UIDsToGenerate: []uint32{1111},
+		GIDsToGenerate: []uint32{11110, 11111},
+	}))
+	require.NoError(t, err, "Setup: could not create user manager")
+	t.Cleanup(func() { _ = m.Stop() })
+
+	m.SetLocalGroupsDryRun(true)
+	require.True(t, m.Config().LocalGroupsDryRun, "SetLocalGroupsDryRun should enable the dry run")
+
+	u := types.UserInfo{Name: "user4", Dir: "/home/user4", Shell: "/bin/bash", Groups: []types.GroupInfo{{Name: "admin", UGID: "1"}}}
+	require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "UpdateUser should not return an error, but did")
+	require.NoFileExists(t, groupsFile+"-", "The group file should not be edited in dry run")
+
+	require.NoError(t, m.Reload(config), "Reload should not return an error, but did")
+	require.False(t, m.Config().LocalGroupsDryRun, "Reload should reset the dry run to the configured value")
+}
+
+func TestUpdateUserLocalGroupsSync(t *testing.T) {
+	tests := map[string]struct {
+		policy         string
+		previousGroups []types.GroupInfo
+		groups         []types.GroupInfo
+		removedByAdmin []string
+	}{
+		"Add_user_to_local_groups_on_first_login_with_first_login_policy": {policy: users.LocalGroupsSyncFirstLogin, groups: []types.GroupInfo{{Name: "localgroup1"}}},
+		"Add_user_back_to_local_groups_on_login_with_login_policy": {
+			policy: users.LocalGroupsSyncLogin, previousGroups: []types.GroupInfo{{Name: "localgroup1"}, {Name: "localgroup3"}},
+			groups: []types.GroupInfo{{Name: "localgroup1"}, {Name: "localgroup3"}}, removedByAdmin: []string{"localgroup1"},
+		},
+		"Keep_changes_of_admin_if_groups_are_unchanged_with_change_policy": {
+			policy: users.LocalGroupsSyncChange, previousGroups: []types.GroupInfo{{Name: "localgroup1"}, {Name: "localgroup3"}},
+			groups: []types.GroupInfo{{Name: "localgroup1"}, {Name: "localgroup3"}}, removedByAdmin: []string{"localgroup1"},
+		},
+		"Reconcile_local_groups_if_groups_changed_with_change_policy": {
+			policy: users.LocalGroupsSyncChange, previousGroups: []types.GroupInfo{{Name: "localgroup1"}, {Name: "localgroup3"}},
+			groups: []types.GroupInfo{{Name: "localgroup1"}, {Name: "localgroup2"}}, removedByAdmin: []string{"localgroup1"},
+		},
+		"Do_not_reconcile_local_groups_after_first_login_with_first_login_policy": {
+			policy: users.LocalGroupsSyncFirstLogin, previousGroups: []types.GroupInfo{{Name: "localgroup1"}, {Name: "localgroup3"}},
+			groups: []types.GroupInfo{{Name: "localgroup2"}}, removedByAdmin: []string{"localgroup1"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			groupsFile := localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+
+			config := users.DefaultConfig
+			config.LocalGroupsSync = tc.policy
+			m, err := users.NewManager(config, t.TempDir(), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+				UIDsToGenerate: []uint32{1111},
+				GIDsToGenerate: []uint32{11110},
+			}))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			u := types.UserInfo{Name: "user4", Dir: "/home/user4", Shell: "/bin/bash"}
+			if tc.previousGroups != nil {
+				u.Groups = tc.previousGroups
+				require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "Setup: could not create user")
+			}
+			require.NoError(t, localentries.RemoveUser(context.Background(), u.Name, tc.removedByAdmin), "Setup: could not remove user from local groups")
+
+			u.Groups = tc.groups
+			require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "UpdateUser should not return an error, but did")
+
+			localgroupstestutils.RequireGroupFile(t, groupsFile, golden.Path(t)+".group")
+		})
+	}
+}
+
+func TestUpdateUserLocalGroupsFilter(t *testing.T) {
+	groups := []types.GroupInfo{{Name: "localgroup1"}, {Name: "localgroup3"}}
+
+	tests := map[string]struct {
+		allowlist []string
+		denylist  []string
+	}{
+		"Only_add_user_to_local_groups_of_allowlist":       {allowlist: []string{"localgroup1", "localgroup2"}},
+		"Do_not_add_user_to_local_groups_of_denylist":      {denylist: []string{"localgroup3"}},
+		"Denylist_takes_precedence_over_allowlist":         {allowlist: []string{"localgroup1", "localgroup3"}, denylist: []string{"localgroup1"}},
+		"Do_not_add_user_to_any_group_if_all_are_filtered": {allowlist: []string{"localgroup2"}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			groupsFile := localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+
+			config := users.DefaultConfig
+			config.LocalGroupsAllowlist = tc.allowlist
+			config.LocalGroupsDenylist = tc.denylist
+			m, err := users.NewManager(config, t.TempDir(), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+				UIDsToGenerate: []uint32{1111},
+				GIDsToGenerate: []uint32{11110},
+			}))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			u := types.UserInfo{Name: "user4", Dir: "/home/user4", Shell: "/bin/bash", Groups: groups}
+			require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "UpdateUser should not return an error, but did")
+
+			localgroupstestutils.RequireGroupFile(t, groupsFile, golden.Path(t)+".group")
+		})
+	}
+}
+
+func TestUpdateUserMissingLocalGroups(t *testing.T) {
+	groups := []types.GroupInfo{{Name: "localgroup1"}, {Name: "dev-group1"}, {Name: "dev-group2"}, {Name: "other-group"}}
+
+	tests := map[string]struct {
+		rules  []users.MissingLocalGroupRule
+		gidMin uint32
+		gidMax uint32
+		dryRun bool
+
+		wantErr bool
+	}{
+		"Ignore_missing_local_groups_by_default":         {},
+		"Create_missing_local_groups_matching_pattern":   {rules: []users.MissingLocalGroupRule{{Pattern: "dev-*", Action: users.MissingLocalGroupCreate}}},
+		"Create_all_missing_local_groups":                {rules: []users.MissingLocalGroupRule{{Pattern: "*", Action: users.MissingLocalGroupCreate}}},
+		"Skip_used_GIDs_when_creating_local_groups":      {rules: []users.MissingLocalGroupRule{{Pattern: "dev-*", Action: users.MissingLocalGroupCreate}}, gidMin: 42, gidMax: 45},
+		"First_matching_rule_applies":                    {rules: []users.MissingLocalGroupRule{{Pattern: "dev-group2", Action: users.MissingLocalGroupIgnore}, {Pattern: "dev-*", Action: users.MissingLocalGroupCreate}}},
+		"Do_not_create_missing_local_groups_in_dry_run":  {rules: []users.MissingLocalGroupRule{{Pattern: "*", Action: users.MissingLocalGroupCreate}}, dryRun: true},
+		"Do_not_fail_on_missing_groups_matching_no_rule": {rules: []users.MissingLocalGroupRule{{Pattern: "admin-*", Action: users.MissingLocalGroupFail}}},
+
+		"Error_if_missing_local_group_matches_fail_rule": {rules: []users.MissingLocalGroupRule{{Pattern: "other-*", Action: users.MissingLocalGroupFail}}, wantErr: true},
+		"Error_if_there_is_no_free_GID":                  {rules: []users.MissingLocalGroupRule{{Pattern: "dev-*", Action: users.MissingLocalGroupCreate}}, gidMin: 43, gidMax: 45, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			groupsFile := localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+
+			config := users.DefaultConfig
+			config.LocalGroupsMissing = tc.rules
+			config.LocalGroupsDryRun = tc.dryRun
+			if tc.gidMax != 0 {
+				config.LocalGroupsGIDMin = tc.gidMin
+				config.LocalGroupsGIDMax = tc.gidMax
+			}
+			m, err := users.NewManager(config, t.TempDir(), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+				UIDsToGenerate: []uint32{1111},
+				GIDsToGenerate: []uint32{11110},
+			}))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			u := types.UserInfo{Name: "user4", Dir: "/home/user4", Shell: "/bin/bash", Groups: groups}
+			err = m.UpdateUser(context.Background(), u, "broker-id")
+			if tc.wantErr {
+				require.Error(t, err, "UpdateUser should return an error, but did not")
+			} else {
+				require.NoError(t, err, "UpdateUser should not return an error, but did")
+			}
+
+			localgroupstestutils.RequireGroupFile(t, groupsFile, golden.Path(t)+".group")
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/localentries"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// Kinds of differences between the memberships in local groups recorded in the database and the local group file.
+const (
+	// LocalGroupDriftNotMember is a user which authd added to a local group, but which is not a member of it anymore,
+	// for example because the group file was edited manually or because its edit failed.
+	LocalGroupDriftNotMember = "not-member"
+	// LocalGroupDriftMissingGroup is a user which authd added to a local group which doesn't exist anymore.
+	LocalGroupDriftMissingGroup = "missing-group"
+	// LocalGroupDriftNotRemoved is a user whose membership in a local group expired, but which is still a member of
+	// it, for example because its removal failed.
+	LocalGroupDriftNotRemoved = "not-removed"
+	// LocalGroupDriftExtraMember is a user which is a member of a local group authd manages, but which authd didn't
+	// add to it, for example because the group file was edited manually.
+	LocalGroupDriftExtraMember = "extra-member"
+)
+
+// ReconcileLocalGroups compares the memberships in local groups which authd granted to its users, as recorded in the
+// database, with the local group file, and returns the differences in both directions. If repair is set, the users are
+// added back to the groups they are missing from, the missing groups are created if local_groups_missing allows it,
+// and the users are removed from the groups whose membership expired. The extra memberships are only reported, as an
+// administrator may have granted them. The actor is recorded in the audit log as the requester of the repair.
+func (m *Manager) ReconcileLocalGroups(repair bool, actor string) (drift []types.LocalGroupDrift, err error) {
+	defer decorate.OnError(&err, "failed to reconcile local groups")
+
+	config := m.config()
+
+	if repair {
+		if err := m.checkWritable(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Prevent logins from changing the local groups while we are comparing and repairing them.
+	m.updateUserMu.Lock()
+	defer m.updateUserMu.Unlock()
+
+	users, err := m.cache.AllUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	drift, err = m.localGroupsDrift(users)
+	if err != nil || !repair || len(drift) == 0 {
+		return drift, err
+	}
+
+	var revokedEvents []types.AuditEvent
+	var revokedGroups []string
+	for _, u := range users {
+		ctx := log.WithFields(context.Background(), log.UserField, u.Name)
+
+		if slices.ContainsFunc(drift, func(d types.LocalGroupDrift) bool {
+			return d.User == u.Name && (d.Kind == LocalGroupDriftNotMember || d.Kind == LocalGroupDriftMissingGroup)
+		}) {
+			groups, _, e := m.recordedLocalGroups(u)
+			if e != nil {
+				err = errors.Join(err, e)
+				continue
+			}
+
+			// The user is only added to the groups it's missing from, as no group is removed from the recorded ones.
+			if e := localentries.Update(ctx, u.Name, groups, groups, localentries.WithDryRun(config.LocalGroupsDryRun),
+				localentries.WithMissingGroups(config.missingLocalGroupAction, config.LocalGroupsGIDMin, config.LocalGroupsGIDMax)); e != nil {
+				err = errors.Join(err, e)
+			}
+		}
+
+		// The memberships which expired but are still in the group file are revoked like RevokeExpiredLocalGroups does.
+		var notRemoved []string
+		for _, d := range drift {
+			if d.User == u.Name && d.Kind == LocalGroupDriftNotRemoved {
+				notRemoved = append(notRemoved, d.Group)
+			}
+		}
+		if len(notRemoved) == 0 {
+			continue
+		}
+		if e := localentries.RemoveUser(ctx, u.Name, notRemoved, localentries.WithDryRun(config.LocalGroupsDryRun)); e != nil {
+			err = errors.Join(err, e)
+			continue
+		}
+		if config.LocalGroupsDryRun {
+			continue
+		}
+		if e := m.cache.RevokeLocalGroups(u.UID, notRemoved); e != nil {
+			err = errors.Join(err, e)
+			continue
+		}
+		log.Infof(ctx, "Revoked expired memberships of user %q in local groups %s", u.Name, strings.Join(notRemoved, ", "))
+		revokedEvents = append(revokedEvents, types.AuditEvent{
+			Action:  AuditMembershipsChanged,
+			Target:  u.Name,
+			Details: fmt.Sprintf("removed from %s, whose membership expired", strings.Join(notRemoved, ",")),
+		})
+		revokedGroups = append(revokedGroups, notRemoved...)
+	}
+	if len(revokedEvents) > 0 {
+		m.audit(actor, revokedEvents...)
+		m.notify(append(changesOf(revokedEvents...), groupChanges(revokedGroups...)...)...)
+	}
+
+	// The drift which remains, like the groups which are still missing or the changes only logged in dry run, was not
+	// repaired.
+	remaining, e := m.localGroupsDrift(users)
+	if e != nil {
+		return drift, errors.Join(err, e)
+	}
+	repaired := make(map[string][]string)
+	for i, d := range drift {
+		if slices.Contains(remaining, d) {
+			continue
+		}
+		drift[i].Repaired = true
+		if d.Kind == LocalGroupDriftNotMember || d.Kind == LocalGroupDriftMissingGroup {
+			repaired[d.User] = append(repaired[d.User], d.Group)
+		}
+	}
+
+	var events []types.AuditEvent
+	var groups []string
+	for _, u := range users {
+		if len(repaired[u.Name]) == 0 {
+			continue
+		}
+		log.Infof(context.Background(), "Added user %q back to local groups %s", u.Name, strings.Join(repaired[u.Name], ", "))
+		events = append(events, types.AuditEvent{
+			Action:  AuditMembershipsChanged,
+			Target:  u.Name,
+			Details: fmt.Sprintf("added back to %s", strings.Join(repaired[u.Name], ",")),
+		})
+		groups = append(groups, repaired[u.Name]...)
+	}
+	if len(events) > 0 {
+		m.audit(actor, events...)
+		m.notify(append(changesOf(events...), groupChanges(groups...)...)...)
+	}
+
+	return drift, err
+}
+
+// localGroupsDrift returns the differences between the memberships of the users in local groups, recorded in the
+// database, and the local group file: the memberships which are not in the file, the expired ones which are still in
+// it, and the memberships in the groups authd manages, which are the ones recorded for any user, which authd didn't
+// grant. The expired memberships which are not in the file are ignored, as they are about to be revoked.
+func (m *Manager) localGroupsDrift(users []cache.UserDB) (drift []types.LocalGroupDrift, err error) {
+	localGroups, err := localentries.LocalGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string][]string)
+	expired := make(map[string][]string)
+	var managed []string
+	for _, u := range users {
+		active[u.Name], expired[u.Name], err = m.recordedLocalGroups(u)
+		if err != nil {
+			return nil, err
+		}
+		managed = append(managed, active[u.Name]...)
+		managed = append(managed, expired[u.Name]...)
+	}
+	slices.Sort(managed)
+	managed = slices.Compact(managed)
+
+	for _, u := range users {
+		if len(managed) == 0 {
+			break
+		}
+
+		memberOf, err := localentries.LocalUserGroups(u.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, g := range active[u.Name] {
+			kind := LocalGroupDriftNotMember
+			if !slices.ContainsFunc(localGroups, func(lg localentries.Group) bool { return lg.Name == g }) {
+				kind = LocalGroupDriftMissingGroup
+			} else if slices.Contains(memberOf, g) {
+				continue
+			}
+			drift = append(drift, types.LocalGroupDrift{User: u.Name, Group: g, Kind: kind})
+		}
+
+		slices.Sort(memberOf)
+		for _, g := range memberOf {
+			if !slices.Contains(managed, g) || slices.Contains(active[u.Name], g) {
+				continue
+			}
+			kind := LocalGroupDriftExtraMember
+			if slices.Contains(expired[u.Name], g) {
+				kind = LocalGroupDriftNotRemoved
+			}
+			drift = append(drift, types.LocalGroupDrift{User: u.Name, Group: g, Kind: kind})
+		}
+	}
+
+	return drift, nil
+}
+
+// recordedLocalGroups returns the sorted local groups of the user recorded in the database whose membership is active,
+// and the ones whose membership expired.
+func (m *Manager) recordedLocalGroups(u cache.UserDB) (active, expired []string, err error) {
+	groups, err := m.cache.UserLocalGroups(u.UID)
+	if errors.Is(err, cache.NoDataFoundError{}) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	for _, g := range groups {
+		if t, ok := u.LocalGroupsExpiry[g]; ok && !time.Unix(t, 0).After(now) {
+			expired = append(expired, g)
+			continue
+		}
+		active = append(active, g)
+	}
+	slices.Sort(active)
+	slices.Sort(expired)
+	return active, expired, nil
+}
@@ -0,0 +1,67 @@
+package users_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+)
+
+func TestReconcileLocalGroups(t *testing.T) {
+	tests := map[string]struct {
+		groupFile string
+		dbFile    string
+		repair    bool
+		rules     []users.MissingLocalGroupRule
+		dryRun    bool
+
+		wantErr bool
+	}{
+		"No_drift_if_local_groups_match_database":      {groupFile: "users_in_groups.group"},
+		"Report_drift_without_repairing_it":            {groupFile: "local_groups_drift.group"},
+		"Repair_drift_by_adding_users_back_to_groups":  {groupFile: "local_groups_drift.group", repair: true},
+		"Repair_drift_by_creating_missing_groups":      {groupFile: "local_groups_drift.group", repair: true, rules: []users.MissingLocalGroupRule{{Pattern: "localgroup*", Action: users.MissingLocalGroupCreate}}},
+		"Do_not_repair_drift_in_dry_run":               {groupFile: "local_groups_drift.group", repair: true, dryRun: true},
+		"Ignore_expired_memberships_missing_in_groups": {groupFile: "local_groups_drift.group", dbFile: "users_with_expiring_local_groups", repair: true},
+		"Report_expired_memberships_not_removed":       {groupFile: "users_in_groups.group", dbFile: "users_with_expiring_local_groups"},
+		"Repair_drift_by_removing_expired_memberships": {groupFile: "users_in_groups.group", dbFile: "users_with_expiring_local_groups", repair: true},
+		"Report_extra_memberships":                     {groupFile: "local_groups_extra_members.group"},
+		"Do_not_repair_extra_memberships":              {groupFile: "local_groups_extra_members.group", repair: true},
+
+		"Error_if_missing_group_matches_fail_rule": {groupFile: "local_groups_drift.group", repair: true, rules: []users.MissingLocalGroupRule{{Pattern: "localgroup2", Action: users.MissingLocalGroupFail}}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			groupsFile := localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", tc.groupFile))
+
+			if tc.dbFile == "" {
+				tc.dbFile = "multiple_users_and_local_groups"
+			}
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
+
+			config := users.DefaultConfig
+			config.LocalGroupsMissing = tc.rules
+			config.LocalGroupsDryRun = tc.dryRun
+			config.LocalGroupsGIDMin = 42
+			config.LocalGroupsGIDMax = 45
+			m, err := users.NewManager(config, cacheDir)
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			got, err := m.ReconcileLocalGroups(tc.repair, "test")
+			if tc.wantErr {
+				require.Error(t, err, "ReconcileLocalGroups should return an error, but did not")
+			} else {
+				require.NoError(t, err, "ReconcileLocalGroups should not return an error, but did")
+			}
+
+			golden.CheckOrUpdateYAML(t, got)
+			localgroupstestutils.RequireGroupFile(t, groupsFile, golden.Path(t)+".group")
+		})
+	}
+}
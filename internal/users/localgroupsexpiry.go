@@ -0,0 +1,79 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ubuntu/authd/internal/users/localentries"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// expiredLocalGroupsCheckInterval is the interval between two revocations of the expired memberships in local groups.
+const expiredLocalGroupsCheckInterval = time.Minute
+
+// RevokeExpiredLocalGroups removes the users from the local groups whose memberships, granted by their broker for a
+// limited time, expired. It returns the names of the users whose memberships were revoked.
+func (m *Manager) RevokeExpiredLocalGroups() (revoked []string, err error) {
+	defer decorate.OnError(&err, "failed to revoke expired memberships in local groups")
+
+	config := m.config()
+
+	if err := m.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	// Prevent the users from logging in while we are revoking their memberships.
+	m.updateUserMu.Lock()
+	defer m.updateUserMu.Unlock()
+
+	users, err := m.cache.AllUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, u := range users {
+		var expired []string
+		for g, t := range u.LocalGroupsExpiry {
+			if !time.Unix(t, 0).After(now) {
+				expired = append(expired, g)
+			}
+		}
+		if len(expired) == 0 {
+			continue
+		}
+		slices.Sort(expired)
+
+		ctx := log.WithFields(context.Background(), log.UserField, u.Name)
+		if e := localentries.RemoveUser(ctx, u.Name, expired, localentries.WithDryRun(config.LocalGroupsDryRun)); e != nil {
+			err = errors.Join(err, e)
+			continue
+		}
+		// In dry-run mode, the memberships are kept, so that they are revoked once it's disabled.
+		if config.LocalGroupsDryRun {
+			continue
+		}
+		if e := m.cache.RevokeLocalGroups(u.UID, expired); e != nil {
+			err = errors.Join(err, e)
+			continue
+		}
+
+		log.Infof(ctx, "Revoked expired memberships of user %q in local groups %s", u.Name, strings.Join(expired, ", "))
+		events := []types.AuditEvent{{
+			Action:  AuditMembershipsChanged,
+			Target:  u.Name,
+			Details: fmt.Sprintf("removed from %s, whose membership expired", strings.Join(expired, ",")),
+		}}
+		m.audit(ActorLocalGroupsExpiry, events...)
+		m.notify(append(changesOf(events...), groupChanges(expired...)...)...)
+		revoked = append(revoked, u.Name)
+	}
+
+	return revoked, err
+}
@@ -0,0 +1,90 @@
+package users_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/idgenerator"
+	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+// neverExpires is a Unix time far enough in the future for the memberships expiring then to not expire during tests.
+const neverExpires = 4102444800
+
+func TestUpdateUserLocalGroupsExpiry(t *testing.T) {
+	tests := map[string]struct {
+		groups []types.GroupInfo
+	}{
+		"Add_user_to_local_groups_until_their_memberships_expire":   {groups: []types.GroupInfo{{Name: "localgroup1", ExpiresAt: neverExpires}, {Name: "localgroup3"}}},
+		"Do_not_add_user_to_local_groups_whose_memberships_expired": {groups: []types.GroupInfo{{Name: "localgroup1", ExpiresAt: 1}, {Name: "localgroup3"}}},
+		"Keep_longest_membership_in_local_group_provided_twice": {groups: []types.GroupInfo{
+			{Name: "group1", UGID: "1", Parents: []types.GroupInfo{{Name: "localgroup1", ExpiresAt: 1}}},
+			{Name: "localgroup1", ExpiresAt: neverExpires},
+		}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			groupsFile := localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+
+			m, err := users.NewManager(users.DefaultConfig, t.TempDir(), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+				UIDsToGenerate: []uint32{1111},
+				GIDsToGenerate: []uint32{11110, 11111},
+			}))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			u := types.UserInfo{Name: "user4", Dir: "/home/user4", Shell: "/bin/bash", Groups: tc.groups}
+			require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "UpdateUser should not return an error, but did")
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(userstestutils.GetManagerCache(m))
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+
+			localgroupstestutils.RequireGroupFile(t, groupsFile, golden.Path(t)+".group")
+		})
+	}
+}
+
+func TestRevokeExpiredLocalGroups(t *testing.T) {
+	tests := map[string]struct {
+		dbFile string
+		dryRun bool
+
+		wantRevoked []string
+	}{
+		"Revoke_expired_memberships_in_local_groups":    {dbFile: "users_with_expiring_local_groups", wantRevoked: []string{"user1", "user2"}},
+		"Do_not_revoke_memberships_which_do_not_expire": {dbFile: "multiple_users_and_local_groups"},
+		"Do_not_revoke_expired_memberships_in_dry_run":  {dbFile: "users_with_expiring_local_groups", dryRun: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			groupsFile := localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
+
+			config := users.DefaultConfig
+			config.LocalGroupsDryRun = tc.dryRun
+			m, err := users.NewManager(config, cacheDir)
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			revoked, err := m.RevokeExpiredLocalGroups()
+			require.NoError(t, err, "RevokeExpiredLocalGroups should not return an error, but did")
+			require.Equal(t, tc.wantRevoked, revoked, "RevokeExpiredLocalGroups should return the users whose memberships were revoked")
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(userstestutils.GetManagerCache(m))
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+
+			localgroupstestutils.RequireGroupFile(t, groupsFile, golden.Path(t)+".group")
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package users
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// ErrUserLocked is returned when a locked user tries to log in.
+var ErrUserLocked = errors.New("user is locked")
+
+// LockUser locks the user: it's still returned by NSS, but it can't authenticate anymore until it's unlocked. The
+// actor is recorded in the audit log as the requester of the change.
+func (m *Manager) LockUser(name, actor string) error {
+	return m.setUserLocked(name, true, actor)
+}
+
+// UnlockUser unlocks a user previously locked with LockUser, or locked out after too many failed authentication
+// attempts. The actor is recorded in the audit log as the requester of the change.
+func (m *Manager) UnlockUser(name, actor string) error {
+	reset := m.ResetFailedLogins(name)
+	if reset {
+		log.Infof(context.Background(), "Reset failed authentication attempts of user %q", name)
+	}
+
+	err := m.setUserLocked(name, false, actor)
+	// The users which are not in the database yet can be locked out too.
+	if reset && errors.Is(err, NoDataFoundError{}) {
+		return nil
+	}
+	return err
+}
+
+// UserLocked returns whether the user was locked with LockUser. The users which are not in the database are not locked.
+func (m *Manager) UserLocked(name string) (bool, error) {
+	u, err := m.cache.UserByName(name)
+	if errors.Is(err, NoDataFoundError{}) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return u.Locked, nil
+}
+
+func (m *Manager) setUserLocked(name string, locked bool, actor string) (err error) {
+	defer decorate.OnError(&err, "failed to set lock of user %q", name)
+
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	// Prevent the user from logging in while we are locking it.
+	m.updateUserMu.Lock()
+	defer m.updateUserMu.Unlock()
+
+	u, err := m.cache.UserByName(name)
+	if err != nil {
+		return err
+	}
+	if u.Locked == locked {
+		log.Infof(context.Background(), "Lock of user %q is already set to %t", name, locked)
+		return nil
+	}
+
+	if err := m.cache.SetUserLocked(u.UID, locked); err != nil {
+		return err
+	}
+
+	log.Infof(context.Background(), "Set lock of user %q to %t", name, locked)
+	action := AuditUserLocked
+	if !locked {
+		action = AuditUserUnlocked
+	}
+	m.audit(actor, types.AuditEvent{Action: action, Target: name})
+	return nil
+}
@@ -0,0 +1,124 @@
+package users_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestLockUser(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username string
+		unlock   bool
+		dbFile   string
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Lock_user":                     {},
+		"Lock_already_locked_user":      {dbFile: "locked_user"},
+		"Unlock_user":                   {unlock: true, dbFile: "locked_user"},
+		"Unlock_already_unlocked_user":  {unlock: true},
+		"Error_if_user_does_not_exist":  {username: "doesnotexist", wantErrType: cache.NoDataFoundError{}},
+		"Error_if_db_has_invalid_entry": {dbFile: "invalid_entry_in_userByName", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.username == "" {
+				tc.username = "user1"
+			}
+			if tc.dbFile == "" {
+				tc.dbFile = "one_user_and_group"
+			}
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
+			m := newManagerForTests(t, cacheDir)
+
+			var err error
+			if tc.unlock {
+				err = m.UnlockUser(tc.username, "test")
+			} else {
+				err = m.LockUser(tc.username, "test")
+			}
+			requireErrorAssertions(t, err, tc.wantErrType, tc.wantErr)
+			if tc.wantErrType != nil || tc.wantErr {
+				return
+			}
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(userstestutils.GetManagerCache(m))
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestLockedUserCannotLogIn(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+	cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "locked_user.db.yaml"), cacheDir)
+	m := newManagerForTests(t, cacheDir)
+
+	u := types.UserInfo{Name: "user1", UID: 1111, Dir: "/home/user1", Shell: "/bin/bash"}
+	err := m.UpdateUser(context.Background(), u, "broker-id")
+	require.ErrorIs(t, err, users.ErrUserLocked, "UpdateUser should refuse to log in a locked user")
+
+	shadow, err := m.ShadowByName("user1")
+	require.NoError(t, err, "ShadowByName should return the locked user")
+	require.True(t, shadow.Locked, "Shadow entry should report the user as locked")
+
+	require.NoError(t, m.UnlockUser("user1", "test"), "UnlockUser should not fail")
+	shadow, err = m.ShadowByName("user1")
+	require.NoError(t, err, "ShadowByName should return the unlocked user")
+	require.False(t, shadow.Locked, "Shadow entry should not report the user as locked anymore")
+}
+
+func TestUserLocked(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username string
+		dbFile   string
+
+		wantLocked bool
+		wantErr    bool
+	}{
+		"Locked_user":                   {dbFile: "locked_user", wantLocked: true},
+		"Unlocked_user":                 {dbFile: "one_user_and_group"},
+		"Unknown_user_is_not_locked":    {username: "doesnotexist", dbFile: "one_user_and_group"},
+		"Error_if_db_has_invalid_entry": {dbFile: "invalid_entry_in_userByName", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.username == "" {
+				tc.username = "user1"
+			}
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
+			m := newManagerForTests(t, cacheDir)
+
+			locked, err := m.UserLocked(tc.username)
+			if tc.wantErr {
+				require.Error(t, err, "UserLocked should return an error but didn't")
+				return
+			}
+			require.NoError(t, err, "UserLocked should not return an error")
+			require.Equal(t, tc.wantLocked, locked, "UserLocked should report whether the user is locked")
+		})
+	}
+}
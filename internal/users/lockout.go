@@ -0,0 +1,255 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+)
+
+// failedLogins are the recent failed authentication attempts of the users, by name, and when they were locked out.
+// Like the ones of pam_faillock, which are kept in /run, they are not persisted: a restart of the daemon resets them.
+type failedLogins struct {
+	mu    sync.Mutex
+	users map[string]*userFailedLogins
+	// sources are the times of the recent failed authentication attempts of any user from each known source, by its
+	// key. There are never more than the number of attempts which lock out the source.
+	sources map[string][]time.Time
+}
+
+// remoteHostSource is the kind of the sources which are remote hosts. The terminals are not counted as sources, as
+// all the users of a shared console would be locked out by the failed attempts of one of them.
+const remoteHostSource = "rhost"
+
+// sourceKey returns the key of the failed authentication attempts from the source of the given kind, so that sources
+// of different kinds with the same name are never counted together.
+func sourceKey(kind, name string) string {
+	return kind + ":" + name
+}
+
+type userFailedLogins struct {
+	attempts []types.FailedLogin
+	// lockedAt is zero if the user is not locked out.
+	lockedAt time.Time
+}
+
+// validateLockout checks that the failed authentication attempts of the users can be counted.
+func validateLockout(config Config) error {
+	if (config.LockoutDeny > 0 || config.LockoutSourceDeny > 0) && config.LockoutFailInterval <= 0 {
+		return fmt.Errorf("invalid lockout fail interval %s, must be positive when the lockout is enabled", config.LockoutFailInterval)
+	}
+	if config.LockoutUnlockTime < 0 {
+		return fmt.Errorf("invalid lockout unlock time %s, must not be negative", config.LockoutUnlockTime)
+	}
+	return nil
+}
+
+// RecordFailedLogin records a failed authentication attempt of the user from the given terminal or remote host, which
+// can be empty if they are unknown. The user is locked out once it fails to authenticate too many times within the
+// configured interval, and so is the remote host once too many attempts of any user come from it, if enabled. The
+// attempt is notified as a security event even if the lockout is disabled.
+func (m *Manager) RecordFailedLogin(name, tty, rhost string) {
+	c := m.config()
+	if name == "" {
+		return
+	}
+	source := rhost
+	if source == "" {
+		source = tty
+	}
+
+	m.failedLogins.mu.Lock()
+	defer m.failedLogins.mu.Unlock()
+
+	now := time.Now()
+	m.pruneFailedLogins(now)
+
+	if c.LockoutDeny == 0 {
+		m.securityEvent(failedLoginEvent(name, source, 0, 0))
+		m.recordSourceFailedLogin(remoteHostSource, rhost, now)
+		return
+	}
+
+	if m.failedLogins.users == nil {
+		m.failedLogins.users = make(map[string]*userFailedLogins)
+	}
+	u := m.failedLogins.users[name]
+	if u == nil {
+		u = &userFailedLogins{}
+		m.failedLogins.users[name] = u
+	}
+	u.attempts = append(u.attempts, types.FailedLogin{Time: now, Source: source})
+	m.securityEvent(failedLoginEvent(name, source, len(u.attempts), c.LockoutFailInterval))
+	m.recordSourceFailedLogin(remoteHostSource, rhost, now)
+
+	if !u.lockedAt.IsZero() || len(u.attempts) < int(c.LockoutDeny) {
+		return
+	}
+
+	u.lockedAt = now
+	details := fmt.Sprintf("%d failed authentication attempts within %s", len(u.attempts), c.LockoutFailInterval)
+	if c.LockoutUnlockTime > 0 {
+		details += fmt.Sprintf(", locked out for %s", c.LockoutUnlockTime)
+	}
+	log.Infof(context.Background(), "User %q locked out after %s", name, details)
+	m.audit(ActorLockout, types.AuditEvent{Action: AuditUserLockedOut, Target: name, Details: details})
+}
+
+// recordSourceFailedLogin records a failed authentication attempt from the source of the given kind, which is locked
+// out once too many attempts of any user come from it within the configured interval, like when it tries the passwords
+// of several users. It must be called with the lock of the failed logins held.
+func (m *Manager) recordSourceFailedLogin(kind, name string, now time.Time) {
+	c := m.config()
+	if name == "" || c.LockoutSourceDeny == 0 {
+		return
+	}
+
+	if m.failedLogins.sources == nil {
+		m.failedLogins.sources = make(map[string][]time.Time)
+	}
+	key := sourceKey(kind, name)
+	attempts := m.failedLogins.sources[key]
+	lockedOut := len(attempts) >= int(c.LockoutSourceDeny)
+
+	// Only the most recent attempts are kept, as the older ones don't change when the lockout ends.
+	attempts = append(attempts, now)
+	if len(attempts) > int(c.LockoutSourceDeny) {
+		attempts = slices.Delete(attempts, 0, len(attempts)-int(c.LockoutSourceDeny))
+	}
+	m.failedLogins.sources[key] = attempts
+
+	// The lockout is only recorded when the source reaches the limit, not on each attempt which keeps it locked out.
+	if lockedOut || len(attempts) < int(c.LockoutSourceDeny) {
+		return
+	}
+	details := fmt.Sprintf("%d failed authentication attempts within %s", c.LockoutSourceDeny, c.LockoutFailInterval)
+	log.Infof(context.Background(), "Source %q locked out after %s", key, details)
+	m.audit(ActorLockout, types.AuditEvent{Action: AuditSourceLockedOut, Target: name, Details: details})
+}
+
+// failedLoginEvent returns the security event of a failed authentication attempt of the user, which is the nth one
+// within the interval if the attempts are counted.
+func failedLoginEvent(name, source string, n int, interval time.Duration) types.AuditEvent {
+	details := "from an unknown source"
+	if source != "" {
+		details = fmt.Sprintf("from %s", source)
+	}
+	if n > 0 {
+		details += fmt.Sprintf(", %d failed authentication attempts within %s", n, interval)
+	}
+	return types.AuditEvent{Time: time.Now(), Actor: ActorLogin, Action: EventLoginFailed, Target: name, Details: details}
+}
+
+// ResetFailedLogins forgets the failed authentication attempts of the user, like after a successful login, which
+// ends its lockout. It returns true if the user had failed authentication attempts.
+func (m *Manager) ResetFailedLogins(name string) bool {
+	m.failedLogins.mu.Lock()
+	defer m.failedLogins.mu.Unlock()
+
+	_, ok := m.failedLogins.users[name]
+	delete(m.failedLogins.users, name)
+	return ok
+}
+
+// Lockout returns whether the user is locked out after too many failed authentication attempts, until when, and its
+// recent failed attempts.
+func (m *Manager) Lockout(name string) types.Lockout {
+	config := m.config()
+
+	m.failedLogins.mu.Lock()
+	defer m.failedLogins.mu.Unlock()
+
+	m.pruneFailedLogins(time.Now())
+
+	u := m.failedLogins.users[name]
+	if u == nil {
+		return types.Lockout{}
+	}
+
+	l := types.Lockout{LockedOut: !u.lockedAt.IsZero(), FailedLogins: slices.Clone(u.attempts)}
+	if l.LockedOut && config.LockoutUnlockTime > 0 {
+		l.LockedUntil = u.lockedAt.Add(config.LockoutUnlockTime)
+	}
+	return l
+}
+
+// RemoteHostLockout returns whether the authentication attempts from the remote host are locked out after too many
+// failed attempts of any user from it, and until when. The lockout of a remote host ends once its failed attempts are
+// older than the configured interval, as a successful login doesn't reset them, or when an administrator unlocks it.
+func (m *Manager) RemoteHostLockout(rhost string) types.Lockout {
+	config := m.config()
+	if rhost == "" || config.LockoutSourceDeny == 0 {
+		return types.Lockout{}
+	}
+
+	m.failedLogins.mu.Lock()
+	defer m.failedLogins.mu.Unlock()
+
+	m.pruneFailedLogins(time.Now())
+
+	attempts := m.failedLogins.sources[sourceKey(remoteHostSource, rhost)]
+	if len(attempts) < int(config.LockoutSourceDeny) {
+		return types.Lockout{}
+	}
+
+	// The source is locked out until fewer than the allowed number of attempts are within the interval.
+	oldest := attempts[len(attempts)-int(config.LockoutSourceDeny)]
+	return types.Lockout{LockedOut: true, LockedUntil: oldest.Add(config.LockoutFailInterval)}
+}
+
+// UnlockRemoteHost forgets the failed authentication attempts from the remote host, which ends its lockout. The actor
+// is recorded in the audit log as the requester of the change. It returns false if there were no failed attempts from
+// the remote host.
+func (m *Manager) UnlockRemoteHost(rhost, actor string) bool {
+	m.failedLogins.mu.Lock()
+	defer m.failedLogins.mu.Unlock()
+
+	m.pruneFailedLogins(time.Now())
+
+	key := sourceKey(remoteHostSource, rhost)
+	if _, ok := m.failedLogins.sources[key]; !ok {
+		return false
+	}
+	delete(m.failedLogins.sources, key)
+
+	log.Infof(context.Background(), "Reset failed authentication attempts from source %q", key)
+	m.audit(actor, types.AuditEvent{Action: AuditSourceUnlocked, Target: rhost})
+	return true
+}
+
+// pruneFailedLogins forgets the failed authentication attempts which are too old to be counted, and ends the lockouts
+// which expired. It must be called with the lock of the failed logins held.
+func (m *Manager) pruneFailedLogins(now time.Time) {
+	c := m.config()
+	for name, u := range m.failedLogins.users {
+		if !u.lockedAt.IsZero() {
+			// The failed attempts of a user locked out are kept until its lockout ends.
+			if c.LockoutDeny == 0 || (c.LockoutUnlockTime > 0 && !now.Before(u.lockedAt.Add(c.LockoutUnlockTime))) {
+				delete(m.failedLogins.users, name)
+			}
+			continue
+		}
+
+		u.attempts = slices.DeleteFunc(u.attempts, func(a types.FailedLogin) bool {
+			return now.Sub(a.Time) > c.LockoutFailInterval
+		})
+		if len(u.attempts) == 0 {
+			delete(m.failedLogins.users, name)
+		}
+	}
+
+	for source, attempts := range m.failedLogins.sources {
+		attempts = slices.DeleteFunc(attempts, func(t time.Time) bool {
+			return c.LockoutSourceDeny == 0 || now.Sub(t) > c.LockoutFailInterval
+		})
+		if len(attempts) == 0 {
+			delete(m.failedLogins.sources, source)
+			continue
+		}
+		m.failedLogins.sources[source] = attempts
+	}
+}
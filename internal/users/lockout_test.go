@@ -0,0 +1,182 @@
+package users_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestLockout(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		username     string
+		deny         uint32
+		failInterval time.Duration
+		unlockTime   time.Duration
+		failures     int
+		reset        bool
+		unlock       bool
+
+		wantLockedOut    bool
+		wantLockedUntil  bool
+		wantFailedLogins int
+		wantAudited      bool
+	}{
+		"Do_not_lock_out_user_with_fewer_failed_attempts":  {deny: 3, unlockTime: time.Hour, failures: 2, wantFailedLogins: 2},
+		"Lock_out_user_after_too_many_failed_attempts":     {deny: 3, unlockTime: time.Hour, failures: 3, wantLockedOut: true, wantLockedUntil: true, wantFailedLogins: 3, wantAudited: true},
+		"Lock_out_user_until_unlocked_without_unlock_time": {deny: 3, failures: 3, wantLockedOut: true, wantFailedLogins: 3, wantAudited: true},
+		"Do_not_lock_out_users_if_lockout_is_disabled":     {failures: 5},
+		"Do_not_count_failed_attempts_out_of_interval":     {deny: 3, failInterval: time.Nanosecond, unlockTime: time.Hour, failures: 3},
+		"End_lockout_after_unlock_time":                    {deny: 3, unlockTime: time.Nanosecond, failures: 3, wantAudited: true},
+		"End_lockout_on_reset":                             {deny: 3, unlockTime: time.Hour, failures: 3, reset: true, wantAudited: true},
+		"End_lockout_when_user_is_unlocked":                {deny: 3, failures: 3, unlock: true, wantAudited: true},
+		"End_lockout_of_user_not_in_database":              {username: "doesnotexist", deny: 3, failures: 3, unlock: true, wantAudited: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.username == "" {
+				tc.username = "user1"
+			}
+			if tc.failInterval == 0 {
+				tc.failInterval = time.Hour
+			}
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "one_user_and_group.db.yaml"), cacheDir)
+
+			config := users.DefaultConfig
+			config.LockoutDeny = tc.deny
+			config.LockoutFailInterval = tc.failInterval
+			config.LockoutUnlockTime = tc.unlockTime
+			m, err := users.NewManager(config, cacheDir)
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			for range tc.failures {
+				m.RecordFailedLogin(tc.username, "pts/0", "192.0.2.1")
+			}
+			if tc.reset {
+				m.ResetFailedLogins(tc.username)
+			}
+			if tc.unlock {
+				require.NoError(t, m.UnlockUser(tc.username, "test"), "UnlockUser should not return an error, but did")
+			}
+
+			got := m.Lockout(tc.username)
+			require.Equal(t, tc.wantLockedOut, got.LockedOut, "Lockout should tell whether the user is locked out")
+			require.Equal(t, tc.wantLockedUntil, !got.LockedUntil.IsZero(), "Lockout should tell until when the user is locked out")
+			require.Len(t, got.FailedLogins, tc.wantFailedLogins, "Lockout should return the recent failed attempts")
+			for _, f := range got.FailedLogins {
+				require.Equal(t, "192.0.2.1", f.Source, "Lockout should return the source of the failed attempts")
+			}
+
+			events, err := m.AuditEvents(types.AuditFilter{Action: users.AuditUserLockedOut})
+			require.NoError(t, err, "AuditEvents should not return an error, but did")
+			if !tc.wantAudited {
+				require.Empty(t, events, "No lockout should be audited")
+				return
+			}
+			require.Len(t, events, 1, "The lockout of the user should be audited once")
+			require.Equal(t, tc.username, events[0].Target, "The lockout should be audited for the user")
+		})
+	}
+}
+
+func TestRemoteHostLockout(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		rhost        string
+		tty          string
+		deny         uint32
+		sourceDeny   uint32
+		failInterval time.Duration
+		failures     int
+		otherHost    bool
+		unlock       bool
+
+		wantLockedOut bool
+		wantAudited   bool
+	}{
+		"Do_not_lock_out_remote_host_with_fewer_failed_attempts": {sourceDeny: 3, failures: 2},
+		"Lock_out_remote_host_after_too_many_failed_attempts":    {sourceDeny: 3, failures: 3, wantLockedOut: true, wantAudited: true},
+		"Audit_lockout_of_remote_host_once":                      {sourceDeny: 3, failures: 5, wantLockedOut: true, wantAudited: true},
+		"End_lockout_when_remote_host_is_unlocked":               {sourceDeny: 3, failures: 3, unlock: true, wantAudited: true},
+		"Do_not_lock_out_other_remote_hosts":                     {sourceDeny: 3, failures: 3, otherHost: true, wantAudited: true},
+		"Do_not_lock_out_unknown_remote_host":                    {rhost: "-", sourceDeny: 3, failures: 3},
+		"Do_not_lock_out_terminals":                              {rhost: "-", tty: "192.0.2.1", sourceDeny: 3, failures: 3},
+		"Do_not_lock_out_remote_hosts_with_user_lockout_only":    {deny: 3, failures: 5},
+		"Do_not_lock_out_remote_hosts_if_lockout_is_disabled":    {failures: 5},
+		"Do_not_count_failed_attempts_out_of_interval":           {sourceDeny: 3, failInterval: time.Nanosecond, failures: 3},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			switch tc.rhost {
+			case "":
+				tc.rhost = "192.0.2.1"
+			case "-":
+				tc.rhost = ""
+			}
+			if tc.failInterval == 0 {
+				tc.failInterval = time.Hour
+			}
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "one_user_and_group.db.yaml"), cacheDir)
+
+			config := users.DefaultConfig
+			config.LockoutDeny = tc.deny
+			config.LockoutSourceDeny = tc.sourceDeny
+			config.LockoutFailInterval = tc.failInterval
+			m, err := users.NewManager(config, cacheDir)
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			// Each failed attempt is for another user, which is never locked out itself.
+			for i := range tc.failures {
+				m.RecordFailedLogin(fmt.Sprintf("user%d", i), tc.tty, tc.rhost)
+			}
+			if tc.unlock {
+				require.True(t, m.UnlockRemoteHost(tc.rhost, "test"), "UnlockRemoteHost should tell that the remote host had failed attempts")
+				require.False(t, m.UnlockRemoteHost(tc.rhost, "test"), "UnlockRemoteHost should tell that the remote host has no failed attempts anymore")
+			}
+
+			rhost := "192.0.2.1"
+			if tc.otherHost {
+				rhost = "192.0.2.2"
+			}
+			got := m.RemoteHostLockout(rhost)
+			require.Equal(t, tc.wantLockedOut, got.LockedOut, "RemoteHostLockout should tell whether the remote host is locked out")
+			require.Equal(t, tc.wantLockedOut, !got.LockedUntil.IsZero(), "RemoteHostLockout should tell until when the remote host is locked out")
+
+			events, err := m.AuditEvents(types.AuditFilter{Action: users.AuditSourceLockedOut})
+			require.NoError(t, err, "AuditEvents should not return an error, but did")
+			if !tc.wantAudited {
+				require.Empty(t, events, "No lockout should be audited")
+				return
+			}
+			require.Len(t, events, 1, "The lockout of the remote host should be audited once")
+			require.Equal(t, tc.rhost, events[0].Target, "The lockout should be audited for the remote host")
+
+			events, err = m.AuditEvents(types.AuditFilter{Action: users.AuditSourceUnlocked})
+			require.NoError(t, err, "AuditEvents should not return an error, but did")
+			if !tc.unlock {
+				require.Empty(t, events, "No unlock should be audited")
+				return
+			}
+			require.Len(t, events, 1, "The unlock of the remote host should be audited once")
+			require.Equal(t, "test", events[0].Actor, "The unlock should be audited with its requester")
+		})
+	}
+}
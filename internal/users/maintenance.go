@@ -0,0 +1,70 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// RunMaintenance prunes orphaned references and the entries of the audit log which are too old from the database,
+// rebuilds its secondary indexes and compacts it.
+func (m *Manager) RunMaintenance() (r types.MaintenanceReport, err error) {
+	defer decorate.OnError(&err, "failed to run maintenance")
+
+	if err := m.checkWritable(); err != nil {
+		return types.MaintenanceReport{}, err
+	}
+
+	// Prevent logins from updating the database while we are rebuilding it.
+	m.updateUserMu.Lock()
+	defer m.updateUserMu.Unlock()
+
+	start := time.Now()
+
+	// The audit log is pruned first, for the compaction to reclaim the space of its entries.
+	config := m.config()
+	var before time.Time
+	if config.AuditLogRetentionDays > 0 {
+		before = start.AddDate(0, 0, -int(config.AuditLogRetentionDays))
+	}
+	pruned, err := m.cache.PruneAuditEntries(before, int(config.AuditLogMaxEntries))
+	if err != nil {
+		return types.MaintenanceReport{}, err
+	}
+	if pruned > 0 {
+		log.Infof(context.Background(), "Removed %d entries from the audit log", pruned)
+	}
+
+	cr, err := m.cache.Maintenance()
+	if err != nil {
+		return types.MaintenanceReport{}, err
+	}
+
+	log.Infof(context.Background(), "Database maintenance done in %s: %d orphaned references pruned, %d index entries rebuilt, size reduced from %d to %d bytes",
+		time.Since(start).Round(time.Millisecond), cr.PrunedReferences, cr.RebuiltIndexEntries, cr.SizeBefore, cr.SizeAfter)
+
+	return types.MaintenanceReport(cr), nil
+}
+
+// delayUntil returns the duration from now until the next occurrence of the time of the day start, formatted as
+// HH:MM. If start is empty, it returns the interval.
+func delayUntil(start string, interval time.Duration, now time.Time) (time.Duration, error) {
+	if start == "" {
+		return interval, nil
+	}
+
+	t, err := time.Parse("15:04", start)
+	if err != nil {
+		return 0, fmt.Errorf("invalid maintenance start time %q, must be formatted as HH:MM: %v", start, err)
+	}
+
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next.Sub(now), nil
+}
@@ -0,0 +1,97 @@
+package users_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestRunMaintenance(t *testing.T) {
+	tests := map[string]struct {
+		dbFile string
+
+		wantErr bool
+	}{
+		"Successfully_run_maintenance": {dbFile: "multiple_users_and_groups"},
+
+		"Error_if_db_has_invalid_entry": {dbFile: "invalid_entry_in_groupToUsers", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// We don't care about the local groups in this test, but we still need to mock them.
+			_ = localgroupstestutils.SetupGroupMock(t, "empty.group")
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
+			m := newManagerForTests(t, cacheDir)
+
+			_, err := m.RunMaintenance()
+			requireErrorAssertions(t, err, nil, tc.wantErr)
+			if tc.wantErr {
+				return
+			}
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(userstestutils.GetManagerCache(m))
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
+
+func TestRunMaintenancePrunesAuditLog(t *testing.T) {
+	t.Parallel()
+
+	m := newManagerForTests(t, t.TempDir())
+	old := cache.AuditEntry{Time: time.Now().AddDate(-2, 0, 0).UTC(), Actor: users.ActorLogin, Action: users.AuditUserAdded, Target: "olduser"}
+	recent := cache.AuditEntry{Time: time.Now().Add(-time.Hour).UTC(), Actor: users.ActorLogin, Action: users.AuditUserAdded, Target: "newuser"}
+	require.NoError(t, userstestutils.GetManagerCache(m).AppendAuditEntries(old, recent), "Setup: could not append audit entries")
+
+	_, err := m.RunMaintenance()
+	require.NoError(t, err, "RunMaintenance should not return an error")
+
+	events, err := m.AuditEvents(types.AuditFilter{})
+	require.NoError(t, err, "AuditEvents should not return an error")
+	require.Equal(t, []types.AuditEvent{types.AuditEvent(recent)}, events, "The entries older than the retention should be removed")
+}
+
+func TestDelayUntil(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		start string
+
+		want    time.Duration
+		wantErr bool
+	}{
+		"Without_start_time_wait_for_interval": {want: 24 * time.Hour},
+		"Start_time_later_today":               {start: "14:00", want: 90 * time.Minute},
+		"Start_time_earlier_today_is_tomorrow": {start: "03:00", want: 14*time.Hour + 30*time.Minute},
+		"Start_time_now_is_tomorrow":           {start: "12:30", want: 24 * time.Hour},
+
+		"Error_on_invalid_start_time": {start: "25:00", wantErr: true},
+		"Error_on_malformed_start":    {start: "noon", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := users.DelayUntil(tc.start, 24*time.Hour, now)
+			if tc.wantErr {
+				require.Error(t, err, "DelayUntil should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "DelayUntil should not return an error, but did")
+			require.Equal(t, tc.want, got, "DelayUntil should return the expected delay")
+		})
+	}
+}
@@ -7,10 +7,18 @@ import (
 	"fmt"
 	"os"
 	"os/user"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"text/template"
+	"time"
 
+	"github.com/ubuntu/authd/internal/crash"
+	"github.com/ubuntu/authd/internal/users/accountsservice"
 	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/devicetokens"
+	"github.com/ubuntu/authd/internal/users/homedir"
 	"github.com/ubuntu/authd/internal/users/idgenerator"
 	"github.com/ubuntu/authd/internal/users/localentries"
 	"github.com/ubuntu/authd/internal/users/tempentries"
@@ -25,6 +33,170 @@ type Config struct {
 	UIDMax uint32 `mapstructure:"uid_max"`
 	GIDMin uint32 `mapstructure:"gid_min"`
 	GIDMax uint32 `mapstructure:"gid_max"`
+	// RemoteGroupsGIDMin and RemoteGroupsGIDMax are the range of the GIDs of the groups provided by the brokers.
+	// If RemoteGroupsGIDMax is 0, those groups use the GID_MIN-GID_MAX range, like the user private groups.
+	RemoteGroupsGIDMin uint32 `mapstructure:"remote_groups_gid_min"`
+	RemoteGroupsGIDMax uint32 `mapstructure:"remote_groups_gid_max"`
+
+	// StaleUsersRetentionDays is the number of days after their last login after which users are considered stale.
+	// A value of 0 disables the stale users policy.
+	StaleUsersRetentionDays uint32 `mapstructure:"stale_users_retention_days"`
+	// StaleUsersAction is what happens to stale users: they are either deleted (the default) or disabled.
+	StaleUsersAction string `mapstructure:"stale_users_action"`
+	// StaleUsersArchiveDir is the directory where the home directories of deleted stale users are archived.
+	// If empty, home directories are not archived.
+	StaleUsersArchiveDir string `mapstructure:"stale_users_archive_dir"`
+
+	// MaintenanceInterval is the interval between two maintenances of the database. 0 disables the maintenance.
+	MaintenanceInterval time.Duration `mapstructure:"maintenance_interval"`
+	// MaintenanceStart is the time of the day, formatted as HH:MM, of the first maintenance of the database.
+	// If empty, the first maintenance happens one interval after the start of the daemon.
+	MaintenanceStart string `mapstructure:"maintenance_start"`
+	// AuditLogRetentionDays is the number of days the entries of the audit log are kept, and AuditLogMaxEntries the
+	// number of its newest entries which are kept. The older entries are removed by the maintenance. 0 disables the
+	// limit.
+	AuditLogRetentionDays uint32 `mapstructure:"audit_log_retention_days"`
+	AuditLogMaxEntries    uint32 `mapstructure:"audit_log_max_entries"`
+
+	// PreSyncInterval is the interval between two creations of the users expected by the brokers which support listing
+	// them, the first one happening at the start of the daemon. 0 disables the pre-sync of the users.
+	PreSyncInterval time.Duration `mapstructure:"presync_interval"`
+
+	// UserInfoTTL is the duration after which the information of a user which was not provided or confirmed again by
+	// its broker is stale. Stale users are flagged as such when listed, and re-validated with their broker when their
+	// account is checked without authenticating with authd. 0 disables the expiration of the information of the users.
+	UserInfoTTL time.Duration `mapstructure:"user_info_ttl"`
+
+	// ReadOnly opens the database read-only: users and groups can be looked up, but they are not created or modified.
+	// The stale users policy, the maintenance of the database and the pre-sync of the users are disabled.
+	ReadOnly bool `mapstructure:"read_only"`
+
+	// LastlogFile is the lastlog file updated on each login, usually /var/log/lastlog. If empty, it's not updated.
+	LastlogFile string `mapstructure:"lastlog_file"`
+
+	// Lastlog2Database is the lastlog2 database updated on each login, usually /var/lib/lastlog/lastlog2.db. It's used
+	// instead of LastlogFile if the lastlog2 library is installed and the database exists. If empty, it's not updated.
+	Lastlog2Database string `mapstructure:"lastlog2_database"`
+
+	// AccountsServiceDir is the data directory of AccountsService, usually /var/lib/AccountsService, in which the icons
+	// of the users and their other data displayed by the greeter and the desktop settings are stored. If empty,
+	// AccountsService is not updated.
+	AccountsServiceDir string `mapstructure:"accountsservice_dir"`
+
+	// AvatarDirs are the directories in which the brokers store the pictures of the users. The pictures are only copied
+	// to AccountsService from these directories, as they are read as root.
+	AvatarDirs []string `mapstructure:"avatar_dirs"`
+
+	// CreateHomeDirs creates the home directories of the users at their first login, with the files of SkelDir.
+	// Brokers can override it for their users in their configuration file.
+	CreateHomeDirs bool `mapstructure:"create_home_dirs"`
+	// SkelDir is the directory whose files are copied to the new home directories. If empty, they are created empty.
+	SkelDir string `mapstructure:"skel_dir"`
+	// HomeDirMode is the mode, in octal, of the new home directories.
+	HomeDirMode string `mapstructure:"home_dir_mode"`
+	// HomeDirUmask is the umask, in octal, applied to the files copied from SkelDir.
+	HomeDirUmask string `mapstructure:"home_dir_umask"`
+	// HomeDirACL are ACL entries, in the setfacl(1) syntax, set on the new home directories.
+	HomeDirACL []string `mapstructure:"home_dir_acl"`
+	// HomeDirSELinuxRelabel restores the default SELinux context of the new home directories with restorecon(8).
+	HomeDirSELinuxRelabel bool `mapstructure:"home_dir_selinux_relabel"`
+	// HomeDirSubdirs are templates of subdirectories created in the new home directories, for example a folder per
+	// tenant of the identity provider. See homeDirTemplateData for the available fields.
+	HomeDirSubdirs []string `mapstructure:"home_dir_subdirs"`
+
+	// HomeDirCleanup is what happens to the home directory and the mail spool of purged or deleted stale users: they
+	// are kept (the default), archived to HomeDirArchiveDir and deleted, or deleted.
+	HomeDirCleanup string `mapstructure:"home_dir_cleanup"`
+	// HomeDirArchiveDir is the directory where the home directories and the mail spools are archived.
+	HomeDirArchiveDir string `mapstructure:"home_dir_archive_dir"`
+	// HomeDirCleanupDryRun only logs what the home directory cleanup would do.
+	HomeDirCleanupDryRun bool `mapstructure:"home_dir_cleanup_dry_run"`
+	// MailSpoolDir is the directory of the mail spools of the users. If empty, mail spools are not cleaned up.
+	MailSpoolDir string `mapstructure:"mail_spool_dir"`
+
+	// QuotaProfiles are the disk quota profiles which can be applied to the users, by name.
+	QuotaProfiles map[string]QuotaProfile `mapstructure:"quota_profiles"`
+	// QuotaGroupProfiles are the names of the quota profiles applied to the members of the given groups.
+	QuotaGroupProfiles map[string]string `mapstructure:"quota_group_profiles"`
+	// QuotaDefaultProfile is the name of the quota profile applied to the users without a broker or a group profile.
+	// If empty, no quota is applied to them.
+	QuotaDefaultProfile string `mapstructure:"quota_default_profile"`
+	// QuotaCommand is the command which applies the quotas, called with the arguments of setquota(8). It defaults to
+	// setquota.
+	QuotaCommand string `mapstructure:"quota_command"`
+
+	// PrimaryGroup is the name of the group shared by the users as their primary group, either a local group, like
+	// "users", or a group provided by the broker. If empty, each user gets a user private group with the name of the
+	// user. Brokers can override it for their users in their configuration file.
+	PrimaryGroup string `mapstructure:"primary_group"`
+
+	// DefaultShell is the login shell of the users whose broker doesn't provide one. Brokers can override it for their
+	// users in their configuration file. If it's not a valid login shell on the machine, /bin/sh is used.
+	DefaultShell string `mapstructure:"default_shell"`
+
+	// MinPwdAge, MaxPwdAge, PwdWarnPeriod and PwdInactivity are the password aging fields, in days, of the shadow
+	// entries of the users. -1 leaves the field empty. Brokers can override them for their users in their
+	// configuration file.
+	MinPwdAge     int `mapstructure:"min_pwd_age"`
+	MaxPwdAge     int `mapstructure:"max_pwd_age"`
+	PwdWarnPeriod int `mapstructure:"pwd_warn_period"`
+	PwdInactivity int `mapstructure:"pwd_inactivity"`
+
+	// ExpiredPasswordAction is what happens when a user whose password expired, according to their broker, logs in:
+	// they either have to change it (the default) or their login is denied.
+	ExpiredPasswordAction string `mapstructure:"expired_password_action"`
+
+	// LocalGroupRules add the users to local groups according to the roles or claims provided by their broker as their
+	// groups. They are evaluated on every login, and the users are removed from the local groups of a rule once they
+	// lose its claim.
+	LocalGroupRules []LocalGroupRule `mapstructure:"local_group_rules"`
+	// LocalGroupsDryRun only logs the changes of the local groups authd would make, to validate the rules before
+	// enforcing them.
+	LocalGroupsDryRun bool `mapstructure:"local_groups_dry_run"`
+	// LocalGroupsSync is when the local groups of the users are reconciled with the ones declared by their broker,
+	// including the removals: on every login (the default), only when the declared groups change, or only when the
+	// users are created.
+	LocalGroupsSync string `mapstructure:"local_groups_sync"`
+	// LocalGroupsAllowlist are the only local groups the users can be added to, if not empty.
+	LocalGroupsAllowlist []string `mapstructure:"local_groups_allowlist"`
+	// LocalGroupsDenylist are local groups the users can't be added to, like "sudo" or "docker". It takes precedence
+	// over the allowlist.
+	LocalGroupsDenylist []string `mapstructure:"local_groups_denylist"`
+	// LocalGroupsMissing are what happens when the users are added to local groups which don't exist, by pattern of
+	// their names. The first matching rule applies, and the groups matching none are ignored.
+	LocalGroupsMissing []MissingLocalGroupRule `mapstructure:"local_groups_missing"`
+	// LocalGroupsGIDMin and LocalGroupsGIDMax are the range of the GIDs of the local groups which are created.
+	LocalGroupsGIDMin uint32 `mapstructure:"local_groups_gid_min"`
+	LocalGroupsGIDMax uint32 `mapstructure:"local_groups_gid_max"`
+
+	// SudoersDir is the directory of the sudoers drop-ins, like /etc/sudoers.d, in which a drop-in granting
+	// SudoersPrivileges is created for each user having the SudoersClaim claim. It's removed once the user loses the
+	// claim or is deleted. If empty, no drop-in is managed.
+	SudoersDir string `mapstructure:"sudoers_dir"`
+	// SudoersClaim is the name of the group provided by the broker, like "admin", granting the sudoers privileges.
+	SudoersClaim string `mapstructure:"sudoers_claim"`
+	// SudoersPrivileges is the privilege specification granted to the users, as in sudoers(5), like
+	// "ALL=(ALL:ALL) ALL".
+	SudoersPrivileges string `mapstructure:"sudoers_privileges"`
+	// VisudoCommand is the command which validates the drop-ins before they are installed, called with the arguments
+	// of visudo(8). It defaults to visudo.
+	VisudoCommand string `mapstructure:"visudo_command"`
+
+	// LockoutDeny is the number of failed authentication attempts of a user, within LockoutFailInterval, after which
+	// it's locked out, like the deny option of pam_faillock. 0 disables the lockout.
+	LockoutDeny uint32 `mapstructure:"lockout_deny"`
+	// LockoutSourceDeny is the number of failed authentication attempts of any user from a remote host, within
+	// LockoutFailInterval, after which the remote host is locked out. 0 disables the lockout of the remote hosts.
+	LockoutSourceDeny uint32 `mapstructure:"lockout_source_deny"`
+	// LockoutFailInterval is the interval in which the failed authentication attempts must happen to lock out the user.
+	LockoutFailInterval time.Duration `mapstructure:"lockout_fail_interval"`
+	// LockoutUnlockTime is how long the user is locked out. If 0, it's locked out until an administrator unlocks it.
+	LockoutUnlockTime time.Duration `mapstructure:"lockout_unlock_time"`
+
+	// DeviceTokenLifetime is how long the device tokens issued by the brokers after a multi-factor authentication are
+	// kept, to be presented to the brokers at the next logins of the users on this machine for them to skip the second
+	// factor. 0 disables the device tokens.
+	DeviceTokenLifetime time.Duration `mapstructure:"device_token_lifetime"`
 }
 
 // DefaultConfig is the default configuration for the user manager.
@@ -33,18 +205,82 @@ var DefaultConfig = Config{
 	UIDMax: 1999999999,
 	GIDMin: 1000000000,
 	GIDMax: 1999999999,
+
+	StaleUsersAction: StaleUsersActionDelete,
+
+	MaintenanceInterval: 24 * time.Hour,
+	MaintenanceStart:    "03:00",
+
+	AuditLogRetentionDays: 365,
+	AuditLogMaxEntries:    100000,
+
+	SkelDir:      homedir.DefaultSkelDir,
+	HomeDirMode:  fmt.Sprintf("%04o", homedir.DefaultMode),
+	HomeDirUmask: "0022",
+
+	HomeDirCleanup:    HomeDirCleanupKeep,
+	HomeDirArchiveDir: "/var/backups/authd",
+	MailSpoolDir:      "/var/mail",
+
+	DefaultShell: "/bin/bash",
+
+	MinPwdAge:     -1,
+	MaxPwdAge:     -1,
+	PwdWarnPeriod: -1,
+	PwdInactivity: -1,
+
+	ExpiredPasswordAction: ExpiredPasswordActionChange,
+
+	LocalGroupsSync:   LocalGroupsSyncLogin,
+	LocalGroupsGIDMin: 100,
+	LocalGroupsGIDMax: 999,
+
+	SudoersClaim:      "admin",
+	SudoersPrivileges: "ALL=(ALL:ALL) ALL",
+
+	LockoutFailInterval: 15 * time.Minute,
+	LockoutUnlockTime:   10 * time.Minute,
 }
 
 // Manager is the manager for any user related operation.
 type Manager struct {
-	cache            *cache.Cache
-	config           Config
+	cache Storage
+	// settings is the current configuration of the manager, which is replaced when it's reloaded.
+	settings atomic.Pointer[settings]
+	// settingsMu serializes the changes of the settings, which are derived from the current ones.
+	settingsMu       sync.Mutex
 	temporaryRecords *tempentries.TemporaryRecords
+	idGenerator      tempentries.IDGenerator
 	updateUserMu     sync.Mutex
+	shellsFile       string
+	changeHandler    func(types.Change)
+	failedLogins     failedLogins
+	deviceTokens     *devicetokens.Store
+
+	// securityEventHandler is called for each security event, like the lockouts of the users.
+	securityEventHandler func(types.AuditEvent)
+
+	stopPeriodicTasks context.CancelFunc
+	periodicTasks     sync.WaitGroup
+}
+
+// settings is the configuration of the manager with the values derived from it.
+type settings struct {
+	Config
+	homeDirOpts    homedir.Options
+	homeDirSubdirs []*template.Template
 }
 
 type options struct {
-	idGenerator tempentries.IDGenerator
+	idGenerator   tempentries.IDGenerator
+	shellsFile    string
+	changeHandler func(types.Change)
+	storage       Storage
+	preSyncSource PreSyncSource
+
+	deviceTokenKeyring *devicetokens.Keyring
+
+	securityEventHandler func(types.AuditEvent)
 }
 
 // Option is a function that allows changing some of the default behaviors of the manager.
@@ -58,11 +294,58 @@ func WithIDGenerator(g tempentries.IDGenerator) Option {
 	}
 }
 
+// WithShellsFile makes the manager read the valid login shells from a specific file instead of /etc/shells.
+// This option is only useful in tests.
+func WithShellsFile(path string) Option {
+	return func(o *options) {
+		o.shellsFile = path
+	}
+}
+
+// WithChangeHandler makes the manager call h for each change of the users and groups in the database.
+func WithChangeHandler(h func(types.Change)) Option {
+	return func(o *options) {
+		o.changeHandler = h
+	}
+}
+
+// WithSecurityEventHandler makes the manager call h for each security event, whose action is one of SecurityEvents.
+// h is called while the manager records the event, and must not block.
+func WithSecurityEventHandler(h func(types.AuditEvent)) Option {
+	return func(o *options) {
+		o.securityEventHandler = h
+	}
+}
+
+// WithStorage makes the manager store the users and groups in s instead of the bbolt database of the cache
+// directory. The manager takes ownership of s and closes it when stopped.
+func WithStorage(s Storage) Option {
+	return func(o *options) {
+		o.storage = s
+	}
+}
+
+// WithPreSyncSource makes the manager periodically create the users returned by source, if the pre-sync of the users
+// is enabled in the configuration.
+func WithPreSyncSource(source PreSyncSource) Option {
+	return func(o *options) {
+		o.preSyncSource = source
+	}
+}
+
+// WithDeviceTokenKeyring makes the manager store the device tokens in k instead of the persistent keyrings of the
+// users. This option is only useful in tests.
+func WithDeviceTokenKeyring(k *devicetokens.Keyring) Option {
+	return func(o *options) {
+		o.deviceTokenKeyring = k
+	}
+}
+
 // NewManager creates a new user manager.
 func NewManager(config Config, cacheDir string, args ...Option) (m *Manager, err error) {
 	log.Debugf(context.Background(), "Creating user manager with config: %+v", config)
 
-	opts := &options{}
+	opts := &options{shellsFile: defaultShellsFile}
 	for _, arg := range args {
 		arg(opts)
 	}
@@ -75,6 +358,9 @@ func NewManager(config Config, cacheDir string, args ...Option) (m *Manager, err
 		if config.GIDMin >= config.GIDMax {
 			return nil, errors.New("GID_MIN must be less than GID_MAX")
 		}
+		if config.RemoteGroupsGIDMax != 0 && config.RemoteGroupsGIDMin >= config.RemoteGroupsGIDMax {
+			return nil, errors.New("REMOTE_GROUPS_GID_MIN must be less than REMOTE_GROUPS_GID_MAX")
+		}
 		// Check that the number of possible UIDs is at least twice the number of possible pre-auth users.
 		numUIDs := config.UIDMax - config.UIDMin
 		minNumUIDs := uint32(tempentries.MaxPreAuthUsers * 2)
@@ -87,37 +373,204 @@ func NewManager(config Config, cacheDir string, args ...Option) (m *Manager, err
 			UIDMax: config.UIDMax,
 			GIDMin: config.GIDMin,
 			GIDMax: config.GIDMax,
+
+			RemoteGroupGIDMin: config.RemoteGroupsGIDMin,
+			RemoteGroupGIDMax: config.RemoteGroupsGIDMax,
 		}
 	}
 
+	s, err := newSettings(config)
+	if err != nil {
+		return nil, err
+	}
+
+	maintenanceDelay, err := delayUntil(config.MaintenanceStart, config.MaintenanceInterval, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
 	m = &Manager{
-		config:           config,
 		temporaryRecords: tempentries.NewTemporaryRecords(opts.idGenerator),
+		idGenerator:      opts.idGenerator,
+		shellsFile:       opts.shellsFile,
+		changeHandler:    opts.changeHandler,
+
+		securityEventHandler: opts.securityEventHandler,
+	}
+	m.settings.Store(s)
+
+	keyring := opts.deviceTokenKeyring
+	if keyring == nil {
+		keyring = devicetokens.NewKeyring(func(name string) (uint32, error) {
+			u, err := m.cache.UserByName(name)
+			return u.UID, err
+		})
+	}
+	m.deviceTokens = devicetokens.New(cacheDir, devicetokens.WithKeyring(keyring))
+
+	m.cache = opts.storage
+	if m.cache == nil {
+		newCache := cache.New
+		if config.ReadOnly {
+			newCache = cache.NewReadOnly
+		}
+		c, err := newCache(cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		m.cache = c
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.stopPeriodicTasks = cancel
+	if config.ReadOnly {
+		log.Info(ctx, "The users manager is read-only, the stale users policy, the database maintenance and the pre-sync of the users are disabled")
+		return m, nil
+	}
+	if config.StaleUsersRetentionDays > 0 {
+		m.runPeriodically(ctx, 0, staleUsersCheckInterval, func() {
+			if _, err := m.ExpireStaleUsers(); err != nil {
+				log.Warningf(ctx, "%v", err)
+			}
+		})
+	}
+	m.runPeriodically(ctx, expiredLocalGroupsCheckInterval, expiredLocalGroupsCheckInterval, func() {
+		if _, err := m.RevokeExpiredLocalGroups(); err != nil {
+			log.Warningf(ctx, "%v", err)
+		}
+	})
+	if config.PreSyncInterval > 0 && opts.preSyncSource != nil {
+		m.runPeriodically(ctx, 0, config.PreSyncInterval, func() { m.preSync(ctx, opts.preSyncSource) })
+	}
+	if config.MaintenanceInterval > 0 {
+		m.runPeriodically(ctx, maintenanceDelay, config.MaintenanceInterval, func() {
+			if _, err := m.RunMaintenance(); err != nil {
+				log.Warningf(ctx, "%v", err)
+			}
+		})
+	}
+
+	return m, nil
+}
+
+// newSettings validates the configuration and returns the settings of the manager matching it.
+func newSettings(config Config) (*settings, error) {
+	switch config.StaleUsersAction {
+	case "", StaleUsersActionDelete, StaleUsersActionDisable:
+	default:
+		return nil, fmt.Errorf("invalid stale users action %q, must be %q or %q", config.StaleUsersAction, StaleUsersActionDelete, StaleUsersActionDisable)
 	}
 
-	c, err := cache.New(cacheDir)
+	homeDirOpts, homeDirSubdirs, err := parseHomeDirConfig(config)
 	if err != nil {
 		return nil, err
 	}
-	m.cache = c
+	if err := validateHomeDirCleanup(config); err != nil {
+		return nil, err
+	}
+	if err := validateQuotaConfig(config); err != nil {
+		return nil, err
+	}
+	if err := validateDefaultShell(config.DefaultShell); err != nil {
+		return nil, err
+	}
+	if err := validateShadowAging(config); err != nil {
+		return nil, err
+	}
+	if err := validateExpiredPasswordAction(config.ExpiredPasswordAction); err != nil {
+		return nil, err
+	}
+	if err := validateLocalGroupsFilter(config); err != nil {
+		return nil, err
+	}
+	if err := validateMissingLocalGroups(config); err != nil {
+		return nil, err
+	}
+	if err := validateLocalGroupRules(config); err != nil {
+		return nil, err
+	}
+	if err := validateLocalGroupsSync(config.LocalGroupsSync); err != nil {
+		return nil, err
+	}
+	if err := validateSudoersConfig(config); err != nil {
+		return nil, err
+	}
+	if err := validateLockout(config); err != nil {
+		return nil, err
+	}
+	if err := validateDeviceTokens(config); err != nil {
+		return nil, err
+	}
 
-	return m, nil
+	return &settings{Config: config, homeDirOpts: homeDirOpts, homeDirSubdirs: homeDirSubdirs}, nil
 }
 
-// Stop closes the underlying cache.
+// config returns the current configuration of the manager.
+func (m *Manager) config() *settings {
+	return m.settings.Load()
+}
+
+// Config returns the current configuration of the manager.
+func (m *Manager) Config() Config {
+	return m.config().Config
+}
+
+// Stop stops the periodic checks of the manager and closes the underlying cache.
 func (m *Manager) Stop() error {
+	m.stopPeriodicTasks()
+	m.periodicTasks.Wait()
 	return m.cache.Close()
 }
 
-// UpdateUser updates the user information in the cache.
-func (m *Manager) UpdateUser(u types.UserInfo) (err error) {
+// runPeriodically runs the task after the given delay and then at every interval, until Stop is called.
+// If the delay is 0, the task is run before the manager can be stopped.
+func (m *Manager) runPeriodically(ctx context.Context, delay, interval time.Duration, task func()) {
+	// A panic of the task is reported, and the task is run again at the next interval.
+	run := func() {
+		defer crash.Recover(ctx, "periodic task", nil)
+		task()
+	}
+
+	m.periodicTasks.Add(1)
+	go func() {
+		defer m.periodicTasks.Done()
+
+		if delay == 0 {
+			run()
+			delay = interval
+		}
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			run()
+			timer.Reset(interval)
+		}
+	}()
+}
+
+// ErrUserOfOtherBroker is returned when a broker provides a user which was already provided by another broker.
+var ErrUserOfOtherBroker = errors.New("user is provided by another broker")
+
+// UpdateUser updates the user information in the cache. brokerID is the broker which provided the user information: if
+// the user already exists, it must be the broker which provided it before.
+func (m *Manager) UpdateUser(ctx context.Context, u types.UserInfo, brokerID string) (err error) {
 	defer decorate.OnError(&err, "failed to update user %q", u.Name)
 
-	if u.Name == "" {
-		return errors.New("empty username")
-	}
+	_, err = m.updateUser(ctx, u, brokerID, false)
+	return err
+}
 
-	var uid uint32
+// updateUser updates the user information in the cache. If preSync is true, the user is only created if it doesn't
+// exist yet, and the creation is not recorded as a login of the user. It returns whether the user was updated.
+func (m *Manager) updateUser(ctx context.Context, u types.UserInfo, brokerID string, preSync bool) (updated bool, err error) {
+	// The configuration can be reloaded during the update, which must apply a single version of it.
+	config := m.config()
 
 	// Prevent a TOCTOU race condition between the check for existence in our database and the registration of the
 	// temporary user/group records. This does not prevent a race condition where a user is created by some other NSS
@@ -125,10 +578,78 @@ func (m *Manager) UpdateUser(u types.UserInfo) (err error) {
 	m.updateUserMu.Lock()
 	defer m.updateUserMu.Unlock()
 
+	update, err := m.prepareUserUpdate(config, u, brokerID, preSync, make(map[string]cache.GroupDB))
+	if update != nil {
+		defer update.cleanup()
+	}
+	if err != nil || update == nil {
+		return false, err
+	}
+
+	userDB, err := m.cache.ApplyUserUpdate(update.UserUpdate)
+	if err != nil {
+		return false, err
+	}
+	if err := m.finishUserUpdate(ctx, config, update, userDB); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// userUpdate is an update of a user prepared by prepareUserUpdate, to be applied to the database alone or in a batch,
+// and then finished by finishUserUpdate.
+type userUpdate struct {
+	cache.UserUpdate
+
+	// info is the user information provided by the broker, with its resolved groups.
+	info    types.UserInfo
+	oldUser cache.UserDB
+
+	localGroups     []string
+	oldLocalGroups  []string
+	syncLocalGroups bool
+	oldGroups       []string
+	newGroups       []string
+
+	// appliedEvents are the events recorded in the audit log when the update was applied.
+	appliedEvents []types.AuditEvent
+	// cleanups remove the temporary records of the IDs generated for the user and its groups, once they are stored.
+	cleanups []func()
+}
+
+// cleanup removes the temporary records of the update.
+func (update *userUpdate) cleanup() {
+	for _, cleanup := range update.cleanups {
+		cleanup()
+	}
+}
+
+// prepareUserUpdate checks the user information and generates the IDs of the new user and groups, without changing the
+// database. It must be called with updateUserMu held, and the update must be cleaned up once applied, even if it
+// failed.
+//
+// batchGroups are the groups of the updates already prepared in the batch, by UGID, which are reused. It returns a nil
+// update if there is nothing to update, like for an existing user when preSync is true.
+func (m *Manager) prepareUserUpdate(config *settings, u types.UserInfo, brokerID string, preSync bool, batchGroups map[string]cache.GroupDB) (update *userUpdate, err error) {
+	if err := m.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if u.Name == "" {
+		return nil, errors.New("empty username")
+	}
+
+	update = &userUpdate{}
+	var uid uint32
+
 	// Check if the user already exists in the database
 	oldUser, err := m.cache.UserByName(u.Name)
 	if err != nil && !errors.Is(err, cache.NoDataFoundError{}) {
-		return fmt.Errorf("could not get user %q: %w", u.Name, err)
+		return update, fmt.Errorf("could not get user %q: %w", u.Name, err)
+	}
+	if err == nil && preSync {
+		// The user is refreshed on its next login.
+		return nil, nil
 	}
 	if errors.Is(err, cache.NoDataFoundError{}) {
 		// Check if the user exists on the system
@@ -136,65 +657,117 @@ func (m *Manager) UpdateUser(u types.UserInfo) (err error) {
 		var unknownUserErr user.UnknownUserError
 		if !errors.As(err, &unknownUserErr) {
 			log.Errorf(context.Background(), "User already exists on the system: %+v", existingUser)
-			return fmt.Errorf("user %q already exists on the system (but not in this authd instance)", u.Name)
+			return update, fmt.Errorf("user %q already exists on the system (but not in this authd instance)", u.Name)
 		}
 
 		// The user does not exist, so we generate a unique UID for it. To avoid that a user with the same UID is
 		// created by some other NSS source, this also registers a temporary user in our NSS handler. We remove that
-		// temporary user before returning from this function, at which point the user is added to the database (so we
-		// don't need the temporary user anymore to keep the UID unique).
+		// temporary user once the update is applied, at which point the user is added to the database (so we don't
+		// need the temporary user anymore to keep the UID unique).
 		var cleanup func()
 		uid, cleanup, err = m.temporaryRecords.RegisterUser(u.Name)
 		if err != nil {
-			return fmt.Errorf("could not register user %q: %w", u.Name, err)
+			return update, fmt.Errorf("could not register user %q: %w", u.Name, err)
 		}
-		defer cleanup()
+		update.cleanups = append(update.cleanups, cleanup)
 	} else {
+		if isExpired(oldUser.ExpirationDate) {
+			return update, fmt.Errorf("user %q is disabled", u.Name)
+		}
+		if oldUser.Locked {
+			return update, ErrUserLocked
+		}
+		// Don't let a broker take over a user provided by another broker, which can be a different identity with the
+		// same name.
+		if err := m.CheckBrokerForUser(u.Name, brokerID); err != nil {
+			return update, err
+		}
 		// The user already exists in the database, use the existing UID to avoid permission issues.
 		uid = oldUser.UID
 	}
 
-	// Prepend the user private group
-	u.Groups = append([]types.GroupInfo{{Name: u.Name, UGID: u.Name}}, u.Groups...)
+	// Resolve the nested groups, so that the user is a member of all the groups containing its groups.
+	groups, err := flattenGroups(u.Groups)
+	if err != nil {
+		return update, err
+	}
+	u.Groups = applyLocalGroupRules(groups, config.LocalGroupRules)
+
+	primaryGroup := u.PrimaryGroup
+	if primaryGroup == "" {
+		primaryGroup = config.PrimaryGroup
+	}
+	// Prepend the user private group, unless the users share a primary group.
+	if primaryGroup == "" {
+		u.Groups = append([]types.GroupInfo{{Name: u.Name, UGID: u.Name}}, u.Groups...)
+	}
 
 	var authdGroups []cache.GroupDB
 	var localGroups []string
-	for _, g := range u.Groups {
+	localGroupsExpiry := make(map[string]int64)
+	var auditEvents []types.AuditEvent
+	for i, g := range u.Groups {
 		if g.Name == "" {
-			return fmt.Errorf("empty group name for user %q", u.Name)
+			return update, fmt.Errorf("empty group name for user %q", u.Name)
 		}
 
 		if g.UGID == "" {
 			// An empty UGID means that the group is local.
+			if g.ExpiresAt != 0 {
+				if !time.Unix(g.ExpiresAt, 0).After(time.Now()) {
+					log.Debugf(context.Background(), "Not adding user %q to local group %q, whose membership expired", u.Name, g.Name)
+					continue
+				}
+				localGroupsExpiry[g.Name] = g.ExpiresAt
+			}
 			localGroups = append(localGroups, g.Name)
 			continue
 		}
 
+		// The group is already added by a previous update of the batch.
+		if batchGroup, ok := batchGroups[g.UGID]; ok {
+			g.GID = &batchGroup.GID
+			authdGroups = append(authdGroups, cache.NewGroupDB(g.Name, *g.GID, g.UGID, nil))
+			continue
+		}
+		if err := checkBatchGroupNameConflict(batchGroups, g.Name, g.UGID); err != nil {
+			return update, err
+		}
+
 		// It's not a local group, so before storing it in the database, check if a group with the same name already
 		// exists.
 		if err := m.checkGroupNameConflict(g.Name, g.UGID); err != nil {
-			return err
+			return update, err
 		}
 
 		// Check if the group already exists in the database
 		oldGroup, err := m.findGroup(g)
 		if err != nil && !errors.Is(err, cache.NoDataFoundError{}) {
 			// Unexpected error
-			return err
+			return update, err
 		}
 		if errors.Is(err, cache.NoDataFoundError{}) {
 			// The group does not exist in the database, so we generate a unique GID for it. Similar to the RegisterUser
 			// call above, this also registers a temporary group in our NSS handler. We remove that temporary group
-			// before returning from this function, at which point the group is added to the database (so we don't need
-			// the temporary group anymore to keep the GID unique).
-			gid, cleanup, err := m.temporaryRecords.RegisterGroup(g.Name)
+			// once the update is applied, at which point the group is added to the database (so we don't need the
+			// temporary group anymore to keep the GID unique).
+			registerGroup := m.temporaryRecords.RegisterRemoteGroup
+			if i == 0 && primaryGroup == "" {
+				// The user private group uses the same range as the other groups which are not provided by a broker.
+				registerGroup = m.temporaryRecords.RegisterGroup
+			}
+			gid, cleanup, err := registerGroup(g.Name)
 			if err != nil {
-				return fmt.Errorf("could not generate GID for group %q: %v", g.Name, err)
+				return update, fmt.Errorf("could not generate GID for group %q: %v", g.Name, err)
 			}
-
-			defer cleanup()
+			update.cleanups = append(update.cleanups, cleanup)
 
 			g.GID = &gid
+			auditEvents = append(auditEvents, types.AuditEvent{
+				Action:  AuditGroupAdded,
+				Target:  g.Name,
+				Details: fmt.Sprintf("GID %d", gid),
+			})
 		} else {
 			// The group already exists in the database, use the existing GID to avoid permission issues.
 			g.GID = &oldGroup.GID
@@ -203,29 +776,134 @@ func (m *Manager) UpdateUser(u types.UserInfo) (err error) {
 		authdGroups = append(authdGroups, cache.NewGroupDB(g.Name, *g.GID, g.UGID, nil))
 	}
 
+	localGroups = config.filterLocalGroups(u.Name, localGroups)
+
 	oldLocalGroups, err := m.cache.UserLocalGroups(uid)
 	if err != nil && !errors.Is(err, cache.NoDataFoundError{}) {
-		return err
+		return update, err
+	}
+	// The local groups which are not reconciled are left as they were applied, so that the database keeps tracking
+	// the ones authd added the user to.
+	syncLocalGroups := config.syncLocalGroups(oldUser.Name == "", localGroups, oldLocalGroups)
+	if !syncLocalGroups {
+		log.Debugf(context.Background(), "Not reconciling local groups of user %q, according to the %q sync policy", u.Name, config.LocalGroupsSync)
+		localGroups = oldLocalGroups
+		localGroupsExpiry = oldUser.LocalGroupsExpiry
 	}
 
-	// Update user information in the cache.
-	userDB := cache.NewUserDB(u.Name, uid, authdGroups[0].GID, u.Gecos, u.Dir, u.Shell)
-	if err := m.cache.UpdateUserEntry(userDB, authdGroups, localGroups); err != nil {
-		return err
+	oldGroups := slices.Clone(oldLocalGroups)
+	if oldUser.Name != "" {
+		oldAuthdGroups, err := m.cache.UserGroups(uid)
+		if err != nil && !errors.Is(err, cache.NoDataFoundError{}) {
+			return update, err
+		}
+		for _, g := range oldAuthdGroups {
+			oldGroups = append(oldGroups, g.Name)
+		}
+	}
+
+	if oldUser.Dir != "" && oldUser.Dir != u.Dir {
+		u.Dir = refreshedHomeDir(u.Name, oldUser.Dir, u.Dir)
+	}
+
+	newGroups := slices.Clone(localGroups)
+	for _, g := range authdGroups {
+		newGroups = append(newGroups, g.Name)
+	}
+
+	gid, err := primaryGID(primaryGroup, authdGroups)
+	if err != nil {
+		return update, err
+	}
+
+	newUser := cache.NewUserDB(u.Name, uid, gid, u.Gecos, u.Dir, u.Shell)
+	for g, t := range localGroupsExpiry {
+		// The memberships in the local groups which were filtered out are not tracked.
+		if slices.Contains(localGroups, g) {
+			if newUser.LocalGroupsExpiry == nil {
+				newUser.LocalGroupsExpiry = make(map[string]int64)
+			}
+			newUser.LocalGroupsExpiry[g] = t
+		}
 	}
+	config.applyShadowAging(&newUser, u.ShadowAging)
+	newUser.PasswordExpiresAt = passwordExpiresAt(u.PasswordExpiresAt, u.PasswordChanged, oldUser)
+
+	// The user, its groups, its broker and the audit log are updated in a single transaction, so that a failure doesn't
+	// leave a partially updated user.
+	actor := ActorLogin
+	if preSync {
+		actor = ActorPreSync
+	}
+	update.UserUpdate = cache.UserUpdate{
+		User:               newUser,
+		AuthdGroups:        authdGroups,
+		LocalGroups:        localGroups,
+		BrokerID:           brokerID,
+		ExtendedAttributes: u.ExtendedAttributes,
+		KeepLastLogin:      preSync,
+		// The attributes which were modified locally are kept by the cache, so compare with the stored ones.
+		AuditEntries: func(stored cache.UserDB) []cache.AuditEntry {
+			update.appliedEvents = append(auditEvents, userUpdateEvents(oldUser, stored, oldGroups, newGroups)...)
+			return auditEntries(actor, update.appliedEvents...)
+		},
+	}
+	update.info = u
+	update.oldUser = oldUser
+	update.localGroups, update.oldLocalGroups, update.syncLocalGroups = localGroups, oldLocalGroups, syncLocalGroups
+	update.oldGroups, update.newGroups = oldGroups, newGroups
+
+	// The groups of the user are only reused by the next updates of the batch if its update is valid.
+	for _, g := range authdGroups {
+		batchGroups[g.UGID] = g
+	}
+
+	return update, nil
+}
+
+// finishUserUpdate notifies the changes of the applied update and applies the ones outside of the database, like the
+// memberships of the local groups and the sudoers drop-in. userDB is the user as stored.
+func (m *Manager) finishUserUpdate(ctx context.Context, config *settings, update *userUpdate, userDB cache.UserDB) error {
+	changedGroups := append(difference(update.newGroups, update.oldGroups), difference(update.oldGroups, update.newGroups)...)
+	m.notify(append(changesOf(update.appliedEvents...), groupChanges(changedGroups...)...)...)
 
-	// Update local groups.
-	if err := localentries.Update(u.Name, localGroups, oldLocalGroups); err != nil {
+	// Update local groups. The changes are audited with the broker and the session of the login.
+	if update.syncLocalGroups {
+		ctx := log.WithFields(ctx, log.BrokerField, update.BrokerID)
+		if err := localentries.Update(ctx, userDB.Name, update.localGroups, update.oldLocalGroups, localentries.WithDryRun(config.LocalGroupsDryRun),
+			localentries.WithMissingGroups(config.missingLocalGroupAction, config.LocalGroupsGIDMin, config.LocalGroupsGIDMax)); err != nil {
+			return err
+		}
+	}
+	if err := config.updateSudoers(userDB.Name, userDB.UID, update.info.Groups); err != nil {
 		return err
 	}
 
-	if err = checkHomeDirOwnership(userDB.Dir, userDB.UID, userDB.GID); err != nil {
+	if config.AccountsServiceDir != "" {
+		// The user can still log in if it's not displayed properly by the desktop.
+		if err := accountsservice.Update(config.AccountsServiceDir, userDB.Name, update.info.Avatar,
+			accountsservice.WithIconDirs(config.AvatarDirs)); err != nil {
+			log.Warningf(context.Background(), "%v", err)
+		}
+	}
+
+	if err := checkHomeDirOwnership(userDB.Dir, userDB.UID, userDB.GID); err != nil {
 		return fmt.Errorf("failed to check home directory owner and group: %w", err)
 	}
 
 	return nil
 }
 
+// checkBatchGroupNameConflict checks that the group doesn't have the name of another group of the batch.
+func checkBatchGroupNameConflict(batchGroups map[string]cache.GroupDB, name string, ugid string) error {
+	for _, g := range batchGroups {
+		if g.Name == name && g.UGID != ugid {
+			return fmt.Errorf("group %q already exists with a different UGID %q", name, g.UGID)
+		}
+	}
+	return nil
+}
+
 // checkGroupNameConflict checks if a group with the given name already exists.
 // If it does, it checks if it has the same UGID.
 func (m *Manager) checkGroupNameConflict(name string, ugid string) error {
@@ -277,6 +955,42 @@ func (m *Manager) findGroup(group types.GroupInfo) (oldGroup cache.GroupDB, err
 	return m.cache.GroupByName(group.Name)
 }
 
+// refreshedHomeDir returns the home directory to use when the broker provides a new one for an existing user. The
+// existing home directory is kept if it exists on the system, so that the data of the user is not lost.
+func refreshedHomeDir(name, oldHome, newHome string) string {
+	if _, err := os.Stat(oldHome); err == nil {
+		log.Warningf(context.Background(), "User %q already has a homedir. The existing %q one will be kept instead of %q", name, oldHome, newHome)
+		return oldHome
+	}
+
+	log.Infof(context.Background(), "Home directory of user %q changed from %q to %q", name, oldHome, newHome)
+	return newHome
+}
+
+// primaryGID returns the GID of the primary group of the user: its user private group, which is the first of its
+// authd groups, if name is empty, or else the group with this name, either one of its authd groups or a local group.
+func primaryGID(name string, authdGroups []cache.GroupDB) (uint32, error) {
+	if name == "" {
+		return authdGroups[0].GID, nil
+	}
+
+	for _, g := range authdGroups {
+		if g.Name == name {
+			return g.GID, nil
+		}
+	}
+
+	g, found, err := localentries.GroupByName(name)
+	if err != nil {
+		return 0, err
+	}
+	if found {
+		return g.GID, nil
+	}
+
+	return 0, fmt.Errorf("primary group %q is neither a group of the user nor a local group", name)
+}
+
 // checkHomeDirOwnership checks if the home directory of the user is owned by the user and the user's group.
 // If not, it logs a warning.
 func checkHomeDirOwnership(home string, uid, gid uint32) error {
@@ -319,12 +1033,46 @@ func (m *Manager) BrokerForUser(username string) (string, error) {
 	return brokerID, nil
 }
 
-// UpdateBrokerForUser updates the broker ID for the given user.
+// CheckBrokerForUser returns ErrUserOfOtherBroker if the user is already provided by another broker than brokerID. The
+// user can't be given to another broker, which can provide a different identity with the same name: it must be removed
+// first, for the other broker to provide it as a new user.
+func (m *Manager) CheckBrokerForUser(username, brokerID string) error {
+	owner, err := m.cache.BrokerForUser(username)
+	if errors.Is(err, cache.NoDataFoundError{}) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if owner != "" && owner != brokerID {
+		log.Warningf(context.Background(), "User %q is provided by broker %q, denying its assignment to broker %q", username, owner, brokerID)
+		return ErrUserOfOtherBroker
+	}
+	return nil
+}
+
+// UpdateBrokerForUser updates the broker ID for the given user. It returns ErrUserOfOtherBroker if the user is already
+// provided by another broker.
 func (m *Manager) UpdateBrokerForUser(username, brokerID string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	previous, err := m.cache.BrokerForUser(username)
+	if err != nil && !errors.Is(err, cache.NoDataFoundError{}) {
+		return err
+	}
+	if err := m.CheckBrokerForUser(username, brokerID); err != nil {
+		return err
+	}
+
 	if err := m.cache.UpdateBrokerForUser(username, brokerID); err != nil {
 		return err
 	}
 
+	if previous != brokerID {
+		m.notify(types.Change{Kind: ChangeUserBrokerChanged, Name: username})
+	}
 	return nil
 }
 
@@ -440,5 +1188,9 @@ func (m *Manager) AllShadows() ([]types.ShadowEntry, error) {
 //
 // The temporary user record is removed when UpdateUser is called with the same username.
 func (m *Manager) RegisterUserPreAuth(name string) (uint32, error) {
+	// No new users can be created in read-only mode, so there is no need to register them.
+	if err := m.checkWritable(); err != nil {
+		return 0, err
+	}
 	return m.temporaryRecords.RegisterPreAuthUser(name)
 }
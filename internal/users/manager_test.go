@@ -5,11 +5,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/ubuntu/authd/internal/testutils/golden"
 	"github.com/ubuntu/authd/internal/users"
 	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/devicetokens"
 	"github.com/ubuntu/authd/internal/users/idgenerator"
 	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
 	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
@@ -27,23 +29,131 @@ func TestNewManager(t *testing.T) {
 		gidMin          uint32
 		gidMax          uint32
 
+		remoteGroupsGIDMin uint32
+		remoteGroupsGIDMax uint32
+
+		staleUsersAction string
+		maintenanceStart string
+
+		skelDir        string
+		homeDirMode    string
+		homeDirUmask   string
+		homeDirACL     []string
+		homeDirSubdirs []string
+
+		homeDirCleanup    string
+		homeDirArchiveDir string
+
+		quotaProfiles       map[string]users.QuotaProfile
+		quotaDefaultProfile string
+		quotaGroupProfiles  map[string]string
+
+		defaultShell string
+
+		maxPwdAge int
+
+		expiredPasswordAction string
+
+		localGroupRules []users.LocalGroupRule
+		localGroupsSync string
+
+		localGroupsAllowlist []string
+		localGroupsDenylist  []string
+
+		localGroupsMissing []users.MissingLocalGroupRule
+		localGroupsGIDMin  uint32
+		localGroupsGIDMax  uint32
+
+		sudoersDir        string
+		sudoersClaim      string
+		sudoersPrivileges string
+
+		lockoutDeny         uint32
+		lockoutFailInterval time.Duration
+		lockoutUnlockTime   time.Duration
+
+		deviceTokenLifetime time.Duration
+
 		wantErr bool
 	}{
-		"Successfully_create_manager_with_default_config": {},
-		"Successfully_create_manager_with_custom_config":  {uidMin: 10000, uidMax: 20000, gidMin: 10000, gidMax: 20000},
+		"Successfully_create_manager_with_default_config":          {},
+		"Successfully_create_manager_with_custom_config":           {uidMin: 10000, uidMax: 20000, gidMin: 10000, gidMax: 20000},
+		"Successfully_create_manager_with_remote_groups_GID_range": {remoteGroupsGIDMin: 30000, remoteGroupsGIDMax: 40000},
+		"Successfully_create_manager_with_home_directories_policy": {
+			skelDir: "/etc/authd/skel", homeDirMode: "0700", homeDirUmask: "077",
+			homeDirACL: []string{"g:admins:rx"}, homeDirSubdirs: []string{"work/{{.Domain}}"},
+		},
+		"Successfully_create_manager_with_local_group_rules": {localGroupRules: []users.LocalGroupRule{{Claim: "admin", Groups: []string{"localgroup1", "localgroup2"}}}},
+		"Successfully_create_manager_with_sudoers_drop_ins":  {sudoersDir: "/etc/sudoers.d", sudoersClaim: "admin", sudoersPrivileges: "ALL=(ALL:ALL) ALL"},
 
 		// Corrupted databases
 		"New_recreates_any_missing_buckets_and_delete_unknowns": {dbFile: "database_with_unknown_bucket"},
 
-		"Error_when_database_is_corrupted":     {corruptedDbFile: true, wantErr: true},
-		"Error_if_cacheDir_does_not_exist":     {dbFile: "-", wantErr: true},
-		"Error_if_UID_MIN_is_equal_to_UID_MAX": {uidMin: 1000, uidMax: 1000, wantErr: true},
-		"Error_if_GID_MIN_is_equal_to_GID_MAX": {gidMin: 1000, gidMax: 1000, wantErr: true},
-		"Error_if_UID_range_is_too_small":      {uidMin: 1000, uidMax: 2000, wantErr: true},
+		"Error_when_database_is_corrupted":                                 {corruptedDbFile: true, wantErr: true},
+		"Error_if_cacheDir_does_not_exist":                                 {dbFile: "-", wantErr: true},
+		"Error_if_UID_MIN_is_equal_to_UID_MAX":                             {uidMin: 1000, uidMax: 1000, wantErr: true},
+		"Error_if_GID_MIN_is_equal_to_GID_MAX":                             {gidMin: 1000, gidMax: 1000, wantErr: true},
+		"Error_if_UID_range_is_too_small":                                  {uidMin: 1000, uidMax: 2000, wantErr: true},
+		"Error_if_REMOTE_GROUPS_GID_MIN_is_equal_to_REMOTE_GROUPS_GID_MAX": {remoteGroupsGIDMin: 1000, remoteGroupsGIDMax: 1000, wantErr: true},
+		"Error_if_stale_users_action_is_invalid":                           {staleUsersAction: "archive", wantErr: true},
+		"Error_if_maintenance_start_is_invalid":                            {maintenanceStart: "invalid", wantErr: true},
+		"Error_if_skel_dir_is_relative":                                    {skelDir: "skel", wantErr: true},
+		"Error_if_home_dir_mode_is_not_octal":                              {homeDirMode: "0790", wantErr: true},
+		"Error_if_home_dir_mode_denies_access_to_owner":                    {homeDirMode: "0550", wantErr: true},
+		"Error_if_home_dir_umask_is_invalid":                               {homeDirUmask: "01777", wantErr: true},
+		"Error_if_home_dir_ACL_is_invalid":                                 {homeDirACL: []string{"admins:rx"}, wantErr: true},
+		"Error_if_home_dir_subdir_template_is_invalid":                     {homeDirSubdirs: []string{"{{.Domain"}, wantErr: true},
+		"Error_if_home_dir_subdir_template_uses_unknown_field":             {homeDirSubdirs: []string{"{{.Tenant}}"}, wantErr: true},
+		"Error_if_home_dir_subdir_is_outside_of_home":                      {homeDirSubdirs: []string{"../{{.Domain}}"}, wantErr: true},
+		"Error_if_home_dir_cleanup_is_invalid":                             {homeDirCleanup: "shred", wantErr: true},
+		"Error_if_home_dir_archive_dir_is_relative":                        {homeDirCleanup: users.HomeDirCleanupArchive, homeDirArchiveDir: "archives", wantErr: true},
+		"Error_if_quota_profile_file_system_is_relative":                   {quotaProfiles: map[string]users.QuotaProfile{"p": {Filesystem: "home"}}, wantErr: true},
+		"Error_if_quota_profile_soft_limit_is_greater_than_hard_limit":     {quotaProfiles: map[string]users.QuotaProfile{"p": {Filesystem: "/home", BlockSoftLimit: 2, BlockHardLimit: 1}}, wantErr: true},
+		"Error_if_default_quota_profile_does_not_exist":                    {quotaDefaultProfile: "doesnotexist", wantErr: true},
+		"Error_if_group_quota_profile_does_not_exist":                      {quotaGroupProfiles: map[string]string{"group1": "doesnotexist"}, wantErr: true},
+		"Error_if_default_shell_is_not_an_absolute_path":                   {defaultShell: "bash", wantErr: true},
+		"Error_if_password_aging_field_is_invalid":                         {maxPwdAge: -2, wantErr: true},
+		"Error_if_expired_password_action_is_invalid":                      {expiredPasswordAction: "lock", wantErr: true},
+
+		"Error_if_local_group_rule_has_no_claim":      {localGroupRules: []users.LocalGroupRule{{Groups: []string{"localgroup1"}}}, wantErr: true},
+		"Error_if_local_group_rule_has_no_groups":     {localGroupRules: []users.LocalGroupRule{{Claim: "admin"}}, wantErr: true},
+		"Error_if_local_group_of_rule_does_not_exist": {localGroupRules: []users.LocalGroupRule{{Claim: "admin", Groups: []string{"localgroup1", "doesnotexist"}}}, wantErr: true},
+		"Error_if_local_groups_sync_is_invalid":       {localGroupsSync: "never", wantErr: true},
+
+		"Error_if_local_groups_allowlist_has_empty_group": {localGroupsAllowlist: []string{"localgroup1", ""}, wantErr: true},
+		"Error_if_local_groups_denylist_has_empty_group":  {localGroupsDenylist: []string{""}, wantErr: true},
+		"Error_if_local_group_of_rule_is_denied":          {localGroupRules: []users.LocalGroupRule{{Claim: "admin", Groups: []string{"localgroup1"}}}, localGroupsDenylist: []string{"localgroup1"}, wantErr: true},
+		"Error_if_local_group_of_rule_is_not_allowed":     {localGroupRules: []users.LocalGroupRule{{Claim: "admin", Groups: []string{"localgroup1"}}}, localGroupsAllowlist: []string{"localgroup2"}, wantErr: true},
+
+		"Successfully_create_manager_with_created_local_group_of_rule": {
+			localGroupRules:    []users.LocalGroupRule{{Claim: "admin", Groups: []string{"localgroup1", "created-group"}}},
+			localGroupsMissing: []users.MissingLocalGroupRule{{Pattern: "created-*", Action: users.MissingLocalGroupCreate}},
+		},
+		"Error_if_missing_local_group_rule_has_no_pattern":      {localGroupsMissing: []users.MissingLocalGroupRule{{Action: users.MissingLocalGroupCreate}}, wantErr: true},
+		"Error_if_missing_local_group_rule_pattern_is_invalid":  {localGroupsMissing: []users.MissingLocalGroupRule{{Pattern: "[", Action: users.MissingLocalGroupFail}}, wantErr: true},
+		"Error_if_missing_local_group_rule_action_is_invalid":   {localGroupsMissing: []users.MissingLocalGroupRule{{Pattern: "*", Action: "skip"}}, wantErr: true},
+		"Error_if_GID_range_of_created_local_groups_is_invalid": {localGroupsMissing: []users.MissingLocalGroupRule{{Pattern: "*", Action: users.MissingLocalGroupCreate}}, localGroupsGIDMin: 999, localGroupsGIDMax: 100, wantErr: true},
+		"Error_if_GID_range_of_created_local_groups_overlaps":   {localGroupsMissing: []users.MissingLocalGroupRule{{Pattern: "*", Action: users.MissingLocalGroupCreate}}, localGroupsGIDMax: 1000000000, wantErr: true},
+		"Error_if_local_group_of_rule_does_not_exist_and_is_not_created": {
+			localGroupRules:    []users.LocalGroupRule{{Claim: "admin", Groups: []string{"doesnotexist"}}},
+			localGroupsMissing: []users.MissingLocalGroupRule{{Pattern: "*", Action: users.MissingLocalGroupFail}},
+			wantErr:            true,
+		},
+
+		"Error_if_sudoers_dir_is_relative":           {sudoersDir: "sudoers.d", sudoersClaim: "admin", sudoersPrivileges: "ALL=(ALL:ALL) ALL", wantErr: true},
+		"Error_if_sudoers_claim_is_empty":            {sudoersDir: "/etc/sudoers.d", sudoersClaim: "-", wantErr: true},
+		"Error_if_sudoers_privileges_are_blank":      {sudoersDir: "/etc/sudoers.d", sudoersPrivileges: " ", wantErr: true},
+		"Error_if_sudoers_privileges_span_two_lines": {sudoersDir: "/etc/sudoers.d", sudoersClaim: "admin", sudoersPrivileges: "ALL=(ALL) ALL\nALL", wantErr: true},
+
+		"Successfully_create_manager_with_lockout":       {lockoutDeny: 3},
+		"Error_if_lockout_fail_interval_is_not_positive": {lockoutDeny: 3, lockoutFailInterval: -time.Minute, wantErr: true},
+		"Error_if_lockout_unlock_time_is_negative":       {lockoutUnlockTime: -time.Minute, wantErr: true},
+
+		"Error_if_device_token_lifetime_is_negative": {deviceTokenLifetime: -time.Minute, wantErr: true},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			destCmdsFile := localgroupstestutils.SetupGPasswdMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+			groupsFile := localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
 
 			cacheDir := t.TempDir()
 			if tc.dbFile == "" {
@@ -73,8 +183,73 @@ func TestNewManager(t *testing.T) {
 			if tc.gidMax != 0 {
 				config.GIDMax = tc.gidMax
 			}
+			config.RemoteGroupsGIDMin = tc.remoteGroupsGIDMin
+			config.RemoteGroupsGIDMax = tc.remoteGroupsGIDMax
+			if tc.staleUsersAction != "" {
+				config.StaleUsersAction = tc.staleUsersAction
+			}
+			if tc.maintenanceStart != "" {
+				config.MaintenanceStart = tc.maintenanceStart
+			}
+			if tc.skelDir != "" {
+				config.SkelDir = tc.skelDir
+			}
+			if tc.homeDirMode != "" {
+				config.HomeDirMode = tc.homeDirMode
+			}
+			config.HomeDirUmask = tc.homeDirUmask
+			config.HomeDirACL = tc.homeDirACL
+			config.HomeDirSubdirs = tc.homeDirSubdirs
+			if tc.homeDirCleanup != "" {
+				config.HomeDirCleanup = tc.homeDirCleanup
+			}
+			if tc.homeDirArchiveDir != "" {
+				config.HomeDirArchiveDir = tc.homeDirArchiveDir
+			}
+			config.QuotaProfiles = tc.quotaProfiles
+			config.QuotaDefaultProfile = tc.quotaDefaultProfile
+			config.QuotaGroupProfiles = tc.quotaGroupProfiles
+			if tc.defaultShell != "" {
+				config.DefaultShell = tc.defaultShell
+			}
+			if tc.maxPwdAge != 0 {
+				config.MaxPwdAge = tc.maxPwdAge
+			}
+			if tc.expiredPasswordAction != "" {
+				config.ExpiredPasswordAction = tc.expiredPasswordAction
+			}
+			config.LocalGroupRules = tc.localGroupRules
+			if tc.localGroupsSync != "" {
+				config.LocalGroupsSync = tc.localGroupsSync
+			}
+			config.LocalGroupsAllowlist = tc.localGroupsAllowlist
+			config.LocalGroupsDenylist = tc.localGroupsDenylist
+			config.LocalGroupsMissing = tc.localGroupsMissing
+			if tc.localGroupsGIDMin != 0 {
+				config.LocalGroupsGIDMin = tc.localGroupsGIDMin
+			}
+			if tc.localGroupsGIDMax != 0 {
+				config.LocalGroupsGIDMax = tc.localGroupsGIDMax
+			}
+			config.SudoersDir = tc.sudoersDir
+			if tc.sudoersClaim == "-" {
+				config.SudoersClaim = ""
+			} else if tc.sudoersClaim != "" {
+				config.SudoersClaim = tc.sudoersClaim
+			}
+			if tc.sudoersPrivileges != "" {
+				config.SudoersPrivileges = tc.sudoersPrivileges
+			}
+			config.LockoutDeny = tc.lockoutDeny
+			if tc.lockoutFailInterval != 0 {
+				config.LockoutFailInterval = tc.lockoutFailInterval
+			}
+			if tc.lockoutUnlockTime != 0 {
+				config.LockoutUnlockTime = tc.lockoutUnlockTime
+			}
+			config.DeviceTokenLifetime = tc.deviceTokenLifetime
 
-			m, err := users.NewManager(config, cacheDir)
+			m, err := users.NewManager(config, cacheDir, users.WithDeviceTokenKeyring(devicetokens.Z_ForTests_NewKeyring(t)))
 			if tc.wantErr {
 				require.Error(t, err, "NewManager should return an error, but did not")
 				return
@@ -86,7 +261,7 @@ func TestNewManager(t *testing.T) {
 
 			golden.CheckOrUpdate(t, got)
 
-			localgroupstestutils.RequireGPasswdOutput(t, destCmdsFile, golden.Path(t)+".gpasswd.output")
+			localgroupstestutils.RequireGroupFile(t, groupsFile, golden.Path(t)+".group")
 		})
 	}
 }
@@ -109,6 +284,8 @@ type userCase struct {
 type groupCase struct {
 	types.GroupInfo
 	GID uint32 // The GID to generate for this group
+	// ParentGIDs are the GIDs to generate for the parents of this group, in the order in which they are nested.
+	ParentGIDs []uint32
 }
 
 func TestUpdateUser(t *testing.T) {
@@ -132,14 +309,20 @@ func TestUpdateUser(t *testing.T) {
 			{GroupInfo: types.GroupInfo{Name: "localgroup1", UGID: ""}},
 			{GroupInfo: types.GroupInfo{Name: "group1", UGID: "1"}, GID: 11111},
 		},
-		"mixed-groups-gpasswd-fail": {
-			{GroupInfo: types.GroupInfo{Name: "group1", UGID: "1"}, GID: 11111},
-			{GroupInfo: types.GroupInfo{Name: "gpasswdfail", UGID: ""}},
-		},
 		"nameless-group":          {{GroupInfo: types.GroupInfo{Name: "", UGID: "1"}, GID: 11111}},
 		"different-name-same-gid": {{GroupInfo: types.GroupInfo{Name: "newgroup1", UGID: "1"}, GID: 11111}},
 		"group-exists-on-system":  {{GroupInfo: types.GroupInfo{Name: "root", UGID: "1"}, GID: 11111}},
 		"no-groups":               {},
+		"nested-groups": {
+			{GroupInfo: types.GroupInfo{Name: "group1", UGID: "1", Parents: []types.GroupInfo{
+				{Name: "parentgroup1", UGID: "2", Parents: []types.GroupInfo{{Name: "localgroup1"}}},
+			}}, GID: 11111, ParentGIDs: []uint32{22222}},
+		},
+		"nested-groups-cycle": {
+			{GroupInfo: types.GroupInfo{Name: "group1", UGID: "1", Parents: []types.GroupInfo{
+				{Name: "parentgroup1", UGID: "2", Parents: []types.GroupInfo{{Name: "group1", UGID: "1"}}},
+			}}, GID: 11111, ParentGIDs: []uint32{22222}},
+		},
 		// This group case has no GID to generate, because it's expected that the GID of the old group is re-used
 		"different-name-same-ugid": {{GroupInfo: types.GroupInfo{Name: "renamed-group", UGID: "12345678"}}},
 	}
@@ -147,6 +330,7 @@ func TestUpdateUser(t *testing.T) {
 	tests := map[string]struct {
 		userCase   string
 		groupsCase string
+		brokerID   string
 
 		dbFile          string
 		localGroupsFile string
@@ -161,6 +345,7 @@ func TestUpdateUser(t *testing.T) {
 		"GID_does_not_change_if_group_with_same_UGID_exists":                {groupsCase: "different-name-same-ugid", dbFile: "one_user_and_group"},
 		"GID_does_not_change_if_group_with_same_name_and_empty_UGID_exists": {groupsCase: "authd-group", dbFile: "group-with-empty-UGID"},
 		"Removing_last_user_from_a_group_keeps_the_group_record":            {groupsCase: "no-groups", dbFile: "one_user_and_group"},
+		"Successfully_update_user_with_nested_groups":                       {groupsCase: "nested-groups", localGroupsFile: "users_in_groups.group"},
 
 		"Error_if_user_has_no_username":                           {userCase: "nameless", wantErr: true, noOutput: true},
 		"Error_if_group_has_no_name":                              {groupsCase: "nameless-group", wantErr: true, noOutput: true},
@@ -168,6 +353,8 @@ func TestUpdateUser(t *testing.T) {
 		"Error_if_group_with_same_name_but_different_UGID_exists": {groupsCase: "authd-group", dbFile: "one_user_and_group", wantErr: true, noOutput: true},
 		"Error_if_user_exists_on_system":                          {userCase: "user-exists-on-system", wantErr: true, noOutput: true},
 		"Error_if_group_exists_on_system":                         {groupsCase: "group-exists-on-system", wantErr: true, noOutput: true},
+		"Error_if_user_is_provided_by_another_broker":             {brokerID: "other-broker-id", dbFile: "one_user_and_group", wantErr: true, noOutput: true},
+		"Error_if_group_is_nested_in_itself":                      {groupsCase: "nested-groups-cycle", wantErr: true, noOutput: true},
 
 		"Error_on_invalid_entry": {groupsCase: "authd-group", dbFile: "invalid_entry_in_userToGroups", localGroupsFile: "users_in_groups.group", wantErr: true, noOutput: true},
 	}
@@ -177,14 +364,17 @@ func TestUpdateUser(t *testing.T) {
 				t.Parallel()
 			}
 
-			var destCmdsFile string
+			var groupsFile string
 			if tc.localGroupsFile != "" {
-				destCmdsFile = localgroupstestutils.SetupGPasswdMock(t, filepath.Join("testdata", "groups", tc.localGroupsFile))
+				groupsFile = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", tc.localGroupsFile))
 			}
 
 			if tc.userCase == "" {
 				tc.userCase = "user1"
 			}
+			if tc.brokerID == "" {
+				tc.brokerID = "broker-id"
+			}
 
 			user := userCases[tc.userCase]
 			user.Dir = "/home/" + user.Name
@@ -205,6 +395,7 @@ func TestUpdateUser(t *testing.T) {
 				if group.GID != 0 {
 					gids = append(gids, group.GID)
 				}
+				gids = append(gids, group.ParentGIDs...)
 			}
 
 			managerOpts := []users.Option{
@@ -222,7 +413,7 @@ func TestUpdateUser(t *testing.T) {
 				oldUID = oldUser.UID
 			}
 
-			err := m.UpdateUser(user.UserInfo)
+			err := m.UpdateUser(context.Background(), user.UserInfo, tc.brokerID)
 			log.Debugf(context.Background(), "UpdateUser error: %v", err)
 
 			requireErrorAssertions(t, err, nil, tc.wantErr)
@@ -241,7 +432,39 @@ func TestUpdateUser(t *testing.T) {
 
 			golden.CheckOrUpdateYAML(t, got)
 
-			localgroupstestutils.RequireGPasswdOutput(t, destCmdsFile, golden.Path(t)+".gpasswd.output")
+			localgroupstestutils.RequireGroupFile(t, groupsFile, golden.Path(t)+".group")
+		})
+	}
+}
+
+func TestRefreshedHomeDir(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		oldHomeExists bool
+
+		wantOldHome bool
+	}{
+		"Use_new_home_directory_if_old_one_does_not_exist": {},
+		"Keep_old_home_directory_if_it_exists":             {oldHomeExists: true, wantOldHome: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			oldHome := filepath.Join(t.TempDir(), "old")
+			newHome := filepath.Join(t.TempDir(), "new")
+			if tc.oldHomeExists {
+				require.NoError(t, os.Mkdir(oldHome, 0700), "Setup: could not create old home directory")
+			}
+
+			got := users.RefreshedHomeDir("user1", oldHome, newHome)
+
+			want := newHome
+			if tc.wantOldHome {
+				want = oldHome
+			}
+			require.Equal(t, want, got, "RefreshedHomeDir should return the expected home directory")
 		})
 	}
 }
@@ -263,8 +486,8 @@ func TestBrokerForUser(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about the output of gpasswd in this test, but we still need to mock it.
-			_ = localgroupstestutils.SetupGPasswdMock(t, "empty.group")
+			// We don't care about the local groups in this test, but we still need to mock them.
+			_ = localgroupstestutils.SetupGroupMock(t, "empty.group")
 
 			cacheDir := t.TempDir()
 			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
@@ -285,25 +508,31 @@ func TestBrokerForUser(t *testing.T) {
 func TestUpdateBrokerForUser(t *testing.T) {
 	tests := map[string]struct {
 		username string
+		brokerID string
 
 		dbFile string
 
 		wantErr     bool
 		wantErrType error
 	}{
-		"Successfully_update_broker_for_user": {},
+		"Successfully_update_broker_for_user":                  {username: "userwithoutbroker"},
+		"Successfully_update_broker_for_user_with_same_broker": {brokerID: "broker-id"},
 
-		"Error_if_user_does_not_exist":  {username: "doesnotexist", wantErrType: cache.NoDataFoundError{}},
-		"Error_if_db_has_invalid_entry": {dbFile: "invalid_entry_in_userByName", wantErr: true},
+		"Error_if_user_does_not_exist":                {username: "doesnotexist", wantErrType: cache.NoDataFoundError{}},
+		"Error_if_db_has_invalid_entry":               {dbFile: "invalid_entry_in_userByName", wantErr: true},
+		"Error_if_user_is_provided_by_another_broker": {wantErrType: users.ErrUserOfOtherBroker},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about the output of gpasswd in this test, but we still need to mock it.
-			_ = localgroupstestutils.SetupGPasswdMock(t, "empty.group")
+			// We don't care about the local groups in this test, but we still need to mock them.
+			_ = localgroupstestutils.SetupGroupMock(t, "empty.group")
 
 			if tc.username == "" {
 				tc.username = "user1"
 			}
+			if tc.brokerID == "" {
+				tc.brokerID = "ExampleBrokerID"
+			}
 			if tc.dbFile == "" {
 				tc.dbFile = "multiple_users_and_groups"
 			}
@@ -312,7 +541,7 @@ func TestUpdateBrokerForUser(t *testing.T) {
 			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
 			m := newManagerForTests(t, cacheDir)
 
-			err := m.UpdateBrokerForUser(tc.username, "ExampleBrokerID")
+			err := m.UpdateBrokerForUser(tc.username, tc.brokerID)
 
 			requireErrorAssertions(t, err, tc.wantErrType, tc.wantErr)
 			if tc.wantErrType != nil || tc.wantErr {
@@ -350,8 +579,8 @@ func TestUserByIDAndName(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about the output of gpasswd in this test, but we still need to mock it.
-			_ = localgroupstestutils.SetupGPasswdMock(t, "empty.group")
+			// We don't care about the local groups in this test, but we still need to mock them.
+			_ = localgroupstestutils.SetupGroupMock(t, "empty.group")
 
 			cacheDir := t.TempDir()
 			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
@@ -403,8 +632,8 @@ func TestAllUsers(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about the output of gpasswd in this test, but we still need to mock it.
-			_ = localgroupstestutils.SetupGPasswdMock(t, "empty.group")
+			// We don't care about the local groups in this test, but we still need to mock them.
+			_ = localgroupstestutils.SetupGroupMock(t, "empty.group")
 
 			cacheDir := t.TempDir()
 			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
@@ -445,8 +674,8 @@ func TestGroupByIDAndName(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about the output of gpasswd in this test, but we still need to mock it.
-			_ = localgroupstestutils.SetupGPasswdMock(t, "empty.group")
+			// We don't care about the local groups in this test, but we still need to mock them.
+			_ = localgroupstestutils.SetupGroupMock(t, "empty.group")
 
 			cacheDir := t.TempDir()
 			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
@@ -497,8 +726,8 @@ func TestAllGroups(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about the output of gpasswd in this test, but we still need to mock it.
-			_ = localgroupstestutils.SetupGPasswdMock(t, "empty.group")
+			// We don't care about the local groups in this test, but we still need to mock them.
+			_ = localgroupstestutils.SetupGroupMock(t, "empty.group")
 
 			cacheDir := t.TempDir()
 			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
@@ -532,8 +761,8 @@ func TestShadowByName(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about the output of gpasswd in this test, but we still need to mock it.
-			_ = localgroupstestutils.SetupGPasswdMock(t, "empty.group")
+			// We don't care about the local groups in this test, but we still need to mock them.
+			_ = localgroupstestutils.SetupGroupMock(t, "empty.group")
 
 			cacheDir := t.TempDir()
 			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
@@ -564,8 +793,8 @@ func TestAllShadows(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			// We don't care about the output of gpasswd in this test, but we still need to mock it.
-			_ = localgroupstestutils.SetupGPasswdMock(t, "empty.group")
+			// We don't care about the local groups in this test, but we still need to mock them.
+			_ = localgroupstestutils.SetupGroupMock(t, "empty.group")
 
 			cacheDir := t.TempDir()
 			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
@@ -584,10 +813,6 @@ func TestAllShadows(t *testing.T) {
 	}
 }
 
-func TestMockgpasswd(t *testing.T) {
-	localgroupstestutils.Mockgpasswd(t)
-}
-
 func requireErrorAssertions(t *testing.T, gotErr, wantErrType error, wantErr bool) {
 	t.Helper()
 
@@ -602,9 +827,62 @@ func requireErrorAssertions(t *testing.T, gotErr, wantErrType error, wantErr boo
 	require.NoError(t, gotErr, "Error should not be returned")
 }
 
+func TestUpdateUserPrimaryGroup(t *testing.T) {
+	tests := map[string]struct {
+		configPrimaryGroup string
+		userPrimaryGroup   string
+		groups             []types.GroupInfo
+		generatedGIDs      []uint32
+
+		wantGID uint32
+		wantErr bool
+	}{
+		"User_private_group_by_default":                       {groups: []types.GroupInfo{{Name: "group1", UGID: "1"}}, wantGID: 11110},
+		"Shared_primary_group_provided_by_the_broker":         {configPrimaryGroup: "group1", groups: []types.GroupInfo{{Name: "group1", UGID: "1"}}, generatedGIDs: []uint32{11111}, wantGID: 11111},
+		"Shared_local_primary_group":                          {configPrimaryGroup: "localgroup1", wantGID: 41},
+		"Primary_group_of_the_broker_overrides_configuration": {configPrimaryGroup: "localgroup1", userPrimaryGroup: "localgroup3", wantGID: 45},
+
+		"Error_if_primary_group_does_not_exist": {configPrimaryGroup: "doesnotexist", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+			if tc.generatedGIDs == nil {
+				tc.generatedGIDs = []uint32{11110, 11111}
+			}
+
+			config := users.DefaultConfig
+			config.PrimaryGroup = tc.configPrimaryGroup
+			m, err := users.NewManager(config, t.TempDir(), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+				UIDsToGenerate: []uint32{1111},
+				GIDsToGenerate: tc.generatedGIDs,
+			}))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			u := types.UserInfo{Name: "user1", Dir: "/home/user1", Shell: "/bin/bash", Groups: tc.groups, PrimaryGroup: tc.userPrimaryGroup}
+			err = m.UpdateUser(context.Background(), u, "broker-id")
+			if tc.wantErr {
+				require.Error(t, err, "UpdateUser should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "UpdateUser should not return an error, but did")
+
+			got, err := m.UserByName("user1")
+			require.NoError(t, err, "UserByName should not return an error, but did")
+			require.Equal(t, tc.wantGID, got.GID, "UpdateUser did not set the expected primary group")
+
+			_, err = m.GroupByName("user1")
+			require.Equal(t, tc.configPrimaryGroup == "", err == nil, "A user private group should only be created without a shared primary group")
+		})
+	}
+}
+
 func newManagerForTests(t *testing.T, cacheDir string, opts ...users.Option) *users.Manager {
 	t.Helper()
 
+	// The device tokens are not stored in the persistent keyrings of the users of the machine.
+	opts = append([]users.Option{users.WithDeviceTokenKeyring(devicetokens.Z_ForTests_NewKeyring(t))}, opts...)
 	m, err := users.NewManager(users.DefaultConfig, cacheDir, opts...)
 	require.NoError(t, err, "NewManager should not return an error, but did")
 
@@ -0,0 +1,45 @@
+package users
+
+import (
+	"time"
+
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/decorate"
+)
+
+// Metrics returns statistics about the users and groups and about the database, for capacity planning.
+func (m *Manager) Metrics() (metrics types.Metrics, err error) {
+	defer decorate.OnError(&err, "failed to get metrics")
+
+	cm, err := m.cache.Metrics()
+	if err != nil {
+		return types.Metrics{}, err
+	}
+
+	created, err := m.cache.CountAuditEntriesSince(AuditUserAdded, time.Now().Add(-time.Hour))
+	if err != nil {
+		return types.Metrics{}, err
+	}
+
+	metrics = types.Metrics{
+		Users:                   cm.Users,
+		Groups:                  cm.Groups,
+		UsersCreatedLastHour:    created,
+		DatabaseSize:            cm.Size,
+		WriteTransactions:       cm.WriteTransactions,
+		MaxWriteTransactionTime: cm.MaxWriteTransactionTime,
+	}
+	if cm.WriteTransactions > 0 {
+		metrics.AverageWriteTransactionTime = cm.WriteTransactionsTime / time.Duration(cm.WriteTransactions)
+	}
+
+	return metrics, nil
+}
+
+// CheckDatabase returns an error if the database can't be read.
+func (m *Manager) CheckDatabase() (err error) {
+	defer decorate.OnError(&err, "database check failed")
+
+	_, err = m.cache.Metrics()
+	return err
+}
@@ -0,0 +1,37 @@
+package users_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+	cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "multiple_users_and_groups.db.yaml"), cacheDir)
+	m := newManagerForTests(t, cacheDir)
+
+	err := userstestutils.GetManagerCache(m).AppendAuditEntries(
+		cache.AuditEntry{Time: time.Now().Add(-2 * time.Hour), Action: users.AuditUserAdded, Target: "user1"},
+		cache.AuditEntry{Time: time.Now().Add(-time.Minute), Action: users.AuditUserAdded, Target: "user2"},
+		cache.AuditEntry{Time: time.Now(), Action: users.AuditUserUpdated, Target: "user2"},
+	)
+	require.NoError(t, err, "Setup: AppendAuditEntries should not return an error")
+
+	got, err := m.Metrics()
+	require.NoError(t, err, "Metrics should not return an error")
+	require.Equal(t, 4, got.Users, "Metrics should count the users")
+	require.Equal(t, 5, got.Groups, "Metrics should count the groups")
+	require.Equal(t, 1, got.UsersCreatedLastHour, "Metrics should only count the users created during the last hour")
+	require.Positive(t, got.DatabaseSize, "Metrics should return the size of the database")
+	require.Equal(t, uint64(1), got.WriteTransactions, "Metrics should count the write transactions")
+	require.Positive(t, got.AverageWriteTransactionTime, "Metrics should return the average duration of the write transactions")
+	require.Equal(t, got.AverageWriteTransactionTime, got.MaxWriteTransactionTime, "With a single write transaction, the average and maximum durations should match")
+}
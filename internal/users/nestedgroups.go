@@ -0,0 +1,63 @@
+package users
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+// flattenGroups returns the groups followed by all the groups they are nested in, directly or transitively. Each group
+// is only returned once and without its parents. It returns an error if a group is nested in itself.
+func flattenGroups(groups []types.GroupInfo) ([]types.GroupInfo, error) {
+	var flattened []types.GroupInfo
+	seen := make(map[string]bool)
+
+	// path contains the groups from the one the user is a direct member of to the one being flattened.
+	var flatten func(groups []types.GroupInfo, path []types.GroupInfo) error
+	flatten = func(groups []types.GroupInfo, path []types.GroupInfo) error {
+		for _, g := range groups {
+			key := groupKey(g)
+			for i, p := range path {
+				if groupKey(p) != key {
+					continue
+				}
+				var cycle []string
+				for _, c := range path[i:] {
+					cycle = append(cycle, c.Name)
+				}
+				cycle = append(cycle, g.Name)
+				return fmt.Errorf("group %q is nested in itself: %s", g.Name, strings.Join(cycle, " -> "))
+			}
+
+			if !seen[key] {
+				seen[key] = true
+				flattened = append(flattened, types.GroupInfo{Name: g.Name, GID: g.GID, UGID: g.UGID, ExpiresAt: g.ExpiresAt})
+			} else if i := slices.IndexFunc(flattened, func(f types.GroupInfo) bool { return groupKey(f) == key }); flattened[i].ExpiresAt != 0 {
+				// A membership provided more than once lasts as long as the longest one.
+				if g.ExpiresAt == 0 || g.ExpiresAt > flattened[i].ExpiresAt {
+					flattened[i].ExpiresAt = g.ExpiresAt
+				}
+			}
+
+			if err := flatten(g.Parents, append(path, g)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := flatten(groups, nil); err != nil {
+		return nil, err
+	}
+	return flattened, nil
+}
+
+// groupKey identifies a group provided by the broker: remote groups by their UGID and local groups by their name.
+func groupKey(g types.GroupInfo) string {
+	if g.UGID == "" {
+		return "local:" + g.Name
+	}
+	return "remote:" + g.UGID
+}
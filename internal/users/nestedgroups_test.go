@@ -0,0 +1,59 @@
+package users_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestFlattenGroups(t *testing.T) {
+	t.Parallel()
+
+	remote := func(name string, parents ...types.GroupInfo) types.GroupInfo {
+		return types.GroupInfo{Name: name, UGID: "ugid-" + name, Parents: parents}
+	}
+	local := func(name string) types.GroupInfo {
+		return types.GroupInfo{Name: name}
+	}
+
+	tests := map[string]struct {
+		groups []types.GroupInfo
+
+		want    []types.GroupInfo
+		wantErr bool
+	}{
+		"No_groups":                 {},
+		"Groups_without_parents":    {groups: []types.GroupInfo{remote("group1"), local("local1")}, want: []types.GroupInfo{remote("group1"), local("local1")}},
+		"Group_with_parent":         {groups: []types.GroupInfo{remote("group1", remote("parent1"))}, want: []types.GroupInfo{remote("group1"), remote("parent1")}},
+		"Group_with_local_parent":   {groups: []types.GroupInfo{remote("group1", local("local1"))}, want: []types.GroupInfo{remote("group1"), local("local1")}},
+		"Transitive_memberships":    {groups: []types.GroupInfo{remote("group1", remote("parent1", remote("grandparent1")))}, want: []types.GroupInfo{remote("group1"), remote("parent1"), remote("grandparent1")}},
+		"Groups_with_common_parent": {groups: []types.GroupInfo{remote("group1", remote("parent1")), remote("group2", remote("parent1"))}, want: []types.GroupInfo{remote("group1"), remote("parent1"), remote("group2")}},
+		"Parent_which_is_also_a_direct_membership": {
+			groups: []types.GroupInfo{remote("parent1"), remote("group1", remote("parent1"))},
+			want:   []types.GroupInfo{remote("parent1"), remote("group1")},
+		},
+		"Renamed_group_is_identified_by_its_UGID": {
+			groups: []types.GroupInfo{remote("group1", types.GroupInfo{Name: "renamed", UGID: "ugid-group2"}), remote("group2")},
+			want:   []types.GroupInfo{remote("group1"), {Name: "renamed", UGID: "ugid-group2"}},
+		},
+
+		"Error_if_group_is_its_own_parent":         {groups: []types.GroupInfo{remote("group1", remote("group1"))}, wantErr: true},
+		"Error_if_group_is_nested_in_itself":       {groups: []types.GroupInfo{remote("group1", remote("parent1", remote("group1")))}, wantErr: true},
+		"Error_if_local_group_is_nested_in_itself": {groups: []types.GroupInfo{local("local1"), remote("group1", types.GroupInfo{Name: "local1", Parents: []types.GroupInfo{remote("group1")}})}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := users.FlattenGroups(tc.groups)
+			if tc.wantErr {
+				require.Error(t, err, "FlattenGroups should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "FlattenGroups should not return an error, but did")
+			require.Equal(t, tc.want, got, "FlattenGroups did not return the expected groups")
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/decorate"
+)
+
+const (
+	// ExpiredPasswordActionChange makes the users change their expired password when they log in.
+	ExpiredPasswordActionChange = "change"
+	// ExpiredPasswordActionDeny denies the login of the users whose password expired.
+	ExpiredPasswordActionDeny = "deny"
+)
+
+// validateExpiredPasswordAction checks that the configured action on expired passwords is valid.
+func validateExpiredPasswordAction(action string) error {
+	switch action {
+	case ExpiredPasswordActionChange, ExpiredPasswordActionDeny:
+		return nil
+	default:
+		return fmt.Errorf("invalid expired password action %q, must be %q or %q", action, ExpiredPasswordActionChange, ExpiredPasswordActionDeny)
+	}
+}
+
+// passwordExpiresAt returns the expiration time of the password of the user to store: the one provided by the broker
+// if any, else the stored one, which is cleared when the user changes their password.
+func passwordExpiresAt(provided int64, passwordChanged bool, oldUser cache.UserDB) int64 {
+	if provided != 0 {
+		return provided
+	}
+	if passwordChanged {
+		return 0
+	}
+	return oldUser.PasswordExpiresAt
+}
+
+// PasswordExpired returns true if the password of the user expired, according to the expiration time last provided by
+// its broker. It's also true for the users whose broker doesn't report the expiration anymore, for example for
+// offline logins.
+func (m *Manager) PasswordExpired(name string) (expired bool, err error) {
+	defer decorate.OnError(&err, "could not check the password expiration of user %q", name)
+
+	u, err := m.cache.UserByName(name)
+	if errors.Is(err, cache.NoDataFoundError{}) {
+		return false, NoDataFoundError{}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return u.PasswordExpiresAt != 0 && !time.Now().Before(time.Unix(u.PasswordExpiresAt, 0)), nil
+}
+
+// DenyExpiredPasswords returns true if the login of the users whose password expired is denied instead of making
+// them change their password.
+func (m *Manager) DenyExpiredPasswords() bool {
+	return m.config().ExpiredPasswordAction == ExpiredPasswordActionDeny
+}
@@ -0,0 +1,62 @@
+package users_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/idgenerator"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestPasswordExpired(t *testing.T) {
+	t.Parallel()
+
+	past := time.Now().Add(-time.Hour).Unix()
+	future := time.Now().Add(time.Hour).Unix()
+
+	tests := map[string]struct {
+		expiresAt int64
+		// If relogin is set, the user logs in again with nextExpiresAt and passwordChanged.
+		relogin         bool
+		nextExpiresAt   int64
+		passwordChanged bool
+
+		wantExpired bool
+	}{
+		"Password_without_expiration_date_is_not_expired": {},
+		"Password_with_future_expiration_date":            {expiresAt: future},
+		"Password_with_past_expiration_date":              {expiresAt: past, wantExpired: true},
+
+		"Keep_expiration_date_if_broker_does_not_provide_it":      {expiresAt: past, relogin: true, wantExpired: true},
+		"Replace_expiration_date_with_the_one_provided_by_broker": {expiresAt: past, relogin: true, nextExpiresAt: future},
+		"Clear_expiration_date_when_password_is_changed":          {expiresAt: past, relogin: true, passwordChanged: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := users.NewManager(users.DefaultConfig, t.TempDir(), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+				UIDsToGenerate: []uint32{1111},
+				GIDsToGenerate: []uint32{11110},
+			}))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			u := types.UserInfo{Name: "user1", Dir: "/home/user1", Shell: "/bin/bash", PasswordExpiresAt: tc.expiresAt}
+			require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "Setup: UpdateUser should not return an error")
+
+			if tc.relogin {
+				u.PasswordExpiresAt = tc.nextExpiresAt
+				u.PasswordChanged = tc.passwordChanged
+				require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "Setup: UpdateUser should not return an error")
+			}
+
+			got, err := m.PasswordExpired("user1")
+			require.NoError(t, err, "PasswordExpired should not return an error")
+			require.Equal(t, tc.wantExpired, got, "PasswordExpired did not return the expected value")
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// PreSyncSource returns the users expected on the machine, by ID of the broker providing them.
+type PreSyncSource func(ctx context.Context) (map[string][]types.UserInfo, error)
+
+// PreSyncUsers creates the users provided by the broker which don't exist yet in the database, so that they can be
+// looked up before their first login. The existing users are not modified, they are refreshed on their next login.
+// The creation is not recorded as a login, so the stale users policy doesn't apply to the users until they log in.
+//
+// The users which can't be created, like the ones existing on the system, are skipped, and the other ones are created
+// in a single transaction: if it fails, none of them is. It returns the names of the created users.
+func (m *Manager) PreSyncUsers(brokerID string, users []types.UserInfo) (created []string, err error) {
+	defer decorate.OnError(&err, "failed to pre-sync users of broker %q", brokerID)
+
+	config := m.config()
+
+	m.updateUserMu.Lock()
+	defer m.updateUserMu.Unlock()
+
+	var updates, prepared []*userUpdate
+	// The temporary records keep the generated IDs unique until the users are stored.
+	defer func() {
+		for _, update := range prepared {
+			update.cleanup()
+		}
+	}()
+	batchGroups := make(map[string]cache.GroupDB)
+	for _, u := range users {
+		u.Shell = m.ResolveShell(u.Shell, "")
+		update, e := m.prepareUserUpdate(config, u, brokerID, true, batchGroups)
+		if update != nil {
+			prepared = append(prepared, update)
+		}
+		if e != nil {
+			err = errors.Join(err, fmt.Errorf("could not create user %q: %w", u.Name, e))
+			continue
+		}
+		if update != nil {
+			updates = append(updates, update)
+		}
+	}
+	if len(updates) == 0 {
+		return nil, err
+	}
+
+	batch := make([]cache.UserUpdate, 0, len(updates))
+	for _, update := range updates {
+		batch = append(batch, update.UserUpdate)
+	}
+	stored, e := m.cache.ApplyUserUpdates(batch...)
+	if e != nil {
+		return nil, errors.Join(err, e)
+	}
+
+	for i, update := range updates {
+		if e := m.finishUserUpdate(context.Background(), config, update, stored[i]); e != nil {
+			err = errors.Join(err, fmt.Errorf("could not create user %q: %w", stored[i].Name, e))
+		}
+		created = append(created, stored[i].Name)
+	}
+
+	return created, err
+}
+
+// preSync creates the users returned by the source which don't exist yet in the database.
+func (m *Manager) preSync(ctx context.Context, source PreSyncSource) {
+	usersByBroker, err := source(ctx)
+	if err != nil {
+		// The users of the brokers which could be listed are still created.
+		log.Warningf(ctx, "Could not list all the users to pre-sync: %v", err)
+	}
+
+	for brokerID, users := range usersByBroker {
+		created, err := m.PreSyncUsers(brokerID, users)
+		if len(created) > 0 {
+			log.Infof(ctx, "Pre-synced %d users of broker %q", len(created), brokerID)
+		}
+		if err != nil {
+			log.Warningf(ctx, "%v", err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package users_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/idgenerator"
+	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestPreSyncUsers(t *testing.T) {
+	t.Parallel()
+
+	newUser := types.UserInfo{
+		Name:   "newuser",
+		Gecos:  "gecos for newuser",
+		Dir:    "/home/newuser",
+		Shell:  "/bin/bash",
+		Groups: []types.GroupInfo{{Name: "newgroup", UGID: "99"}},
+	}
+	existingUser := types.UserInfo{Name: "user1", Gecos: "modified gecos", Dir: "/home/user1", Shell: "/bin/bash"}
+	systemUser := types.UserInfo{Name: "root", Dir: "/root", Shell: "/bin/bash"}
+
+	cacheDir := t.TempDir()
+	cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "one_user_and_group.db.yaml"), cacheDir)
+	m := newManagerForTests(t, cacheDir, users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+		UIDsToGenerate: []uint32{2222, 3333},
+		GIDsToGenerate: []uint32{22222, 33333, 44444},
+	}))
+
+	created, err := m.PreSyncUsers("broker-id", []types.UserInfo{newUser, existingUser, systemUser})
+	require.Error(t, err, "PreSyncUsers should return an error for the user existing on the system")
+	require.Equal(t, []string{"newuser"}, created, "PreSyncUsers should only create the new users")
+
+	got, err := cache.Z_ForTests_DumpNormalizedYAML(userstestutils.GetManagerCache(m))
+	require.NoError(t, err, "Created database should be valid yaml content")
+	golden.CheckOrUpdate(t, got)
+
+	// The users without a shell get the default one, like on login.
+	_, err = m.PreSyncUsers("broker-id", []types.UserInfo{{Name: "usernoshell", Dir: "/home/usernoshell"}})
+	require.NoError(t, err, "PreSyncUsers should not return an error for a user without a shell")
+	u, err := m.UserByName("usernoshell")
+	require.NoError(t, err, "Setup: could not get the pre-synced user")
+	require.NotEmpty(t, u.Shell, "The pre-synced user without a shell should get the default one")
+}
+
+func TestPreSyncUsersInBatch(t *testing.T) {
+	t.Parallel()
+
+	sharedGroup := types.GroupInfo{Name: "sharedgroup", UGID: "99"}
+	batch := []types.UserInfo{
+		{Name: "batchuser1", Dir: "/home/batchuser1", Shell: "/bin/bash", Groups: []types.GroupInfo{sharedGroup}},
+		{Name: "batchuser2", Dir: "/home/batchuser2", Shell: "/bin/bash", Groups: []types.GroupInfo{sharedGroup}},
+		{Name: "batchuser3", Dir: "/home/batchuser3", Shell: "/bin/bash", Groups: []types.GroupInfo{{Name: "sharedgroup", UGID: "100"}}},
+	}
+
+	m := newManagerForTests(t, t.TempDir(), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+		UIDsToGenerate: []uint32{2222, 3333, 4444},
+		GIDsToGenerate: []uint32{22222, 33333, 44444, 55555},
+	}))
+
+	created, err := m.PreSyncUsers("broker-id", batch)
+	require.Error(t, err, "PreSyncUsers should return an error for the group with the name of another group of the batch")
+	require.Equal(t, []string{"batchuser1", "batchuser2"}, created, "PreSyncUsers should create the valid users of the batch")
+
+	group, err := m.GroupByName("sharedgroup")
+	require.NoError(t, err, "The group shared by the users of the batch should be created")
+	require.ElementsMatch(t, []string{"batchuser1", "batchuser2"}, group.Users, "The group should be shared by the users of the batch")
+	_, err = m.UserByName("batchuser3")
+	require.Error(t, err, "The user with the conflicting group should not be created")
+}
+
+func TestPreSyncSource(t *testing.T) {
+	t.Parallel()
+
+	newUser := types.UserInfo{
+		Name:   "newuser",
+		Dir:    "/home/newuser",
+		Shell:  "/bin/bash",
+		Groups: []types.GroupInfo{{Name: "newgroup", UGID: "99"}},
+	}
+	source := func(context.Context) (map[string][]types.UserInfo, error) {
+		return map[string][]types.UserInfo{"broker-id": {newUser}}, nil
+	}
+
+	config := users.DefaultConfig
+	config.PreSyncInterval = time.Hour
+	m, err := users.NewManager(config, t.TempDir(), users.WithPreSyncSource(source), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+		UIDsToGenerate: []uint32{2222},
+		GIDsToGenerate: []uint32{22222, 33333},
+	}))
+	require.NoError(t, err, "NewManager should not return an error, but did")
+	t.Cleanup(func() { _ = m.Stop() })
+
+	require.Eventually(t, func() bool {
+		_, err := m.UserByName("newuser")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "The users of the source should be created at the start of the manager")
+
+	lastLogin, err := m.LastLogin("newuser")
+	require.NoError(t, err, "LastLogin should not return an error, but did")
+	require.True(t, lastLogin.Time.IsZero(), "The creation of a pre-synced user should not be recorded as a login")
+}
@@ -0,0 +1,109 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ubuntu/authd/internal/users/accountsservice"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/localentries"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// PurgeUser fully removes the user from the database: its user record, its group memberships, its broker assignment
+// and its user private group. The user is also removed from the local groups authd added it to, and its sudoers
+// drop-in is removed.
+//
+// The home directory and the mail spool of the user are cleaned up according to the home directory cleanup policy.
+// The actor is recorded in the audit log as the requester of the change.
+func (m *Manager) PurgeUser(name, actor string) (err error) {
+	defer decorate.OnError(&err, "failed to purge user %q", name)
+
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	// Prevent the user from logging in while we are removing it.
+	m.updateUserMu.Lock()
+	defer m.updateUserMu.Unlock()
+
+	u, err := m.cache.UserByName(name)
+	if err != nil {
+		return err
+	}
+
+	// Clean up the files first, so that the purge can be retried if they can't be archived.
+	if err := m.cleanUpUserFiles(u, actor); err != nil {
+		return err
+	}
+
+	if err := m.deleteUser(u); err != nil {
+		return err
+	}
+	m.audit(actor, types.AuditEvent{Action: AuditUserDeleted, Target: name, Details: fmt.Sprintf("UID %d", u.UID)})
+
+	deleted, err := m.deleteUserPrivateGroup(u)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		m.audit(actor, types.AuditEvent{Action: AuditGroupDeleted, Target: name, Details: fmt.Sprintf("GID %d", u.GID)})
+	}
+
+	log.Infof(context.Background(), "Purged user %q", name)
+	return nil
+}
+
+// deleteUserPrivateGroup removes the primary group of the user if it's a user private group without other members.
+// It returns true if the group was removed.
+func (m *Manager) deleteUserPrivateGroup(u cache.UserDB) (bool, error) {
+	upg, err := m.cache.GroupByID(u.GID)
+	if errors.Is(err, cache.NoDataFoundError{}) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if upg.Name != u.Name || len(upg.Users) > 0 {
+		log.Infof(context.Background(), "Keeping primary group %q of user %q which is not a user private group", upg.Name, u.Name)
+		return false, nil
+	}
+
+	return true, m.cache.DeleteGroup(upg.GID)
+}
+
+// deleteUser removes the user from the local groups authd added it to, removes its sudoers drop-in and deletes it
+// from the database, with its device tokens.
+func (m *Manager) deleteUser(u cache.UserDB) error {
+	config := m.config()
+
+	// The local groups are tracked in the database, so the user is removed from them first for the deletion to be
+	// retried if it fails. The local groups the user was added to by an administrator are kept.
+	localGroups, err := m.cache.UserLocalGroups(u.UID)
+	if err != nil && !errors.Is(err, cache.NoDataFoundError{}) {
+		return err
+	}
+	if err := localentries.RemoveUser(context.Background(), u.Name, localGroups, localentries.WithDryRun(config.LocalGroupsDryRun)); err != nil {
+		return err
+	}
+	if err := config.removeSudoers(u.Name, u.UID); err != nil {
+		return err
+	}
+
+	// The device tokens are deleted while the UID of the user is known, to find the keyring they are stored in.
+	if err := m.deviceTokens.Delete(u.Name); err != nil {
+		log.Warningf(context.Background(), "%v", err)
+	}
+	if err := m.cache.DeleteUser(u.UID); err != nil {
+		return err
+	}
+	if config.AccountsServiceDir != "" {
+		if err := accountsservice.Remove(config.AccountsServiceDir, u.Name); err != nil {
+			log.Warningf(context.Background(), "%v", err)
+		}
+	}
+	return nil
+}
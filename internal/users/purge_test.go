@@ -0,0 +1,53 @@
+package users_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users/cache"
+	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+)
+
+func TestPurgeUser(t *testing.T) {
+	tests := map[string]struct {
+		username string
+		dbFile   string
+
+		wantErr     bool
+		wantErrType error
+	}{
+		"Purge_user_and_its_private_group":                     {username: "user1"},
+		"Purge_user_and_keep_primary_group_shared_with_others": {username: "user3"},
+		"Purge_user_without_private_group":                     {username: "user1", dbFile: "multiple_users_and_groups"},
+
+		"Error_if_user_does_not_exist":  {username: "doesnotexist", wantErrType: cache.NoDataFoundError{}},
+		"Error_if_db_has_invalid_entry": {username: "user1", dbFile: "invalid_entry_in_userByName", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.dbFile == "" {
+				tc.dbFile = "users_with_private_groups"
+			}
+			groupsFile := localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", tc.dbFile+".db.yaml"), cacheDir)
+			m := newManagerForTests(t, cacheDir)
+
+			err := m.PurgeUser(tc.username, "test")
+			requireErrorAssertions(t, err, tc.wantErrType, tc.wantErr)
+			if tc.wantErrType != nil || tc.wantErr {
+				return
+			}
+
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(userstestutils.GetManagerCache(m))
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+
+			localgroupstestutils.RequireGroupFile(t, groupsFile, golden.Path(t)+".group")
+		})
+	}
+}
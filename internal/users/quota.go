@@ -0,0 +1,174 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// defaultQuotaCommand is the command used to apply the quotas if none is configured.
+const defaultQuotaCommand = "setquota"
+
+// QuotaProfile are the disk quotas applied to the users on a file system.
+type QuotaProfile struct {
+	// Filesystem is the mount point of the file system, usually the one of the home directories.
+	Filesystem string `mapstructure:"filesystem"`
+	// BlockSoftLimit and BlockHardLimit are the limits of the disk usage, in KiB. 0 means no limit.
+	BlockSoftLimit uint64 `mapstructure:"block_soft_limit"`
+	BlockHardLimit uint64 `mapstructure:"block_hard_limit"`
+	// InodeSoftLimit and InodeHardLimit are the limits of the number of files. 0 means no limit.
+	InodeSoftLimit uint64 `mapstructure:"inode_soft_limit"`
+	InodeHardLimit uint64 `mapstructure:"inode_hard_limit"`
+}
+
+// validateQuotaConfig checks that the quota profiles are valid and that the referenced ones exist.
+func validateQuotaConfig(config Config) (err error) {
+	defer decorate.OnError(&err, "invalid quota configuration")
+
+	for name, p := range config.QuotaProfiles {
+		if !filepath.IsAbs(p.Filesystem) {
+			return fmt.Errorf("file system %q of quota profile %q must be an absolute path", p.Filesystem, name)
+		}
+		if p.BlockHardLimit != 0 && p.BlockSoftLimit > p.BlockHardLimit {
+			return fmt.Errorf("block soft limit of quota profile %q is greater than its hard limit", name)
+		}
+		if p.InodeHardLimit != 0 && p.InodeSoftLimit > p.InodeHardLimit {
+			return fmt.Errorf("inode soft limit of quota profile %q is greater than its hard limit", name)
+		}
+	}
+
+	if config.QuotaDefaultProfile != "" {
+		if _, ok := lookupFold(config.QuotaProfiles, config.QuotaDefaultProfile); !ok {
+			return fmt.Errorf("unknown default quota profile %q", config.QuotaDefaultProfile)
+		}
+	}
+	for group, profile := range config.QuotaGroupProfiles {
+		if _, ok := lookupFold(config.QuotaProfiles, profile); !ok {
+			return fmt.Errorf("unknown quota profile %q of group %q", profile, group)
+		}
+	}
+
+	return nil
+}
+
+// ApplyQuota applies the quota profile of the user, unless it was already applied, so that the quotas changed by an
+// administrator are kept until the profile of the user changes.
+//
+// brokerProfile is the quota profile set by the broker of the user. If set, it takes precedence over the profiles of
+// the groups of the user and over the default profile.
+func (m *Manager) ApplyQuota(name, brokerProfile string) (err error) {
+	defer decorate.OnError(&err, "failed to apply quota to user %q", name)
+
+	config := m.config()
+
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	u, err := m.cache.UserByName(name)
+	if err != nil {
+		return err
+	}
+
+	profileName, err := m.quotaProfileOf(u.UID, brokerProfile)
+	if err != nil || profileName == "" {
+		return err
+	}
+	profile, ok := lookupFold(config.QuotaProfiles, profileName)
+	if !ok {
+		return fmt.Errorf("unknown quota profile %q", profileName)
+	}
+
+	applied, err := m.cache.QuotaProfile(name)
+	if err != nil {
+		return err
+	}
+	if applied == profileName {
+		return nil
+	}
+
+	command := config.QuotaCommand
+	if command == "" {
+		command = defaultQuotaCommand
+	}
+	args := []string{"-u", name,
+		strconv.FormatUint(profile.BlockSoftLimit, 10), strconv.FormatUint(profile.BlockHardLimit, 10),
+		strconv.FormatUint(profile.InodeSoftLimit, 10), strconv.FormatUint(profile.InodeHardLimit, 10),
+		profile.Filesystem,
+	}
+	if out, err := exec.Command(command, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %v: %s", command, err, strings.TrimSpace(string(out)))
+	}
+
+	if err := m.cache.SetQuotaProfile(u.UID, profileName); err != nil {
+		return err
+	}
+
+	log.Infof(context.Background(), "Applied quota profile %q to user %q", profileName, name)
+	m.audit(ActorLogin, types.AuditEvent{Action: AuditQuotaApplied, Target: name, Details: profileName})
+	return nil
+}
+
+// quotaProfileOf returns the name of the quota profile of the user: the one of its broker, else the one of its groups
+// which comes first in alphabetical order, else the default one. It's empty if the user has no quota profile.
+//
+// The names of the profiles and of the groups are case-insensitive, the returned name is lowercased.
+func (m *Manager) quotaProfileOf(uid uint32, brokerProfile string) (string, error) {
+	config := m.config()
+
+	if brokerProfile != "" {
+		return strings.ToLower(brokerProfile), nil
+	}
+
+	if len(config.QuotaGroupProfiles) > 0 {
+		groups, err := m.cache.UserGroups(uid)
+		if err != nil && !errors.Is(err, cache.NoDataFoundError{}) {
+			return "", err
+		}
+		localGroups, err := m.cache.UserLocalGroups(uid)
+		if err != nil && !errors.Is(err, cache.NoDataFoundError{}) {
+			return "", err
+		}
+
+		names := localGroups
+		for _, g := range groups {
+			names = append(names, g.Name)
+		}
+		for i, g := range names {
+			names[i] = strings.ToLower(g)
+		}
+		slices.Sort(names)
+		for _, g := range names {
+			if profile, ok := lookupFold(config.QuotaGroupProfiles, g); ok {
+				return strings.ToLower(profile), nil
+			}
+		}
+	}
+
+	return strings.ToLower(config.QuotaDefaultProfile), nil
+}
+
+// lookupFold returns the value of the key of the map, compared case-insensitively. The keys of the configuration file
+// are lowercased when it's read, but not the ones set otherwise, nor the names referencing them.
+func lookupFold[V any](m map[string]V, key string) (V, bool) {
+	if v, ok := m[strings.ToLower(key)]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	var zero V
+	return zero, false
+}
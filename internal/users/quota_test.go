@@ -0,0 +1,114 @@
+package users_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	userstestutils "github.com/ubuntu/authd/internal/users/testutils"
+)
+
+func TestApplyQuota(t *testing.T) {
+	t.Parallel()
+
+	profiles := map[string]users.QuotaProfile{
+		"default":  {Filesystem: "/home", BlockSoftLimit: 1000, BlockHardLimit: 2000},
+		"group1":   {Filesystem: "/home", InodeSoftLimit: 100, InodeHardLimit: 200},
+		"staff":    {Filesystem: "/srv", BlockHardLimit: 5000},
+		"students": {Filesystem: "/home", BlockSoftLimit: 10, BlockHardLimit: 20, InodeSoftLimit: 30, InodeHardLimit: 40},
+	}
+
+	tests := map[string]struct {
+		username       string
+		brokerProfile  string
+		defaultProfile string
+		groupProfiles  map[string]string
+		appliedProfile string
+		failingCommand bool
+
+		wantCommand string
+		wantProfile string
+		wantErr     bool
+		wantErrType error
+	}{
+		"Apply_default_profile":                        {defaultProfile: "default", wantCommand: "-u user1 1000 2000 0 0 /home", wantProfile: "default"},
+		"Apply_group_profile_over_default_profile":     {defaultProfile: "default", groupProfiles: map[string]string{"group1": "group1"}, wantCommand: "-u user1 0 0 100 200 /home", wantProfile: "group1"},
+		"Apply_broker_profile_over_group_profile":      {brokerProfile: "staff", groupProfiles: map[string]string{"group1": "group1"}, wantCommand: "-u user1 0 5000 0 0 /srv", wantProfile: "staff"},
+		"Apply_profile_if_another_one_was_applied":     {defaultProfile: "students", appliedProfile: "default", wantCommand: "-u user1 10 20 30 40 /home", wantProfile: "students"},
+		"Do_not_apply_profile_already_applied":         {defaultProfile: "default", appliedProfile: "default", wantProfile: "default"},
+		"Do_not_apply_profile_of_other_groups":         {groupProfiles: map[string]string{"othergroup": "group1"}},
+		"Do_not_apply_anything_if_there_is_no_profile": {},
+
+		"Apply_default_profile_case_insensitively": {defaultProfile: "Default", wantCommand: "-u user1 1000 2000 0 0 /home", wantProfile: "default"},
+		"Apply_group_profile_case_insensitively":   {groupProfiles: map[string]string{"GROUP1": "Group1"}, wantCommand: "-u user1 0 0 100 200 /home", wantProfile: "group1"},
+		"Apply_broker_profile_case_insensitively":  {brokerProfile: "STAFF", wantCommand: "-u user1 0 5000 0 0 /srv", wantProfile: "staff"},
+
+		"Error_if_broker_profile_does_not_exist": {brokerProfile: "doesnotexist", wantErr: true},
+		"Error_if_command_fails":                 {defaultProfile: "default", failingCommand: true, wantErr: true},
+		"Error_if_user_does_not_exist":           {username: "doesnotexist", defaultProfile: "default", wantErrType: cache.NoDataFoundError{}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.username == "" {
+				tc.username = "user1"
+			}
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "one_user_and_group.db.yaml"), cacheDir)
+			if tc.appliedProfile != "" {
+				c, err := cache.New(cacheDir)
+				require.NoError(t, err, "Setup: could not open the cache")
+				require.NoError(t, c.SetQuotaProfile(1111, tc.appliedProfile), "Setup: could not set the applied quota profile")
+				require.NoError(t, c.Close(), "Setup: could not close the cache")
+			}
+
+			// The command records its arguments, so that we can check what would have been applied.
+			cmdDir := t.TempDir()
+			argsFile := filepath.Join(cmdDir, "args")
+			exitCode := 0
+			if tc.failingCommand {
+				exitCode = 1
+			}
+			script := fmt.Sprintf("#!/bin/sh\necho \"$@\" >> %q\nexit %d\n", argsFile, exitCode)
+			command := filepath.Join(cmdDir, "setquota")
+			require.NoError(t, os.WriteFile(command, []byte(script), 0700), "Setup: could not create quota command")
+
+			config := users.DefaultConfig
+			config.QuotaProfiles = profiles
+			config.QuotaDefaultProfile = tc.defaultProfile
+			config.QuotaGroupProfiles = tc.groupProfiles
+			config.QuotaCommand = command
+			m, err := users.NewManager(config, cacheDir)
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			err = m.ApplyQuota(tc.username, tc.brokerProfile)
+			requireErrorAssertions(t, err, tc.wantErrType, tc.wantErr)
+			if tc.wantErrType != nil {
+				return
+			}
+
+			gotProfile, err := userstestutils.GetManagerCache(m).QuotaProfile(tc.username)
+			require.NoError(t, err, "Could not get the applied quota profile")
+			if tc.wantErr {
+				require.Empty(t, gotProfile, "No quota profile should be recorded on failure")
+				return
+			}
+			require.Equal(t, tc.wantProfile, gotProfile, "Unexpected quota profile recorded")
+
+			gotArgs, err := os.ReadFile(argsFile)
+			if tc.wantCommand == "" {
+				require.ErrorIs(t, err, os.ErrNotExist, "Quota command should not have been called")
+				return
+			}
+			require.NoError(t, err, "Quota command should have been called")
+			require.Equal(t, tc.wantCommand+"\n", string(gotArgs), "Quota command should have been called once with the profile limits")
+		})
+	}
+}
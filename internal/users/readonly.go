@@ -0,0 +1,14 @@
+package users
+
+import "errors"
+
+// ErrReadOnly is returned when trying to modify the users or groups of a read-only manager.
+var ErrReadOnly = errors.New("users manager is read-only")
+
+// checkWritable returns ErrReadOnly if the manager was created in read-only mode.
+func (m *Manager) checkWritable() error {
+	if m.config().ReadOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package users_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/devicetokens"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestReadOnlyManager(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+	cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "one_user_and_group.db.yaml"), cacheDir)
+	// Let authd upgrade the database to the current schema, which can't be done in read-only mode.
+	require.NoError(t, newManagerForTests(t, cacheDir).Stop(), "Setup: could not stop the manager")
+
+	config := users.DefaultConfig
+	config.ReadOnly = true
+	config.DeviceTokenLifetime = time.Hour
+	m, err := users.NewManager(config, cacheDir, users.WithDeviceTokenKeyring(devicetokens.Z_ForTests_NewKeyring(t)))
+	require.NoError(t, err, "NewManager should open an existing database in read-only mode")
+	t.Cleanup(func() { _ = m.Stop() })
+
+	u, err := m.UserByName("user1")
+	require.NoError(t, err, "UserByName should work in read-only mode")
+	require.Equal(t, uint32(1111), u.UID, "UserByName should return the stored user")
+	g, err := m.GroupByName("group1")
+	require.NoError(t, err, "GroupByName should work in read-only mode")
+	require.Equal(t, []string{"user1"}, g.Users, "GroupByName should return the stored group")
+
+	err = m.UpdateUser(context.Background(), types.UserInfo{Name: "user1", Dir: "/home/user1", Shell: "/bin/bash"}, "broker-id")
+	require.ErrorIs(t, err, users.ErrReadOnly, "UpdateUser should fail in read-only mode")
+	err = m.UpdateUser(context.Background(), types.UserInfo{Name: "newuser", Dir: "/home/newuser", Shell: "/bin/bash"}, "broker-id")
+	require.ErrorIs(t, err, users.ErrReadOnly, "UpdateUser should not create users in read-only mode")
+	_, err = m.RegisterUserPreAuth("newuser")
+	require.ErrorIs(t, err, users.ErrReadOnly, "RegisterUserPreAuth should fail in read-only mode")
+	require.ErrorIs(t, m.LockUser("user1", "test"), users.ErrReadOnly, "LockUser should fail in read-only mode")
+	require.ErrorIs(t, m.PurgeUser("user1", "test"), users.ErrReadOnly, "PurgeUser should fail in read-only mode")
+	_, err = m.RunMaintenance()
+	require.ErrorIs(t, err, users.ErrReadOnly, "RunMaintenance should fail in read-only mode")
+	_, err = m.ReconcileLocalGroups(true, "test")
+	require.ErrorIs(t, err, users.ErrReadOnly, "ReconcileLocalGroups should fail to repair in read-only mode")
+	err = m.SetDeviceToken("broker-id", "user1", "some-token")
+	require.ErrorIs(t, err, users.ErrReadOnly, "SetDeviceToken should fail in read-only mode")
+}
+
+func TestReadOnlyManagerRequiresExistingDatabase(t *testing.T) {
+	t.Parallel()
+
+	config := users.DefaultConfig
+	config.ReadOnly = true
+	_, err := users.NewManager(config, t.TempDir())
+	require.Error(t, err, "NewManager should fail in read-only mode if there is no database")
+}
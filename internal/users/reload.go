@@ -0,0 +1,64 @@
+package users
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// Reload applies the new configuration to the next operations of the manager. The operations in progress keep the
+// previous configuration.
+//
+// The ID ranges, the read-only mode and the scheduling of the periodic tasks are only applied when the manager is
+// created: their previous values are kept, with a warning if they were changed.
+func (m *Manager) Reload(config Config) (err error) {
+	defer decorate.OnError(&err, "failed to reload users configuration")
+
+	m.settingsMu.Lock()
+	defer m.settingsMu.Unlock()
+
+	current := m.config()
+
+	var ignored []string
+	keep := func(name string, changed bool) {
+		if changed {
+			ignored = append(ignored, name)
+		}
+	}
+	keep("UID_MIN", config.UIDMin != current.UIDMin)
+	keep("UID_MAX", config.UIDMax != current.UIDMax)
+	keep("GID_MIN", config.GIDMin != current.GIDMin)
+	keep("GID_MAX", config.GIDMax != current.GIDMax)
+	keep("REMOTE_GROUPS_GID_MIN", config.RemoteGroupsGIDMin != current.RemoteGroupsGIDMin)
+	keep("REMOTE_GROUPS_GID_MAX", config.RemoteGroupsGIDMax != current.RemoteGroupsGIDMax)
+	keep("read_only", config.ReadOnly != current.ReadOnly)
+	keep("stale_users_retention_days", (config.StaleUsersRetentionDays == 0) != (current.StaleUsersRetentionDays == 0))
+	keep("presync_interval", config.PreSyncInterval != current.PreSyncInterval)
+	keep("maintenance_interval", config.MaintenanceInterval != current.MaintenanceInterval)
+	keep("maintenance_start", config.MaintenanceStart != current.MaintenanceStart)
+
+	config.UIDMin, config.UIDMax = current.UIDMin, current.UIDMax
+	config.GIDMin, config.GIDMax = current.GIDMin, current.GIDMax
+	config.RemoteGroupsGIDMin, config.RemoteGroupsGIDMax = current.RemoteGroupsGIDMin, current.RemoteGroupsGIDMax
+	config.ReadOnly = current.ReadOnly
+	config.PreSyncInterval = current.PreSyncInterval
+	config.MaintenanceInterval, config.MaintenanceStart = current.MaintenanceInterval, current.MaintenanceStart
+	// The stale users policy can be changed, but it can't be enabled or disabled without restarting.
+	if (config.StaleUsersRetentionDays == 0) != (current.StaleUsersRetentionDays == 0) {
+		config.StaleUsersRetentionDays = current.StaleUsersRetentionDays
+	}
+
+	s, err := newSettings(config)
+	if err != nil {
+		return err
+	}
+	m.settings.Store(s)
+
+	if len(ignored) > 0 {
+		log.Warningf(context.Background(), "The changes of %s are only applied when authd restarts", strings.Join(ignored, ", "))
+	}
+	log.Info(context.Background(), "Users configuration reloaded")
+	return nil
+}
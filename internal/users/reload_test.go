@@ -0,0 +1,68 @@
+package users_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+)
+
+func TestReload(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		reloadedTTL         time.Duration
+		invalidStaleAction  bool
+		changeRestartFields bool
+
+		wantStale bool
+		wantErr   bool
+	}{
+		"Applies_new_configuration":                 {reloadedTTL: time.Hour, wantStale: true},
+		"Keeps_fields_applied_on_restart":           {changeRestartFields: true},
+		"Applies_configuration_without_any_changes": {},
+		"Error_and_keep_configuration_if_invalid":   {reloadedTTL: time.Hour, invalidStaleAction: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "one_user_and_group.db.yaml"), cacheDir)
+
+			m, err := users.NewManager(users.DefaultConfig, cacheDir)
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			config := users.DefaultConfig
+			config.UserInfoTTL = tc.reloadedTTL
+			if tc.invalidStaleAction {
+				config.StaleUsersAction = "invalid"
+			}
+			if tc.changeRestartFields {
+				config.UIDMin, config.UIDMax = 1000, 2000
+				config.ReadOnly = true
+			}
+
+			err = m.Reload(config)
+			if tc.wantErr {
+				require.Error(t, err, "Reload should return an error, but did not")
+			} else {
+				require.NoError(t, err, "Reload should not return an error, but did")
+			}
+
+			if tc.changeRestartFields {
+				err := m.ConfirmUser("user1")
+				require.NoError(t, err, "The read-only mode should only be applied on restart")
+			}
+
+			// The user was never refreshed, so it is only stale if the TTL was applied.
+			got, err := m.UserIsStale("user1")
+			require.NoError(t, err, "UserIsStale should not return an error")
+			require.Equal(t, tc.wantStale, got, "UserIsStale should reflect the applied configuration")
+		})
+	}
+}
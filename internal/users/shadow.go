@@ -0,0 +1,41 @@
+package users
+
+import (
+	"fmt"
+
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+// validateShadowAging checks that the configured password aging fields are either a number of days or -1.
+func validateShadowAging(config Config) error {
+	for _, f := range []struct {
+		name  string
+		value int
+	}{
+		{"min_pwd_age", config.MinPwdAge},
+		{"max_pwd_age", config.MaxPwdAge},
+		{"pwd_warn_period", config.PwdWarnPeriod},
+		{"pwd_inactivity", config.PwdInactivity},
+	} {
+		if f.value < -1 {
+			return fmt.Errorf("invalid %s %d, must be a number of days or -1", f.name, f.value)
+		}
+	}
+	return nil
+}
+
+// applyShadowAging sets the password aging fields of the user to the configured ones, unless they are overridden.
+func (s *settings) applyShadowAging(u *cache.UserDB, override types.ShadowAging) {
+	value := func(configured int, override *int) int {
+		if override != nil {
+			return *override
+		}
+		return configured
+	}
+
+	u.MinPwdAge = value(s.MinPwdAge, override.MinPwdAge)
+	u.MaxPwdAge = value(s.MaxPwdAge, override.MaxPwdAge)
+	u.PwdWarnPeriod = value(s.PwdWarnPeriod, override.PwdWarnPeriod)
+	u.PwdInactivity = value(s.PwdInactivity, override.PwdInactivity)
+}
@@ -0,0 +1,68 @@
+package users_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/idgenerator"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestShadowAging(t *testing.T) {
+	t.Parallel()
+
+	ptr := func(v int) *int { return &v }
+
+	tests := map[string]struct {
+		minPwdAge     int
+		maxPwdAge     int
+		pwdWarnPeriod int
+		pwdInactivity int
+		override      types.ShadowAging
+
+		want types.ShadowEntry
+	}{
+		"Fields_are_empty_by_default": {
+			minPwdAge: -1, maxPwdAge: -1, pwdWarnPeriod: -1, pwdInactivity: -1,
+			want: types.ShadowEntry{MinPwdAge: -1, MaxPwdAge: -1, PwdWarnPeriod: -1, PwdInactivity: -1},
+		},
+		"Use_configured_fields": {
+			minPwdAge: 1, maxPwdAge: 90, pwdWarnPeriod: 7, pwdInactivity: 30,
+			want: types.ShadowEntry{MinPwdAge: 1, MaxPwdAge: 90, PwdWarnPeriod: 7, PwdInactivity: 30},
+		},
+		"Fields_set_by_broker_override_configured_ones": {
+			minPwdAge: 1, maxPwdAge: 90, pwdWarnPeriod: 7, pwdInactivity: 30,
+			override: types.ShadowAging{MaxPwdAge: ptr(365), PwdInactivity: ptr(-1)},
+			want:     types.ShadowEntry{MinPwdAge: 1, MaxPwdAge: 365, PwdWarnPeriod: 7, PwdInactivity: -1},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config := users.DefaultConfig
+			config.MinPwdAge = tc.minPwdAge
+			config.MaxPwdAge = tc.maxPwdAge
+			config.PwdWarnPeriod = tc.pwdWarnPeriod
+			config.PwdInactivity = tc.pwdInactivity
+			m, err := users.NewManager(config, t.TempDir(), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+				UIDsToGenerate: []uint32{1111},
+				GIDsToGenerate: []uint32{11110},
+			}))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			u := types.UserInfo{Name: "user1", Dir: "/home/user1", Shell: "/bin/bash", ShadowAging: tc.override}
+			require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "UpdateUser should not return an error")
+
+			got, err := m.ShadowByName("user1")
+			require.NoError(t, err, "ShadowByName should not return an error")
+			tc.want.Name = "user1"
+			tc.want.LastPwdChange = -1
+			tc.want.ExpirationDate = -1
+			require.Equal(t, tc.want, got, "Shadow entry should have the expected password aging fields")
+		})
+	}
+}
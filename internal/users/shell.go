@@ -0,0 +1,100 @@
+package users
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ubuntu/authd/log"
+)
+
+const (
+	// defaultShellsFile is the file listing the valid login shells.
+	defaultShellsFile = "/etc/shells"
+	// fallbackShell is the shell used if none of the configured ones is available on the machine.
+	fallbackShell = "/bin/sh"
+)
+
+// validateDefaultShell checks that the configured default shell is an absolute path.
+func validateDefaultShell(shell string) error {
+	if shell != "" && !filepath.IsAbs(shell) {
+		return fmt.Errorf("default_shell %q must be an absolute path", shell)
+	}
+	return nil
+}
+
+// ResolveShell returns the login shell of a user: the shell provided by the broker if any, else the default shell of
+// the broker, else the default shell of the configuration. Default shells which are not valid login shells on this
+// machine are skipped, and /bin/sh is used if none of them is.
+func (m *Manager) ResolveShell(providedShell, brokerDefaultShell string) string {
+	if providedShell != "" {
+		return providedShell
+	}
+
+	validShells, err := readShells(m.shellsFile)
+	if err != nil {
+		log.Warningf(context.Background(), "Could not read the valid login shells: %v", err)
+	}
+
+	for _, shell := range []string{brokerDefaultShell, m.config().DefaultShell} {
+		if shell == "" {
+			continue
+		}
+		if err := checkShell(shell, validShells); err != nil {
+			log.Warningf(context.Background(), "Not using default shell %q: %v", shell, err)
+			continue
+		}
+		return shell
+	}
+
+	return fallbackShell
+}
+
+// checkShell checks that the shell is an executable listed in the valid login shells, if they are known.
+func checkShell(shell string, validShells []string) error {
+	if !filepath.IsAbs(shell) {
+		return errors.New("not an absolute path")
+	}
+	if validShells != nil && !slices.Contains(validShells, shell) {
+		return errors.New("not listed in the valid login shells")
+	}
+
+	fi, err := os.Stat(shell)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() || fi.Mode().Perm()&0111 == 0 {
+		return errors.New("not an executable")
+	}
+	return nil
+}
+
+// readShells returns the valid login shells listed in the given file. It returns nil if the file doesn't exist, in
+// which case all shells are considered valid, like getusershell(3) does with its own defaults.
+func readShells(path string) (shells []string, err error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	shells = []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		shells = append(shells, line)
+	}
+	return shells, scanner.Err()
+}
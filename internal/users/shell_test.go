@@ -0,0 +1,55 @@
+package users_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+)
+
+func TestResolveShell(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		providedShell      string
+		brokerDefaultShell string
+		defaultShell       string
+		shells             string
+		noShellsFile       bool
+
+		want string
+	}{
+		"Use_shell_provided_by_broker":                  {providedShell: "/usr/bin/fish", brokerDefaultShell: "/bin/bash", want: "/usr/bin/fish"},
+		"Use_default_shell_of_broker":                   {brokerDefaultShell: "/bin/bash", defaultShell: "/bin/sh", want: "/bin/bash"},
+		"Use_default_shell_of_configuration":            {defaultShell: "/bin/bash", want: "/bin/bash"},
+		"Use_any_existing_shell_without_shells_file":    {defaultShell: "/bin/bash", noShellsFile: true, want: "/bin/bash"},
+		"Skip_default_shell_of_broker_not_in_shells":    {brokerDefaultShell: "/bin/sh", defaultShell: "/bin/bash", shells: "/bin/bash\n", want: "/bin/bash"},
+		"Skip_default_shell_of_broker_which_is_missing": {brokerDefaultShell: "/does/not/exist", defaultShell: "/bin/bash", shells: "/does/not/exist\n/bin/bash\n", want: "/bin/bash"},
+		"Fall_back_to_sh_if_no_default_shell_is_valid":  {brokerDefaultShell: "/does/not/exist", defaultShell: "/bin/bash", shells: "/does/not/exist\n", want: "/bin/sh"},
+		"Fall_back_to_sh_if_no_default_shell_is_set":    {want: "/bin/sh"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.shells == "" {
+				tc.shells = "# Comment\n\n/bin/sh\n/bin/bash\n"
+			}
+			shellsFile := filepath.Join(t.TempDir(), "shells")
+			if !tc.noShellsFile {
+				require.NoError(t, os.WriteFile(shellsFile, []byte(tc.shells), 0600), "Setup: could not create shells file")
+			}
+
+			config := users.DefaultConfig
+			config.DefaultShell = tc.defaultShell
+			m, err := users.NewManager(config, t.TempDir(), users.WithShellsFile(shellsFile))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			got := m.ResolveShell(tc.providedShell, tc.brokerDefaultShell)
+			require.Equal(t, tc.want, got, "ResolveShell did not return the expected shell")
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package users
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+const (
+	// StaleUsersActionDelete deletes stale users from the database and removes them from the local groups authd added
+	// them to.
+	StaleUsersActionDelete = "delete"
+	// StaleUsersActionDisable disables stale users, so that they can't log in anymore.
+	StaleUsersActionDisable = "disable"
+
+	// staleUsersCheckInterval is the interval between two checks for stale users.
+	staleUsersCheckInterval = 24 * time.Hour
+)
+
+// ExpireStaleUsers applies the stale users policy to all users which did not log in during the configured retention
+// period. It returns the names of the users which were deleted or disabled.
+func (m *Manager) ExpireStaleUsers() (expired []string, err error) {
+	defer decorate.OnError(&err, "failed to expire stale users")
+
+	config := m.config()
+
+	if err := m.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if config.StaleUsersRetentionDays == 0 {
+		return nil, nil
+	}
+
+	// Prevent stale users from logging in while we are expiring them.
+	m.updateUserMu.Lock()
+	defer m.updateUserMu.Unlock()
+
+	cutoff := time.Now().AddDate(0, 0, -int(config.StaleUsersRetentionDays))
+	staleUsers, err := m.cache.UsersLastLoggedInBefore(cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range staleUsers {
+		if config.StaleUsersAction == StaleUsersActionDisable {
+			if isExpired(u.ExpirationDate) {
+				continue
+			}
+			if e := m.cache.DisableUser(u.UID); e != nil {
+				err = errors.Join(err, e)
+				continue
+			}
+			log.Infof(context.Background(), "Disabled user %q which did not log in since %s", u.Name, cutoff.Format(time.DateOnly))
+			m.audit(ActorStaleUsersPolicy, types.AuditEvent{
+				Action:  AuditUserDisabled,
+				Target:  u.Name,
+				Details: fmt.Sprintf("no login during the last %d days", config.StaleUsersRetentionDays),
+			})
+			expired = append(expired, u.Name)
+			continue
+		}
+
+		// The home directory cleanup policy supersedes the archive of the home directories of stale users.
+		if config.StaleUsersArchiveDir != "" && config.homeDirCleanup() == HomeDirCleanupKeep {
+			if e := archivePath(u.Dir, config.StaleUsersArchiveDir, u.Name); e != nil {
+				// Don't delete the user if we could not archive its home directory, so that we can retry later.
+				err = errors.Join(err, e)
+				continue
+			}
+		}
+		if e := m.cleanUpUserFiles(u, ActorStaleUsersPolicy); e != nil {
+			// Don't delete the user if we could not clean up its files, so that we can retry later.
+			err = errors.Join(err, e)
+			continue
+		}
+		if e := m.deleteUser(u); e != nil {
+			err = errors.Join(err, e)
+			continue
+		}
+		log.Infof(context.Background(), "Deleted user %q which did not log in since %s", u.Name, cutoff.Format(time.DateOnly))
+		m.audit(ActorStaleUsersPolicy, types.AuditEvent{
+			Action:  AuditUserDeleted,
+			Target:  u.Name,
+			Details: fmt.Sprintf("no login during the last %d days", config.StaleUsersRetentionDays),
+		})
+		expired = append(expired, u.Name)
+	}
+
+	return expired, err
+}
+
+// isExpired returns true if the given shadow expiration date, in days since the epoch, is in the past.
+func isExpired(expirationDate int) bool {
+	if expirationDate < 0 {
+		return false
+	}
+	return time.Unix(0, 0).AddDate(0, 0, expirationDate).Before(time.Now())
+}
+
+// archivePath stores the content of the file or directory at path, like a home directory, in a compressed tarball
+// in archiveDir. A missing path is not an error.
+func archivePath(path, archiveDir, name string) (err error) {
+	defer decorate.OnError(&err, "could not archive %q", path)
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		log.Infof(context.Background(), "%q does not exist, nothing to archive", path)
+		return nil
+	}
+
+	if err := os.MkdirAll(archiveDir, 0700); err != nil {
+		return err
+	}
+
+	dest := filepath.Join(archiveDir, fmt.Sprintf("%s-%s.tar.gz", name, time.Now().Format("20060102T150405")))
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(dest)
+		}
+	}()
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(filepath.Dir(path), p)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+
+	log.Infof(context.Background(), "Archived %q to %q", path, dest)
+	return f.Close()
+}
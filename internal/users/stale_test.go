@@ -0,0 +1,154 @@
+package users_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+	"github.com/ubuntu/authd/internal/users/devicetokens"
+	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestExpireStaleUsers(t *testing.T) {
+	tests := map[string]struct {
+		retentionDays uint32
+		action        string
+
+		wantErr bool
+	}{
+		"Delete_users_which_did_not_log_in_during_retention_period":  {retentionDays: 30},
+		"Disable_users_which_did_not_log_in_during_retention_period": {retentionDays: 30, action: users.StaleUsersActionDisable},
+		"Users_are_kept_if_retention_period_is_long_enough":          {retentionDays: 365 * 100},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			groupsFile := localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+
+			cacheDir := t.TempDir()
+			cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "multiple_users_and_local_groups.db.yaml"), cacheDir)
+
+			config := users.DefaultConfig
+			config.StaleUsersRetentionDays = tc.retentionDays
+			if tc.action != "" {
+				config.StaleUsersAction = tc.action
+			}
+
+			// Stale users are expired when the manager starts, so stopping it waits for the first check to be done.
+			m, err := users.NewManager(config, cacheDir, users.WithDeviceTokenKeyring(devicetokens.Z_ForTests_NewKeyring(t)))
+			require.NoError(t, err, "NewManager should not return an error, but did")
+			require.NoError(t, m.Stop(), "Stop should not return an error, but did")
+
+			c, err := cache.New(cacheDir)
+			require.NoError(t, err, "Setup: could not reopen the cache")
+			t.Cleanup(func() { _ = c.Close() })
+			got, err := cache.Z_ForTests_DumpNormalizedYAML(c)
+			require.NoError(t, err, "Created database should be valid yaml content")
+			golden.CheckOrUpdate(t, got)
+
+			localgroupstestutils.RequireGroupFile(t, groupsFile, golden.Path(t)+".group")
+		})
+	}
+}
+
+func TestDisabledUserCannotBeUpdated(t *testing.T) {
+	_ = localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "empty.group"))
+
+	cacheDir := t.TempDir()
+	cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "multiple_users_and_groups.db.yaml"), cacheDir)
+
+	c, err := cache.New(cacheDir)
+	require.NoError(t, err, "Setup: could not open the cache")
+	require.NoError(t, c.DisableUser(1111), "Setup: DisableUser should not return an error, but did")
+	require.NoError(t, c.Close(), "Setup: could not close the cache")
+
+	m := newManagerForTests(t, cacheDir)
+	err = m.UpdateUser(context.Background(), types.UserInfo{Name: "user1", Dir: "/home/user1", Shell: "/bin/bash"}, "broker-id")
+	require.Error(t, err, "UpdateUser should fail for a disabled user")
+}
+
+func TestArchiveHomeDir(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		missingHome bool
+
+		wantErr bool
+	}{
+		"Archive_home_directory":            {},
+		"Missing_home_directory_is_ignored": {missingHome: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			home := filepath.Join(t.TempDir(), "user1")
+			if !tc.missingHome {
+				require.NoError(t, os.MkdirAll(filepath.Join(home, "subdir"), 0700), "Setup: could not create home directory")
+				require.NoError(t, os.WriteFile(filepath.Join(home, "file"), []byte("content"), 0600), "Setup: could not create file")
+				require.NoError(t, os.WriteFile(filepath.Join(home, "subdir", "other"), []byte("other content"), 0600), "Setup: could not create file")
+				require.NoError(t, os.Symlink("file", filepath.Join(home, "link")), "Setup: could not create symlink")
+			}
+			archiveDir := filepath.Join(t.TempDir(), "archives")
+
+			err := users.ArchivePath(home, archiveDir, "user1")
+			if tc.wantErr {
+				require.Error(t, err, "ArchivePath should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "ArchivePath should not return an error, but did")
+
+			archives, err := filepath.Glob(filepath.Join(archiveDir, "user1-*.tar.gz"))
+			require.NoError(t, err, "Glob should not return an error")
+			if tc.missingHome {
+				require.Empty(t, archives, "No archive should be created for a missing home directory")
+				return
+			}
+			require.Len(t, archives, 1, "Exactly one archive should be created")
+
+			golden.CheckOrUpdateYAML(t, tarballContent(t, archives[0]))
+		})
+	}
+}
+
+// tarballContent returns the names of the entries of the tarball with the content of the regular files.
+func tarballContent(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err, "Could not open archive")
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	require.NoError(t, err, "Archive should be gzip compressed")
+	tr := tar.NewReader(gr)
+
+	content := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err, "Archive should be a valid tarball")
+
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			require.NoError(t, err, "Could not read archive entry")
+			content[hdr.Name] = string(data)
+		case tar.TypeSymlink:
+			content[hdr.Name] = "-> " + hdr.Linkname
+		default:
+			content[hdr.Name] = ""
+		}
+	}
+	return content
+}
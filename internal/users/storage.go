@@ -0,0 +1,68 @@
+package users
+
+import (
+	"time"
+
+	"github.com/ubuntu/authd/internal/users/cache"
+)
+
+// Storage is the database of the users and groups managed by authd. The default implementation is the bbolt
+// database of the cache package.
+//
+// All the methods must be safe for concurrent use. The lookups return a cache.NoDataFoundError when the requested
+// entry doesn't exist.
+type Storage interface {
+	// Users.
+	UserByID(uid uint32) (cache.UserDB, error)
+	UserByName(name string) (cache.UserDB, error)
+	AllUsers() ([]cache.UserDB, error)
+	ListUsers(match func(cache.UserRecord) bool) ([]cache.UserRecord, error)
+	UsersLastLoggedInBefore(t time.Time) ([]cache.UserDB, error)
+	ApplyUserUpdate(update cache.UserUpdate) (cache.UserDB, error)
+	ApplyUserUpdates(updates ...cache.UserUpdate) ([]cache.UserDB, error)
+	UpdateUserAttributes(usr cache.UserDB) error
+	DeleteUser(uid uint32) error
+	DisableUser(uid uint32) error
+	SetUserLocked(uid uint32, locked bool) error
+	RecordLogin(uid uint32, t time.Time, source string) error
+	LastLogin(name string) (time.Time, string, error)
+	RefreshedAt(name string) (time.Time, error)
+	SetUserRefreshed(uid uint32, t time.Time) error
+	RemapUserID(name string, newUID uint32) (cache.IDTranslation, error)
+
+	// Attributes of the users.
+	BrokerForUser(username string) (string, error)
+	UpdateBrokerForUser(username, brokerID string) error
+	UserExtendedAttributes(uid uint32) (map[string]string, error)
+	QuotaProfile(name string) (string, error)
+	SetQuotaProfile(uid uint32, profile string) error
+
+	// Groups.
+	GroupByID(gid uint32) (cache.GroupDB, error)
+	GroupByName(name string) (cache.GroupDB, error)
+	GroupByUGID(ugid string) (cache.GroupDB, error)
+	AllGroups() ([]cache.GroupDB, error)
+	UserGroups(uid uint32) ([]cache.GroupDB, error)
+	UserLocalGroups(uid uint32) ([]string, error)
+	RevokeLocalGroups(uid uint32, groups []string) error
+	DeleteGroup(gid uint32) error
+	RemapGroupID(name string, newGID uint32) (cache.IDTranslation, error)
+
+	// IDs.
+	UserIDCollisions() ([]cache.IDCollision, error)
+	GroupIDCollisions() ([]cache.IDCollision, error)
+	IDTranslations() ([]cache.IDTranslation, error)
+
+	// Audit log.
+	AppendAuditEntries(entries ...cache.AuditEntry) error
+	AuditEntries() ([]cache.AuditEntry, error)
+	CountAuditEntriesSince(action string, since time.Time) (int, error)
+	PruneAuditEntries(before time.Time, maxEntries int) (int, error)
+
+	// Administration of the database.
+	Maintenance() (cache.MaintenanceReport, error)
+	Metrics() (cache.Metrics, error)
+	Close() error
+}
+
+var _ Storage = (*cache.Cache)(nil)
@@ -0,0 +1,52 @@
+package users_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/cache"
+)
+
+// storageSpy is a storage recording the users looked up and whether it was closed.
+type storageSpy struct {
+	*cache.Cache
+
+	lookedUp []string
+	closed   bool
+}
+
+func (s *storageSpy) UserByName(name string) (cache.UserDB, error) {
+	s.lookedUp = append(s.lookedUp, name)
+	return s.Cache.UserByName(name)
+}
+
+func (s *storageSpy) Close() error {
+	s.closed = true
+	return s.Cache.Close()
+}
+
+func TestWithStorage(t *testing.T) {
+	t.Parallel()
+
+	dbDir := t.TempDir()
+	cache.Z_ForTests_CreateDBFromYAML(t, filepath.Join("testdata", "db", "multiple_users_and_groups.db.yaml"), dbDir)
+	c, err := cache.New(dbDir)
+	require.NoError(t, err, "Setup: could not open the database")
+	s := &storageSpy{Cache: c}
+
+	// The cache directory is not used when a storage is provided.
+	cacheDir := filepath.Join(t.TempDir(), "unused")
+	m, err := users.NewManager(users.DefaultConfig, cacheDir, users.WithStorage(s))
+	require.NoError(t, err, "NewManager should not return an error, but did")
+	require.NoDirExists(t, cacheDir, "NewManager should not create the cache directory when a storage is provided")
+
+	u, err := m.UserByName("user1")
+	require.NoError(t, err, "UserByName should not return an error, but did")
+	require.Equal(t, uint32(1111), u.UID, "UserByName should return the user of the storage")
+	require.Equal(t, []string{"user1"}, s.lookedUp, "The manager should look up the user in the storage")
+
+	require.NoError(t, m.Stop(), "Stop should not return an error, but did")
+	require.True(t, s.closed, "Stop should close the storage")
+}
@@ -0,0 +1,134 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ubuntu/authd/internal/users/types"
+	"github.com/ubuntu/authd/log"
+	"github.com/ubuntu/decorate"
+)
+
+// defaultVisudoCommand is the command used to validate the sudoers drop-ins if none is configured.
+const defaultVisudoCommand = "visudo"
+
+// validateSudoersConfig checks that the sudoers drop-ins can be generated from the configuration.
+func validateSudoersConfig(config Config) (err error) {
+	defer decorate.OnError(&err, "invalid sudoers configuration")
+
+	if config.SudoersDir == "" {
+		return nil
+	}
+	if !filepath.IsAbs(config.SudoersDir) {
+		return fmt.Errorf("sudoers directory %q must be an absolute path", config.SudoersDir)
+	}
+	if config.SudoersClaim == "" {
+		return errors.New("no claim granting the sudoers privileges")
+	}
+	if strings.TrimSpace(config.SudoersPrivileges) == "" {
+		return errors.New("no sudoers privileges")
+	}
+	if strings.ContainsAny(config.SudoersPrivileges, "\n\\") {
+		return fmt.Errorf("sudoers privileges %q must be on a single line", config.SudoersPrivileges)
+	}
+
+	return nil
+}
+
+// sudoersFile returns the path of the sudoers drop-in of the user. The user is referred to by its UID, as user names
+// can contain characters which are not allowed in the names of the drop-ins.
+func (s *settings) sudoersFile(uid uint32) string {
+	return filepath.Join(s.SudoersDir, fmt.Sprintf("authd-%d", uid))
+}
+
+// updateSudoers creates the sudoers drop-in of the user if it has the claim granting the sudoers privileges, or
+// removes it otherwise.
+func (s *settings) updateSudoers(name string, uid uint32, groups []types.GroupInfo) (err error) {
+	defer decorate.OnError(&err, "could not update sudoers drop-in of user %q", name)
+
+	if s.SudoersDir == "" {
+		return nil
+	}
+
+	if !slices.ContainsFunc(groups, func(g types.GroupInfo) bool { return g.Name == s.SudoersClaim }) {
+		return s.removeSudoers(name, uid)
+	}
+
+	path := s.sudoersFile(uid)
+	content := fmt.Sprintf("# Managed by authd for user %s, do not edit.\n#%d %s\n", name, uid, s.SudoersPrivileges)
+	if current, err := os.ReadFile(path); err == nil && string(current) == content {
+		return nil
+	}
+
+	if s.LocalGroupsDryRun {
+		log.Infof(context.Background(), "Dry run: would grant sudoers privileges %q to user %q", s.SudoersPrivileges, name)
+		return nil
+	}
+
+	// The drop-in is written and validated under a name containing a dot, which sudo ignores, and only renamed once
+	// valid so that an invalid drop-in can't break sudo.
+	f, err := os.CreateTemp(s.SudoersDir, ".authd-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+	if err := f.Chmod(0440); err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	command := s.VisudoCommand
+	if command == "" {
+		command = defaultVisudoCommand
+	}
+	if out, err := exec.Command(command, "-c", "-q", "-f", f.Name()).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %v: %s", command, err, strings.TrimSpace(string(out)))
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+
+	log.Infof(context.Background(), "Granted sudoers privileges %q to user %q", s.SudoersPrivileges, name)
+	return nil
+}
+
+// removeSudoers removes the sudoers drop-in of the user, if any.
+func (s *settings) removeSudoers(name string, uid uint32) error {
+	if s.SudoersDir == "" {
+		return nil
+	}
+
+	path := s.sudoersFile(uid)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if s.LocalGroupsDryRun {
+		log.Infof(context.Background(), "Dry run: would revoke sudoers privileges of user %q", name)
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	log.Infof(context.Background(), "Revoked sudoers privileges of user %q", name)
+	return nil
+}
@@ -0,0 +1,106 @@
+package users_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/users"
+	"github.com/ubuntu/authd/internal/users/devicetokens"
+	"github.com/ubuntu/authd/internal/users/idgenerator"
+	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
+	"github.com/ubuntu/authd/internal/users/types"
+)
+
+func TestUpdateUserSudoers(t *testing.T) {
+	tests := map[string]struct {
+		groups         []types.GroupInfo
+		previousGroups []types.GroupInfo
+		dryRun         bool
+		invalidDropIn  bool
+		purge          bool
+
+		wantDropIn bool
+		wantErr    bool
+	}{
+		"Grant_privileges_to_user_with_claim":             {groups: []types.GroupInfo{{Name: "admin", UGID: "1"}}, wantDropIn: true},
+		"Grant_privileges_to_user_with_nested_claim":      {groups: []types.GroupInfo{{Name: "group1", UGID: "1", Parents: []types.GroupInfo{{Name: "admin", UGID: "2"}}}}, wantDropIn: true},
+		"Keep_privileges_of_user_still_having_claim":      {groups: []types.GroupInfo{{Name: "admin", UGID: "1"}}, previousGroups: []types.GroupInfo{{Name: "admin", UGID: "1"}}, wantDropIn: true},
+		"Do_not_grant_privileges_to_user_without_claim":   {groups: []types.GroupInfo{{Name: "group1", UGID: "1"}}},
+		"Revoke_privileges_of_user_which_lost_claim":      {groups: []types.GroupInfo{{Name: "group1", UGID: "1"}}, previousGroups: []types.GroupInfo{{Name: "admin", UGID: "2"}}},
+		"Revoke_privileges_of_purged_user":                {previousGroups: []types.GroupInfo{{Name: "admin", UGID: "1"}}, purge: true},
+		"Do_not_grant_privileges_in_dry_run":              {groups: []types.GroupInfo{{Name: "admin", UGID: "1"}}, dryRun: true},
+		"Do_not_revoke_privileges_in_dry_run":             {groups: []types.GroupInfo{{Name: "group1", UGID: "1"}}, previousGroups: []types.GroupInfo{{Name: "admin", UGID: "2"}}, dryRun: true, wantDropIn: true},
+		"Do_not_revoke_privileges_of_purged_user_dry_run": {previousGroups: []types.GroupInfo{{Name: "admin", UGID: "1"}}, purge: true, dryRun: true, wantDropIn: true},
+
+		"Error_if_drop_in_is_invalid": {groups: []types.GroupInfo{{Name: "admin", UGID: "1"}}, invalidDropIn: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			localgroupstestutils.SetupGroupMock(t, filepath.Join("testdata", "groups", "users_in_groups.group"))
+
+			// The command fails when the drop-in is invalid, as visudo would.
+			cmdDir := t.TempDir()
+			exitCode := 0
+			if tc.invalidDropIn {
+				exitCode = 1
+			}
+			script := fmt.Sprintf("#!/bin/sh\necho \"syntax error\" >&2\nexit %d\n", exitCode)
+			command := filepath.Join(cmdDir, "visudo")
+			require.NoError(t, os.WriteFile(command, []byte(script), 0700), "Setup: could not create visudo command")
+
+			sudoersDir := t.TempDir()
+			config := users.DefaultConfig
+			config.SudoersDir = sudoersDir
+			config.SudoersClaim = "admin"
+			config.SudoersPrivileges = "ALL=(ALL:ALL) ALL"
+			config.VisudoCommand = command
+			m, err := users.NewManager(config, t.TempDir(), users.WithIDGenerator(&idgenerator.IDGeneratorMock{
+				UIDsToGenerate: []uint32{1111},
+				GIDsToGenerate: []uint32{11110, 11111, 11112},
+			}), users.WithDeviceTokenKeyring(devicetokens.Z_ForTests_NewKeyring(t)))
+			require.NoError(t, err, "Setup: could not create user manager")
+			t.Cleanup(func() { _ = m.Stop() })
+
+			u := types.UserInfo{Name: "user4", Dir: "/home/user4", Shell: "/bin/bash"}
+			if tc.previousGroups != nil {
+				u.Groups = tc.previousGroups
+				require.NoError(t, m.UpdateUser(context.Background(), u, "broker-id"), "Setup: could not create user")
+			}
+			m.SetLocalGroupsDryRun(tc.dryRun)
+
+			u.Groups = tc.groups
+			if tc.purge {
+				require.NoError(t, m.PurgeUser(u.Name, "test"), "PurgeUser should not return an error, but did")
+			} else {
+				err = m.UpdateUser(context.Background(), u, "broker-id")
+				if tc.wantErr {
+					require.Error(t, err, "UpdateUser should return an error, but did not")
+				} else {
+					require.NoError(t, err, "UpdateUser should not return an error, but did")
+				}
+			}
+
+			entries, err := os.ReadDir(sudoersDir)
+			require.NoError(t, err, "Could not read the sudoers directory")
+			if !tc.wantDropIn {
+				require.Empty(t, entries, "No drop-in should be left in the sudoers directory")
+				return
+			}
+			require.Len(t, entries, 1, "There should be a single drop-in in the sudoers directory")
+			require.Equal(t, "authd-1111", entries[0].Name(), "The drop-in should be named after the UID of the user")
+
+			info, err := entries[0].Info()
+			require.NoError(t, err, "Could not get the drop-in file info")
+			require.Equal(t, os.FileMode(0440), info.Mode().Perm(), "The drop-in should only be readable")
+
+			got, err := os.ReadFile(filepath.Join(sudoersDir, entries[0].Name()))
+			require.NoError(t, err, "Could not read the drop-in")
+			golden.CheckOrUpdate(t, string(got))
+		})
+	}
+}
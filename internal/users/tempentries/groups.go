@@ -71,6 +71,15 @@ func groupEntry(group groupRecord) types.GroupEntry {
 // Returns the generated GID and a cleanup function that should be called to remove the temporary group once the group
 // was added to the database.
 func (r *temporaryGroupRecords) RegisterGroup(name string) (gid uint32, cleanup func(), err error) {
+	return r.registerGroup(name, r.idGenerator.GenerateGID)
+}
+
+// RegisterRemoteGroup is like RegisterGroup, but for groups provided by a broker, which have their own GID range.
+func (r *temporaryGroupRecords) RegisterRemoteGroup(name string) (gid uint32, cleanup func(), err error) {
+	return r.registerGroup(name, r.idGenerator.GenerateRemoteGroupGID)
+}
+
+func (r *temporaryGroupRecords) registerGroup(name string, generateGID func() (uint32, error)) (gid uint32, cleanup func(), err error) {
 	r.registerMu.Lock()
 	defer r.registerMu.Unlock()
 
@@ -85,7 +94,7 @@ func (r *temporaryGroupRecords) RegisterGroup(name string) (gid uint32, cleanup
 
 	// Generate a GID until we find a unique one
 	for {
-		gid, err = r.idGenerator.GenerateGID()
+		gid, err = generateGID()
 		if err != nil {
 			return 0, nil, err
 		}
@@ -123,12 +132,14 @@ func (r *temporaryGroupRecords) uniqueNameAndGID(name string, gid uint32, tmpID
 		return false, err
 	}
 	for _, entry := range entries {
+		//consttime:ignore The password of the temporary groups is their ID, which is not a secret.
 		if entry.Name == name && entry.Passwd != tmpID {
 			// A group with the same name already exists, we can't register this temporary group.
 			log.Debugf(context.Background(), "Name %q already in use by GID %d", name, entry.GID)
 			return false, fmt.Errorf("group %q already exists", name)
 		}
 
+		//consttime:ignore The password of the temporary groups is their ID, which is not a secret.
 		if entry.GID == gid && entry.Passwd != tmpID {
 			log.Debugf(context.Background(), "GID %d already in use by group %q, generating a new one", gid, entry.Name)
 			return false, nil
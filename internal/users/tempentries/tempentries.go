@@ -18,6 +18,7 @@ type NoDataFoundError = cache.NoDataFoundError
 type IDGenerator interface {
 	GenerateUID() (uint32, error)
 	GenerateGID() (uint32, error)
+	GenerateRemoteGroupGID() (uint32, error)
 }
 
 // TemporaryRecords is the in-memory temporary user and group records.
@@ -15,13 +15,14 @@ func init() {
 }
 
 type manager struct {
-	cache *cache.Cache
+	cache users.Storage
 }
 
-// GetManagerCache returns the cache of the manager.
+// GetManagerCache returns the cache of the manager. It panics if the manager doesn't use the default storage.
 func GetManagerCache(m *users.Manager) *cache.Cache {
 	//#nosec:G103 // This is only used in tests.
 	mTest := *(*manager)(unsafe.Pointer(m))
 
-	return mTest.cache
+	//nolint:forcetypeassert // The tests using this function rely on the default storage.
+	return mTest.cache.(*cache.Cache)
 }
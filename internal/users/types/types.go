@@ -1,6 +1,8 @@
 // Package types provides types for the users package.
 package types
 
+import "time"
+
 // UserInfo is the user information returned by the broker.
 type UserInfo struct {
 	Name  string
@@ -10,6 +12,33 @@ type UserInfo struct {
 	Shell string
 
 	Groups []GroupInfo
+
+	// Avatar is the path of the picture of the user, if the broker provides one.
+	Avatar string `json:"avatar,omitempty"`
+
+	// ExtendedAttributes are key/value attributes attached to the user by the broker, like an employee ID.
+	ExtendedAttributes map[string]string `json:"extended_attributes,omitempty"`
+
+	// PasswordExpiresAt is the Unix time at which the password of the user expires, as set by the identity provider.
+	// 0 means that the broker doesn't know it.
+	PasswordExpiresAt int64 `json:"password_expires_at,omitempty"`
+
+	// PasswordChanged is set if the user just changed their password. It's not provided by the broker.
+	PasswordChanged bool `json:"-"`
+
+	// ShadowAging overrides the configured password aging of the user. It's not provided by the broker.
+	ShadowAging ShadowAging `json:"-"`
+
+	// PrimaryGroup overrides the configured primary group of the user. It's not provided by the broker.
+	PrimaryGroup string `json:"-"`
+}
+
+// ShadowAging are the password aging fields, in days, of the shadow entry of a user. Unset fields are not overridden.
+type ShadowAging struct {
+	MinPwdAge     *int
+	MaxPwdAge     *int
+	PwdWarnPeriod *int
+	PwdInactivity *int
 }
 
 // GroupInfo is the group information returned by the broker.
@@ -17,6 +46,13 @@ type GroupInfo struct {
 	Name string
 	GID  *uint32
 	UGID string
+
+	// Parents are the groups which this group is a member of. The user is a member of them as well.
+	Parents []GroupInfo `json:",omitempty"`
+
+	// ExpiresAt is the Unix time at which the membership of the user in the group expires, for example for a temporary
+	// privilege. It's only supported for local groups. 0 means that the membership doesn't expire.
+	ExpiresAt int64 `json:"expires_at,omitempty"`
 }
 
 // UserEntry is the user information sent to the NSS service.
@@ -38,6 +74,8 @@ type ShadowEntry struct {
 	PwdInactivity  int
 	MinPwdAge      int
 	ExpirationDate int
+	// Locked is true if the user was locked by an administrator.
+	Locked bool
 }
 
 // GroupEntry is the group information sent to the NSS service.
@@ -47,3 +85,168 @@ type GroupEntry struct {
 	Users  []string
 	Passwd string
 }
+
+// IDCollision is a UID or GID which is used by more than one user or group.
+type IDCollision struct {
+	// Kind is either "user" or "group".
+	Kind string
+	ID   uint32
+	// AuthdNames are the names of the authd entries using the ID.
+	AuthdNames []string
+	// LocalNames are the names of the entries of the local passwd or group file using the ID.
+	LocalNames []string
+}
+
+// IDTranslation is a record of a UID or GID which was remapped to a new value.
+type IDTranslation struct {
+	// Kind is either "user" or "group".
+	Kind  string
+	Name  string
+	OldID uint32
+	NewID uint32
+	Time  time.Time
+}
+
+// MaintenanceReport is the summary of a maintenance of the database.
+type MaintenanceReport struct {
+	PrunedReferences    int
+	RebuiltIndexEntries int
+	SizeBefore          int64
+	SizeAfter           int64
+}
+
+// AuditEvent is a record of a change done to the authd users and groups.
+type AuditEvent struct {
+	Time time.Time
+	// Actor is who requested the change.
+	Actor string
+	// Action is what was done, for example "user-added".
+	Action string
+	// Target is the name of the user or group which was changed.
+	Target string
+	// Details is a human readable description of the change.
+	Details string
+}
+
+// AuditFilter selects audit events. Empty fields match all events.
+type AuditFilter struct {
+	Target string
+	Action string
+	Since  time.Time
+	// Limit is the maximum number of events to return, keeping the most recent ones.
+	Limit int
+}
+
+// UserAttributes are the attributes of a user which can be modified locally. Nil fields are not modified.
+type UserAttributes struct {
+	Gecos *string
+	Dir   *string
+	Shell *string
+}
+
+// LastLogin is the record of the last successful login of a user.
+type LastLogin struct {
+	// Time is zero if the user never logged in.
+	Time time.Time
+	// Source is the remote host or the terminal the user logged in from, if known.
+	Source   string
+	BrokerID string
+}
+
+// FailedLogin is a failed authentication attempt of a user.
+type FailedLogin struct {
+	Time time.Time
+	// Source is the remote host or the terminal the user tried to log in from, if known.
+	Source string
+}
+
+// Lockout is the state of the lockout of a user after too many failed authentication attempts.
+type Lockout struct {
+	// LockedOut is true if the user can't authenticate until LockedUntil.
+	LockedOut bool
+	// LockedUntil is zero if the user is locked out until an administrator unlocks it.
+	LockedUntil time.Time
+	// FailedLogins are the recent failed authentication attempts of the user, from the oldest to the newest.
+	FailedLogins []FailedLogin
+}
+
+// Metrics are statistics about the users and groups managed by authd and their database.
+type Metrics struct {
+	Users  int
+	Groups int
+	// UsersCreatedLastHour is the number of users created during the last hour.
+	UsersCreatedLastHour int
+	// DatabaseSize is the size in bytes of the database.
+	DatabaseSize int64
+	// WriteTransactions is the number of write transactions on the database since the start of the daemon.
+	WriteTransactions uint64
+	// AverageWriteTransactionTime and MaxWriteTransactionTime are the average and the maximum durations of these
+	// write transactions.
+	AverageWriteTransactionTime time.Duration
+	MaxWriteTransactionTime     time.Duration
+}
+
+// UserFilter selects users. Zero-value fields match all users.
+type UserFilter struct {
+	BrokerID string
+	// LastLoginBefore selects the users whose last login is older than it. Users without any recorded login are not
+	// selected.
+	LastLoginBefore time.Time
+	// Locked selects the locked users if true and the unlocked ones if false.
+	Locked *bool
+	// Stale selects the users whose information expired if true and the other ones if false.
+	Stale *bool
+	// MinUID and MaxUID select the users whose UID is in this inclusive range. A zero MaxUID has no upper bound.
+	MinUID uint32
+	MaxUID uint32
+}
+
+// GroupFilter selects groups. Zero-value fields match all groups.
+type GroupFilter struct {
+	// Member selects the groups having this user as member.
+	Member string
+	// MinGID and MaxGID select the groups whose GID is in this inclusive range. A zero MaxGID has no upper bound.
+	MinGID uint32
+	MaxGID uint32
+}
+
+// Page selects a page of a listing sorted by ID.
+type Page struct {
+	// After is the last ID of the previous page, 0 for the first page.
+	After uint32
+	// Size is the maximum number of entries of the page. A zero Size returns all the entries.
+	Size int
+}
+
+// UserSummary is a user with its broker and its state.
+type UserSummary struct {
+	UserEntry
+	BrokerID string
+	// LastLogin is zero if the user never logged in.
+	LastLogin time.Time
+	Locked    bool
+	// Stale is true if the information of the user was not provided or confirmed by its broker for longer than the
+	// configured TTL.
+	Stale bool
+}
+
+// Change is a change of an authd user or group in the database.
+type Change struct {
+	// Kind is what changed, for example "UserAdded".
+	Kind string
+	// Name is the name of the user or group which changed.
+	Name string
+}
+
+// LocalGroupDrift is a difference between the memberships in local groups of one of the users of authd, according to
+// the database, and the local group file.
+type LocalGroupDrift struct {
+	User  string
+	Group string
+	// Kind is "not-member", if the user is not a member of the group it was granted, "missing-group", if that group
+	// doesn't exist, "not-removed", if the user is still a member of the group after its membership expired, or
+	// "extra-member", if the user is a member of a group authd manages without authd granting it.
+	Kind string
+	// Repaired is true if the membership was granted again or revoked.
+	Repaired bool
+}
@@ -0,0 +1,271 @@
+// Package varlink implements the server side of the varlink protocol, used by the systemd components to query the
+// services of the system, like the users and groups they provide.
+//
+// The messages are JSON objects terminated by a NUL byte. A call can ask for several replies with "more", which are all
+// sent with "continues" but the last one.
+package varlink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/ubuntu/authd/log"
+)
+
+const (
+	// ErrMethodNotFound is returned when the called method isn't implemented by the server.
+	ErrMethodNotFound = "org.varlink.service.MethodNotFound"
+	// ErrInvalidParameter is returned when a parameter of the call is invalid.
+	ErrInvalidParameter = "org.varlink.service.InvalidParameter"
+	// ErrExpectedMore is returned when the call must ask for several replies.
+	ErrExpectedMore = "org.varlink.service.ExpectedMore"
+)
+
+// maxMessageSize bounds the size of the calls, which are small, so that a client can't make the server hold an
+// unbounded message in memory.
+const maxMessageSize = 64 * 1024
+
+// Call is a call of a method by a client.
+type Call struct {
+	Method     string          `json:"method"`
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	// More is set when the client accepts several replies.
+	More bool `json:"more,omitempty"`
+	// Oneway is set when the client doesn't expect any reply.
+	Oneway bool `json:"oneway,omitempty"`
+}
+
+// Error is a varlink error, returned to the client with its name and parameters.
+type Error struct {
+	Name       string
+	Parameters any
+}
+
+// Error returns the name of the varlink error.
+func (e Error) Error() string {
+	return e.Name
+}
+
+// Reply sends a reply to the call. continues must be set on all the replies but the last one of a call asking for
+// more.
+type Reply func(parameters any, continues bool) error
+
+// MethodHandler handles a call of a method. It sends its replies with reply, or returns an Error to reply with it. It
+// must send at least one reply if it doesn't return an error.
+type MethodHandler func(ctx context.Context, call Call, reply Reply) error
+
+// reply is a message sent to the client.
+type reply struct {
+	Parameters any    `json:"parameters,omitempty"`
+	Continues  bool   `json:"continues,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Server serves varlink methods on sockets.
+type Server struct {
+	methods map[string]MethodHandler
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// listeners and conns are the sockets served and their connections, which are closed when the server stops.
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	// calls are the calls in progress, which a graceful stop waits for.
+	calls   sync.WaitGroup
+	stopped bool
+	mu      sync.Mutex
+}
+
+// NewServer returns a server of the methods, by their full name like "io.systemd.UserDatabase.GetUserRecord".
+func NewServer(methods map[string]MethodHandler) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		methods:   methods,
+		ctx:       ctx,
+		cancel:    cancel,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve accepts the connections on lis until the server stops. It always returns nil once stopped.
+func (s *Server) Serve(lis net.Listener) error {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		_ = lis.Close()
+		return nil
+	}
+	s.listeners[lis] = struct{}{}
+	s.mu.Unlock()
+
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.stopped {
+				return nil
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		if s.stopped {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return nil
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		go s.serveConn(conn)
+	}
+}
+
+// Stop closes the sockets and the connections, cancelling the calls in progress.
+func (s *Server) Stop() {
+	s.stop(true)
+	s.cancel()
+	s.calls.Wait()
+}
+
+// GracefulStop closes the sockets, waits for the calls in progress to finish, then closes the connections.
+func (s *Server) GracefulStop() {
+	s.stop(false)
+	s.calls.Wait()
+	s.cancel()
+}
+
+// stop stops accepting new connections and new calls. The connections are closed if force is set, otherwise only
+// their reading side is, so that the calls in progress can still reply.
+func (s *Server) stop(force bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	for lis := range s.listeners {
+		_ = lis.Close()
+	}
+	for conn := range s.conns {
+		if uc, ok := conn.(*net.UnixConn); ok && !force {
+			_ = uc.CloseRead()
+			continue
+		}
+		_ = conn.Close()
+	}
+}
+
+// serveConn handles the calls of a connection, one after the other, until the client closes it.
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxMessageSize)
+	scanner.Split(splitMessages)
+	for scanner.Scan() {
+		s.mu.Lock()
+		if s.stopped {
+			s.mu.Unlock()
+			return
+		}
+		s.calls.Add(1)
+		s.mu.Unlock()
+
+		err := s.handle(conn, scanner.Bytes())
+		s.calls.Done()
+		if err != nil {
+			log.Debugf(context.Background(), "Closing varlink connection: %v", err)
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Debugf(context.Background(), "Closing varlink connection: %v", err)
+	}
+}
+
+// handle handles a call, and returns an error if the connection must be closed.
+func (s *Server) handle(conn net.Conn, msg []byte) error {
+	var call Call
+	if err := json.Unmarshal(msg, &call); err != nil {
+		return fmt.Errorf("invalid call: %v", err)
+	}
+
+	var lastReplied bool
+	send := func(r reply) error {
+		if call.Oneway {
+			return nil
+		}
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = conn.Write(append(data, 0))
+		return err
+	}
+	replyFunc := func(parameters any, continues bool) error {
+		if lastReplied {
+			return errors.New("the call was already replied to")
+		}
+		if continues && !call.More {
+			return Error{Name: ErrExpectedMore}
+		}
+		lastReplied = !continues
+		return send(reply{Parameters: emptyIfNil(parameters), Continues: continues})
+	}
+
+	handler, ok := s.methods[call.Method]
+	if !ok {
+		return send(reply{Error: ErrMethodNotFound, Parameters: map[string]string{"method": call.Method}})
+	}
+
+	err := handler(s.ctx, call, replyFunc)
+	var verr Error
+	if errors.As(err, &verr) {
+		if lastReplied {
+			return nil
+		}
+		return send(reply{Error: verr.Name, Parameters: emptyIfNil(verr.Parameters)})
+	}
+	if err != nil {
+		return err
+	}
+	if !lastReplied {
+		return errors.New("the method didn't send its last reply")
+	}
+	return nil
+}
+
+// emptyIfNil returns an empty object instead of nil parameters, which varlink requires.
+func emptyIfNil(parameters any) any {
+	if parameters == nil {
+		return struct{}{}
+	}
+	if raw, ok := parameters.(json.RawMessage); ok && len(raw) == 0 {
+		return struct{}{}
+	}
+	return parameters
+}
+
+// splitMessages splits the messages on the NUL byte terminating them.
+func splitMessages(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return 0, nil, errors.New("truncated message")
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,148 @@
+package varlink_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/varlink"
+)
+
+func TestServe(t *testing.T) {
+	t.Parallel()
+
+	methods := map[string]varlink.MethodHandler{
+		"org.example.Echo": func(_ context.Context, call varlink.Call, reply varlink.Reply) error {
+			return reply(call.Parameters, false)
+		},
+		"org.example.List": func(_ context.Context, call varlink.Call, reply varlink.Reply) error {
+			if err := reply(map[string]int{"n": 1}, true); err != nil {
+				return err
+			}
+			return reply(map[string]int{"n": 2}, false)
+		},
+		"org.example.Fail": func(context.Context, varlink.Call, varlink.Reply) error {
+			return varlink.Error{Name: "org.example.Failed", Parameters: map[string]string{"reason": "expected"}}
+		},
+		"org.example.Broken": func(context.Context, varlink.Call, varlink.Reply) error {
+			return errors.New("not a varlink error")
+		},
+	}
+
+	tests := map[string]struct {
+		calls []string
+
+		wantReplies []string
+	}{
+		"Reply_to_call":                  {calls: []string{`{"method":"org.example.Echo","parameters":{"a":1}}`}, wantReplies: []string{`{"parameters":{"a":1}}`}},
+		"Reply_to_call_without_params":   {calls: []string{`{"method":"org.example.Echo"}`}, wantReplies: []string{`{"parameters":{}}`}},
+		"Reply_several_times_with_more":  {calls: []string{`{"method":"org.example.List","more":true}`}, wantReplies: []string{`{"parameters":{"n":1},"continues":true}`, `{"parameters":{"n":2}}`}},
+		"Reply_to_calls_one_after_other": {calls: []string{`{"method":"org.example.Echo","parameters":{"a":1}}`, `{"method":"org.example.Echo","parameters":{"a":2}}`}, wantReplies: []string{`{"parameters":{"a":1}}`, `{"parameters":{"a":2}}`}},
+		"Do_not_reply_to_oneway_call":    {calls: []string{`{"method":"org.example.Echo","parameters":{"a":1},"oneway":true}`, `{"method":"org.example.Echo","parameters":{"a":2}}`}, wantReplies: []string{`{"parameters":{"a":2}}`}},
+
+		"Error_when_method_is_not_found":           {calls: []string{`{"method":"org.example.DoesNotExist"}`}, wantReplies: []string{`{"parameters":{"method":"org.example.DoesNotExist"},"error":"org.varlink.service.MethodNotFound"}`}},
+		"Error_when_method_fails":                  {calls: []string{`{"method":"org.example.Fail"}`}, wantReplies: []string{`{"parameters":{"reason":"expected"},"error":"org.example.Failed"}`}},
+		"Error_when_several_replies_without_more":  {calls: []string{`{"method":"org.example.List"}`}, wantReplies: []string{`{"parameters":{},"error":"org.varlink.service.ExpectedMore"}`}},
+		"Error_closes_connection_on_invalid_call":  {calls: []string{`not json`, `{"method":"org.example.Echo"}`}},
+		"Error_closes_connection_on_method_errors": {calls: []string{`{"method":"org.example.Broken"}`, `{"method":"org.example.Echo"}`}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := varlink.NewServer(methods)
+			socketPath := startServer(t, s)
+
+			conn, err := net.Dial("unix", socketPath)
+			require.NoError(t, err, "Setup: could not connect to the server")
+			defer conn.Close()
+
+			for _, c := range tc.calls {
+				_, err := conn.Write(append([]byte(c), 0))
+				require.NoError(t, err, "Setup: could not send the call")
+			}
+
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)), "Setup: could not set read deadline")
+			r := bufio.NewReader(conn)
+			var got []string
+			for {
+				msg, err := r.ReadBytes(0)
+				if err != nil {
+					require.NotErrorIs(t, err, os.ErrDeadlineExceeded, "The server should close the connection")
+					break
+				}
+				got = append(got, string(msg[:len(msg)-1]))
+				if len(got) == len(tc.wantReplies) {
+					break
+				}
+			}
+			require.Equal(t, tc.wantReplies, got, "The server should send the expected replies")
+		})
+	}
+}
+
+func TestGracefulStop(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s := varlink.NewServer(map[string]varlink.MethodHandler{
+		"org.example.Wait": func(_ context.Context, _ varlink.Call, reply varlink.Reply) error {
+			close(started)
+			<-release
+			return reply(nil, false)
+		},
+	})
+	socketPath := startServer(t, s)
+
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err, "Setup: could not connect to the server")
+	defer conn.Close()
+	_, err = conn.Write(append([]byte(`{"method":"org.example.Wait"}`), 0))
+	require.NoError(t, err, "Setup: could not send the call")
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("GracefulStop should wait for the calls in progress")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	msg, err := bufio.NewReader(conn).ReadBytes(0)
+	require.NoError(t, err, "The call in progress should still be replied to")
+	require.Equal(t, `{"parameters":{}}`, string(msg[:len(msg)-1]), "The reply should be the expected one")
+	<-stopped
+
+	_, err = net.Dial("unix", socketPath)
+	require.Error(t, err, "The server should not accept connections once stopped")
+}
+
+// startServer serves s on a socket and returns its path. The server is stopped when the test ends.
+func startServer(t *testing.T, s *varlink.Server) string {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "varlink.socket")
+	lis, err := net.Listen("unix", socketPath)
+	require.NoError(t, err, "Setup: could not listen on socket")
+
+	serveErr := make(chan error)
+	go func() { serveErr <- s.Serve(lis) }()
+	t.Cleanup(func() {
+		s.Stop()
+		require.NoError(t, <-serveErr, "Serve should not return an error once stopped")
+	})
+
+	return socketPath
+}
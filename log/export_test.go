@@ -0,0 +1,8 @@
+package log
+
+import "context"
+
+// JournalFields returns the journal fields of the logs of ctx.
+func JournalFields(ctx context.Context) map[string]string {
+	return journalFields(ctx)
+}
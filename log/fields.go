@@ -0,0 +1,59 @@
+package log
+
+import (
+	"context"
+	"slices"
+)
+
+// Names of the fields identifying what the logs are about, so that log aggregation systems can index them.
+const (
+	// ComponentField is the component of the daemon emitting the log.
+	ComponentField = "component"
+	// SessionIDField is the ID of the authentication session.
+	SessionIDField = "session_id"
+	// UserField is the name of the user.
+	UserField = "user"
+	// BrokerField is the ID of the broker.
+	BrokerField = "broker"
+	// GroupField is the name of the group.
+	GroupField = "group"
+	// AuditField is the audit channel of the log, like "local-groups", so that the changes of the privileges of the
+	// users can be traced.
+	AuditField = "audit"
+	// ResultField is the result of the audited change.
+	ResultField = "result"
+)
+
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx whose logs have the given fields, as alternating keys and values. The fields of
+// the parent context are kept, and overridden by the new values of the same keys.
+func WithFields(ctx context.Context, keysAndValues ...any) context.Context {
+	parent := fields(ctx)
+	merged := make([]any, 0, len(parent)+len(keysAndValues))
+	for i := 0; i+1 < len(parent); i += 2 {
+		if slices.Contains(keys(keysAndValues), parent[i]) {
+			continue
+		}
+		merged = append(merged, parent[i], parent[i+1])
+	}
+	merged = append(merged, keysAndValues...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// fields returns the fields of the logs of ctx, as alternating keys and values.
+func fields(ctx context.Context) []any {
+	if ctx == nil {
+		return nil
+	}
+	f, _ := ctx.Value(fieldsKey{}).([]any)
+	return f
+}
+
+// keys returns the keys of alternating keys and values.
+func keys(keysAndValues []any) (r []any) {
+	for i := 0; i < len(keysAndValues); i += 2 {
+		r = append(r, keysAndValues[i])
+	}
+	return r
+}
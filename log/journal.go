@@ -2,6 +2,10 @@ package log
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/coreos/go-systemd/v22/journal"
 )
@@ -19,11 +23,36 @@ func InitJournalHandler(force bool) {
 		}
 	}
 
-	SetHandler(func(_ context.Context, level Level, format string, args ...interface{}) {
-		journal.Print(mapPriority(level), format, args...)
+	SetHandler(func(ctx context.Context, level Level, format string, args ...interface{}) {
+		_ = journal.Send(fmt.Sprintf(format, args...), mapPriority(level), journalFields(ctx))
 	})
 }
 
+// journalFieldNames are the names of the native journal fields of the log fields. The other log fields are prefixed
+// with AUTHD_.
+var journalFieldNames = map[string]string{
+	ComponentField: "AUTHD_COMPONENT",
+	SessionIDField: "AUTHD_SESSION",
+	BrokerField:    "AUTHD_BROKER",
+	UserField:      "USER",
+}
+
+// journalFields returns the journal fields of the logs of ctx, identified by the name of the program.
+func journalFields(ctx context.Context) map[string]string {
+	f := redactedFields(ctx)
+	vars := make(map[string]string, len(f)/2+1)
+	vars["SYSLOG_IDENTIFIER"] = filepath.Base(os.Args[0])
+	for i := 0; i+1 < len(f); i += 2 {
+		key := fmt.Sprint(f[i])
+		name, ok := journalFieldNames[key]
+		if !ok {
+			name = "AUTHD_" + strings.ToUpper(key)
+		}
+		vars[name] = fmt.Sprint(f[i+1])
+	}
+	return vars
+}
+
 func mapPriority(level Level) journal.Priority {
 	if level <= DebugLevel {
 		return journal.PriDebug
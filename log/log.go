@@ -5,8 +5,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	stdlog "log"
 	"log/slog"
 	"maps"
+	"os"
+	"slices"
 	"sync"
 	"sync/atomic"
 )
@@ -24,6 +27,26 @@ var logLevel slog.Level
 
 var hasCustomOutput atomic.Pointer[io.Writer]
 
+// Format is the format of the log lines.
+type Format string
+
+const (
+	// TextFormat prints human readable log lines, with the fields of the logs appended to their message.
+	TextFormat Format = "text"
+	// KeyValueFormat prints log lines as key=value pairs.
+	KeyValueFormat Format = "keyvalue"
+	// JSONFormat prints log lines as JSON objects.
+	JSONFormat Format = "json"
+)
+
+// Formats are the supported formats of the log lines.
+var Formats = []Format{TextFormat, KeyValueFormat, JSONFormat}
+
+var logFormat atomic.Pointer[Format]
+
+// defaultLogger is the logger printing in the text format when no custom output is set.
+var defaultLogger = slog.Default()
+
 const (
 	// ErrorLevel level. Logs. Used for errors that should definitely be noted.
 	// Commonly used for hooks to send errors to an error tracking service.
@@ -38,7 +61,7 @@ const (
 
 func logFuncAdapter(slogFunc func(ctx context.Context, msg string, args ...interface{})) Handler {
 	return func(ctx context.Context, _ Level, format string, args ...interface{}) {
-		slogFunc(ctx, fmt.Sprintf(format, args...))
+		slogFunc(ctx, fmt.Sprintf(format, args...), redactedFields(ctx)...)
 	}
 }
 
@@ -71,7 +94,10 @@ func IsLevelEnabled(level Level) bool {
 }
 
 func isLevelEnabled(context context.Context, level Level) bool {
-	return slog.Default().Enabled(context, level)
+	if debugScope.Load() == nil {
+		return slog.Default().Enabled(context, level)
+	}
+	return level >= GetLevel() || inDebugScope(context)
 }
 
 // SetLevel sets the standard logger level.
@@ -79,20 +105,67 @@ func SetLevel(level Level) (oldLevel Level) {
 	logLevelMu.Lock()
 	defer func() {
 		logLevelMu.Unlock()
-		if outPtr := hasCustomOutput.Load(); outPtr != nil {
-			SetOutput(*outPtr)
+		if hasCustomOutput.Load() != nil || GetFormat() != TextFormat {
+			installLogger()
 		}
 	}()
+	oldLevel = logLevel
 	logLevel = level
-	return slog.SetLogLoggerLevel(level)
+	slog.SetLogLoggerLevel(handlerLevel(level))
+	return oldLevel
 }
 
-// SetOutput sets the log output.
+// SetOutput sets the log output. A nil output restores the default one.
 func SetOutput(out io.Writer) {
-	hasCustomOutput.Store(&out)
-	slog.SetDefault(slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{
-		Level: GetLevel(),
-	})))
+	if out == nil {
+		hasCustomOutput.Store(nil)
+	} else {
+		hasCustomOutput.Store(&out)
+	}
+	installLogger()
+}
+
+// GetFormat returns the format of the log lines.
+func GetFormat() Format {
+	if f := logFormat.Load(); f != nil {
+		return *f
+	}
+	return TextFormat
+}
+
+// SetFormat sets the format of the log lines printed by the default handlers.
+func SetFormat(format Format) error {
+	if !slices.Contains(Formats, format) {
+		return fmt.Errorf("unknown log format %q, must be one of %v", format, Formats)
+	}
+	logFormat.Store(&format)
+	installLogger()
+	return nil
+}
+
+// installLogger sets the default logger matching the output, the format and the level of the logs.
+func installLogger() {
+	var out io.Writer = os.Stderr
+	if outPtr := hasCustomOutput.Load(); outPtr != nil {
+		out = *outPtr
+	}
+	opts := &slog.HandlerOptions{Level: handlerLevel(GetLevel())}
+
+	switch GetFormat() {
+	case JSONFormat:
+		slog.SetDefault(slog.New(slog.NewJSONHandler(out, opts)))
+	case KeyValueFormat:
+		slog.SetDefault(slog.New(slog.NewTextHandler(out, opts)))
+	default:
+		if hasCustomOutput.Load() == nil {
+			// Setting another default logger redirected the standard logger to it.
+			stdlog.SetOutput(os.Stderr)
+			stdlog.SetFlags(stdlog.LstdFlags)
+			slog.SetDefault(defaultLogger)
+			return
+		}
+		slog.SetDefault(slog.New(slog.NewTextHandler(out, opts)))
+	}
 }
 
 // SetLevelHandler allows to define the default handler function for a given level.
@@ -135,6 +208,12 @@ func logf(context context.Context, level Level, format string, args ...interface
 		return
 	}
 
+	// The secrets are redacted from the message before any handler gets it.
+	msg := fmt.Sprintf(format, args...)
+	if redacted := redact(context, msg); redacted != msg {
+		format, args = "%s", []any{redacted}
+	}
+
 	handlersMu.RLock()
 	handler := handlers[level]
 	handlersMu.RUnlock()
@@ -1,8 +1,13 @@
 package log_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -215,3 +220,135 @@ func TestSetHandler(t *testing.T) {
 		require.False(t, handlerCalled, "Handler should not have been called")
 	}
 }
+
+func TestFormat(t *testing.T) {
+	// This can't be parallel.
+	defaultLevel := log.GetLevel()
+	t.Cleanup(func() {
+		_ = log.SetFormat(log.TextFormat)
+		log.SetOutput(os.Stderr)
+		log.SetLevel(defaultLevel)
+	})
+
+	tests := map[string]struct {
+		format log.Format
+		fields []any
+
+		wantContains []string
+		wantErr      bool
+	}{
+		"Text_format_appends_the_fields": {format: log.TextFormat, fields: []any{log.UserField, "user1"}, wantContains: []string{`msg="Some message"`, "user=user1"}},
+		"Key_value_format_prints_fields": {
+			format:       log.KeyValueFormat,
+			fields:       []any{log.ComponentField, "pam", log.SessionIDField, "some-session"},
+			wantContains: []string{"level=INFO", `msg="Some message"`, "component=pam", "session_id=some-session"},
+		},
+		"JSON_format_prints_fields": {
+			format:       log.JSONFormat,
+			fields:       []any{log.BrokerField, "broker-id", log.UserField, "user1"},
+			wantContains: []string{`"level":"INFO"`, `"msg":"Some message"`, `"broker":"broker-id"`, `"user":"user1"`},
+		},
+		"Newer_fields_override_the_ones_of_the_parent_context": {
+			format:       log.JSONFormat,
+			fields:       []any{log.UserField, "user2"},
+			wantContains: []string{`"user":"user2"`, `"component":"nss"`},
+		},
+
+		"Error_on_unknown_format": {format: "xml", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			log.SetOutput(&out)
+			log.SetLevel(log.InfoLevel)
+
+			err := log.SetFormat(tc.format)
+			if tc.wantErr {
+				require.Error(t, err, "SetFormat should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "SetFormat should not return an error, but did")
+			require.Equal(t, tc.format, log.GetFormat(), "GetFormat should return the format which was set")
+
+			ctx := log.WithFields(context.Background(), log.ComponentField, "nss", log.UserField, "user1")
+			log.Info(log.WithFields(ctx, tc.fields...), "Some message")
+
+			got := out.String()
+			require.Equal(t, 1, strings.Count(got, "\n"), "Only one log line should be printed")
+			for _, want := range tc.wantContains {
+				require.Contains(t, got, want, "The log line should contain the expected field")
+			}
+			if tc.format == log.JSONFormat {
+				var line map[string]any
+				require.NoError(t, json.Unmarshal(out.Bytes(), &line), "The log line should be valid JSON")
+			}
+		})
+	}
+}
+
+func TestDebugScope(t *testing.T) {
+	// This can't be parallel.
+	defaultLevel := log.GetLevel()
+	t.Cleanup(func() {
+		log.SetDebugScope(nil)
+		log.SetOutput(os.Stderr)
+		log.SetLevel(defaultLevel)
+	})
+
+	tests := map[string]struct {
+		scope  map[string][]string
+		fields []any
+
+		wantPrinted bool
+	}{
+		"Prints_debug_logs_of_user_in_scope":   {scope: map[string][]string{log.UserField: {"user1"}}, fields: []any{log.UserField, "user1"}, wantPrinted: true},
+		"Prints_debug_logs_of_broker_in_scope": {scope: map[string][]string{log.BrokerField: {"broker-id"}}, fields: []any{log.UserField, "user2", log.BrokerField, "broker-id"}, wantPrinted: true},
+
+		"Does_not_print_debug_logs_out_of_scope":     {scope: map[string][]string{log.UserField: {"user1"}}, fields: []any{log.UserField, "user2"}},
+		"Does_not_print_debug_logs_without_scope":    {fields: []any{log.UserField, "user1"}},
+		"Does_not_print_debug_logs_with_empty_scope": {scope: map[string][]string{log.UserField: {}}, fields: []any{log.UserField, "user1"}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			log.SetOutput(&out)
+			log.SetLevel(log.InfoLevel)
+			log.SetDebugScope(tc.scope)
+			t.Cleanup(func() { log.SetDebugScope(nil) })
+
+			ctx := log.WithFields(context.Background(), tc.fields...)
+			log.Debug(ctx, "Some debug message")
+			log.Info(context.Background(), "Some info message")
+
+			if tc.wantPrinted {
+				require.Contains(t, out.String(), "Some debug message", "The debug log should be printed")
+			} else {
+				require.NotContains(t, out.String(), "Some debug message", "The debug log should not be printed")
+			}
+			require.Contains(t, out.String(), "Some info message", "The logs of the level should always be printed")
+			require.False(t, log.IsLevelEnabled(log.DebugLevel), "The level of the logs should not change")
+		})
+	}
+}
+
+func TestJournalFields(t *testing.T) {
+	t.Parallel()
+
+	ctx := log.WithFields(context.Background(),
+		log.ComponentField, "pam",
+		log.SessionIDField, "some-session",
+		log.BrokerField, "broker-id",
+		log.UserField, "user1",
+		"other_field", 42,
+	)
+
+	got := log.JournalFields(ctx)
+	require.Equal(t, map[string]string{
+		"SYSLOG_IDENTIFIER": filepath.Base(os.Args[0]),
+		"AUTHD_COMPONENT":   "pam",
+		"AUTHD_SESSION":     "some-session",
+		"AUTHD_BROKER":      "broker-id",
+		"USER":              "user1",
+		"AUTHD_OTHER_FIELD": "42",
+	}, got, "JournalFields should return the native journal fields")
+}
@@ -0,0 +1,23 @@
+package log
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// dumpPayloads is whether the payloads of the gRPC and D-Bus calls are logged.
+var dumpPayloads atomic.Bool
+
+// SetPayloadDumps enables the logs of the payloads of the gRPC and D-Bus calls, at the debug level. Their secrets are
+// redacted like in all the logs.
+func SetPayloadDumps(enabled bool) {
+	dumpPayloads.Store(enabled)
+}
+
+// Payloadf outputs the payload of a call with the level [DebugLevel] if the payload dumps are enabled.
+func Payloadf(context context.Context, format string, args ...interface{}) {
+	if !dumpPayloads.Load() {
+		return
+	}
+	logf(context, DebugLevel, format, args...)
+}
@@ -0,0 +1,134 @@
+package log
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Redacted replaces the secrets in the logs.
+const Redacted = "[redacted]"
+
+// secretValues matches the values of the keys holding secrets, like passwords, challenges and tokens, whether they are
+// printed as JSON, even escaped in a string, as protobuf text, as Go values or as key=value pairs. The values must
+// follow their key directly, so that sentences like "invalid token: expired" are kept.
+var secretValues = regexp.MustCompile(`(?i)\b([\w-]*(?:password|passwd|passphrase|challenge|secret|token)\\?["']?[:=])` +
+	`(\s*"(?:[^"\\]|\\.)*"|\s*\\"(?:[^\\]|\\[^"])*\\"|[^\s,;(){}\[\]"\\]+)`)
+
+// hashKey is the key the names of the users are hashed with in the logs, nil if they are not hashed.
+var hashKey atomic.Pointer[[]byte]
+
+// SetHashUsernames replaces the names of the users of the logs by their hash with key, so that the logs can be shared
+// without identifying the users, while the logs of a same user can still be followed. The key must be secret and kept
+// across restarts, otherwise the names could be found by hashing the likely ones. A nil key keeps the names.
+//
+// The names are the ones of the UserField of the contexts of the logs, which are replaced in their messages too.
+func SetHashUsernames(key []byte) {
+	if key == nil {
+		hashKey.Store(nil)
+		return
+	}
+	hashKey.Store(&key)
+}
+
+// HashUsername returns the hash identifying the user in the logs, or the name itself if the names are not hashed.
+func HashUsername(name string) string {
+	key := hashKey.Load()
+	if key == nil {
+		return name
+	}
+	mac := hmac.New(sha256.New, *key)
+	mac.Write([]byte(name))
+	return "user-" + hex.EncodeToString(mac.Sum(nil)[:6])
+}
+
+// redact returns the message without the secrets, and with the name of the user of ctx hashed if enabled.
+func redact(ctx context.Context, msg string) string {
+	msg = secretValues.ReplaceAllStringFunc(msg, func(m string) string {
+		sub := secretValues.FindStringSubmatch(m)
+		key, value := sub[1], sub[2]
+		space := value[:len(value)-len(strings.TrimLeft(value, " \t\n"))]
+		switch value = value[len(space):]; {
+		case strings.HasPrefix(value, `\"`):
+			return key + space + `\"` + Redacted + `\"`
+		case strings.HasPrefix(value, `"`):
+			return key + space + `"` + Redacted + `"`
+		default:
+			return key + space + Redacted
+		}
+	})
+
+	if hashKey.Load() == nil {
+		return msg
+	}
+	for _, name := range usernames(ctx) {
+		msg = replaceWord(msg, name, HashUsername(name))
+	}
+	return msg
+}
+
+// replaceWord replaces the occurrences of word in s which are not part of a longer word, so that the name of a user
+// doesn't alter the ones it is a part of, like "user1" in "user10".
+func replaceWord(s, word, replacement string) string {
+	if word == "" {
+		return s
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, word)
+		if i < 0 {
+			break
+		}
+		end := i + len(word)
+		before, _ := utf8.DecodeLastRuneInString(s[:i])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		b.WriteString(s[:i])
+		if isWordRune(before) || isWordRune(after) {
+			b.WriteString(word)
+		} else {
+			b.WriteString(replacement)
+		}
+		s = s[end:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// isWordRune returns true if r is part of a word, like a name.
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// redactedFields returns the fields of the logs of ctx, with the name of the user hashed if enabled.
+func redactedFields(ctx context.Context) []any {
+	f := fields(ctx)
+	if hashKey.Load() == nil {
+		return f
+	}
+	r := make([]any, len(f))
+	copy(r, f)
+	for i := 0; i+1 < len(r); i += 2 {
+		if r[i] == UserField {
+			r[i+1] = HashUsername(fmt.Sprint(r[i+1]))
+		}
+	}
+	return r
+}
+
+// usernames returns the names of the users of the fields of ctx.
+func usernames(ctx context.Context) (names []string) {
+	f := fields(ctx)
+	for i := 0; i+1 < len(f); i += 2 {
+		if name := fmt.Sprint(f[i+1]); f[i] == UserField && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,158 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/log"
+)
+
+func TestRedact(t *testing.T) {
+	// This can't be parallel.
+	defaultLevel := log.GetLevel()
+	t.Cleanup(func() {
+		log.SetHandler(nil)
+		log.SetLevel(defaultLevel)
+	})
+
+	tests := map[string]struct {
+		format string
+		args   []any
+
+		want string
+	}{
+		"Keeps_messages_without_secrets": {format: "User %q has no token yet: %v", args: []any{"user1", "none"}, want: `User "user1" has no token yet: none`},
+		"Keeps_the_sentences_about_secrets": {
+			format: "Could not read device token: %v", args: []any{"permission denied"},
+			want: "Could not read device token: permission denied",
+		},
+
+		"Redacts_JSON_values": {
+			format: "Authentication data: %s", args: []any{`{"challenge":"some password","wait":"true"}`},
+			want: `Authentication data: {"challenge":"[redacted]","wait":"true"}`,
+		},
+		"Redacts_JSON_values_with_spaces": {
+			format: "Authentication data: %s", args: []any{`{"device_token": "some token"}`},
+			want: `Authentication data: {"device_token": "[redacted]"}`,
+		},
+		"Redacts_JSON_values_with_escaped_quotes": {
+			format: "Authentication data: %s", args: []any{`{"challenge":"some \"quoted\" password"}`},
+			want: `Authentication data: {"challenge":"[redacted]"}`,
+		},
+		"Redacts_escaped_JSON_values": {
+			format: "Authentication data: %q", args: []any{`{"challenge":"some password","wait":"true"}`},
+			want: `Authentication data: "{\"challenge\":\"[redacted]\",\"wait\":\"true\"}"`,
+		},
+		"Redacts_protobuf_text_values": {
+			format: "gRPC request: %s", args: []any{`session_id:"some-session" authentication_data:{challenge:"some password"}`},
+			want: `gRPC request: session_id:"some-session" authentication_data:{challenge:"[redacted]"}`,
+		},
+		"Redacts_Go_values": {
+			format: "%#v", args: []any{struct{ Password, User string }{"some password", "user1"}},
+			want: `struct { Password string; User string }{Password:"[redacted]", User:"user1"}`,
+		},
+		"Redacts_map_values": {
+			format: "%v", args: []any{map[string]string{"secret": "s3cr3t", "user": "user1"}},
+			want: "map[secret:[redacted] user:user1]",
+		},
+		"Redacts_key_value_pairs": {format: "Options: password=%s mode=%s", args: []any{"s3cr3t", "auth"}, want: "Options: password=[redacted] mode=auth"},
+		"Redacts_case_insensitively": {
+			format: "%s", args: []any{`{"DeviceToken":"some token","PASSWD":"s3cr3t"}`},
+			want: `{"DeviceToken":"[redacted]","PASSWD":"[redacted]"}`,
+		},
+		"Redacts_messages_without_arguments": {format: `{"token":"some token"}`, want: `{"token":"[redacted]"}`},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			log.SetHandler(func(_ context.Context, _ log.Level, format string, args ...interface{}) {
+				got = fmt.Sprintf(format, args...)
+			})
+			log.SetLevel(log.DebugLevel)
+
+			if tc.args == nil {
+				log.Debug(context.Background(), tc.format)
+			} else {
+				log.Debugf(context.Background(), tc.format, tc.args...)
+			}
+			require.Equal(t, tc.want, got, "The secrets should be redacted from the log")
+		})
+	}
+}
+
+func TestHashUsernames(t *testing.T) {
+	// This can't be parallel.
+	defaultLevel := log.GetLevel()
+	t.Cleanup(func() {
+		log.SetHashUsernames(nil)
+		_ = log.SetFormat(log.TextFormat)
+		log.SetOutput(os.Stderr)
+		log.SetLevel(defaultLevel)
+	})
+
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	log.SetLevel(log.InfoLevel)
+	require.NoError(t, log.SetFormat(log.KeyValueFormat), "Setup: could not set the log format")
+
+	ctx := log.WithFields(context.Background(), log.UserField, "user1", log.BrokerField, "broker-id")
+	require.Equal(t, "user1", log.HashUsername("user1"), "HashUsername should return the name when the names are not hashed")
+
+	log.Infof(ctx, "User %q logged in", "user1")
+	require.Contains(t, out.String(), `User \"user1\" logged in`, "The names of the users should be kept by default")
+	require.Contains(t, out.String(), "user=user1", "The user field should be kept by default")
+	require.Equal(t, "user1", log.JournalFields(ctx)["USER"], "The user journal field should be kept by default")
+
+	log.SetHashUsernames([]byte("some other key"))
+	otherKeyHash := log.HashUsername("user1")
+
+	out.Reset()
+	log.SetHashUsernames([]byte("some key"))
+	hash := log.HashUsername("user1")
+	require.Equal(t, hash, log.HashUsername("user1"), "HashUsername should always return the same hash for a user")
+	require.NotEqual(t, hash, log.HashUsername("user2"), "HashUsername should return a different hash for another user")
+	require.NotEqual(t, hash, otherKeyHash, "HashUsername should return a different hash with another key")
+
+	log.Infof(ctx, "User %q logged in", "user1")
+	require.NotContains(t, out.String(), "user1", "The name of the user should be hashed")
+	require.Contains(t, out.String(), fmt.Sprintf(`User \"%s\" logged in`, hash), "The name of the user should be hashed in the message")
+	require.Contains(t, out.String(), "user="+hash, "The user field should be hashed")
+	require.Contains(t, out.String(), "broker=broker-id", "The other fields should be kept")
+	require.Equal(t, hash, log.JournalFields(ctx)["USER"], "The user journal field should be hashed")
+
+	out.Reset()
+	log.Infof(ctx, "Users user1, user10 and superuser1 logged in")
+	require.Contains(t, out.String(), fmt.Sprintf("Users %s, user10 and superuser1 logged in", hash),
+		"Only the whole name of the user should be hashed in the message")
+}
+
+func TestPayloadf(t *testing.T) {
+	// This can't be parallel.
+	defaultLevel := log.GetLevel()
+	t.Cleanup(func() {
+		log.SetPayloadDumps(false)
+		log.SetHandler(nil)
+		log.SetLevel(defaultLevel)
+	})
+
+	var got []string
+	log.SetHandler(func(_ context.Context, _ log.Level, format string, args ...interface{}) {
+		got = append(got, fmt.Sprintf(format, args...))
+	})
+	log.SetLevel(log.DebugLevel)
+
+	log.Payloadf(context.Background(), "gRPC request: %s", `challenge:"some password"`)
+	require.Empty(t, got, "The payloads should not be logged by default")
+
+	log.SetPayloadDumps(true)
+	log.Payloadf(context.Background(), "gRPC request: %s", `challenge:"some password"`)
+	require.Equal(t, []string{`gRPC request: challenge:"[redacted]"`}, got, "The payloads should be logged redacted once enabled")
+
+	log.SetLevel(log.InfoLevel)
+	log.Payloadf(context.Background(), "gRPC request: %s", `challenge:"some password"`)
+	require.Len(t, got, 1, "The payloads should only be logged at the debug level")
+}
@@ -0,0 +1,143 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ubuntu/decorate"
+)
+
+// RotatingFile is a log file which is rotated once it reaches its maximum size or age, so that the logs don't fill the
+// disk. The rotated files are named after the log file, suffixed with .1 for the most recent one, .2 and so on.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+
+	f         *os.File
+	size      int64
+	startedAt time.Time
+	mu        sync.Mutex
+}
+
+// OpenRotatingFile opens the log file at path, appending to it if it exists. The file is rotated once it's larger than
+// maxSize bytes or older than maxAge, which is measured from the first write in the file, and maxBackups rotated files
+// are kept. A zero maxSize or maxAge disables the matching rotation.
+func OpenRotatingFile(path string, maxSize int64, maxAge time.Duration, maxBackups int) (r *RotatingFile, err error) {
+	defer decorate.OnError(&err, "can't open log file %q", path)
+
+	if maxSize < 0 || maxAge < 0 || maxBackups < 0 {
+		return nil, errors.New("the maximum size, age and number of backups can't be negative")
+	}
+
+	r = &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write writes p to the log file, rotating it first if the write would exceed its maximum size or if it's too old.
+func (r *RotatingFile) Write(p []byte) (n int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		return 0, fs.ErrClosed
+	}
+
+	if r.size > 0 && (r.maxSize > 0 && r.size+int64(len(p)) > r.maxSize ||
+		r.maxAge > 0 && time.Since(r.startedAt) > r.maxAge) {
+		// Keep logging in the current file if it can't be rotated: the logs can't report the error.
+		if err := r.rotate(); err != nil && r.f == nil {
+			if err := r.open(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if r.size == 0 {
+		r.startedAt = time.Now()
+	}
+	n, err = r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the log file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// open opens the log file for appending.
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	r.f = f
+	r.size = fi.Size()
+	// The creation time of the file is not available, the age of an existing file is counted from its last write.
+	r.startedAt = fi.ModTime()
+	return nil
+}
+
+// rotate shifts the rotated files, removing the oldest one, and moves the current log file to the most recent one.
+func (r *RotatingFile) rotate() (err error) {
+	defer decorate.OnError(&err, "can't rotate log file %q", r.path)
+
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	r.f = nil
+
+	if r.maxBackups == 0 {
+		if err := os.Remove(r.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return r.open()
+	}
+
+	if err := os.Remove(r.backupPath(r.maxBackups)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for i := r.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(r.backupPath(i), r.backupPath(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(r.path, r.backupPath(1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return r.open()
+}
+
+// backupPath returns the path of the n-th most recent rotated file.
+func (r *RotatingFile) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", r.path, n)
+}
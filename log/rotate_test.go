@@ -0,0 +1,98 @@
+package log_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/log"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		existingContent string
+		maxSize         int64
+		maxAge          time.Duration
+		maxBackups      int
+		noDir           bool
+
+		wantFiles map[string]string
+		wantErr   bool
+	}{
+		"Does_not_rotate_when_rotation_is_disabled": {maxBackups: 3, wantFiles: map[string]string{"log": "line1\nline2\nline3\n"}},
+		"Appends_to_existing_file":                  {existingContent: "line0\n", maxSize: 100, wantFiles: map[string]string{"log": "line0\nline1\nline2\nline3\n"}},
+		"Rotates_when_exceeding_max_size": {
+			maxSize: 8, maxBackups: 3,
+			wantFiles: map[string]string{"log": "line3\n", "log.1": "line2\n", "log.2": "line1\n"},
+		},
+		"Rotates_existing_file_when_exceeding_max_size": {
+			existingContent: "line0\n", maxSize: 8, maxBackups: 5,
+			wantFiles: map[string]string{"log": "line3\n", "log.1": "line2\n", "log.2": "line1\n", "log.3": "line0\n"},
+		},
+		"Rotates_when_older_than_max_age": {
+			maxAge: time.Nanosecond, maxBackups: 3,
+			wantFiles: map[string]string{"log": "line3\n", "log.1": "line2\n", "log.2": "line1\n"},
+		},
+		"Keeps_only_max_backups_rotated_files": {
+			maxSize: 8, maxBackups: 1,
+			wantFiles: map[string]string{"log": "line3\n", "log.1": "line2\n"},
+		},
+		"Removes_rotated_file_without_backups": {
+			maxSize:   8,
+			wantFiles: map[string]string{"log": "line3\n"},
+		},
+
+		"Error_on_negative_max_size":             {maxSize: -1, wantErr: true},
+		"Error_on_negative_max_age":              {maxAge: -time.Hour, wantErr: true},
+		"Error_on_negative_max_backups":          {maxBackups: -1, wantErr: true},
+		"Error_when_file_directory_is_not_found": {noDir: true, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "log")
+			if tc.noDir {
+				path = filepath.Join(dir, "doesnotexist", "log")
+			}
+			if tc.existingContent != "" {
+				err := os.WriteFile(path, []byte(tc.existingContent), 0600)
+				require.NoError(t, err, "Setup: could not write existing log file")
+			}
+
+			f, err := log.OpenRotatingFile(path, tc.maxSize, tc.maxAge, tc.maxBackups)
+			if tc.wantErr {
+				require.Error(t, err, "OpenRotatingFile should return an error, but did not")
+				return
+			}
+			require.NoError(t, err, "OpenRotatingFile should not return an error, but did")
+
+			for _, line := range []string{"line1\n", "line2\n", "line3\n"} {
+				// Make sure the file is older than the maximum age.
+				time.Sleep(time.Millisecond)
+				n, err := f.Write([]byte(line))
+				require.NoError(t, err, "Write should not return an error, but did")
+				require.Equal(t, len(line), n, "Write should write the whole line")
+			}
+			require.NoError(t, f.Close(), "Close should not return an error, but did")
+
+			_, err = f.Write([]byte("after close\n"))
+			require.Error(t, err, "Write should return an error after Close, but did not")
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err, "Setup: could not read log directory")
+			got := make(map[string]string)
+			for _, e := range entries {
+				d, err := os.ReadFile(filepath.Join(dir, e.Name()))
+				require.NoError(t, err, "Setup: could not read log file")
+				got[e.Name()] = string(d)
+			}
+			require.Equal(t, tc.wantFiles, got, "The log files should have the expected content")
+		})
+	}
+}
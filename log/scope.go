@@ -0,0 +1,71 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
+)
+
+// debugScope is the fields whose values enable the debug logs, whatever the level.
+var debugScope atomic.Pointer[map[string][]string]
+
+// SetDebugScope enables the debug logs of the contexts having one of the given values for a field, as the name of a
+// user with UserField, whatever the level of the logs. An empty scope disables it.
+func SetDebugScope(scope map[string][]string) {
+	s := make(map[string][]string)
+	for k, v := range scope {
+		if len(v) > 0 {
+			s[k] = slices.Clone(v)
+		}
+	}
+	if len(s) == 0 {
+		debugScope.Store(nil)
+	} else {
+		debugScope.Store(&s)
+	}
+
+	// The level of the handlers depends on whether a scope is set.
+	SetLevel(GetLevel())
+}
+
+// DebugScope returns the fields whose values enable the debug logs, whatever the level.
+func DebugScope() map[string][]string {
+	s := debugScope.Load()
+	if s == nil {
+		return nil
+	}
+	r := make(map[string][]string, len(*s))
+	for k, v := range *s {
+		r[k] = slices.Clone(v)
+	}
+	return r
+}
+
+// inDebugScope returns true if one of the fields of ctx is in the debug scope.
+func inDebugScope(ctx context.Context) bool {
+	s := debugScope.Load()
+	if s == nil {
+		return false
+	}
+	f := fields(ctx)
+	for i := 0; i+1 < len(f); i += 2 {
+		key, ok := f[i].(string)
+		if !ok {
+			continue
+		}
+		if slices.Contains((*s)[key], fmt.Sprint(f[i+1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// handlerLevel returns the level of the handlers printing the logs: the debug logs must reach them when a scope is
+// set, and are filtered before.
+func handlerLevel(level Level) Level {
+	if debugScope.Load() != nil {
+		return min(level, DebugLevel)
+	}
+	return level
+}
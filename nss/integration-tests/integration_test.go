@@ -27,10 +27,10 @@ func TestIntegration(t *testing.T) {
 	// Create a default daemon to use for most test cases.
 	defaultSocket := filepath.Join(os.TempDir(), "nss-integration-tests.sock")
 	defaultDbState := "multiple_users_and_groups"
-	defaultOutputPath := filepath.Join(filepath.Dir(daemonPath), "gpasswd.output")
+	defaultOutputPath := filepath.Join(filepath.Dir(daemonPath), "group")
 	defaultGroupsFilePath := filepath.Join(testutils.TestFamilyPath(t), "gpasswd.group")
 
-	env := append(localgroupstestutils.AuthdIntegrationTestsEnvWithGpasswdMock(t, defaultOutputPath, defaultGroupsFilePath), "AUTHD_INTEGRATIONTESTS_CURRENT_USER_AS_ROOT=1")
+	env := append(localgroupstestutils.AuthdIntegrationTestsEnvWithGroupMock(t, defaultOutputPath, defaultGroupsFilePath), "AUTHD_INTEGRATIONTESTS_CURRENT_USER_AS_ROOT=1")
 	ctx, cancel := context.WithCancel(context.Background())
 	_, stopped := testutils.RunDaemon(ctx, t, daemonPath,
 		testutils.WithSocketPath(defaultSocket),
@@ -122,12 +122,12 @@ func TestIntegration(t *testing.T) {
 
 			if useAlternativeDaemon {
 				// Run a specific new daemon for special test cases.
-				outPath := filepath.Join(t.TempDir(), "gpasswd.output")
+				outPath := filepath.Join(t.TempDir(), "group")
 				groupsFilePath := filepath.Join("testdata", "empty.group")
 
 				var daemonStopped chan struct{}
 				ctx, cancel := context.WithCancel(context.Background())
-				env := localgroupstestutils.AuthdIntegrationTestsEnvWithGpasswdMock(t, outPath, groupsFilePath)
+				env := localgroupstestutils.AuthdIntegrationTestsEnvWithGroupMock(t, outPath, groupsFilePath)
 				if !tc.currentUserNotRoot {
 					env = append(env, "AUTHD_INTEGRATIONTESTS_CURRENT_USER_AS_ROOT=1")
 				}
@@ -180,16 +180,7 @@ func TestIntegration(t *testing.T) {
 	}
 }
 
-func TestMockgpasswd(t *testing.T) {
-	localgroupstestutils.Mockgpasswd(t)
-}
-
 func TestMain(m *testing.M) {
-	// Needed to skip the test setup when running the gpasswd mock.
-	if os.Getenv("GO_WANT_HELPER_PROCESS") != "" {
-		os.Exit(m.Run())
-	}
-
 	execPath, cleanup, err := testutils.BuildDaemon("-tags=withexamplebroker,integrationtests")
 	if err != nil {
 		log.Printf("Setup: failed to build daemon: %v", err)
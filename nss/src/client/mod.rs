@@ -1,18 +1,39 @@
+use authd::info_client::InfoClient;
 use authd::nss_client::NssClient;
 use hyper_util::rt::TokioIo;
 use std::error::Error;
 use tokio::net::UnixStream;
+use tonic::metadata::MetadataValue;
+use tonic::service::interceptor::InterceptedService;
 use tonic::transport::{Channel, Endpoint, Uri};
+use tonic::{Request, Status};
 use tower::service_fn;
 
-use crate::{info, CONNECTION_TIMEOUT};
+use crate::{info, CONNECTION_TIMEOUT, REQUEST_TIMEOUT};
 
 pub mod authd {
     tonic::include_proto!("authd");
 }
 
-/// new_client creates a new client connection to the gRPC server or returns an active one.
-pub async fn new_client() -> Result<NssClient<Channel>, Box<dyn Error>> {
+/// API_VERSION is the version of the authd API used by this library, which must match
+/// apiversion.Current in the daemon.
+const API_VERSION: &str = "1";
+/// API_VERSION_METADATA_KEY is the gRPC metadata key in which the API version is sent to the daemon.
+const API_VERSION_METADATA_KEY: &str = "authd-api-version";
+
+/// Client is the NSS client sending the API version with each request.
+pub type Client = NssClient<InterceptedService<Channel, fn(Request<()>) -> Result<Request<()>, Status>>>;
+
+/// add_api_version sends the version of the API used by this library with the request, so that a daemon
+/// which doesn't support it can reject it with a clear error.
+fn add_api_version(mut req: Request<()>) -> Result<Request<()>, Status> {
+    req.metadata_mut()
+        .insert(API_VERSION_METADATA_KEY, MetadataValue::from_static(API_VERSION));
+    Ok(req)
+}
+
+/// connect creates a new channel to the gRPC server.
+async fn connect() -> Result<Channel, Box<dyn Error>> {
     info!("Connecting to authd on {}...", super::socket_path());
 
     // The URL must have a valid format, even though we don't use it.
@@ -24,5 +45,58 @@ pub async fn new_client() -> Result<NssClient<Channel>, Box<dyn Error>> {
         }))
         .await?;
 
-    Ok(NssClient::new(ch))
+    Ok(ch)
+}
+
+/// new_client creates a new client connection to the gRPC server or returns an active one.
+pub async fn new_client() -> Result<Client, Box<dyn Error>> {
+    let ch = connect().await?;
+
+    Ok(NssClient::with_interceptor(
+        ch,
+        add_api_version as fn(Request<()>) -> Result<Request<()>, Status>,
+    ))
+}
+
+/// log_capabilities logs the version and the capabilities of the daemon, to help triaging the bugs involving a
+/// daemon and a library which don't support the same version of the API.
+///
+/// It is only called when a request fails because of the version of the API, as every lookup would otherwise
+/// cost an extra request.
+pub async fn log_capabilities() {
+    let ch = match connect().await {
+        Ok(ch) => ch,
+        Err(e) => {
+            info!("could not connect to gRPC server: {}", e);
+            return;
+        }
+    };
+
+    let mut req = Request::new(authd::Empty {});
+    req.set_timeout(REQUEST_TIMEOUT);
+    let caps = match InfoClient::new(ch).get_capabilities(req).await {
+        Ok(r) => r.into_inner(),
+        Err(e) => {
+            info!("could not get the capabilities of authd: {}", e.message());
+            return;
+        }
+    };
+
+    let ranges = caps.id_ranges.unwrap_or_default();
+    info!(
+        "authd {}, serving versions {} to {} of the API, differs from this library, using version {}: \
+         services {}, features {:?}, UIDs {}-{}, GIDs {}-{}, GIDs of the groups of the brokers {}-{}",
+        caps.version,
+        caps.min_api_version,
+        caps.api_version,
+        API_VERSION,
+        caps.services.join(", "),
+        caps.features,
+        ranges.uid_min,
+        ranges.uid_max,
+        ranges.gid_min,
+        ranges.gid_max,
+        ranges.remote_groups_gid_min,
+        ranges.remote_groups_gid_max,
+    );
 }
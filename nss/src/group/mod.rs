@@ -51,7 +51,7 @@ fn get_all_entries() -> Response<Vec<Group>> {
             Ok(r) => Response::Success(group_entries_to_groups(r.into_inner().entries)),
             Err(e) => {
                 info!("error when listing groups: {}", e.code());
-                super::grpc_status_to_nss_response(e)
+                super::grpc_status_to_nss_response(e).await
             }
         }
     })
@@ -82,7 +82,7 @@ fn get_entry_by_gid(gid: gid_t) -> Response<Group> {
             Ok(r) => Response::Success(group_entry_to_group(r.into_inner())),
             Err(e) => {
                 info!("error when getting group by gid '{}': {}", gid, e.code());
-                super::grpc_status_to_nss_response(e)
+                super::grpc_status_to_nss_response(e).await
             }
         }
     })
@@ -117,7 +117,7 @@ fn get_entry_by_name(name: String) -> Response<Group> {
                     name,
                     e.code().description()
                 );
-                super::grpc_status_to_nss_response(e)
+                super::grpc_status_to_nss_response(e).await
             }
         }
     })
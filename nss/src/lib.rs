@@ -42,9 +42,15 @@ fn socket_path() -> String {
 }
 
 /// grpc_status_to_nss_response converts a gRPC status to a NSS response.
-fn grpc_status_to_nss_response<T>(status: Status) -> Response<T> {
+async fn grpc_status_to_nss_response<T>(status: Status) -> Response<T> {
     match status.code() {
         Code::NotFound => Response::NotFound,
+        Code::FailedPrecondition | Code::Unimplemented => {
+            // The daemon and this library don't support the same version of the API.
+            info!("authd API version mismatch: {}", status.message());
+            client::log_capabilities().await;
+            Response::Unavail
+        }
         _ => Response::Unavail,
     }
 }
@@ -52,7 +52,7 @@ fn get_all_entries() -> Response<Vec<Passwd>> {
             Ok(r) => Response::Success(passwd_entries_to_passwds(r.into_inner().entries)),
             Err(e) => {
                 info!("error when listing passwd: {}", e.code());
-                super::grpc_status_to_nss_response(e)
+                super::grpc_status_to_nss_response(e).await
             }
         }
     })
@@ -83,7 +83,7 @@ fn get_entry_by_uid(uid: uid_t) -> Response<Passwd> {
             Ok(r) => Response::Success(passwd_entry_to_passwd(r.into_inner())),
             Err(e) => {
                 info!("error when getting passwd by uid '{}': {}", uid, e.code());
-                super::grpc_status_to_nss_response(e)
+                super::grpc_status_to_nss_response(e).await
             }
         }
     })
@@ -123,7 +123,7 @@ fn get_entry_by_name(name: String) -> Response<Passwd> {
             Ok(r) => Response::Success(passwd_entry_to_passwd(r.into_inner())),
             Err(e) => {
                 info!("error when getting passwd by name '{}': {}", name, e.code());
-                super::grpc_status_to_nss_response(e)
+                super::grpc_status_to_nss_response(e).await
             }
         }
     })
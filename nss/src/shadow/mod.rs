@@ -46,7 +46,7 @@ fn get_all_entries() -> Response<Vec<Shadow>> {
             Ok(r) => Response::Success(shadow_entries_to_shadows(r.into_inner().entries)),
             Err(e) => {
                 info!("error when listing shadow: {}", e.code());
-                super::grpc_status_to_nss_response(e)
+                super::grpc_status_to_nss_response(e).await
             }
         }
     })
@@ -77,7 +77,7 @@ fn get_entry_by_name(name: String) -> Response<Shadow> {
             Ok(r) => Response::Success(shadow_entry_to_shadow(r.into_inner())),
             Err(e) => {
                 info!("error when getting shadow by name '{}': {}", name, e.code());
-                super::grpc_status_to_nss_response(e)
+                super::grpc_status_to_nss_response(e).await
             }
         }
     })
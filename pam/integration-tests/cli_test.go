@@ -13,6 +13,7 @@ import (
 	"github.com/ubuntu/authd/internal/proto/authd"
 	"github.com/ubuntu/authd/internal/testutils"
 	"github.com/ubuntu/authd/internal/testutils/golden"
+	"github.com/ubuntu/authd/internal/testutils/vhs"
 	localgroupstestutils "github.com/ubuntu/authd/internal/users/localentries/testutils"
 	"github.com/ubuntu/authd/pam/internal/pam_test"
 )
@@ -27,13 +28,14 @@ func TestCLIAuthenticate(t *testing.T) {
 	const socketPathEnv = "AUTHD_TESTS_CLI_AUTHENTICATE_TESTS_SOCK"
 	tapeCommand := fmt.Sprintf("./pam_authd login socket=${%s}", socketPathEnv)
 
-	defaultGPasswdOutput, groupsFile := prepareGPasswdFiles(t)
-	defaultSocketPath := runAuthd(t, defaultGPasswdOutput, groupsFile, true)
+	defaultGroupsOutput, groupsFile := prepareGroupFiles(t)
+	defaultSocketPath := runAuthd(t, defaultGroupsOutput, groupsFile, true)
 
 	tests := map[string]struct {
 		tape          string
 		tapeSettings  []tapeSetting
 		tapeVariables map[string]string
+		generatedTape *vhs.Tape
 
 		clientOptions      clientOptions
 		socketPath         string
@@ -43,17 +45,15 @@ func TestCLIAuthenticate(t *testing.T) {
 	}{
 		"Authenticate_user_successfully": {
 			tape:          "simple_auth",
-			tapeVariables: map[string]string{"AUTHD_SIMPLE_AUTH_TAPE_USER": "user1"},
+			generatedTape: cliSimpleAuthTape("user1"),
 		},
 		"Authenticate_user_successfully_with_preset_user": {
 			tape:          "simple_auth_with_preset_user",
 			clientOptions: clientOptions{PamUser: "user-integration-simple-preset"},
 		},
 		"Authenticate_user_successfully_with_invalid_connection_timeout": {
-			tape: "simple_auth",
-			tapeVariables: map[string]string{
-				"AUTHD_SIMPLE_AUTH_TAPE_USER": "user-integration-invalid-timeout",
-			},
+			tape:          "simple_auth",
+			generatedTape: cliSimpleAuthTape("user-integration-invalid-timeout"),
 			clientOptions: clientOptions{PamTimeout: "invalid"},
 		},
 		"Authenticate_user_successfully_after_trying_empty_user": {
@@ -185,16 +185,16 @@ func TestCLIAuthenticate(t *testing.T) {
 			require.NoError(t, err, "Setup: symlinking the pam client")
 
 			socketPath := defaultSocketPath
-			gpasswdOutput := defaultGPasswdOutput
+			groupsOutput := defaultGroupsOutput
 			if tc.wantLocalGroups || tc.currentUserNotRoot || tc.stopDaemonAfter > 0 {
 				// For the local groups tests we need to run authd again so that it has
-				// special environment that generates a fake gpasswd output for us to test.
+				// special environment that edits a copy of the group file for us to test.
 				// Similarly for the not-root tests authd has to run in a more restricted way.
 				// In the other cases this is not needed, so we can just use a shared authd.
 				var groupsFile string
 				var cancel func()
-				gpasswdOutput, groupsFile = prepareGPasswdFiles(t)
-				socketPath, cancel = runAuthdWithCancel(t, gpasswdOutput, groupsFile, !tc.currentUserNotRoot)
+				groupsOutput, groupsFile = prepareGroupFiles(t)
+				socketPath, cancel = runAuthdWithCancel(t, groupsOutput, groupsFile, !tc.currentUserNotRoot)
 
 				if tc.stopDaemonAfter > 0 {
 					go func() {
@@ -209,6 +209,9 @@ func TestCLIAuthenticate(t *testing.T) {
 			}
 
 			td := newTapeData(tc.tape, tc.tapeSettings...)
+			if tc.generatedTape != nil {
+				td = newGeneratedTapeData(tc.tape, tc.generatedTape, tc.tapeSettings...)
+			}
 			td.Command = tapeCommand
 			td.Variables = tc.tapeVariables
 			td.Env[socketPathEnv] = socketPath
@@ -217,7 +220,7 @@ func TestCLIAuthenticate(t *testing.T) {
 			got := td.ExpectedOutput(t, outDir)
 			golden.CheckOrUpdate(t, got)
 
-			localgroupstestutils.RequireGPasswdOutput(t, gpasswdOutput, golden.Path(t)+".gpasswd_out")
+			localgroupstestutils.RequireGroupFile(t, groupsOutput, golden.Path(t)+".group")
 
 			requireRunnerResultForUser(t, authd.SessionMode_AUTH, tc.clientOptions.PamUser, got)
 		})
@@ -348,6 +351,17 @@ func TestPamCLIRunStandalone(t *testing.T) {
 	require.Contains(t, outStr, pam.ErrIgnore.Error())
 }
 
-func TestMockgpasswd(t *testing.T) {
-	localgroupstestutils.Mockgpasswd(t)
+// cliSimpleAuthTape returns the tape authenticating the user with the password of the example broker in the CLI.
+func cliSimpleAuthTape(username string) *vhs.Tape {
+	return vhs.NewTape().
+		Hidden(func(t *vhs.Tape) {
+			t.Wait().Type(vhs.Var(vhsCommandVariable)).Press(vhs.Enter).WaitFor(`Username: user name\n`)
+		}).
+		Hidden(func(t *vhs.Tape) { t.Command(tapeTypeUsername(username)) }).
+		Hidden(func(t *vhs.Tape) {
+			t.Press(vhs.Enter).WaitScreen("Select your provider").WaitScreen("2. ExampleBroker")
+		}).
+		Hidden(func(t *vhs.Tape) { t.Type("2").Command(tapeWaitPrompt("Gimme your password")) }).
+		Hidden(func(t *vhs.Tape) { t.Command(tapeTypeCLIPassword("goodpass")) }).
+		Hidden(func(t *vhs.Tape) { t.Press(vhs.Enter).Command(vhs.Var(vhsCommandFinalAuthWaitVariable)) })
 }
@@ -34,18 +34,18 @@ var (
 	authdArtifactsDirSync sync.Once
 )
 
-func runAuthd(t *testing.T, gpasswdOutput, groupsFile string, currentUserAsRoot bool) string {
+func runAuthd(t *testing.T, groupsOutput, groupsFile string, currentUserAsRoot bool) string {
 	t.Helper()
 
-	socketPath, _ := runAuthdWithCancel(t, gpasswdOutput, groupsFile, currentUserAsRoot)
+	socketPath, _ := runAuthdWithCancel(t, groupsOutput, groupsFile, currentUserAsRoot)
 	return socketPath
 }
 
-func runAuthdWithCancel(t *testing.T, gpasswdOutput, groupsFile string, currentUserAsRoot bool, args ...testutils.DaemonOption) (string, func()) {
+func runAuthdWithCancel(t *testing.T, groupsOutput, groupsFile string, currentUserAsRoot bool, args ...testutils.DaemonOption) (string, func()) {
 	t.Helper()
 
 	ctx, cancel := context.WithCancel(context.Background())
-	env := localgroupstestutils.AuthdIntegrationTestsEnvWithGpasswdMock(t, gpasswdOutput, groupsFile)
+	env := localgroupstestutils.AuthdIntegrationTestsEnvWithGroupMock(t, groupsOutput, groupsFile)
 	if currentUserAsRoot {
 		env = append(env, authdCurrentUserRootEnvVariableContent)
 	}
@@ -246,15 +246,15 @@ func prependBinToPath(t *testing.T) string {
 	return "PATH=" + strings.Join([]string{filepath.Join(strings.TrimSpace(string(out)), "bin"), env}, ":")
 }
 
-func prepareGPasswdFiles(t *testing.T) (string, string) {
+func prepareGroupFiles(t *testing.T) (string, string) {
 	t.Helper()
 
-	gpasswdOutput := filepath.Join(t.TempDir(), "gpasswd.output")
+	groupsOutput := filepath.Join(t.TempDir(), "group")
 	groupsFile := filepath.Join(testutils.TestFamilyPath(t), "gpasswd.group")
 
-	saveArtifactsForDebugOnCleanup(t, []string{gpasswdOutput, groupsFile})
+	saveArtifactsForDebugOnCleanup(t, []string{groupsOutput, groupsFile})
 
-	return gpasswdOutput, groupsFile
+	return groupsOutput, groupsFile
 }
 
 func getUbuntuVersion(t *testing.T) int {
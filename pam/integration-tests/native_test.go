@@ -24,8 +24,8 @@ func TestNativeAuthenticate(t *testing.T) {
 	tapeCommand := fmt.Sprintf("./pam_authd login socket=${%s} force_native_client=true",
 		socketPathEnv)
 
-	defaultGPasswdOutput, groupsFile := prepareGPasswdFiles(t)
-	defaultSocketPath := runAuthd(t, defaultGPasswdOutput, groupsFile, true)
+	defaultGroupsOutput, groupsFile := prepareGroupFiles(t)
+	defaultSocketPath := runAuthd(t, defaultGroupsOutput, groupsFile, true)
 
 	tests := map[string]struct {
 		tape          string
@@ -302,16 +302,16 @@ func TestNativeAuthenticate(t *testing.T) {
 			require.NoError(t, err, "Setup: symlinking the pam client")
 
 			socketPath := defaultSocketPath
-			gpasswdOutput := defaultGPasswdOutput
+			groupsOutput := defaultGroupsOutput
 			if tc.wantLocalGroups || tc.currentUserNotRoot || tc.stopDaemonAfter > 0 {
 				// For the local groups tests we need to run authd again so that it has
-				// special environment that generates a fake gpasswd output for us to test.
+				// special environment that edits a copy of the group file for us to test.
 				// Similarly for the not-root tests authd has to run in a more restricted way.
 				// In the other cases this is not needed, so we can just use a shared authd.
 				var groupsFile string
 				var cancel func()
-				gpasswdOutput, groupsFile = prepareGPasswdFiles(t)
-				socketPath, cancel = runAuthdWithCancel(t, gpasswdOutput, groupsFile, !tc.currentUserNotRoot)
+				groupsOutput, groupsFile = prepareGroupFiles(t)
+				socketPath, cancel = runAuthdWithCancel(t, groupsOutput, groupsFile, !tc.currentUserNotRoot)
 
 				if tc.stopDaemonAfter > 0 {
 					go func() {
@@ -338,7 +338,7 @@ func TestNativeAuthenticate(t *testing.T) {
 			got := td.ExpectedOutput(t, outDir)
 			golden.CheckOrUpdate(t, got)
 
-			localgroupstestutils.RequireGPasswdOutput(t, gpasswdOutput, golden.Path(t)+".gpasswd_out")
+			localgroupstestutils.RequireGroupFile(t, groupsOutput, golden.Path(t)+".group")
 
 			if !tc.skipRunnerCheck {
 				requireRunnerResultForUser(t, authd.SessionMode_AUTH, tc.clientOptions.PamUser, got)
@@ -79,8 +79,8 @@ func testSSHAuthenticate(t *testing.T, sharedSSHd bool) {
 	require.NoError(t, err, "Setup: Can't read sshd host public key")
 	saveArtifactsForDebugOnCleanup(t, []string{sshdHostKey + ".pub"})
 
-	defaultGPasswdOutput, groupsFile := prepareGPasswdFiles(t)
-	defaultSocketPath := runAuthd(t, defaultGPasswdOutput, groupsFile, true)
+	defaultGroupsOutput, groupsFile := prepareGroupFiles(t)
+	defaultSocketPath := runAuthd(t, defaultGroupsOutput, groupsFile, true)
 
 	const tapeCommand = "ssh ${AUTHD_PAM_SSH_USER}@localhost ${AUTHD_PAM_SSH_ARGS}"
 	defaultTapeSettings := []tapeSetting{{vhsHeight, 1000}, {vhsWidth, 1500}}
@@ -258,14 +258,14 @@ Wait`,
 			t.Parallel()
 
 			socketPath := defaultSocketPath
-			gpasswdOutput := defaultGPasswdOutput
+			groupsOutput := defaultGroupsOutput
 			if tc.wantLocalGroups {
 				// For the local groups tests we need to run authd again so that it has
-				// special environment that generates a fake gpasswd output for us to test.
+				// special environment that edits a copy of the group file for us to test.
 				// In the other cases this is not needed, so we can just use a shared authd.
 				var groupsFile string
-				gpasswdOutput, groupsFile = prepareGPasswdFiles(t)
-				socketPath = runAuthd(t, gpasswdOutput, groupsFile, true)
+				groupsOutput, groupsFile = prepareGroupFiles(t)
+				socketPath = runAuthd(t, groupsOutput, groupsFile, true)
 			}
 			if tc.socketPath != "" {
 				socketPath = tc.socketPath
@@ -317,7 +317,7 @@ Wait`,
 				require.Contains(t, got, userEnv, "Logged in user does not matches")
 			}
 
-			localgroupstestutils.RequireGPasswdOutput(t, gpasswdOutput, golden.Path(t)+".gpasswd_out")
+			localgroupstestutils.RequireGroupFile(t, groupsOutput, golden.Path(t)+".group")
 		})
 	}
 }
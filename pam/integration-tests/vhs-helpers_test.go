@@ -22,20 +22,21 @@ import (
 	"github.com/ubuntu/authd/internal/proto/authd"
 	"github.com/ubuntu/authd/internal/services/permissions"
 	"github.com/ubuntu/authd/internal/testutils"
+	"github.com/ubuntu/authd/internal/testutils/vhs"
 	"github.com/ubuntu/authd/pam/internal/pam_test"
 )
 
 const (
-	vhsWidth       = "Width"
-	vhsHeight      = "Height"
-	vhsFontFamily  = "FontFamily"
-	vhsFontSize    = "FontSize"
-	vhsPadding     = "Padding"
-	vhsMargin      = "Margin"
-	vhsShell       = "Shell"
-	vhsWaitTimeout = "WaitTimeout"
-	vhsWaitPattern = "WaitPattern"
-	vhsTypingSpeed = "TypingSpeed"
+	vhsWidth       = vhs.Width
+	vhsHeight      = vhs.Height
+	vhsFontFamily  = vhs.FontFamily
+	vhsFontSize    = vhs.FontSize
+	vhsPadding     = vhs.Padding
+	vhsMargin      = vhs.Margin
+	vhsShell       = vhs.Shell
+	vhsWaitTimeout = vhs.WaitTimeout
+	vhsWaitPattern = vhs.WaitPattern
+	vhsTypingSpeed = vhs.TypingSpeed
 
 	vhsCommandVariable = "AUTHD_TEST_TAPE_COMMAND"
 
@@ -71,6 +72,9 @@ type tapeData struct {
 	Settings  map[string]any
 	Env       map[string]string
 	Variables map[string]string
+
+	// Tape is the generated tape which is run instead of the tape file of the test type, if set.
+	Tape *vhs.Tape
 }
 
 type vhsTestType int
@@ -185,6 +189,33 @@ func newTapeData(tapeName string, settings ...tapeSetting) tapeData {
 	}
 }
 
+// newGeneratedTapeData returns the data of the tape generated with the vhs package. Its settings override the default
+// ones, and are overridden by settings.
+func newGeneratedTapeData(tapeName string, tape *vhs.Tape, settings ...tapeSetting) tapeData {
+	var tapeSettings []tapeSetting
+	for _, s := range tape.Settings() {
+		tapeSettings = append(tapeSettings, tapeSetting{s.Key, s.Value})
+	}
+	td := newTapeData(tapeName, append(tapeSettings, settings...)...)
+	td.Tape = tape
+	return td
+}
+
+// tapeTypeUsername returns the command typing the username in the tapes, waiting for it to be printed.
+func tapeTypeUsername(username string) string {
+	return fmt.Sprintf("TypeUsername %q", username)
+}
+
+// tapeTypeCLIPassword returns the command typing the password in the CLI tapes, waiting for it to be printed.
+func tapeTypeCLIPassword(password string) string {
+	return fmt.Sprintf("TypeCLIPassword %q", password)
+}
+
+// tapeWaitPrompt returns the command waiting for the prompt matching the pattern in the tapes.
+func tapeWaitPrompt(pattern string) string {
+	return fmt.Sprintf("Wait+Prompt /%s/", pattern)
+}
+
 type clientOptions struct {
 	PamUser        string
 	PamEnv         []string
@@ -405,9 +436,14 @@ func (td tapeData) PrepareTape(t *testing.T, testType vhsTestType, outputPath st
 	currentDir, err := os.Getwd()
 	require.NoError(t, err, "Setup: Could not get current directory for the tests")
 
-	tape, err := os.ReadFile(filepath.Join(
-		currentDir, "testdata", "tapes", testType.tapesPath(t), td.Name+".tape"))
-	require.NoError(t, err, "Setup: read tape file %s", td.Name)
+	var tape []byte
+	if td.Tape != nil {
+		tape = []byte(td.Tape.Commands())
+	} else {
+		tape, err = os.ReadFile(filepath.Join(
+			currentDir, "testdata", "tapes", testType.tapesPath(t), td.Name+".tape"))
+		require.NoError(t, err, "Setup: read tape file %s", td.Name)
+	}
 
 	tapeString := evaluateTapeVariables(t, string(tape), td, testType)
 
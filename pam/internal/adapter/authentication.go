@@ -1,11 +1,9 @@
 package adapter
 
 import (
+	"bytes"
 	"context"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/sha512"
-	"encoding/base64"
+	"crypto"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -15,8 +13,10 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/msteinert/pam/v2"
 	"github.com/ubuntu/authd/internal/brokers/auth"
+	"github.com/ubuntu/authd/internal/brokers/challenge"
 	"github.com/ubuntu/authd/internal/brokers/layouts"
 	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/securemem"
 	"github.com/ubuntu/authd/log"
 	pam_proto "github.com/ubuntu/authd/pam/internal/proto"
 	"google.golang.org/grpc/codes"
@@ -37,7 +37,7 @@ var (
 // sendIsAuthenticated sends the authentication secrets or wait request to the brokers.
 // The event will contain the returned value from the broker.
 func sendIsAuthenticated(ctx context.Context, client authd.PAMClient, sessionID string,
-	authData *authd.IARequest_AuthenticationData, secret *string) tea.Cmd {
+	authData *authd.IARequest_AuthenticationData, secret *securemem.Buffer) tea.Cmd {
 	return func() (msg tea.Msg) {
 		log.Debugf(context.TODO(), "Authentication request for session %q: %#v",
 			sessionID, authData.Item)
@@ -65,16 +65,18 @@ func sendIsAuthenticated(ctx context.Context, client authd.PAMClient, sessionID
 					secret: secret,
 				}
 			}
+			secret.Wipe()
 			return pamError{
-				status: pam.ErrSystem,
+				status: PamStatusFromError(err, pam.ErrSystem),
 				msg:    fmt.Sprintf("authentication status failure: %v", err),
 			}
 		}
 
 		return isAuthenticatedResultReceived{
-			access: res.Access,
-			msg:    res.Msg,
-			secret: secret,
+			access:        res.Access,
+			msg:           res.Msg,
+			secret:        secret,
+			encryptionKey: res.EncryptionKey,
 		}
 	}
 }
@@ -83,6 +85,8 @@ func sendIsAuthenticated(ctx context.Context, client authd.PAMClient, sessionID
 // with the given password or wait has been requested.
 type isAuthenticatedRequested struct {
 	item authd.IARequestAuthenticationDataItem
+	// secret is the secret entered by the user, which is encrypted as the challenge item before being sent.
+	secret *securemem.Buffer
 }
 
 // isAuthenticatedRequestedSend is the internal event signaling that the authentication
@@ -96,8 +100,11 @@ type isAuthenticatedRequestedSend struct {
 // and data that was retrieved.
 type isAuthenticatedResultReceived struct {
 	access string
-	secret *string
+	// secret is the secret sent to the broker in clear, to be wiped once it's not needed anymore.
+	secret *securemem.Buffer
 	msg    string
+	// encryptionKey is the new encryption key of the session, if the broker rotated it.
+	encryptionKey string
 }
 
 // isAuthenticatedCancelled is the event to cancel the auth request.
@@ -127,12 +134,12 @@ type authenticationModel struct {
 	currentModel     authenticationComponent
 	currentSessionID string
 	currentBrokerID  string
-	currentSecret    string
+	currentSecret    *securemem.Buffer
 	currentLayout    string
 
 	authTracker *authTracker
 
-	encryptionKey *rsa.PublicKey
+	encryptionKey crypto.PublicKey
 
 	errorMsg string
 }
@@ -154,13 +161,13 @@ type errMsgToDisplay struct {
 // newPasswordCheck is sent to request a new password quality check.
 type newPasswordCheck struct {
 	ctx      context.Context
-	password string
+	password *securemem.Buffer
 }
 
 // newPasswordCheckResult returns the password quality check result.
 type newPasswordCheckResult struct {
 	ctx      context.Context
-	password string
+	password *securemem.Buffer
 	msg      string
 }
 
@@ -194,10 +201,12 @@ func (m *authenticationModel) Update(msg tea.Msg) (authModel authenticationModel
 		return *m, tea.Sequence(m.cancelIsAuthenticated(), sendEvent(AuthModeSelected{}))
 
 	case newPasswordCheck:
-		currentSecret := m.currentSecret
+		// The check runs while the current secret can be replaced, so it uses its own copy.
+		currentSecret := securemem.New(bytes.Clone(m.currentSecret.Bytes()))
 		return *m, func() tea.Msg {
+			defer currentSecret.Wipe()
 			res := newPasswordCheckResult{ctx: msg.ctx, password: msg.password}
-			if err := checkPasswordQuality(currentSecret, msg.password); err != nil {
+			if err := checkPasswordQuality(currentSecret.Bytes(), msg.password.Bytes()); err != nil {
 				res.msg = err.Error()
 			}
 			return res
@@ -211,13 +220,11 @@ func (m *authenticationModel) Update(msg tea.Msg) (authModel authenticationModel
 
 		if msg.msg == "" {
 			return *m, sendEvent(isAuthenticatedRequestedSend{
-				ctx: msg.ctx,
-				isAuthenticatedRequested: isAuthenticatedRequested{
-					// TODO(UDENG-5844): Rename this to "secret" once all broker installations support the auth data field "secret".
-					item: &authd.IARequest_AuthenticationData_Challenge{Challenge: msg.password},
-				},
+				ctx:                      msg.ctx,
+				isAuthenticatedRequested: isAuthenticatedRequested{secret: msg.password},
 			})
 		}
+		msg.password.Wipe()
 
 		errMsg, err := json.Marshal(msg.msg)
 		if err != nil {
@@ -257,9 +264,8 @@ func (m *authenticationModel) Update(msg tea.Msg) (authModel authenticationModel
 		return *m, func() tea.Msg {
 			authTracker.waitAndStart(cancelFunc)
 
-			secret, hasSecret := msg.item.(*authd.IARequest_AuthenticationData_Challenge)
-			if hasSecret && clientType == Gdm && currentLayout == layouts.NewPassword {
-				return newPasswordCheck{ctx: ctx, password: secret.Challenge}
+			if msg.secret != nil && clientType == Gdm && currentLayout == layouts.NewPassword {
+				return newPasswordCheck{ctx: ctx, password: msg.secret}
 			}
 
 			return isAuthenticatedRequestedSend{msg, ctx}
@@ -282,13 +288,23 @@ func (m *authenticationModel) Update(msg tea.Msg) (authModel authenticationModel
 	case isAuthenticatedResultReceived:
 		log.Debugf(context.TODO(), "%#v", msg)
 
+		if msg.encryptionKey != "" {
+			// Use the new key of the session before handling the result, which can ask for the next challenge.
+			encryptionKey := msg.encryptionKey
+			msg.encryptionKey = ""
+			return *m, tea.Sequence(sendEvent(encryptionKeyRotated{encryptionKey: encryptionKey}), sendEvent(msg))
+		}
+
 		// Resets password if the authentication wasn't successful.
 		defer func() {
 			// the returned authModel is a copy of function-level's `m` at this point!
 			m := &authModel
 			if msg.secret != nil &&
 				(msg.access == auth.Granted || msg.access == auth.Next) {
-				m.currentSecret = *msg.secret
+				m.currentSecret.Wipe()
+				m.currentSecret = msg.secret
+			} else {
+				msg.secret.Wipe()
 			}
 
 			if msg.access != auth.Next && msg.access != auth.Retry {
@@ -388,7 +404,7 @@ func (m *authenticationModel) Blur() {
 
 // Compose initialize the authentication model to be used.
 // It creates and attaches the sub layout models based on UILayout.
-func (m *authenticationModel) Compose(brokerID, sessionID string, encryptionKey *rsa.PublicKey, layout *authd.UILayout) tea.Cmd {
+func (m *authenticationModel) Compose(brokerID, sessionID string, encryptionKey crypto.PublicKey, layout *authd.UILayout) tea.Cmd {
 	m.currentBrokerID = brokerID
 	m.currentSessionID = sessionID
 	m.encryptionKey = encryptionKey
@@ -482,22 +498,23 @@ func dataToMsg(data string) (string, error) {
 	return r, nil
 }
 
-func (authData *isAuthenticatedRequestedSend) encryptSecretIfPresent(publicKey *rsa.PublicKey) (*string, error) {
+// encryptSecretIfPresent sets the challenge of the authentication data to the encrypted secret, and returns the secret,
+// still held in locked memory, to be wiped once it's not needed anymore.
+func (authData *isAuthenticatedRequestedSend) encryptSecretIfPresent(publicKey crypto.PublicKey) (*securemem.Buffer, error) {
 	// no password value, pass it as is
-	secret, ok := authData.item.(*authd.IARequest_AuthenticationData_Challenge)
-	if !ok {
+	if authData.secret == nil {
 		return nil, nil
 	}
 
-	ciphertext, err := rsa.EncryptOAEP(sha512.New(), rand.Reader, publicKey, []byte(secret.Challenge), nil)
+	encrypted, err := challenge.Encrypt(publicKey, authData.secret.Bytes())
 	if err != nil {
+		authData.secret.Wipe()
 		return nil, err
 	}
 
-	// encrypt it to base64 and replace the password with it
-	base64Encoded := base64.StdEncoding.EncodeToString(ciphertext)
-	authData.item = &authd.IARequest_AuthenticationData_Challenge{Challenge: base64Encoded}
-	return &secret.Challenge, nil
+	// TODO(UDENG-5844): Rename this to "secret" once all broker installations support the auth data field "secret".
+	authData.item = &authd.IARequest_AuthenticationData_Challenge{Challenge: encrypted}
+	return authData.secret, nil
 }
 
 // wait waits for the current authentication to be completed.
@@ -291,7 +291,7 @@ func getAuthenticationModes(client authd.PAMClient, sessionID string, uiLayouts
 		gamResp, err := client.GetAuthenticationModes(context.Background(), gamReq)
 		if err != nil {
 			return pamError{
-				status: pam.ErrSystem,
+				status: PamStatusFromError(err, pam.ErrSystem),
 				msg:    fmt.Sprintf("could not get authentication modes: %v", err),
 			}
 		}
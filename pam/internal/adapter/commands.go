@@ -9,6 +9,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/msteinert/pam/v2"
 	"github.com/ubuntu/authd/internal/brokers"
+	"github.com/ubuntu/authd/internal/brokers/challenge"
 	"github.com/ubuntu/authd/internal/proto/authd"
 	"github.com/ubuntu/authd/log"
 )
@@ -21,7 +22,7 @@ func sendEvent(msg tea.Msg) tea.Cmd {
 }
 
 // startBrokerSession returns the sessionID after marking a broker as current.
-func startBrokerSession(client authd.PAMClient, brokerID, username string, mode authd.SessionMode) tea.Cmd {
+func startBrokerSession(client authd.PAMClient, brokerID, username, tty, rhost string, mode authd.SessionMode) tea.Cmd {
 	return func() tea.Msg {
 		if brokerID == brokers.LocalBrokerName {
 			return pamError{status: pam.ErrIgnore}
@@ -43,11 +44,15 @@ func startBrokerSession(client authd.PAMClient, brokerID, username string, mode
 			Username: username,
 			Lang:     lang,
 			Mode:     mode,
+			Tty:      tty,
+			Rhost:    rhost,
+
+			EncryptionVersion: uint32(challenge.LatestVersion),
 		}
 
 		sbResp, err := client.SelectBroker(context.TODO(), sbReq)
 		if err != nil {
-			return pamError{status: pam.ErrSystem, msg: fmt.Sprintf("can't select broker: %v", err)}
+			return pamError{status: PamStatusFromError(err, pam.ErrSystem), msg: fmt.Sprintf("can't select broker: %v", err)}
 		}
 
 		sessionID := sbResp.GetSessionId()
@@ -78,7 +83,7 @@ func getLayout(client authd.PAMClient, sessionID, authModeID string) tea.Cmd {
 		if err != nil {
 			// TODO: probably go back to broker selection here
 			return pamError{
-				status: pam.ErrSystem,
+				status: PamStatusFromError(err, pam.ErrSystem),
 				msg:    fmt.Sprintf("can't select authentication mode: %v", err),
 			}
 		}
@@ -10,6 +10,7 @@ import (
 	"github.com/ubuntu/authd/internal/brokers/layouts"
 	"github.com/ubuntu/authd/internal/brokers/layouts/entries"
 	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/securemem"
 	"github.com/ubuntu/authd/log"
 )
 
@@ -88,9 +89,7 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch entry := entry.(type) {
 			case *textinputModel:
 				return m, sendEvent(isAuthenticatedRequested{
-					item: &authd.IARequest_AuthenticationData_Challenge{
-						Challenge: entry.Value(),
-					},
+					secret: securemem.New([]byte(entry.Value())),
 				})
 			}
 
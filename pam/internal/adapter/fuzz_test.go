@@ -0,0 +1,65 @@
+package adapter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ubuntu/authd/internal/brokers/layouts"
+	"github.com/ubuntu/authd/internal/brokers/layouts/entries"
+	"github.com/ubuntu/authd/internal/proto/authd"
+)
+
+func FuzzDataToMsg(f *testing.F) {
+	f.Add(`{"message": "Access denied"}`)
+	f.Add(`{"message": ""}`)
+	f.Add(`{"message": 42}`)
+	f.Add(`{"msg": "Access denied"}`)
+	f.Add(`{}`)
+	f.Add(`null`)
+	f.Add(``)
+	f.Add(`not json`)
+
+	f.Fuzz(func(t *testing.T, data string) {
+		msg, err := dataToMsg(data)
+		if err != nil {
+			return
+		}
+		if data == "" {
+			require.Empty(t, msg, "Message of empty data should be empty")
+			return
+		}
+
+		var v map[string]string
+		require.NoError(t, json.Unmarshal([]byte(data), &v), "Accepted data should be a JSON object of strings")
+		require.Equal(t, v["message"], msg, "Message should be the one of the data")
+	})
+}
+
+func FuzzCompose(f *testing.F) {
+	f.Add(layouts.Form, "Enter your password", entries.CharsPassword, "", layouts.False, "", "")
+	f.Add(layouts.Form, "Enter your pin code:", entries.Digits, "Resend", layouts.True, "", "")
+	f.Add(layouts.Form, "", "", "", "", "", "")
+	f.Add(layouts.QrCode, "Scan the QR code", "", "Regenerate code", layouts.True, "https://ubuntu.com", "1337")
+	f.Add(layouts.QrCode, "", "", "", "", "", "")
+	f.Add(layouts.NewPassword, "Enter your new password", entries.CharsPassword, "Skip", "", "", "")
+	f.Add(layouts.NewPassword, "", entries.Chars, "", "", "", "")
+	f.Add("some-layout", "Label", entries.Chars, "Button", layouts.True, "Content", "Code")
+
+	f.Fuzz(func(t *testing.T, layoutType, label, entry, button, wait, content, code string) {
+		layout := &authd.UILayout{
+			Type:    layoutType,
+			Label:   &label,
+			Entry:   &entry,
+			Button:  &button,
+			Wait:    &wait,
+			Content: &content,
+			Code:    &code,
+		}
+
+		m := newAuthenticationModel(nil, InteractiveTerminal)
+		_ = m.Compose("some-broker-id", "some-session-id", nil, layout)
+		_ = m.Focus()
+		_ = m.View()
+	})
+}
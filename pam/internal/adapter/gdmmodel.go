@@ -10,6 +10,7 @@ import (
 	"github.com/msteinert/pam/v2"
 	"github.com/ubuntu/authd/internal/brokers/auth"
 	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/securemem"
 	"github.com/ubuntu/authd/log"
 	"github.com/ubuntu/authd/pam/internal/gdm"
 	"github.com/ubuntu/authd/pam/internal/proto"
@@ -138,9 +139,13 @@ func (m *gdmModel) pollGdm() tea.Cmd {
 					status: pam.ErrSystem, msg: "missing auth requested",
 				})
 			}
-			commands = append(commands, sendEvent(isAuthenticatedRequested{
-				item: res.IsAuthenticatedRequested.GetAuthenticationData().Item,
-			}))
+			req := isAuthenticatedRequested{item: res.IsAuthenticatedRequested.GetAuthenticationData().Item}
+			if secret, ok := req.item.(*authd.IARequest_AuthenticationData_Challenge); ok {
+				// The secret is only held in locked memory from now on.
+				req = isAuthenticatedRequested{secret: securemem.New([]byte(secret.Challenge))}
+				secret.Challenge = ""
+			}
+			commands = append(commands, sendEvent(req))
 
 		case *gdm.EventData_ReselectAuthMode:
 			commands = append(commands, sendEvent(reselectAuthMode{}))
@@ -3,15 +3,14 @@ package adapter
 
 import (
 	"context"
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/base64"
+	"crypto"
 	"fmt"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/msteinert/pam/v2"
+	"github.com/ubuntu/authd/internal/brokers/challenge"
 	"github.com/ubuntu/authd/internal/consts"
 	"github.com/ubuntu/authd/internal/proto/authd"
 	"github.com/ubuntu/authd/log"
@@ -40,7 +39,7 @@ var debug string
 type sessionInfo struct {
 	brokerID      string
 	sessionID     string
-	encryptionKey *rsa.PublicKey
+	encryptionKey crypto.PublicKey
 }
 
 // UIModel is the global models orchestrator.
@@ -91,6 +90,11 @@ type SessionStarted struct {
 	encryptionKey string
 }
 
+// encryptionKeyRotated signals that the broker rotated the encryption key of the current session.
+type encryptionKeyRotated struct {
+	encryptionKey string
+}
+
 // GetAuthenticationModesRequested signals that a model needs to get the broker authentication modes.
 type GetAuthenticationModesRequested struct{}
 
@@ -264,7 +268,8 @@ func (m *UIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		log.Debugf(context.TODO(), "%#v", msg)
 		if m.sessionStartingForBroker == "" {
 			m.sessionStartingForBroker = msg.BrokerID
-			return m, startBrokerSession(m.client, msg.BrokerID, m.username(), m.SessionMode)
+			tty, rhost := loginSource(m.PamMTx)
+			return m, startBrokerSession(m.client, msg.BrokerID, m.username(), tty, rhost, m.SessionMode)
 		}
 		if m.sessionStartingForBroker != msg.BrokerID {
 			return m, tea.Sequence(endSession(m.client, m.currentSession), sendEvent(msg))
@@ -272,33 +277,15 @@ func (m *UIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case SessionStarted:
 		log.Debugf(context.TODO(), "%#v", msg)
 		m.sessionStartingForBroker = ""
-		pubASN1, err := base64.StdEncoding.DecodeString(msg.encryptionKey)
-		if err != nil {
-			return m, sendEvent(pamError{
-				status: pam.ErrSystem,
-				msg:    fmt.Sprintf("encryption key sent by broker is not a valid base64 encoded string: %v", err),
-			})
-		}
-
-		pubKey, err := x509.ParsePKIXPublicKey(pubASN1)
+		publicKey, _, err := challenge.ParsePublicKey(msg.encryptionKey)
 		if err != nil {
-			return m, sendEvent(pamError{
-				status: pam.ErrSystem,
-				msg:    fmt.Sprintf("encryption key send by broker is not valid: %v", err),
-			})
-		}
-		rsaPublicKey, ok := pubKey.(*rsa.PublicKey)
-		if !ok {
-			return m, sendEvent(pamError{
-				status: pam.ErrSystem,
-				msg:    fmt.Sprintf("expected encryption key sent by broker to be  RSA public key, got %T", pubKey),
-			})
+			return m, sendEvent(pamError{status: pam.ErrSystem, msg: err.Error()})
 		}
 
 		m.currentSession = &sessionInfo{
 			brokerID:      msg.brokerID,
 			sessionID:     msg.sessionID,
-			encryptionKey: rsaPublicKey,
+			encryptionKey: publicKey,
 		}
 		return m, sendEvent(GetAuthenticationModesRequested{})
 
@@ -353,6 +340,20 @@ func (m *UIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.updateClientModel(msg),
 		)
 
+	case encryptionKeyRotated:
+		log.Debugf(context.TODO(), "%#v", msg)
+		if m.currentSession == nil {
+			return m, nil
+		}
+		publicKey, _, err := challenge.ParsePublicKey(msg.encryptionKey)
+		if err != nil {
+			return m, sendEvent(pamError{status: pam.ErrSystem, msg: err.Error()})
+		}
+		// The next challenges, including the ones of the next authentication modes, are encrypted with the new key.
+		m.currentSession.encryptionKey = publicKey
+		m.authenticationModel.encryptionKey = publicKey
+		return m, nil
+
 	case SessionEnded:
 		log.Debugf(context.TODO(), "%#v", msg)
 		m.sessionStartingForBroker = ""
@@ -17,7 +17,9 @@ import (
 	"github.com/ubuntu/authd/internal/brokers/auth"
 	"github.com/ubuntu/authd/internal/brokers/layouts"
 	"github.com/ubuntu/authd/internal/brokers/layouts/entries"
+	"github.com/ubuntu/authd/internal/consttime"
 	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/securemem"
 	"github.com/ubuntu/authd/log"
 	"github.com/ubuntu/authd/pam/internal/proto"
 	pam_proto "github.com/ubuntu/authd/pam/internal/proto"
@@ -279,12 +281,13 @@ func (m nativeModel) Update(msg tea.Msg) (nativeModel, tea.Cmd) {
 
 	case newPasswordCheckResult:
 		if msg.msg != "" {
+			msg.password.Wipe()
 			if cmd := maybeSendPamError(m.sendError(msg.msg)); cmd != nil {
 				return m, cmd
 			}
 			return m, m.newPasswordChallenge(nil)
 		}
-		return m, m.newPasswordChallenge(&msg.password)
+		return m, m.newPasswordChallenge(msg.password)
 
 	case isAuthenticatedResultReceived:
 		access := msg.access
@@ -641,9 +644,7 @@ func (m nativeModel) handleFormChallenge(hasWait bool) tea.Cmd {
 		return maybeSendPamError(err)
 	}
 
-	return sendEvent(isAuthenticatedRequested{
-		item: &authd.IARequest_AuthenticationData_Challenge{Challenge: secret},
-	})
+	return sendEvent(isAuthenticatedRequested{secret: securemem.New([]byte(secret))})
 }
 
 func (m nativeModel) promptForSecret(prompt string) (string, error) {
@@ -790,7 +791,10 @@ func (m nativeModel) handleNewPassword() tea.Cmd {
 	return m.newPasswordChallenge(nil)
 }
 
-func (m nativeModel) newPasswordChallenge(previousPassword *string) tea.Cmd {
+// newPasswordChallenge asks for a new password, or for its confirmation if previousPassword is set, which is wiped.
+func (m nativeModel) newPasswordChallenge(previousPassword *securemem.Buffer) tea.Cmd {
+	defer previousPassword.Wipe()
+
 	if previousPassword == nil {
 		instructions := fmt.Sprintf("Enter '%[1]s' to cancel the request and %[2]s",
 			nativeCancelKey, m.goBackActionLabel())
@@ -812,20 +816,20 @@ func (m nativeModel) newPasswordChallenge(previousPassword *string) tea.Cmd {
 	if err != nil && !errors.Is(err, errEmptyResponse) {
 		return maybeSendPamError(err)
 	}
+	secret := securemem.New([]byte(password))
 
 	if previousPassword == nil {
-		return sendEvent(newPasswordCheck{password: password})
+		return sendEvent(newPasswordCheck{password: secret})
 	}
-	if password != *previousPassword {
+	if !consttime.Equal(secret.Bytes(), previousPassword.Bytes()) {
+		secret.Wipe()
 		err := m.sendError("Password entries don't match")
 		if err != nil {
 			return maybeSendPamError(err)
 		}
 		return m.newPasswordChallenge(nil)
 	}
-	return sendEvent(isAuthenticatedRequested{
-		item: &authd.IARequest_AuthenticationData_Challenge{Challenge: password},
-	})
+	return sendEvent(isAuthenticatedRequested{secret: secret})
 }
 
 func (m nativeModel) goBackCommand() (nativeModel, tea.Cmd) {
@@ -10,6 +10,7 @@ import (
 	"github.com/ubuntu/authd/internal/brokers/layouts"
 	"github.com/ubuntu/authd/internal/brokers/layouts/entries"
 	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/securemem"
 	"github.com/ubuntu/authd/log"
 )
 
@@ -77,6 +78,8 @@ func (m newPasswordModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.updateFocusModel(msg)
 
 	case newPasswordCheckResult:
+		// The new password is sent from the entries once confirmed.
+		msg.password.Wipe()
 		if msg.msg != "" {
 			m.Clear()
 			return m, sendEvent(errMsgToDisplay{msg: msg.msg})
@@ -123,7 +126,7 @@ func (m newPasswordModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// First entry is focused
 				if m.focusIndex == 0 {
 					// Check password quality
-					return m, sendEvent(newPasswordCheck{password: m.passwordEntries[0].Value()})
+					return m, sendEvent(newPasswordCheck{password: securemem.New([]byte(m.passwordEntries[0].Value()))})
 				}
 
 				// Second entry is focused
@@ -136,7 +139,7 @@ func (m newPasswordModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				return m, sendEvent(isAuthenticatedRequested{
-					item: &authd.IARequest_AuthenticationData_Challenge{Challenge: entry.Value()},
+					secret: securemem.New([]byte(entry.Value())),
 				})
 			}
 
@@ -15,7 +15,7 @@ import (
 var passwordQualityMu sync.Mutex
 
 // checkPasswordQuality checks the quality of the new password using the pwquality library.
-func checkPasswordQuality(oldPassword, newPassword string) error {
+func checkPasswordQuality(oldPassword, newPassword []byte) error {
 	passwordQualityMu.Lock()
 	defer passwordQualityMu.Unlock()
 
@@ -35,11 +35,11 @@ func checkPasswordQuality(oldPassword, newPassword string) error {
 		return fmt.Errorf("can't ready pwquality configuration: %s", errMsg)
 	}
 
-	oldC := C.CString(oldPassword)
-	defer C.free(unsafe.Pointer(oldC))
+	oldC := cString(oldPassword)
+	defer freeCString(oldC, len(oldPassword))
 
-	newC := C.CString(newPassword)
-	defer C.free(unsafe.Pointer(newC))
+	newC := cString(newPassword)
+	defer freeCString(newC, len(newPassword))
 
 	if ret := C.pwquality_check(pwq, newC, oldC, nil, &auxErrPointer); ret < 0 {
 		var buf [C.PWQ_MAX_ERROR_MESSAGE_LEN]C.char
@@ -48,3 +48,18 @@ func checkPasswordQuality(oldPassword, newPassword string) error {
 	}
 	return nil
 }
+
+// cString returns a copy of the secret in C memory, to be freed with freeCString, which wipes it.
+func cString[T string | []byte](secret T) *C.char {
+	p := C.malloc(C.size_t(len(secret) + 1))
+	b := unsafe.Slice((*byte)(p), len(secret)+1)
+	copy(b, secret)
+	b[len(secret)] = 0
+	return (*C.char)(p)
+}
+
+// freeCString wipes the C string of n bytes and frees it.
+func freeCString(s *C.char, n int) {
+	clear(unsafe.Slice((*byte)(unsafe.Pointer(s)), n+1))
+	C.free(unsafe.Pointer(s))
+}
@@ -11,6 +11,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/term"
 	"github.com/msteinert/pam/v2"
+	"github.com/ubuntu/authd/internal/services/errmessages"
 	"github.com/ubuntu/authd/log"
 )
 
@@ -123,6 +124,38 @@ func IsTerminalTTY(mTx pam.ModuleTransaction) bool {
 	return isTerminalTTYValue
 }
 
+// loginSource returns the terminal and the remote host the user logs in from, if they are known.
+func loginSource(mTx pam.ModuleTransaction) (tty, rhost string) {
+	// The items are only informative, so ignore the errors.
+	tty, _ = mTx.GetItem(pam.Tty)
+	rhost, _ = mTx.GetItem(pam.Rhost)
+	return tty, rhost
+}
+
+// PamStatusFromError returns the PAM status matching the well-defined cause of an error of the daemon, or fallback if
+// its cause is not well-defined.
+func PamStatusFromError(err error, fallback pam.Error) pam.Error {
+	e, ok := errmessages.FromError(err)
+	if !ok {
+		return fallback
+	}
+
+	switch e.Reason {
+	case errmessages.ReasonUserUnknown:
+		return pam.ErrUserUnknown
+	case errmessages.ReasonBrokerUnavailable:
+		return pam.ErrAuthinfoUnavail
+	case errmessages.ReasonRetryable:
+		return pam.ErrTryAgain
+	case errmessages.ReasonUserLocked:
+		return pam.ErrPermDenied
+	case errmessages.ReasonSessionTimedOut:
+		return pam.ErrAuth
+	default:
+		return fallback
+	}
+}
+
 func maybeSendPamError(err error) tea.Cmd {
 	if err == nil {
 		return nil
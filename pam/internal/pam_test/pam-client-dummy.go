@@ -17,6 +17,7 @@ import (
 	"github.com/ubuntu/authd/internal/brokers/auth"
 	"github.com/ubuntu/authd/internal/brokers/layouts"
 	"github.com/ubuntu/authd/internal/brokers/layouts/entries"
+	"github.com/ubuntu/authd/internal/consttime"
 	"github.com/ubuntu/authd/internal/proto/authd"
 	"github.com/ubuntu/authd/log"
 	"golang.org/x/exp/maps"
@@ -468,7 +469,7 @@ func (dc *DummyClient) handleChallenge(secret string, msg string) (*authd.IAResp
 		return nil, err
 	}
 
-	if string(plaintext) == dc.isAuthenticatedWantSecret {
+	if consttime.Equal(string(plaintext), dc.isAuthenticatedWantSecret) {
 		return &authd.IAResponse{
 			Access: auth.Granted,
 			Msg:    msg,
@@ -532,6 +533,18 @@ func (dc *DummyClient) SetDefaultBrokerForUser(ctx context.Context, in *authd.SD
 	return &authd.Empty{}, nil
 }
 
+// CheckAccount simulates CheckAccount using the provided parameters. Passwords never expire.
+func (dc *DummyClient) CheckAccount(ctx context.Context, in *authd.CARequest, opts ...grpc.CallOption) (*authd.CAResponse, error) {
+	log.Debugf(ctx, "CheckAccount Called: %#v", in)
+	if in == nil {
+		return nil, errors.New("no input values provided")
+	}
+	if in.Username == "" {
+		return nil, errors.New("no valid username provided")
+	}
+	return &authd.CAResponse{}, nil
+}
+
 // Utility functions for testing purposes.
 
 // SelectedUsername returns the selected Username on the client.
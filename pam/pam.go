@@ -20,11 +20,15 @@ import (
 	"github.com/ubuntu/authd/internal/consts"
 	"github.com/ubuntu/authd/internal/grpcutils"
 	"github.com/ubuntu/authd/internal/proto/authd"
+	"github.com/ubuntu/authd/internal/services/apiversion"
 	"github.com/ubuntu/authd/internal/services/errmessages"
+	"github.com/ubuntu/authd/internal/services/info"
+	"github.com/ubuntu/authd/internal/services/permissions"
 	"github.com/ubuntu/authd/log"
 	"github.com/ubuntu/authd/pam/internal/adapter"
 	"github.com/ubuntu/authd/pam/internal/gdm"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -57,6 +61,10 @@ var supportedArgs = []string{
 	"logfile",             // The path of the file that will be used for logging.
 	"disable_journal",     // Disable logging on systemd journal (this is implicit when `logfile` is set).
 	"socket",              // The authd socket to connect to.
+	"tcp",                 // The address of a remote authd to connect to with TLS, instead of the socket.
+	"tls_cert",            // The certificate of this client, to connect to a remote authd.
+	"tls_key",             // The private key of the certificate of this client.
+	"tls_ca",              // The certificates of the authorities signing the certificate of the remote authd.
 	"connection_timeout",  // The timeout on connecting to authd socket in milliseconds (defaults to 2 seconds).
 	"force_native_client", // Use native PAM client instead of custom UIs.
 	"force_reauth",        // Whether the authentication should be performed again even if it has been already completed.
@@ -259,7 +267,7 @@ func (h *pamModule) handleAuthRequest(mode authd.SessionMode, mTx pam.ModuleTran
 			if msgErr := showPamMessage(mTx, pam.ErrorMsg, err.Error()); msgErr != nil {
 				log.Warningf(context.TODO(), "Impossible to show PAM message: %v", msgErr)
 			}
-			return fmt.Errorf("%w: %w", pam.ErrSystem, err)
+			return fmt.Errorf("%w: %w", adapter.PamStatusFromError(err, pam.ErrSystem), err)
 		}
 
 		if response.GetPreviousBroker() == brokers.LocalBrokerName {
@@ -430,13 +438,31 @@ func (h *pamModule) AcctMgmt(mTx pam.ModuleTransaction, flags pam.Flags, args []
 		return pam.ErrIgnore
 	}
 
+	// Make the user change their password if it expired according to their broker.
+	resp, err := client.CheckAccount(context.TODO(), &authd.CARequest{Username: user})
+	if err != nil {
+		log.Warningf(context.TODO(), "Can't check account of %q: %v", user, err)
+		return nil
+	}
+	if resp.GetPasswordExpired() {
+		if err := showPamMessage(mTx, pam.TextInfo, "Your password has expired. You must change it now."); err != nil {
+			log.Warningf(context.TODO(), "Impossible to show PAM message: %v", err)
+		}
+		return pam.ErrNewAuthtokReqd
+	}
+
 	return nil
 }
 
 func newClientConnection(args map[string]string) (conn *grpc.ClientConn, closeConn func(), err error) {
-	conn, err = grpc.NewClient("unix://"+getSocketPath(args),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithUnaryInterceptor(errmessages.FormatErrorMessage))
+	target, creds, err := getClientTarget(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn, err = grpc.NewClient(target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(errmessages.FormatErrorMessage, apiversion.ClientInterceptor))
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not connect to authd: %v", err)
 	}
@@ -460,9 +486,27 @@ func newClientConnection(args map[string]string) (conn *grpc.ClientConn, closeCo
 		return nil, nil, err
 	}
 
+	logDaemonMismatch(conn, timeout)
+
 	return conn, cleanup, err
 }
 
+// logDaemonMismatch logs the capabilities of the daemon if its version or the one of its API differ from the ones of
+// this module, to help triaging the bugs involving components of different versions.
+func logDaemonMismatch(conn *grpc.ClientConn, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	caps, err := authd.NewInfoClient(conn).GetCapabilities(ctx, &authd.Empty{})
+	if err != nil {
+		log.Warningf(ctx, "Could not get the capabilities of authd: %v", err)
+		return
+	}
+	if mismatch := info.Mismatch(caps, consts.Version, apiversion.Current); mismatch != "" {
+		log.Warning(ctx, mismatch)
+	}
+}
+
 // newClient returns a new GRPC client ready to emit requests.
 func newClient(args map[string]string) (client authd.PAMClient, closeConn func(), err error) {
 	conn, closeConn, err := newClientConnection(args)
@@ -472,6 +516,21 @@ func newClient(args map[string]string) (client authd.PAMClient, closeConn func()
 	return authd.NewPAMClient(conn), closeConn, nil
 }
 
+// getClientTarget returns the address of authd to connect to and the credentials to connect with, which are the ones of
+// the certificate of the client if a remote authd is set.
+func getClientTarget(args map[string]string) (target string, creds credentials.TransportCredentials, err error) {
+	address, ok := args["tcp"]
+	if !ok {
+		return "unix://" + getSocketPath(args), insecure.NewCredentials(), nil
+	}
+
+	config, err := permissions.NewClientTLSConfig(args["tls_cert"], args["tls_key"], args["tls_ca"])
+	if err != nil {
+		return "", nil, fmt.Errorf("could not connect to authd at %s: %v", address, err)
+	}
+	return address, credentials.NewTLS(config), nil
+}
+
 // getSocketPath returns the socket path to connect to which can be overridden manually.
 func getSocketPath(args map[string]string) string {
 	if val, ok := args["socket"]; ok {